@@ -0,0 +1,51 @@
+//go:build js && wasm
+
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Command wasm exposes the Containerfile analysis to JavaScript, e.g. for in-browser analysis
+// in web IDEs. Once loaded, it registers the global function doaAnalyze(content) which returns
+// the results as a JSON string.
+ package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"syscall/js"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func main() {
+	js.Global().Set("doaAnalyze", js.FuncOf(analyze))
+	// keep the Go runtime alive so that doaAnalyze can be called
+	select {}
+}
+
+func analyze(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return js.Global().Get("Error").New("doaAnalyze expects the Containerfile content as single argument")
+	}
+	results := analyzer.AnalyzeReader(context.Background(), "Containerfile", strings.NewReader(args[0].String()))
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		return js.Global().Get("Error").New(err.Error())
+	}
+	return string(bytes)
+}
@@ -0,0 +1,292 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package app analyzes the Containerfiles of the containers forming one application, listed on
+// the command line or read from a compose file or a devfile, and checks them against each other,
+// e.g. the containers sharing a volume must agree on the ownership of its mount paths.
+ package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// Mount is a volume mounted in a container. Bind mounts of compose files are volumes named after
+// their host path.
+type Mount struct {
+	Volume string `json:"volume"`
+	Path   string `json:"path"`
+}
+
+type Container struct {
+	Name          string  `json:"name"`
+	Containerfile string  `json:"containerfile"`
+	Mounts        []Mount `json:"mounts,omitempty"`
+}
+
+// Application is a set of containers deployed together.
+type Application struct {
+	Containers []Container
+	// Pod is set when the containers run in the same pod, sharing the network namespace, e.g.
+	// the containers of a devfile
+	Pod bool
+	// Skipped are the containers whose Containerfile is unknown, e.g. the compose services
+	// without build section
+	Skipped []string
+}
+
+// FromContainerfiles returns the application made of the Containerfiles, each container being
+// named after the directory of its Containerfile. They have no mounts, the volumes declared with
+// the same path by several Containerfiles are considered shared.
+func FromContainerfiles(files []string) *Application {
+	application := &Application{}
+	names := map[string]int{}
+	for _, file := range files {
+		name := filepath.Base(filepath.Dir(file))
+		if abs, err := filepath.Abs(file); err == nil {
+			name = filepath.Base(filepath.Dir(abs))
+		}
+		names[name]++
+		if names[name] > 1 {
+			name = file
+		}
+		application.Containers = append(application.Containers, Container{Name: name, Containerfile: file})
+	}
+	return application
+}
+
+// Load reads the application of a compose file or, when it has a schemaVersion, of a devfile.
+func Load(path string) (*Application, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read %s", path)
+	}
+	var probe struct {
+		SchemaVersion string `yaml:"schemaVersion"`
+	}
+	if err := yaml.Unmarshal(content, &probe); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", path)
+	}
+	if probe.SchemaVersion != "" {
+		return ParseDevfile(content, filepath.Dir(path))
+	}
+	return ParseCompose(content, filepath.Dir(path))
+}
+
+type composeFile struct {
+	Services map[string]struct {
+		Build   yaml.Node   `yaml:"build"`
+		Volumes []yaml.Node `yaml:"volumes"`
+	} `yaml:"services"`
+}
+
+// ParseCompose returns the services of the compose file having a build section, dir is the
+// directory of the file the build contexts are relative to.
+func ParseCompose(content []byte, dir string) (*Application, error) {
+	var compose composeFile
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the compose file")
+	}
+	if len(compose.Services) == 0 {
+		return nil, errors.New("the compose file has no services")
+	}
+	var names []string
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	application := &Application{}
+	for _, name := range names {
+		service := compose.Services[name]
+		var build struct {
+			Context    string `yaml:"context"`
+			Dockerfile string `yaml:"dockerfile"`
+		}
+		switch service.Build.Kind {
+		case yaml.ScalarNode:
+			build.Context = service.Build.Value
+		case yaml.MappingNode:
+			if err := service.Build.Decode(&build); err != nil {
+				return nil, errors.Wrapf(err, "invalid build section of service %s", name)
+			}
+		default:
+			application.Skipped = append(application.Skipped, name)
+			continue
+		}
+		if build.Context == "" {
+			build.Context = "."
+		}
+		if build.Dockerfile == "" {
+			build.Dockerfile = "Dockerfile"
+		}
+		container := Container{Name: name, Containerfile: filepath.Join(dir, build.Context, build.Dockerfile)}
+		if filepath.IsAbs(build.Dockerfile) {
+			container.Containerfile = build.Dockerfile
+		}
+		for _, volume := range service.Volumes {
+			if mount, ok := composeMount(volume); ok {
+				container.Mounts = append(container.Mounts, mount)
+			}
+		}
+		application.Containers = append(application.Containers, container)
+	}
+	return application, nil
+}
+
+// composeMount reads the short syntax, source:target[:mode], and the long syntax of the volumes
+// of a service. Anonymous volumes can't be shared and are ignored.
+func composeMount(volume yaml.Node) (Mount, bool) {
+	var mount Mount
+	switch volume.Kind {
+	case yaml.ScalarNode:
+		parts := strings.Split(volume.Value, ":")
+		if len(parts) < 2 {
+			return mount, false
+		}
+		mount = Mount{Volume: parts[0], Path: parts[1]}
+	case yaml.MappingNode:
+		var long struct {
+			Source string `yaml:"source"`
+			Target string `yaml:"target"`
+		}
+		if err := volume.Decode(&long); err != nil {
+			return mount, false
+		}
+		mount = Mount{Volume: long.Source, Path: long.Target}
+	}
+	return mount, mount.Volume != "" && mount.Path != ""
+}
+
+type devfile struct {
+	Components []struct {
+		Name  string `yaml:"name"`
+		Image *struct {
+			ImageName  string `yaml:"imageName"`
+			Dockerfile *struct {
+				URI string `yaml:"uri"`
+			} `yaml:"dockerfile"`
+		} `yaml:"image"`
+		Container *struct {
+			Image        string  `yaml:"image"`
+			VolumeMounts []Mount `yaml:"volumeMounts"`
+		} `yaml:"container"`
+	} `yaml:"components"`
+}
+
+// ParseDevfile returns the container components of the devfile whose image is built by one of
+// its image components, dir is the directory of the devfile the Dockerfile URIs are relative to.
+// The containers of a devfile run in the same pod.
+func ParseDevfile(content []byte, dir string) (*Application, error) {
+	var file devfile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the devfile")
+	}
+	containerfiles := map[string]string{}
+	for _, component := range file.Components {
+		if component.Image == nil || component.Image.Dockerfile == nil {
+			continue
+		}
+		uri := component.Image.Dockerfile.URI
+		if strings.Contains(uri, "://") {
+			// remote Dockerfiles are not downloaded
+			continue
+		}
+		containerfiles[component.Image.ImageName] = filepath.Join(dir, uri)
+	}
+	application := &Application{Pod: true}
+	for _, component := range file.Components {
+		if component.Container == nil {
+			continue
+		}
+		containerfile, ok := containerfiles[component.Container.Image]
+		if !ok {
+			application.Skipped = append(application.Skipped, component.Name)
+			continue
+		}
+		application.Containers = append(application.Containers, Container{
+			Name:          component.Name,
+			Containerfile: containerfile,
+			Mounts:        component.Container.VolumeMounts,
+		})
+	}
+	if len(application.Containers) == 0 {
+		return nil, errors.New("the devfile has no container built from a local Dockerfile")
+	}
+	return application, nil
+}
+
+type ContainerReport struct {
+	Container
+	Score   int               `json:"score"`
+	Results []analyzer.Result `json:"results"`
+}
+
+type Report struct {
+	Score   int              `json:"score"`
+	Summary analyzer.Summary `json:"summary"`
+	// Containers are the results of each Containerfile
+	Containers []ContainerReport `json:"containers"`
+	// Findings are the cross-image findings of the application
+	Findings []analyzer.Result `json:"findings"`
+}
+
+// Analyze analyzes the Containerfile of every container and checks them against each other. The
+// configuration is applied to all the results. The score of the application is the average score
+// of its containers, lowered by the cross-image findings.
+func Analyze(ctx context.Context, application *Application, cfg *config.Config, analyze workspace.AnalyzeFunc) (*Report, error) {
+	report := &Report{Containers: []ContainerReport{}}
+	images := map[string]*image{}
+	var all []analyzer.Result
+	total := 0
+	for _, container := range application.Containers {
+		content, err := os.ReadFile(container.Containerfile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the Containerfile of %s", container.Name)
+		}
+		if images[container.Name], err = inspect(content); err != nil {
+			return nil, errors.Wrapf(err, "%s", container.Containerfile)
+		}
+		results := cfg.Apply(analyze(ctx, container.Containerfile))
+		score := workspace.Score(results)
+		report.Containers = append(report.Containers, ContainerReport{Container: container, Score: score, Results: results})
+		all = append(all, results...)
+		total += score
+	}
+	report.Findings = cfg.Apply(check(application, images))
+	all = append(all, report.Findings...)
+
+	report.Score = 100
+	if len(report.Containers) > 0 {
+		report.Score = (total + len(report.Containers)/2) / len(report.Containers)
+	}
+	report.Score -= 100 - workspace.Score(report.Findings)
+	if report.Score < 0 {
+		report.Score = 0
+	}
+	report.Summary = analyzer.SummarizeFailingOn(all, cfg.FailOnSeverity())
+	return report, nil
+}
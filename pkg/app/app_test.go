@@ -0,0 +1,189 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+func noResults(ctx context.Context, path string) []analyzer.Result {
+	return nil
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseCompose(t *testing.T) {
+	application, err := ParseCompose([]byte(`
+services:
+  web:
+    build: ./web
+    volumes:
+      - data:/srv/data
+      - ./config:/etc/web:ro
+  worker:
+    build:
+      context: worker
+      dockerfile: Containerfile
+    volumes:
+      - type: volume
+        source: data
+        target: /data
+  cache:
+    image: redis
+volumes:
+  data: {}
+`), "app")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(application.Containers) != 2 || len(application.Skipped) != 1 || application.Skipped[0] != "cache" {
+		t.Fatalf("Unexpected application %v", application)
+	}
+	web, worker := application.Containers[0], application.Containers[1]
+	if web.Containerfile != filepath.Join("app", "web", "Dockerfile") || len(web.Mounts) != 2 || web.Mounts[1].Volume != "./config" {
+		t.Errorf("Unexpected container %v", web)
+	}
+	if worker.Containerfile != filepath.Join("app", "worker", "Containerfile") || len(worker.Mounts) != 1 || worker.Mounts[0] != (Mount{Volume: "data", Path: "/data"}) {
+		t.Errorf("Unexpected container %v", worker)
+	}
+	if application.Pod {
+		t.Errorf("Expected the services not to share a pod")
+	}
+}
+
+func TestParseDevfile(t *testing.T) {
+	application, err := ParseDevfile([]byte(`
+schemaVersion: 2.2.0
+components:
+  - name: build-web
+    image:
+      imageName: web:latest
+      dockerfile:
+        uri: web/Dockerfile
+  - name: web
+    container:
+      image: web:latest
+      volumeMounts:
+        - name: data
+          path: /data
+  - name: tools
+    container:
+      image: registry.access.redhat.com/ubi9/ubi
+  - name: data
+    volume:
+      size: 1Gi
+`), "app")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !application.Pod || len(application.Containers) != 1 || len(application.Skipped) != 1 {
+		t.Fatalf("Unexpected application %v", application)
+	}
+	if web := application.Containers[0]; web.Containerfile != filepath.Join("app", "web", "Dockerfile") || len(web.Mounts) != 1 || web.Mounts[0].Path != "/data" {
+		t.Errorf("Unexpected container %v", web)
+	}
+}
+
+func TestFailIfSharedVolumeOwnersDiffer(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"web/Dockerfile": "FROM scratch\nUSER 1001\n",
+		"db/Dockerfile":  "FROM scratch\nRUN mkdir -p /var/lib/db && chown -R postgres:postgres /var/lib/db\nUSER postgres\nEXPOSE 5432\n",
+	})
+	application := &Application{Containers: []Container{
+		{Name: "web", Containerfile: filepath.Join(dir, "web", "Dockerfile"), Mounts: []Mount{{Volume: "data", Path: "/srv/data"}}},
+		{Name: "db", Containerfile: filepath.Join(dir, "db", "Dockerfile"), Mounts: []Mount{{Volume: "data", Path: "/var/lib/db/data"}}},
+	}}
+	report, err := Analyze(context.Background(), application, &config.Config{}, noResults)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].RuleID != analyzer.RuleSharedVolumeOwnership.ID {
+		t.Fatalf("Expected a %s finding but they were %v", analyzer.RuleSharedVolumeOwnership.ID, report.Findings)
+	}
+	for _, expected := range []string{"web expects /srv/data owned by 1001", "db expects /var/lib/db/data owned by postgres", "line 2"} {
+		if !strings.Contains(report.Findings[0].Description, expected) {
+			t.Errorf("Expected %q in the description but it was %s", expected, report.Findings[0].Description)
+		}
+	}
+	if report.Score != 90 || report.Summary.Verdict != analyzer.VerdictFailed {
+		t.Errorf("Unexpected score %d and verdict %s", report.Score, report.Summary.Verdict)
+	}
+}
+
+func TestGroupWritableSharedVolumeIsAllowed(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"web/Containerfile":    "FROM scratch\nVOLUME /data\nUSER 1001\n",
+		"worker/Containerfile": "FROM scratch\nRUN mkdir /data && chgrp -R 0 /data && chmod -R g=u /data\nVOLUME /data\nUSER 1002\n",
+	})
+	application := FromContainerfiles([]string{filepath.Join(dir, "web", "Containerfile"), filepath.Join(dir, "worker", "Containerfile")})
+	report, err := Analyze(context.Background(), application, &config.Config{}, noResults)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected a finding as web doesn't make /data group writable but they were %v", report.Findings)
+	}
+
+	os.WriteFile(filepath.Join(dir, "web", "Containerfile"), []byte("FROM scratch\nCOPY --chmod=775 data /data\nVOLUME /data\nUSER 1001\n"), 0644)
+	if report, _ = Analyze(context.Background(), application, &config.Config{}, noResults); len(report.Findings) != 0 {
+		t.Errorf("Expected no finding but they were %v", report.Findings)
+	}
+}
+
+func TestFailIfContainersOfPodExposeSamePort(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"web/Containerfile":   "FROM scratch\nEXPOSE 8080\n",
+		"proxy/Containerfile": "FROM scratch\nEXPOSE 8080/tcp 8443\n",
+	})
+	application := FromContainerfiles([]string{filepath.Join(dir, "web", "Containerfile"), filepath.Join(dir, "proxy", "Containerfile")})
+	if report, _ := Analyze(context.Background(), application, &config.Config{}, noResults); len(report.Findings) != 0 {
+		t.Errorf("Expected no finding outside a pod but they were %v", report.Findings)
+	}
+	application.Pod = true
+	report, _ := Analyze(context.Background(), application, &config.Config{}, noResults)
+	if len(report.Findings) != 1 || !strings.Contains(report.Findings[0].Description, "web, proxy all expose 8080/TCP") {
+		t.Errorf("Expected a %s finding but they were %v", analyzer.RulePodPortConflict.ID, report.Findings)
+	}
+}
+
+func TestGroupWritable(t *testing.T) {
+	for mode, expected := range map[string]bool{"775": true, "0755": false, "2770": true, "g+w": true, "g=u": true, "u+x,a+rw": true, "o+w": false, "+x": false} {
+		if groupWritable(mode) != expected {
+			t.Errorf("Expected %s to be group writable: %v", mode, expected)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package app
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+)
+
+// chownRegexp and chmodRegexp capture the owner or the mode and the paths of the commands
+var (
+	chownRegexp = regexp.MustCompile(`\bchown\s+(?:-\S+\s+)*(\S+)\s+([^;&|<>]+)`)
+	chmodRegexp = regexp.MustCompile(`\bchmod\s+(?:-\S+\s+)*(\S+)\s+([^;&|<>]+)`)
+)
+
+// change is a chown or a chmod of a path of the final stage
+type change struct {
+	path string
+	// value is the owner, without its group, or whether the path is group writable
+	value string
+	line  int
+}
+
+// image is what the final stage of a Containerfile tells about the volumes it shares.
+type image struct {
+	*manifests.Image
+	volumes []string
+	owners  []change
+	modes   []change
+}
+
+func inspect(content []byte) (*image, error) {
+	inspected, err := manifests.Inspect(content)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the Containerfile")
+	}
+	inspectedImage := &image{Image: inspected}
+	for _, node := range res.AST.Children {
+		var args []string
+		for n := node.Next; n != nil; n = n.Next {
+			args = append(args, n.Value)
+		}
+		switch strings.ToLower(node.Value) {
+		case "from":
+			// only the final stage matters
+			*inspectedImage = image{Image: inspected}
+		case "volume":
+			inspectedImage.volumes = append(inspectedImage.volumes, args...)
+		case "run":
+			command := strings.Join(args, " ")
+			for _, match := range chownRegexp.FindAllStringSubmatch(command, -1) {
+				for _, p := range strings.Fields(match[2]) {
+					inspectedImage.owners = append(inspectedImage.owners, change{path: p, value: ownerUser(match[1]), line: node.StartLine})
+				}
+			}
+			for _, match := range chmodRegexp.FindAllStringSubmatch(command, -1) {
+				for _, p := range strings.Fields(match[2]) {
+					inspectedImage.modes = append(inspectedImage.modes, change{path: p, value: fmt.Sprint(groupWritable(match[1])), line: node.StartLine})
+				}
+			}
+		case "copy", "add":
+			if len(args) < 2 {
+				continue
+			}
+			destination := args[len(args)-1]
+			for _, flag := range node.Flags {
+				if owner := strings.TrimPrefix(flag, "--chown="); owner != flag {
+					inspectedImage.owners = append(inspectedImage.owners, change{path: destination, value: ownerUser(owner), line: node.StartLine})
+				}
+				if mode := strings.TrimPrefix(flag, "--chmod="); mode != flag {
+					inspectedImage.modes = append(inspectedImage.modes, change{path: destination, value: fmt.Sprint(groupWritable(mode)), line: node.StartLine})
+				}
+			}
+		}
+	}
+	return inspectedImage, nil
+}
+
+func ownerUser(owner string) string {
+	return strings.SplitN(strings.SplitN(owner, ":", 2)[0], ".", 2)[0]
+}
+
+// groupWritable reports whether the octal or symbolic mode grants the write permission to the
+// group, e.g. 775, g+w, g=u or a+rwx.
+func groupWritable(mode string) bool {
+	if strings.Trim(mode, "01234567") == "" {
+		return len(mode) >= 3 && (mode[len(mode)-2]-'0')&2 != 0
+	}
+	for _, clause := range strings.Split(mode, ",") {
+		index := strings.IndexAny(clause, "+=")
+		if index < 0 {
+			continue
+		}
+		who, permissions := clause[:index], clause[index+1:]
+		if (who == "" || strings.ContainsAny(who, "ga")) && strings.ContainsAny(permissions, "wu") {
+			return true
+		}
+	}
+	return false
+}
+
+// last returns the last change of the path or of one of its parents.
+func last(changes []change, mountPath string) (change, bool) {
+	mountPath = path.Clean(mountPath)
+	for i := len(changes) - 1; i >= 0; i-- {
+		changed := path.Clean(changes[i].path)
+		if changed == mountPath || strings.HasPrefix(mountPath, strings.TrimSuffix(changed, "/")+"/") {
+			return changes[i], true
+		}
+	}
+	return change{}, false
+}
+
+// usage is the mount of a shared volume by a container
+type usage struct {
+	container Container
+	image     *image
+	path      string
+}
+
+// writer is the user writing to the mount path: its owner when the image sets it, the user of
+// the image otherwise
+func (u usage) writer() (string, int) {
+	if owner, ok := last(u.image.owners, u.path); ok {
+		return owner.value, owner.line
+	}
+	user := ownerUser(u.image.User)
+	if user == "" {
+		user = "root"
+	}
+	return user, 0
+}
+
+func (u usage) groupWritable() bool {
+	mode, ok := last(u.image.modes, u.path)
+	return ok && mode.value == "true"
+}
+
+// check returns the cross-image findings of the application.
+func check(application *Application, images map[string]*image) []analyzer.Result {
+	mounted := false
+	for _, container := range application.Containers {
+		mounted = mounted || len(container.Mounts) > 0
+	}
+	volumes := map[string][]usage{}
+	for _, container := range application.Containers {
+		image := images[container.Name]
+		mounts := container.Mounts
+		if !mounted {
+			for _, volume := range image.volumes {
+				mounts = append(mounts, Mount{Volume: volume, Path: volume})
+			}
+		}
+		for _, mount := range mounts {
+			volumes[mount.Volume] = append(volumes[mount.Volume], usage{container: container, image: image, path: mount.Path})
+		}
+	}
+	var names []string
+	for name := range volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []analyzer.Result{}
+	for _, name := range names {
+		if result, ok := checkSharedVolume(name, volumes[name]); ok {
+			results = append(results, result)
+		}
+	}
+	if application.Pod {
+		results = append(results, checkPorts(application, images)...)
+	}
+	return results
+}
+
+/*
+web: USER 1001, VOLUME /data
+db:  RUN chown -R postgres /var/lib/postgresql/data, both mounting the data volume
+*/
+func checkSharedVolume(name string, usages []usage) (analyzer.Result, bool) {
+	writers := map[string]bool{}
+	var containers []string
+	var expectations []string
+	allGroupWritable := true
+	for _, usage := range usages {
+		writer, line := usage.writer()
+		writers[writer] = true
+		containers = append(containers, usage.container.Name)
+		location := usage.container.Containerfile
+		if line > 0 {
+			location = fmt.Sprintf("%s line %d", location, line)
+		}
+		expectations = append(expectations, fmt.Sprintf("%s expects %s owned by %s (%s)", usage.container.Name, usage.path, writer, location))
+		allGroupWritable = allGroupWritable && usage.groupWritable()
+	}
+	if len(usages) < 2 || len(writers) < 2 || allGroupWritable {
+		return analyzer.Result{}, false
+	}
+	return analyzer.RuleSharedVolumeOwnership.Failed(fmt.Sprintf(`containers %s share the volume %s but don't agree on its owner: %s. Only one of them can write to it, and OpenShift runs them with an arbitrary UID anyway: make the mount paths owned by the root group and group writable in every image (chgrp -R 0 <path> && chmod -R g=u <path>)`,
+		strings.Join(containers, ", "), name, strings.Join(expectations, ", "))), true
+}
+
+func checkPorts(application *Application, images map[string]*image) []analyzer.Result {
+	exposing := map[manifests.Port][]string{}
+	var ports []manifests.Port
+	for _, container := range application.Containers {
+		for _, port := range images[container.Name].Ports {
+			if len(exposing[port]) == 0 {
+				ports = append(ports, port)
+			}
+			exposing[port] = append(exposing[port], container.Name)
+		}
+	}
+	var results []analyzer.Result
+	for _, port := range ports {
+		if containers := exposing[port]; len(containers) > 1 {
+			results = append(results, analyzer.RulePodPortConflict.Failed(fmt.Sprintf("containers %s all expose %d/%s but they share the network namespace of the pod, only one of them can listen on it",
+				strings.Join(containers, ", "), port.Number, port.Protocol)))
+		}
+	}
+	return results
+}
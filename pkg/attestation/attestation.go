@@ -0,0 +1,280 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package attestation produces in-toto attestations of the analysis of an image, bound to the
+// digest of the image and signed as DSSE envelopes with cosign keys, so that the clusters, e.g.
+// their admission controllers, can verify that an image passed the analysis before running it.
+// The envelopes can be attached to the image with cosign attach attestation.
+ package attestation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	STATEMENT_TYPE = "https://in-toto.io/Statement/v0.1"
+	// PREDICATE_TYPE identifies the analysis predicate, e.g. for cosign verify-attestation --type
+	PREDICATE_TYPE = "https://github.com/redhat-developer/docker-openshift-analyzer/analysis/v1"
+	// PAYLOAD_TYPE is the type of the statements signed in the DSSE envelopes
+	PAYLOAD_TYPE = "application/vnd.in-toto+json"
+	// PASSWORD_ENV is the environment variable holding the password of the cosign key, as for cosign
+	PASSWORD_ENV = "COSIGN_PASSWORD"
+)
+
+// Subject is the image the statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Analyzer identifies the doa build and the ruleset which analyzed the image.
+type Analyzer struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	RulesetVersion string `json:"rulesetVersion"`
+}
+
+// Finding is a failed result of the analysis.
+type Finding struct {
+	RuleID      string                  `json:"ruleId,omitempty"`
+	Severity    analyzer.ResultSeverity `json:"severity"`
+	Line        int                     `json:"line,omitempty"`
+	Fingerprint string                  `json:"fingerprint,omitempty"`
+}
+
+// Predicate is the analysis of the image.
+type Predicate struct {
+	Analyzer   Analyzer  `json:"analyzer"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+	// Source is the Containerfile or the image analyzed
+	Source   string                  `json:"source"`
+	FailOn   analyzer.ResultSeverity `json:"failOn"`
+	Verdict  analyzer.Verdict        `json:"verdict"`
+	Score    int                     `json:"score"`
+	Summary  analyzer.Summary        `json:"summary"`
+	Findings []Finding               `json:"findings"`
+}
+
+// Statement is an in-toto statement of the analysis of the image.
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Envelope is a DSSE envelope, its payload being the JSON statement.
+type Envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"`
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+type EnvelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// encryptedKey is the content of the encrypted private keys generated by cosign generate-key-pair
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewPredicate describes the analysis of source, the results being the ones reported after the
+// configuration and the feedback file were applied.
+func NewPredicate(source string, results []analyzer.Result, failOn analyzer.ResultSeverity, score int, info Analyzer, now time.Time) Predicate {
+	summary := analyzer.SummarizeFailingOn(results, failOn)
+	predicate := Predicate{
+		Analyzer:   info,
+		AnalyzedAt: now.UTC().Truncate(time.Second),
+		Source:     source,
+		FailOn:     failOn,
+		Verdict:    summary.Verdict,
+		Score:      score,
+		Summary:    summary,
+		Findings:   []Finding{},
+	}
+	for _, result := range results {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		finding := Finding{RuleID: result.RuleID, Severity: result.Severity, Fingerprint: result.Fingerprint}
+		if result.Line != nil {
+			finding.Line = result.Line.Start
+		}
+		predicate.Findings = append(predicate.Findings, finding)
+	}
+	return predicate
+}
+
+// NewStatement binds the predicate to the image digest.
+func NewStatement(digest name.Digest, predicate Predicate) (Statement, error) {
+	algorithm, hex, ok := strings.Cut(digest.DigestStr(), ":")
+	if !ok {
+		return Statement{}, errors.Errorf("invalid digest %s", digest.DigestStr())
+	}
+	return Statement{
+		Type:          STATEMENT_TYPE,
+		PredicateType: PREDICATE_TYPE,
+		Subject:       []Subject{{Name: digest.Context().Name(), Digest: map[string]string{algorithm: hex}}},
+		Predicate:     predicate,
+	}, nil
+}
+
+// ResolveDigest returns the digest of the image, read from its registry unless the reference is
+// already a digest.
+func ResolveDigest(image string) (name.Digest, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	if digest, ok := ref.(name.Digest); ok {
+		return digest, nil
+	}
+	descriptor, err := remote.Head(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return name.Digest{}, errors.Wrapf(err, "unable to resolve the digest of %s", image)
+	}
+	return ref.Context().Digest(descriptor.Digest.String()), nil
+}
+
+// Envelope returns the statement in an unsigned DSSE envelope.
+func (s Statement) Envelope() (*Envelope, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{PayloadType: PAYLOAD_TYPE, Payload: payload, Signatures: []EnvelopeSignature{}}, nil
+}
+
+// Sign adds the signature of the payload by the key to the envelope.
+func (e *Envelope) Sign(key crypto.Signer) error {
+	message := PAE(e.PayloadType, e.Payload)
+	var signature []byte
+	var err error
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		signature, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to sign the attestation")
+	}
+	e.Signatures = append(e.Signatures, EnvelopeSignature{Sig: signature})
+	return nil
+}
+
+// PAE is the pre-authentication encoding of the payload, the message signed by DSSE.
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// LoadKey reads the private key at path: a key generated by cosign generate-key-pair, decrypted
+// with the password, or an unencrypted PKCS#8 or EC private key.
+func LoadKey(path string, password []byte) (crypto.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the key %s", path)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.Errorf("no private key found in %s", path)
+	}
+	der := block.Bytes
+	switch block.Type {
+	case "ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY":
+		if der, err = decrypt(block.Bytes, password); err != nil {
+			return nil, errors.Wrapf(err, "unable to decrypt the key %s", path)
+		}
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid private key %s", path)
+	}
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	}
+	return nil, errors.Errorf("unsupported key type %T", key)
+}
+
+// decrypt decrypts the content of a cosign key, encrypted with a scrypt derived key and nacl
+// secretbox.
+func decrypt(content []byte, password []byte) ([]byte, error) {
+	var encrypted encryptedKey
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.KDF.Name != "scrypt" || encrypted.Cipher.Name != "nacl/secretbox" || len(encrypted.Cipher.Nonce) != 24 {
+		return nil, errors.Errorf("unsupported encryption %s with %s", encrypted.KDF.Name, encrypted.Cipher.Name)
+	}
+	params := encrypted.KDF.Params
+	derived, err := scrypt.Key(password, encrypted.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	var nonce [24]byte
+	copy(key[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("wrong password")
+	}
+	return der, nil
+}
@@ -0,0 +1,203 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const digest = "sha256:0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+
+// writeCosignKey writes the key encrypted with the password as cosign generate-key-pair does.
+func writeCosignKey(t *testing.T, key *ecdsa.PrivateKey, password []byte) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := encryptedKey{}
+	encrypted.KDF.Name = "scrypt"
+	encrypted.KDF.Params.N, encrypted.KDF.Params.R, encrypted.KDF.Params.P = 1024, 8, 1
+	encrypted.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	encrypted.Cipher.Name = "nacl/secretbox"
+	encrypted.Cipher.Nonce = []byte("0123456789abcdef01234567")
+	derived, err := scrypt.Key(password, encrypted.KDF.Salt, 1024, 8, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	encrypted.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+	content, err := json.Marshal(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.key")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: content}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewStatement(t *testing.T) {
+	failed := analyzer.RuleUserRoot.Failed("root")
+	failed.Line = &analyzer.Line{Start: 4, End: 4}
+	results := []analyzer.Result{failed, analyzer.RuleUserRoot.Passed("not root")}
+	predicate := NewPredicate("Containerfile", results, analyzer.SeverityCritical, 90, Analyzer{Name: "doa", Version: "1.0.0"}, time.Now())
+	ref, err := name.NewDigest("quay.io/shop/web@" + digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := NewStatement(ref, predicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statement.Subject) != 1 || statement.Subject[0].Name != "quay.io/shop/web" || statement.Subject[0].Digest["sha256"] != digest[len("sha256:"):] {
+		t.Errorf("Unexpected subject %v", statement.Subject)
+	}
+	if statement.Type != STATEMENT_TYPE || statement.PredicateType != PREDICATE_TYPE {
+		t.Errorf("Unexpected types %s and %s", statement.Type, statement.PredicateType)
+	}
+	if len(predicate.Findings) != 1 || predicate.Findings[0].Line != 4 || predicate.Verdict != analyzer.VerdictPassed {
+		t.Errorf("Unexpected predicate %+v", predicate)
+	}
+}
+
+func TestSignWithCosignKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeCosignKey(t, key, []byte("s3cr3t"))
+	if _, err := LoadKey(path, []byte("wrong")); err == nil {
+		t.Error("Expected an error with a wrong password")
+	}
+	signer, err := LoadKey(path, []byte("s3cr3t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, _ := name.NewDigest("quay.io/shop/web@" + digest)
+	statement, err := NewStatement(ref, Predicate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := statement.Envelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := envelope.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	message := sha256.Sum256(PAE(PAYLOAD_TYPE, envelope.Payload))
+	if len(envelope.Signatures) != 1 || !ecdsa.VerifyASN1(&key.PublicKey, message[:], envelope.Signatures[0].Sig) {
+		t.Errorf("Expected the envelope to be signed by the key but it was %v", envelope.Signatures)
+	}
+}
+
+// attach pushes the signed analysis of the image with the score as cosign attach attestation does
+func attach(t *testing.T, ref name.Digest, key *ecdsa.PrivateKey, score int) {
+	statement, err := NewStatement(ref, Predicate{Score: score, AnalyzedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := statement.Envelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := envelope.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := json.Marshal(envelope)
+	image, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(content, "application/vnd.dsse.envelope.v1+json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + ".att")
+	if err := remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyImage(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	ref, _ := name.NewDigest(strings.TrimPrefix(server.URL, "http://") + "/shop/web@" + digest)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	verifier, err := signature.NewVerifier(signature.Trust{
+		Keys: []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyImage(ref, verifier, 80); err == nil {
+		t.Error("Expected an error for an image without attestation")
+	}
+	untrusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	attach(t, ref, untrusted, 95)
+	if _, err := VerifyImage(ref, verifier, 80); err == nil || !strings.Contains(err.Error(), "not signed by a trusted key") {
+		t.Errorf("Expected an error for an untrusted attestation but got %v", err)
+	}
+	attach(t, ref, key, 70)
+	if _, err := VerifyImage(ref, verifier, 80); err == nil || !strings.Contains(err.Error(), "below 80") {
+		t.Errorf("Expected an error for a low score but got %v", err)
+	}
+	statement, err := VerifyImage(ref, verifier, 70)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if statement.Predicate.Score != 70 {
+		t.Errorf("Expected the analysis scoring 70 but got %d", statement.Predicate.Score)
+	}
+	other, _ := name.NewDigest(ref.Context().Name() + "@sha256:" + strings.Repeat("f", 64))
+	envelope := Envelope{}
+	image, _ := remote.Image(ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + ".att"))
+	layers, _ := image.Layers()
+	reader, _ := layers[0].Compressed()
+	json.NewDecoder(reader).Decode(&envelope)
+	if _, err := VerifyEnvelope(envelope, verifier, other); err == nil {
+		t.Error("Expected an error for the attestation of another image")
+	}
+}
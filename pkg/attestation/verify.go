@@ -0,0 +1,122 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package attestation
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+)
+
+// VerifyEnvelope checks that the envelope is signed by a trusted key and that its statement is an
+// analysis of the image digest, it returns the statement.
+func VerifyEnvelope(envelope Envelope, verifier *signature.Verifier, digest name.Digest) (*Statement, error) {
+	if envelope.PayloadType != PAYLOAD_TYPE {
+		return nil, errors.Errorf("unexpected payload type %s", envelope.PayloadType)
+	}
+	message := PAE(envelope.PayloadType, envelope.Payload)
+	trusted := false
+	for _, sig := range envelope.Signatures {
+		if verifier.Verify(message, signature.Signature{Content: sig.Sig}) == nil {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, errors.New("the attestation is not signed by a trusted key")
+	}
+	statement := Statement{}
+	if err := json.Unmarshal(envelope.Payload, &statement); err != nil {
+		return nil, errors.Wrap(err, "invalid attestation statement")
+	}
+	if statement.PredicateType != PREDICATE_TYPE {
+		return nil, errors.Errorf("the attestation is a %s, not an analysis", statement.PredicateType)
+	}
+	algorithm, hex, _ := strings.Cut(digest.DigestStr(), ":")
+	for _, subject := range statement.Subject {
+		if subject.Digest[algorithm] == hex {
+			return &statement, nil
+		}
+	}
+	// the attestation of another image can't be replayed
+	return nil, errors.Errorf("the attestation is not about %s", digest.DigestStr())
+}
+
+// VerifyImage checks the attestations attached to the image by cosign attach attestation and
+// returns the most recent trusted analysis scoring at least minScore.
+func VerifyImage(digest name.Digest, verifier *signature.Verifier, minScore int, options ...remote.Option) (*Statement, error) {
+	tag := digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + ".att")
+	image, err := remote.Image(tag, options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no attestation found for %s", digest)
+	}
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, errors.Wrapf(err, "no attestation found for %s", digest)
+	}
+	var verified *Statement
+	var failures []string
+	for _, layer := range layers {
+		reader, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		statement, err := verifyLayer(content, verifier, digest, minScore)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		if verified == nil || statement.Predicate.AnalyzedAt.After(verified.Predicate.AnalyzedAt) {
+			verified = statement
+		}
+	}
+	if verified != nil {
+		return verified, nil
+	}
+	if len(failures) == 0 {
+		return nil, errors.Errorf("no attestation found for %s", digest)
+	}
+	return nil, errors.Errorf("no valid analysis attestation for %s: %s", digest, strings.Join(failures, ", "))
+}
+
+// verifyLayer verifies the DSSE envelope of an attestation layer and the score of its analysis.
+func verifyLayer(content []byte, verifier *signature.Verifier, digest name.Digest, minScore int) (*Statement, error) {
+	envelope := Envelope{}
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return nil, errors.Wrap(err, "invalid attestation envelope")
+	}
+	statement, err := VerifyEnvelope(envelope, verifier, digest)
+	if err != nil {
+		return nil, err
+	}
+	if statement.Predicate.Score < minScore {
+		return nil, errors.Errorf("the score %d of the analysis is below %d", statement.Predicate.Score, minScore)
+	}
+	return statement, nil
+}
@@ -0,0 +1,78 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package audit analyzes the images run by the workloads of a namespace and aggregates their
+// results into a fleet report, with the counts of findings, a score and the workloads of each
+// image.
+ package audit
+
+import (
+	"context"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+)
+
+type Image struct {
+	Image string `json:"image"`
+	// Workloads are the workloads running the image, e.g. Deployment/web
+	Workloads []string          `json:"workloads"`
+	Score     int               `json:"score"`
+	Summary   analyzer.Summary  `json:"summary"`
+	Results   []analyzer.Result `json:"results"`
+}
+
+type Report struct {
+	Namespace string           `json:"namespace"`
+	Score     int              `json:"score"`
+	Summary   analyzer.Summary `json:"summary"`
+	Images    []Image          `json:"images"`
+}
+
+// AnalyzeFunc analyzes images, e.g. analyzer.AnalyzeImages
+type AnalyzeFunc func(ctx context.Context, images []string) []analyzer.ImageResults
+
+// Analyze analyzes the images of the namespace and aggregates their results, the configuration
+// being applied to them. The score of the fleet is the average score of its images.
+func Analyze(ctx context.Context, namespace string, usages []manifests.ImageUsage, cfg *config.Config, analyze AnalyzeFunc) *Report {
+	images := make([]string, len(usages))
+	for i, usage := range usages {
+		images[i] = usage.Image
+	}
+	report := &Report{Namespace: namespace, Images: []Image{}}
+	var all []analyzer.Result
+	var scores []int
+	for i, analyzed := range analyze(ctx, images) {
+		results := cfg.Apply(analyzed.Results)
+		image := Image{
+			Image:     analyzed.Image,
+			Workloads: usages[i].Workloads,
+			Score:     workspace.Score(results),
+			Summary:   analyzer.SummarizeFailingOn(results, cfg.FailOnSeverity()),
+			Results:   results,
+		}
+		report.Images = append(report.Images, image)
+		all = append(all, results...)
+		scores = append(scores, image.Score)
+	}
+	report.Summary = analyzer.SummarizeFailingOn(all, cfg.FailOnSeverity())
+	report.Score = workspace.Average(scores)
+	return report
+}
@@ -0,0 +1,62 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+)
+
+func TestAnalyze(t *testing.T) {
+	usages := []manifests.ImageUsage{
+		{Image: "quay.io/shop/api:3.0", Workloads: []string{"Deployment/api"}},
+		{Image: "quay.io/shop/web:1.2", Workloads: []string{"CronJob/cleanup", "Deployment/web"}},
+	}
+	analyze := func(ctx context.Context, images []string) []analyzer.ImageResults {
+		var analyzed []analyzer.ImageResults
+		for _, image := range images {
+			results := []analyzer.Result{}
+			if image == "quay.io/shop/web:1.2" {
+				results = append(results, analyzer.RuleUserRoot.Failed("root"))
+			}
+			analyzed = append(analyzed, analyzer.ImageResults{Image: image, Results: results})
+		}
+		return analyzed
+	}
+	report := Analyze(context.Background(), "shop", usages, &config.Config{}, analyze)
+	if report.Namespace != "shop" || len(report.Images) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	api, web := report.Images[0], report.Images[1]
+	if api.Image != "quay.io/shop/api:3.0" || api.Score != 100 || !reflect.DeepEqual(api.Workloads, []string{"Deployment/api"}) {
+		t.Errorf("Unexpected image %+v", api)
+	}
+	webScore := workspace.Score(web.Results)
+	if web.Summary.Failed != 1 || webScore == 100 || !reflect.DeepEqual(web.Workloads, usages[1].Workloads) {
+		t.Errorf("Unexpected image %+v", web)
+	}
+	if report.Score != workspace.Average([]int{100, webScore}) || report.Summary.Verdict != analyzer.VerdictFailed {
+		t.Errorf("Unexpected fleet score %d and summary %+v", report.Score, report.Summary)
+	}
+}
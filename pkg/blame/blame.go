@@ -0,0 +1,128 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package blame attributes the findings to the commits which last changed their lines with git
+// blame, so that they can be routed to the engineers who wrote the offending instructions.
+ package blame
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// UNCOMMITTED is the commit git blame reports for the lines which are not committed yet
+const UNCOMMITTED = "0000000000000000000000000000000000000000"
+
+// Containerfile returns the Containerfile analyzed for path, the Dockerfile or the Containerfile
+// of the directory when path is a directory.
+func Containerfile(path string) string {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return path
+	}
+	if _, err := os.Stat(filepath.Join(path, "Dockerfile")); err == nil {
+		return filepath.Join(path, "Dockerfile")
+	}
+	return filepath.Join(path, "Containerfile")
+}
+
+// File runs git blame on the file and returns the last change of each of its lines, keyed by line
+// number.
+func File(path string) (map[int]analyzer.Blame, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git is required to blame the findings but it was not found in the PATH")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to blame %s: %s", path, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return parse(stdout.Bytes())
+}
+
+// parse reads the output of git blame --line-porcelain, where each line of the file is preceded
+// by the full description of its commit.
+func parse(output []byte) (map[int]analyzer.Blame, error) {
+	blames := map[int]analyzer.Blame{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var current analyzer.Blame
+	line := 0
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "\t") {
+			// the content of the line closes its description
+			blames[line] = current
+			continue
+		}
+		key, value, _ := strings.Cut(text, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.Email = strings.Trim(value, "<>")
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid git blame output %q", text)
+			}
+			current.Date = time.Unix(seconds, 0).UTC()
+		default:
+			// header of a line: <commit> <original line> <final line> [<lines in group>]
+			fields := strings.Fields(text)
+			if len(key) == 40 && len(fields) >= 3 {
+				number, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return nil, errors.Errorf("invalid git blame output %q", text)
+				}
+				current = analyzer.Blame{Commit: key}
+				line = number
+			}
+		}
+	}
+	return blames, scanner.Err()
+}
+
+// Annotate sets the blame of the results located in the Containerfile at path. The analysis
+// errors and the results located in other files are left as is.
+func Annotate(path string, results []analyzer.Result) ([]analyzer.Result, error) {
+	blames, err := File(Containerfile(path))
+	if err != nil {
+		return results, err
+	}
+	annotated := make([]analyzer.Result, 0, len(results))
+	for _, result := range results {
+		if result.Line != nil && result.File == nil {
+			if blame, ok := blames[result.Line.Start]; ok {
+				result.Blame = &blame
+			}
+		}
+		annotated = append(annotated, result)
+	}
+	return annotated, nil
+}
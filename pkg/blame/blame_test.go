@@ -0,0 +1,117 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package blame
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const porcelain = `4f1c2d9e8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d 1 1 2
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0100
+committer Jane Doe
+committer-mail <jane@example.com>
+committer-time 1700000000
+committer-tz +0100
+summary Add the Containerfile
+filename Containerfile
+	FROM ubi9
+4f1c2d9e8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d 2 2
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0100
+committer Jane Doe
+committer-mail <jane@example.com>
+committer-time 1700000000
+committer-tz +0100
+summary Add the Containerfile
+filename Containerfile
+	USER root
+0000000000000000000000000000000000000000 3 3 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+author-time 1700000100
+author-tz +0000
+committer Not Committed Yet
+committer-mail <not.committed.yet>
+committer-time 1700000100
+committer-tz +0000
+summary Version of Containerfile from Containerfile
+previous 4f1c2d9e8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d Containerfile
+filename Containerfile
+	EXPOSE 80
+`
+
+func TestParse(t *testing.T) {
+	blames, err := parse([]byte(porcelain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blames) != 3 {
+		t.Fatalf("Expected 3 lines but they were %d", len(blames))
+	}
+	if blame := blames[2]; blame.Author != "Jane Doe" || blame.Email != "jane@example.com" || blame.Date.Unix() != 1700000000 || blame.Commit[:7] != "4f1c2d9" {
+		t.Errorf("Unexpected blame %+v", blame)
+	}
+	if blames[3].Commit != UNCOMMITTED {
+		t.Errorf("Expected line 3 to be uncommitted but it was %+v", blames[3])
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Containerfile"), []byte("FROM ubi9\nUSER root\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "Containerfile"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "commit", "-q", "-m", "Add the Containerfile"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, output)
+		}
+	}
+	results, err := Annotate(dir, []analyzer.Result{
+		analyzer.RuleUserRoot.Failed("root").InFile("Containerfile", 2),
+		{RuleID: analyzer.RuleUserRoot.ID, Line: &analyzer.Line{Start: 2, End: 2}},
+		{Name: "Analyze error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Blame != nil || results[2].Blame != nil {
+		t.Errorf("Expected only the results of the Containerfile to be blamed")
+	}
+	if results[1].Blame == nil || results[1].Blame.Author != "Jane Doe" {
+		t.Errorf("Unexpected blame %+v", results[1].Blame)
+	}
+}
@@ -0,0 +1,361 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package bot runs remediation campaigns on GitHub repositories: their Containerfiles are
+// analyzed, the safe fixes of doa convert are committed on a branch and a pull request is opened
+// with the findings summary as its description.
+ package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/convert"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/notify"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+)
+
+// DEFAULT_BRANCH is the branch the fixes are pushed to, force pushed on every run so that the
+// pull request is updated rather than opened again
+const DEFAULT_BRANCH = "doa/remediation"
+
+const DEFAULT_TITLE = "Make the Containerfiles run on OpenShift"
+
+// The identity of the commits of the fixes
+const (
+	COMMIT_NAME  = "doa"
+	COMMIT_EMAIL = "doa-bot@users.noreply.github.com"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+var (
+	githubAPI = "https://api.github.com"
+	githubURL = "https://github.com"
+)
+
+type Options struct {
+	// Repository is the GitHub repository, owner/name
+	Repository string
+	// Token authenticates the clone, the push and the pull request
+	Token string
+	// Base is the branch the pull request targets, the default branch of the repository when empty
+	Base string
+	// Branch is the branch of the fixes, DEFAULT_BRANCH when empty
+	Branch string
+	// Title is the title of the pull request, DEFAULT_TITLE when empty
+	Title string
+	// DryRun only reports the fixes, without pushing them nor opening the pull request
+	DryRun bool
+	// Analyze analyzes the Containerfiles, analyzer.AnalyzePath when nil
+	Analyze workspace.AnalyzeFunc
+}
+
+// Fix is the remediation of a Containerfile.
+type Fix struct {
+	// Path is relative to the root of the repository
+	Path            string                   `json:"path"`
+	Transformations []convert.Transformation `json:"transformations"`
+	ScoreBefore     int                      `json:"scoreBefore"`
+	ScoreAfter      int                      `json:"scoreAfter"`
+	Fixed           int                      `json:"fixed"`
+	// Remaining are the failed findings the fixes don't address
+	Remaining []analyzer.Result `json:"remaining"`
+}
+
+// Result is the outcome of the campaign on a repository.
+type Result struct {
+	Repository string `json:"repository"`
+	Fixes      []Fix  `json:"fixes"`
+	// PullRequest is the URL of the pull request, empty when nothing was fixed or in dry run
+	PullRequest string `json:"pullRequest,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Run clones the repository, remediates its Containerfiles and opens a pull request with the
+// fixes, or updates the one already open for the branch.
+func Run(ctx context.Context, options Options) (*Result, error) {
+	if strings.Count(options.Repository, "/") != 1 {
+		return nil, errors.Errorf("invalid repository %s, expected owner/name", options.Repository)
+	}
+	if options.Branch == "" {
+		options.Branch = DEFAULT_BRANCH
+	}
+	if options.Title == "" {
+		options.Title = DEFAULT_TITLE
+	}
+	if options.Analyze == nil {
+		options.Analyze = analyzer.AnalyzePath
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git is required to remediate a repository but it was not found in the PATH")
+	}
+	dir, err := os.MkdirTemp("", "doa-bot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	g := gitRunner{ctx: ctx, dir: dir, token: options.Token}
+	args := []string{"clone", "--depth", "1"}
+	if options.Base != "" {
+		args = append(args, "--branch", options.Base)
+	}
+	if _, err := g.run("clone "+options.Repository, append(args, githubURL+"/"+options.Repository+".git", ".")...); err != nil {
+		return nil, err
+	}
+	if options.Base == "" {
+		base, err := g.run("read the default branch", "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		options.Base = strings.TrimSpace(string(base))
+	}
+
+	result := &Result{Repository: options.Repository}
+	if result.Fixes, err = Remediate(ctx, dir, options.Analyze); err != nil {
+		return nil, err
+	}
+	if len(result.Fixes) == 0 {
+		return result, nil
+	}
+	result.Description = Description(result.Fixes)
+	if options.DryRun {
+		return result, nil
+	}
+	message := options.Title + "\n\n" + commitMessage(result.Fixes)
+	for _, step := range [][]string{
+		{"checkout", "-B", options.Branch},
+		{"add", "--all"},
+		{"commit", "--message", message},
+		{"push", "--force", "origin", "HEAD:refs/heads/" + options.Branch},
+	} {
+		if _, err := g.run(step[0]+" the fixes", step...); err != nil {
+			return nil, err
+		}
+	}
+	if result.PullRequest, err = OpenPullRequest(ctx, options, result.Description); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Remediate applies the safe fixes of doa convert to the Containerfiles of the directory, see
+// convert.SafeTransformations, and returns the fixes of the changed Containerfiles.
+func Remediate(ctx context.Context, dir string, analyze workspace.AnalyzeFunc) ([]Fix, error) {
+	files, err := workspace.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	fixes := []Fix{}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convert.ConvertOnly(content, convert.SafeTransformations...)
+		if err != nil || bytes.Equal(converted.Content, content) {
+			// the Containerfiles which can't be parsed are left to the reviewers
+			continue
+		}
+		before := analyze(ctx, path)
+		if err := os.WriteFile(path, converted.Content, 0644); err != nil {
+			return nil, err
+		}
+		after := analyze(ctx, path)
+		fix := Fix{
+			Path:            file,
+			Transformations: converted.Transformations,
+			ScoreBefore:     workspace.Score(before),
+			ScoreAfter:      workspace.Score(after),
+			Remaining:       failed(after),
+		}
+		if fixed := len(failed(before)) - len(fix.Remaining); fixed > 0 {
+			fix.Fixed = fixed
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes, nil
+}
+
+func failed(results []analyzer.Result) []analyzer.Result {
+	failed := []analyzer.Result{}
+	for _, result := range results {
+		if result.Status == analyzer.StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Description returns the description of the pull request of the fixes, in Markdown: the
+// changes of each Containerfile, its score before and after them and the findings left to fix
+// by hand, the most severe first.
+func Description(fixes []Fix) string {
+	var out strings.Builder
+	out.WriteString("doa fixed the findings of the Containerfiles which don't require changing the application, so that their containers run with the arbitrary UID OpenShift assigns.\n\n")
+	out.WriteString("| Containerfile | Score | Fixed findings | Remaining findings |\n|---|---|---|---|\n")
+	for _, fix := range fixes {
+		fmt.Fprintf(&out, "| `%s` | %d → %d | %d | %d |\n", fix.Path, fix.ScoreBefore, fix.ScoreAfter, fix.Fixed, len(fix.Remaining))
+	}
+	for _, fix := range fixes {
+		fmt.Fprintf(&out, "\n### `%s`\n\n", fix.Path)
+		for _, transformation := range fix.Transformations {
+			fmt.Fprintf(&out, "- line %d: %s\n", transformation.Line, transformation.Description)
+		}
+		if len(fix.Remaining) == 0 {
+			continue
+		}
+		out.WriteString("\nTo fix by hand:\n\n")
+		top := notify.TopFindings(fix.Remaining)
+		for _, result := range top {
+			line := ""
+			if result.Line != nil {
+				line = fmt.Sprintf(" (line %d)", result.Line.Start)
+			}
+			fmt.Fprintf(&out, "- **%s** %s: %s%s\n", result.Severity, result.Name, result.Description, line)
+		}
+		if more := len(fix.Remaining) - len(top); more > 0 {
+			fmt.Fprintf(&out, "- and %d more, see `doa analyze -f %s`\n", more, fix.Path)
+		}
+	}
+	return out.String()
+}
+
+func commitMessage(fixes []Fix) string {
+	var lines []string
+	for _, fix := range fixes {
+		lines = append(lines, fmt.Sprintf("%s: %d transformation(s), score %d -> %d", fix.Path, len(fix.Transformations), fix.ScoreBefore, fix.ScoreAfter))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gitRunner runs git in the clone, authenticated by the token. The token is passed through the
+// environment so that it doesn't show in the arguments of the processes nor in the remote URL.
+type gitRunner struct {
+	ctx   context.Context
+	dir   string
+	token string
+}
+
+func (g gitRunner) run(purpose string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = g.dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+COMMIT_NAME, "GIT_AUTHOR_EMAIL="+COMMIT_EMAIL,
+		"GIT_COMMITTER_NAME="+COMMIT_NAME, "GIT_COMMITTER_EMAIL="+COMMIT_EMAIL,
+		"GIT_TERMINAL_PROMPT=0")
+	if g.token != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + g.token))
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http."+githubURL+"/.extraheader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to %s: %s", purpose, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+type pullRequest struct {
+	Title   string `json:"title,omitempty"`
+	Head    string `json:"head,omitempty"`
+	Base    string `json:"base,omitempty"`
+	Body    string `json:"body,omitempty"`
+	Number  int    `json:"number,omitempty"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// OpenPullRequest opens the pull request of the branch of the options, or updates the title and
+// the description of the one already open, and returns its URL.
+func OpenPullRequest(ctx context.Context, options Options, description string) (string, error) {
+	request := pullRequest{Title: options.Title, Head: options.Branch, Base: options.Base, Body: description}
+	var opened pullRequest
+	status, err := githubRequest(ctx, options.Token, http.MethodPost, "/repos/"+options.Repository+"/pulls", request, &opened)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusUnprocessableEntity {
+		return opened.HTMLURL, nil
+	}
+	// GitHub refuses a second pull request for the same branch
+	owner := strings.SplitN(options.Repository, "/", 2)[0]
+	query := url.Values{"head": {owner + ":" + options.Branch}, "base": {options.Base}, "state": {"open"}}
+	var existing []pullRequest
+	if _, err := githubRequest(ctx, options.Token, http.MethodGet, "/repos/"+options.Repository+"/pulls?"+query.Encode(), nil, &existing); err != nil {
+		return "", err
+	}
+	if len(existing) == 0 {
+		return "", errors.Errorf("unable to open the pull request of %s on %s", options.Branch, options.Repository)
+	}
+	path := fmt.Sprintf("/repos/%s/pulls/%d", options.Repository, existing[0].Number)
+	if _, err := githubRequest(ctx, options.Token, http.MethodPatch, path, pullRequest{Title: options.Title, Body: description}, &opened); err != nil {
+		return "", err
+	}
+	return opened.HTMLURL, nil
+}
+
+// githubRequest calls the GitHub API and decodes its response into out, the 422 status being
+// returned rather than failing.
+func githubRequest(ctx context.Context, token string, method string, path string, in interface{}, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, githubAPI+path, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to call the GitHub API")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf("unable to %s %s: %s", method, path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, errors.Wrap(err, "unable to read the GitHub response")
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,143 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixable = `FROM registry.access.redhat.com/ubi9/nodejs-20
+RUN chown -R 1001:1001 /opt/app-root/src
+COPY . .
+CMD ["node", "server.js"]
+`
+
+// gitRepository creates the bare repository owner/name under the root, with the files committed
+// on main.
+func gitRepository(t *testing.T, root string, files map[string]string) {
+	work := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bare := filepath.Join(root, "owner", "name.git")
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main", work},
+		{"-C", work, "add", "--all"},
+		{"-C", work, "-c", "user.name=dev", "-c", "user.email=dev@example.com", "commit", "--quiet", "--message", "init"},
+		{"clone", "--quiet", "--bare", work, bare},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("Unexpected error %s: %s", err, out)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	gitRepository(t, root, map[string]string{"Containerfile": fixable, "README.md": "app\n"})
+	var opened pullRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/owner/name/pulls" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&opened)
+		json.NewEncoder(w).Encode(pullRequest{Number: 1, HTMLURL: "https://github.com/owner/name/pull/1"})
+	}))
+	defer server.Close()
+	githubURL, githubAPI = "file://"+root, server.URL
+	defer func() { githubURL, githubAPI = "https://github.com", "https://api.github.com" }()
+
+	result, err := Run(context.Background(), Options{Repository: "owner/name", Token: "secret"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if result.PullRequest != "https://github.com/owner/name/pull/1" || len(result.Fixes) != 1 || result.Fixes[0].Path != "Containerfile" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if opened.Head != DEFAULT_BRANCH || opened.Base != "main" || !strings.Contains(opened.Body, "`Containerfile`") {
+		t.Errorf("Unexpected pull request %v", opened)
+	}
+	content, err := exec.Command("git", "-C", filepath.Join(root, "owner", "name.git"), "show", DEFAULT_BRANCH+":Containerfile").Output()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !strings.Contains(string(content), "COPY . .\nRUN chown -R 1001:0 /opt/app-root/src\n") {
+		t.Errorf("Expected the fixes to be pushed but got\n%s", content)
+	}
+}
+
+func TestRunWithoutFixes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	gitRepository(t, root, map[string]string{"Containerfile": "FROM registry.access.redhat.com/ubi9/ubi-minimal\nUSER 1001\n"})
+	githubURL = "file://" + root
+	defer func() { githubURL = "https://github.com" }()
+
+	result, err := Run(context.Background(), Options{Repository: "owner/name"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(result.Fixes) != 0 || result.PullRequest != "" {
+		t.Errorf("Expected no fix but got %v", result)
+	}
+}
+
+func TestOpenPullRequestUpdatesTheOpenOne(t *testing.T) {
+	var updated pullRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		case r.Method == http.MethodGet && r.URL.Query().Get("head") == "owner:"+DEFAULT_BRANCH:
+			json.NewEncoder(w).Encode([]pullRequest{{Number: 7}})
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/owner/name/pulls/7":
+			json.NewDecoder(r.Body).Decode(&updated)
+			json.NewEncoder(w).Encode(pullRequest{Number: 7, HTMLURL: "https://github.com/owner/name/pull/7"})
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	githubAPI = server.URL
+	defer func() { githubAPI = "https://api.github.com" }()
+
+	options := Options{Repository: "owner/name", Branch: DEFAULT_BRANCH, Base: "main", Title: DEFAULT_TITLE}
+	link, err := OpenPullRequest(context.Background(), options, "new description")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if link != "https://github.com/owner/name/pull/7" || updated.Body != "new description" {
+		t.Errorf("Expected the pull request 7 to be updated but got %s %v", link, updated)
+	}
+}
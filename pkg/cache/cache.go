@@ -0,0 +1,197 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package cache stores on disk the results of the analysis of the Containerfiles, keyed by their
+// content and by everything else the results depend on (the rule set, the configuration, the
+// analysis settings, the files of the build context), so that the files which didn't change
+// since the previous run are not analyzed again.
+ package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+type Cache struct {
+	Dir string
+}
+
+// DefaultDir is the directory the results are cached in, e.g. ~/.cache/doa/results
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "doa", "results"), nil
+}
+
+func New(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Key hashes the content of a Containerfile along with the settings its results depend on.
+func Key(content []byte, settings ...string) string {
+	hash := sha256.New()
+	for _, setting := range settings {
+		hash.Write([]byte(setting))
+		hash.Write([]byte{0})
+	}
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// FileDigest hashes the content of the file, e.g. of an executable whose version is unknown.
+func FileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// Get returns the results cached for the key, if any.
+func (c *Cache) Get(key string) ([]analyzer.Result, bool) {
+	bytes, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	results := []analyzer.Result{}
+	if err := json.Unmarshal(bytes, &results); err != nil {
+		return nil, false
+	}
+	return results, true
+}
+
+// Put caches the results for the key. The file is renamed once written, so that concurrent runs
+// never read a partial file.
+func (c *Cache) Put(key string, results []analyzer.Result) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "unable to create the results cache")
+	}
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".results-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to write the results cache")
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(bytes)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to write the results cache")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "unable to write the results cache")
+	}
+	return nil
+}
+
+// ContextDigest hashes the paths, the sizes and the modification times of the files of the build
+// context, which the rules read, e.g. the ignore file, the COPY sources or the scanned secrets. The
+// content of the .git directory is left out as only its presence is checked. It reports false
+// when the context has more than analyzer.MAX_CONTEXT_ENTRIES entries.
+func ContextDigest(dir string) (string, bool) {
+	hash := sha256.New()
+	entries := 0
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entries++; entries > analyzer.MAX_CONTEXT_ENTRIES {
+			return errors.New("too many entries in the build context")
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00%d\x00%d\x00", filepath.ToSlash(rel), info.Mode(), info.Size(), info.ModTime().UnixNano())
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", false
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// AnalyzePath returns the cached results of the Containerfile at path, analyzing it with
+// analyzer.AnalyzePath when its content, its build context or the settings changed. The build
+// context is the directory of the Containerfile unless the context sets one. Directories, the
+// build contexts too large to be hashed and the results which may change without the
+// Containerfile changing, e.g. an unreachable base image, are not cached.
+func (c *Cache) AnalyzePath(ctx context.Context, path string, settings ...string) []analyzer.Result {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return analyzer.AnalyzePath(ctx, path)
+	}
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		absolute = path
+	}
+	dir, ok := analyzer.BuildContextDir(ctx)
+	if !ok {
+		dir = filepath.Dir(path)
+	}
+	digest, ok := ContextDigest(dir)
+	if !ok {
+		return analyzer.AnalyzePath(ctx, path)
+	}
+	key := Key(content, append([]string{absolute, "context=" + digest}, settings...)...)
+	if results, ok := c.Get(key); ok {
+		return results
+	}
+	results := analyzer.AnalyzePath(ctx, path)
+	for _, result := range results {
+		if result.RuleID == "" || result.RuleID == analyzer.RuleBaseImageAnalysis.ID {
+			return results
+		}
+	}
+	// a cache which can't be written only slows down the analysis
+	_ = c.Put(key, results)
+	return results
+}
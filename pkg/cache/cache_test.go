@@ -0,0 +1,114 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestKeyDependsOnContentAndSettings(t *testing.T) {
+	key := Key([]byte("FROM ubi9\n"), "1.21.0", "config")
+	if key != Key([]byte("FROM ubi9\n"), "1.21.0", "config") {
+		t.Errorf("Expected the key to be stable")
+	}
+	for _, other := range []string{
+		Key([]byte("FROM ubi8\n"), "1.21.0", "config"),
+		Key([]byte("FROM ubi9\n"), "1.22.0", "config"),
+		Key([]byte("FROM ubi9\n"), "1.21.0", "other config"),
+		Key([]byte("FROM ubi9\n"), "1.21.0config"),
+	} {
+		if other == key {
+			t.Errorf("Expected the key to change with the content and the settings")
+		}
+	}
+}
+
+func TestAnalyzePathReusesCachedResults(t *testing.T) {
+	cache := New(t.TempDir())
+	path := filepath.Join(t.TempDir(), "Containerfile")
+	if err := os.WriteFile(path, []byte("FROM scratch\nUSER root\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := cache.AnalyzePath(context.Background(), path, "settings")
+	if len(results) == 0 {
+		t.Fatalf("Expected the root user to be reported")
+	}
+
+	// the cached results are returned as long as the content doesn't change
+	digest, ok := ContextDigest(filepath.Dir(path))
+	if !ok {
+		t.Fatalf("Expected the build context to be hashed")
+	}
+	key := Key([]byte("FROM scratch\nUSER root\n"), path, "context="+digest, "settings")
+	if err := cache.Put(key, []analyzer.Result{{RuleID: "cached"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cached := cache.AnalyzePath(context.Background(), path, "settings"); len(cached) != 1 || cached[0].RuleID != "cached" {
+		t.Errorf("Expected the cached results but they were %v", cached)
+	}
+	if err := os.WriteFile(path, []byte("FROM scratch\nUSER 1001\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if analyzed := cache.AnalyzePath(context.Background(), path, "settings"); len(analyzed) == 1 && analyzed[0].RuleID == "cached" {
+		t.Errorf("Expected the modified Containerfile to be analyzed again")
+	}
+}
+
+func TestAnalyzePathAnalyzesAgainWhenTheBuildContextChanges(t *testing.T) {
+	cache := New(t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Containerfile")
+	if err := os.WriteFile(path, []byte("FROM scratch\nUSER 1001\nCOPY . /app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := ContextDigest(dir)
+	if err := cache.Put(Key([]byte("FROM scratch\nUSER 1001\nCOPY . /app\n"), path, "context="+digest, "settings"), []analyzer.Result{{RuleID: "cached"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if analyzed := cache.AnalyzePath(context.Background(), path, "settings"); len(analyzed) == 1 && analyzed[0].RuleID == "cached" {
+		t.Errorf("Expected the Containerfile to be analyzed again when its build context changes")
+	}
+}
+
+func TestFileDigestChangesWithTheContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := FileDigest(path)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := os.WriteFile(path, []byte("v2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := FileDigest(path); other == digest {
+		t.Errorf("Expected the digest to change with the content")
+	}
+	if _, err := FileDigest(path + ".missing"); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
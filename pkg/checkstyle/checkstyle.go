@@ -0,0 +1,132 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package checkstyle writes the results in the Checkstyle XML format, read by the lint aggregation
+// tools, e.g. Jenkins Warnings NG or reviewdog -f=checkstyle.
+ package checkstyle
+
+import (
+	"encoding/xml"
+	"io"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// VERSION is the version of Checkstyle whose format is written
+const VERSION = "4.3"
+
+// SOURCE_PREFIX prefixes the ID of the rules in the source of the errors
+const SOURCE_PREFIX = "doa."
+
+type Report struct {
+	XMLName xml.Name `xml:"checkstyle"`
+	Version string   `xml:"version,attr"`
+	Files   []File   `xml:"file"`
+}
+
+type File struct {
+	Name   string  `xml:"name,attr"`
+	Errors []Error `xml:"error"`
+}
+
+type Error struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// Severity maps a severity to the corresponding Checkstyle severity.
+func Severity(severity analyzer.ResultSeverity) string {
+	switch severity {
+	case analyzer.SeverityCritical, analyzer.SeverityHigh:
+		return "error"
+	case analyzer.SeverityMedium:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// NewReport returns the failed results grouped by file: the file of the build context or the
+// manifest they were found in, the Containerfile (or image) otherwise.
+func NewReport(containerfile string, results []analyzer.Result) Report {
+	report := Report{Version: VERSION, Files: []File{}}
+	report.Add(containerfile, results)
+	return report
+}
+
+// Add adds the failed results of another Containerfile to the report, see NewReport.
+func (r *Report) Add(containerfile string, results []analyzer.Result) {
+	for _, result := range results {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		name, line := Location(containerfile, result)
+		index := 0
+		for index < len(r.Files) && r.Files[index].Name != name {
+			index++
+		}
+		if index == len(r.Files) {
+			r.Files = append(r.Files, File{Name: name})
+		}
+		source := result.RuleID
+		if source == "" {
+			source = result.Name
+		}
+		r.Files[index].Errors = append(r.Files[index].Errors, Error{
+			Line:     line,
+			Severity: Severity(result.Severity),
+			Message:  result.Description,
+			Source:   SOURCE_PREFIX + source,
+		})
+	}
+}
+
+// Location returns the file and the line of the result, 0 when it isn't bound to a line.
+func Location(containerfile string, result analyzer.Result) (string, int) {
+	switch {
+	case result.Manifest != nil:
+		return result.Manifest.Path, result.Manifest.Line
+	case result.File != nil:
+		return result.File.Path, result.File.Line
+	case result.Line != nil:
+		return containerfile, result.Line.Start
+	}
+	return containerfile, 0
+}
+
+// Write writes the failed results as a Checkstyle report.
+func Write(w io.Writer, containerfile string, results []analyzer.Result) error {
+	return WriteReport(w, NewReport(containerfile, results))
+}
+
+// WriteReport writes the Checkstyle report.
+func WriteReport(w io.Writer, report Report) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
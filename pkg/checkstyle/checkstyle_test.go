@@ -0,0 +1,60 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package checkstyle
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestWriteGroupsErrorsByFile(t *testing.T) {
+	root := analyzer.RuleUserRoot.Failed(`USER "root" is set`)
+	root.Line = &analyzer.Line{Start: 3, End: 3}
+	secret := analyzer.RuleContextSecret.Failed("id_rsa is in the build context")
+	secret.File = &analyzer.FileLocation{Path: "keys/id_rsa", Line: 1}
+	results := []analyzer.Result{root, analyzer.RuleUserRoot.Passed("USER 1001"), secret}
+
+	var out bytes.Buffer
+	if err := Write(&out, "web/Containerfile", results); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="web/Containerfile">
+    <error line="3" severity="warning" message="USER &#34;root&#34; is set" source="doa.user-root"></error>
+  </file>
+  <file name="keys/id_rsa">
+    <error line="1" severity="error" message="id_rsa is in the build context" source="doa.context-secret"></error>
+  </file>
+</checkstyle>
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\nbut it was\n%s", expected, out.String())
+	}
+}
+
+func TestWriteWithoutResults(t *testing.T) {
+	var out bytes.Buffer
+	Write(&out, "Containerfile", nil)
+	if !strings.Contains(out.String(), `<checkstyle version="4.3"></checkstyle>`) {
+		t.Errorf("Unexpected report %s", out.String())
+	}
+}
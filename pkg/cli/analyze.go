@@ -19,58 +19,669 @@
  package cli
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/blame"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/cache"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/checkstyle"
 	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/convert"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/machine"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/notify"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/pdf"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/plugin"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/policy"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/rdjson"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/schema"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/tap"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/triage"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/version"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func NewCmdAnalyze() *cobra.Command {
 	analyzeCmd := &cobra.Command{
-		Use:     "analyze",
-		Short:   "Analyze the Containerfile and discover potential issues when deploying it on OpenShift",
-		Long:    "Analyze the Containerfile and discover potential issues when deploying it on OpenShift. It accepts the project root path or the Containerfile path.",
-		Args:    cobra.MaximumNArgs(0),
-		Run:     doAnalyze,
-		Example: `  doa analyze -f /your/local/project/path[/Containerfile_name]`,
+		Use:   "analyze",
+		Short: "Analyze the Containerfile and discover potential issues when deploying it on OpenShift",
+		Long:  "Analyze the Containerfile and discover potential issues when deploying it on OpenShift. It accepts the project root path or the Containerfile path.",
+		Args:  cobra.MaximumNArgs(0),
+		Run:   doAnalyze,
+		Example: `  doa analyze -f /your/local/project/path[/Containerfile_name]
+  doa analyze -f /your/local/project/path --quiet || echo "Containerfile is not OpenShift compliant"
+  doa analyze -f buildconfig.yaml
+  doa analyze -f /your/local/project/path -o pdf > report.pdf
+  doa analyze -f Containerfile --target test`,
 	}
 	analyzeCmd.PersistentFlags().StringP(
-		"file", "f", "", "Container file to analyze",
+		"file", "f", "", "Container file to analyze, or YAML manifests embedding Containerfiles (BuildConfigs, Tekton, GitHub workflows)",
 	)
 	analyzeCmd.PersistentFlags().StringP(
 		"image", "i", "", "Image name to analyze",
 	)
 	analyzeCmd.PersistentFlags().StringP(
-		"output", "o", "", "Specify output format, supported format: json",
+		"output", "o", "", "Specify output format, supported formats: json, configmap, pdf, checkstyle, tap, rdjson",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"no-color", false, "Disable colored output. Colors are also disabled when NO_COLOR is set or the output is not a terminal",
+	)
+	analyzeCmd.PersistentFlags().BoolP(
+		"quiet", "q", false, "Print nothing, exit with code 1 if any issue is found",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"summary-only", false, "Print only the number of issues found and the verdict, exit with code 1 if any issue is found",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"context", "", "Build context directory, as passed to podman build. The files copied by COPY and ADD are checked to exist in it",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"dialect", string(analyzer.DialectDocker), "Builder the Containerfile is written for, which changes the instruction flags considered valid: docker, podman",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"platform", "", "Platform the image is deployed to, which selects the rules and their severity: openshift, kubernetes (default the platform of the configuration file, openshift otherwise)",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"target", "", "Stage to analyze as the final one, as docker build --target does, only the stages it depends on being analyzed with it",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"packs", "", "Comma separated rule packs to check, e.g. java,database, the other packs being disabled: "+strings.Join(analyzer.PackNames(), ", ")+" (default the packs detected from the base images and the commands)",
+	)
+	analyzeCmd.PersistentFlags().StringArray(
+		"build-context", nil, "Additional build context referenced by FROM or COPY --from, name=value as in buildx, e.g. base=docker-image://alpine:3.19",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"show-passed", false, "Also report the checks which passed, e.g. a non-root USER, to demonstrate the compliance of the image",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"scan-secrets", false, "Scan the content of the build context files copied by COPY and ADD for credentials, e.g. private keys, tokens and random looking strings",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"profile-rules", false, "Print to stderr the execution time and the number of issues of each rule, slowest first",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"coverage", false, "Print to stderr the instructions checked by the rules and the ones which weren't evaluated at all, also reported by the PDF report",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"size", false, "Print to stderr the estimated size added by the instructions of the final stage, the largest ones flagged, the base image size being read from its registry",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"min-confidence", string(analyzer.ConfidenceLow), "Report only the issues found with at least this confidence: high, medium, low",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"config", config.DEFAULT_FILE, "Configuration file customizing the rules, e.g. their severity",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"policy", "", "Reference of a policy pulled with doa policy pull, used instead of the configuration file",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"report-namespace", "", "Namespace of the ConfigMap written by --output configmap",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"report-workload", "", "Workload running the image, e.g. deployment/web, recorded in the ConfigMap written by --output configmap",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"blame", false, "Attribute the findings to the author and the commit of their line with git blame",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"watch", false, "Analyze the Containerfile again every time it, the configuration file or the feedback file changes, until interrupted",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"no-cache", false, "Analyze the Containerfile even if its results are cached",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"cache-dir", "", "Directory the results are cached in (default ~/.cache/doa/results)",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"validate-output", false, "Validate the JSON output against its schema, see doa schema, and fail when it doesn't match",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"policy-lock", "", "Reference of a policy whose locked rules and fail-on can't be weakened by the configuration file, applied on top of it",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"plugins-dir", plugin.DefaultDir(), "Directory of the plugin binaries adding their rules to the analysis of the Containerfiles",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"triage-file", triage.DEFAULT_FILE, "Feedback file listing the findings marked as false positives, see doa triage",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"lang", "", "Language of the reported issues, one of "+strings.Join(i18n.Languages(), ", ")+" (default en)",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"notify", false, "Post the findings summary to the notifications of the configuration file whose threshold is exceeded",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"report-url", "", "Link to the full report of the run in the notifications, e.g. the artifacts of the CI job (default the report-url of the notifications)",
+	)
+	analyzeCmd.PersistentFlags().String(
+		"store", "", "SQLite database the run and its findings are recorded in, see doa history (default $"+store.STORE_ENV+")",
+	)
+	analyzeCmd.PersistentFlags().Bool(
+		"machine", false, "Read analysis requests from stdin and write the results to stdout as length-prefixed JSON frames, until stdin is closed",
 	)
 	return analyzeCmd
 }
 
 func doAnalyze(cmd *cobra.Command, args []string) {
+	machineMode, _ := cmd.Flags().GetBool("machine")
 	containerfile := cmd.Flag("file")
 	image := cmd.Flag("image")
-	if containerfile.Value.String() == "" && image.Value.String() == "" {
+	if !machineMode && containerfile.Value.String() == "" && image.Value.String() == "" {
 		PrintNoArgsWarningMessage(cmd.Name())
 		return
 	}
 
-	outputFunc := PrintPrettifyOutput
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	summaryOnly, _ := cmd.Flags().GetBool("summary-only")
+	if quiet && summaryOnly {
+		RedirectErrorStringToStdErrAndExit("flags --quiet and --summary-only can't be used together, type --help for a list of all flags\n")
+	}
+
 	out := cmd.Flag("output")
-	if out.Value.String() != "" && !strings.EqualFold(out.Value.String(), "json") {
+	format := strings.ToLower(out.Value.String())
+	if format != "" && format != "json" && format != "configmap" && format != "pdf" && format != "checkstyle" && format != "tap" && format != "rdjson" {
 		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag %s, type --help for a list of all flags\n", out.Value.String(), out.Name))
-	} else if strings.EqualFold(out.Value.String(), "json") {
-		outputFunc = PrintPrettifyJsonOutput
+	}
+	if format == "pdf" && term.IsTerminal(int(os.Stdout.Fd())) {
+		RedirectErrorStringToStdErrAndExit("the PDF report is written to the standard output, redirect it to a file, e.g. doa analyze -o pdf > report.pdf\n")
+	}
+	humanOutput := format == ""
+	validateOutput, _ := cmd.Flags().GetBool("validate-output")
+
+	minConfidence, err := analyzer.ParseConfidence(cmd.Flag("min-confidence").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	lang, _ := cmd.Flags().GetString("lang")
+	ctx, err := i18n.WithLanguage(context.Background(), lang)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
 	}
 
-	if containerfile.Value.String() != "" {
-		outputFunc(analyzer.AnalyzePath(containerfile.Value.String()))
-	} else if image.Value.String() != "" {
-		outputFunc(analyzer.AnalyzeImage(image.Value.String()))
+	dialect, err := analyzer.ParseDialect(cmd.Flag("dialect").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx = analyzer.WithDialect(ctx, dialect)
+	if target := cmd.Flag("target").Value.String(); target != "" {
+		ctx = analyzer.WithTarget(ctx, target)
+	}
+
+	var platform analyzer.Platform
+	if value := cmd.Flag("platform").Value.String(); value != "" {
+		if platform, err = analyzer.ParsePlatform(value); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	}
+
+	var packs map[string]bool
+	if value := cmd.Flag("packs").Value.String(); value != "" {
+		if packs, err = analyzer.ParsePacks(value); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	}
+
+	if contextDir, _ := cmd.Flags().GetString("context"); contextDir != "" {
+		if info, err := os.Stat(contextDir); err != nil || !info.IsDir() {
+			RedirectErrorStringToStdErrAndExit(fmt.Sprintf("the build context %s is not a directory\n", contextDir))
+		}
+		ctx = analyzer.WithVerifiedBuildContext(ctx, contextDir)
+	}
+
+	buildContexts, _ := cmd.Flags().GetStringArray("build-context")
+	if len(buildContexts) > 0 {
+		contexts := map[string]string{}
+		for _, buildContext := range buildContexts {
+			name, value, err := analyzer.ParseNamedContext(buildContext)
+			if err != nil {
+				RedirectErrorStringToStdErrAndExit(err.Error())
+			}
+			contexts[name] = value
+		}
+		ctx = analyzer.WithNamedContexts(ctx, contexts)
+	}
+
+	if showPassed, _ := cmd.Flags().GetBool("show-passed"); showPassed {
+		ctx = analyzer.WithPassedResults(ctx)
+	}
+
+	if scanSecrets, _ := cmd.Flags().GetBool("scan-secrets"); scanSecrets {
+		ctx = analyzer.WithSecretScan(ctx)
+	}
+
+	var profile *analyzer.Profile
+	if profileRules, _ := cmd.Flags().GetBool("profile-rules"); profileRules {
+		profile = analyzer.NewProfile()
+		ctx = analyzer.WithProfile(ctx, profile)
+	}
+	var coverage *analyzer.Coverage
+	if covering, _ := cmd.Flags().GetBool("coverage"); covering {
+		coverage = analyzer.NewCoverage()
+		ctx = analyzer.WithCoverage(ctx, coverage)
+	}
+
+	// the rules of the plugins are registered first, so that the configuration can refer to them
+	plugins, err := plugin.Load(cmd.Flag("plugins-dir").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	// prepare loads the configuration and the feedback file, again on every analysis in watch and
+	// machine modes, and applies them to ctx along with the plugins, the platform and the packs
+	prepare := func() (context.Context, *session, error) {
+		triageFile, err := triage.Load(cmd.Flag("triage-file").Value.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg, configName, overrides, err := loadConfig(cmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the custom rules of the configuration are run as an additional plugin
+		customRules, err := cfg.Plugin()
+		if err != nil {
+			return nil, nil, err
+		}
+		rules, _ := customRules.Rules()
+		if err := analyzer.RegisterRules(configName, rules); err != nil {
+			return nil, nil, err
+		}
+		ctx := analyzer.WithPlugins(ctx, append(plugins.Plugins, customRules))
+		if platform != "" {
+			ctx = analyzer.WithPlatform(ctx, platform)
+		} else if cfg.Platform != "" {
+			ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+		}
+		// --packs overrides the packs of the configuration
+		ctx = analyzer.WithPacks(analyzer.WithPacks(ctx, cfg.EnabledPacks()), packs)
+		return ctx, &session{cfg: cfg, triage: triageFile, overrides: overrides, rules: rules}, nil
+	}
+
+	if machineMode {
+		err := machine.Serve(os.Stdin, os.Stdout, func() (context.Context, func([]analyzer.Result) []analyzer.Result, error) {
+			ctx, session, err := prepare()
+			if err != nil {
+				return nil, nil, err
+			}
+			return ctx, func(results []analyzer.Result) []analyzer.Result {
+				defer session.close()
+				results, _ = session.filter(results, minConfidence)
+				for _, override := range session.overrides {
+					results = append(results, override.Result())
+				}
+				return results
+			}, nil
+		})
+		plugins.Close()
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		return
+	}
+
+	watching, _ := cmd.Flags().GetBool("watch")
+	if watching && containerfile.Value.String() == "" {
+		plugins.Close()
+		RedirectErrorStringToStdErrAndExit("flag --watch requires a Containerfile, type --help for a list of all flags\n")
+	}
+
+	// report analyzes the Containerfile or the image, prints the results and returns the exit code
+	report := func() (int, error) {
+		ctx, session, err := prepare()
+		if err != nil {
+			return 0, err
+		}
+		defer session.close()
+		cfg := session.cfg
+
+		var results []analyzer.Result
+		manifest := containerfile.Value.String() != "" && manifests.IsManifest(containerfile.Value.String())
+		if manifest {
+			if results, err = manifests.AnalyzeManifests(ctx, containerfile.Value.String()); err != nil {
+				return 0, err
+			}
+		} else if resultsCache := newCache(cmd, profile != nil || coverage != nil); resultsCache != nil && containerfile.Value.String() != "" {
+			results = resultsCache.AnalyzePath(ctx, containerfile.Value.String(), cacheSettings(cmd, cfg, plugins)...)
+		} else if containerfile.Value.String() != "" {
+			results = analyzer.AnalyzePath(ctx, containerfile.Value.String())
+		} else if image.Value.String() != "" {
+			results = analyzer.AnalyzeImage(ctx, image.Value.String())
+		}
+		results, suppressed := session.filter(results, minConfidence)
+		if blamed, _ := cmd.Flags().GetBool("blame"); blamed && containerfile.Value.String() != "" && !manifest {
+			if results, err = blame.Annotate(containerfile.Value.String(), results); err != nil {
+				fmt.Fprintf(os.Stderr, "the findings are not attributed: %s\n", err)
+			}
+		}
+		for _, override := range session.overrides {
+			results = append(results, override.Result())
+		}
+		failOn := cfg.FailOnSeverity()
+
+		title := containerfile.Value.String()
+		if title == "" {
+			title = image.Value.String()
+		}
+		var stack analyzer.RuntimeStack
+		if containerfile.Value.String() != "" && !manifest {
+			stack, _ = analyzer.DetectRuntimePath(containerfile.Value.String())
+		}
+		if path := storePath(cmd); path != "" {
+			run := store.Run{Target: title, Score: workspace.Score(results), Summary: analyzer.SummarizeFailingOn(results, failOn)}
+			recordRuns(path, []store.Run{run}, [][]analyzer.Result{results})
+		}
+		if notifying, _ := cmd.Flags().GetBool("notify"); notifying {
+			message := notify.NewMessage(title, results, workspace.Score(results), failOn)
+			message.ReportURL, _ = cmd.Flags().GetString("report-url")
+			if err := notify.Notify(cfg.Notifications, message); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+
+		if profile != nil {
+			profile.CountMatches(results)
+			PrintProfile(os.Stderr, profile)
+		}
+		if coverage != nil {
+			PrintCoverage(os.Stderr, coverage)
+		}
+		if sizing, _ := cmd.Flags().GetBool("size"); sizing && containerfile.Value.String() != "" && !manifest {
+			estimates, err := analyzer.EstimateSizePath(analyzer.WithImageSizer(ctx, registryImageSize), containerfile.Value.String())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "the size is not estimated: %s\n", err)
+			} else {
+				PrintSizes(os.Stderr, estimates)
+			}
+		}
+
+		if humanOutput && !quiet && suppressed > 0 {
+			fmt.Fprintf(os.Stderr, "%d finding(s) marked as false positive, see doa triage list\n", suppressed)
+		}
+
+		if !quiet {
+			printer := NewPrettifyPrinter(os.Stdout, UseColor(noColor, os.Stdout))
+			printer.FailOn = failOn
+			printer.Runtime = stack
+			switch {
+			case format == "configmap":
+				PrintConfigMapOutput(cmd, results, failOn)
+			case format == "pdf":
+				PrintPdfOutput(title, results, failOn, stack.Runtime, coverage)
+			case format == "checkstyle" || format == "tap":
+				PrintLintOutput(format, reportedFile(cmd), results)
+			case format == "rdjson":
+				PrintRdjsonOutput(reportedFile(cmd), !manifest && containerfile.Value.String() != "", results)
+			case humanOutput && summaryOnly:
+				printer.PrintSummary(results)
+			case humanOutput:
+				printer.Print(results)
+			case summaryOnly:
+				PrintSummaryJsonOutput(results, failOn, stack.Runtime, validateOutput)
+			default:
+				if !manifest && containerfile.Value.String() != "" {
+					if content, err := os.ReadFile(reportedFile(cmd)); err == nil {
+						results = convert.AttachPatches(reportedFile(cmd), content, results)
+					}
+				}
+				PrintPrettifyJsonOutput(results, validateOutput)
+			}
+		}
+		if len(cfg.ExitCodes) > 0 {
+			return cfg.ExitCode(results), nil
+		}
+		// in quiet and summary-only modes the verdict is also reported through the exit code
+		// so that CI scripts can gate on it
+		if (quiet || summaryOnly) && analyzer.SummarizeFailingOn(results, failOn).Verdict == analyzer.VerdictFailed {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	if watching {
+		err := watch(watchedFiles(cmd), func() {
+			if humanOutput && term.IsTerminal(int(os.Stdout.Fd())) {
+				// clear the terminal so that only the last report is shown
+				fmt.Print("\x1b[H\x1b[2J")
+			}
+			if _, err := report(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		})
+		plugins.Close()
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		return
+	}
+
+	code, err := report()
+	plugins.Close()
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if code != 0 {
+		os.Exit(code)
 	}
 }
 
+// session is the configuration and the feedback file an analysis is run with, the custom rules of
+// the configuration stay registered until it is closed.
+type session struct {
+	cfg       *config.Config
+	triage    *triage.File
+	overrides []config.Override
+	rules     []analyzer.Rule
+}
+
+// filter drops the results below the minimum confidence, applies the configuration and suppresses
+// the findings marked as false positives, it returns the number of suppressed findings.
+func (s *session) filter(results []analyzer.Result, minConfidence analyzer.ResultConfidence) ([]analyzer.Result, int) {
+	results = analyzer.FilterByConfidence(results, minConfidence)
+	results = s.cfg.Apply(results)
+	if s.cfg.Lock != nil {
+		// the findings of the locked rules can't be marked as false positives either
+		entries := []triage.Entry{}
+		for _, entry := range s.triage.Entries {
+			if s.cfg.Lock.Locks(entry.RuleID) {
+				s.overrides = append(s.overrides, config.Override{RuleID: entry.RuleID, Setting: "triage"})
+			} else {
+				entries = append(entries, entry)
+			}
+		}
+		s.triage.Entries = entries
+	}
+	return s.triage.Suppress(results)
+}
+
+func (s *session) close() {
+	analyzer.UnregisterRules(s.rules)
+}
+
+// loadConfig returns the policy set by --policy, pulling it when it's not in the cache, or the
+// configuration file, along with its name. With --policy-lock the configuration file is applied on
+// top of the policy, the settings weakening its lock are ignored and returned as overrides.
+func loadConfig(cmd *cobra.Command) (*config.Config, string, []config.Override, error) {
+	file := cmd.Flag("config").Value.String()
+	reference := cmd.Flag("policy").Value.String()
+	lockReference := cmd.Flag("policy-lock").Value.String()
+	if reference != "" && lockReference != "" {
+		return nil, "", nil, errors.New("flags --policy and --policy-lock can't be used together, type --help for a list of all flags")
+	}
+	if reference == "" && lockReference == "" {
+		cfg, err := loadConfigFile(file)
+		return cfg, file, nil, err
+	}
+	verifier, err := loadVerifier()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if reference != "" {
+		bundle, err := policy.Load(reference, verifier)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		cfg, err := bundle.Config()
+		return cfg, reference, nil, err
+	}
+	bundle, err := policy.Load(lockReference, verifier)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	locking, err := bundle.Config()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	project, err := loadConfigFile(file)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cfg, overrides := locking.Enforce(project)
+	return cfg, file, overrides, nil
+}
+
+// newCache returns the cache of the results, nil when it's disabled or when the analysis has to run
+// to record its profile or its coverage.
+func newCache(cmd *cobra.Command, recorded bool) *cache.Cache {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache || recorded {
+		return nil
+	}
+	dir := cmd.Flag("cache-dir").Value.String()
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return nil
+		}
+	}
+	return cache.New(dir)
+}
+
+// cacheSettings returns everything the results depend on besides the Containerfile and its build
+// context, so that they are analyzed again when one of them changes. The dev builds share their
+// version and commit, they are told apart by the digest of their executable, as are the plugins.
+func cacheSettings(cmd *cobra.Command, cfg *config.Config, plugins *plugin.Set) []string {
+	settings := []string{analyzer.RULESET_VERSION, version.Version, version.Commit}
+	if version.Version == "dev" {
+		if executable, err := os.Executable(); err == nil {
+			digest, _ := cache.FileDigest(executable)
+			settings = append(settings, "executable="+digest)
+		}
+	}
+	for _, flag := range []string{"lang", "dialect", "platform", "target", "packs", "context", "build-context", "show-passed", "scan-secrets"} {
+		if cmd.Flag(flag) != nil {
+			settings = append(settings, flag+"="+cmd.Flag(flag).Value.String())
+		}
+	}
+	configuration, _ := json.Marshal(cfg)
+	settings = append(settings, string(configuration))
+	if plugins == nil {
+		return settings
+	}
+	for _, loaded := range plugins.Plugins {
+		digest, _ := cache.FileDigest(plugins.Paths[loaded.Name()])
+		settings = append(settings, "plugin="+loaded.Name()+"@"+digest)
+	}
+	return settings
+}
+
+// PrintProfile writes the profile as a table, the time of an instruction includes the time of
+// the rules it runs.
+func PrintProfile(out io.Writer, profile *analyzer.Profile) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tCALLS\tTIME\tMATCHES")
+	for _, entry := range profile.Entries() {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\n", entry.Name, entry.Kind, entry.Calls, entry.Duration.Round(time.Microsecond), entry.Matches)
+	}
+	w.Flush()
+}
+
+// PrintCoverage writes the coverage of the instructions as a table followed by the number of
+// covered instructions.
+func PrintCoverage(out io.Writer, coverage *analyzer.Coverage) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tINSTRUCTION\tCOVERAGE\tRULES")
+	entries := coverage.Entries()
+	covered := 0
+	for _, entry := range entries {
+		if entry.Covered() {
+			covered++
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", entry.Line.Start, entry.Instruction, entry.Status(), strings.Join(entry.Rules, ", "))
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%d/%d instruction(s) covered by at least one rule\n", covered, len(entries))
+}
+
+// registryImageSize returns the compressed size of the layers of the image, read from its
+// manifest in the registry.
+func registryImageSize(image string) (int64, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return 0, err
+	}
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return 0, err
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
+
+// PrintSizes writes the estimated size of each instruction, the largest ones being flagged with *.
+func PrintSizes(out io.Writer, estimates []analyzer.SizeEstimate) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tINSTRUCTION\tSIZE\tDETAIL")
+	var total int64
+	for _, estimate := range estimates {
+		total += estimate.Bytes
+		size := formatSize(estimate.Bytes)
+		if estimate.Top {
+			size += " *"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", estimate.Line.Start, estimate.Instruction, size, estimate.Detail)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%s estimated, * marks the largest contributions\n", formatSize(total))
+}
+
+// formatSize formats the bytes with a binary unit, e.g. 12.5 MiB.
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
 func PrintNoArgsWarningMessage(command string) {
 	fmt.Printf(`
 No arg received. Did you forget to add the Containerfile or project path to analyze?
@@ -82,17 +693,117 @@ To find out more, run 'doa %s --help'
 `, command, command)
 }
 
-func PrintPrettifyJsonOutput(results []analyzer.Result) {
+// PrintConfigMapOutput writes the results as a ConfigMap to apply next to the workload running
+// the image, see manifests.Report.
+func PrintConfigMapOutput(cmd *cobra.Command, results []analyzer.Result, failOn analyzer.ResultSeverity) {
+	options := manifests.ReportOptions{
+		Image:     cmd.Flag("image").Value.String(),
+		Source:    cmd.Flag("file").Value.String(),
+		Namespace: cmd.Flag("report-namespace").Value.String(),
+		Workload:  cmd.Flag("report-workload").Value.String(),
+		FailOn:    failOn,
+	}
+	options.Name = options.Image
+	if options.Name == "" {
+		options.Name = filepath.Base(filepath.Dir(absolutePath(blame.Containerfile(options.Source))))
+	}
+	bytes, err := manifests.Report(results, options)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	fmt.Print(string(bytes))
+}
+
+// PrintPdfOutput writes the results as a PDF report, for the compliance and audit workflows
+// requiring a signed-off document, see pdf.Report.
+func PrintPdfOutput(target string, results []analyzer.Result, failOn analyzer.ResultSeverity, runtime analyzer.Runtime, coverage *analyzer.Coverage) {
+	report := pdf.Report{
+		Target:  target,
+		Date:    time.Now(),
+		Results: results,
+		FailOn:  failOn,
+		Score:   workspace.Score(results),
+		Version: version.Version,
+		Runtime: runtime,
+	}
+	if coverage != nil {
+		report.Coverage = coverage.Entries()
+	}
+	if err := pdf.Write(os.Stdout, report); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
+
+// PrintLintOutput writes the results in the format of the lint aggregation tools: checkstyle or tap.
+func PrintLintOutput(format string, file string, results []analyzer.Result) {
+	write := checkstyle.Write
+	if format == "tap" {
+		write = tap.Write
+	}
+	if err := write(os.Stdout, file, results); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
+
+// PrintRdjsonOutput writes the results in the Reviewdog Diagnostic Format, the changes of doa
+// convert being suggested when the Containerfile can be read.
+func PrintRdjsonOutput(file string, suggest bool, results []analyzer.Result) {
+	var content []byte
+	if suggest {
+		content, _ = os.ReadFile(file)
+	}
+	if err := rdjson.Write(os.Stdout, file, content, results); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
+
+// reportedFile returns the analyzed Containerfile, rather than the project directory holding it,
+// or the analyzed image.
+func reportedFile(cmd *cobra.Command) string {
+	if file := cmd.Flag("file").Value.String(); file != "" {
+		return blame.Containerfile(file)
+	}
+	return cmd.Flag("image").Value.String()
+}
+
+func absolutePath(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return path
+}
+
+// PrintPrettifyJsonOutput writes the results as JSON, validated against their schema when validate
+// is set, see doa schema.
+func PrintPrettifyJsonOutput(results []analyzer.Result, validate bool) {
 	var bytes []byte
 	var err error
 	if bytes, err = json.MarshalIndent(results, "", "    "); err != nil {
 		fmt.Println("error while converting output to json. Please try again without the output (--o) flag")
 	}
+	validateJsonOutput(schema.RESULTS, bytes, validate)
 	fmt.Println(string(bytes))
 }
 
-func PrintPrettifyOutput(results []analyzer.Result) {
-	for i, sug := range results {
-		fmt.Printf("%d - %s (%s): %s\n\n", i+1, sug.Name, sug.Severity, sug.Description)
+// PrintSummaryJsonOutput writes the summary of the results as JSON, along with the runtime detected
+// from the Containerfile if any.
+func PrintSummaryJsonOutput(results []analyzer.Result, failOn analyzer.ResultSeverity, runtime analyzer.Runtime, validate bool) {
+	summary := analyzer.SummarizeFailingOn(results, failOn)
+	summary.Runtime = runtime
+	var bytes []byte
+	var err error
+	if bytes, err = json.MarshalIndent(summary, "", "    "); err != nil {
+		fmt.Println("error while converting output to json. Please try again without the output (--o) flag")
+	}
+	validateJsonOutput(schema.SUMMARY, bytes, validate)
+	fmt.Println(string(bytes))
+}
+
+func validateJsonOutput(name string, bytes []byte, validate bool) {
+	if !validate {
+		return
+	}
+	if err := schema.Validate(name, bytes); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
 	}
 }
@@ -0,0 +1,119 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdAnnotate() *cobra.Command {
+	annotateCmd := &cobra.Command{
+		Use:     "annotate <Containerfile>",
+		Short:   "Print the Containerfile with the rules checking each instruction",
+		Long:    "Print the Containerfile with, below each instruction, the rules checking it and whether they passed or failed. Findings not bound to an instruction are listed at the end.",
+		Args:    cobra.ExactArgs(1),
+		Run:     doAnnotate,
+		Example: `  doa annotate /your/local/project/path/Containerfile`,
+	}
+	annotateCmd.Flags().Bool(
+		"no-color", false, "Disable colored output. Colors are also disabled when NO_COLOR is set or the output is not a terminal",
+	)
+	annotateCmd.Flags().String(
+		"lang", "", "Language of the reported issues, one of "+strings.Join(i18n.Languages(), ", ")+" (default en)",
+	)
+	return annotateCmd
+}
+
+func doAnnotate(cmd *cobra.Command, args []string) {
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	lang, _ := cmd.Flags().GetString("lang")
+	ctx, err := i18n.WithLanguage(context.Background(), lang)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to open %s - error %s", args[0], err))
+	}
+	defer file.Close()
+	ctx = analyzer.WithBuildContext(ctx, filepath.Dir(file.Name()))
+	annotated, err := analyzer.AnnotateReader(ctx, file.Name(), file)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	NewPrettifyPrinter(os.Stdout, UseColor(noColor, os.Stdout)).PrintAnnotated(annotated)
+}
+
+// PrintAnnotated writes the lines of the Containerfile followed, after the last line of each
+// instruction, by the rules which checked it.
+func (p PrettifyPrinter) PrintAnnotated(annotated *analyzer.Annotated) {
+	numberWidth := len(fmt.Sprint(len(annotated.Lines)))
+	indent := strings.Repeat(" ", numberWidth+3)
+	annotations := map[int][]analyzer.Annotation{}
+	for _, annotation := range annotated.Annotations {
+		annotations[annotation.Line.End] = append(annotations[annotation.Line.End], annotation)
+	}
+
+	for i, line := range annotated.Lines {
+		fmt.Fprintf(p.Out, "%s | %s\n", p.colorize(colorGray, fmt.Sprintf("%*d", numberWidth, i+1)), line)
+		for _, annotation := range annotations[i+1] {
+			p.printAnnotation(indent, annotation)
+		}
+	}
+
+	if len(annotated.Unlocated) > 0 {
+		fmt.Fprintln(p.Out)
+		fmt.Fprintln(p.Out, p.colorize(colorBold, "Image"))
+		for _, result := range annotated.Unlocated {
+			p.printFailed(indent, result)
+		}
+	}
+}
+
+func (p PrettifyPrinter) printAnnotation(indent string, annotation analyzer.Annotation) {
+	if len(annotation.Checked) == 0 && len(annotation.Failed) == 0 {
+		fmt.Fprintf(p.Out, "%s%s\n", indent, p.colorize(colorGray, "no rule checks "+annotation.Instruction))
+		return
+	}
+	for _, result := range annotation.Failed {
+		p.printFailed(indent, result)
+	}
+	for _, rule := range annotation.Passed() {
+		fmt.Fprintf(p.Out, "%s%s %s\n", indent, p.colorize(colorGreen, statusIcons[analyzer.StatusPass]), p.colorize(colorGray, rule.ID))
+	}
+}
+
+func (p PrettifyPrinter) printFailed(indent string, result analyzer.Result) {
+	fmt.Fprintf(p.Out, "%s%s %s (%s)\n", indent,
+		p.colorize(colorRed, statusIcons[analyzer.StatusFailed]),
+		p.colorize(colorBold, result.RuleID),
+		p.colorize(severityColors[result.Severity], string(result.Severity)),
+	)
+	for _, line := range descriptionLines(result.Description) {
+		fmt.Fprintf(p.Out, "%s  %s\n", indent, line)
+	}
+}
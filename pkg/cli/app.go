@@ -0,0 +1,132 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/app"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdApp() *cobra.Command {
+	appCmd := &cobra.Command{
+		Use:   "app [compose file or devfile]",
+		Short: "Analyze the Containerfiles of the containers forming one application",
+		Long: `Analyze the Containerfiles of the containers forming one application and check them against each other, e.g. the
+containers sharing a volume must agree on the ownership of its mount paths and the containers of a pod can't expose the
+same port. The containers are the services of a compose file having a build section, the container components of a
+devfile built by one of its image components, or the Containerfiles given with -f. The volumes declared with the same
+path by several Containerfiles given with -f are considered shared.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  doApp,
+		Example: `  doa app compose.yaml
+  doa app devfile.yaml -o json
+  doa app -f web/Containerfile -f worker/Containerfile --pod`,
+	}
+	appCmd.Flags().StringArrayP("file", "f", nil, "Containerfile of a container of the application, can be repeated")
+	appCmd.Flags().Bool("pod", false, "The containers given with -f run in the same pod")
+	appCmd.Flags().String("config", config.DEFAULT_FILE, "Configuration file")
+	appCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	appCmd.Flags().Bool("no-color", false, "Disable colored output")
+	return appCmd
+}
+
+func doApp(cmd *cobra.Command, args []string) {
+	files, _ := cmd.Flags().GetStringArray("file")
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+
+	var application *app.Application
+	switch {
+	case len(args) > 0 && len(files) == 0:
+		var err error
+		if application, err = app.Load(args[0]); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	case len(args) == 0 && len(files) > 1:
+		application = app.FromContainerfiles(files)
+		application.Pod, _ = cmd.Flags().GetBool("pod")
+	case len(args) == 0 && len(files) == 0:
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	case len(args) == 0:
+		RedirectErrorStringToStdErrAndExit("an application has at least 2 Containerfiles, use doa analyze for a single one\n")
+	default:
+		RedirectErrorStringToStdErrAndExit("only one of a compose file, a devfile and -f can be used\n")
+	}
+	for _, skipped := range application.Skipped {
+		fmt.Fprintf(os.Stderr, "skipping container %s: it isn't built from a local Containerfile\n", skipped)
+	}
+
+	configFile := cmd.Flag("config").Value.String()
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+	report, err := app.Analyze(ctx, application, cfg, analyzer.AnalyzePath)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+	} else {
+		noColor, _ := cmd.Flags().GetBool("no-color")
+		printer := NewPrettifyPrinter(os.Stdout, UseColor(noColor, os.Stdout))
+		printer.FailOn = cfg.FailOnSeverity()
+		var all []analyzer.Result
+		for _, container := range report.Containers {
+			fmt.Printf("%s (%s), score %d\n\n", printer.colorize(colorBold, container.Name), container.Containerfile, container.Score)
+			printer.Print(container.Results)
+			all = append(all, container.Results...)
+		}
+		fmt.Printf("%s, score %d\n\n", printer.colorize(colorBold, "Application"), report.Score)
+		printer.Print(report.Findings)
+		printer.PrintSummary(append(all, report.Findings...))
+	}
+	if report.Summary.Verdict == analyzer.VerdictFailed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,138 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/attestation"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/triage"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/version"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdAttest() *cobra.Command {
+	attestCmd := &cobra.Command{
+		Use:   "attest",
+		Short: "Produce a signed attestation of the analysis of an image",
+		Long: `Analyze the image, or the Containerfile it was built from, and print an in-toto attestation of the results bound to the
+digest of the image: the verdict, the score, the number of findings per severity and the failed findings, along with the
+doa and ruleset versions. With --key the attestation is a DSSE envelope signed with the cosign key, its password being read
+from the COSIGN_PASSWORD environment variable, which can be attached to the image with cosign attach attestation and
+verified before the image is deployed. Without --key the in-toto statement is printed, e.g. to be signed by cosign attest.`,
+		Args: cobra.NoArgs,
+		Run:  doAttest,
+		Example: `  doa attest -i quay.io/shop/web:1.2 --key cosign.key > web.att.json
+  cosign attach attestation --attestation web.att.json quay.io/shop/web:1.2
+  doa attest -i quay.io/shop/web@sha256:... -f Containerfile > statement.json`,
+	}
+	attestCmd.Flags().StringP("image", "i", "", "Image the attestation is bound to, analyzed unless --file is set")
+	attestCmd.Flags().StringP("file", "f", "", "Containerfile the image was built from, analyzed instead of the image")
+	attestCmd.Flags().String("key", "", "Cosign private key signing the attestation")
+	attestCmd.Flags().String(
+		"config", config.DEFAULT_FILE, "Configuration file customizing the rules, e.g. their severity",
+	)
+	attestCmd.Flags().String(
+		"triage-file", triage.DEFAULT_FILE, "Feedback file listing the findings marked as false positives, see doa triage",
+	)
+	return attestCmd
+}
+
+func doAttest(cmd *cobra.Command, args []string) {
+	image, _ := cmd.Flags().GetString("image")
+	if image == "" {
+		RedirectErrorStringToStdErrAndExit("flag --image is required, type --help for a list of all flags\n")
+	}
+	containerfile, _ := cmd.Flags().GetString("file")
+	digest, err := attestation.ResolveDigest(image)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	triageFile, err := triage.Load(cmd.Flag("triage-file").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+
+	var results []analyzer.Result
+	source := containerfile
+	if containerfile != "" {
+		results = analyzer.AnalyzePath(ctx, containerfile)
+	} else {
+		// the analyzed image is the attested one, whatever its tag points to now
+		source = digest.String()
+		results = analyzer.AnalyzeImage(ctx, source)
+	}
+	results, _ = triageFile.Suppress(cfg.Apply(results))
+
+	info := version.Get(analyzer.RULESET_VERSION)
+	predicate := attestation.NewPredicate(source, results, cfg.FailOnSeverity(), workspace.Score(results),
+		attestation.Analyzer{Name: "doa", Version: info.Version, RulesetVersion: info.RulesetVersion}, time.Now())
+	statement, err := attestation.NewStatement(digest, predicate)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	output, err := json.MarshalIndent(statement, "", "    ")
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if keyPath, _ := cmd.Flags().GetString("key"); keyPath != "" {
+		key, err := attestation.LoadKey(keyPath, []byte(os.Getenv(attestation.PASSWORD_ENV)))
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		envelope, err := statement.Envelope()
+		if err == nil {
+			err = envelope.Sign(key)
+		}
+		if err == nil {
+			// cosign reads one envelope per line
+			output, err = json.Marshal(envelope)
+		}
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	}
+	fmt.Println(string(output))
+}
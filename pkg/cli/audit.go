@@ -0,0 +1,129 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/audit"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdAudit() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Analyze the images run by the workloads of a namespace and report the fleet",
+		Long: `Analyze the images run by the Pods, Deployments, StatefulSets, DaemonSets, Jobs and CronJobs of the namespace and report,
+for each image, the workloads running it, the number of findings per severity and a score from 0 to 100, along with the
+totals and the average score of the fleet. The workloads are read with oc, or kubectl, from the cluster of the kubeconfig
+file and the images analyzed as doa images does.`,
+		Args: cobra.NoArgs,
+		Run:  doAudit,
+		Example: `  doa audit --namespace shop
+  doa audit -n shop --kubeconfig ~/.kube/prod -o json`,
+	}
+	auditCmd.Flags().StringP("namespace", "n", "", "Namespace of the workloads (default the namespace of the current context)")
+	auditCmd.Flags().String("kubeconfig", "", "Kubeconfig file of the cluster (default the one of oc or kubectl)")
+	auditCmd.Flags().Int("jobs", analyzer.DEFAULT_IMAGE_JOBS, "Number of images analyzed at the same time")
+	auditCmd.Flags().String("config", config.DEFAULT_FILE, "Configuration file customizing the rules, e.g. their severity")
+	auditCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	auditCmd.Flags().String("store", "", "SQLite database the runs of the images are recorded in, see doa history (default $"+store.STORE_ENV+")")
+	return auditCmd
+}
+
+func doAudit(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	if jobs < 1 {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("invalid value %d for flag jobs, at least one image must be analyzed at a time\n", jobs))
+	}
+	namespace, _ := cmd.Flags().GetString("namespace")
+	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+	usages, err := manifests.NamespaceImages(namespace, kubeconfig)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+
+	report := audit.Analyze(ctx, namespace, usages, cfg, func(ctx context.Context, images []string) []analyzer.ImageResults {
+		return analyzer.AnalyzeImages(ctx, images, jobs)
+	})
+
+	if path := storePath(cmd); path != "" {
+		var runs []store.Run
+		var results [][]analyzer.Result
+		for _, image := range report.Images {
+			runs = append(runs, store.Run{Target: image.Image, Project: namespace, Score: image.Score, Summary: image.Summary})
+			results = append(results, image.Results)
+		}
+		recordRuns(path, runs, results)
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tWORKLOADS\tCRITICAL\tHIGH\tMEDIUM\tLOW\tSCORE")
+	for _, image := range report.Images {
+		printAuditRow(w, image.Image, strings.Join(image.Workloads, " "), image.Summary, image.Score)
+	}
+	printAuditRow(w, "TOTAL", fmt.Sprintf("%d image(s)", len(report.Images)), report.Summary, report.Score)
+	w.Flush()
+}
+
+func printAuditRow(w *tabwriter.Writer, image, workloads string, summary analyzer.Summary, score int) {
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", image, workloads,
+		summary.BySeverity[analyzer.SeverityCritical], summary.BySeverity[analyzer.SeverityHigh],
+		summary.BySeverity[analyzer.SeverityMedium], summary.BySeverity[analyzer.SeverityLow], score)
+}
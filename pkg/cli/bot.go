@@ -0,0 +1,115 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/bot"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdBot() *cobra.Command {
+	botCmd := &cobra.Command{
+		Use:   "bot <owner/name>...",
+		Short: "Open pull requests fixing the Containerfiles of GitHub repositories",
+		Long: `Clone each GitHub repository, analyze its Containerfiles and apply the fixes of doa convert which don't require
+changing the application, i.e. giving the files to the root group and moving the permission fixes after the copies. The
+fixes are committed on a branch, force pushed, and a pull request is opened with the findings summary as its description,
+or the pull request already open for the branch is updated. The token of the environment variable authenticates the clone,
+the push and the pull request, it needs the contents and pull requests write permissions.`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doBot,
+		Example: `  GITHUB_TOKEN=<token> doa bot shop/web shop/worker
+  doa bot shop/web --base develop --branch doa/openshift --dry-run -o json`,
+	}
+	botCmd.Flags().String("token-env", "GITHUB_TOKEN", "Environment variable holding the GitHub token")
+	botCmd.Flags().String("base", "", "Branch the pull requests target (default the default branch of the repository)")
+	botCmd.Flags().String("branch", bot.DEFAULT_BRANCH, "Branch the fixes are pushed to")
+	botCmd.Flags().String("title", bot.DEFAULT_TITLE, "Title of the pull requests")
+	botCmd.Flags().Bool("dry-run", false, "Report the fixes without pushing them nor opening the pull requests")
+	botCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	return botCmd
+}
+
+func doBot(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "json" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unsupported output format %s, expected json\n", output))
+	}
+	options := bot.Options{}
+	tokenEnv, _ := cmd.Flags().GetString("token-env")
+	options.Token = os.Getenv(tokenEnv)
+	options.Base, _ = cmd.Flags().GetString("base")
+	options.Branch, _ = cmd.Flags().GetString("branch")
+	options.Title, _ = cmd.Flags().GetString("title")
+	options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	if options.Token == "" && !options.DryRun {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("$%s must hold a GitHub token to push the fixes and open the pull requests\n", tokenEnv))
+	}
+
+	results := []bot.Result{}
+	failed := false
+	for _, repository := range args {
+		options.Repository = repository
+		result, err := bot.Run(context.Background(), options)
+		if err != nil {
+			// the campaign goes on with the other repositories
+			fmt.Fprintf(os.Stderr, "%s: %s\n", repository, err)
+			failed = true
+			continue
+		}
+		results = append(results, *result)
+	}
+	if output == "json" {
+		bytes, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+	} else {
+		PrintBotResults(os.Stdout, results)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// PrintBotResults prints the fixed Containerfiles and the pull request of each repository.
+func PrintBotResults(out io.Writer, results []bot.Result) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tFIXED FILES\tFIXED FINDINGS\tPULL REQUEST")
+	for _, result := range results {
+		fixed := 0
+		for _, fix := range result.Fixes {
+			fixed += fix.Fixed
+		}
+		pullRequest := result.PullRequest
+		if pullRequest == "" {
+			pullRequest = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", result.Repository, len(result.Fixes), fixed, pullRequest)
+	}
+	w.Flush()
+}
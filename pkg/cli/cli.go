@@ -23,6 +23,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/policy"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
 	"github.com/spf13/cobra"
 )
 
@@ -49,6 +52,7 @@ func DockerOpenShiftAnalyzerCommands() *cobra.Command {
 		RunE:    ShowHelp,
 		Example: doaExample,
 	}
+	// completion is provided by our own command, see NewCmdCompletion
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	// Create a custom help function that will exit when we enter an invalid command, for example:
@@ -65,6 +69,33 @@ func DockerOpenShiftAnalyzerCommands() *cobra.Command {
 
 	rootCmdList := append([]*cobra.Command{},
 		NewCmdAnalyze(),
+		NewCmdAnnotate(),
+		NewCmdApp(),
+		NewCmdAttest(),
+		NewCmdAudit(),
+		NewCmdBot(),
+		NewCmdCompletion(),
+		NewCmdConvert(),
+		NewCmdCrossCheck(),
+		NewCmdDocs(),
+		NewCmdExplainCrash(),
+		NewCmdGenerate(),
+		NewCmdHistory(),
+		NewCmdImages(),
+		NewCmdInit(),
+		NewCmdMerge(),
+		NewCmdPolicy(),
+		NewCmdPublish(),
+		NewCmdRules(),
+		NewCmdSchema(),
+		NewCmdServe(),
+		NewCmdSimulate(),
+		NewCmdTestRun(),
+		NewCmdTriage(),
+		NewCmdUpdate(),
+		NewCmdVersion(),
+		NewCmdWaivers(),
+		NewCmdWorkspace(),
 	)
 
 	rootCmd.AddCommand(rootCmdList...)
@@ -77,6 +108,26 @@ func RedirectErrorStringToStdErrAndExit(err string) {
 	os.Exit(1)
 }
 
+// loadVerifier returns the verifier of the signatures configured in the trust file, nil when
+// there is no trust file.
+func loadVerifier() (*signature.Verifier, error) {
+	path, err := signature.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return signature.Load(path)
+}
+
+// loadConfigFile reads the configuration file, the OCI artifacts it extends being verified by the
+// trust file, see policy.Fetcher.
+func loadConfigFile(path string) (*config.Config, error) {
+	verifier, err := loadVerifier()
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadWith(path, policy.Fetcher(verifier))
+}
+
 // ShowHelp will show the help correctly (and whether or not the command is invalid...)
 // Taken from: https://github.com/redhat-developer/odo/blob/f55a4f0a7af4cd5f7c4e56dd70a66d38be0643cf/pkg/odo/cli/cli.go#L272
 func ShowHelp(cmd *cobra.Command, args []string) error {
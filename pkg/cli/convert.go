@@ -0,0 +1,89 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/convert"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdConvert() *cobra.Command {
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a Containerfile into an OpenShift friendly one",
+		Long: `Restructure the Containerfile into an OpenShift friendly shape: replace the base images by Universal Base Images,
+set a non-root USER, give the copied files to the root group, apply the permission fixes to all the copied files and
+replace the privileged ports. The converted Containerfile is written along with the report of every transformation.`,
+		Args: cobra.NoArgs,
+		Run:  doConvert,
+		Example: `  doa convert -f Dockerfile > Dockerfile.openshift
+  doa convert -f Dockerfile --output Containerfile --report report.json`,
+	}
+	convertCmd.Flags().StringP(
+		"file", "f", "", "Containerfile to convert",
+	)
+	convertCmd.Flags().StringP(
+		"output", "o", "-", "File to write the converted Containerfile to, - writes to the standard output",
+	)
+	convertCmd.Flags().String(
+		"report", "", "File to write the transformations to as JSON, they are printed to stderr otherwise",
+	)
+	return convertCmd
+}
+
+func doConvert(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to read %s - error %s", file, err))
+	}
+	result, err := convert.Convert(content)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		os.Stdout.Write(result.Content)
+	} else if err := os.WriteFile(output, result.Content, 0644); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to write %s - error %s", output, err))
+	}
+
+	report, _ := cmd.Flags().GetString("report")
+	if report == "" {
+		for _, transformation := range result.Transformations {
+			fmt.Fprintf(os.Stderr, "line %d: [%s] %s\n", transformation.Line, transformation.Kind, transformation.Description)
+		}
+		return
+	}
+	bytes, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if err := os.WriteFile(report, append(bytes, '\n'), 0644); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to write %s - error %s", report, err))
+	}
+}
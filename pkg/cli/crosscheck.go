@@ -0,0 +1,142 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdCrossCheck() *cobra.Command {
+	crossCheckCmd := &cobra.Command{
+		Use:   "crosscheck",
+		Short: "Check the container specs of the manifests against their Containerfiles",
+		Long: `Render the manifests, a Helm chart or a Kustomize overlay, and check every container spec against the Containerfile
+its image is built from: the securityContext must be compatible with the USER of the image and the container ports must
+match the exposed ones. The Containerfile of each image is set in the images section of the configuration file.`,
+		Args: cobra.NoArgs,
+		Run:  doCrossCheck,
+		Example: `  doa crosscheck --manifests deploy.yaml
+  doa crosscheck --helm ./chart --values values.yaml --values values-prod.yaml
+  doa crosscheck --kustomize overlays/prod`,
+	}
+	crossCheckCmd.Flags().StringArrayP(
+		"manifests", "m", nil, "Manifests file, can be repeated",
+	)
+	crossCheckCmd.Flags().String(
+		"helm", "", "Helm chart to render with helm template",
+	)
+	crossCheckCmd.Flags().StringArray(
+		"values", nil, "Values file of the Helm chart, can be repeated",
+	)
+	crossCheckCmd.Flags().String(
+		"kustomize", "", "Kustomize overlay directory to build",
+	)
+	crossCheckCmd.Flags().String(
+		"config", config.DEFAULT_FILE, "Configuration file mapping the images to their Containerfile",
+	)
+	return crossCheckCmd
+}
+
+func doCrossCheck(cmd *cobra.Command, args []string) {
+	files, _ := cmd.Flags().GetStringArray("manifests")
+	chart, _ := cmd.Flags().GetString("helm")
+	values, _ := cmd.Flags().GetStringArray("values")
+	overlay, _ := cmd.Flags().GetString("kustomize")
+
+	var content []byte
+	switch {
+	case len(files) > 0 && chart == "" && overlay == "":
+		for _, file := range files {
+			bytes, err := os.ReadFile(file)
+			if err != nil {
+				RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to read %s - error %s", file, err))
+			}
+			content = append(content, []byte("\n---\n")...)
+			content = append(content, bytes...)
+		}
+	case chart != "" && len(files) == 0 && overlay == "":
+		rendered, err := manifests.RenderHelm(chart, values)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		content = rendered
+	case overlay != "" && len(files) == 0 && chart == "":
+		rendered, err := manifests.RenderKustomize(overlay)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		content = rendered
+	case len(files) == 0 && chart == "" && overlay == "":
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	default:
+		RedirectErrorStringToStdErrAndExit("only one of --manifests, --helm and --kustomize can be used\n")
+	}
+	if len(values) > 0 && chart == "" {
+		RedirectErrorStringToStdErrAndExit("--values can only be used with --helm\n")
+	}
+
+	configFile := cmd.Flag("config").Value.String()
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	containers, err := manifests.Containers(content)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	images := map[string]*manifests.Image{}
+	found := 0
+	for _, container := range containers {
+		file, ok := cfg.Containerfile(container.Image)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "skipping %s container %s: no Containerfile is configured for %s in %s\n", container.Workload, container.Name, container.Image, configFile)
+			continue
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(configFile), file)
+		}
+		image, ok := images[file]
+		if !ok {
+			bytes, err := os.ReadFile(file)
+			if err != nil {
+				RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to read %s - error %s", file, err))
+			}
+			if image, err = manifests.Inspect(bytes); err != nil {
+				RedirectErrorStringToStdErrAndExit(fmt.Sprintf("%s: %s", file, err))
+			}
+			images[file] = image
+		}
+		for _, inconsistency := range manifests.Check(container, image) {
+			fmt.Println(inconsistency)
+			found++
+		}
+	}
+	if found > 0 {
+		fmt.Printf("%d inconsistency(ies) found\n", found)
+		os.Exit(1)
+	}
+	fmt.Println("The container specs are consistent with their Containerfiles")
+}
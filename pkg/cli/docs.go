@@ -0,0 +1,61 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+func NewCmdDocs() *cobra.Command {
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate documentation for doa",
+		Args:  cobra.NoArgs,
+	}
+
+	manCmd := &cobra.Command{
+		Use:     "man",
+		Short:   "Generate the man pages of doa and its commands",
+		Long:    "Generate the man pages of doa and its commands. A page (section 1) is written for each command in the output directory.",
+		Args:    cobra.NoArgs,
+		RunE:    doDocsMan,
+		Example: `  doa docs man --dir /usr/local/share/man/man1`,
+	}
+	manCmd.PersistentFlags().StringP(
+		"dir", "d", ".", "Directory where the man pages are written",
+	)
+
+	docsCmd.AddCommand(manCmd)
+	return docsCmd
+}
+
+func doDocsMan(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	now := time.Now()
+	root := cmd.Root()
+	root.DisableAutoGenTag = true
+	return doc.GenManTree(root, &doc.GenManHeader{Section: "1", Date: &now, Source: "doa", Manual: "User Commands"}, dir)
+}
@@ -0,0 +1,144 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/crash"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdExplainCrash() *cobra.Command {
+	explainCrashCmd := &cobra.Command{
+		Use:   "explain-crash",
+		Short: "Map the errors of the logs of a crashing container to the Containerfile",
+		Long: `Read the logs of the container of a pod, from its previous instance when it was restarted as in CrashLoopBackOff,
+or from a file, and report the permission denied, read-only filesystem, port binding and user lookup errors along with the
+rules and the lines of the Containerfile most likely responsible for them. The logs of a pod are read with oc, or kubectl
+when oc is not installed.`,
+		Args: cobra.NoArgs,
+		Run:  doExplainCrash,
+		Example: `  doa explain-crash -f Containerfile --pod web-7d9c8b6f4-x2x9q -n my-project
+  doa explain-crash -f Containerfile --log crash.log -o json`,
+	}
+	explainCrashCmd.Flags().StringP("file", "f", "", "Containerfile of the image of the container, or the directory holding it")
+	explainCrashCmd.Flags().String("pod", "", "Pod whose logs are read")
+	explainCrashCmd.Flags().StringP("namespace", "n", "", "Namespace of the pod (default the one of the current context)")
+	explainCrashCmd.Flags().StringP("container", "c", "", "Container of the pod (default the first one)")
+	explainCrashCmd.Flags().String("log", "", "File holding the logs of the container, - for the standard input")
+	explainCrashCmd.Flags().String("context", "", "Build context directory (default the directory of the Containerfile)")
+	explainCrashCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	return explainCrashCmd
+}
+
+func doExplainCrash(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	pod, _ := cmd.Flags().GetString("pod")
+	logFile, _ := cmd.Flags().GetString("log")
+	if file == "" || (pod == "" && logFile == "") {
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	}
+	if pod != "" && logFile != "" {
+		RedirectErrorStringToStdErrAndExit("--pod and --log can't be used together\n")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "json" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unsupported output format %s, expected json\n", output))
+	}
+
+	var logs []byte
+	var err error
+	switch {
+	case pod != "":
+		namespace, _ := cmd.Flags().GetString("namespace")
+		container, _ := cmd.Flags().GetString("container")
+		logs, err = manifests.PodLogs(pod, namespace, container)
+	case logFile == "-":
+		logs, err = io.ReadAll(os.Stdin)
+	default:
+		logs, err = os.ReadFile(logFile)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		dir := file
+		file = filepath.Join(dir, "Dockerfile")
+		if _, err := os.Stat(file); err != nil {
+			file = filepath.Join(dir, "Containerfile")
+		}
+	}
+	dir, _ := cmd.Flags().GetString("context")
+	if dir == "" {
+		dir = filepath.Dir(file)
+	}
+	causes, err := crash.ExplainFile(analyzer.WithBuildContext(context.Background(), dir), file, logs)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if output == "json" {
+		bytes, err := json.MarshalIndent(causes, "", "  ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	PrintCauses(os.Stdout, causes)
+}
+
+// PrintCauses prints each error of the logs followed by the findings most likely responsible for
+// it, or the rules to check when the analysis found none.
+func PrintCauses(out io.Writer, causes []crash.Cause) {
+	if len(causes) == 0 {
+		fmt.Fprintln(out, "No permission, port binding or user lookup error found in the logs")
+		return
+	}
+	for i, cause := range causes {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s: %s\n", cause.Kind, cause.Message)
+		if cause.Line != nil {
+			fmt.Fprintf(out, "  %s is set up at line %d\n", cause.Path, cause.Line.Start)
+		}
+		if len(cause.Results) == 0 {
+			fmt.Fprintf(out, "  no finding, check the rules %s\n", strings.Join(cause.Rules, ", "))
+			continue
+		}
+		for _, result := range cause.Results {
+			line := "-"
+			if result.Line != nil {
+				line = fmt.Sprint(result.Line.Start)
+			}
+			fmt.Fprintf(out, "  line %s [%s] %s\n", line, result.RuleID, result.Description)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdGenerate() *cobra.Command {
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate files derived from a Containerfile",
+		Args:  cobra.NoArgs,
+	}
+	generateCmd.AddCommand(newCmdGenerateManifests())
+	return generateCmd
+}
+
+func newCmdGenerateManifests() *cobra.Command {
+	manifestsCmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "Generate the Kubernetes/OpenShift manifests deploying the image",
+		Long: `Generate a Deployment (or a DeploymentConfig), a Service and a Route consistent with the Containerfile: the ports
+come from EXPOSE, the probes from HEALTHCHECK and the securityContext from the USER of the final stage.`,
+		Args: cobra.NoArgs,
+		Run:  doGenerateManifests,
+		Example: `  doa generate manifests -f Containerfile --image quay.io/org/app:1.0 > openshift.yaml
+  doa generate manifests -f Dockerfile --kind deploymentconfig --replicas 2 --output manifests.yaml`,
+	}
+	manifestsCmd.Flags().StringP(
+		"file", "f", "", "Containerfile the image is built from",
+	)
+	manifestsCmd.Flags().String(
+		"name", "", "Name of the resources, defaults to the name of the Containerfile directory",
+	)
+	manifestsCmd.Flags().String(
+		"image", "", "Image to deploy, defaults to <name>:latest",
+	)
+	manifestsCmd.Flags().String(
+		"kind", string(manifests.KindDeployment), "Kind of the workload: deployment, deploymentconfig",
+	)
+	manifestsCmd.Flags().Int(
+		"replicas", 1, "Number of replicas",
+	)
+	manifestsCmd.Flags().StringP(
+		"output", "o", "-", "File to write the manifests to, - writes to the standard output",
+	)
+	return manifestsCmd
+}
+
+func doGenerateManifests(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to read %s - error %s", file, err))
+	}
+	image, err := manifests.Inspect(content)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	value, _ := cmd.Flags().GetString("kind")
+	kind, err := manifests.ParseKind(value)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	replicas, _ := cmd.Flags().GetInt("replicas")
+	if replicas < 1 {
+		RedirectErrorStringToStdErrAndExit("the number of replicas must be at least 1\n")
+	}
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		if dir, err := filepath.Abs(filepath.Dir(file)); err == nil {
+			name = filepath.Base(dir)
+		}
+	}
+	name = manifests.Name(name)
+	imageName, _ := cmd.Flags().GetString("image")
+	if imageName == "" {
+		imageName = name + ":latest"
+		fmt.Fprintf(os.Stderr, "warning: no --image given, the manifests deploy %s\n", imageName)
+	}
+
+	generated, err := manifests.Generate(image, manifests.Options{Name: name, Image: imageName, Kind: kind, Replicas: replicas})
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	for _, warning := range generated.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		os.Stdout.Write(generated.Content)
+	} else if err := os.WriteFile(output, generated.Content, 0644); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to write %s - error %s", output, err))
+	}
+}
@@ -0,0 +1,169 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdHistory() *cobra.Command {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Query the findings of the runs recorded with --store",
+		Long: `Query the runs of doa analyze and doa workspace recorded in the SQLite database set by --store, or by the ` + store.STORE_ENV + `
+environment variable, to follow the trend of the findings. The runs are grouped with --by:
+  runs     lists the runs, the most recent first
+  rules    counts the findings of each rule, the most frequent first
+  day      averages the score and the number of findings per day
+  week     averages the score and the number of findings per week`,
+		Args: cobra.NoArgs,
+		Run:  doHistory,
+		Example: `  doa history --store doa.db --target web/Containerfile
+  doa history --by rules --since 30d
+  doa history --by week --rule user-root -o json`,
+	}
+	historyCmd.Flags().String("store", "", "SQLite database the runs are recorded in (default $"+store.STORE_ENV+")")
+	historyCmd.Flags().String("target", "", "Only the runs of this Containerfile or image")
+	historyCmd.Flags().String("project", "", "Only the runs of this project of a workspace")
+	historyCmd.Flags().String("rule", "", "Only the runs having findings of this rule")
+	historyCmd.Flags().String("since", "", "Only the runs since this date (2006-01-02) or duration (e.g. 12h, 30d, 8w)")
+	historyCmd.Flags().String("by", "runs", "Grouping of the runs: runs, rules, day, week")
+	historyCmd.Flags().Int("limit", 20, "Maximum number of runs listed, 0 for all")
+	historyCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	return historyCmd
+}
+
+func doHistory(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+	path := storePath(cmd)
+	if path == "" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("no store, set it with --store or %s\n", store.STORE_ENV))
+	}
+	if _, err := os.Stat(path); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to open the store %s - error %s\n", path, err))
+	}
+	query := store.Query{}
+	query.Target, _ = cmd.Flags().GetString("target")
+	query.Project, _ = cmd.Flags().GetString("project")
+	query.RuleID, _ = cmd.Flags().GetString("rule")
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		var err error
+		if query.Since, err = store.ParseSince(since, time.Now()); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	}
+	s, err := store.Open(path)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	defer s.Close()
+
+	var report interface{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	by, _ := cmd.Flags().GetString("by")
+	switch by {
+	case "runs":
+		query.Limit, _ = cmd.Flags().GetInt("limit")
+		runs, err := s.Runs(query)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		report = runs
+		fmt.Fprintln(w, "DATE\tTARGET\tPROJECT\tVERDICT\tCRITICAL\tHIGH\tMEDIUM\tLOW\tSCORE")
+		for _, run := range runs {
+			project := run.Project
+			if project == "" {
+				project = "-"
+			}
+			counts := run.Summary.BySeverity
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n", run.Date.Local().Format("2006-01-02 15:04"), run.Target, project, run.Summary.Verdict,
+				counts[analyzer.SeverityCritical], counts[analyzer.SeverityHigh], counts[analyzer.SeverityMedium], counts[analyzer.SeverityLow], run.Score)
+		}
+	case "rules":
+		counts, err := s.Rules(query)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		report = counts
+		fmt.Fprintln(w, "RULE\tSEVERITY\tFINDINGS\tRUNS\tLAST SEEN")
+		for _, count := range counts {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", count.RuleID, count.Severity, count.Findings, count.Runs, count.LastSeen.Local().Format("2006-01-02 15:04"))
+		}
+	case string(store.PeriodDay), string(store.PeriodWeek):
+		points, err := s.Trend(query, store.Period(by))
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		report = points
+		fmt.Fprintln(w, "PERIOD\tRUNS\tFAILED\tFINDINGS\tSCORE")
+		for _, point := range points {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%d\n", point.Period, point.Runs, point.Failed, point.Findings, point.Score)
+		}
+	default:
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag by, expected runs, rules, day or week\n", by))
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	w.Flush()
+}
+
+// storePath returns the database set by --store or by the DOA_STORE environment variable, empty
+// when the runs are not recorded.
+func storePath(cmd *cobra.Command) string {
+	if path, _ := cmd.Flags().GetString("store"); path != "" {
+		return path
+	}
+	return os.Getenv(store.STORE_ENV)
+}
+
+// recordRuns records the runs in the store, a failure only being reported as the analysis
+// succeeded.
+func recordRuns(path string, runs []store.Run, results [][]analyzer.Result) {
+	s, err := store.Open(path)
+	if err == nil {
+		defer s.Close()
+		for i := range runs {
+			if err = s.Record(&runs[i], results[i]); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "the run is not recorded: %s\n", err)
+	}
+}
@@ -0,0 +1,143 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// imageReport is an image of the JSON output of doa images
+type imageReport struct {
+	Image   string            `json:"image"`
+	Score   int               `json:"score"`
+	Summary analyzer.Summary  `json:"summary"`
+	Results []analyzer.Result `json:"results"`
+}
+
+func NewCmdImages() *cobra.Command {
+	imagesCmd := &cobra.Command{
+		Use:   "images [image...]",
+		Short: "Analyze several images concurrently and report them per image",
+		Long: `Analyze the images, and the images of the tags of an ImageStream, and report for each of them the number of findings
+per severity and a score from 0 to 100. The images are analyzed as doa analyze --image does, several of them at the same time
+since their configs are pulled from the registries, the configs of the tags pointing to the same image being pulled once.`,
+		Run: doImages,
+		Example: `  doa images quay.io/shop/web:1.2 quay.io/shop/api:3.0
+  doa images --imagestream web -n shop --jobs 8 -o json`,
+	}
+	imagesCmd.Flags().String("imagestream", "", "ImageStream whose tags are analyzed, read from the cluster of the current context")
+	imagesCmd.Flags().StringP("namespace", "n", "", "Namespace of the ImageStream (default the namespace of the current context)")
+	imagesCmd.Flags().Int("jobs", analyzer.DEFAULT_IMAGE_JOBS, "Number of images analyzed at the same time")
+	imagesCmd.Flags().String("config", config.DEFAULT_FILE, "Configuration file customizing the rules, e.g. their severity")
+	imagesCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	imagesCmd.Flags().String("store", "", "SQLite database the runs of the images are recorded in, see doa history (default $"+store.STORE_ENV+")")
+	return imagesCmd
+}
+
+func doImages(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+	images := args
+	if imageStream, _ := cmd.Flags().GetString("imagestream"); imageStream != "" {
+		namespace, _ := cmd.Flags().GetString("namespace")
+		streamImages, err := manifests.ImageStreamImages(imageStream, namespace)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		images = append(images, streamImages...)
+	}
+	if len(images) == 0 {
+		RedirectErrorStringToStdErrAndExit("no image to analyze, pass the images or an ImageStream with --imagestream, type --help for a list of all flags\n")
+	}
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	if jobs < 1 {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("invalid value %d for flag jobs, at least one image must be analyzed at a time\n", jobs))
+	}
+
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+
+	var reports []imageReport
+	for _, analyzed := range analyzer.AnalyzeImages(ctx, images, jobs) {
+		results := cfg.Apply(analyzed.Results)
+		reports = append(reports, imageReport{
+			Image:   analyzed.Image,
+			Score:   workspace.Score(results),
+			Summary: analyzer.SummarizeFailingOn(results, cfg.FailOnSeverity()),
+			Results: results,
+		})
+	}
+
+	if path := storePath(cmd); path != "" {
+		var runs []store.Run
+		var results [][]analyzer.Result
+		for _, report := range reports {
+			runs = append(runs, store.Run{Target: report.Image, Score: report.Score, Summary: report.Summary})
+			results = append(results, report.Results)
+		}
+		recordRuns(path, runs, results)
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(reports, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tCRITICAL\tHIGH\tMEDIUM\tLOW\tSCORE")
+	for _, report := range reports {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n", report.Image,
+			report.Summary.BySeverity[analyzer.SeverityCritical], report.Summary.BySeverity[analyzer.SeverityHigh],
+			report.Summary.BySeverity[analyzer.SeverityMedium], report.Summary.BySeverity[analyzer.SeverityLow], report.Score)
+	}
+	w.Flush()
+}
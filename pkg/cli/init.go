@@ -0,0 +1,105 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/scaffold"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdInit() *cobra.Command {
+	var runtimes []string
+	for _, runtime := range scaffold.Runtimes() {
+		runtimes = append(runtimes, string(runtime))
+	}
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Generate an OpenShift compliant Containerfile",
+		Long: `Generate a starter Containerfile for a runtime which follows the rules checked by doa analyze: a UBI base image,
+a non-root numeric USER of the root group, an unprivileged port and an exec form command.
+
+Without --runtime, the runtime is detected from the base image, the installed packages and the command of an
+existing Containerfile, set by --from or found in the current directory, e.g. to rewrite it for OpenShift.`,
+		Args: cobra.NoArgs,
+		Run:  doInit,
+		Example: `  doa init --runtime nodejs
+  doa init --runtime java --port 8443 --output Containerfile
+  doa init --runtime go --output -
+  doa init --from legacy/Dockerfile --output Containerfile`,
+	}
+	initCmd.Flags().String(
+		"runtime", "", fmt.Sprintf("Runtime of the application: %s", strings.Join(runtimes, ", ")),
+	)
+	initCmd.Flags().String(
+		"from", "", "Existing Containerfile to detect the runtime from when --runtime is not set, the Dockerfile or Containerfile of the current directory by default",
+	)
+	initCmd.Flags().Int(
+		"port", scaffold.DEFAULT_PORT, "Port the application listens on",
+	)
+	initCmd.Flags().StringP(
+		"output", "o", "Dockerfile", "File to write, - writes to the standard output",
+	)
+	initCmd.Flags().Bool(
+		"force", false, "Overwrite the output file if it exists",
+	)
+	return initCmd
+}
+
+func doInit(cmd *cobra.Command, args []string) {
+	value, _ := cmd.Flags().GetString("runtime")
+	if value == "" {
+		from, _ := cmd.Flags().GetString("from")
+		if from == "" {
+			from = "."
+		}
+		stack, err := analyzer.DetectRuntimePath(from)
+		if err != nil || stack.Runtime == "" {
+			RedirectErrorStringToStdErrAndExit("a runtime is required, use the --runtime flag\n")
+		}
+		fmt.Fprintf(os.Stderr, "runtime %s detected from %s\n", stack.Runtime, strings.Join(stack.Evidence, ", "))
+		value = string(stack.Runtime)
+	}
+	runtime, err := scaffold.ParseRuntime(value)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	port, _ := cmd.Flags().GetInt("port")
+	content, err := scaffold.Generate(runtime, scaffold.Options{Port: port})
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(output); err == nil && !force {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("%s already exists, use --force to overwrite it\n", output))
+	}
+	if err := os.WriteFile(output, content, 0644); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to write %s - error %s", output, err))
+	}
+	fmt.Printf("%s written, analyze it with doa analyze -f %s\n", output, output)
+}
@@ -0,0 +1,175 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/checkstyle"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/merge"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/pdf"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/rdjson"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/tap"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/version"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func NewCmdMerge() *cobra.Command {
+	mergeCmd := &cobra.Command{
+		Use:   "merge [target=]report.json...",
+		Short: "Merge the reports of sharded CI jobs into one report",
+		Long: `Merge the reports written by doa analyze -o json, e.g. by the CI jobs analyzing a share of the Containerfiles
+each, into one report. The results found by several reports of the same target are only kept once and the merged
+results are summarized again. The target of a report, the Containerfile or the image it was written for, is given
+before its path, e.g. web/Containerfile=web.json, and defaults to --target.`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doMerge,
+		Example: `  doa merge shard-1.json shard-2.json shard-3.json
+  doa merge web/Containerfile=web.json api/Containerfile=api.json -o checkstyle > doa.xml`,
+	}
+	mergeCmd.Flags().String("target", "Containerfile", "Target of the reports given without one")
+	mergeCmd.Flags().StringP("output", "o", "", "Specify output format, supported formats: json, checkstyle, tap, rdjson, pdf")
+	mergeCmd.Flags().Bool("summary-only", false, "Print only the number of issues found and the verdict")
+	mergeCmd.Flags().String("config", config.DEFAULT_FILE, "Configuration file, read for fail-on and exit-codes")
+	mergeCmd.Flags().Bool("no-color", false, "Disable colored output")
+	return mergeCmd
+}
+
+func doMerge(cmd *cobra.Command, args []string) {
+	format := strings.ToLower(cmd.Flag("output").Value.String())
+	switch format {
+	case "", "json", "checkstyle", "tap", "rdjson", "pdf":
+	default:
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", format))
+	}
+	if format == "pdf" && term.IsTerminal(int(os.Stdout.Fd())) {
+		RedirectErrorStringToStdErrAndExit("the PDF report is written to the standard output, redirect it to a file, e.g. doa merge -o pdf > report.pdf\n")
+	}
+	cfg, err := loadConfigFile(cmd.Flag("config").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	target := cmd.Flag("target").Value.String()
+	var reports []merge.Report
+	for _, arg := range args {
+		report, err := merge.Load(arg, target)
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		reports = append(reports, report)
+	}
+	reports = merge.Merge(reports)
+	results := merge.Results(reports)
+	failOn := cfg.FailOnSeverity()
+
+	summaryOnly, _ := cmd.Flags().GetBool("summary-only")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	printer := NewPrettifyPrinter(os.Stdout, UseColor(noColor, os.Stdout))
+	printer.FailOn = failOn
+	switch {
+	case summaryOnly && format == "json":
+		PrintSummaryJsonOutput(results, failOn, "", false)
+	case summaryOnly:
+		printer.PrintSummary(results)
+	case format == "json" && len(reports) == 1:
+		PrintPrettifyJsonOutput(results, false)
+	case format == "json":
+		// the results of several targets can't be told apart in the output of doa analyze
+		bytes, err := json.MarshalIndent(reports, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+	case format == "checkstyle":
+		report := checkstyle.NewReport("", nil)
+		for _, merged := range reports {
+			report.Add(merged.Target, merged.Results)
+		}
+		err = checkstyle.WriteReport(os.Stdout, report)
+	case format == "tap":
+		targets := []tap.Target{}
+		for _, merged := range reports {
+			targets = append(targets, tap.Target{File: merged.Target, Results: merged.Results})
+		}
+		err = tap.WriteTargets(os.Stdout, targets)
+	case format == "rdjson":
+		result := rdjson.NewResult("", nil, nil)
+		for _, merged := range reports {
+			content, _ := os.ReadFile(merged.Target)
+			result.Diagnostics = append(result.Diagnostics, rdjson.NewResult(merged.Target, content, merged.Results).Diagnostics...)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	case format == "pdf":
+		err = pdf.Write(os.Stdout, mergedPdfReport(reports, results, failOn))
+	default:
+		for _, merged := range reports {
+			fmt.Printf("%s, score %d\n\n", printer.colorize(colorBold, merged.Target), workspace.Score(merged.Results))
+			printer.Print(merged.Results)
+		}
+		printer.PrintSummary(results)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	if len(cfg.ExitCodes) > 0 {
+		os.Exit(cfg.ExitCode(results))
+	}
+	if analyzer.SummarizeFailingOn(results, failOn).Verdict == analyzer.VerdictFailed {
+		os.Exit(1)
+	}
+}
+
+// mergedPdfReport returns the PDF report of the merged results, their description being prefixed
+// by their target when there are several targets.
+func mergedPdfReport(reports []merge.Report, results []analyzer.Result, failOn analyzer.ResultSeverity) pdf.Report {
+	report := pdf.Report{
+		Target:  reports[0].Target,
+		Date:    time.Now(),
+		Results: results,
+		FailOn:  failOn,
+		Score:   workspace.Score(results),
+		Version: version.Version,
+	}
+	if len(reports) == 1 {
+		return report
+	}
+	targets := []string{}
+	report.Results = []analyzer.Result{}
+	for _, merged := range reports {
+		targets = append(targets, merged.Target)
+		for _, result := range merged.Results {
+			result.Description = merged.Target + ": " + result.Description
+			report.Results = append(report.Results, result)
+		}
+	}
+	report.Target = strings.Join(targets, ", ")
+	return report
+}
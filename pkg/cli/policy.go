@@ -0,0 +1,96 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdPolicy() *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Distribute the configuration of doa as an OCI artifact",
+		Long: `Push a configuration file to an OCI registry as a versioned policy and pull it in the projects, so that every project
+runs the exact same rules. A pulled policy is used by doa analyze --policy.
+When a trust file is configured (~/.config/doa/trust.yaml or DOA_TRUST_FILE), the pulled policies must have a trusted cosign signature.`,
+		Args: cobra.NoArgs,
+	}
+
+	pushCmd := &cobra.Command{
+		Use:     "push <reference>",
+		Short:   "Push a configuration file as a policy",
+		Args:    cobra.ExactArgs(1),
+		Run:     doPolicyPush,
+		Example: `  doa policy push ghcr.io/org/openshift-policy:v3 -f .doa.yaml`,
+	}
+	pushCmd.Flags().StringP("file", "f", config.DEFAULT_FILE, "Configuration file to push")
+
+	pullCmd := &cobra.Command{
+		Use:   "pull <reference>",
+		Short: "Pull a policy",
+		Args:  cobra.ExactArgs(1),
+		Run:   doPolicyPull,
+		Example: `  doa policy pull ghcr.io/org/openshift-policy:v3
+  doa policy pull ghcr.io/org/openshift-policy:v3 --output .doa.yaml`,
+	}
+	pullCmd.Flags().StringP("output", "o", "", "File to write the policy to, it's stored in the policy cache otherwise")
+
+	policyCmd.AddCommand(pushCmd, pullCmd)
+	return policyCmd
+}
+
+func doPolicyPush(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	content, err := os.ReadFile(file)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to read %s - error %s", file, err))
+	}
+	digest, err := policy.Push(args[0], content)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	fmt.Printf("%s pushed to %s@%s\n", file, args[0], digest)
+}
+
+func doPolicyPull(cmd *cobra.Command, args []string) {
+	verifier, err := loadVerifier()
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	bundle, err := policy.Pull(args[0], verifier)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if _, err := bundle.Config(); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		if output, err = policy.Store(bundle); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+	} else if err := os.WriteFile(output, bundle.Content, 0644); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to write %s - error %s", output, err))
+	}
+	fmt.Printf("%s@%s pulled to %s\n", args[0], bundle.Digest, output)
+}
@@ -0,0 +1,210 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/blame"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"golang.org/x/term"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+	colorGray   = "\x1b[90m"
+)
+
+var severityColors = map[analyzer.ResultSeverity]string{
+	analyzer.SeverityCritical: colorBold + colorRed,
+	analyzer.SeverityHigh:     colorRed,
+	analyzer.SeverityMedium:   colorYellow,
+	analyzer.SeverityLow:      colorCyan,
+}
+
+var statusIcons = map[analyzer.ResultStatus]string{
+	analyzer.StatusFailed: "✖",
+	analyzer.StatusPass:   "✔",
+}
+
+// PrettifyPrinter writes results as aligned, human readable rows. Colors are only
+// emitted when enabled, see UseColor.
+type PrettifyPrinter struct {
+	Out   io.Writer
+	Color bool
+	// FailOn is the least severe failed finding failing the verdict, low when empty
+	FailOn analyzer.ResultSeverity
+	// Runtime is the runtime detected from the Containerfile, the failed findings are followed by
+	// the remediations tailored to it
+	Runtime analyzer.RuntimeStack
+}
+
+func NewPrettifyPrinter(out io.Writer, color bool) PrettifyPrinter {
+	return PrettifyPrinter{
+		Out:   out,
+		Color: color,
+	}
+}
+
+// UseColor reports whether colored output should be written to the given file. Colors are
+// disabled by the --no-color flag, by the NO_COLOR environment variable (https://no-color.org)
+// or when the output is not a terminal.
+func UseColor(noColor bool, file *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(file.Fd()))
+}
+
+func (p PrettifyPrinter) Print(results []analyzer.Result) {
+	indexWidth := len(fmt.Sprint(len(results)))
+	severityWidth := 0
+	for _, res := range results {
+		severityWidth = maxInt(severityWidth, len(res.Severity))
+	}
+
+	for i, res := range results {
+		icon, ok := statusIcons[res.Status]
+		if !ok {
+			icon = "•"
+		}
+		iconColor, severityColor := colorRed, severityColors[res.Severity]
+		if res.Status == analyzer.StatusPass {
+			iconColor, severityColor = colorGreen, colorGray
+		}
+		confidence := ""
+		if res.Confidence != "" && res.Confidence != analyzer.ConfidenceHigh {
+			confidence = " " + p.colorize(colorGray, fmt.Sprintf("(%s confidence)", res.Confidence))
+		}
+		fmt.Fprintf(p.Out, "%s %s  %s  %s%s\n",
+			p.colorize(colorGray, pad(fmt.Sprintf("%d", i+1), indexWidth)),
+			p.colorize(iconColor, icon),
+			p.colorize(severityColor, pad(strings.ToUpper(string(res.Severity)), severityWidth)),
+			p.colorize(colorBold, res.Name),
+			confidence,
+		)
+		indent := strings.Repeat(" ", indexWidth+3)
+		for _, line := range descriptionLines(res.Description) {
+			fmt.Fprintf(p.Out, "%s%s\n", indent, line)
+		}
+		if res.Manifest != nil {
+			fmt.Fprintf(p.Out, "%s%s\n", indent, p.colorize(colorGray, manifestLine(*res.Manifest)))
+		}
+		if res.Blame != nil {
+			fmt.Fprintf(p.Out, "%s%s\n", indent, p.colorize(colorGray, blameLine(*res.Blame)))
+		}
+		if hint := p.remediationHint(res); hint != "" {
+			fmt.Fprintf(p.Out, "%s%s\n", indent, p.colorize(colorGray, hint))
+		}
+		fmt.Fprintln(p.Out)
+	}
+}
+
+// PrintSummary writes the number of issues found per severity followed by the verdict.
+func (p PrettifyPrinter) PrintSummary(results []analyzer.Result) {
+	failOn := p.FailOn
+	if failOn == "" {
+		failOn = analyzer.SeverityLow
+	}
+	summary := analyzer.SummarizeFailingOn(results, failOn)
+	var counts []string
+	for _, severity := range []analyzer.ResultSeverity{analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium, analyzer.SeverityLow} {
+		counts = append(counts, p.colorize(severityColors[severity], fmt.Sprintf("%d %s", summary.BySeverity[severity], severity)))
+	}
+	if p.Runtime.Runtime != "" {
+		fmt.Fprintf(p.Out, "runtime: %s (%s)\n", p.Runtime.Runtime, strings.Join(p.Runtime.Evidence, ", "))
+	}
+	fmt.Fprintf(p.Out, "%d issue(s) found: %s\n", summary.Failed, strings.Join(counts, ", "))
+	if summary.Verdict == analyzer.VerdictFailed {
+		fmt.Fprintf(p.Out, "%s\n", p.colorize(colorBold+colorRed, statusIcons[analyzer.StatusFailed]+" FAILED"))
+	} else {
+		fmt.Fprintf(p.Out, "%s\n", p.colorize(colorBold+colorGreen, statusIcons[analyzer.StatusPass]+" PASSED"))
+	}
+}
+
+// remediationHint returns the remediation of the rule of a failed result tailored to the detected
+// runtime, empty when the rule has no specific remediation for it.
+func (p PrettifyPrinter) remediationHint(res analyzer.Result) string {
+	if res.Status != analyzer.StatusFailed || p.Runtime.Runtime == "" {
+		return ""
+	}
+	rule, ok := analyzer.FindRule(res.RuleID)
+	if !ok {
+		return ""
+	}
+	if remediation := rule.RemediationFor(p.Runtime.Runtime); remediation != rule.Remediation {
+		return fmt.Sprintf("%s: %s", p.Runtime.Runtime, remediation)
+	}
+	return ""
+}
+
+func (p PrettifyPrinter) colorize(color string, text string) string {
+	if !p.Color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func manifestLine(location analyzer.ManifestLocation) string {
+	return fmt.Sprintf("%s, %s line %d", location.Resource, location.Path, location.Line)
+}
+
+func blameLine(change analyzer.Blame) string {
+	if change.Commit == blame.UNCOMMITTED {
+		return "not committed yet"
+	}
+	return fmt.Sprintf("last changed by %s <%s> on %s (%s)", change.Author, change.Email, change.Date.Format("2006-01-02"), change.Commit[:7])
+}
+
+// descriptionLines splits a description on its line breaks, dropping the indentation that
+// comes from multi-line string literals.
+func descriptionLines(description string) []string {
+	var lines []string
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func pad(text string, width int) string {
+	count := utf8.RuneCountInString(text)
+	if count >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-count)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/triage"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdPublish() *cobra.Command {
+	publishCmd := &cobra.Command{
+		Use:   "publish <imagestreamtag/name:tag|buildconfig/name>",
+		Short: "Publish a summary of the results as annotations of an ImageStreamTag or a BuildConfig",
+		Long: `Analyze the Containerfile or the image and write a summary of the results as annotations of the ImageStreamTag or the
+BuildConfig in the cluster of the current context, so that they can be seen in the cluster without an external dashboard.
+The annotations hold the verdict, the score from 0 to 100, the number of findings per severity and the most severe findings.
+They are written with oc, or kubectl when oc is not installed. Without --cluster the annotations are printed instead.`,
+		Args: cobra.ExactArgs(1),
+		Run:  doPublish,
+		Example: `  doa publish imagestreamtag/web:latest -f Containerfile --cluster
+  doa publish bc/web -f Containerfile -n shop --cluster
+  doa publish istag/web:1.0 -i image-registry.openshift-image-registry.svc:5000/shop/web:1.0`,
+	}
+	publishCmd.Flags().StringP("file", "f", "", "Containerfile to analyze")
+	publishCmd.Flags().StringP("image", "i", "", "Image to analyze")
+	publishCmd.Flags().Bool("cluster", false, "Write the annotations in the cluster of the current context")
+	publishCmd.Flags().StringP("namespace", "n", "", "Namespace of the ImageStreamTag or the BuildConfig (default the namespace of the current context)")
+	publishCmd.Flags().String(
+		"config", config.DEFAULT_FILE, "Configuration file customizing the rules, e.g. their severity",
+	)
+	publishCmd.Flags().String(
+		"triage-file", triage.DEFAULT_FILE, "Feedback file listing the findings marked as false positives, see doa triage",
+	)
+	return publishCmd
+}
+
+func doPublish(cmd *cobra.Command, args []string) {
+	target, err := manifests.ParseTarget(args[0])
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	target.Namespace, _ = cmd.Flags().GetString("namespace")
+
+	containerfile, _ := cmd.Flags().GetString("file")
+	image, _ := cmd.Flags().GetString("image")
+	if (containerfile == "") == (image == "") {
+		RedirectErrorStringToStdErrAndExit("one of the flags --file and --image is required, type --help for a list of all flags\n")
+	}
+
+	configFile, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	triageFile, err := triage.Load(cmd.Flag("triage-file").Value.String())
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+
+	var results []analyzer.Result
+	if containerfile != "" {
+		results = analyzer.AnalyzePath(ctx, containerfile)
+	} else {
+		results = analyzer.AnalyzeImage(ctx, image)
+	}
+	results, _ = triageFile.Suppress(cfg.Apply(results))
+
+	annotations, err := manifests.Annotations(results, workspace.Score(results), manifests.ReportOptions{
+		Image:  image,
+		Source: containerfile,
+		FailOn: cfg.FailOnSeverity(),
+	})
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if cluster, _ := cmd.Flags().GetBool("cluster"); !cluster {
+		bytes, err := json.MarshalIndent(annotations, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	if err := manifests.Publish(target, annotations); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	fmt.Printf("Results published on %s: %s, score %s\n", target, annotations[manifests.VERDICT_LABEL], annotations[manifests.SCORE_ANNOTATION])
+}
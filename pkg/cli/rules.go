@@ -0,0 +1,127 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/sarif"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdRules() *cobra.Command {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect the rules checked by doa",
+		Args:  cobra.NoArgs,
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the catalog of rules",
+		Long:  "Export the catalog of rules (IDs, descriptions, severities, remediation and references) so that other tools and documentation can stay in sync with doa.",
+		Args:  cobra.NoArgs,
+		Run:   doRulesExport,
+		Example: `  doa rules export
+  doa rules export --format sarif-taxonomy`,
+	}
+	exportCmd.PersistentFlags().String(
+		"format", "json", "Specify output format, supported formats: json, sarif-taxonomy",
+	)
+
+	explainCmd := &cobra.Command{
+		Use:   "explain <rule-id>",
+		Short: "Explain a rule and link the documentation sections about it",
+		Long:  "Explain why a rule matters on OpenShift and how to fix its findings, with links to the sections of the OpenShift documentation and the container guidelines explaining it.",
+		Args:  cobra.ExactArgs(1),
+		Run:   doRulesExplain,
+		Example: `  doa rules explain user-root
+  doa rules explain hardcoded-resources --runtime java`,
+	}
+	explainCmd.Flags().String(
+		"runtime", "", "Runtime to tailor the remediation to: java, nodejs, python, go",
+	)
+
+	rulesCmd.AddCommand(exportCmd)
+	rulesCmd.AddCommand(explainCmd)
+	return rulesCmd
+}
+
+type rulesCatalog struct {
+	RulesetVersion string          `json:"rulesetVersion"`
+	Rules          []analyzer.Rule `json:"rules"`
+}
+
+func doRulesExport(cmd *cobra.Command, args []string) {
+	format := cmd.Flag("format")
+
+	var output interface{}
+	switch strings.ToLower(format.Value.String()) {
+	case "json":
+		output = rulesCatalog{
+			RulesetVersion: analyzer.RULESET_VERSION,
+			Rules:          analyzer.Rules,
+		}
+	case "sarif-taxonomy":
+		output = sarif.Taxonomy(analyzer.Rules, analyzer.RULESET_VERSION)
+	default:
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag %s, type --help for a list of all flags\n", format.Value.String(), format.Name))
+	}
+
+	bytes, err := json.MarshalIndent(output, "", "    ")
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("error while converting the rules to json: %s", err))
+	}
+	fmt.Println(string(bytes))
+}
+
+func doRulesExplain(cmd *cobra.Command, args []string) {
+	rule, ok := analyzer.FindRule(args[0])
+	if !ok {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown rule %s, see doa rules export for the list of the rules\n", args[0]))
+	}
+	runtime, _ := cmd.Flags().GetString("runtime")
+
+	fmt.Printf("%s - %s\n", rule.ID, rule.Name)
+	fmt.Printf("Severity: %s, confidence: %s\n", rule.Severity, rule.Confidence)
+	if len(rule.Instructions) > 0 {
+		fmt.Printf("Instructions: %s\n", strings.Join(rule.Instructions, ", "))
+	}
+	if rule.Pack != "" {
+		fmt.Printf("Pack: %s\n", rule.Pack)
+	}
+	fmt.Printf("\n%s\n", rule.Description)
+	if remediation := rule.RemediationFor(analyzer.Runtime(strings.ToLower(runtime))); remediation != "" {
+		fmt.Printf("\nRemediation:\n  %s\n", remediation)
+	}
+	if docs := rule.Docs(); len(docs) > 0 {
+		fmt.Printf("\nDocumentation:\n")
+		for _, doc := range docs {
+			fmt.Printf("  - %s\n    %s\n", doc.Title, doc.URL)
+		}
+	}
+	if len(rule.References) > 0 {
+		fmt.Printf("\nReferences:\n")
+		for _, reference := range rule.References {
+			fmt.Printf("  - %s\n", reference)
+		}
+	}
+}
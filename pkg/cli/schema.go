@@ -0,0 +1,55 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdSchema() *cobra.Command {
+	schemaCmd := &cobra.Command{
+		Use:   "schema [" + strings.Join(schema.Names, "|") + "]",
+		Short: "Print the JSON schema of the JSON output of doa",
+		Long: `Print the JSON schema of the JSON output of doa, so that the integrations can validate the reports
+they read: the results written by doa analyze -o json (the default) or the summary written by
+doa analyze --summary-only -o json. The reports are validated against it by doa analyze --validate-output.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: schema.Names,
+		Run:       doSchema,
+		Example: `  doa schema
+  doa schema summary > summary.schema.json`,
+	}
+	return schemaCmd
+}
+
+func doSchema(cmd *cobra.Command, args []string) {
+	name := schema.RESULTS
+	if len(args) > 0 {
+		name = strings.ToLower(args[0])
+	}
+	bytes, err := schema.Get(name)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	fmt.Print(string(bytes))
+}
@@ -0,0 +1,187 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/attestation"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/server"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+// WEBHOOK_TOKEN_ENV is the environment variable holding the token of the webhooks, so that it
+// doesn't show in the process list
+const WEBHOOK_TOKEN_ENV = "DOA_WEBHOOK_TOKEN"
+
+func NewCmdServe() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Analyze the images pushed to the registries notifying doa with their webhooks",
+		Long: `Run doa as a service analyzing the images pushed to the registries: the push webhooks of Quay (/webhooks/quay),
+Docker Hub (/webhooks/dockerhub) and of the OCI registries sending the notifications of the distribution registry
+(/webhooks/oci) queue the analysis of the pushed tags, whose results are sent to the sinks:
+  file:<path>                  appends the analyses to a JSON lines file
+  slack:<webhook URL>          posts a summary to a Slack channel
+  teams:<webhook URL>          posts a summary to a Microsoft Teams channel
+  annotation[:<namespace>]     annotates the ImageStreamTag of the image, see doa publish
+The webhooks are authenticated with the token set by --token or by the ` + WEBHOOK_TOKEN_ENV + ` environment variable,
+given as the token query parameter of the webhook URL or as a bearer token. The notifications of the configuration file
+are posted when the analysis exceeds their threshold.
+With a store, the analyses are recorded in it and the runs recorded by doa analyze, doa workspace and doa serve are
+served as JSON, with the same token, for dashboards like Grafana:
+  GET /api/v1/runs                 the runs, the most recent first
+  GET /api/v1/runs/<id>/findings   the failed findings of a run
+  GET /api/v1/rules                the number of findings of each rule
+  GET /api/v1/trend                the average score and findings per day, or per week with ?period=week
+  GET /api/v1/projects             the score of each project, from the last run of its targets
+filtered by the target, project, rule and since (e.g. 30d) query parameters.
+With --admission, /admission is a validating admission webhook checking that the images of the created workloads have an
+analysis attestation, see doa attest, signed by a key of the trust file (~/.config/doa/trust.yaml or DOA_TRUST_FILE) and
+scoring at least the min-score of the admission policy of the configuration file. The images failing the verification
+are rejected in the enforced namespaces and admitted with a warning in the warned ones. The API server only calls
+webhooks served over HTTPS, see --tls-cert and --tls-key.`,
+		Args: cobra.NoArgs,
+		Run:  doServe,
+		Example: `  doa serve --sink file:analyses.jsonl
+  doa serve --store doa.db
+  doa serve --admission --tls-cert tls.crt --tls-key tls.key --store doa.db
+  DOA_WEBHOOK_TOKEN=s3cr3t doa serve --listen :9000 --sink slack:https://hooks.slack.com/services/... --sink annotation:images`,
+	}
+	serveCmd.Flags().String("listen", ":8080", "Address the webhooks are served on")
+	serveCmd.Flags().String("token", "", "Token authenticating the webhooks (default $"+WEBHOOK_TOKEN_ENV+")")
+	serveCmd.Flags().StringArray("sink", nil, "Sink the analyses are sent to, can be repeated")
+	serveCmd.Flags().String("store", "", "SQLite database the analyses are recorded in and served from by the results API (default $"+store.STORE_ENV+")")
+	serveCmd.Flags().String("config", config.DEFAULT_FILE, "Configuration file")
+	serveCmd.Flags().Bool("admission", false, "Serve the admission webhook verifying the analysis attestations of the images")
+	serveCmd.Flags().String("tls-cert", "", "Certificate served over HTTPS, with --tls-key")
+	serveCmd.Flags().String("tls-key", "", "Private key of the certificate served over HTTPS")
+	return serveCmd
+}
+
+func doServe(cmd *cobra.Command, args []string) {
+	configFile := cmd.Flag("config").Value.String()
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	analysisCtx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		analysisCtx = analyzer.WithPlatform(analysisCtx, cfg.Platform)
+	}
+	analysisCtx = analyzer.WithPacks(analysisCtx, cfg.EnabledPacks())
+
+	values, _ := cmd.Flags().GetStringArray("sink")
+	var sinks []server.Sink
+	for _, value := range values {
+		sink, err := server.ParseSink(value, cfg.FailOnSeverity())
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		sinks = append(sinks, sink)
+	}
+	var results *store.Store
+	if path := storePath(cmd); path != "" {
+		if results, err = store.Open(path); err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		defer results.Close()
+	}
+	for _, notification := range cfg.Notifications {
+		sinks = append(sinks, &server.NotificationSink{Notification: notification, FailOn: cfg.FailOnSeverity(), Thresholds: true})
+	}
+	var verify server.VerifyFunc
+	if admission, _ := cmd.Flags().GetBool("admission"); admission {
+		verifier, err := loadVerifier()
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		if verifier == nil {
+			RedirectErrorStringToStdErrAndExit("--admission requires a trust file listing the keys signing the attestations\n")
+		}
+		verify = func(ctx context.Context, image string, minScore int) error {
+			digest, err := attestation.ResolveDigest(image)
+			if err != nil {
+				return err
+			}
+			_, err = attestation.VerifyImage(digest, verifier, minScore, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithContext(ctx))
+			return err
+		}
+	}
+	if len(sinks) == 0 && results == nil && verify == nil {
+		RedirectErrorStringToStdErrAndExit("at least one --sink, one notification of the configuration file, --store or --admission is required\n")
+	}
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv(WEBHOOK_TOKEN_ENV)
+	}
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "the webhooks are not authenticated, set a token with --token or %s\n", WEBHOOK_TOKEN_ENV)
+	}
+
+	s := server.New(func(ctx context.Context, image string) []analyzer.Result {
+		return cfg.Apply(analyzer.AnalyzeImage(analysisCtx, image))
+	}, sinks, server.Options{Token: token, FailOn: cfg.FailOnSeverity(), Store: results, Verify: verify, Admission: cfg.Admission})
+	listen, _ := cmd.Flags().GetString("listen")
+	httpServer := &http.Server{Addr: listen, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go s.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "serving the webhooks on %s\n", listen)
+	certificate, _ := cmd.Flags().GetString("tls-cert")
+	key, _ := cmd.Flags().GetString("tls-key")
+	if (certificate == "") != (key == "") {
+		RedirectErrorStringToStdErrAndExit("--tls-cert and --tls-key must be set together\n")
+	}
+	if certificate != "" {
+		err = httpServer.ListenAndServeTLS(certificate, key)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
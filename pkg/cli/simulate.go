@@ -0,0 +1,111 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdSimulate() *cobra.Command {
+	simulateCmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Report the paths the container would fail to access with an arbitrary UID",
+		Long: `Simulate the container of the image built from the Containerfile running as OpenShift runs it, with an arbitrary UID
+of the project range and the root group, and report every path it would fail to access: the working directory, the files of
+the ENTRYPOINT and CMD, the volumes and the paths the application writes to (log, PID and temporary files set in the ENV
+instructions and the start command). The owners and modes are replayed from the COPY, ADD, WORKDIR and RUN mkdir, touch,
+chmod, chown, chgrp and rm instructions, the modes of the files copied from the build context being read from it. The
+accesses to the paths of the base image are reported as undetermined. The command exits with code 1 when an access is denied.`,
+		Args: cobra.NoArgs,
+		Run:  doSimulate,
+		Example: `  doa simulate -f Containerfile
+  doa simulate -f Containerfile --uid 1000680000 --context . -o json`,
+	}
+	simulateCmd.Flags().StringP("file", "f", "", "Containerfile to simulate, or the directory holding it")
+	simulateCmd.Flags().Int64("uid", analyzer.DEFAULT_SIMULATED_UID, "UID the container runs with")
+	simulateCmd.Flags().String("context", "", "Build context directory the files are copied from (default the directory of the Containerfile)")
+	simulateCmd.Flags().String("target", "", "Stage to simulate as the final one, as docker build --target does")
+	simulateCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	return simulateCmd
+}
+
+func doSimulate(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	}
+	uid, _ := cmd.Flags().GetInt64("uid")
+	if uid <= 0 {
+		RedirectErrorStringToStdErrAndExit("--uid must be a non-root UID\n")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "json" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unsupported output format %s, expected json\n", output))
+	}
+	ctx := context.Background()
+	if dir, _ := cmd.Flags().GetString("context"); dir != "" {
+		ctx = analyzer.WithBuildContext(ctx, dir)
+	}
+	if target, _ := cmd.Flags().GetString("target"); target != "" {
+		ctx = analyzer.WithTarget(ctx, target)
+	}
+	simulation, err := analyzer.SimulatePath(ctx, file, uid)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if output == "json" {
+		bytes, err := json.MarshalIndent(simulation, "", "  ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+	} else {
+		PrintSimulation(os.Stdout, simulation)
+	}
+	if len(simulation.Denials) > 0 {
+		os.Exit(1)
+	}
+}
+
+// PrintSimulation prints the denied accesses as a table, followed by the number of checked ones.
+func PrintSimulation(out io.Writer, simulation analyzer.Simulation) {
+	if len(simulation.Denials) > 0 {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tACCESS\tPURPOSE\tLINE\tREASON")
+		for _, denial := range simulation.Denials {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", denial.Path, denial.Access, denial.Purpose, denial.Line.Start, denial.Reason)
+		}
+		w.Flush()
+	}
+	user := ""
+	if simulation.User != "" {
+		user = fmt.Sprintf(" instead of the USER %s", simulation.User)
+	}
+	fmt.Fprintf(out, "%d of %d accesses denied to the UID %d%s, %d undetermined\n",
+		len(simulation.Denials), simulation.Checked, simulation.UID, user, simulation.Undetermined)
+}
@@ -0,0 +1,123 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/testrun"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdTestRun() *cobra.Command {
+	testRunCmd := &cobra.Command{
+		Use:   "test-run",
+		Short: "Run the image with an arbitrary UID and report its permission errors",
+		Long: `Build the image of the Containerfile with Podman, or Buildah, and start it as OpenShift runs it, with an arbitrary
+non-root UID, the root group, no capability and a read-only root filesystem, for the given duration. The permission denied and
+read-only filesystem errors logged by the container are reported along with the instruction of the Containerfile which set
+the permissions of their path. The image and the container are removed afterwards. The command exits with code 1 when an
+error is found.`,
+		Args: cobra.NoArgs,
+		Run:  doTestRun,
+		Example: `  doa test-run -f Containerfile
+  doa test-run -f Containerfile --duration 30s --writable-root -o json`,
+	}
+	testRunCmd.Flags().StringP("file", "f", "", "Containerfile of the image to run")
+	testRunCmd.Flags().Int64("uid", analyzer.DEFAULT_SIMULATED_UID, "UID the container runs with")
+	testRunCmd.Flags().String("context", "", "Build context directory (default the directory of the Containerfile)")
+	testRunCmd.Flags().String("target", "", "Stage to build, as docker build --target does")
+	testRunCmd.Flags().String("builder", "podman", "Tool building the image, podman or buildah")
+	testRunCmd.Flags().Duration("duration", testrun.DEFAULT_DURATION, "How long the container runs before its logs are checked")
+	testRunCmd.Flags().Bool("writable-root", false, "Don't mount the root filesystem of the container read-only")
+	testRunCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	return testRunCmd
+}
+
+func doTestRun(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		PrintNoArgsWarningMessage(cmd.Name())
+		return
+	}
+	options := testrun.Options{Containerfile: file}
+	options.UID, _ = cmd.Flags().GetInt64("uid")
+	if options.UID <= 0 {
+		RedirectErrorStringToStdErrAndExit("--uid must be a non-root UID\n")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "json" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unsupported output format %s, expected json\n", output))
+	}
+	options.Context, _ = cmd.Flags().GetString("context")
+	options.Target, _ = cmd.Flags().GetString("target")
+	options.Builder, _ = cmd.Flags().GetString("builder")
+	options.Duration, _ = cmd.Flags().GetDuration("duration")
+	options.WritableRoot, _ = cmd.Flags().GetBool("writable-root")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	report, err := testrun.Run(ctx, options)
+	stop()
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if output == "json" {
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+	} else {
+		PrintTestRun(os.Stdout, *report)
+	}
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// PrintTestRun prints the errors of the test run as a table, followed by the state of the container.
+func PrintTestRun(out io.Writer, report testrun.Report) {
+	if len(report.Errors) > 0 {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tPATH\tLINE\tMESSAGE")
+		for _, found := range report.Errors {
+			line, path := "-", found.Path
+			if found.Line != nil {
+				line = fmt.Sprint(found.Line.Start)
+			}
+			if path == "" {
+				path = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", found.Kind, path, line, found.Message)
+		}
+		w.Flush()
+	}
+	state := "still running"
+	if !report.Running {
+		state = fmt.Sprintf("exited with code %d", report.ExitCode)
+	}
+	fmt.Fprintf(out, "%d permission errors logged by the container run with the UID %d, %s\n", len(report.Errors), report.UID, state)
+}
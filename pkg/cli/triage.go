@@ -0,0 +1,173 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"text/tabwriter"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/triage"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdTriage() *cobra.Command {
+	triageCmd := &cobra.Command{
+		Use:   "triage",
+		Short: "Mark findings as false positives",
+		Long: `Record findings as false positives in a feedback file, so that doa analyze suppresses them on subsequent runs.
+Each entry keeps the reason and the author of the decision and is meant to be committed along with the Containerfile.
+The entries given an expiry are waivers: once expired, their findings are reported again, see doa waivers list.`,
+		Args: cobra.NoArgs,
+	}
+	triageCmd.PersistentFlags().String(
+		"triage-file", triage.DEFAULT_FILE, "Feedback file storing the findings marked as false positives",
+	)
+
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Mark a finding as a false positive",
+		Args:  cobra.NoArgs,
+		Run:   doTriageAdd,
+		Example: `  doa triage add --rule chown-group --line 12 --reason "the group is mapped to root by the base image"
+  doa triage add --rule user-root --reason "the image is only run by the build pipeline"
+  doa triage add --rule copied-secret --fingerprint 3f2a9c0d51e8b7a46c1d2e9f80b3a5c7 --reason "test certificate"
+  doa triage add --rule unpinned-packages --reason "pinned by the base image upgrade, see JIRA-123" --expires 90d`,
+	}
+	addCmd.Flags().String("rule", "", "ID of the rule of the finding, see doa rules export")
+	addCmd.Flags().Int("line", 0, "Line of the finding, 0 suppresses the rule on every line")
+	addCmd.Flags().String("fingerprint", "", "Fingerprint of the finding, see the JSON output, which survives line shifts")
+	addCmd.Flags().String("reason", "", "Why the finding is a false positive")
+	addCmd.Flags().String("author", "", "Who triaged the finding (default the current user)")
+	addCmd.Flags().String("expires", "", "Date (2006-01-02) or duration (e.g. 30d, 8w) after which the finding is reported again (default never)")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Report a finding marked as false positive again",
+		Args:  cobra.NoArgs,
+		Run:   doTriageRemove,
+	}
+	removeCmd.Flags().String("rule", "", "ID of the rule of the finding")
+	removeCmd.Flags().Int("line", 0, "Line of the finding")
+	removeCmd.Flags().String("fingerprint", "", "Fingerprint of the finding")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the findings marked as false positives",
+		Args:  cobra.NoArgs,
+		Run:   doTriageList,
+	}
+
+	triageCmd.AddCommand(addCmd, removeCmd, listCmd)
+	return triageCmd
+}
+
+func loadTriageFile(cmd *cobra.Command) (*triage.File, string) {
+	path, _ := cmd.Flags().GetString("triage-file")
+	file, err := triage.Load(path)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	return file, path
+}
+
+func doTriageAdd(cmd *cobra.Command, args []string) {
+	ruleID, _ := cmd.Flags().GetString("rule")
+	line, _ := cmd.Flags().GetInt("line")
+	fingerprint, _ := cmd.Flags().GetString("fingerprint")
+	reason, _ := cmd.Flags().GetString("reason")
+	author, _ := cmd.Flags().GetString("author")
+	if _, ok := analyzer.FindRule(ruleID); !ok {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown rule '%s', type doa rules export for a list of all rules\n", ruleID))
+	}
+	if reason == "" {
+		RedirectErrorStringToStdErrAndExit("a reason is required to mark a finding as a false positive, use the --reason flag\n")
+	}
+	if author == "" {
+		if current, err := user.Current(); err == nil {
+			author = current.Username
+		}
+	}
+	var expires *time.Time
+	if value, _ := cmd.Flags().GetString("expires"); value != "" {
+		expiry, err := triage.ParseExpiry(value, time.Now())
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		expiry = expiry.UTC().Truncate(time.Second)
+		expires = &expiry
+	}
+
+	file, path := loadTriageFile(cmd)
+	file.Add(triage.Entry{
+		RuleID:      ruleID,
+		Line:        line,
+		Fingerprint: fingerprint,
+		Reason:      reason,
+		Author:      author,
+		Date:        time.Now().UTC().Truncate(time.Second),
+		Expires:     expires,
+	})
+	if err := file.Save(path); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
+
+func doTriageRemove(cmd *cobra.Command, args []string) {
+	ruleID, _ := cmd.Flags().GetString("rule")
+	line, _ := cmd.Flags().GetInt("line")
+	fingerprint, _ := cmd.Flags().GetString("fingerprint")
+
+	file, path := loadTriageFile(cmd)
+	if !file.Remove(ruleID, line, fingerprint) {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("no finding of rule '%s' at line %d is marked as a false positive\n", ruleID, line))
+	}
+	if err := file.Save(path); err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+}
+
+func doTriageList(cmd *cobra.Command, args []string) {
+	file, _ := loadTriageFile(cmd)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tLINE\tAUTHOR\tDATE\tEXPIRES\tREASON")
+	for _, entry := range file.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.RuleID, entryLine(entry), entry.Author, entry.Date.Format("2006-01-02"), entryExpiry(entry), entry.Reason)
+	}
+	w.Flush()
+}
+
+// entryLine returns the fingerprint or the line the entry matches, * for every line.
+func entryLine(entry triage.Entry) string {
+	if entry.Fingerprint != "" {
+		return entry.Fingerprint
+	} else if entry.Line != 0 {
+		return fmt.Sprint(entry.Line)
+	}
+	return "*"
+}
+
+func entryExpiry(entry triage.Entry) string {
+	if entry.Expires == nil {
+		return "never"
+	}
+	return entry.Expires.Local().Format("2006-01-02")
+}
@@ -0,0 +1,88 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/update"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdUpdate() *cobra.Command {
+	updateCmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update doa to the latest released version",
+		Long: `Check the GitHub releases for a newer version of doa and replace the current binary with it.
+The downloaded binary is verified against the checksums published with the release before being installed.
+When a trust file is configured (~/.config/doa/trust.yaml or DOA_TRUST_FILE), the checksums must have a trusted cosign signature.`,
+		Args: cobra.NoArgs,
+		Run:  doUpdate,
+		Example: `  doa update --check
+  doa update`,
+	}
+	updateCmd.PersistentFlags().Bool(
+		"check", false, "Only check if a newer version is available",
+	)
+	updateCmd.PersistentFlags().String(
+		"repository", update.DEFAULT_REPOSITORY, "GitHub repository (owner/name) publishing the releases",
+	)
+	return updateCmd
+}
+
+func doUpdate(cmd *cobra.Command, args []string) {
+	check, _ := cmd.Flags().GetBool("check")
+	repository, _ := cmd.Flags().GetString("repository")
+
+	release, err := update.LatestRelease(repository)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	newer, err := update.IsNewer(release, version.Version)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if !newer {
+		fmt.Printf("doa %s is already up to date\n", version.Version)
+		return
+	}
+	if check {
+		fmt.Printf("doa %s is available (current version %s): %s\n", release.TagName, version.Version, release.HTMLURL)
+		return
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to locate the doa executable: %s", err))
+	}
+	verifier, err := loadVerifier()
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	if err := update.Apply(release, executable, verifier); err != nil {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unable to update doa: %s", err))
+	}
+	fmt.Printf("doa updated from %s to %s\n", version.Version, release.TagName)
+}
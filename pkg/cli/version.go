@@ -0,0 +1,70 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdVersion() *cobra.Command {
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of doa",
+		Long:  "Print the version of doa, the git commit and date it has been built from and the version of the embedded rule set.",
+		Args:  cobra.NoArgs,
+		Run:   doVersion,
+		Example: `  doa version
+  doa version --format json`,
+	}
+	versionCmd.PersistentFlags().String(
+		"format", "", "Specify output format, supported format: json",
+	)
+	return versionCmd
+}
+
+func doVersion(cmd *cobra.Command, args []string) {
+	format := cmd.Flag("format")
+	info := version.Get(analyzer.RULESET_VERSION)
+
+	if strings.EqualFold(format.Value.String(), "json") {
+		bytes, err := json.MarshalIndent(info, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(fmt.Sprintf("error while converting version to json: %s", err))
+		}
+		fmt.Println(string(bytes))
+		return
+	} else if format.Value.String() != "" {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag %s, type --help for a list of all flags\n", format.Value.String(), format.Name))
+	}
+
+	fmt.Printf("doa version %s\n", info.Version)
+	fmt.Printf("  commit:          %s\n", info.Commit)
+	if info.BuildDate != "" {
+		fmt.Printf("  build date:      %s\n", info.BuildDate)
+	}
+	fmt.Printf("  ruleset version: %s\n", info.RulesetVersion)
+	fmt.Printf("  go version:      %s\n", info.GoVersion)
+	fmt.Printf("  platform:        %s\n", info.Platform)
+}
@@ -0,0 +1,99 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/triage"
+	"github.com/spf13/cobra"
+)
+
+// waiver is an entry of the JSON output of doa waivers list
+type waiver struct {
+	triage.Entry
+	Status string `json:"status"`
+}
+
+func NewCmdWaivers() *cobra.Command {
+	waiversCmd := &cobra.Command{
+		Use:   "waivers",
+		Short: "Report the exemptions of the feedback file",
+		Long: `Report the findings exempted by the feedback file, see doa triage, along with their justification, their author and
+whether they are permanent, active until they expire or expired, the findings of the expired ones being reported again.`,
+		Args: cobra.NoArgs,
+	}
+	waiversCmd.PersistentFlags().String(
+		"triage-file", triage.DEFAULT_FILE, "Feedback file storing the findings marked as false positives",
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the permanent, active and expired exemptions",
+		Args:  cobra.NoArgs,
+		Run:   doWaiversList,
+		Example: `  doa waivers list
+  doa waivers list --status expired -o json`,
+	}
+	listCmd.Flags().String("status", "", "Only the exemptions with this status: permanent, active, expired")
+	listCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+
+	waiversCmd.AddCommand(listCmd)
+	return waiversCmd
+}
+
+func doWaiversList(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+	status, _ := cmd.Flags().GetString("status")
+	if status != "" && status != triage.STATUS_PERMANENT && status != triage.STATUS_ACTIVE && status != triage.STATUS_EXPIRED {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag status, type --help for a list of all flags\n", status))
+	}
+
+	file, _ := loadTriageFile(cmd)
+	now := time.Now()
+	waivers := []waiver{}
+	for _, entry := range file.Entries {
+		if entryStatus := entry.Status(now); status == "" || entryStatus == status {
+			waivers = append(waivers, waiver{Entry: entry, Status: entryStatus})
+		}
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(waivers, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tLINE\tSTATUS\tEXPIRES\tAUTHOR\tREASON")
+	for _, waiver := range waivers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", waiver.RuleID, entryLine(waiver.Entry), waiver.Status, entryExpiry(waiver.Entry), waiver.Author, waiver.Reason)
+	}
+	w.Flush()
+}
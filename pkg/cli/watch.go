@@ -0,0 +1,100 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package cli
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// WATCH_DELAY groups the events of a single save, editors often writing a file in several steps
+const WATCH_DELAY = 100 * time.Millisecond
+
+// watchedFiles returns the files whose changes trigger a new analysis: the Containerfile, the
+// configuration file and the feedback file.
+func watchedFiles(cmd *cobra.Command) []string {
+	containerfile := cmd.Flag("file").Value.String()
+	files := []string{containerfile}
+	if info, err := os.Stat(containerfile); err == nil && info.IsDir() {
+		files = []string{filepath.Join(containerfile, "Dockerfile"), filepath.Join(containerfile, "Containerfile")}
+	}
+	return append(files, cmd.Flag("config").Value.String(), cmd.Flag("triage-file").Value.String())
+}
+
+// watch calls analyze, then calls it again every time one of the files changes, until the
+// process is interrupted. The directories of the files are watched rather than the files, as
+// editors often replace a file when saving it.
+func watch(files []string, analyze func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watched := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		watched[path] = true
+		if dir := filepath.Dir(path); !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				return err
+			}
+			dirs[dir] = true
+		}
+	}
+
+	analyze()
+	changed := make(chan struct{}, 1)
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !watched[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(WATCH_DELAY, func() {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			})
+		case <-changed:
+			analyze()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdWorkspace() *cobra.Command {
+	workspaceCmd := &cobra.Command{
+		Use:   "workspace [directory]",
+		Short: "Analyze all the Containerfiles of a monorepo and report them per project",
+		Long: `Analyze all the Containerfiles found below the directory (the current directory by default) and report, for each project,
+the number of findings per severity, a score from 0 to 100 and the owners of the project.
+The projects are mapped to subdirectories in the projects section of the configuration file, every directory holding a
+Containerfile is a project otherwise. The owners of a project default to the owners of its Containerfiles in the CODEOWNERS file.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  doWorkspace,
+		Example: `  doa workspace
+  doa workspace ~/src/monorepo -o json`,
+	}
+	workspaceCmd.Flags().String("config", "", "Configuration file (default .doa.yaml in the directory)")
+	workspaceCmd.Flags().StringP("output", "o", "", "Specify output format, supported format: json")
+	workspaceCmd.Flags().Bool("no-cache", false, "Analyze the Containerfiles even if their results are cached")
+	workspaceCmd.Flags().String("store", "", "SQLite database the runs of the Containerfiles are recorded in, see doa history (default $"+store.STORE_ENV+")")
+	workspaceCmd.Flags().String("cache-dir", "", "Directory the results are cached in (default ~/.cache/doa/results)")
+	return workspaceCmd
+}
+
+func doWorkspace(cmd *cobra.Command, args []string) {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && !strings.EqualFold(output, "json") {
+		RedirectErrorStringToStdErrAndExit(fmt.Sprintf("unknown value '%s' for flag output, type --help for a list of all flags\n", output))
+	}
+	configFile, _ := cmd.Flags().GetString("config")
+	if configFile == "" {
+		configFile = filepath.Join(root, config.DEFAULT_FILE)
+	}
+	cfg, err := loadConfigFile(configFile)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	customRules, err := cfg.Plugin()
+	if err == nil {
+		rules, _ := customRules.Rules()
+		err = analyzer.RegisterRules(configFile, rules)
+	}
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{customRules})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	ctx = analyzer.WithPacks(ctx, cfg.EnabledPacks())
+
+	analyze := analyzer.AnalyzePath
+	if resultsCache := newCache(cmd, false); resultsCache != nil {
+		settings := cacheSettings(cmd, cfg, nil)
+		analyze = func(ctx context.Context, path string) []analyzer.Result {
+			return resultsCache.AnalyzePath(ctx, path, settings...)
+		}
+	}
+	report, err := workspace.Analyze(ctx, root, cfg, analyze)
+	if err != nil {
+		RedirectErrorStringToStdErrAndExit(err.Error())
+	}
+
+	if path := storePath(cmd); path != "" {
+		var runs []store.Run
+		var results [][]analyzer.Result
+		for _, project := range report.Projects {
+			for _, file := range project.Files {
+				runs = append(runs, store.Run{
+					Target:  filepath.ToSlash(filepath.Join(root, file.Path)),
+					Project: project.Name,
+					Score:   file.Score,
+					Summary: analyzer.SummarizeFailingOn(file.Results, cfg.FailOnSeverity()),
+				})
+				results = append(results, file.Results)
+			}
+		}
+		recordRuns(path, runs, results)
+	}
+
+	if output != "" {
+		bytes, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			RedirectErrorStringToStdErrAndExit(err.Error())
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tPATH\tOWNERS\tFILES\tCRITICAL\tHIGH\tMEDIUM\tLOW\tSCORE")
+	for _, project := range report.Projects {
+		printWorkspaceRow(w, project.Name, project.Path, strings.Join(project.Owners, " "), len(project.Files), project.Summary, project.Score)
+	}
+	files := 0
+	for _, project := range report.Projects {
+		files += len(project.Files)
+	}
+	printWorkspaceRow(w, "TOTAL", "", "", files, report.Summary, report.Score)
+	w.Flush()
+}
+
+func printWorkspaceRow(w *tabwriter.Writer, name, path, owners string, files int, summary analyzer.Summary, score int) {
+	if path == "" {
+		path = "-"
+	}
+	if owners == "" {
+		owners = "-"
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", name, path, owners, files,
+		summary.BySeverity[analyzer.SeverityCritical], summary.BySeverity[analyzer.SeverityHigh],
+		summary.BySeverity[analyzer.SeverityMedium], summary.BySeverity[analyzer.SeverityLow], score)
+}
@@ -18,15 +18,18 @@
  package command
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/redhat-developer/docker-openshift-analyzer/pkg/decompiler"
+	"time"
 
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
 	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
 )
 
@@ -46,16 +49,144 @@ const (
 	SeverityLow      ResultSeverity = "low"
 )
 
+var severityLevels = map[ResultSeverity]int{
+	SeverityLow:      0,
+	SeverityMedium:   1,
+	SeverityHigh:     2,
+	SeverityCritical: 3,
+}
+
+func ParseSeverity(value string) (ResultSeverity, error) {
+	severity := ResultSeverity(strings.ToLower(value))
+	if _, ok := severityLevels[severity]; !ok {
+		return "", fmt.Errorf("unknown severity %s, expected one of critical, high, medium, low", value)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether the severity is as severe as min.
+func (s ResultSeverity) AtLeast(min ResultSeverity) bool {
+	return severityLevels[s] >= severityLevels[min]
+}
+
+// ResultConfidence tells how sure the analyzer is about a result. Heuristic checks (e.g. based
+// on regular expressions or on values only known at build time) report a lower confidence.
+type ResultConfidence string
+
+const (
+	ConfidenceHigh   ResultConfidence = "high"
+	ConfidenceMedium ResultConfidence = "medium"
+	ConfidenceLow    ResultConfidence = "low"
+)
+
+var confidenceLevels = map[ResultConfidence]int{
+	ConfidenceLow:    0,
+	ConfidenceMedium: 1,
+	ConfidenceHigh:   2,
+}
+
+func ParseConfidence(value string) (ResultConfidence, error) {
+	confidence := ResultConfidence(strings.ToLower(value))
+	if _, ok := confidenceLevels[confidence]; !ok {
+		return "", fmt.Errorf("unknown confidence %s, expected one of high, medium, low", value)
+	}
+	return confidence, nil
+}
+
 type Result struct {
-	Name        string         `json:"name"`
-	Status      ResultStatus   `json:"status"`
-	Severity    ResultSeverity `json:"severity"`
-	Description string         `json:"description"`
+	RuleID     string           `json:"ruleId,omitempty"`
+	Name       string           `json:"name"`
+	Status     ResultStatus     `json:"status"`
+	Severity   ResultSeverity   `json:"severity"`
+	Confidence ResultConfidence `json:"confidence,omitempty"`
+	Line       *Line            `json:"line,omitempty"`
+	// File is set for the results found in a file of the build context rather than in the
+	// Containerfile
+	File        *FileLocation `json:"file,omitempty"`
+	Description string        `json:"description"`
+	// Fingerprint identifies the result across runs, even when the lines of the Containerfile
+	// are shifted
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Blame is the last change of the line of the result, only set on demand, see pkg/blame
+	Blame *Blame `json:"blame,omitempty"`
+	// Manifest is set for the results of a Containerfile embedded in a manifest, Line being the
+	// line in the Containerfile text
+	Manifest *ManifestLocation `json:"manifest,omitempty"`
+	// Docs are the documentation sections explaining the rule of the result
+	Docs []DocLink `json:"docs,omitempty"`
+	// Patch is the unified diff of the Containerfile fixing the result, only set in the JSON
+	// output of a Containerfile, see convert.AttachPatches
+	Patch string `json:"patch,omitempty"`
+}
+
+// Blame is the commit which last changed a line
+type Blame struct {
+	Commit string    `json:"commit"`
+	Author string    `json:"author"`
+	Email  string    `json:"email,omitempty"`
+	Date   time.Time `json:"date"`
+}
+
+// FileLocation is a line of a file, relative to the build context
+type FileLocation struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+}
+
+// ManifestLocation is a line of a manifest embedding the Containerfile, e.g. the inline
+// dockerfile of a BuildConfig
+type ManifestLocation struct {
+	Path string `json:"path"`
+	// Resource is the kind and the name of the resource, e.g. BuildConfig/web
+	Resource string `json:"resource"`
+	Line     int    `json:"line"`
+}
+
+// InFile sets the line of the build context file where the result was found.
+func (r Result) InFile(path string, line int) Result {
+	r.File = &FileLocation{Path: path, Line: line}
+	return r
+}
+
+// At sets the lines of the Containerfile where the result was found. Lines of a parent image
+// are not reported as they don't belong to the analyzed Containerfile.
+func (r Result) At(source utils.Source, line Line) Result {
+	if source.Type != utils.Parent {
+		r.Line = &line
+	}
+	return r
+}
+
+// FilterByConfidence drops the results whose confidence is lower than min. Results without a
+// confidence, like analysis errors, are always kept.
+func FilterByConfidence(results []Result, min ResultConfidence) []Result {
+	filtered := []Result{}
+	for _, result := range results {
+		if result.Confidence == "" || confidenceLevels[result.Confidence] >= confidenceLevels[min] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+type passedResultsKeyType struct{}
+
+var passedResultsKey passedResultsKeyType
+
+// WithPassedResults makes the rules also report the checks which passed, e.g. a non-root USER,
+// so that a report can demonstrate the compliance of the image.
+func WithPassedResults(ctx context.Context) context.Context {
+	return context.WithValue(ctx, passedResultsKey, true)
+}
+
+func reportsPassed(ctx context.Context) bool {
+	passed, _ := ctx.Value(passedResultsKey).(bool)
+	return passed
 }
 
 type Line struct {
-	Start int
-	End   int
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type Command interface {
@@ -63,24 +194,52 @@ type Command interface {
 	PostProcess(ctx context.Context) []Result
 }
 
+// RULESET_VERSION identifies the set of checks performed by the analyzer. It has to be
+// bumped every time a check is added, removed or its behavior changes.
+const RULESET_VERSION = "1.34.0"
+
 var commandHandlers = map[string]Command{
-	utils.EXPOSE_INSTRUCTION: Expose{},
-	utils.FROM_INSTRUCTION:   From{},
-	utils.RUN_INSTRUCTION:    Run{},
-	utils.USER_INSTRUCTION:   User{},
+	utils.ADD_INSTRUCTION:        Add{},
+	utils.ARG_INSTRUCTION:        Arg{},
+	utils.CMD_INSTRUCTION:        Cmd{},
+	utils.COPY_INSTRUCTION:       Copy{},
+	utils.ENTRYPOINT_INSTRUCTION: Entrypoint{},
+	utils.ENV_INSTRUCTION:        Env{},
+	utils.EXPOSE_INSTRUCTION:     Expose{},
+	utils.FROM_INSTRUCTION:       From{},
+	utils.LABEL_INSTRUCTION:      Label{},
+	utils.RUN_INSTRUCTION:        Run{},
+	utils.USER_INSTRUCTION:       User{},
+	utils.VOLUME_INSTRUCTION:     Volume{},
 }
 
-func AnalyzePath(path string) []Result {
+// wholeInstructions are analyzed at once: their handlers get the first argument and walk the
+// following ones themselves, e.g. the key/value pairs of ENV or the arguments of
+// the exec form of ENTRYPOINT which make a single command line, the destination of COPY or the
+// stage name of FROM.
+var wholeInstructions = map[string]bool{
+	utils.ADD_INSTRUCTION:        true,
+	utils.CMD_INSTRUCTION:        true,
+	utils.COPY_INSTRUCTION:       true,
+	utils.ENTRYPOINT_INSTRUCTION: true,
+	utils.ENV_INSTRUCTION:        true,
+	utils.FROM_INSTRUCTION:       true,
+	utils.LABEL_INSTRUCTION:      true,
+}
+
+// AnalyzePath analyzes the Containerfile at path, or the Dockerfile/Containerfile of the path
+// directory. The context carries the analysis settings, e.g. the language of the messages.
+func AnalyzePath(ctx context.Context, path string) []Result {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
-		return []Result{
+		return localize(ctx, []Result{
 			{
 				Name:        "Analyze error",
 				Status:      StatusFailed,
 				Severity:    SeverityCritical,
-				Description: fmt.Sprintf("unable to analyze %s - error %s", path, err),
+				Description: i18n.Sprintf(ctx, "unable to analyze %s - error %s", path, err),
 			},
-		}
+		})
 	}
 
 	if fileInfo.IsDir() {
@@ -92,106 +251,291 @@ func AnalyzePath(path string) []Result {
 
 	file, err := os.Open(path)
 	if err != nil {
-		return []Result{
+		return localize(ctx, []Result{
 			{
 				Name:        "File not found",
 				Status:      StatusFailed,
 				Severity:    SeverityCritical,
-				Description: fmt.Sprintf("unable to open %s - error %s", path, err),
+				Description: i18n.Sprintf(ctx, "unable to open %s - error %s", path, err),
 			},
-		}
+		})
 	}
 	defer file.Close()
 
-	return AnalyzeFile(file)
+	return AnalyzeFile(ctx, file)
 }
 
-func AnalyzeImage(image string) []Result {
-	node, err := decompiler.Decompile(image)
+func AnalyzeImage(ctx context.Context, image string) []Result {
+	node, err := decompile(image)
 	if err != nil {
-		return []Result{
+		return localize(ctx, []Result{
 			{
 				Name:        "Analyze error",
 				Status:      StatusFailed,
 				Severity:    SeverityCritical,
-				Description: fmt.Sprintf("unable to analyze %s - error %s", image, err),
+				Description: i18n.Sprintf(ctx, "unable to analyze %s - error %s", image, err),
 			},
-		}
+		})
 	}
-	ctx := context.Background()
 	suggestions, _ := AnalyzeNodeFromSource(ctx, node, utils.Source{
 		Name: "",
 		Type: utils.Image,
 	})
-	return suggestions
+	return withDocs(localize(ctx, withFingerprints(forPlatform(ctx, forPacks(ctx, node, suggestions)), snippets(node, nil))))
+}
+
+// AnalyzeFile analyzes the Containerfile, its directory being the build context unless the
+// context already sets one, see WithBuildContext and WithVerifiedBuildContext.
+func AnalyzeFile(ctx context.Context, file *os.File) []Result {
+	if _, ok := buildContext(ctx); !ok {
+		ctx = WithBuildContext(ctx, filepath.Dir(file.Name()))
+	}
+	return AnalyzeReader(ctx, file.Name(), file)
 }
 
-func AnalyzeFile(file *os.File) []Result {
-	res, err := parser.Parse(file)
+// AnalyzeReader analyzes the Containerfile content read from reader, name is only used to
+// report errors.
+func AnalyzeReader(ctx context.Context, name string, reader io.Reader) []Result {
+	content, err := io.ReadAll(reader)
 	if err != nil {
-		return []Result{
+		return localize(ctx, []Result{
 			{
-				Name:        "Parse error",
+				Name:        "Analyze error",
 				Status:      StatusFailed,
 				Severity:    SeverityCritical,
-				Description: fmt.Sprintf("unable to analyze the Containerfile. Error when parsing %s : %s", file.Name(), err.Error()),
+				Description: i18n.Sprintf(ctx, "unable to analyze %s - error %s", name, err),
 			},
-		}
+		})
 	}
+	_, results := parseAndAnalyze(ctx, name, content)
+	return results
+}
 
-	ctx := context.Background()
-
-	suggestions, _ := AnalyzeNodeFromSource(ctx, res.AST, utils.Source{
+// parseAndAnalyze parses and analyzes the Containerfile. The instructions which can't be parsed
+// are reported as parse errors and the other ones are still analyzed. The returned node is nil
+// when no instruction could be parsed.
+func parseAndAnalyze(ctx context.Context, name string, content []byte) (*parser.Node, []Result) {
+	source := utils.Source{
 		Name: "",
 		Type: utils.Image,
-	})
-	return suggestions
+	}
+	res, parseErrors, err := parse(content)
+	suggestions := []Result{}
+	for _, parseError := range parseErrors {
+		suggestions = append(suggestions, RuleParseError.Failed(
+			i18n.Sprintf(ctx, "unable to analyze the Containerfile. Error when parsing %s : %s", name, parseError.err.Error()),
+		).At(source, parseError.line))
+	}
+	if err != nil {
+		return nil, withDocs(localize(ctx, withFingerprints(append(suggestions, RuleParseError.Failed(
+			i18n.Sprintf(ctx, "unable to analyze the Containerfile. Error when parsing %s : %s", name, err.Error()),
+		)), snippets(nil, content))))
+	}
+
+	coverage := coverageFrom(ctx)
+	if coverage != nil {
+		coverage.declare(res.AST)
+	}
+	ast := res.AST
+	if target, ok := targetOf(ctx); ok {
+		if ast, ok = targetAST(res.AST, target); !ok {
+			return nil, localize(ctx, []Result{
+				{
+					Name:        "Analyze error",
+					Status:      StatusFailed,
+					Severity:    SeverityCritical,
+					Description: i18n.Sprintf(ctx, "unable to analyze %s - error %s", name, fmt.Sprintf("target stage %q could not be found", target)),
+				},
+			})
+		}
+	}
+
+	ctx = withIgnoreFile(ctx)
+	results, analyzed := AnalyzeNodeFromSource(ctx, ast, source)
+	results = append(results, analyzeSyntax(ctx, content, ast, source)...)
+	results = append(results, analyzeDialect(ctx, ast, source)...)
+	results = append(results, analyzeUnknownInstructions(ctx, ast, source)...)
+	results = append(results, analyzeDuplicates(ctx, ast, source)...)
+	results = append(results, analyzeLayering(ctx, ast, source)...)
+	results = append(results, analyzeBuildContext(ctx)...)
+	results = append(results, analyzeCopiedSecrets(analyzed)...)
+	results = append(results, analyzePlugins(ctx, content)...)
+	results = withDocs(localize(ctx, withFingerprints(forPlatform(ctx, forPacks(ctx, ast, append(suggestions, results...))), snippets(ast, content))))
+	if coverage != nil {
+		coverage.CountResults(results)
+	}
+	return ast, results
+}
+
+// MAX_PARSE_ERRORS is the number of instructions which can be dropped before giving up parsing
+const MAX_PARSE_ERRORS = 20
+
+// NO_INSTRUCTIONS_ERROR is the error of the parser when the file has no instructions left
+const NO_INSTRUCTIONS_ERROR = "file with no instructions"
+
+type parseError struct {
+	line Line
+	err  error
+}
+
+// parse parses the content, blanking the lines of the instructions the parser fails on until
+// the remaining ones can be parsed. Errors without a location, located on lines which are
+// already blank or reporting a file without instructions can't be recovered from.
+func parse(content []byte) (*parser.Result, []parseError, error) {
+	var parseErrors []parseError
+	for {
+		res, err := parser.Parse(bytes.NewReader(content))
+		if err == nil {
+			return res, parseErrors, nil
+		}
+		var location *parser.ErrorLocation
+		if !errors.As(err, &location) || len(location.Location) == 0 || len(parseErrors) == MAX_PARSE_ERRORS ||
+			strings.Contains(err.Error(), NO_INSTRUCTIONS_ERROR) {
+			return nil, parseErrors, err
+		}
+		line := Line{Start: location.Location[0].Start.Line, End: location.Location[len(location.Location)-1].End.Line}
+		blanked := blankLines(content, line)
+		if bytes.Equal(blanked, content) {
+			return nil, parseErrors, err
+		}
+		parseErrors = append(parseErrors, parseError{line: line, err: err})
+		content = blanked
+	}
+}
+
+// blankLines empties the lines of the content, keeping the numbering of the other lines.
+func blankLines(content []byte, line Line) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for i := line.Start - 1; i < line.End && i < len(lines); i++ {
+		if i >= 0 {
+			lines[i] = nil
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// localize translates the names of the results, descriptions are translated when they are
+// formatted.
+func localize(ctx context.Context, results []Result) []Result {
+	for i := range results {
+		results[i].Name = i18n.Translate(ctx, results[i].Name)
+	}
+	return results
 }
 
 func AnalyzeNodeFromSource(ctx context.Context, node *parser.Node, source utils.Source) ([]Result, context.Context) {
 	suggestions := []Result{}
 	commands := []string{}
+	// the instructions of a parent image don't belong to the Containerfile, they aren't covered
+	var covered *Coverage
+	if coverage := coverageFrom(ctx); coverage != nil {
+		previous := coverage.current
+		coverage.current = nil
+		defer func() { coverage.current = previous }()
+		if source.Type != utils.Parent {
+			covered = coverage
+		}
+	}
 	for _, child := range node.Children {
 		commands = append(commands, child.Original) // TODO to be used if we need to check previous rows to make sugestions
 		line := Line{
 			Start: child.StartLine,
 			End:   child.EndLine,
 		}
-		handler := commandHandlers[strings.ToUpper(child.Value+" ")]
-		if handler != nil {
+		instruction := strings.ToUpper(child.Value + " ")
+		handler := commandHandlers[instruction]
+		ctx = context.WithValue(ctx, instructionKey, child)
+		if covered != nil {
+			covered.visit(child, handler != nil)
+		}
+		start := time.Now()
+		if handler != nil && wholeInstructions[instruction] {
+			if child.Next != nil {
+				ctx = handler.Analyze(ctx, child.Next, source, line)
+			}
+		} else if handler != nil {
 			for n := child.Next; n != nil; n = n.Next {
 				if n.Value == "" {
-					suggestions = append(suggestions, Result{
-						Name:        "Wrong value",
-						Status:      StatusFailed,
-						Severity:    SeverityMedium,
-						Description: fmt.Sprintf("%s %s has an empty value", child.Value, GenerateErrorLocation(source, line)),
-					})
+					suggestions = append(suggestions, RuleEmptyValue.Failed(
+						i18n.Sprintf(ctx, "%s %s has an empty value", child.Value, GenerateErrorLocation(ctx, source, line)),
+					).At(source, line))
 
 				} else {
 					ctx = handler.Analyze(ctx, n, source, line)
 				}
 			}
 		}
+		if handler != nil {
+			profileInstruction(ctx, strings.TrimSpace(instruction), start)
+		}
+	}
+	if covered != nil {
+		covered.current = nil
 	}
 	for key, _ := range commandHandlers {
 		handler := commandHandlers[key]
 
+		start := time.Now()
 		suggestions = append(suggestions, handler.PostProcess(ctx)...)
+		profileInstruction(ctx, strings.TrimSpace(key), start)
 	}
 	return suggestions, ctx
 }
 
+type instructionKeyType struct{}
+
+// instructionKey holds the node of the instruction being analyzed, giving the handlers access
+// to its flags and attributes
+var instructionKey instructionKeyType
+
+func currentInstruction(ctx context.Context) *parser.Node {
+	node, _ := ctx.Value(instructionKey).(*parser.Node)
+	return node
+}
+
+// isExecForm reports whether the instruction being analyzed uses the exec (JSON) form, e.g.
+// CMD ["node", "server.js"]
+func isExecForm(ctx context.Context) bool {
+	node := currentInstruction(ctx)
+	return node != nil && node.Attributes["json"]
+}
+
+// instructionFlag returns the value of the flag of the instruction being analyzed, e.g. builder
+// for COPY --from=builder.
+func instructionFlag(ctx context.Context, name string) (string, bool) {
+	node := currentInstruction(ctx)
+	if node == nil {
+		return "", false
+	}
+	return instructionFlagValue(node, name)
+}
+
+// storedResults returns the results stored in the context under key.
+func storedResults(ctx context.Context, key interface{}) []Result {
+	results, _ := ctx.Value(key).([]Result)
+	return results
+}
+
+// appendResults stores the results in the context under key, along with the results stored
+// by the previous instructions.
+func appendResults(ctx context.Context, key interface{}, results ...Result) context.Context {
+	if len(results) == 0 {
+		return ctx
+	}
+	previous := storedResults(ctx, key)
+	return context.WithValue(ctx, key, append(append([]Result{}, previous...), results...))
+}
+
 func IsCommand(text string, command string) bool {
 	return strings.Contains(text, command)
 }
 
-func GenerateErrorLocation(source utils.Source, line Line) string {
+func GenerateErrorLocation(ctx context.Context, source utils.Source, line Line) string {
 	if source.Type == utils.Parent {
-		return fmt.Sprintf("in parent image %s", source.Name)
+		return i18n.Sprintf(ctx, "in parent image %s", source.Name)
 	}
 	if line.Start == line.End {
-		return fmt.Sprintf("at line %d", line.Start)
+		return i18n.Sprintf(ctx, "at line %d", line.Start)
 	}
-	return fmt.Sprintf("at line %d-%d", line.Start, line.End)
+	return i18n.Sprintf(ctx, "at line %d-%d", line.Start, line.End)
 }
@@ -18,25 +18,77 @@
 
  package command
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+)
 
 func TestCheckNginx(t *testing.T) {
 	for _, tag := range []string{"1.25.0", "1.25.1", "1.25.2", "1.25.3"} {
 		t.Run(tag, func(t *testing.T) {
-			AnalyzeImage("docker.io/nginx:" + tag)
+			AnalyzeImage(context.Background(), "docker.io/nginx:"+tag)
 		})
 	}
 }
 
 func TestFromScratch(t *testing.T) {
-	errors := AnalyzePath("resources/Containerfile.fromscratch")
+	errors := AnalyzePath(context.Background(), "resources/Containerfile.fromscratch")
 	if len(errors) != 1 {
 		t.Error("Image with FROM scratch returns errors")
 	}
 }
 func TestFromNginxWithUser(t *testing.T) {
-	errors := AnalyzePath("resources/Containerfile.fromnginxwithuser")
-	if len(errors) != 1 {
+	errors := AnalyzePath(context.Background(), "resources/Containerfile.fromnginxwithuser")
+	// the official nginx image runs nginx as root, whatever the USER
+	if len(errors) != 2 || len(resultsOfRule(errors, RuleWebServerNonRoot)) != 1 {
 		t.Error("Image with FROM nginx with USER returns errors")
 	}
 }
+
+func TestParseErrorDoesNotStopTheAnalysis(t *testing.T) {
+	results := AnalyzeReader(context.Background(), "Containerfile", strings.NewReader("FROM scratch\nENV a=b c\nUSER 0\n"))
+	parseErrors := resultsOfRule(results, RuleParseError)
+	if len(parseErrors) != 1 || parseErrors[0].Line == nil || parseErrors[0].Line.Start != 2 {
+		t.Errorf("Expected a %s result at line 2 but they were %v", RuleParseError.ID, parseErrors)
+	}
+	users := resultsOfRule(results, RuleUserRoot)
+	if len(users) != 1 || users[0].Line == nil || users[0].Line.Start != 3 {
+		t.Errorf("Expected the instructions after the parse error to be analyzed but they were %v", results)
+	}
+}
+
+func TestParseErrorWithoutInstructions(t *testing.T) {
+	results := AnalyzeReader(context.Background(), "Containerfile", strings.NewReader("# nothing to build\n"))
+	if len(results) != 1 || results[0].RuleID != RuleParseError.ID || results[0].Line != nil {
+		t.Errorf("Expected a single %s result but they were %v", RuleParseError.ID, results)
+	}
+}
+
+func FuzzAnalyzeReader(f *testing.F) {
+	f.Add("FROM scratch\nUSER 1001\nRUN chown -R 1001:0 /app && chmod 775 /app\n")
+	f.Add("FROM scratch\nENV a=b c\nEXPOSE 80/tcp\n")
+	f.Add("FROM builder AS final\nCMD [\"run\"\nENTRYPOINT run \\\n")
+	f.Fuzz(func(t *testing.T, content string) {
+		AnalyzeReader(WithoutBaseImageAnalysis(context.Background()), "Containerfile", strings.NewReader(content))
+	})
+}
+
+func TestEscapeDirectiveJoinsBacktickContinuations(t *testing.T) {
+	results := AnalyzeReader(context.Background(), "Containerfile", strings.NewReader("# escape=`\nFROM scratch\nRUN chown 1001:1001 C:\\app `\n    && echo done\nUSER 1001\n"))
+	chowns := resultsOfRule(results, RuleChownGroup)
+	if len(chowns) != 1 || chowns[0].Line.Start != 3 || chowns[0].Line.End != 4 {
+		t.Errorf("Expected a %s result at lines 3-4 but they were %v", RuleChownGroup.ID, results)
+	}
+	if len(resultsOfRule(results, RuleEmptyValue)) != 0 {
+		t.Errorf("Expected the continuation not to be analyzed as an instruction but they were %v", results)
+	}
+}
+
+func TestWindowsLineEndings(t *testing.T) {
+	results := AnalyzeReader(context.Background(), "Containerfile", strings.NewReader("FROM scratch\r\nRUN chown 1001:1001 /app \\\r\n    && echo done\r\nUSER 1001\r\n"))
+	chowns := resultsOfRule(results, RuleChownGroup)
+	if len(chowns) != 1 || chowns[0].Line.Start != 2 || chowns[0].Line.End != 3 {
+		t.Errorf("Expected a %s result at lines 2-3 but they were %v", RuleChownGroup.ID, results)
+	}
+}
@@ -0,0 +1,120 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Annotation tells, for an instruction of the Containerfile, which rules checked it and which
+// of them failed.
+type Annotation struct {
+	Instruction string
+	Line        Line
+	// Checked are the rules checking the instruction
+	Checked []Rule
+	// Failed are the results reported at the lines of the instruction
+	Failed []Result
+}
+
+// Passed returns the rules which checked the instruction without reporting any issue.
+func (a Annotation) Passed() []Rule {
+	var passed []Rule
+	for _, rule := range a.Checked {
+		failed := false
+		for _, result := range a.Failed {
+			failed = failed || result.RuleID == rule.ID
+		}
+		if !failed {
+			passed = append(passed, rule)
+		}
+	}
+	return passed
+}
+
+// Annotated is a Containerfile along with the annotations of its instructions.
+type Annotated struct {
+	Lines       []string
+	Annotations []Annotation
+	// Unlocated are the results which don't refer to an analyzed instruction, e.g. USER
+	// implicitly set to root or the instructions which can't be parsed
+	Unlocated []Result
+}
+
+// Checks reports whether the rule checks the instruction, e.g. RUN.
+func (r Rule) Checks(instruction string) bool {
+	for _, i := range r.Instructions {
+		if strings.EqualFold(i, instruction) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotateReader analyzes the Containerfile read from reader and annotates each of its
+// instructions with the rules checking it, name is only used to report errors.
+func AnnotateReader(ctx context.Context, name string, reader io.Reader) (*Annotated, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	ast, results := parseAndAnalyze(ctx, name, content)
+	if ast == nil {
+		return nil, errors.New(results[len(results)-1].Description)
+	}
+
+	annotated := &Annotated{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		// the lines of Containerfiles written on Windows end with \r\n
+		annotated.Lines = append(annotated.Lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+
+	located := map[int]bool{}
+	for _, child := range ast.Children {
+		annotation := Annotation{
+			Instruction: strings.ToUpper(child.Value),
+			Line:        Line{Start: child.StartLine, End: child.EndLine},
+		}
+		for _, rule := range Rules {
+			if rule.Checks(annotation.Instruction) {
+				annotation.Checked = append(annotation.Checked, rule)
+			}
+		}
+		for i, result := range results {
+			if result.Line != nil && result.Line.Start >= annotation.Line.Start && result.Line.Start <= annotation.Line.End {
+				annotation.Failed = append(annotation.Failed, result)
+				located[i] = true
+			}
+		}
+		annotated.Annotations = append(annotated.Annotations, annotation)
+	}
+	for i, result := range results {
+		if !located[i] {
+			annotated.Unlocated = append(annotated.Unlocated, result)
+		}
+	}
+	return annotated, nil
+}
@@ -0,0 +1,67 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateReportsCheckedRulesPerInstruction(t *testing.T) {
+	annotated, err := AnnotateReader(context.Background(), "Containerfile", strings.NewReader("FROM scratch\nUSER 1001\nRUN chown -R 1000:1000 /data\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(annotated.Lines) != 3 || len(annotated.Annotations) != 3 {
+		t.Fatalf("Expected 3 lines and 3 annotations but they were %d and %d", len(annotated.Lines), len(annotated.Annotations))
+	}
+	user := annotated.Annotations[1]
+	if user.Instruction != "USER" || len(user.Failed) != 0 || len(user.Passed()) != len(user.Checked) || len(user.Checked) == 0 {
+		t.Errorf("Expected USER to pass every rule checking it but it was %v", user)
+	}
+	run := annotated.Annotations[2]
+	if len(resultsOfRule(run.Failed, RuleChownGroup)) != 1 {
+		t.Errorf("Expected a %s failure on RUN but they were %v", RuleChownGroup.ID, run.Failed)
+	}
+	for _, rule := range run.Passed() {
+		if rule.ID == RuleChownGroup.ID {
+			t.Errorf("Expected %s not to be reported as passed", RuleChownGroup.ID)
+		}
+	}
+}
+
+func TestAnnotateReportsUnlocatedResults(t *testing.T) {
+	annotated, err := AnnotateReader(context.Background(), "Containerfile", strings.NewReader("FROM scratch\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(annotated.Unlocated) != 1 {
+		t.Errorf("Expected 1 result not bound to an instruction but they were %v", annotated.Unlocated)
+	}
+}
+
+func TestAnnotateStripsWindowsLineEndings(t *testing.T) {
+	annotated, err := AnnotateReader(context.Background(), "Containerfile", strings.NewReader("FROM scratch\r\nUSER 1001\r\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(annotated.Lines) != 2 || annotated.Lines[1] != "USER 1001" {
+		t.Errorf("Unexpected lines %q", annotated.Lines)
+	}
+}
@@ -0,0 +1,92 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// buildTools are compilers and build tools which are only needed to build the application
+var buildTools = map[string]bool{
+	"build-essential": true,
+	"gcc":             true,
+	"g++":             true,
+	"gcc-c++":         true,
+	"clang":           true,
+	"make":            true,
+	"cmake":           true,
+	"autoconf":        true,
+	"automake":        true,
+	"libtool":         true,
+	"maven":           true,
+	"gradle":          true,
+	"golang":          true,
+	"go-toolset":      true,
+	"rust":            true,
+	"cargo":           true,
+	"node-gyp":        true,
+}
+
+// buildImageRegexp matches the images providing build toolchains
+var buildImageRegexp = regexp.MustCompile(`(^|/)(golang|maven|gradle|gcc|rust|go-toolset)(:|@|$)`)
+
+var npmGlobalInstallRegexp = regexp.MustCompile(`\bnpm\s+(install|i)\s+(-g|--global)\s+([^;&|]+)`)
+
+/*
+RUN dnf install -y gcc make && make install
+RUN npm install -g node-gyp
+*/
+func analyzeBuildTools(ctx context.Context, s string, source utils.Source, line Line) []Result {
+	var installed []string
+	matches := append(packageInstallRegexp.FindAllStringSubmatch(s, -1), npmGlobalInstallRegexp.FindAllStringSubmatch(s, -1)...)
+	for _, match := range matches {
+		for _, pkg := range strings.Fields(match[len(match)-1]) {
+			if name := packageName(pkg); buildTools[name] {
+				installed = append(installed, name)
+			}
+		}
+	}
+	if len(installed) == 0 {
+		return nil
+	}
+	return []Result{RuleBuildToolsInFinalStage.Failed(i18n.Sprintf(ctx, `build tools %s installed %s are left in the final stage. They inflate the image and its attack surface, install them in a builder stage and copy the built artifacts only`,
+		strings.Join(installed, ", "), GenerateErrorLocation(ctx, source, line))).At(source, line)}
+}
+
+func analyzeBuildImage(ctx context.Context, image string, source utils.Source, line Line) []Result {
+	if !buildImageRegexp.MatchString(image) {
+		return nil
+	}
+	return []Result{RuleBuildToolsInFinalStage.Failed(i18n.Sprintf(ctx, `the final stage is based on the build image %s %s. It inflates the image and its attack surface, build the application in a builder stage and copy the built artifacts to a runtime image`,
+		image, GenerateErrorLocation(ctx, source, line))).At(source, line)}
+}
+
+// packageName strips the version from a package spec, e.g. gcc=4:12.2.0-3 or gcc-11.3.1
+func packageName(pkg string) string {
+	pkg = strings.SplitN(pkg, "=", 2)[0]
+	pkg = strings.SplitN(pkg, "@", 2)[0]
+	for rpmVersionRegexp.MatchString(pkg) {
+		pkg = rpmVersionRegexp.ReplaceAllString(pkg, "")
+	}
+	return pkg
+}
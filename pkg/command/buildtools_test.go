@@ -0,0 +1,61 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+func TestFailIfBuildToolsInFinalStage(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN dnf install -y gcc-11.3.1-4.el9 make python3 && make\n"), RuleBuildToolsInFinalStage)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleBuildToolsInFinalStage.ID, results)
+	}
+}
+
+func TestBuildToolsInBuilderStage(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch AS builder\nRUN npm install -g node-gyp\nFROM scratch\nUSER 1001\nCOPY --from=builder /app /app\n"), RuleBuildToolsInFinalStage)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestFailIfBuildImageUsedAsFinalStage(t *testing.T) {
+	results := analyzeBuildImage(context.Background(), "registry.access.redhat.com/ubi9/go-toolset:1.20", utils.Source{Name: "test", Type: utils.Image}, Line{Start: 1, End: 1})
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleBuildToolsInFinalStage.ID, results)
+	}
+}
+
+func TestStagesAreNamed(t *testing.T) {
+	res := analyzeContentContext(t, "FROM scratch AS builder\nFROM scratch AS runtime\n")
+	stage, ok := CurrentStage(res)
+	if !ok || stage.Index != 1 || stage.Name != "runtime" {
+		t.Errorf("Unexpected final stage %v", stage)
+	}
+}
+
+func TestResultsOfEveryRunInstructionAreReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nRUN chown -R node:node /app\nRUN chown -R node:node /data\nUSER 1001\n"), RuleChownGroup)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 suggestions but they were %v", results)
+	}
+}
@@ -0,0 +1,70 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Schemes of the BuildKit named contexts, e.g. --build-context base=docker-image://alpine:3.19
+const (
+	DOCKER_IMAGE_SCHEME = "docker-image://"
+	OCI_LAYOUT_SCHEME   = "oci-layout://"
+)
+
+type namedContextsKeyType struct{}
+
+var namedContextsKey namedContextsKeyType
+
+// ParseNamedContext parses a --build-context flag, name=value.
+func ParseNamedContext(value string) (string, string, error) {
+	nameValue := strings.SplitN(value, "=", 2)
+	if len(nameValue) != 2 || nameValue[0] == "" || nameValue[1] == "" {
+		return "", "", errors.Errorf("invalid build context %s, expected name=value", value)
+	}
+	return nameValue[0], nameValue[1], nil
+}
+
+// WithNamedContexts sets the additional build contexts, by name, referenced by FROM and
+// COPY --from. A value is an image (docker-image://), an OCI layout (oci-layout://), a URL or a
+// local directory.
+func WithNamedContexts(ctx context.Context, contexts map[string]string) context.Context {
+	return context.WithValue(ctx, namedContextsKey, contexts)
+}
+
+func namedContext(ctx context.Context, name string) (string, bool) {
+	contexts, _ := ctx.Value(namedContextsKey).(map[string]string)
+	value, ok := contexts[name]
+	return value, ok
+}
+
+// resolveImage returns the image referenced by FROM or COPY --from, named contexts being
+// resolved, and whether it can be analyzed as an image. Stages are resolved by the caller.
+func resolveImage(ctx context.Context, reference string) (string, bool) {
+	if value, ok := namedContext(ctx, reference); ok {
+		reference = value
+	}
+	if strings.HasPrefix(reference, DOCKER_IMAGE_SCHEME) {
+		return strings.TrimPrefix(reference, DOCKER_IMAGE_SCHEME), true
+	}
+	// an OCI layout, a Git or HTTP URL or a local directory
+	return reference, !strings.Contains(reference, "://") && !strings.HasPrefix(reference, "/") && !strings.HasPrefix(reference, ".")
+}
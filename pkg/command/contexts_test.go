@@ -0,0 +1,91 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+func analyzeWithNamedContexts(t *testing.T, content string, contexts map[string]string) []Result {
+	res, err := parser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %s", content, err)
+	}
+	ctx := WithNamedContexts(context.Background(), contexts)
+	results, _ := AnalyzeNodeFromSource(ctx, res.AST, utils.Source{Name: "test", Type: utils.Image})
+	return results
+}
+
+func TestParseNamedContext(t *testing.T) {
+	name, value, err := ParseNamedContext("base=docker-image://alpine:3.19")
+	if err != nil || name != "base" || value != "docker-image://alpine:3.19" {
+		t.Errorf("Unexpected name %s, value %s and error %v", name, value, err)
+	}
+	if _, _, err := ParseNamedContext("base"); err == nil {
+		t.Errorf("Expected an error for a build context without value")
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	ctx := WithNamedContexts(context.Background(), map[string]string{
+		"base":   "docker-image://registry.access.redhat.com/ubi9/ubi:9.3",
+		"layout": "oci-layout:///tmp/layout",
+		"assets": "./assets",
+	})
+	for reference, expected := range map[string]struct {
+		image      string
+		analyzable bool
+	}{
+		"base":                     {"registry.access.redhat.com/ubi9/ubi:9.3", true},
+		"layout":                   {"oci-layout:///tmp/layout", false},
+		"assets":                   {"./assets", false},
+		"oci-layout:///tmp/layout": {"oci-layout:///tmp/layout", false},
+		"nginx:1.25":               {"nginx:1.25", true},
+	} {
+		image, analyzable := resolveImage(ctx, reference)
+		if image != expected.image || analyzable != expected.analyzable {
+			t.Errorf("Expected %s to resolve to %s (%t) but it was %s (%t)", reference, expected.image, expected.analyzable, image, analyzable)
+		}
+	}
+}
+
+func TestFromStageIsNotAnalyzedAsAnImage(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch AS base\nUSER 1001\nFROM base\nCMD [\"run\"]\n")
+	if failures := resultsOfRule(results, RuleBaseImageAnalysis); len(failures) != 0 {
+		t.Errorf("Expected the stage not to be analyzed as an image but they were %v", failures)
+	}
+}
+
+func TestFromOCILayoutIsNotAnalyzedAsAnImage(t *testing.T) {
+	results := analyzeWithNamedContexts(t, "FROM oci-layout:///tmp/layout\nUSER 1001\nFROM layout\nUSER 1001\n", map[string]string{"layout": "oci-layout:///tmp/layout"})
+	if failures := resultsOfRule(results, RuleBaseImageAnalysis); len(failures) != 0 {
+		t.Errorf("Expected the OCI layouts not to be analyzed as images but they were %v", failures)
+	}
+}
+
+func TestCopyFromNamedContextProvenance(t *testing.T) {
+	results := resultsOfRule(analyzeWithNamedContexts(t, "FROM scratch\nCOPY --from=assets /logo.png /app/logo.png\nRUN chmod 664 /app/logo.png\nUSER 1001\n", map[string]string{"assets": "./assets"}), RuleOwnershipFixAfterCopy)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "the build context assets") {
+		t.Errorf("Expected a %s suggestion about the build context but they were %v", RuleOwnershipFixAfterCopy.ID, results)
+	}
+}
@@ -0,0 +1,140 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Copy and Add analyze the files copied into the image. The handlers get all the arguments, the
+// last one being the destination.
+type Copy struct{}
+
+type Add struct{}
+
+type copyResultKeyType struct{}
+type addResultKeyType struct{}
+
+var copyResultKey copyResultKeyType
+var addResultKey addResultKeyType
+
+// secretFilePatterns match the names of files usually holding credentials or private keys
+var secretFilePatterns = []string{
+	"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	".npmrc", ".netrc", ".pypirc", ".git-credentials", ".dockercfg",
+	"credentials", "credentials.json", ".env", ".env.*", "kubeconfig",
+}
+
+// secretDirectories usually hold credentials
+var secretDirectories = map[string]bool{
+	".ssh":    true,
+	".aws":    true,
+	".gnupg":  true,
+	".kube":   true,
+	".docker": true,
+}
+
+func (c Copy) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	for _, src := range copySources(node) {
+		ctx = withListenedPorts(ctx, configPorts(ctx, src, source, line)...)
+		if source.Type != utils.Parent {
+			ctx = withRuntimeWrites(ctx, configWrites(ctx, src, source, line)...)
+		}
+	}
+	if source.Type != utils.Parent {
+		ctx = appendFinalStageResults(ctx, profileRule(ctx, RuleWebServerNonRoot, func() []Result {
+			return analyzeWebServerConfigs(ctx, node, source, line)
+		})...)
+		ctx = withCopiedFiles(ctx, "COPY", node, line)
+	}
+	results := append(analyzeCopiedFiles(ctx, "COPY", node, source, line), analyzeCopyOwner(ctx, "COPY", source, line)...)
+	results = append(results, analyzeIgnoredSources(ctx, "COPY", node, source, line)...)
+	results = append(results, analyzeMissingSources(ctx, "COPY", node, source, line)...)
+	return appendResults(ctx, copyResultKey, results...)
+}
+
+func (c Copy) PostProcess(ctx context.Context) []Result {
+	return storedResults(ctx, copyResultKey)
+}
+
+func (a Add) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	if source.Type != utils.Parent {
+		ctx = withCopiedFiles(ctx, "ADD", node, line)
+	}
+	results := append(analyzeCopiedFiles(ctx, "ADD", node, source, line), analyzeCopyOwner(ctx, "ADD", source, line)...)
+	results = append(results, analyzeIgnoredSources(ctx, "ADD", node, source, line)...)
+	results = append(results, analyzeMissingSources(ctx, "ADD", node, source, line)...)
+	return appendResults(ctx, addResultKey, results...)
+}
+
+func (a Add) PostProcess(ctx context.Context) []Result {
+	return storedResults(ctx, addResultKey)
+}
+
+// copySources returns the sources of a COPY or ADD instruction, i.e. all the arguments but the
+// destination.
+func copySources(node *parser.Node) []string {
+	var args []string
+	for n := node; n != nil; n = n.Next {
+		args = append(args, n.Value)
+	}
+	if len(args) < 2 {
+		return nil
+	}
+	return args[:len(args)-1]
+}
+
+/*
+COPY id_rsa /home/app/.ssh/id_rsa
+COPY .npmrc package.json /app/
+ADD certs/server.key /etc/pki/
+*/
+func analyzeCopiedFiles(ctx context.Context, instruction string, node *parser.Node, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, src := range copySources(node) {
+		if strings.Contains(src, "://") || !isSecretFile(src) {
+			continue
+		}
+		results = append(results, RuleSecretCopy.Failed(i18n.Sprintf(ctx, `%s of %s %s copies a file which could contain credentials into the image, where anyone pulling it can read them. Use build secrets (RUN --mount=type=secret) or mount an OpenShift Secret at runtime instead`,
+			instruction, src, GenerateErrorLocation(ctx, source, line))).At(source, line))
+	}
+	return results
+}
+
+func isSecretFile(file string) bool {
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+	for _, dir := range strings.Split(file, "/") {
+		if secretDirectories[dir] {
+			return true
+		}
+	}
+	name := path.Base(file)
+	for _, pattern := range secretFilePatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
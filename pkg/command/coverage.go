@@ -0,0 +1,170 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// NodeCoverage is the coverage of an instruction of the Containerfile.
+type NodeCoverage struct {
+	Instruction string `json:"instruction"`
+	Line        Line   `json:"line"`
+	// Evaluated is set when the instruction was analyzed, the instructions without handler (e.g.
+	// WORKDIR) and the ones of the stages left out by WithTarget are not
+	Evaluated bool `json:"evaluated"`
+	// Rules are the rules which checked the instruction or reported a result on it
+	Rules []string `json:"rules,omitempty"`
+}
+
+// Covered reports whether at least one rule checked the instruction.
+func (n NodeCoverage) Covered() bool {
+	return n.Evaluated && len(n.Rules) > 0
+}
+
+// Status describes the coverage of the instruction: covered, not covered or not evaluated.
+func (n NodeCoverage) Status() string {
+	switch {
+	case !n.Evaluated:
+		return "not evaluated"
+	case n.Covered():
+		return "covered"
+	}
+	return "not covered"
+}
+
+// Coverage records the instructions of the Containerfile evaluated by the rules, see
+// WithCoverage, to show the blind spots of the analysis.
+type Coverage struct {
+	nodes map[*parser.Node]*nodeCoverage
+	// current is the instruction being analyzed, nil during the post-processing
+	current *parser.Node
+}
+
+type nodeCoverage struct {
+	NodeCoverage
+	rules map[string]bool
+}
+
+type coverageKeyType struct{}
+
+var coverageKey coverageKeyType
+
+func NewCoverage() *Coverage {
+	return &Coverage{nodes: map[*parser.Node]*nodeCoverage{}}
+}
+
+// WithCoverage records the coverage of the instructions in coverage.
+func WithCoverage(ctx context.Context, coverage *Coverage) context.Context {
+	return context.WithValue(ctx, coverageKey, coverage)
+}
+
+func coverageFrom(ctx context.Context) *Coverage {
+	coverage, _ := ctx.Value(coverageKey).(*Coverage)
+	return coverage
+}
+
+// declare starts recording the coverage of the instructions of the syntax tree, not evaluated yet.
+// The coverage is the one of the last analyzed Containerfile, e.g. in watch mode.
+func (c *Coverage) declare(node *parser.Node) {
+	c.nodes = map[*parser.Node]*nodeCoverage{}
+	c.current = nil
+	if node == nil {
+		return
+	}
+	for _, child := range node.Children {
+		c.node(child)
+	}
+}
+
+func (c *Coverage) node(child *parser.Node) *nodeCoverage {
+	entry, ok := c.nodes[child]
+	if !ok {
+		entry = &nodeCoverage{
+			NodeCoverage: NodeCoverage{
+				Instruction: strings.ToUpper(child.Value),
+				Line:        Line{Start: child.StartLine, End: child.EndLine},
+			},
+			rules: map[string]bool{},
+		}
+		c.nodes[child] = entry
+	}
+	return entry
+}
+
+// visit records that the instruction is analyzed, evaluated being false when no handler analyzes
+// it. The rules statically checking an evaluated instruction cover it.
+func (c *Coverage) visit(child *parser.Node, evaluated bool) {
+	entry := c.node(child)
+	c.current = nil
+	if !evaluated {
+		return
+	}
+	c.current = child
+	entry.Evaluated = true
+	for _, rule := range Rules {
+		if rule.Checks(entry.Instruction) {
+			entry.rules[rule.ID] = true
+		}
+	}
+}
+
+// ran records that the rule checked the instruction being analyzed.
+func (c *Coverage) ran(rule Rule) {
+	if c.current != nil {
+		c.node(c.current).rules[rule.ID] = true
+	}
+}
+
+// CountResults records the rules of the results as covering the instructions they are reported
+// on, including the results of the post-processing.
+func (c *Coverage) CountResults(results []Result) {
+	for _, result := range results {
+		if result.RuleID == "" || result.Line == nil {
+			continue
+		}
+		for _, entry := range c.nodes {
+			if entry.Evaluated && result.Line.Start >= entry.Line.Start && result.Line.Start <= entry.Line.End {
+				entry.rules[result.RuleID] = true
+			}
+		}
+	}
+}
+
+// Entries returns the coverage of the instructions, in the order of the Containerfile.
+func (c *Coverage) Entries() []NodeCoverage {
+	var entries []NodeCoverage
+	for _, entry := range c.nodes {
+		coverage := entry.NodeCoverage
+		coverage.Rules = nil
+		for rule := range entry.rules {
+			coverage.Rules = append(coverage.Rules, rule)
+		}
+		sort.Strings(coverage.Rules)
+		entries = append(entries, coverage)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Line.Start < entries[j].Line.Start
+	})
+	return entries
+}
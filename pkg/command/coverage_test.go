@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	content := "FROM alpine AS test\nRUN make test\nFROM registry.access.redhat.com/ubi9/ubi-minimal\nWORKDIR /app\nUSER root\nEXPOSE 80\n"
+	coverage := NewCoverage()
+	ctx := WithCoverage(context.Background(), coverage)
+	parseAndAnalyze(ctx, "Containerfile", []byte(content))
+
+	entries := coverage.Entries()
+	if len(entries) != 6 {
+		t.Fatalf("Expected the coverage of 6 instructions but got %v", entries)
+	}
+	workdir := entries[3]
+	if workdir.Instruction != "WORKDIR" || workdir.Evaluated || workdir.Covered() {
+		t.Errorf("Expected WORKDIR not to be evaluated but its coverage was %v", workdir)
+	}
+	user := entries[4]
+	if !user.Covered() || !containsString(user.Rules, RuleUserRoot.ID) {
+		t.Errorf("Expected USER to be covered by %s but its coverage was %v", RuleUserRoot.ID, user)
+	}
+	expose := entries[5]
+	if !expose.Covered() || !containsString(expose.Rules, RulePrivilegedPort.ID) {
+		t.Errorf("Expected EXPOSE to be covered by %s but its coverage was %v", RulePrivilegedPort.ID, expose)
+	}
+}
+
+func TestCoverageOfTarget(t *testing.T) {
+	content := "FROM alpine AS test\nRUN make test\nFROM registry.access.redhat.com/ubi9/ubi-minimal\nUSER 1001\n"
+	coverage := NewCoverage()
+	parseAndAnalyze(WithTarget(WithCoverage(context.Background(), coverage), "test"), "Containerfile", []byte(content))
+	for _, entry := range coverage.Entries() {
+		if evaluated := entry.Line.Start <= 2; entry.Evaluated != evaluated {
+			t.Errorf("Expected the instruction at line %d to be evaluated: %t", entry.Line.Start, evaluated)
+		}
+	}
+}
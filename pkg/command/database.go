@@ -0,0 +1,189 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type dataDirsKeyType struct{}
+type rootGroupKeyType struct{}
+
+// dataDirsKey holds the data directories of the databases set by ENV or VOLUME
+var dataDirsKey dataDirsKeyType
+
+// rootGroupKey holds the paths given to the root group by chown or chgrp in each stage
+var rootGroupKey rootGroupKeyType
+
+// dataDir is the data directory of a database
+type dataDir struct {
+	database string
+	path     string
+	origin   string
+	stage    int
+	source   utils.Source
+	line     Line
+}
+
+// dataDirVariables are the variables setting the data directory of the databases
+var dataDirVariables = map[string]string{
+	"PGDATA":          "PostgreSQL",
+	"MYSQL_DATADIR":   "MySQL",
+	"MARIADB_DATADIR": "MariaDB",
+}
+
+// dataDirVolumeRegexp matches the default data directories of the databases, declared as volumes
+var dataDirVolumeRegexp = regexp.MustCompile(`^/var/lib/(postgresql|pgsql|mysql|mariadb)(?:/|$)`)
+
+var dataDirVolumeDatabases = map[string]string{
+	"postgresql": "PostgreSQL",
+	"pgsql":      "PostgreSQL",
+	"mysql":      "MySQL",
+	"mariadb":    "MariaDB",
+}
+
+// rootGroupRegexp matches the commands giving the root group the ownership of paths, e.g.
+// chown -R 1001:0 /var/lib/pgsql or chgrp -R root /var/lib/mysql
+var rootGroupRegexp = regexp.MustCompile(`^(?:chown\s+(?:-\S+\s+)*\S*:(?:0|root)|chgrp\s+(?:-\S+\s+)*(?:0|root))\s`)
+
+// initdbRegexp matches the initialization of a database cluster
+var initdbRegexp = regexp.MustCompile(`\b(?:initdb|pg_ctl\s+(?:-\S+\s+)*initdb|mysql_install_db|mariadb-install-db|mysqld\s+(?:\S+\s+)*--initialize(?:-insecure)?)\b`)
+
+// switchUserRegexp captures the user a command is run as, e.g. su postgres -c, gosu postgres,
+// runuser -u postgres or sudo -u postgres
+var switchUserRegexp = regexp.MustCompile(`\b(?:su\s+(?:-\s+|-l\s+)?|gosu\s+|su-exec\s+|runuser\s+(?:-u\s+|-l\s+)?|sudo\s+-u\s+)([a-z_][\w-]*|\d+)\b`)
+
+/*
+ENV PGDATA=/var/lib/postgresql/data/pgdata
+ENV MYSQL_DATADIR=/var/lib/mysql/data
+*/
+func withDataDirs(ctx context.Context, origin string, name string, value string, source utils.Source, line Line) context.Context {
+	database, ok := dataDirVariables[strings.ToUpper(name)]
+	if !ok || !path.IsAbs(value) {
+		return ctx
+	}
+	return appendDataDir(ctx, database, path.Clean(value), origin, source, line)
+}
+
+/*
+VOLUME /var/lib/postgresql/data
+*/
+func withDataDirVolume(ctx context.Context, volume string, source utils.Source, line Line) context.Context {
+	match := dataDirVolumeRegexp.FindStringSubmatch(volume)
+	if match == nil {
+		return ctx
+	}
+	return appendDataDir(ctx, dataDirVolumeDatabases[match[1]], volume, "VOLUME", source, line)
+}
+
+func appendDataDir(ctx context.Context, database string, dir string, origin string, source utils.Source, line Line) context.Context {
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(dataDirsKey).([]dataDir)
+	return context.WithValue(ctx, dataDirsKey, append(append([]dataDir{}, previous...), dataDir{
+		database: database,
+		path:     dir,
+		origin:   origin,
+		stage:    stage.Index,
+		source:   source,
+		line:     line,
+	}))
+}
+
+// withRootGroupPaths records the paths the chown and chgrp commands of a RUN instruction give to
+// the root group.
+func withRootGroupPaths(ctx context.Context, commands []string) context.Context {
+	var paths []groupWritablePath
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		if !rootGroupRegexp.MatchString(command) {
+			continue
+		}
+		targets, recursive := ownershipTargets(command)
+		for _, target := range targets {
+			paths = append(paths, groupWritablePath{path: path.Clean(target), recursive: recursive})
+		}
+	}
+	if len(paths) == 0 {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(rootGroupKey).(map[int][]groupWritablePath)
+	owned := map[int][]groupWritablePath{}
+	for index, p := range previous {
+		owned[index] = p
+	}
+	owned[stage.Index] = append(append([]groupWritablePath{}, owned[stage.Index]...), paths...)
+	return context.WithValue(ctx, rootGroupKey, owned)
+}
+
+// analyzeDataDirs reports the data directories of the final stage which are not owned by the root
+// group and group writable, themselves or their parent directory: the database started by the
+// arbitrary UID can't create its files.
+func analyzeDataDirs(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	dirs, _ := ctx.Value(dataDirsKey).([]dataDir)
+	writable, _ := ctx.Value(groupWritableKey).(map[int][]groupWritablePath)
+	owned, _ := ctx.Value(rootGroupKey).(map[int][]groupWritablePath)
+	var results []Result
+	reported := map[string]bool{}
+	for _, dir := range dirs {
+		if dir.stage != stage.Index || reported[dir.path] {
+			continue
+		}
+		if isGroupWritable(dir.path, writable[stage.Index]) && isGroupWritable(dir.path, owned[stage.Index]) {
+			continue
+		}
+		reported[dir.path] = true
+		parent := path.Dir(dir.path)
+		results = append(results, RuleDatabaseDataDir.Failed(i18n.Sprintf(ctx, `%s %s sets the %s data directory %s, which is not prepared for the arbitrary UID of OpenShift. Give it, or its parent directory, to the root group and make it group writable (chgrp -R 0 %s && chmod -R g=u %s)`,
+			dir.origin, GenerateErrorLocation(ctx, dir.source, dir.line), dir.database, dir.path, parent, parent)).At(dir.source, dir.line))
+	}
+	return results
+}
+
+/*
+USER postgres
+RUN initdb -D /var/lib/postgresql/data
+RUN su postgres -c "initdb -D /var/lib/postgresql/data"
+*/
+func analyzeInitdb(ctx context.Context, s string, source utils.Source, line Line) []Result {
+	command := initdbRegexp.FindString(s)
+	if command == "" {
+		return nil
+	}
+	user := ""
+	if match := switchUserRegexp.FindStringSubmatch(s); match != nil && match[1] != "root" && match[1] != "0" {
+		user = match[1]
+	} else if uid, _ := ctx.Value(userUIDKey).(string); uid != "" && uid != "0" {
+		user = uid
+	} else if result, _ := ctx.Value(userNameResultKey).(*Result); result != nil {
+		user = i18n.Translate(ctx, "the user of the USER instruction")
+	}
+	if user == "" {
+		return nil
+	}
+	return []Result{RuleDatabaseDataDir.Failed(i18n.Sprintf(ctx, `'%s' %s initializes the database as %s at build time: the data directory is owned by this fixed UID, which the arbitrary UID OpenShift runs the container with can't use. Initialize the database when the container starts, like the entrypoints of the database images do`,
+		command, GenerateErrorLocation(ctx, source, line), user)).At(source, line)}
+}
@@ -0,0 +1,77 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfDataDirIsNotPrepared(t *testing.T) {
+	for instruction, database := range map[string]string{
+		"ENV PGDATA=/var/lib/postgresql/data/pgdata": "PostgreSQL",
+		"ENV MYSQL_DATADIR=/var/lib/mysql/data":      "MySQL",
+		"VOLUME /var/lib/mysql":                      "MySQL",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleDatabaseDataDir)
+			if len(results) != 1 || results[0].Line.Start != 3 || !strings.Contains(results[0].Description, database) {
+				t.Errorf("Expected a %s finding at line 3 but they were %v", RuleDatabaseDataDir.ID, results)
+			}
+		})
+	}
+}
+
+func TestPreparedDataDirIsNotReported(t *testing.T) {
+	for _, prepare := range []string{
+		"RUN mkdir -p /var/lib/pgsql/data && chgrp -R 0 /var/lib/pgsql/data && chmod -R g=u /var/lib/pgsql/data",
+		"RUN chown -R 26:0 /var/lib/pgsql && chmod -R 775 /var/lib/pgsql",
+	} {
+		t.Run(prepare, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\n"+prepare+"\nENV PGDATA=/var/lib/pgsql/data/userdata\nUSER 26\n"), RuleDatabaseDataDir)
+			if len(results) != 0 {
+				t.Errorf("Expected no finding but they were %v", results)
+			}
+		})
+	}
+}
+
+func TestDataDirOnlyGroupWritableIsReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nRUN chown -R postgres:postgres /var/lib/pgsql && chmod -R 775 /var/lib/pgsql\nENV PGDATA=/var/lib/pgsql/data\n"), RuleDatabaseDataDir)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s finding at line 3 but they were %v", RuleDatabaseDataDir.ID, results)
+	}
+}
+
+func TestFailIfDatabaseIsInitializedByFixedUID(t *testing.T) {
+	for _, containerfile := range []string{
+		"FROM scratch\nUSER 999\nRUN initdb -D /var/lib/postgresql/data\n",
+		"FROM scratch\nRUN su postgres -c \"initdb -D /var/lib/postgresql/data\"\n",
+		"FROM scratch\nRUN gosu mysql mysqld --initialize-insecure --datadir=/var/lib/mysql\n",
+	} {
+		t.Run(containerfile, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, containerfile), RuleDatabaseDataDir)
+			if len(results) != 1 || !strings.Contains(results[0].Description, "at build time") {
+				t.Errorf("Expected a %s finding but they were %v", RuleDatabaseDataDir.ID, results)
+			}
+		})
+	}
+	if results := resultsOfRule(analyzeContent(t, "FROM scratch AS builder\nUSER 999\nRUN initdb -D /data\nFROM scratch\n"), RuleDatabaseDataDir); len(results) != 0 {
+		t.Errorf("Expected no finding for a builder stage but they were %v", results)
+	}
+}
@@ -0,0 +1,150 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Dialect is the builder the Containerfile is written for, the instruction flags they support
+// differ.
+type Dialect string
+
+const (
+	// DialectDocker is docker build with BuildKit and the Dockerfile frontend
+	DialectDocker Dialect = "docker"
+	// DialectPodman is podman build and Buildah, used by the OpenShift builds
+	DialectPodman Dialect = "podman"
+)
+
+type dialectKeyType struct{}
+
+var dialectKey dialectKeyType
+
+// dialectFlags are the flags of the instructions supported by each builder
+var dialectFlags = map[Dialect]map[string][]string{
+	DialectDocker: {
+		"run":         {"mount", "network", "security"},
+		"copy":        {"from", "chown", "chmod", "link", "parents", "exclude"},
+		"add":         {"chown", "chmod", "link", "keep-git-dir", "checksum", "exclude"},
+		"from":        {"platform"},
+		"healthcheck": {"interval", "timeout", "start-period", "start-interval", "retries"},
+	},
+	DialectPodman: {
+		"run":         {"mount", "network"},
+		"copy":        {"from", "chown", "chmod", "link", "parents", "exclude"},
+		"add":         {"chown", "chmod", "link", "keep-git-dir", "checksum", "exclude"},
+		"from":        {"platform"},
+		"healthcheck": {"interval", "timeout", "start-period", "start-interval", "retries"},
+	},
+}
+
+// mountOptions are the options of RUN --mount supported by BuildKit
+var mountOptions = map[string]bool{
+	"type": true, "target": true, "dst": true, "destination": true, "source": true, "src": true,
+	"from": true, "ro": true, "readonly": true, "rw": true, "readwrite": true, "id": true,
+	"sharing": true, "mode": true, "uid": true, "gid": true, "required": true, "size": true, "env": true,
+}
+
+// podmanMountOptions are the options of RUN --mount only supported by Podman and Buildah, e.g.
+// the SELinux relabeling options z and Z
+var podmanMountOptions = map[string]bool{
+	"z": true, "Z": true, "U": true, "relabel": true, "bind-propagation": true, "bind-nonrecursive": true,
+	"tmpfs-size": true, "tmpfs-mode": true, "nosuid": true, "nodev": true, "noexec": true,
+}
+
+func ParseDialect(value string) (Dialect, error) {
+	dialect := Dialect(strings.ToLower(value))
+	if _, ok := dialectFlags[dialect]; !ok {
+		return "", fmt.Errorf("unknown dialect %s, expected one of docker, podman", value)
+	}
+	return dialect, nil
+}
+
+// WithDialect sets the builder the Containerfile is written for, DialectDocker by default.
+func WithDialect(ctx context.Context, dialect Dialect) context.Context {
+	return context.WithValue(ctx, dialectKey, dialect)
+}
+
+func dialectOf(ctx context.Context) Dialect {
+	if dialect, ok := ctx.Value(dialectKey).(Dialect); ok {
+		return dialect
+	}
+	return DialectDocker
+}
+
+/*
+RUN --mount=type=bind,source=.,target=/src,Z make
+RUN --security=insecure ./test.sh
+*/
+func analyzeDialect(ctx context.Context, ast *parser.Node, source utils.Source) []Result {
+	dialect := dialectOf(ctx)
+	var results []Result
+	for _, node := range ast.Children {
+		instruction := strings.ToLower(node.Value)
+		supported, ok := dialectFlags[dialect][instruction]
+		if !ok {
+			continue
+		}
+		line := Line{Start: node.StartLine, End: node.EndLine}
+		for _, flag := range node.Flags {
+			name := strings.SplitN(strings.TrimPrefix(flag, "--"), "=", 2)[0]
+			if !containsString(supported, name) {
+				results = append(results, RuleUnsupportedFlag.Failed(i18n.Sprintf(ctx, `flag --%s of %s %s is not supported by %s build, the build fails`,
+					name, node.Value, GenerateErrorLocation(ctx, source, line), dialect)).At(source, line))
+				continue
+			}
+			if name == "mount" {
+				results = append(results, analyzeMountOptions(ctx, dialect, strings.TrimPrefix(flag, "--mount="), source, line)...)
+			}
+		}
+	}
+	return results
+}
+
+func analyzeMountOptions(ctx context.Context, dialect Dialect, mount string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, option := range strings.Split(mount, ",") {
+		name := strings.SplitN(option, "=", 2)[0]
+		switch {
+		case mountOptions[name] || name == "":
+		case podmanMountOptions[name] && dialect == DialectDocker:
+			results = append(results, RuleUnsupportedFlag.Failed(i18n.Sprintf(ctx, `mount option %s of RUN %s is specific to Podman and Buildah, docker build fails on it. Analyze the Containerfile with --dialect podman if it is only built with Podman or Buildah`,
+				name, GenerateErrorLocation(ctx, source, line))).At(source, line))
+		case !podmanMountOptions[name]:
+			results = append(results, RuleUnsupportedFlag.Failed(i18n.Sprintf(ctx, `mount option %s of RUN %s is not supported by %s build, the build fails`,
+				name, GenerateErrorLocation(ctx, source, line), dialect)).At(source, line))
+		}
+	}
+	return results
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
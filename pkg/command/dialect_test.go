@@ -0,0 +1,55 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+const podmanContainerfile = `FROM scratch
+RUN --mount=type=bind,source=.,target=/src,Z --network=none make -C /src
+RUN --security=insecure ./test.sh
+USER 1001
+`
+
+func TestDockerDialectReportsPodmanMountOptions(t *testing.T) {
+	results := resultsOfRule(analyzeFile(t, podmanContainerfile), RuleUnsupportedFlag)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected a %s suggestion at line 2 but they were %v", RuleUnsupportedFlag.ID, results)
+	}
+}
+
+func TestPodmanDialect(t *testing.T) {
+	_, results := parseAndAnalyze(WithDialect(context.Background(), DialectPodman), "Containerfile", []byte(podmanContainerfile))
+	if flags := resultsOfRule(results, RuleUnsupportedFlag); len(flags) != 1 || flags[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleUnsupportedFlag.ID, flags)
+	}
+	if syntax := resultsOfRule(results, RuleSyntaxDirective); len(syntax) != 0 {
+		t.Errorf("Expected no %s suggestion as Buildah ignores the directive but they were %v", RuleSyntaxDirective.ID, syntax)
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	if dialect, err := ParseDialect("Podman"); err != nil || dialect != DialectPodman {
+		t.Errorf("Expected the podman dialect but it was %s, error %v", dialect, err)
+	}
+	if _, err := ParseDialect("kaniko"); err == nil {
+		t.Errorf("Expected an error for an unknown dialect")
+	}
+}
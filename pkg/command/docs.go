@@ -0,0 +1,43 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+//go:generate go run gendocs.go
+
+// DocLink is a documentation section explaining a rule, e.g. an anchor of the OpenShift image
+// guidelines. The links of the rules are maintained in docs.yaml.
+type DocLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Docs returns the documentation sections explaining the rule, the most specific first.
+func (r Rule) Docs() []DocLink {
+	return ruleDocs[r.ID]
+}
+
+// withDocs links the results to the documentation sections of their rules.
+func withDocs(results []Result) []Result {
+	for i := range results {
+		if docs, ok := ruleDocs[results[i].RuleID]; ok {
+			results[i].Docs = docs
+		}
+	}
+	return results
+}
@@ -0,0 +1,74 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRulesAreDocumented(t *testing.T) {
+	for _, rule := range Rules {
+		if len(rule.Docs()) == 0 {
+			t.Errorf("The rule %s has no documentation sections in docs.yaml", rule.ID)
+		}
+	}
+	for id := range ruleDocs {
+		if _, ok := FindRule(id); !ok {
+			t.Errorf("docs.yaml documents the unknown rule %s", id)
+		}
+	}
+}
+
+func TestGeneratedDocsAreUpToDate(t *testing.T) {
+	content, err := os.ReadFile("docs.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	var input struct {
+		Anchors map[string]DocLink  `yaml:"anchors"`
+		Rules   map[string][]string `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(content, &input); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := map[string][]DocLink{}
+	for id, anchors := range input.Rules {
+		for _, anchor := range anchors {
+			expected[id] = append(expected[id], input.Anchors[anchor])
+		}
+	}
+	if !reflect.DeepEqual(ruleDocs, expected) {
+		t.Errorf("docs_generated.go doesn't match docs.yaml, run go generate ./pkg/command/")
+	}
+}
+
+func TestResultsLinkDocs(t *testing.T) {
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte("FROM scratch\nUSER root\n"))
+	results = resultsOfRule(results, RuleUserRoot)
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result but got %v", results)
+	}
+	if !reflect.DeepEqual(results[0].Docs, RuleUserRoot.Docs()) {
+		t.Errorf("Expected the docs %v but they were %v", RuleUserRoot.Docs(), results[0].Docs)
+	}
+}
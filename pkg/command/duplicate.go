@@ -0,0 +1,106 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// envAssignment is the last value set to a variable by ENV in the stage
+type envAssignment struct {
+	value string
+	line  Line
+	// used is set when an instruction may have used the value since it was set
+	used bool
+}
+
+// analyzeDuplicates reports the instructions of each stage which have no effect because a later
+// one overrides them: the ENTRYPOINT, CMD and HEALTHCHECK followed by another one, the ports
+// exposed twice and the ENV values set again before being used.
+func analyzeDuplicates(ctx context.Context, ast *parser.Node, source utils.Source) []Result {
+	var results []Result
+	stages := astStages(ast)
+	for i, stage := range stages {
+		final := i == len(stages)-1
+		overridden := map[string]*parser.Node{}
+		exposed := map[string]Line{}
+		envs := map[string]*envAssignment{}
+		for _, instruction := range stage.instructions {
+			line := Line{Start: instruction.StartLine, End: instruction.EndLine}
+			name := strings.ToUpper(instruction.Value)
+			for variable, env := range envs {
+				// the commands get the variables through their environment
+				if name == "RUN" || referencesVariable(instruction.Original, variable) {
+					env.used = true
+				}
+			}
+			switch name {
+			case "ENTRYPOINT", "CMD", "HEALTHCHECK":
+				if final && name != "HEALTHCHECK" {
+					// reported by entrypoint-cmd-conflict
+					break
+				}
+				if previous, ok := overridden[name]; ok {
+					previousLine := Line{Start: previous.StartLine, End: previous.EndLine}
+					results = append(results, RuleDuplicateInstruction.Failed(i18n.Sprintf(ctx, "%s %s is overridden by %s %s, only the last one of the stage is used",
+						name, GenerateErrorLocation(ctx, source, previousLine), name, GenerateErrorLocation(ctx, source, line))).At(source, previousLine))
+				}
+				overridden[name] = instruction
+			case "EXPOSE":
+				for n := instruction.Next; n != nil; n = n.Next {
+					port := strings.ToLower(n.Value)
+					if port == "" || strings.Contains(port, "$") {
+						continue
+					}
+					if !strings.Contains(port, "/") {
+						port += "/tcp"
+					}
+					if previous, ok := exposed[port]; ok {
+						results = append(results, RuleDuplicateInstruction.Failed(i18n.Sprintf(ctx, "port %s exposed %s is already exposed %s",
+							n.Value, GenerateErrorLocation(ctx, source, line), GenerateErrorLocation(ctx, source, previous))).At(source, line))
+						continue
+					}
+					exposed[port] = line
+				}
+			case "ENV":
+				for key := instruction.Next; key != nil && key.Next != nil; {
+					value := key.Next.Value
+					if previous, ok := envs[key.Value]; ok && !previous.used && previous.value != value && !referencesVariable(value, key.Value) {
+						results = append(results, RuleOverwrittenEnv.Failed(i18n.Sprintf(ctx, "ENV %s %s is set again to another value %s before being used, its value %s is never used",
+							key.Value, GenerateErrorLocation(ctx, source, previous.line), GenerateErrorLocation(ctx, source, line), previous.value)).At(source, previous.line))
+					}
+					envs[key.Value] = &envAssignment{value: value, line: line}
+					key = key.Next.Next
+				}
+			}
+		}
+	}
+	return results
+}
+
+// referencesVariable reports whether s references the variable, e.g. $PATH or ${PATH:-/bin}.
+func referencesVariable(s string, variable string) bool {
+	return regexp.MustCompile(`\$\{?` + regexp.QuoteMeta(variable) + `\b`).MatchString(s)
+}
@@ -0,0 +1,88 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDuplicateInstructions(t *testing.T) {
+	content := `FROM golang:1.22 AS builder
+CMD ["make"]
+CMD ["make", "test"]
+FROM registry.access.redhat.com/ubi9/ubi-minimal
+HEALTHCHECK CMD curl -f http://localhost:8080/
+HEALTHCHECK CMD curl -f http://localhost:8080/health
+EXPOSE 8080 8443
+EXPOSE 8080/tcp 9090/udp
+CMD ["/app"]
+CMD ["/app", "--debug"]
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	lines := map[int]bool{}
+	for _, result := range resultsOfRule(results, RuleDuplicateInstruction) {
+		lines[result.Line.Start] = true
+	}
+	for _, line := range []int{2, 5, 8} {
+		if !lines[line] {
+			t.Errorf("Expected a duplicate instruction at line %d but the results were %v", line, results)
+		}
+	}
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 duplicate instructions but the results were %v", resultsOfRule(results, RuleDuplicateInstruction))
+	}
+	// the CMD instructions of the final stage are checked by entrypoint-cmd-conflict
+	if len(resultsOfRule(results, RuleEntrypointCmdConflict)) != 1 {
+		t.Errorf("Expected the CMD of the final stage to be reported once but the results were %v", results)
+	}
+}
+
+func TestOverwrittenEnv(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal
+ENV APP_HOME=/opt/app PORT=8080
+ENV APP_HOME=/app
+ENV PATH=/usr/bin
+RUN echo $PATH
+ENV PATH=/opt/bin:$PATH
+ENV MODE=dev
+RUN make
+ENV MODE=prod
+ENV PORT=8080
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleOverwrittenEnv)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected APP_HOME to be reported at line 2 but the results were %v", results)
+	}
+}
+
+func TestOverwrittenEnvNotFirstOfItsInstruction(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal
+ENV A=1 B=2
+ENV B=4
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleOverwrittenEnv)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected B to be reported at line 2 but the results were %v", results)
+	}
+}
@@ -0,0 +1,245 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Entrypoint and Cmd analyze the commands run when the container starts. In the exec form the
+// arguments are joined into a single command line.
+type Entrypoint struct{}
+
+type Cmd struct{}
+
+type entrypointResultKeyType struct{}
+type cmdResultKeyType struct{}
+type startCommandsKeyType struct{}
+
+var entrypointResultKey entrypointResultKeyType
+var cmdResultKey cmdResultKeyType
+
+// positionalArgsRegexp matches the shell parameters expanded to the arguments of the container,
+// ${1:-default} has a default value
+var positionalArgsRegexp = regexp.MustCompile(`\$(?:[@*1-9]|\{[@*1-9]\})`)
+
+// argsCountRegexp matches the scripts checking the number of arguments they got
+var argsCountRegexp = regexp.MustCompile(`\$\{?#\}?`)
+
+// startCommandsKey holds the ENTRYPOINT and CMD instructions of the Containerfile
+var startCommandsKey startCommandsKeyType
+
+type startCommand struct {
+	instruction string
+	command     string
+	exec        bool
+	stage       int
+	source      utils.Source
+	line        Line
+}
+
+func (e Entrypoint) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	ctx = withListenedPorts(ctx, commandPorts("ENTRYPOINT", commandLine(node), source, line)...)
+	ctx = withStartCommand(ctx, "ENTRYPOINT", commandLine(node), source, line)
+	ctx = withPrivilegeSignals(ctx, commandLine(node), source, line)
+	return appendResults(ctx, entrypointResultKey, analyzeStartCommand(ctx, "ENTRYPOINT", commandLine(node), source, line)...)
+}
+
+func (e Entrypoint) PostProcess(ctx context.Context) []Result {
+	results := append(storedResults(ctx, entrypointResultKey), analyzeStartCommandConflicts(ctx)...)
+	results = append(results, profileRule(ctx, RuleHostIdentity, func() []Result {
+		return analyzeStartScriptHostIdentity(ctx)
+	})...)
+	results = append(results, profileRule(ctx, RuleRuntimeTruststoreImport, func() []Result {
+		return analyzeStartScriptTruststoreImport(ctx)
+	})...)
+	return append(results, analyzeEntrypointArguments(ctx)...)
+}
+
+func (c Cmd) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	ctx = withListenedPorts(ctx, commandPorts("CMD", commandLine(node), source, line)...)
+	ctx = withStartCommand(ctx, "CMD", commandLine(node), source, line)
+	ctx = withPrivilegeSignals(ctx, commandLine(node), source, line)
+	return appendResults(ctx, cmdResultKey, analyzeStartCommand(ctx, "CMD", commandLine(node), source, line)...)
+}
+
+func (c Cmd) PostProcess(ctx context.Context) []Result {
+	return storedResults(ctx, cmdResultKey)
+}
+
+// withStartCommand records an ENTRYPOINT or CMD instruction of the Containerfile, the ones of
+// the parent image are overridden.
+func withStartCommand(ctx context.Context, instruction string, command string, source utils.Source, line Line) context.Context {
+	if source.Type == utils.Parent {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(startCommandsKey).([]startCommand)
+	return context.WithValue(ctx, startCommandsKey, append(append([]startCommand{}, previous...), startCommand{
+		instruction: instruction,
+		command:     command,
+		exec:        isExecForm(ctx),
+		stage:       stage.Index,
+		source:      source,
+		line:        line,
+	}))
+}
+
+// finalStartCommands returns the ENTRYPOINT and the CMD instructions of the final stage.
+func finalStartCommands(ctx context.Context) ([]startCommand, []startCommand) {
+	stage, _ := CurrentStage(ctx)
+	commands, _ := ctx.Value(startCommandsKey).([]startCommand)
+	var entrypoints, cmds []startCommand
+	for _, command := range commands {
+		if command.stage != stage.Index {
+			continue
+		}
+		if command.instruction == "ENTRYPOINT" {
+			entrypoints = append(entrypoints, command)
+		} else {
+			cmds = append(cmds, command)
+		}
+	}
+	return entrypoints, cmds
+}
+
+/*
+ENTRYPOINT java -jar app.jar
+CMD ["--debug"]
+*/
+func analyzeStartCommandConflicts(ctx context.Context) []Result {
+	entrypoints, cmds := finalStartCommands(ctx)
+	var results []Result
+	for _, commands := range [][]startCommand{entrypoints, cmds} {
+		for i := 0; i < len(commands)-1; i++ {
+			last := commands[len(commands)-1]
+			results = append(results, RuleEntrypointCmdConflict.Failed(i18n.Sprintf(ctx, `%s %s is overridden by %s %s, only the last one of the stage is used`,
+				commands[i].instruction, GenerateErrorLocation(ctx, commands[i].source, commands[i].line), last.instruction, GenerateErrorLocation(ctx, last.source, last.line))).At(commands[i].source, commands[i].line))
+		}
+	}
+	if len(entrypoints) == 0 || len(cmds) == 0 {
+		return results
+	}
+	entrypoint, cmd := entrypoints[len(entrypoints)-1], cmds[len(cmds)-1]
+	if !entrypoint.exec {
+		results = append(results, RuleEntrypointCmdConflict.Failed(i18n.Sprintf(ctx, `CMD %s is ignored because ENTRYPOINT %s uses the shell form. Use the exec form, e.g. ENTRYPOINT ["java", "-jar", "app.jar"]`,
+			GenerateErrorLocation(ctx, cmd.source, cmd.line), GenerateErrorLocation(ctx, entrypoint.source, entrypoint.line))).At(cmd.source, cmd.line))
+	} else if !cmd.exec {
+		results = append(results, RuleEntrypointCmdConflict.Failed(i18n.Sprintf(ctx, `CMD %s uses the shell form, it is passed to ENTRYPOINT %s as "/bin/sh -c <command>" arguments. Use the exec form, e.g. CMD ["--port", "8080"]`,
+			GenerateErrorLocation(ctx, cmd.source, cmd.line), GenerateErrorLocation(ctx, entrypoint.source, entrypoint.line))).At(cmd.source, cmd.line))
+	}
+	return results
+}
+
+/*
+ENTRYPOINT ["/bin/sh", "-c", "exec java -jar app.jar \"$@\"", "--"]
+ENTRYPOINT ["/usr/local/bin/entrypoint.sh"]
+*/
+func analyzeEntrypointArguments(ctx context.Context) []Result {
+	entrypoints, cmds := finalStartCommands(ctx)
+	if len(entrypoints) == 0 || len(cmds) > 0 {
+		return nil
+	}
+	entrypoint := entrypoints[len(entrypoints)-1]
+	uses := ""
+	if match := positionalArgsRegexp.FindString(entrypoint.command); match != "" {
+		uses = match
+	} else if script, ok := entrypointScript(ctx, entrypoint); ok {
+		uses = script
+	}
+	if uses == "" {
+		return nil
+	}
+	return []Result{RuleEntrypointArguments.Failed(i18n.Sprintf(ctx, `ENTRYPOINT %s uses the arguments of the container (%s) but no CMD of the stage sets default ones. Deployed from the console or by oc new-app without arguments, the container fails and ends in CrashLoopBackOff. Set the default arguments with CMD, e.g. CMD ["--help"]`,
+		GenerateErrorLocation(ctx, entrypoint.source, entrypoint.line), uses)).At(entrypoint.source, entrypoint.line)}
+}
+
+// entrypointScript returns the name of the script started by ENTRYPOINT when it is copied from
+// the build context and uses its arguments without checking how many there are.
+func entrypointScript(ctx context.Context, entrypoint startCommand) (string, bool) {
+	rel, content, ok := startScript(ctx, entrypoint)
+	if !ok || argsCountRegexp.Match(content) {
+		return "", false
+	}
+	if match := positionalArgsRegexp.Find(content); match != nil {
+		return rel + ": " + string(match), true
+	}
+	return "", false
+}
+
+// startScript returns the path, relative to the build context, and the content of the script
+// started by the exec form of the command when it is copied from the build context.
+func startScript(ctx context.Context, command startCommand) (string, []byte, bool) {
+	dir, ok := buildContext(ctx)
+	fields := strings.Fields(command.command)
+	if !ok || !command.exec || len(fields) == 0 || !path.IsAbs(fields[0]) {
+		return "", nil, false
+	}
+	src, ok := copiedSource(ctx, path.Clean(fields[0]))
+	if !ok {
+		return "", nil, false
+	}
+	rel, ok := contextSource(src)
+	if !ok {
+		return "", nil, false
+	}
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", nil, false
+	}
+	return rel, content, true
+}
+
+func commandLine(node *parser.Node) string {
+	var args []string
+	for n := node; n != nil; n = n.Next {
+		args = append(args, n.Value)
+	}
+	return strings.Join(args, " ")
+}
+
+func analyzeStartCommand(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	results := profileRule(ctx, RuleHostPath, func() []Result {
+		return analyzeHostPaths(ctx, instruction, s, source, line)
+	})
+	results = append(results, profileRule(ctx, RuleNetworkCapability, func() []Result {
+		return analyzeNetworkCapabilities(ctx, instruction, s, source, line)
+	})...)
+	results = append(results, profileRule(ctx, RuleHardcodedResources, func() []Result {
+		return analyzeResourceFlags(ctx, instruction, s, source, line)
+	})...)
+	results = append(results, profileRule(ctx, RuleHostIdentity, func() []Result {
+		return analyzeHostIdentity(ctx, instruction, s, source, line)
+	})...)
+	results = append(results, profileRule(ctx, RuleRuntimeTruststoreImport, func() []Result {
+		return analyzeTruststoreImport(ctx, instruction, s, source, line)
+	})...)
+	return append(results, profileRule(ctx, RuleRuntimeSystemConfig, func() []Result {
+		return analyzeSystemConfigWrites(ctx, instruction, s, source, line)
+	})...)
+}
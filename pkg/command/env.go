@@ -0,0 +1,59 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Env analyzes the variables set by ENV. Each variable is made of 2 nodes: the key and the value.
+type Env struct{}
+
+type envResultKeyType struct{}
+
+var envResultKey envResultKeyType
+
+func (e Env) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	var results []Result
+	for key := node; key != nil && key.Next != nil; {
+		value := key.Next
+		results = append(results, analyzeHostPaths(ctx, "ENV "+key.Value, value.Value, source, line)...)
+		results = append(results, analyzeResourceFlags(ctx, "ENV "+key.Value, key.Value+"="+value.Value, source, line)...)
+		if portVariables[strings.ToUpper(key.Value)] {
+			ctx = withListenedPorts(ctx, commandPorts("ENV "+key.Value, "PORT="+value.Value, source, line)...)
+		}
+		if source.Type != utils.Parent {
+			// variables set by the builder stages don't end up in the final image
+			ctx = appendFinalStageResults(ctx, analyzeProxyCredentials(ctx, "ENV", key.Value, value.Value, source, line)...)
+			ctx = withRuntimeWrites(ctx, envWrites(ctx, key.Value, value.Value, source, line)...)
+			ctx = withInstallDirWrites(ctx, envInstallDirWrites(ctx, key.Value, value.Value, source, line)...)
+			ctx = withDataDirs(ctx, "ENV "+key.Value, key.Value, value.Value, source, line)
+			ctx = withPrivilegeSignals(ctx, value.Value, source, line)
+		}
+		key = value.Next
+	}
+	return appendResults(ctx, envResultKey, results...)
+}
+
+func (e Env) PostProcess(ctx context.Context) []Result {
+	return storedResults(ctx, envResultKey)
+}
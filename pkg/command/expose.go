@@ -19,11 +19,11 @@
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
 	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
 )
 
@@ -46,28 +46,29 @@ func (e Expose) Analyze(ctx context.Context, node *parser.Node, source utils.Sou
 	var results []Result
 	port, err := strconv.Atoi(str)
 	if err != nil {
-		results = append(results, Result{
-			Name:        "Wrong port value",
-			Status:      StatusFailed,
-			Severity:    SeverityCritical,
-			Description: err.Error(),
-		})
+		result := RuleInvalidPort.Failed(err.Error()).At(source, line)
+		if strings.HasPrefix(str, "$") {
+			// the port is a variable resolved at build time
+			result.Confidence = ConfidenceLow
+		}
+		results = append(results, result)
+	}
+	if err == nil {
+		ctx = withExposedPort(ctx, port)
 	}
 	if port < 1024 {
-		results = append(results, Result{
-			Name:        "Privileged port exposed",
-			Status:      StatusFailed,
-			Severity:    SeverityHigh,
-			Description: fmt.Sprintf(`port %d exposed %s could be wrong. TCP/IP port numbers below 1024 are privileged port numbers`, port, GenerateErrorLocation(source, line)),
-		})
+		results = append(results, RulePrivilegedPort.Failed(
+			i18n.Sprintf(ctx, `port %d exposed %s could be wrong. TCP/IP port numbers below 1024 are privileged port numbers`, port, GenerateErrorLocation(ctx, source, line)),
+		).At(source, line))
+	} else if err == nil && reportsPassed(ctx) {
+		results = append(results, RulePrivilegedPort.Passed(
+			i18n.Sprintf(ctx, `port %d exposed %s is not a privileged port`, port, GenerateErrorLocation(ctx, source, line)),
+		).At(source, line))
 	}
-	return context.WithValue(ctx, exposeResultKey, results)
+	return appendResults(ctx, exposeResultKey, results...)
 }
 
 func (e Expose) PostProcess(ctx context.Context) []Result {
-	result := ctx.Value(exposeResultKey)
-	if result == nil {
-		return nil
-	}
-	return result.([]Result)
+	results := append(storedResults(ctx, exposeResultKey), analyzePortMismatch(ctx)...)
+	return append(results, analyzeNoExposedPort(ctx)...)
 }
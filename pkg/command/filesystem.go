@@ -0,0 +1,221 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type ProvenanceKind string
+
+const (
+	ProvenanceBuildContext ProvenanceKind = "build-context"
+	ProvenanceStage        ProvenanceKind = "stage"
+	ProvenanceImage        ProvenanceKind = "image"
+	// ProvenanceNamedContext is an additional build context, e.g. --build-context assets=./assets
+	ProvenanceNamedContext ProvenanceKind = "named-context"
+)
+
+// copiedFiles are the files copied into a stage by a COPY or ADD instruction, along with where
+// they come from and who owns them.
+type copiedFiles struct {
+	instruction string
+	sources     []string
+	destination string
+	provenance  ProvenanceKind
+	// from is the stage or the image of COPY --from
+	from string
+	// owner and group are set by --chown, the files are owned by root:root otherwise
+	owner string
+	group string
+	stage int
+	line  Line
+}
+
+type copiedFilesKeyType struct{}
+
+// copiedFilesKey holds the files copied by the instructions analyzed so far
+var copiedFilesKey copiedFilesKeyType
+
+// withCopiedFiles records the files copied by the COPY or ADD instruction being analyzed.
+func withCopiedFiles(ctx context.Context, instruction string, node *parser.Node, line Line) context.Context {
+	var args []string
+	for n := node; n != nil; n = n.Next {
+		args = append(args, n.Value)
+	}
+	if len(args) < 2 {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	copied := copiedFiles{
+		instruction: instruction,
+		sources:     args[:len(args)-1],
+		destination: args[len(args)-1],
+		provenance:  ProvenanceBuildContext,
+		owner:       "root",
+		group:       "root",
+		stage:       stage.Index,
+		line:        line,
+	}
+	if from, ok := instructionFlag(ctx, "from"); ok {
+		copied.from = from
+		copied.provenance = ProvenanceImage
+		if _, ok := stageByReference(ctx, from); ok {
+			copied.provenance = ProvenanceStage
+		} else if image, ok := resolveImage(ctx, from); !ok {
+			copied.provenance = ProvenanceNamedContext
+		} else {
+			copied.from = image
+		}
+	}
+	if chown, ok := instructionFlag(ctx, "chown"); ok {
+		owner := strings.SplitN(chown, ":", 2)
+		copied.owner, copied.group = owner[0], owner[0]
+		if len(owner) == 2 {
+			copied.group = owner[1]
+		}
+	}
+	previous, _ := ctx.Value(copiedFilesKey).([]copiedFiles)
+	return context.WithValue(ctx, copiedFilesKey, append(append([]copiedFiles{}, previous...), copied))
+}
+
+// copiedTo returns the files copied into the current stage which are changed by a chown or a
+// chmod of target: the copied files at or below target when recursive, the ones containing
+// target otherwise.
+func copiedTo(ctx context.Context, target string, recursive bool) []copiedFiles {
+	stage, _ := CurrentStage(ctx)
+	all, _ := ctx.Value(copiedFilesKey).([]copiedFiles)
+	target = path.Clean(target)
+	var copied []copiedFiles
+	for _, files := range all {
+		destination := path.Clean(files.destination)
+		if files.stage != stage.Index || strings.HasPrefix(target, "$") {
+			continue
+		}
+		// a directory is only changed itself, unlike the files copied into it
+		file := destination == target && !strings.HasSuffix(files.destination, "/")
+		below := strings.HasPrefix(target, destination+"/")
+		if file || below || (recursive && (destination == target || strings.HasPrefix(destination, target+"/"))) {
+			copied = append(copied, files)
+		}
+	}
+	return copied
+}
+
+// copiedSource returns the path, relative to the build context, of the file copied from it to
+// target in the current stage.
+func copiedSource(ctx context.Context, target string) (string, bool) {
+	stage, _ := CurrentStage(ctx)
+	all, _ := ctx.Value(copiedFilesKey).([]copiedFiles)
+	for i := len(all) - 1; i >= 0; i-- {
+		files := all[i]
+		if files.stage != stage.Index || files.provenance != ProvenanceBuildContext || len(files.sources) != 1 {
+			continue
+		}
+		if path.Clean(files.destination) == target && !strings.HasSuffix(files.destination, "/") {
+			return files.sources[0], true
+		}
+		if path.Join(files.destination, path.Base(files.sources[0])) == target {
+			return files.sources[0], true
+		}
+	}
+	return "", false
+}
+
+/*
+COPY --chown=1001:1001 app /opt/app
+*/
+func analyzeCopyOwner(ctx context.Context, instruction string, source utils.Source, line Line) []Result {
+	chown, ok := instructionFlag(ctx, "chown")
+	if !ok {
+		return nil
+	}
+	owner := strings.SplitN(chown, ":", 2)
+	if len(owner) < 2 || strings.EqualFold(owner[1], "root") || owner[1] == "0" {
+		return nil
+	}
+	result := RuleChownGroup.Failed(i18n.Sprintf(ctx, `owner set on %s --chown=%s %s could cause an unexpected behavior. 
+			In OpenShift the group ID must always be set to the root group (0)`, instruction, chown, GenerateErrorLocation(ctx, source, line)))
+	if strings.HasPrefix(owner[1], "$") {
+		result.Confidence = ConfidenceLow
+	}
+	return []Result{result.At(source, line)}
+}
+
+// ownershipTargets returns the paths of a chown, chgrp or chmod command and whether it is
+// recursive, e.g. /app for chown -R 1001:0 /app
+func ownershipTargets(command string) ([]string, bool) {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if field != "chown" && field != "chgrp" && field != "chmod" {
+			continue
+		}
+		var args []string
+		recursive := false
+		for _, arg := range fields[i+1:] {
+			if arg == "-R" || arg == "--recursive" || (strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "R")) {
+				recursive = true
+			} else if !strings.HasPrefix(arg, "-") {
+				args = append(args, strings.Trim(arg, `"'`))
+			}
+		}
+		if len(args) < 2 {
+			return nil, false
+		}
+		// the first argument is the owner, the group or the mode
+		return args[1:], recursive
+	}
+	return nil, false
+}
+
+/*
+COPY --from=builder /build/target /opt/app
+RUN chown -R 1001:0 /opt/app && chmod -R g=u /opt/app
+*/
+func analyzeOwnershipFix(ctx context.Context, commands []string, source utils.Source, line Line) []Result {
+	var results []Result
+	reported := map[Line]bool{}
+	for _, command := range commands {
+		targets, recursive := ownershipTargets(command)
+		for _, target := range targets {
+			for _, files := range copiedTo(ctx, target, recursive) {
+				if reported[files.line] {
+					continue
+				}
+				reported[files.line] = true
+				provenance := i18n.Translate(ctx, "the build context")
+				if files.provenance == ProvenanceStage {
+					provenance = i18n.Sprintf(ctx, "the stage %s", files.from)
+				} else if files.provenance == ProvenanceImage {
+					provenance = i18n.Sprintf(ctx, "the image %s", files.from)
+				} else if files.provenance == ProvenanceNamedContext {
+					provenance = i18n.Sprintf(ctx, "the build context %s", files.from)
+				}
+				results = append(results, RuleOwnershipFixAfterCopy.Failed(i18n.Sprintf(ctx, `'%s' %s changes the files copied from %s to %s at line %d, owned by %s:%s. The files are duplicated in a new layer, set their owner and permissions with %s --chown=<user>:0 --chmod=<mode> instead`,
+					strings.TrimSpace(command), GenerateErrorLocation(ctx, source, line), provenance, files.destination, files.line.Start, files.owner, files.group, files.instruction)).At(source, line))
+			}
+		}
+	}
+	return results
+}
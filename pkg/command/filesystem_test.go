@@ -0,0 +1,73 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfCopiedFilesAreChangedByRun(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, `FROM scratch AS builder
+RUN make
+FROM scratch
+COPY --from=builder /out/app /opt/app
+RUN chown -R 1001:0 /opt && chmod -R g=u /opt
+USER 1001
+`), RuleOwnershipFixAfterCopy)
+	if len(results) != 1 || results[0].Line.Start != 5 {
+		t.Fatalf("Expected a %s suggestion at line 5 but they were %v", RuleOwnershipFixAfterCopy.ID, results)
+	}
+	if !strings.Contains(results[0].Description, "the stage builder") || !strings.Contains(results[0].Description, "root:root") {
+		t.Errorf("Expected the provenance and the ownership of the files in %s", results[0].Description)
+	}
+}
+
+func TestCopiedFilesOfAnotherStageAreIgnored(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, `FROM scratch AS builder
+COPY src /src
+RUN chmod -R 775 /src
+FROM scratch
+COPY --from=builder /src /src
+USER 1001
+`), RuleOwnershipFixAfterCopy)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion but they were %v", RuleOwnershipFixAfterCopy.ID, results)
+	}
+}
+
+func TestDirectoryOfCopiedFilesChangedWithoutRecursion(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nCOPY dist/ /usr/share/html/\nRUN chmod 775 /usr/share/html\nUSER 1001\n"), RuleOwnershipFixAfterCopy)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion but they were %v", RuleOwnershipFixAfterCopy.ID, results)
+	}
+}
+
+func TestCopyFromImageProvenance(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nCOPY --from=quay.io/org/tools:1.0 --chown=1001:0 /usr/bin/tool /usr/bin/tool\nRUN chmod 775 /usr/bin/tool\nUSER 1001\n"), RuleOwnershipFixAfterCopy)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "the image quay.io/org/tools:1.0") || !strings.Contains(results[0].Description, "1001:0") {
+		t.Errorf("Expected a %s suggestion about the image but they were %v", RuleOwnershipFixAfterCopy.ID, results)
+	}
+}
+
+func TestFailIfCopyChownGroupIsNotRoot(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nCOPY --chown=1001:1001 app /app\nADD --chown=1001:0 lib /lib\nUSER 1001\n"), RuleChownGroup)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected a %s suggestion at line 2 but they were %v", RuleChownGroup.ID, results)
+	}
+}
@@ -0,0 +1,99 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// snippets returns the normalized text of the instructions keyed by their first line. The text
+// of the lines which couldn't be parsed is taken from the content.
+func snippets(node *parser.Node, content []byte) map[int]string {
+	snippets := map[int]string{}
+	for i, line := range strings.Split(string(content), "\n") {
+		snippets[i+1] = normalizeSnippet(line)
+	}
+	if node != nil {
+		for _, child := range node.Children {
+			snippets[child.StartLine] = normalizeSnippet(child.Original)
+		}
+	}
+	return snippets
+}
+
+// normalizeSnippet drops the line continuations and collapses the whitespaces, so that
+// reformatting an instruction doesn't change its fingerprint.
+func normalizeSnippet(text string) string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if word != "\\" && word != "`" {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// withFingerprints sets the fingerprint of the results, a hash of the rule, the normalized
+// snippet of the instruction (or the path of the build context file) and the position of the
+// result among the results of the same rule and snippet. The fingerprint is stable when lines
+// are added or removed elsewhere in the Containerfile.
+func withFingerprints(results []Result, snippets map[int]string) []Result {
+	keys := make([]string, len(results))
+	groups := map[string][]int{}
+	for i, result := range results {
+		rule := result.RuleID
+		if rule == "" {
+			rule = result.Name
+		}
+		snippet := ""
+		if result.File != nil {
+			snippet = result.File.Path
+		} else if result.Line != nil {
+			snippet = snippets[result.Line.Start]
+		}
+		keys[i] = rule + "\x00" + snippet
+		groups[keys[i]] = append(groups[keys[i]], i)
+	}
+	for key, indexes := range groups {
+		// the relative order of the results doesn't change when lines are shifted
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return resultPosition(results[indexes[i]]) < resultPosition(results[indexes[j]])
+		})
+		for occurrence, index := range indexes {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, occurrence)))
+			results[index].Fingerprint = hex.EncodeToString(sum[:16])
+		}
+	}
+	return results
+}
+
+func resultPosition(result Result) int {
+	if result.File != nil {
+		return result.File.Line
+	}
+	if result.Line != nil {
+		return result.Line.Start
+	}
+	return 0
+}
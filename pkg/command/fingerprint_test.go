@@ -0,0 +1,47 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"testing"
+)
+
+func TestFingerprintSurvivesLineShifts(t *testing.T) {
+	content := "FROM registry.access.redhat.com/ubi9/ubi-minimal\nEXPOSE 80\nEXPOSE 80\n"
+	results := resultsOfRule(analyzeFile(t, content), RulePrivilegedPort)
+	shifted := resultsOfRule(analyzeFile(t, "# the web server\n"+content+"\n"), RulePrivilegedPort)
+	if len(results) != 2 || len(shifted) != 2 {
+		t.Fatalf("Expected 2 results but they were %v and %v", results, shifted)
+	}
+	if results[0].Fingerprint == "" || results[0].Fingerprint == results[1].Fingerprint {
+		t.Errorf("Expected distinct fingerprints for the 2 instructions but they were %v", results)
+	}
+	for i := range results {
+		if results[i].Line.Start == shifted[i].Line.Start || results[i].Fingerprint != shifted[i].Fingerprint {
+			t.Errorf("Expected the fingerprint to survive the shift but they were %v and %v", results[i], shifted[i])
+		}
+	}
+}
+
+func TestFingerprintIgnoresFormatting(t *testing.T) {
+	results := resultsOfRule(analyzeFile(t, "FROM ubuntu\nUSER root\n"), RuleUserRoot)
+	reformatted := resultsOfRule(analyzeFile(t, "FROM ubuntu\nUSER    root\n"), RuleUserRoot)
+	if len(results) != 1 || len(reformatted) != 1 || results[0].Fingerprint != reformatted[0].Fingerprint {
+		t.Errorf("Expected the same fingerprint but they were %v and %v", results, reformatted)
+	}
+}
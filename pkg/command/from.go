@@ -19,9 +19,10 @@
 
 import (
 	"context"
-	"fmt"
+	"strings"
+
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
-	"github.com/redhat-developer/docker-openshift-analyzer/pkg/decompiler"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
 	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
 )
 
@@ -32,35 +33,58 @@ type fromResultKeyType struct{}
 
 var fromResultKey fromResultKeyType
 
+type skipBaseImagesKeyType struct{}
+
+var skipBaseImagesKey skipBaseImagesKeyType
+
 const SCRATCH_IMAGE_NAME = "scratch"
 
+// WithoutBaseImageAnalysis skips the analysis of the base images, whose Containerfile is
+// rebuilt from the local engine or the registry, so that the results only depend on the
+// analyzed Containerfile.
+func WithoutBaseImageAnalysis(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipBaseImagesKey, true)
+}
+
+// Analyze gets the image of the instruction, followed by the AS keyword and the name of the
+// stage if any: FROM <image> [AS <name>]. The image can be a previous stage or a named context.
 func (f From) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
-	if node.Value == SCRATCH_IMAGE_NAME {
+	image, analyzable := node.Value, true
+	if source.Type != utils.Parent {
+		name := ""
+		if node.Next != nil && strings.EqualFold(node.Next.Value, "AS") && node.Next.Next != nil {
+			name = node.Next.Next.Value
+		}
+		if stage, ok := stageByReference(ctx, node.Value); ok {
+			// the instructions of the stage have already been analyzed
+			image, analyzable = stage.Image, false
+		} else {
+			image, analyzable = resolveImage(ctx, node.Value)
+		}
+		ctx = withStage(ctx, image, name)
+		ctx = appendFinalStageResults(ctx, analyzeBuildImage(ctx, image, source, line)...)
+		ctx = withWebServerImage(ctx, image, source, line)
+	}
+	if skip, _ := ctx.Value(skipBaseImagesKey).(bool); skip || !analyzable || image == SCRATCH_IMAGE_NAME {
 		return ctx
 	}
-	decompiledNode, err := decompiler.Decompile(node.Value)
+	decompiledNode, err := decompile(image)
 	if err != nil {
 		// unable to decompile base image
-		return context.WithValue(ctx, fromResultKey, []Result{
-			Result{
-				Name:        "Analyze error",
-				Status:      StatusFailed,
-				Severity:    SeverityLow,
-				Description: fmt.Sprintf("unable to analyze the base image %s", node.Value),
-			},
-		})
+		return appendResults(ctx, fromResultKey,
+			RuleBaseImageAnalysis.Failed(i18n.Sprintf(ctx, "unable to analyze the base image %s", image)).At(source, line),
+		)
 	}
 	_, ctx = AnalyzeNodeFromSource(ctx, decompiledNode, utils.Source{
-		Name: node.Value,
+		Name: image,
 		Type: utils.Parent,
 	})
 	return ctx
 }
 
 func (f From) PostProcess(ctx context.Context) []Result {
-	result := ctx.Value(fromResultKey)
-	if result == nil {
-		return nil
-	}
-	return result.([]Result)
+	results := append(storedResults(ctx, fromResultKey), finalStageResults(ctx)...)
+	return append(results, profileRule(ctx, RuleWebServerNonRoot, func() []Result {
+		return analyzeWebServerImage(ctx)
+	})...)
 }
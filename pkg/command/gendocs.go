@@ -0,0 +1,109 @@
+//go:build ignore
+
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// gendocs generates docs_generated.go from docs.yaml, see go generate.
+ package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	INPUT  = "docs.yaml"
+	OUTPUT = "docs_generated.go"
+)
+
+type anchor struct {
+	Title string `yaml:"title"`
+	URL   string `yaml:"url"`
+}
+
+type docs struct {
+	Anchors map[string]anchor   `yaml:"anchors"`
+	Rules   map[string][]string `yaml:"rules"`
+}
+
+func main() {
+	if err := generate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate() error {
+	content, err := os.ReadFile(INPUT)
+	if err != nil {
+		return err
+	}
+	var input docs
+	if err := yaml.Unmarshal(content, &input); err != nil {
+		return fmt.Errorf("unable to parse %s: %s", INPUT, err)
+	}
+	header, err := licenseHeader()
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(header)
+	fmt.Fprintf(buf, "\n// Code generated by gendocs.go from %s. DO NOT EDIT.\n\n package command\n\n", INPUT)
+	buf.WriteString("// ruleDocs are the documentation sections explaining the rules, by rule ID\n")
+	buf.WriteString("var ruleDocs = map[string][]DocLink{\n")
+	var ids []string
+	for id := range input.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(buf, "\t%q: {\n", id)
+		for _, name := range input.Rules[id] {
+			link, ok := input.Anchors[name]
+			if !ok {
+				return fmt.Errorf("unknown anchor %s of the rule %s in %s", name, id, INPUT)
+			}
+			if link.Title == "" || !strings.HasPrefix(link.URL, "https://") {
+				return fmt.Errorf("the anchor %s in %s needs a title and an https URL", name, INPUT)
+			}
+			fmt.Fprintf(buf, "\t\t{Title: %q, URL: %q},\n", link.Title, link.URL)
+		}
+		buf.WriteString("\t},\n")
+	}
+	buf.WriteString("}\n")
+	return os.WriteFile(OUTPUT, buf.Bytes(), 0644)
+}
+
+// licenseHeader returns the license block of this file, which the generated file starts with.
+func licenseHeader() (string, error) {
+	content, err := os.ReadFile("gendocs.go")
+	if err != nil {
+		return "", err
+	}
+	start, end := bytes.Index(content, []byte("/****")), bytes.Index(content, []byte("***/\n"))
+	if start < 0 || end < start {
+		return "", fmt.Errorf("no license header in gendocs.go")
+	}
+	return string(content[start : end+len("***/\n")]), nil
+}
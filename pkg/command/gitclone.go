@@ -0,0 +1,91 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+var gitCloneRegexp = regexp.MustCompile(`\bgit\s+clone\s+([^;&|]+)`)
+
+// gitPinRegexp matches the checkout of a commit after the clone
+var gitPinRegexp = regexp.MustCompile(`\bgit\s+(-C\s+\S+\s+)?(checkout|reset\s+--hard)\s+[0-9a-f]{7,40}\b`)
+
+var tagRegexp = regexp.MustCompile(`^v?\d+(\.\d+)*([-+.]\w+)*$`)
+
+var urlCredentialsRegexp = regexp.MustCompile(`://[^/@\s:]+:[^/@\s]+@`)
+
+/*
+RUN git clone https://github.com/org/app.git
+RUN git clone -b main https://github.com/org/app.git
+RUN git clone --branch v1.2.0 --depth 1 https://github.com/org/app.git
+RUN git clone https://github.com/org/app.git && cd app && git checkout 3f2c1a9
+*/
+func analyzeGitClone(ctx context.Context, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	pinned := gitPinRegexp.MatchString(s)
+	for _, match := range gitCloneRegexp.FindAllStringSubmatch(s, -1) {
+		repository, ref := gitCloneArgs(strings.Fields(match[1]))
+		if repository == "" {
+			continue
+		}
+		if urlCredentialsRegexp.MatchString(repository) {
+			results = append(results, RuleGitCloneMutableRef.Failed(i18n.Sprintf(ctx, `git clone %s contains credentials which are stored in the image history. Use OpenShift Git source builds with a source secret or COPY the sources instead`,
+				GenerateErrorLocation(ctx, source, line))).At(source, line))
+			continue
+		}
+		if pinned || tagRegexp.MatchString(ref) {
+			continue
+		}
+		if ref == "" {
+			ref = i18n.Translate(ctx, "the default branch")
+		}
+		results = append(results, RuleGitCloneMutableRef.Failed(i18n.Sprintf(ctx, `git clone of %s %s checks out %s, which changes over time and makes the build not reproducible. Pin a commit, COPY vendored sources or use OpenShift Git source builds instead`,
+			repository, GenerateErrorLocation(ctx, source, line), ref)).At(source, line))
+	}
+	return results
+}
+
+// gitCloneArgs returns the repository and the branch or tag of the arguments of git clone.
+func gitCloneArgs(args []string) (string, string) {
+	repository, ref := "", ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-b" || arg == "--branch":
+			if i+1 < len(args) {
+				ref = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--branch="):
+			ref = strings.TrimPrefix(arg, "--branch=")
+		case arg == "--depth" || arg == "-o" || arg == "--origin" || arg == "-c" || arg == "--config":
+			// options with a value
+			i++
+		case strings.HasPrefix(arg, "-"):
+		case repository == "":
+			repository = arg
+		}
+	}
+	return repository, ref
+}
@@ -0,0 +1,103 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// hostIdentityPattern is a use of the network identity of the container, with the advice for
+// OpenShift
+type hostIdentityPattern struct {
+	name       string
+	re         *regexp.Regexp
+	confidence ResultConfidence
+	advice     string
+	// buildTime patterns are also reported in RUN instructions
+	buildTime bool
+}
+
+var hostIdentityPatterns = []hostIdentityPattern{
+	{
+		name:       "an edit of /etc/hosts",
+		re:         regexp.MustCompile(`(?:>>?|\btee\s+(?:-a\s+)?)\s*/etc/hosts\b|\bsed\s+(?:-\w+\s+)*-i\S*\s.*/etc/hosts\b`),
+		confidence: ConfidenceHigh,
+		advice:     "/etc/hosts is managed by OpenShift for every pod, the edits are lost. Declare the entries with hostAliases in the pod spec or resolve the other services through their Service names",
+		buildTime:  true,
+	},
+	{
+		name:       "a MAC address",
+		re:         regexp.MustCompile(`--mac-address\b|\b(?:hw\s+ether|address)\s+(?:[0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}\b|/sys/class/net/[\w.${}-]+/address\b`),
+		confidence: ConfidenceMedium,
+		advice:     "pods get a new MAC address every time they are recreated and can't set it. Bind the licenses to something else, e.g. a license key mounted from a Secret",
+	},
+	{
+		name:       "the container hostname",
+		re:         regexp.MustCompile("\\$\\{?HOSTNAME\\b|\\$\\(\\s*hostname\\b|`\\s*hostname\\b|/etc/hostname\\b|\\bhostname\\s+(?:-[fis]\\b|[a-zA-Z])"),
+		confidence: ConfidenceLow,
+		advice:     "the hostname is the name of the pod, which changes every time it is recreated. Read the pod name from the downward API (metadata.name) or give the members of a cluster stable names with a StatefulSet and a headless Service",
+	},
+}
+
+/*
+CMD echo "10.0.0.5 db" >> /etc/hosts && exec app
+ENTRYPOINT ["sh", "-c", "exec app --node-id=$HOSTNAME"]
+*/
+func analyzeHostIdentity(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, pattern := range hostIdentityPatterns {
+		if instruction == "RUN" && !pattern.buildTime {
+			continue
+		}
+		match := pattern.re.FindString(s)
+		if match == "" {
+			continue
+		}
+		result := RuleHostIdentity.Failed(i18n.Sprintf(ctx, "%s relies on %s ('%s' %s): %s",
+			instruction, i18n.Translate(ctx, pattern.name), match, GenerateErrorLocation(ctx, source, line), i18n.Translate(ctx, pattern.advice))).At(source, line)
+		result.Confidence = pattern.confidence
+		results = append(results, result)
+	}
+	return results
+}
+
+/*
+COPY start.sh /start.sh
+ENTRYPOINT ["/start.sh"]
+*/
+func analyzeStartScriptHostIdentity(ctx context.Context) []Result {
+	entrypoints, cmds := finalStartCommands(ctx)
+	commands := append(entrypoints, cmds...)
+	if len(commands) == 0 {
+		return nil
+	}
+	command := commands[len(commands)-1]
+	if len(entrypoints) > 0 {
+		command = entrypoints[len(entrypoints)-1]
+	}
+	rel, content, ok := startScript(ctx, command)
+	if !ok {
+		return nil
+	}
+	return analyzeHostIdentity(ctx, rel, string(content), command.source, command.line)
+}
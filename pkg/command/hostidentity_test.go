@@ -0,0 +1,57 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfHostIdentityIsUsed(t *testing.T) {
+	for instruction, confidence := range map[string]ResultConfidence{
+		`CMD echo "10.0.0.5 db" >> /etc/hosts && exec app`:                                  ConfidenceHigh,
+		"RUN sed -i 's/localhost/db/' /etc/hosts":                                           ConfidenceHigh,
+		`ENTRYPOINT ["sh", "-c", "ip link set eth0 address 02:42:ac:11:00:02 && exec app"]`: ConfidenceMedium,
+		`CMD ["sh", "-c", "exec app --license-mac=$(cat /sys/class/net/eth0/address)"]`:     ConfidenceMedium,
+		`ENTRYPOINT ["sh", "-c", "exec app --node-id=${HOSTNAME}"]`:                         ConfidenceLow,
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleHostIdentity)
+			if len(results) != 1 || results[0].Confidence != confidence || results[0].Line.Start != 3 {
+				t.Errorf("Expected a %s confidence %s finding at line 3 but they were %v", confidence, RuleHostIdentity.ID, results)
+			}
+		})
+	}
+}
+
+func TestHostnameReadAtBuildTimeIsNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nRUN echo $HOSTNAME > /build-host\nUSER 1001\nCMD [\"app\"]\n"), RuleHostIdentity)
+	if len(results) != 0 {
+		t.Errorf("Expected no finding but they were %v", results)
+	}
+}
+
+func TestHostIdentityOfStartScript(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"start.sh": "#!/bin/sh\nexec app --cluster-member=\"$HOSTNAME\" --mac=\"$(cat /sys/class/net/eth0/address)\"\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY start.sh /start.sh\nUSER 1001\nENTRYPOINT [\"/start.sh\"]\n"), RuleHostIdentity)
+	if len(results) != 2 || !strings.HasPrefix(results[0].Description, "start.sh relies on a MAC address") || results[1].Line.Start != 4 {
+		t.Errorf("Expected 2 %s findings of start.sh but they were %v", RuleHostIdentity.ID, results)
+	}
+}
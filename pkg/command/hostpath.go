@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type hostPath struct {
+	re          *regexp.Regexp
+	description string
+}
+
+// hostPaths are only available to privileged containers or to containers mounting host paths,
+// which the default OpenShift policies don't allow.
+var hostPaths = []hostPath{
+	{regexp.MustCompile(`/var/run/docker\.sock|/run/docker\.sock`), "the Docker socket"},
+	{regexp.MustCompile(`/run/podman/podman\.sock|/run/containerd/containerd\.sock|/run/crio/crio\.sock`), "a container engine socket"},
+	{regexp.MustCompile(`/proc/1/`), "the processes of the host"},
+	{regexp.MustCompile(`(>|\btee\s+(-a\s+)?)\s*/sys/fs/cgroup`), "a write to the cgroup file system"},
+	{regexp.MustCompile(`(>|\btee\s+(-a\s+)?)\s*/proc/sys/|\bsysctl\s+-w\b`), "a write to the kernel parameters"},
+	{regexp.MustCompile(`/dev/kmsg|/lib/modules|\bmodprobe\b|\binsmod\b`), "the kernel of the host"},
+}
+
+/*
+ENV DOCKER_HOST=unix:///var/run/docker.sock
+RUN echo 1 > /sys/fs/cgroup/cpu/cpu.cfs_quota_us
+ENTRYPOINT ["sh", "-c", "cat /proc/1/environ"]
+*/
+func analyzeHostPaths(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, path := range hostPaths {
+		if path.re.MatchString(s) {
+			results = append(results, RuleHostPath.Failed(i18n.Sprintf(ctx, `%s %s references %s. It implies a privileged or host-mounted container, which the default OpenShift policies don't allow`,
+				instruction, GenerateErrorLocation(ctx, source, line), i18n.Translate(ctx, path.description))).At(source, line))
+		}
+	}
+	return results
+}
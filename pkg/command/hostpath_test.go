@@ -0,0 +1,97 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+func analyzeContent(t *testing.T, content string) []Result {
+	res, err := parser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %s", content, err)
+	}
+	results, _ := AnalyzeNodeFromSource(context.Background(), res.AST, utils.Source{Name: "test", Type: utils.Image})
+	return results
+}
+
+func analyzeContentContext(t *testing.T, content string) context.Context {
+	res, err := parser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %s", content, err)
+	}
+	_, ctx := AnalyzeNodeFromSource(context.Background(), res.AST, utils.Source{Name: "test", Type: utils.Image})
+	return ctx
+}
+
+func resultsOfRule(results []Result, rule Rule) []Result {
+	var filtered []Result
+	for _, result := range results {
+		if result.RuleID == rule.ID {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+func TestFailIfEnvReferencesDockerSocket(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nENV DOCKER_HOST=unix:///var/run/docker.sock OTHER=value\n"), RuleHostPath)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleHostPath.ID, results)
+	}
+}
+
+func TestFailIfSecondEnvVariableReferencesDockerSocket(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nENV OTHER=value DOCKER_HOST=unix:///var/run/docker.sock\n"), RuleHostPath)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleHostPath.ID, results)
+	}
+}
+
+func TestFailIfRunWritesToCgroups(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN echo 1 > /sys/fs/cgroup/cpu/cpu.cfs_quota_us\n"), RuleHostPath)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleHostPath.ID, results)
+	}
+}
+
+func TestFailIfEntrypointReadsHostProcesses(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nENTRYPOINT [\"sh\", \"-c\", \"cat /proc/1/environ\"]\n"), RuleHostPath)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleHostPath.ID, results)
+	}
+}
+
+func TestReadingCgroupsIsNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nCMD cat /sys/fs/cgroup/memory.max\n"), RuleHostPath)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestEnvWithLegacySyntaxIsNotEmpty(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nENV APP_HOME /app\n"), RuleEmptyValue)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
@@ -0,0 +1,350 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// ignoreFileNames are looked up in the build context, the first one found is used as Buildah does
+var ignoreFileNames = []string{".containerignore", ".dockerignore"}
+
+// contextDirectories are large directories which are not needed by the build and slow down the
+// upload of the build context
+var contextDirectories = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	".venv":        true,
+}
+
+// MAX_CONTEXT_ENTRIES is the number of files and directories of the build context looked at
+const MAX_CONTEXT_ENTRIES = 20000
+
+var errContextTooLarge = errors.New("too many entries in the build context")
+
+type ignoreFileKeyType struct{}
+type verifiedBuildContextKeyType struct{}
+
+var ignoreFileKey ignoreFileKeyType
+var verifiedBuildContextKey verifiedBuildContextKeyType
+
+type ignorePattern struct {
+	pattern string
+	// exception patterns start with ! and include back the files excluded by previous patterns
+	exception bool
+	re        *regexp.Regexp
+}
+
+// ignoreFile holds the patterns of the .containerignore or .dockerignore file of the build context.
+type ignoreFile struct {
+	name     string
+	patterns []ignorePattern
+}
+
+// readIgnoreFile reads the ignore file of the build context dir, it returns nil when there is none.
+func readIgnoreFile(dir string) (*ignoreFile, error) {
+	for _, name := range ignoreFileNames {
+		file, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		ignore := &ignoreFile{name: name}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			pattern := strings.TrimSpace(scanner.Text())
+			if pattern == "" || strings.HasPrefix(pattern, "#") {
+				continue
+			}
+			exception := strings.HasPrefix(pattern, "!")
+			if exception {
+				pattern = strings.TrimSpace(pattern[1:])
+			}
+			pattern = path.Clean(filepath.ToSlash(pattern))
+			if len(pattern) > 1 && pattern[0] == '/' {
+				pattern = pattern[1:]
+			}
+			ignore.patterns = append(ignore.patterns, ignorePattern{
+				pattern:   pattern,
+				exception: exception,
+				re:        compileIgnorePattern(pattern),
+			})
+		}
+		return ignore, scanner.Err()
+	}
+	return nil, nil
+}
+
+// compileIgnorePattern converts the pattern to a regular expression: * and ? don't match the path
+// separator while ** matches any number of directories.
+func compileIgnorePattern(pattern string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '[' && strings.Contains(pattern[i+1:], "]"):
+			end := i + 1 + strings.Index(pattern[i+1:], "]")
+			class := pattern[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i = end
+		default:
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		// an invalid character class only matches itself
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	return compiled
+}
+
+// excludes reports whether the file, relative to the build context, is not sent to the builder:
+// the last pattern matching the file or one of its parent directories wins.
+func (f *ignoreFile) excludes(file string) bool {
+	if f == nil {
+		return false
+	}
+	file = path.Clean(strings.TrimPrefix(filepath.ToSlash(file), "/"))
+	excluded := false
+	for _, pattern := range f.patterns {
+		if pattern.exception != excluded {
+			continue
+		}
+		if pattern.matches(file) {
+			excluded = !pattern.exception
+		}
+	}
+	return excluded
+}
+
+func (p ignorePattern) matches(file string) bool {
+	for {
+		if p.re.MatchString(file) {
+			return true
+		}
+		parent := path.Dir(file)
+		if parent == file || parent == "." || parent == "/" {
+			return false
+		}
+		file = parent
+	}
+}
+
+func (f *ignoreFile) hasExceptions() bool {
+	for _, pattern := range f.patterns {
+		if pattern.exception {
+			return true
+		}
+	}
+	return false
+}
+
+// withIgnoreFile reads the ignore file of the build context, if any.
+func withIgnoreFile(ctx context.Context) context.Context {
+	dir, ok := buildContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ignore, err := readIgnoreFile(dir)
+	if err != nil || ignore == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ignoreFileKey, ignore)
+}
+
+// WithVerifiedBuildContext sets the directory of the build context as WithBuildContext does and
+// also reports the files copied from it which don't exist, dir must then be the actual context of
+// the build, e.g. the one passed to podman build.
+func WithVerifiedBuildContext(ctx context.Context, dir string) context.Context {
+	return context.WithValue(WithBuildContext(ctx, dir), verifiedBuildContextKey, true)
+}
+
+func isBuildContextVerified(ctx context.Context) bool {
+	verified, _ := ctx.Value(verifiedBuildContextKey).(bool)
+	return verified
+}
+
+func contextIgnoreFile(ctx context.Context) *ignoreFile {
+	ignore, _ := ctx.Value(ignoreFileKey).(*ignoreFile)
+	return ignore
+}
+
+// contextSource returns the path of the COPY or ADD source relative to the build context, it
+// reports false for URLs, heredocs and sources using variables.
+func contextSource(src string) (string, bool) {
+	if strings.Contains(src, "://") || strings.HasPrefix(src, "git@") || strings.HasPrefix(src, "<<") || strings.Contains(src, "$") {
+		return "", false
+	}
+	// sources can't escape the build context, ../app is the app file of its root
+	rel := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(src)), "/")
+	return rel, rel != ""
+}
+
+/*
+.dockerignore
+node_modules
+
+COPY node_modules/.bin/serve /usr/local/bin/
+*/
+func analyzeIgnoredSources(ctx context.Context, instruction string, node *parser.Node, source utils.Source, line Line) []Result {
+	ignore := contextIgnoreFile(ctx)
+	if ignore == nil || source.Type == utils.Parent {
+		return nil
+	}
+	if _, ok := instructionFlag(ctx, "from"); ok {
+		return nil
+	}
+	var results []Result
+	for _, src := range copySources(node) {
+		rel, ok := contextSource(src)
+		if !ok || strings.ContainsAny(rel, "*?[") || !ignore.excludes(rel) {
+			continue
+		}
+		results = append(results, RuleCopyIgnoredSource.Failed(i18n.Sprintf(ctx, "%s of %s %s copies files excluded from the build context by %s, the build fails as they are not sent to the builder",
+			instruction, src, GenerateErrorLocation(ctx, source, line), ignore.name)).At(source, line))
+	}
+	return results
+}
+
+/*
+COPY target/*.jar /deployments/
+ADD config/app.properties /etc/app/
+*/
+func analyzeMissingSources(ctx context.Context, instruction string, node *parser.Node, source utils.Source, line Line) []Result {
+	dir, ok := buildContext(ctx)
+	if !ok || !isBuildContextVerified(ctx) || source.Type == utils.Parent {
+		return nil
+	}
+	if _, ok := instructionFlag(ctx, "from"); ok {
+		return nil
+	}
+	ignore := contextIgnoreFile(ctx)
+	var results []Result
+	for _, src := range copySources(node) {
+		rel, ok := contextSource(src)
+		if !ok {
+			continue
+		}
+		if !strings.ContainsAny(rel, "*?[") {
+			// the files excluded by the ignore file are reported by analyzeIgnoredSources
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); os.IsNotExist(err) {
+				results = append(results, RuleCopyMissingSource.Failed(i18n.Sprintf(ctx, "%s of %s %s copies a file which doesn't exist in the build context %s, the build fails",
+					instruction, src, GenerateErrorLocation(ctx, source, line), dir)).At(source, line))
+			}
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(rel)))
+		found := false
+		for _, match := range matches {
+			if matchRel, err := filepath.Rel(dir, match); err == nil && !ignore.excludes(matchRel) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			results = append(results, RuleCopyMissingSource.Failed(i18n.Sprintf(ctx, "%s of %s %s matches no file of the build context %s which is not excluded by an ignore file, the build fails",
+				instruction, src, GenerateErrorLocation(ctx, source, line), dir)).At(source, line))
+		}
+	}
+	return results
+}
+
+// analyzeBuildContext reports the secrets and the large directories of the build context which
+// are not excluded by its ignore file. The whole build context is sent to the builder, e.g. by
+// oc start-build --from-dir, and copied into the image by COPY . /app.
+func analyzeBuildContext(ctx context.Context) []Result {
+	dir, ok := buildContext(ctx)
+	if !ok {
+		return nil
+	}
+	ignore := contextIgnoreFile(ctx)
+	notExcluded := i18n.Sprintf(ctx, "no .containerignore or .dockerignore excludes it")
+	if ignore != nil {
+		notExcluded = i18n.Sprintf(ctx, "%s doesn't exclude it", ignore.name)
+	}
+	var results []Result
+	entries := 0
+	filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if entries++; entries > MAX_CONTEXT_ENTRIES {
+			return errContextTooLarge
+		}
+		rel = filepath.ToSlash(rel)
+		if ignore.excludes(rel) {
+			if entry.IsDir() && !ignore.hasExceptions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case entry.IsDir() && contextDirectories[entry.Name()]:
+			results = append(results, RuleContextDirectory.Failed(i18n.Sprintf(ctx, "the directory %s is sent to the builder with the build context but %s. It slows down the build and could be copied into the image",
+				rel, notExcluded)))
+			return filepath.SkipDir
+		case entry.IsDir() && secretDirectories[entry.Name()]:
+			results = append(results, RuleContextSecret.Failed(i18n.Sprintf(ctx, "the directory %s, which could contain credentials, is sent to the builder with the build context but %s",
+				rel, notExcluded)))
+			return filepath.SkipDir
+		case !entry.IsDir() && isSecretFile(entry.Name()):
+			results = append(results, RuleContextSecret.Failed(i18n.Sprintf(ctx, "the file %s, which could contain credentials, is sent to the builder with the build context but %s",
+				rel, notExcluded)))
+		}
+		return nil
+	})
+	return results
+}
@@ -0,0 +1,150 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildContextDir creates a build context holding the files, directories ending with /
+func buildContextDir(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(file, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func analyzeInContext(dir string, content string) []Result {
+	_, results := parseAndAnalyze(WithBuildContext(context.Background(), dir), "Containerfile", []byte(content))
+	return results
+}
+
+func TestIgnorePatterns(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		".dockerignore": "# comment\n/build\n**/*.log\nnode_modules\ndocs/*.md\n!docs/README.md\n",
+	})
+	ignore, err := readIgnoreFile(dir)
+	if err != nil || ignore == nil {
+		t.Fatalf("Unable to read the ignore file: %v", err)
+	}
+	for file, expected := range map[string]bool{
+		"build":                   true,
+		"build/app.jar":           true,
+		"src/build":               false,
+		"app.log":                 true,
+		"logs/deep/app.log":       true,
+		"node_modules/x/index.js": true,
+		"docs/guide.md":           true,
+		"docs/README.md":          false,
+		"docs/api/guide.md":       false,
+		"src/main.go":             false,
+	} {
+		if ignore.excludes(file) != expected {
+			t.Errorf("Expected %s to be excluded: %t", file, expected)
+		}
+	}
+}
+
+func TestContainerignoreHasPrecedence(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{".containerignore": "target\n", ".dockerignore": "src\n"})
+	ignore, _ := readIgnoreFile(dir)
+	if ignore == nil || ignore.name != ".containerignore" || ignore.excludes("src") || !ignore.excludes("target") {
+		t.Errorf("Expected the .containerignore patterns but they were %v", ignore)
+	}
+}
+
+func TestFailIfCopySourceIsIgnored(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{".dockerignore": "target\n*.md\n!README.md\n"})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY target/app.jar /app/\nCOPY README.md /app/\nCOPY --from=builder target /target\nCOPY . /src\nUSER 1001\n"), RuleCopyIgnoredSource)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected a %s suggestion at line 2 but they were %v", RuleCopyIgnoredSource.ID, results)
+	}
+}
+
+func TestFailIfContextSecretsAndDirectoriesAreNotIgnored(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		".dockerignore":           "node_modules\n",
+		".env":                    "TOKEN=secret",
+		"certs/server.key":        "key",
+		".git/":                   "",
+		"node_modules/x/index.js": "",
+		"src/main.go":             "",
+	})
+	results := analyzeInContext(dir, "FROM scratch\nCOPY src /src\nUSER 1001\n")
+	if secrets := resultsOfRule(results, RuleContextSecret); len(secrets) != 2 {
+		t.Errorf("Expected 2 %s suggestions but they were %v", RuleContextSecret.ID, secrets)
+	}
+	if directories := resultsOfRule(results, RuleContextDirectory); len(directories) != 1 {
+		t.Errorf("Expected a %s suggestion about .git but they were %v", RuleContextDirectory.ID, directories)
+	}
+}
+
+func TestIgnoredContextFilesAreNotReported(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		".containerignore": ".git\n**/*.key\n.env\n",
+		".env":             "TOKEN=secret",
+		"certs/server.key": "key",
+		".git/":            "",
+	})
+	results := analyzeInContext(dir, "FROM scratch\nCOPY . /src\nUSER 1001\n")
+	if len(resultsOfRule(results, RuleContextSecret))+len(resultsOfRule(results, RuleContextDirectory)) != 0 {
+		t.Errorf("Expected no build context suggestion but they were %v", results)
+	}
+}
+
+func TestFailIfCopySourceIsMissing(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		".dockerignore":      "target/*.tmp.jar\n",
+		"target/app.jar":     "",
+		"target/lib.tmp.jar": "",
+		"config/app.yaml":    "",
+	})
+	content := `FROM scratch AS builder
+COPY target/*.jar /deployments/
+COPY target/*.tmp.jar /tmp/
+COPY config/ app.properties /etc/app/
+ADD https://example.com/app.tar.gz /tmp/
+COPY --from=builder /missing /missing
+COPY ../config/app.yaml /etc/app/
+USER 1001
+`
+	_, results := parseAndAnalyze(WithVerifiedBuildContext(context.Background(), dir), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleCopyMissingSource)
+	if len(results) != 2 || results[0].Line.Start != 3 || results[1].Line.Start != 4 {
+		t.Errorf("Expected %s suggestions at lines 3 and 4 but they were %v", RuleCopyMissingSource.ID, results)
+	}
+	if results := resultsOfRule(analyzeInContext(dir, content), RuleCopyMissingSource); len(results) != 0 {
+		t.Errorf("Expected no %s suggestion without a verified build context but they were %v", RuleCopyMissingSource.ID, results)
+	}
+}
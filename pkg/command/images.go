@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"sync"
+)
+
+// DEFAULT_IMAGE_JOBS is the number of images analyzed at the same time by default
+const DEFAULT_IMAGE_JOBS = 4
+
+// ImageResults are the results of an image analyzed by AnalyzeImages.
+type ImageResults struct {
+	Image   string   `json:"image"`
+	Results []Result `json:"results"`
+}
+
+// analyzeImage analyzes an image of AnalyzeImages
+var analyzeImage = AnalyzeImage
+
+// AnalyzeImages analyzes the images as AnalyzeImage does, at most jobs of them at the same time
+// since their configs are pulled from the registry. The results are in the order of the images.
+func AnalyzeImages(ctx context.Context, images []string, jobs int) []ImageResults {
+	if jobs < 1 {
+		jobs = 1
+	}
+	analyzed := make([]ImageResults, len(images))
+	pool := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, image := range images {
+		wg.Add(1)
+		pool <- struct{}{}
+		go func(i int, image string) {
+			defer wg.Done()
+			defer func() { <-pool }()
+			analyzed[i] = ImageResults{Image: image, Results: analyzeImage(ctx, image)}
+		}(i, image)
+	}
+	wg.Wait()
+	return analyzed
+}
@@ -0,0 +1,62 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeImages(t *testing.T) {
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	analyzeImage = func(ctx context.Context, image string) []Result {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return []Result{RuleSudo.Failed(image)}
+	}
+	defer func() { analyzeImage = AnalyzeImage }()
+
+	var images []string
+	for i := 0; i < 10; i++ {
+		images = append(images, fmt.Sprintf("quay.io/app/service-%d:latest", i))
+	}
+	analyzed := AnalyzeImages(context.Background(), images, 3)
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 images analyzed at the same time but got %d", maxRunning)
+	}
+	if len(analyzed) != len(images) {
+		t.Fatalf("Expected %d images but got %v", len(images), analyzed)
+	}
+	for i, image := range analyzed {
+		if image.Image != images[i] || len(image.Results) != 1 || image.Results[0].Description != images[i] {
+			t.Errorf("Expected the results of %s but got %v", images[i], image)
+		}
+	}
+}
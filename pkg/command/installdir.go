@@ -0,0 +1,156 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type installDirWritesKeyType struct{}
+type groupWritableKeyType struct{}
+
+// installDirWritesKey holds the writes to /opt and /usr/local set by the ENV instructions
+var installDirWritesKey installDirWritesKeyType
+
+// groupWritableKey holds the paths made group writable by chmod in each stage
+var groupWritableKey groupWritableKeyType
+
+// groupWritablePath is a path made writable by the root group, or by everyone, e.g. with
+// chmod -R g=u /opt/app
+type groupWritablePath struct {
+	path      string
+	recursive bool
+}
+
+// installDirRegexp matches the paths below the directory of an application in /opt, or below
+// /usr/local
+var installDirRegexp = regexp.MustCompile(`^(?:/opt/[^/]+|/usr/local)(?:/|$)`)
+
+// installDirWriteRegexp matches the writes of the start commands and scripts: redirections, tee,
+// mkdir, touch and in place edits
+var installDirWriteRegexp = regexp.MustCompile(`(?:>>?|\btee\s+(?:-a\s+)?|\b(?:mkdir|touch)\s+(?:-\S+\s+)*|\bsed\s+(?:-\w+\s+)*-i\S*\s+(?:'[^']*'|"[^"]*"|\S+)\s+)\s*((?:/opt|/usr/local)/[^\s;&|)"']+)`)
+
+// dataVariableRegexp matches the names of the variables commonly used to configure where the
+// application keeps its data, e.g. DATA_DIR, APP_CACHE_PATH or UPLOADS_DIR
+var dataVariableRegexp = regexp.MustCompile(`(?:^|_)(?:DATA|CACHE|WORK|STATE|UPLOADS?|STORAGE)_?(?:DIR|PATH|FOLDER|ROOT)?$`)
+
+// chmodGroupAsOwnerRegexp matches the modes giving the group the permissions of the owner, e.g.
+// g=u or ug+rwx,g=u
+var chmodGroupAsOwnerRegexp = regexp.MustCompile(`^chmod\s+(?:-\S+\s+)*\S*\bg[=+]u\b`)
+
+/*
+ENV DATA_DIR=/opt/app/data
+ENV CACHE_PATH=/usr/local/app/cache
+*/
+func envInstallDirWrites(ctx context.Context, name string, value string, source utils.Source, line Line) []runtimeWrite {
+	if !installDirRegexp.MatchString(value) || !dataVariableRegexp.MatchString(strings.ToUpper(name)) {
+		return nil
+	}
+	stage, _ := CurrentStage(ctx)
+	return []runtimeWrite{{rule: RuleInstallDirWrite, path: path.Clean(value), origin: "ENV " + name, stage: stage.Index, source: source, line: line}}
+}
+
+func withInstallDirWrites(ctx context.Context, writes ...runtimeWrite) context.Context {
+	if len(writes) == 0 {
+		return ctx
+	}
+	previous, _ := ctx.Value(installDirWritesKey).([]runtimeWrite)
+	return context.WithValue(ctx, installDirWritesKey, append(append([]runtimeWrite{}, previous...), writes...))
+}
+
+// withGroupWritablePaths records the paths the chmod commands of a RUN instruction make writable
+// by the root group.
+func withGroupWritablePaths(ctx context.Context, commands []string) context.Context {
+	var paths []groupWritablePath
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		if !chmodWritableRegexp.MatchString(command) && !chmodGroupAsOwnerRegexp.MatchString(command) {
+			continue
+		}
+		targets, recursive := ownershipTargets(command)
+		for _, target := range targets {
+			paths = append(paths, groupWritablePath{path: path.Clean(target), recursive: recursive})
+		}
+	}
+	if len(paths) == 0 {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(groupWritableKey).(map[int][]groupWritablePath)
+	writable := map[int][]groupWritablePath{}
+	for index, p := range previous {
+		writable[index] = p
+	}
+	writable[stage.Index] = append(append([]groupWritablePath{}, writable[stage.Index]...), paths...)
+	return context.WithValue(ctx, groupWritableKey, writable)
+}
+
+func startCommandInstallDirWrites(ctx context.Context, origin string, s string, source utils.Source, line Line) []runtimeWrite {
+	stage, _ := CurrentStage(ctx)
+	var writes []runtimeWrite
+	for _, match := range installDirWriteRegexp.FindAllStringSubmatch(s, -1) {
+		writes = append(writes, runtimeWrite{rule: RuleInstallDirWrite, path: path.Clean(match[1]), origin: origin, stage: stage.Index, source: source, line: line})
+	}
+	return writes
+}
+
+// analyzeInstallDirWrites reports the writes of the final stage to /opt/<app> and /usr/local
+// which are neither group writable nor below a volume: the arbitrary UID OpenShift runs the
+// container with only has the permissions of the root group.
+func analyzeInstallDirWrites(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	writes, _ := ctx.Value(installDirWritesKey).([]runtimeWrite)
+	runtimeWrites, _ := ctx.Value(runtimeWritesKey).([]runtimeWrite)
+	writes = append(append(append([]runtimeWrite{}, writes...), runtimeWrites...), startCommandWrites(ctx, startCommandInstallDirWrites)...)
+
+	volumes, _ := ctx.Value(volumesKey).(map[int][]string)
+	writable, _ := ctx.Value(groupWritableKey).(map[int][]groupWritablePath)
+	var results []Result
+	reported := map[string]bool{}
+	for _, write := range writes {
+		if write.stage != stage.Index || reported[write.path] || !installDirRegexp.MatchString(write.path) {
+			continue
+		}
+		if isWritable(write.path, volumes[stage.Index]) || isGroupWritable(write.path, writable[stage.Index]) {
+			continue
+		}
+		reported[write.path] = true
+		dir := mountPoint(write.path)
+		results = append(results, RuleInstallDirWrite.Failed(i18n.Sprintf(ctx, `%s %s writes to %s at runtime, which is not writable by the root group. The arbitrary UID OpenShift runs the container with gets "permission denied": make the directory group writable (chgrp -R 0 %s && chmod -R g=u %s) or mount a volume on it`,
+			write.origin, GenerateErrorLocation(ctx, write.source, write.line), write.path, dir, dir)).At(write.source, write.line))
+	}
+	return results
+}
+
+// isGroupWritable reports whether chmod made the path writable by the root group: the path
+// itself, the directory it is created in, or a parent directory changed recursively.
+func isGroupWritable(file string, writable []groupWritablePath) bool {
+	for _, dir := range writable {
+		if file == dir.path || path.Dir(file) == dir.path || (dir.recursive && strings.HasPrefix(file, strings.TrimSuffix(dir.path, "/")+"/")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFailIfInstallDirIsWrittenAtRuntime(t *testing.T) {
+	for _, instruction := range []string{
+		"ENV DATA_DIR=/opt/app/data",
+		"ENV APP_CACHE_PATH=/usr/local/app/cache",
+		"CMD ./server >> /opt/app/logs/server.log 2>&1",
+		`ENTRYPOINT ["sh", "-c", "mkdir -p /opt/app/work && exec app"]`,
+		`CMD ["sh", "-c", "sed -i \"s/PORT/$PORT/\" /usr/local/etc/app.conf && exec app"]`,
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nCOPY app /opt/app\nUSER 1001\n"+instruction+"\n"), RuleInstallDirWrite)
+			if len(results) != 1 || results[0].Line.Start != 4 {
+				t.Errorf("Expected a %s finding at line 4 but they were %v", RuleInstallDirWrite.ID, results)
+			}
+		})
+	}
+}
+
+func TestGroupWritableInstallDirIsNotReported(t *testing.T) {
+	for _, fix := range []string{
+		"RUN chgrp -R 0 /opt/app && chmod -R g=u /opt/app",
+		"RUN chmod 775 /opt/app/data",
+		"VOLUME /opt/app/data",
+	} {
+		t.Run(fix, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nCOPY app /opt/app\n"+fix+"\nUSER 1001\nENV DATA_DIR=/opt/app/data\n"), RuleInstallDirWrite)
+			if len(results) != 0 {
+				t.Errorf("Expected no finding but they were %v", results)
+			}
+		})
+	}
+}
+
+func TestInstallDirReadsAreNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nENV JAVA_HOME=/opt/java DATA_DIR=/var/lib/app\nUSER 1001\nCMD [\"/opt/app/bin/server\", \"--config=/opt/app/conf/server.yaml\"]\n"), RuleInstallDirWrite)
+	if len(results) != 0 {
+		t.Errorf("Expected no finding but they were %v", results)
+	}
+}
+
+func TestInstallDirWritesOfStartScript(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"start.sh": "#!/bin/sh\nenvsubst < /opt/app/app.conf.tpl > /opt/app/app.conf\nexec app\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY start.sh /start.sh\nUSER 1001\nENTRYPOINT [\"/start.sh\"]\n"), RuleInstallDirWrite)
+	if len(results) != 1 || !strings.HasPrefix(results[0].Description, "start.sh") || !strings.Contains(results[0].Description, "chmod -R g=u /opt/app") {
+		t.Errorf("Expected a %s finding of start.sh but they were %v", RuleInstallDirWrite.ID, results)
+	}
+}
+
+func TestInstallDirWritesAreIgnoredOnKubernetes(t *testing.T) {
+	_, results := parseAndAnalyze(WithPlatform(context.Background(), PlatformKubernetes), "Containerfile", []byte("FROM scratch\nUSER 1001\nENV DATA_DIR=/opt/app/data\n"))
+	results = resultsOfRule(results, RuleInstallDirWrite)
+	if len(results) != 0 {
+		t.Errorf("Expected no finding but they were %v", results)
+	}
+}
@@ -0,0 +1,114 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// INSTRUCTIONS are the instructions of the Dockerfile syntax, any other one fails the build
+var INSTRUCTIONS = []string{"ADD", "ARG", "CMD", "COPY", "ENTRYPOINT", "ENV", "EXPOSE", "FROM", "HEALTHCHECK", "LABEL", "MAINTAINER", "ONBUILD", "RUN", "SHELL", "STOPSIGNAL", "USER", "VOLUME", "WORKDIR"}
+
+// MAX_TYPO_DISTANCE is the number of edits an unknown instruction can be from a known one to be
+// reported as a typo of it
+const MAX_TYPO_DISTANCE = 2
+
+// analyzeUnknownInstructions reports the instructions which aren't Dockerfile instructions, the
+// parser accepting any word, with the known instruction they are a typo of.
+func analyzeUnknownInstructions(ctx context.Context, ast *parser.Node, source utils.Source) []Result {
+	var results []Result
+	for _, child := range ast.Children {
+		node := child
+		if strings.EqualFold(child.Value, "ONBUILD") && child.Next != nil && len(child.Next.Children) > 0 {
+			// the instruction triggered by ONBUILD is parsed as a child node
+			node = child.Next.Children[0]
+		}
+		instruction := strings.ToUpper(node.Value)
+		if instruction == "" || isInstruction(instruction) {
+			continue
+		}
+		line := Line{Start: child.StartLine, End: child.EndLine}
+		description := i18n.Sprintf(ctx, "unknown instruction %s %s, the build fails on it and the line is not analyzed", instruction, GenerateErrorLocation(ctx, source, line))
+		if suggestion, ok := suggestInstruction(instruction); ok {
+			description += i18n.Sprintf(ctx, ". Did you mean %s?", suggestion)
+		}
+		results = append(results, RuleUnknownInstruction.Failed(description).At(source, line))
+	}
+	return results
+}
+
+func isInstruction(instruction string) bool {
+	for _, known := range INSTRUCTIONS {
+		if known == instruction {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestInstruction returns the known instruction closest to the unknown one, if it's close
+// enough to be a typo.
+func suggestInstruction(instruction string) (string, bool) {
+	best, distance := "", MAX_TYPO_DISTANCE+1
+	for _, known := range INSTRUCTIONS {
+		// a short word is close to every short instruction
+		if d := editDistance(instruction, known); d < distance && d < len(known) {
+			best, distance = known, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and transpositions of
+// adjacent characters turning a into b.
+func editDistance(a, b string) int {
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = minInt(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
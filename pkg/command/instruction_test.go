@@ -0,0 +1,63 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUnknownInstructions(t *testing.T) {
+	content := "FROM registry.access.redhat.com/ubi9/ubi-minimal\nCOPYY app /app\nENTYRPOINT [\"/app\"]\nONBUILD RUNN make\nFOOBAR x\nUSER 1001\n"
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleUnknownInstruction)
+	expected := map[int]string{
+		2: "Did you mean COPY?",
+		3: "Did you mean ENTRYPOINT?",
+		4: "Did you mean RUN?",
+		5: "",
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results but got %v", len(expected), results)
+	}
+	for _, result := range results {
+		suggestion := expected[result.Line.Start]
+		if suggestion == "" && strings.Contains(result.Description, "Did you mean") || !strings.Contains(result.Description, suggestion) {
+			t.Errorf("Expected the suggestion %q at line %d but the description was %s", suggestion, result.Line.Start, result.Description)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"COPY", "COPY", 0},
+		{"COPYY", "COPY", 1},
+		{"ENTYRPOINT", "ENTRYPOINT", 1},
+		{"EXPSOE", "EXPOSE", 1},
+		{"WORKDIRR", "WORKDIR", 1},
+		{"FOOBAR", "FROM", 4},
+	} {
+		if distance := editDistance(test.a, test.b); distance != test.distance {
+			t.Errorf("Expected the distance between %s and %s to be %d but it was %d", test.a, test.b, test.distance, distance)
+		}
+	}
+}
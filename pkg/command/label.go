@@ -0,0 +1,95 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Label analyzes the labels set by LABEL. Each label is made of 2 nodes: the key and the value,
+// which keeps its quotes.
+type Label struct{}
+
+type exposeServicesKeyType struct{}
+
+// exposeServicesKey holds the last io.openshift.expose-services label set
+var exposeServicesKey exposeServicesKeyType
+
+const EXPOSE_SERVICES_LABEL = "io.openshift.expose-services"
+
+type exposeServicesLabel struct {
+	value  string
+	source utils.Source
+	line   Line
+}
+
+func (l Label) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	for key := node; key != nil && key.Next != nil; {
+		value := key.Next
+		if key.Value == EXPOSE_SERVICES_LABEL {
+			ctx = context.WithValue(ctx, exposeServicesKey, exposeServicesLabel{value: unquote(value.Value), source: source, line: line})
+		}
+		key = value.Next
+	}
+	return ctx
+}
+
+func (l Label) PostProcess(ctx context.Context) []Result {
+	return analyzeExposeServicesLabel(ctx)
+}
+
+/*
+LABEL io.openshift.expose-services="8080:http,8443:https"
+*/
+func analyzeExposeServicesLabel(ctx context.Context) []Result {
+	label, ok := ctx.Value(exposeServicesKey).(exposeServicesLabel)
+	if !ok {
+		return nil
+	}
+	exposed, _ := ctx.Value(exposedPortsKey).(map[int]bool)
+	var results []Result
+	for _, service := range strings.Split(label.value, ",") {
+		portName := strings.SplitN(strings.TrimSpace(service), ":", 2)
+		port, err := strconv.Atoi(strings.SplitN(portName[0], "/", 2)[0])
+		if err != nil || len(portName) != 2 || portName[1] == "" {
+			results = append(results, RuleExposeServicesLabel.Failed(i18n.Sprintf(ctx, `label %s %s has an invalid value '%s', expected <port>:<name>, e.g. 8080:http`,
+				EXPOSE_SERVICES_LABEL, GenerateErrorLocation(ctx, label.source, label.line), service)).At(label.source, label.line))
+		} else if !exposed[port] {
+			results = append(results, RuleExposeServicesLabel.Failed(i18n.Sprintf(ctx, `label %s %s lists the port %d which is not exposed`,
+				EXPOSE_SERVICES_LABEL, GenerateErrorLocation(ctx, label.source, label.line), port)).At(label.source, label.line))
+		}
+	}
+	return results
+}
+
+// unquote removes the quotes the parser keeps around a LABEL or ENV value.
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+		return unquoted
+	}
+	return value
+}
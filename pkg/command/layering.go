@@ -0,0 +1,196 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// MIN_MERGEABLE_RUNS is the number of consecutive RUN instructions reported as mergeable
+const MIN_MERGEABLE_RUNS = 3
+
+var (
+	// dependencyInstallRegexp matches the commands installing the dependencies of a project from
+	// its manifest, e.g. package.json
+	dependencyInstallRegexp = regexp.MustCompile(`\b(?:npm\s+(?:ci|install|i)\b|yarn\s+install\b|yarn\s*(?:&&|;|$)|pnpm\s+install\b|pip[23]?\s+install\s+(?:[^&|;]*\s)?-r\b|poetry\s+install\b|pipenv\s+install\b|mvnw?\s+[^&|;]*\b(?:dependency:\S+|package|install|verify)\b|go\s+mod\s+download\b|bundle\s+install\b|composer\s+install\b)`)
+	// downloadRegexp captures the files downloaded by curl -o or wget -O
+	downloadRegexp = regexp.MustCompile(`\b(?:curl\s+[^&|;]*-o\s*|wget\s+[^&|;]*-O\s*)([^\s&|;]+)`)
+	// removeRegexp captures the paths removed by rm
+	removeRegexp = regexp.MustCompile(`\brm\s+((?:-\S+\s+)*[^&|;]+)`)
+	// archiveRegexp matches the local archives ADD extracts
+	archiveRegexp = regexp.MustCompile(`\.(?:tar|tar\.gz|tgz|tar\.bz2|tbz2|tar\.xz|txz)$`)
+)
+
+// analyzeLayering reports the advisories about the layers of each stage: the build context copied
+// before the dependencies are installed, the consecutive RUN instructions which could be merged
+// and the files removed by a later layer than the one adding them.
+func analyzeLayering(ctx context.Context, ast *parser.Node, source utils.Source) []Result {
+	var results []Result
+	for _, stage := range astStages(ast) {
+		results = append(results, analyzeCacheBustingCopy(ctx, stage, source)...)
+		results = append(results, analyzeConsecutiveRuns(ctx, stage, source)...)
+		results = append(results, analyzeDeletedLayerFiles(ctx, stage, source)...)
+	}
+	return results
+}
+
+func analyzeCacheBustingCopy(ctx context.Context, stage astStage, source utils.Source) []Result {
+	var contextCopy *parser.Node
+	for _, instruction := range stage.instructions {
+		switch strings.ToLower(instruction.Value) {
+		case "copy", "add":
+			if _, ok := instructionFlagValue(instruction, "from"); ok || contextCopy != nil {
+				continue
+			}
+			for _, copied := range copySources(instruction.Next) {
+				if copied == "." || copied == "./" || copied == "*" {
+					contextCopy = instruction
+				}
+			}
+		case "run":
+			if !dependencyInstallRegexp.MatchString(instruction.Original) {
+				continue
+			}
+			if contextCopy == nil {
+				// the dependencies are installed before the sources are copied
+				return nil
+			}
+			copyLine := Line{Start: contextCopy.StartLine, End: contextCopy.EndLine}
+			line := Line{Start: instruction.StartLine, End: instruction.EndLine}
+			return []Result{RuleCacheBustingCopy.Failed(i18n.Sprintf(ctx, "the build context is copied %s before the dependencies are installed %s, any change to the sources installs them again. Copy the dependency manifests and install the dependencies first",
+				GenerateErrorLocation(ctx, source, copyLine), GenerateErrorLocation(ctx, source, line))).At(source, copyLine)}
+		}
+	}
+	return nil
+}
+
+func analyzeConsecutiveRuns(ctx context.Context, stage astStage, source utils.Source) []Result {
+	var results []Result
+	var runs []*parser.Node
+	report := func() {
+		if len(runs) >= MIN_MERGEABLE_RUNS {
+			line := Line{Start: runs[0].StartLine, End: runs[len(runs)-1].EndLine}
+			results = append(results, RuleConsecutiveRuns.Failed(i18n.Sprintf(ctx, "%d consecutive RUN instructions %s create a layer each, merge them with && unless they are split to be cached separately",
+				len(runs), GenerateErrorLocation(ctx, source, line))).At(source, line))
+		}
+		runs = nil
+	}
+	for _, instruction := range stage.instructions {
+		if strings.EqualFold(instruction.Value, "RUN") {
+			runs = append(runs, instruction)
+		} else {
+			report()
+		}
+	}
+	report()
+	return results
+}
+
+func analyzeDeletedLayerFiles(ctx context.Context, stage astStage, source utils.Source) []Result {
+	var results []Result
+	workdir := "/"
+	// added are the files added by the previous instructions, in order
+	var added []layerFile
+	for _, instruction := range stage.instructions {
+		line := Line{Start: instruction.StartLine, End: instruction.EndLine}
+		switch strings.ToLower(instruction.Value) {
+		case "workdir":
+			if instruction.Next != nil {
+				workdir = resolvePath(workdir, instruction.Next.Value)
+			}
+		case "copy", "add":
+			sources := copySources(instruction.Next)
+			if len(sources) == 0 {
+				continue
+			}
+			destination := instruction.Next
+			for destination.Next != nil {
+				destination = destination.Next
+			}
+			for _, copied := range sources {
+				if strings.EqualFold(instruction.Value, "ADD") && !strings.Contains(copied, "://") && archiveRegexp.MatchString(copied) {
+					// extracted by ADD
+					continue
+				}
+				target := resolvePath(workdir, destination.Value)
+				if len(sources) > 1 || strings.HasSuffix(destination.Value, "/") || destination.Value == "." {
+					target = path.Join(target, path.Base(copied))
+				}
+				added = append(added, layerFile{target, line})
+			}
+		case "run":
+			var removed []string
+			for _, match := range removeRegexp.FindAllStringSubmatch(instruction.Original, -1) {
+				for _, word := range strings.Fields(match[1]) {
+					if !strings.HasPrefix(word, "-") && !strings.ContainsAny(word, "$*") {
+						removed = append(removed, resolvePath(workdir, strings.Trim(word, `"'`)))
+					}
+				}
+			}
+			var kept []layerFile
+			for _, file := range added {
+				if !isRemoved(file.path, removed) {
+					kept = append(kept, file)
+					continue
+				}
+				results = append(results, RuleDeletedLayerFile.Failed(i18n.Sprintf(ctx, "%s added %s is removed %s, the layer adding it still stores it. Remove it in the same instruction or use a multi-stage build",
+					file.path, GenerateErrorLocation(ctx, source, file.line), GenerateErrorLocation(ctx, source, line))).At(source, line))
+			}
+			added = kept
+			for _, match := range downloadRegexp.FindAllStringSubmatch(instruction.Original, -1) {
+				file := resolvePath(workdir, strings.Trim(match[1], `"'`))
+				if !isRemoved(file, removed) {
+					added = append(added, layerFile{file, line})
+				}
+			}
+		}
+	}
+	return results
+}
+
+// layerFile is a file added to the image by an instruction
+type layerFile struct {
+	path string
+	line Line
+}
+
+// isRemoved returns true if file or one of its parent directories is removed.
+func isRemoved(file string, removed []string) bool {
+	for _, r := range removed {
+		if file == r || strings.HasPrefix(file, strings.TrimSuffix(r, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns the absolute path of p relative to the working directory.
+func resolvePath(workdir string, p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(workdir, p)
+}
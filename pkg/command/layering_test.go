@@ -0,0 +1,100 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheBustingCopy(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/nodejs-20 AS builder
+WORKDIR /app
+COPY . .
+RUN npm ci && npm run build
+FROM registry.access.redhat.com/ubi9/nodejs-20-minimal
+WORKDIR /app
+COPY package.json package-lock.json ./
+RUN npm ci --omit=dev
+COPY . .
+COPY --from=builder /app/dist ./dist
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleCacheBustingCopy)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected the COPY at line 3 to be reported but the results were %v", results)
+	}
+}
+
+func TestConsecutiveRuns(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal
+RUN microdnf install -y tar
+RUN microdnf install -y gzip
+RUN microdnf clean all
+WORKDIR /app
+RUN mkdir data
+RUN chmod g+w data
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleConsecutiveRuns)
+	if len(results) != 1 || results[0].Line.Start != 2 || results[0].Line.End != 4 {
+		t.Errorf("Expected the RUN instructions at lines 2-4 to be reported but the results were %v", results)
+	}
+}
+
+func TestDeletedLayerFile(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal
+WORKDIR /tmp
+COPY installer.bin .
+ADD app.tar.gz /opt/
+RUN curl -o /tmp/tool.zip https://example.com/tool.zip && rm /tmp/tool.zip
+RUN curl -o sdk.tgz https://example.com/sdk.tgz
+RUN ./installer.bin
+RUN rm -rf /tmp && rm -f /opt/app.tar.gz
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	results = resultsOfRule(results, RuleDeletedLayerFile)
+	if len(results) != 2 {
+		t.Fatalf("Expected installer.bin and sdk.tgz to be reported but the results were %v", results)
+	}
+	for _, result := range results {
+		if result.Line.Start != 8 {
+			t.Errorf("Expected the RUN at line 8 to be reported but the result was %v", result)
+		}
+	}
+}
+
+func TestLayeringAdvisoriesDontFailTheVerdict(t *testing.T) {
+	content := `FROM scratch
+RUN mkdir /app
+RUN touch /app/a
+RUN touch /app/b
+RUN touch /app/c
+USER 1001
+`
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	if len(resultsOfRule(results, RuleConsecutiveRuns)) != 1 {
+		t.Fatalf("Expected the consecutive RUN instructions to be reported but the results were %v", results)
+	}
+	if summary := SummarizeFailingOn(results, SeverityLow); summary.Verdict != VerdictPassed {
+		t.Errorf("Expected the layering advisories not to fail the verdict but the results were %v", results)
+	}
+}
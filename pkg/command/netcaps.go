@@ -0,0 +1,63 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type networkTool struct {
+	re         *regexp.Regexp
+	capability string
+}
+
+// networkTools need capabilities which are dropped by the restricted SCC
+var networkTools = []networkTool{
+	{regexp.MustCompile(`\bsetcap\s+[^;&|]*cap_net_raw`), "NET_RAW"},
+	{regexp.MustCompile(`\bsetcap\s+[^;&|]*cap_net_admin`), "NET_ADMIN"},
+	{regexp.MustCompile(`\bchmod\s+(u\+s|[4-7][0-7]{3})\s+\S*\b(ping6?|arping|traceroute)\b`), "NET_RAW"},
+	{regexp.MustCompile(`\btcpdump\s+-`), "NET_RAW"},
+	{regexp.MustCompile(`\barping\s+-`), "NET_RAW"},
+	{regexp.MustCompile(`\bip\s+(-\d\s+)?(route|link|addr|address|rule|neigh)\s+(add|del|delete|set|change|replace|flush)\b`), "NET_ADMIN"},
+	{regexp.MustCompile(`\b(iptables|ip6tables|nft)\s+-`), "NET_ADMIN"},
+}
+
+/*
+RUN setcap cap_net_raw+ep /usr/bin/ping
+RUN chmod u+s /bin/ping
+ENTRYPOINT ["tcpdump", "-i", "eth0"]
+CMD ip route add default via 10.0.0.1
+*/
+func analyzeNetworkCapabilities(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	reported := map[string]bool{}
+	for _, tool := range networkTools {
+		match := tool.re.FindString(s)
+		if match == "" || reported[tool.capability] {
+			continue
+		}
+		reported[tool.capability] = true
+		results = append(results, RuleNetworkCapability.Failed(i18n.Sprintf(ctx, `'%s' used in %s %s requires the %s capability, which is dropped by the restricted SCC. The command will fail when the container runs on OpenShift`,
+			match, instruction, GenerateErrorLocation(ctx, source, line), tool.capability)).At(source, line))
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import "testing"
+
+func TestFailIfNetworkToolsNeedCapabilities(t *testing.T) {
+	for _, instruction := range []string{
+		"RUN setcap cap_net_raw+ep /usr/bin/ping",
+		"RUN chmod u+s /bin/ping",
+		`ENTRYPOINT ["tcpdump", "-i", "eth0"]`,
+		"CMD ip route add default via 10.0.0.1",
+		"RUN iptables -A INPUT -p tcp --dport 8080 -j ACCEPT",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleNetworkCapability)
+			if len(results) != 1 {
+				t.Errorf("Expected a %s suggestion but they were %v", RuleNetworkCapability.ID, results)
+			}
+		})
+	}
+}
+
+func TestNetworkToolsInstallationIsNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN dnf install -y tcpdump iproute && ip route show\n"), RuleNetworkCapability)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
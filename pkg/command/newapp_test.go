@@ -0,0 +1,98 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfCommandWithoutExposedPort(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nCMD [\"./server\"]\n"), RuleNoExposedPort)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleNoExposedPort.ID, results)
+	}
+}
+
+func TestFailIfCmdIgnoredByShellFormEntrypoint(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nENTRYPOINT java -jar app.jar\nCMD [\"--debug\"]\n"), RuleEntrypointCmdConflict)
+	if len(results) != 1 || results[0].Line.Start != 5 {
+		t.Errorf("Expected a %s suggestion at line 5 but they were %v", RuleEntrypointCmdConflict.ID, results)
+	}
+}
+
+func TestFailIfShellFormCmdWithExecFormEntrypoint(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"java\", \"-jar\", \"app.jar\"]\nCMD run --debug\n"), RuleEntrypointCmdConflict)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleEntrypointCmdConflict.ID, results)
+	}
+}
+
+func TestFailIfSeveralCmdInFinalStage(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch AS builder\nCMD [\"make\"]\nFROM scratch\nUSER 1001\nEXPOSE 8080\nCMD [\"./server\"]\nCMD [\"./server\", \"--debug\"]\n"), RuleEntrypointCmdConflict)
+	if len(results) != 1 || results[0].Line.Start != 6 {
+		t.Errorf("Expected a %s suggestion at line 6 but they were %v", RuleEntrypointCmdConflict.ID, results)
+	}
+}
+
+func TestExecFormEntrypointAndCmd(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"java\", \"-jar\", \"app.jar\"]\nCMD [\"--debug\"]\n")
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestFailIfExposeServicesLabelListsPortNotExposed(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nLABEL io.openshift.expose-services=\"8080:http,8443:https\" name=app\n"), RuleExposeServicesLabel)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleExposeServicesLabel.ID, results)
+	}
+}
+
+func TestFailIfExposeServicesLabelIsInvalid(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nLABEL io.openshift.expose-services=\"http\"\n"), RuleExposeServicesLabel)
+	if len(results) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleExposeServicesLabel.ID, results)
+	}
+}
+
+func TestFailIfEntrypointUsesArgumentsWithoutCmd(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"/bin/sh\", \"-c\", \"exec java -jar app.jar \\\"$@\\\"\", \"--\"]\n"), RuleEntrypointArguments)
+	if len(results) != 1 || results[0].Line.Start != 4 {
+		t.Errorf("Expected a %s suggestion at line 4 but they were %v", RuleEntrypointArguments.ID, results)
+	}
+	results = resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"/bin/sh\", \"-c\", \"exec java -jar app.jar \\\"$@\\\"\", \"--\"]\nCMD [\"--debug\"]\n"), RuleEntrypointArguments)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion with a CMD but they were %v", RuleEntrypointArguments.ID, results)
+	}
+}
+
+func TestFailIfCopiedEntrypointScriptUsesArguments(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"entrypoint.sh": "#!/bin/sh\nexec \"$@\"\n",
+		"start.sh":      "#!/bin/sh\nif [ $# -eq 0 ]; then set -- serve; fi\nexec app \"$@\"\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY entrypoint.sh /usr/local/bin/\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"/usr/local/bin/entrypoint.sh\"]\n"), RuleEntrypointArguments)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "entrypoint.sh") {
+		t.Errorf("Expected a %s suggestion about entrypoint.sh but they were %v", RuleEntrypointArguments.ID, results)
+	}
+	results = resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY start.sh /start.sh\nUSER 1001\nEXPOSE 8080\nENTRYPOINT [\"/start.sh\"]\n"), RuleEntrypointArguments)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion when the script checks $# but they were %v", RuleEntrypointArguments.ID, results)
+	}
+}
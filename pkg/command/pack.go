@@ -0,0 +1,201 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+)
+
+// Pack gathers the rules specific to a language, a runtime or a kind of server. The rules of a
+// pack are only checked when it's active: detected from the base images and the commands of the
+// Containerfile, or selected with WithPacks. The rules without pack are always checked.
+type Pack struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// images match the base images, commands the other instructions activating the pack
+	images   *regexp.Regexp
+	commands *regexp.Regexp
+}
+
+const (
+	PACK_JAVA      = "java"
+	PACK_NODEJS    = "nodejs"
+	PACK_PYTHON    = "python"
+	PACK_GOLANG    = "golang"
+	PACK_DATABASE  = "database"
+	PACK_WEBSERVER = "webserver"
+)
+
+// Packs is the catalog of the packs.
+var Packs = []Pack{
+	{
+		Name:        PACK_JAVA,
+		Description: "Java applications: JVMs, Maven, Gradle, application servers",
+		images:      regexp.MustCompile(`(?:^|/)[\w.-]*(?:openjdk|jdk|jre|temurin|corretto|semeru|maven|gradle|tomcat|wildfly|jboss|quarkus)[\w.-]*(?::|@|$)`),
+		commands:    regexp.MustCompile(`\b(?:java|javac|mvnw?|gradlew?|keytool|JAVA_OPTS|JAVA_TOOL_OPTIONS)\b|\.jar\b`),
+	},
+	{
+		Name:        PACK_NODEJS,
+		Description: "Node.js applications",
+		images:      regexp.MustCompile(`(?:^|/)(?:node|nodejs[\w.-]*)(?::|@|$)`),
+		commands:    regexp.MustCompile(`\b(?:node|npm|npx|yarn|pnpm|NODE_OPTIONS)\b`),
+	},
+	{
+		Name:        PACK_PYTHON,
+		Description: "Python applications",
+		images:      regexp.MustCompile(`(?:^|/)(?:python|pypy)[\w.-]*(?::|@|$)`),
+		commands:    regexp.MustCompile(`\b(?:python[23]?|pip[23]?|gunicorn|uvicorn|poetry|pipenv)\b`),
+	},
+	{
+		Name:        PACK_GOLANG,
+		Description: "Go applications",
+		images:      regexp.MustCompile(`(?:^|/)(?:golang|go-toolset)[\w.-]*(?::|@|$)`),
+		commands:    regexp.MustCompile(`\bgo\s+(?:build|install|mod|run|generate)\b|\bGOMAXPROCS\b`),
+	},
+	{
+		Name:        PACK_DATABASE,
+		Description: "Databases: PostgreSQL, MySQL, MariaDB",
+		images:      regexp.MustCompile(`(?:^|/)[\w.-]*(?:postgres|postgresql|mysql|mariadb)[\w.-]*(?::|@|$)`),
+		commands:    regexp.MustCompile(`\b(?:initdb|pg_ctl|postgres|mysqld|mariadbd|mysql_install_db|mariadb-install-db|PGDATA|MYSQL_DATADIR|MARIADB_DATADIR)\b|/var/lib/(?:postgresql|pgsql|mysql|mariadb)\b`),
+	},
+	{
+		Name:        PACK_WEBSERVER,
+		Description: "Web servers: nginx, Apache httpd",
+		images:      regexp.MustCompile(`(?:^|/)[\w.-]*(?:nginx|httpd|apache2?)[\w.-]*(?::|@|$)`),
+		commands:    regexp.MustCompile(`\b(?:nginx|httpd|apachectl|apache2ctl|apache2)\b`),
+	},
+}
+
+type packsKeyType struct{}
+
+var packsKey packsKeyType
+
+func FindPack(name string) (Pack, bool) {
+	for _, pack := range Packs {
+		if pack.Name == name {
+			return pack, true
+		}
+	}
+	return Pack{}, false
+}
+
+// ParsePacks parses a comma separated list of packs, e.g. java,database. The packs which are not
+// listed are disabled.
+func ParsePacks(value string) (map[string]bool, error) {
+	packs := map[string]bool{}
+	for _, pack := range Packs {
+		packs[pack.Name] = false
+	}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := FindPack(name); !ok {
+			return nil, errors.Errorf("unknown pack %s, expected one of %s", name, strings.Join(PackNames(), ", "))
+		}
+		packs[name] = true
+	}
+	return packs, nil
+}
+
+// PackNames returns the names of the packs.
+func PackNames() []string {
+	var names []string
+	for _, pack := range Packs {
+		names = append(names, pack.Name)
+	}
+	return names
+}
+
+// WithPacks enables (true) or disables (false) packs, the other packs being detected from the
+// Containerfile. Calling it again overrides the packs set by the previous calls.
+func WithPacks(ctx context.Context, packs map[string]bool) context.Context {
+	selected := map[string]bool{}
+	if previous, ok := ctx.Value(packsKey).(map[string]bool); ok {
+		for name, enabled := range previous {
+			selected[name] = enabled
+		}
+	}
+	for name, enabled := range packs {
+		selected[name] = enabled
+	}
+	return context.WithValue(ctx, packsKey, selected)
+}
+
+// ActivePacks returns the names of the packs whose rules are checked on the Containerfile.
+func ActivePacks(ctx context.Context, node *parser.Node) []string {
+	selected, _ := ctx.Value(packsKey).(map[string]bool)
+	detected := detectPacks(node)
+	var active []string
+	for _, pack := range Packs {
+		enabled, ok := selected[pack.Name]
+		if (ok && enabled) || (!ok && detected[pack.Name]) {
+			active = append(active, pack.Name)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+// detectPacks matches the images of the FROM instructions and the other instructions against the
+// heuristics of the packs, the pack of the detected runtime being active as well.
+func detectPacks(node *parser.Node) map[string]bool {
+	detected := map[string]bool{}
+	if node == nil {
+		return detected
+	}
+	for _, child := range node.Children {
+		for _, pack := range Packs {
+			if strings.EqualFold(child.Value, "FROM") {
+				if child.Next != nil && pack.images.MatchString(strings.ToLower(child.Next.Value)) {
+					detected[pack.Name] = true
+				}
+			} else if pack.commands.MatchString(child.Original) {
+				detected[pack.Name] = true
+			}
+		}
+	}
+	if pack, ok := runtimePacks[DetectRuntime(node).Runtime]; ok {
+		detected[pack] = true
+	}
+	return detected
+}
+
+// forPacks drops the results of the rules of the inactive packs.
+func forPacks(ctx context.Context, node *parser.Node, results []Result) []Result {
+	active := map[string]bool{}
+	for _, name := range ActivePacks(ctx, node) {
+		active[name] = true
+	}
+	kept := []Result{}
+	for _, result := range results {
+		if rule, ok := FindRule(result.RuleID); ok && rule.Pack != "" && !active[rule.Pack] {
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
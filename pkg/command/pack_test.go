@@ -0,0 +1,59 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDetectPacks(t *testing.T) {
+	for content, expected := range map[string][]string{
+		"FROM maven:3.9 AS build\nFROM registry.access.redhat.com/ubi9/openjdk-17-runtime\nCMD [\"java\", \"-jar\", \"app.jar\"]\n": {PACK_JAVA},
+		"FROM node:20\nRUN npm ci\n": {PACK_NODEJS},
+		"FROM scratch\nENV PGDATA=/var/lib/pgsql/data\nCOPY nginx.conf /etc/nginx/nginx.conf\n": {PACK_DATABASE, PACK_WEBSERVER},
+		"FROM scratch\nCOPY app /app\n": nil,
+	} {
+		res, _, err := parse([]byte(content))
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		if active := ActivePacks(context.Background(), res.AST); !reflect.DeepEqual(active, expected) {
+			t.Errorf("Expected the packs %v for %q but they were %v", expected, content, active)
+		}
+	}
+}
+
+func TestSelectedPacksOverrideDetection(t *testing.T) {
+	content := "FROM scratch\nUSER 1001\nENV PGDATA=/var/lib/pgsql/data\n"
+	if results := resultsOfRule(analyzeContent(t, content), RuleDatabaseDataDir); len(results) != 1 {
+		t.Errorf("Expected the database pack to be detected but the results were %v", results)
+	}
+	packs, err := ParsePacks("java, webserver")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	_, results := parseAndAnalyze(WithPacks(context.Background(), packs), "Containerfile", []byte(content))
+	if len(resultsOfRule(results, RuleDatabaseDataDir)) != 0 {
+		t.Errorf("Expected the database pack to be disabled but the results were %v", results)
+	}
+	if _, err := ParsePacks("java,cobol"); err == nil {
+		t.Errorf("Expected an error for an unknown pack")
+	}
+}
@@ -0,0 +1,86 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// packageManagers are the commands matched by packageUpgradeRegexp and packageInstallRegexp
+var packageManagers = []string{"apt-get", "apt", "yum", "dnf", "microdnf", "apk"}
+
+var packageUpgradeRegexp = regexp.MustCompile(`\b(apt-get|apt|yum|dnf|microdnf|apk)\s+(-\S+\s+)*(upgrade|dist-upgrade|update)\b`)
+
+var packageInstallRegexp = regexp.MustCompile(`\b(apt-get|apt|yum|dnf|microdnf|apk)\s+(-\S+\s+)*(install|add)\s+([^;&|]+)`)
+
+// rpmVersionRegexp matches the version of name-version[-release] package specs
+var rpmVersionRegexp = regexp.MustCompile(`-\d[^-]*$`)
+
+/*
+RUN apt-get update && apt-get upgrade -y
+RUN dnf update -y
+*/
+func analyzePackageUpgrade(ctx context.Context, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, match := range packageUpgradeRegexp.FindAllStringSubmatch(s, -1) {
+		manager, command := match[1], match[3]
+		// apt-get and apk update refresh the package index only
+		if command == "update" && (manager == "apt-get" || manager == "apt" || manager == "apk") {
+			continue
+		}
+		results = append(results, RuleUnpinnedPackages.Failed(i18n.Sprintf(ctx, `'%s' %s upgrades the packages of the base image to their latest version. Every build, e.g. every BuildConfig run on OpenShift, could produce a different image. Use an updated base image instead`,
+			strings.TrimSpace(match[0]), GenerateErrorLocation(ctx, source, line))).At(source, line))
+	}
+	return results
+}
+
+/*
+RUN apt-get install -y --no-install-recommends curl=7.88.1-10 git
+RUN dnf install -y nginx-1.20.1 python3-pip
+RUN apk add --no-cache bash=5.2.15-r0
+*/
+func analyzeUnpinnedPackages(ctx context.Context, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, match := range packageInstallRegexp.FindAllStringSubmatch(s, -1) {
+		manager := match[1]
+		var unpinned []string
+		for _, pkg := range strings.Fields(match[4]) {
+			if strings.HasPrefix(pkg, "-") || strings.HasPrefix(pkg, "$") || strings.Contains(pkg, "/") {
+				continue
+			}
+			pinned := strings.Contains(pkg, "=")
+			if manager != "apt-get" && manager != "apt" && manager != "apk" {
+				pinned = rpmVersionRegexp.MatchString(pkg) || strings.HasSuffix(pkg, ".rpm")
+			}
+			if !pinned {
+				unpinned = append(unpinned, pkg)
+			}
+		}
+		if len(unpinned) == 0 {
+			continue
+		}
+		results = append(results, RuleUnpinnedPackages.Failed(i18n.Sprintf(ctx, `packages %s installed %s are not pinned to a version. Every build, e.g. every BuildConfig run on OpenShift, could produce a different image`,
+			strings.Join(unpinned, ", "), GenerateErrorLocation(ctx, source, line))).At(source, line))
+	}
+	return results
+}
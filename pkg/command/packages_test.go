@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfPackagesAreUpgraded(t *testing.T) {
+	for _, cmd := range []string{
+		"apt-get update && apt-get upgrade -y",
+		"dnf update -y",
+		"microdnf -y upgrade",
+	} {
+		t.Run(cmd, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN "+cmd+"\n"), RuleUnpinnedPackages)
+			if len(results) != 1 {
+				t.Errorf("Expected a %s suggestion but they were %v", RuleUnpinnedPackages.ID, results)
+			}
+		})
+	}
+}
+
+func TestFailIfPackagesAreNotPinned(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN apt-get update && apt-get install -y --no-install-recommends curl=7.88.1-10 git && dnf install -y nginx-1.20.1 python3-pip\n"), RuleUnpinnedPackages)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 suggestions but they were %v", results)
+	}
+	if !strings.Contains(results[0].Description, "git") || strings.Contains(results[0].Description, "curl") {
+		t.Errorf("Expected git to be the only unpinned package but it was %s", results[0].Description)
+	}
+	if !strings.Contains(results[1].Description, "python3-pip") || strings.Contains(results[1].Description, "nginx") {
+		t.Errorf("Expected python3-pip to be the only unpinned package but it was %s", results[1].Description)
+	}
+}
+
+func TestPinnedPackagesAreNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN apk add --no-cache bash=5.2.15-r0 && dnf install -y java-17-openjdk-17.0.9.0.9-2.el9\n"), RuleUnpinnedPackages)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
@@ -0,0 +1,73 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+)
+
+// runDirs are owned by root and only writable by root in the base images, the PID and lock
+// files written there fail for a non-root UID
+var runDirs = []string{"/run", "/var/run", "/var/lock"}
+
+// analyzePidFilePermissions reports the PID and lock files of the final stage written under
+// /run, /var/run or /var/lock, unless the directory is a volume, expected to be a tmpfs or an
+// emptyDir, or was made group writable.
+func analyzePidFilePermissions(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	writes, _ := ctx.Value(runtimeWritesKey).([]runtimeWrite)
+	writes = append(append([]runtimeWrite{}, writes...), startCommandWrites(ctx, commandWrites)...)
+	volumes, _ := ctx.Value(volumesKey).(map[int][]string)
+	writable, _ := ctx.Value(groupWritableKey).(map[int][]groupWritablePath)
+	var results []Result
+	reported := map[string]bool{}
+	for _, write := range writes {
+		if write.stage != stage.Index || reported[write.path] || !isRunDir(write.path) {
+			continue
+		}
+		if write.rule.ID != RuleRuntimePidFile.ID && write.rule.ID != RulePidFilePermission.ID {
+			continue
+		}
+		if isWritable(write.path, volumes[stage.Index]) || isGroupWritable(write.path, writable[stage.Index]) {
+			continue
+		}
+		reported[write.path] = true
+		kind := i18n.Translate(ctx, "the PID file")
+		if write.rule.ID == RulePidFilePermission.ID {
+			kind = i18n.Translate(ctx, "the lock file")
+		}
+		dir := mountPoint(write.path)
+		results = append(results, RulePidFilePermission.Failed(i18n.Sprintf(ctx, `%s %s writes %s %s, in a directory only root can write to: it fails for the non-root UID the container runs with. Write it to /tmp, mount an emptyDir on %s or make the directory group writable (chgrp 0 %s && chmod g=u %s)`,
+			write.origin, GenerateErrorLocation(ctx, write.source, write.line), kind, write.path, dir, dir, dir)).At(write.source, write.line))
+	}
+	return results
+}
+
+// isRunDir reports whether the path is below /run, /var/run or /var/lock.
+func isRunDir(file string) bool {
+	for _, dir := range runDirs {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
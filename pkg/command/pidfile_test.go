@@ -0,0 +1,63 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfPidOrLockFileIsWrittenToRunDir(t *testing.T) {
+	for instruction, kind := range map[string]string{
+		"ENV APP_PID_FILE=/var/run/app.pid":                          "the PID file",
+		"ENV LOCK_FILE=/var/lock/app/app.lock":                       "the lock file",
+		`CMD ["app", "--pid-file=/run/app/app.pid"]`:                 "the PID file",
+		`ENTRYPOINT ["sh", "-c", "touch /run/app.lock && exec app"]`: "the lock file",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RulePidFilePermission)
+			if len(results) != 1 || results[0].Line.Start != 3 || !strings.Contains(results[0].Description, kind) {
+				t.Errorf("Expected a %s finding for %s at line 3 but they were %v", RulePidFilePermission.ID, kind, results)
+			}
+		})
+	}
+}
+
+func TestPreparedRunDirIsNotReported(t *testing.T) {
+	for _, fix := range []string{
+		"RUN mkdir -p /var/run/app && chgrp 0 /var/run/app && chmod g=u /var/run/app",
+		"VOLUME /var/run/app",
+	} {
+		t.Run(fix, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\n"+fix+"\nUSER 1001\nCMD [\"app\", \"--pidfile=/var/run/app/app.pid\"]\n"), RulePidFilePermission)
+			if len(results) != 0 {
+				t.Errorf("Expected no finding but they were %v", results)
+			}
+		})
+	}
+}
+
+func TestFailIfCopiedConfigLocksInRunDir(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"httpd.conf": "PidFile /tmp/httpd.pid\nMutex file:/var/lock/httpd default\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY httpd.conf /etc/httpd/conf/httpd.conf\nUSER 1001\n"), RulePidFilePermission)
+	if len(results) != 1 || results[0].Line.Start != 2 || !strings.HasPrefix(results[0].Description, "httpd.conf") {
+		t.Errorf("Expected a %s finding of httpd.conf but they were %v", RulePidFilePermission.ID, results)
+	}
+}
@@ -0,0 +1,127 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Platform is the platform the image is deployed to, it selects the rules checked and their
+// severity.
+type Platform string
+
+const (
+	// PlatformOpenShift enforces the restricted SCC: arbitrary UIDs in the root group
+	PlatformOpenShift Platform = "openshift"
+	// PlatformKubernetes follows the Kubernetes best practices: a non-root user is recommended
+	// rather than required, the pods being hardened with runAsNonRoot and a read-only root
+	// filesystem
+	PlatformKubernetes Platform = "kubernetes"
+)
+
+type platformKeyType struct{}
+
+var platformKey platformKeyType
+
+// platformSeverities override the severity of the rules on a platform
+var platformSeverities = map[Platform]map[string]ResultSeverity{
+	PlatformKubernetes: {
+		RuleUserRoot.ID:       SeverityLow,
+		RulePrivilegedPort.ID: SeverityMedium,
+	},
+}
+
+// chmodWritableRegexp matches the chmod commands giving the group or the others the write
+// permission, e.g. chmod 777 /app/logs or chmod -R go+w /app/data
+var chmodWritableRegexp = regexp.MustCompile(`^chmod\s+(?:-\S+\s+)*(?:[0-7]?[0-7][2367][0-7]|[0-7]?[0-7][0-7][2367]|(?:[ugoa]*[goa][ugoa]*)?[+=][rxXst]*w[rwxXst]*)\s+(.+)$`)
+
+func ParsePlatform(value string) (Platform, error) {
+	platform := Platform(strings.ToLower(value))
+	if platform != PlatformOpenShift && platform != PlatformKubernetes {
+		return "", fmt.Errorf("unknown platform %s, expected one of openshift, kubernetes", value)
+	}
+	return platform, nil
+}
+
+// WithPlatform sets the platform the image is deployed to, PlatformOpenShift by default.
+func WithPlatform(ctx context.Context, platform Platform) context.Context {
+	return context.WithValue(ctx, platformKey, platform)
+}
+
+func platformOf(ctx context.Context) Platform {
+	if platform, ok := ctx.Value(platformKey).(Platform); ok {
+		return platform
+	}
+	return PlatformOpenShift
+}
+
+// AppliesTo reports whether the rule is checked on the platform.
+func (r Rule) AppliesTo(platform Platform) bool {
+	if len(r.Platforms) == 0 {
+		return true
+	}
+	for _, p := range r.Platforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// forPlatform drops the results of the rules which don't apply to the platform of the context and
+// sets the severity of the platform to the others.
+func forPlatform(ctx context.Context, results []Result) []Result {
+	platform := platformOf(ctx)
+	kept := []Result{}
+	for _, result := range results {
+		if rule, ok := FindRule(result.RuleID); ok && !rule.AppliesTo(platform) {
+			continue
+		}
+		if severity, ok := platformSeverities[platform][result.RuleID]; ok {
+			result.Severity = severity
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+/*
+chmod 777 /app/logs
+chmod -R g+w /app/data
+chmod a+rwx /var/cache/app
+*/
+func analyzeWritablePaths(ctx context.Context, commands []string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, command := range commands {
+		match := chmodWritableRegexp.FindStringSubmatch(strings.TrimSpace(command))
+		if match == nil {
+			continue
+		}
+		results = append(results, RuleReadOnlyRootFilesystem.Failed(
+			i18n.Sprintf(ctx, "%s %s makes %s writable at runtime. Writing to the image fails when the pod sets readOnlyRootFilesystem: true, unless a volume is mounted on the path", command, GenerateErrorLocation(ctx, source, line), match[1]),
+		).At(source, line))
+	}
+	return results
+}
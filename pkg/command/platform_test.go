@@ -0,0 +1,69 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+const kubernetesContainerfile = `FROM registry.access.redhat.com/ubi9 AS builder
+RUN useradd -u 1001 builder && chown builder:builder /src
+FROM registry.access.redhat.com/ubi9
+RUN useradd -u 1001 -g 0 appuser && chmod -R 777 /app/logs && chmod 750 /app/bin
+USER appuser
+`
+
+func TestOpenShiftPlatformIgnoresKubernetesRules(t *testing.T) {
+	results := analyzeFile(t, kubernetesContainerfile)
+	if len(resultsOfRule(results, RuleRunAsNonRoot)) != 0 || len(resultsOfRule(results, RuleReadOnlyRootFilesystem)) != 0 {
+		t.Errorf("Unexpected Kubernetes results %v", results)
+	}
+	if len(resultsOfRule(results, RuleChownGroup)) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleChownGroup.ID, results)
+	}
+}
+
+func TestKubernetesPlatform(t *testing.T) {
+	_, results := parseAndAnalyze(WithPlatform(context.Background(), PlatformKubernetes), "Containerfile", []byte(kubernetesContainerfile))
+	if nonRoot := resultsOfRule(results, RuleRunAsNonRoot); len(nonRoot) != 1 || nonRoot[0].Line.Start != 5 {
+		t.Errorf("Expected a %s suggestion at line 5 but they were %v", RuleRunAsNonRoot.ID, nonRoot)
+	}
+	if writable := resultsOfRule(results, RuleReadOnlyRootFilesystem); len(writable) != 1 || writable[0].Line.Start != 4 {
+		t.Errorf("Expected a %s suggestion at line 4 but they were %v", RuleReadOnlyRootFilesystem.ID, writable)
+	}
+	if len(resultsOfRule(results, RuleChownGroup)) != 0 || len(resultsOfRule(results, RuleChmodGroupPermission)) != 0 {
+		t.Errorf("Unexpected OpenShift results %v", results)
+	}
+}
+
+func TestKubernetesPlatformSeverities(t *testing.T) {
+	_, results := parseAndAnalyze(WithPlatform(context.Background(), PlatformKubernetes), "Containerfile", []byte("FROM scratch\nUSER root\n"))
+	if root := resultsOfRule(results, RuleUserRoot); len(root) != 1 || root[0].Severity != SeverityLow {
+		t.Errorf("Expected a low %s suggestion but they were %v", RuleUserRoot.ID, root)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	if platform, err := ParsePlatform("Kubernetes"); err != nil || platform != PlatformKubernetes {
+		t.Errorf("Expected the kubernetes platform but it was %s, error %v", platform, err)
+	}
+	if _, err := ParsePlatform("nomad"); err == nil {
+		t.Errorf("Expected an error for an unknown platform")
+	}
+}
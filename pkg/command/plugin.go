@@ -0,0 +1,105 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+)
+
+// Plugin is a rule pack shipped as a separate binary, which checks rules the analyzer doesn't
+// know about. The plugin package loads the plugins and serves their implementation.
+type Plugin interface {
+	// Name identifies the plugin in the error messages
+	Name() string
+	// Rules is the catalog of the rules checked by the plugin, their IDs must not clash with
+	// the ones of the analyzer
+	Rules() ([]Rule, error)
+	// Analyze returns the results of the rules of the plugin for the Containerfile content
+	Analyze(content []byte) ([]Result, error)
+}
+
+type pluginsKeyType struct{}
+
+var pluginsKey pluginsKeyType
+
+// WithPlugins makes the analysis of the Containerfiles run the rules of the plugins, whose rules
+// are expected to be registered with RegisterRules.
+func WithPlugins(ctx context.Context, plugins []Plugin) context.Context {
+	return context.WithValue(ctx, pluginsKey, plugins)
+}
+
+// RegisterRules adds the rules of a plugin to the catalog, so that they can be configured,
+// triaged and exported like the rules of the analyzer. A rule whose ID is already known is
+// rejected.
+func RegisterRules(plugin string, rules []Rule) error {
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return errors.Errorf("plugin %s has a rule without ID", plugin)
+		}
+		if _, ok := FindRule(rule.ID); ok {
+			return errors.Errorf("rule %s of plugin %s is already defined", rule.ID, plugin)
+		}
+		Rules = append(Rules, rule)
+	}
+	return nil
+}
+
+// UnregisterRules removes the rules from the catalog, e.g. the custom rules of a configuration
+// file which is loaded again.
+func UnregisterRules(rules []Rule) {
+	registered := map[string]bool{}
+	for _, rule := range rules {
+		registered[rule.ID] = true
+	}
+	kept := []Rule{}
+	for _, rule := range Rules {
+		if !registered[rule.ID] {
+			kept = append(kept, rule)
+		}
+	}
+	Rules = kept
+}
+
+// analyzePlugins runs the plugins of the context on the Containerfile content. A plugin failing
+// is reported as an analysis error, the results of the other ones are kept.
+func analyzePlugins(ctx context.Context, content []byte) []Result {
+	plugins, _ := ctx.Value(pluginsKey).([]Plugin)
+	results := []Result{}
+	for _, plugin := range plugins {
+		found, err := plugin.Analyze(content)
+		if err != nil {
+			results = append(results, Result{
+				Name:        "Plugin error",
+				Status:      StatusFailed,
+				Severity:    SeverityCritical,
+				Description: i18n.Sprintf(ctx, "plugin %s failed to analyze the Containerfile - error %s", plugin.Name(), err),
+			})
+			continue
+		}
+		for _, result := range found {
+			if result.Status == StatusPass && !reportsPassed(ctx) {
+				continue
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
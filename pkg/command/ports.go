@@ -0,0 +1,190 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type buildContextKeyType struct{}
+type exposedPortsKeyType struct{}
+type listenedPortsKeyType struct{}
+
+var buildContextKey buildContextKeyType
+var exposedPortsKey exposedPortsKeyType
+var listenedPortsKey listenedPortsKeyType
+
+// listenedPort is a port the application listens on, found in a configuration file or in the
+// arguments of a command
+type listenedPort struct {
+	port   int
+	origin string
+	source utils.Source
+	line   Line
+}
+
+type configPortPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// configPortPatterns match the ports set in the configuration files of common servers, by file
+// name pattern
+var configPortPatterns = []configPortPattern{
+	{"*.conf", regexp.MustCompile(`(?mi)^\s*listen\s+(?:\S*:)?(\d+)`)},
+	{"application*.properties", regexp.MustCompile(`(?m)^\s*server\.port\s*[=:]\s*(\d+)`)},
+	{"gunicorn.conf.py", regexp.MustCompile(`(?m)^\s*bind\s*=\s*["'][^"']*:(\d+)`)},
+}
+
+// commandPortRegexp matches the ports passed to servers on the command line
+var commandPortRegexp = regexp.MustCompile(`(?:--(?:http-)?port[= ]|--(?:bind|listen)[= ](?:\S*:)?|\bPORT=|\b0\.0\.0\.0:)(\d+)\b`)
+
+// portVariables are commonly used to configure the port of the application
+var portVariables = map[string]bool{
+	"PORT":        true,
+	"HTTP_PORT":   true,
+	"SERVER_PORT": true,
+}
+
+// WithBuildContext sets the directory of the build context, the files copied from it are then
+// analyzed as well.
+func WithBuildContext(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, buildContextKey, dir)
+}
+
+// BuildContextDir returns the directory of the build context set by WithBuildContext, if any.
+func BuildContextDir(ctx context.Context) (string, bool) {
+	return buildContext(ctx)
+}
+
+func buildContext(ctx context.Context) (string, bool) {
+	dir, ok := ctx.Value(buildContextKey).(string)
+	return dir, ok && dir != ""
+}
+
+func withExposedPort(ctx context.Context, port int) context.Context {
+	previous, _ := ctx.Value(exposedPortsKey).(map[int]bool)
+	ports := map[int]bool{port: true}
+	for p := range previous {
+		ports[p] = true
+	}
+	return context.WithValue(ctx, exposedPortsKey, ports)
+}
+
+func withListenedPorts(ctx context.Context, ports ...listenedPort) context.Context {
+	if len(ports) == 0 {
+		return ctx
+	}
+	previous, _ := ctx.Value(listenedPortsKey).([]listenedPort)
+	return context.WithValue(ctx, listenedPortsKey, append(append([]listenedPort{}, previous...), ports...))
+}
+
+/*
+CMD ["npm", "start", "--", "--port", "3000"]
+CMD gunicorn --bind 0.0.0.0:8000 app:app
+*/
+func commandPorts(origin string, s string, source utils.Source, line Line) []listenedPort {
+	var ports []listenedPort
+	for _, match := range commandPortRegexp.FindAllStringSubmatch(s, -1) {
+		if port, err := strconv.Atoi(match[1]); err == nil {
+			ports = append(ports, listenedPort{port: port, origin: origin, source: source, line: line})
+		}
+	}
+	return ports
+}
+
+// configPorts returns the ports set in the configuration files copied from the build context,
+// file being relative to the build context. Directories are walked.
+func configPorts(ctx context.Context, file string, source utils.Source, line Line) []listenedPort {
+	dir, ok := buildContext(ctx)
+	if !ok || strings.ContainsAny(file, "*?[") {
+		return nil
+	}
+	var ports []listenedPort
+	root := filepath.Join(dir, filepath.FromSlash(file))
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		for _, pattern := range configPortPatterns {
+			if matched, _ := filepath.Match(pattern.name, info.Name()); !matched {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, path)
+			for _, match := range pattern.re.FindAllStringSubmatch(string(content), -1) {
+				if port, err := strconv.Atoi(match[1]); err == nil {
+					ports = append(ports, listenedPort{port: port, origin: filepath.ToSlash(rel), source: source, line: line})
+				}
+			}
+		}
+		return nil
+	})
+	return ports
+}
+
+// analyzeNoExposedPort reports images running a command, set by the final stage, without any
+// exposed port.
+func analyzeNoExposedPort(ctx context.Context) []Result {
+	exposed, _ := ctx.Value(exposedPortsKey).(map[int]bool)
+	entrypoints, cmds := finalStartCommands(ctx)
+	if len(exposed) > 0 || len(entrypoints)+len(cmds) == 0 {
+		return nil
+	}
+	command := append(entrypoints, cmds...)[0]
+	return []Result{RuleNoExposedPort.Failed(i18n.Sprintf(ctx, `the image runs %s %s but exposes no port, oc new-app doesn't create any Service nor Route for it. Expose the port the application listens on, e.g. EXPOSE 8080`,
+		command.instruction, GenerateErrorLocation(ctx, command.source, command.line))).At(command.source, command.line)}
+}
+
+// analyzePortMismatch reports the ports the application listens on which are not exposed.
+func analyzePortMismatch(ctx context.Context) []Result {
+	exposed, _ := ctx.Value(exposedPortsKey).(map[int]bool)
+	listened, _ := ctx.Value(listenedPortsKey).([]listenedPort)
+	var results []Result
+	reported := map[int]bool{}
+	for _, l := range listened {
+		if exposed[l.port] || reported[l.port] {
+			continue
+		}
+		reported[l.port] = true
+		var ports []string
+		for port := range exposed {
+			ports = append(ports, strconv.Itoa(port))
+		}
+		sort.Strings(ports)
+		exposedPorts := strings.Join(ports, ", ")
+		if exposedPorts == "" {
+			exposedPorts = i18n.Translate(ctx, "none")
+		}
+		results = append(results, RulePortMismatch.Failed(i18n.Sprintf(ctx, `port %d set by %s %s is not exposed (exposed ports: %s). OpenShift creates the Service and the Route of the application (oc new-app) from the exposed ports, which then point at the wrong port. Add EXPOSE %d`,
+			l.port, l.origin, GenerateErrorLocation(ctx, l.source, l.line), exposedPorts, l.port)).At(l.source, l.line))
+	}
+	return results
+}
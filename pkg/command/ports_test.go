@@ -0,0 +1,56 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFailIfCopiedConfigPortIsNotExposed(t *testing.T) {
+	results := resultsOfRule(AnalyzePath(context.Background(), "resources/portmismatch/Containerfile"), RulePortMismatch)
+	if len(results) != 1 {
+		t.Fatalf("Expected a %s suggestion but they were %v", RulePortMismatch.ID, results)
+	}
+	if !strings.Contains(results[0].Description, "8081") || !strings.Contains(results[0].Description, "conf/default.conf") {
+		t.Errorf("Unexpected description %s", results[0].Description)
+	}
+}
+
+func TestFailIfCommandPortIsNotExposed(t *testing.T) {
+	for _, instruction := range []string{
+		`CMD ["npm", "start", "--", "--port", "3000"]`,
+		"CMD gunicorn --bind 0.0.0.0:3000 app:app",
+		"ENV PORT=3000",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nEXPOSE 8080\nUSER 1001\n"+instruction+"\n"), RulePortMismatch)
+			if len(results) != 1 {
+				t.Errorf("Expected a %s suggestion but they were %v", RulePortMismatch.ID, results)
+			}
+		})
+	}
+}
+
+func TestCommandPortIsExposed(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nEXPOSE 3000/tcp\nUSER 1001\nCMD node server.js --port=3000\n"), RulePortMismatch)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
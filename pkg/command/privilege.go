@@ -0,0 +1,155 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// PRIVILEGE_THRESHOLD is the privilege likelihood from which the workload is reported as
+// probably needing a custom SCC, i.e. two signals or a strong one
+const PRIVILEGE_THRESHOLD = 5
+
+// PRIVILEGE_LIKELY is the privilege likelihood from which the finding gets a medium confidence
+const PRIVILEGE_LIKELY = 8
+
+type privilegeSignalsKeyType struct{}
+
+// privilegeSignalsKey holds the privilege signals found in the instructions
+var privilegeSignalsKey privilegeSignalsKeyType
+
+type privilegePattern struct {
+	name   string
+	re     *regexp.Regexp
+	weight int
+}
+
+// privilegeSignal is a hint that the workload needs privileges, found in an instruction
+type privilegeSignal struct {
+	pattern privilegePattern
+	match   string
+	stage   int
+	source  utils.Source
+	line    Line
+}
+
+// privilegePatterns are the hints that the workload needs privileges, weighted by how likely it
+// makes it. Every pattern is only counted once.
+var privilegePatterns = []privilegePattern{
+	{"kernel modules", regexp.MustCompile(`\b(?:modprobe|insmod|rmmod|depmod)\b|/lib/modules\b`), 4},
+	{"device access", regexp.MustCompile(`/dev/[a-z][\w./-]*`), 3},
+	{"kernel parameters", regexp.MustCompile(`\bsysctl\s+-w\b|>\s*/proc/sys/`), 3},
+	{"mounts", regexp.MustCompile(`\b(?:mount|umount|losetup|dmsetup|mkfs(?:\.\w+)?)\s+[-/\w]`), 3},
+	{"namespaces", regexp.MustCompile(`\b(?:nsenter|unshare|chroot|pivot_root)\b`), 3},
+	{"container engine", regexp.MustCompile(`/run/docker\.sock|\bdockerd\b|\bpodman\s+(?:run|build|system\s+service)\b|\bbuildah\s+(?:bud|build|from)\b`), 3},
+	{"host networking tools", regexp.MustCompile(`\b(?:iptables|ip6tables|nft|ebtables|brctl|ethtool|tcpdump)\b|\bip\s+(?:link|route|addr|address)\s+(?:add|set|del|delete)\b`), 2},
+	{"capabilities", regexp.MustCompile(`\b(?:setcap|capsh)\b`), 2},
+	{"init system", regexp.MustCompile(`/s?bin/init\b|/usr/lib/systemd/systemd\b|\bsystemctl\s+enable\b`), 2},
+	{"host processes", regexp.MustCompile(`/proc/1/`), 2},
+}
+
+// unprivilegedDevices are available to every container
+var unprivilegedDevices = map[string]bool{
+	"null": true, "zero": true, "random": true, "urandom": true, "full": true, "tty": true,
+	"console": true, "stdin": true, "stdout": true, "stderr": true, "shm": true, "fd": true,
+	"pts": true, "ptmx": true, "termination-log": true,
+}
+
+// withPrivilegeSignals records the privilege signals of the instruction, the instructions of the
+// parent image are not considered.
+func withPrivilegeSignals(ctx context.Context, s string, source utils.Source, line Line) context.Context {
+	if source.Type == utils.Parent {
+		return ctx
+	}
+	signals := privilegeSignals(ctx, s, source, line)
+	if len(signals) == 0 {
+		return ctx
+	}
+	previous, _ := ctx.Value(privilegeSignalsKey).([]privilegeSignal)
+	return context.WithValue(ctx, privilegeSignalsKey, append(append([]privilegeSignal{}, previous...), signals...))
+}
+
+func privilegeSignals(ctx context.Context, s string, source utils.Source, line Line) []privilegeSignal {
+	stage, _ := CurrentStage(ctx)
+	var signals []privilegeSignal
+	for _, pattern := range privilegePatterns {
+		for _, match := range pattern.re.FindAllString(s, -1) {
+			if pattern.name == "device access" && unprivilegedDevices[strings.SplitN(strings.TrimPrefix(match, "/dev/"), "/", 2)[0]] {
+				continue
+			}
+			signals = append(signals, privilegeSignal{pattern: pattern, match: strings.TrimSpace(match), stage: stage.Index, source: source, line: line})
+			break
+		}
+	}
+	return signals
+}
+
+/*
+RUN dnf install -y kmod iptables
+ENTRYPOINT ["sh", "-c", "modprobe wireguard && ip link add wg0 type wireguard && exec app"]
+*/
+func analyzePrivilegeLikelihood(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	signals, _ := ctx.Value(privilegeSignalsKey).([]privilegeSignal)
+	entrypoints, cmds := finalStartCommands(ctx)
+	if commands := append(entrypoints, cmds...); len(commands) > 0 {
+		command := commands[len(commands)-1]
+		if len(entrypoints) > 0 {
+			command = entrypoints[len(entrypoints)-1]
+		}
+		if _, content, ok := startScript(ctx, command); ok {
+			signals = append(signals, privilegeSignals(ctx, string(content), command.source, command.line)...)
+		}
+	}
+
+	score := 0
+	counted := map[string]bool{}
+	var found []privilegeSignal
+	for _, signal := range signals {
+		if signal.stage != stage.Index || counted[signal.pattern.name] {
+			continue
+		}
+		counted[signal.pattern.name] = true
+		score += signal.pattern.weight
+		found = append(found, signal)
+	}
+	if score < PRIVILEGE_THRESHOLD {
+		return nil
+	}
+	// the strongest signal locates the finding
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].pattern.weight > found[j].pattern.weight
+	})
+	var hints []string
+	for _, signal := range found {
+		hints = append(hints, i18n.Sprintf(ctx, "%s ('%s' %s)", i18n.Translate(ctx, signal.pattern.name), signal.match, GenerateErrorLocation(ctx, signal.source, signal.line)))
+	}
+	result := RulePrivilegedWorkload.Failed(i18n.Sprintf(ctx, `the workload probably needs privileges (likelihood %d): %s. The restricted SCC won't allow it, plan a custom SCC (e.g. privileged or hostaccess) with the platform team or remove the need for privileges`,
+		score, strings.Join(hints, ", "))).At(found[0].source, found[0].line)
+	if score >= PRIVILEGE_LIKELY {
+		result.Confidence = ConfidenceMedium
+	}
+	return []Result{result}
+}
@@ -0,0 +1,62 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfWorkloadProbablyNeedsPrivileges(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nRUN mkdir /mnt/data\nENTRYPOINT [\"sh\", \"-c\", \"modprobe fuse && mount -t fuse /dev/fuse /mnt/data && exec app\"]\n"), RulePrivilegedWorkload)
+	if len(results) != 1 || results[0].Line.Start != 4 {
+		t.Fatalf("Expected a %s finding at line 4 but they were %v", RulePrivilegedWorkload.ID, results)
+	}
+	for _, hint := range []string{"kernel modules", "mounts", "device access", "likelihood 10"} {
+		if !strings.Contains(results[0].Description, hint) {
+			t.Errorf("Expected %q in the description but it was %s", hint, results[0].Description)
+		}
+	}
+	if results[0].Confidence != ConfidenceMedium {
+		t.Errorf("Expected a medium confidence but it was %s", results[0].Confidence)
+	}
+}
+
+func TestSingleWeakHintIsNotReported(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch\nUSER 1001\nRUN ethtool -i eth0 > /dev/null\nCMD [\"app\"]\n")
+	if found := resultsOfRule(results, RulePrivilegedWorkload); len(found) != 0 {
+		t.Errorf("Expected no %s finding but they were %v", RulePrivilegedWorkload.ID, found)
+	}
+}
+
+func TestPrivilegeHintsOfBuilderStagesAreIgnored(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch AS builder\nRUN mount -o loop disk.img /mnt && losetup -a && modprobe loop\nFROM scratch\nUSER 1001\nCMD [\"app\"]\n")
+	if found := resultsOfRule(results, RulePrivilegedWorkload); len(found) != 0 {
+		t.Errorf("Expected no %s finding but they were %v", RulePrivilegedWorkload.ID, found)
+	}
+}
+
+func TestPrivilegeHintsOfStartScript(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"start.sh": "#!/bin/sh\nsysctl -w net.ipv4.ip_forward=1\niptables -t nat -A POSTROUTING -j MASQUERADE\nexec app\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY start.sh /start.sh\nUSER 1001\nENTRYPOINT [\"/start.sh\"]\n"), RulePrivilegedWorkload)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "kernel parameters") || results[0].Confidence != ConfidenceLow {
+		t.Errorf("Expected a low confidence %s finding but they were %v", RulePrivilegedWorkload.ID, results)
+	}
+}
@@ -0,0 +1,127 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type ProfileKind string
+
+const (
+	ProfileRule        ProfileKind = "rule"
+	ProfileInstruction ProfileKind = "instruction"
+)
+
+// ProfileEntry is the time spent by a rule, or by the handler of an instruction, along with
+// the number of issues it reported.
+type ProfileEntry struct {
+	Name     string
+	Kind     ProfileKind
+	Calls    int
+	Duration time.Duration
+	Matches  int
+}
+
+// Profile records the execution of the rules across a scan, see WithProfile. The time of an
+// instruction handler includes the time of the rules it runs.
+type Profile struct {
+	entries map[string]*ProfileEntry
+}
+
+type profileKeyType struct{}
+
+var profileKey profileKeyType
+
+func NewProfile() *Profile {
+	return &Profile{entries: map[string]*ProfileEntry{}}
+}
+
+// WithProfile records the execution of the rules in profile.
+func WithProfile(ctx context.Context, profile *Profile) context.Context {
+	return context.WithValue(ctx, profileKey, profile)
+}
+
+func (p *Profile) entry(name string, kind ProfileKind) *ProfileEntry {
+	key := string(kind) + ":" + name
+	entry, ok := p.entries[key]
+	if !ok {
+		entry = &ProfileEntry{Name: name, Kind: kind}
+		p.entries[key] = entry
+	}
+	return entry
+}
+
+func (p *Profile) record(name string, kind ProfileKind, start time.Time) {
+	entry := p.entry(name, kind)
+	entry.Calls++
+	entry.Duration += time.Since(start)
+}
+
+// CountMatches counts the failed results per rule. The results are the reported ones, as the
+// results of a parent image are also returned by the Containerfile analysis.
+func (p *Profile) CountMatches(results []Result) {
+	for _, result := range results {
+		if result.RuleID != "" && result.Status == StatusFailed {
+			p.entry(result.RuleID, ProfileRule).Matches++
+		}
+	}
+}
+
+// Entries returns the entries, the slowest first.
+func (p *Profile) Entries() []ProfileEntry {
+	var entries []ProfileEntry
+	for _, entry := range p.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Duration != entries[j].Duration {
+			return entries[i].Duration > entries[j].Duration
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func profileFrom(ctx context.Context) *Profile {
+	profile, _ := ctx.Value(profileKey).(*Profile)
+	return profile
+}
+
+// profileRule runs analyze, recording its execution time for the rule when profiling and the
+// instruction it checks when recording the coverage.
+func profileRule(ctx context.Context, rule Rule, analyze func() []Result) []Result {
+	if coverage := coverageFrom(ctx); coverage != nil {
+		coverage.ran(rule)
+	}
+	profile := profileFrom(ctx)
+	if profile == nil {
+		return analyze()
+	}
+	defer profile.record(rule.ID, ProfileRule, time.Now())
+	return analyze()
+}
+
+// profileInstruction records the time spent by the handler of the instruction since start.
+func profileInstruction(ctx context.Context, instruction string, start time.Time) {
+	if profile := profileFrom(ctx); profile != nil {
+		profile.record(instruction, ProfileInstruction, start)
+	}
+}
@@ -0,0 +1,67 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+func profileEntry(profile *Profile, name string, kind ProfileKind) *ProfileEntry {
+	for _, entry := range profile.Entries() {
+		if entry.Name == name && entry.Kind == kind {
+			return &entry
+		}
+	}
+	return nil
+}
+
+func TestProfileRecordsRulesAndInstructions(t *testing.T) {
+	res, err := parser.Parse(strings.NewReader("FROM scratch\nUSER 1001\nRUN chown -R 1001:1001 /app\nRUN echo done\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	profile := NewProfile()
+	results, _ := AnalyzeNodeFromSource(WithProfile(context.Background(), profile), res.AST, utils.Source{Name: "test", Type: utils.Image})
+	profile.CountMatches(results)
+
+	if entry := profileEntry(profile, "RUN", ProfileInstruction); entry == nil || entry.Calls < 2 {
+		t.Errorf("Expected the RUN handler to be profiled but it was %v", entry)
+	}
+	if entry := profileEntry(profile, RuleHostPath.ID, ProfileRule); entry == nil || entry.Calls != 2 || entry.Matches != 0 {
+		t.Errorf("Expected %s to run twice without matches but it was %v", RuleHostPath.ID, entry)
+	}
+	if entry := profileEntry(profile, RuleChownGroup.ID, ProfileRule); entry == nil || entry.Matches != 1 {
+		t.Errorf("Expected 1 match of %s but it was %v", RuleChownGroup.ID, entry)
+	}
+}
+
+func TestProfileIsOptional(t *testing.T) {
+	calls := 0
+	profileRule(context.Background(), RuleHostPath, func() []Result {
+		calls++
+		return nil
+	})
+	if calls != 1 {
+		t.Errorf("Expected the rule to run once but it ran %d times", calls)
+	}
+}
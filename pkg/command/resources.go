@@ -0,0 +1,57 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+type resourceFlag struct {
+	re          *regexp.Regexp
+	alternative string
+}
+
+// resourceFlags size the process regardless of the limits of the container
+var resourceFlags = []resourceFlag{
+	{regexp.MustCompile(`-Xmx\d+[kKmMgG]?`), "-XX:MaxRAMPercentage (e.g. -XX:MaxRAMPercentage=75.0)"},
+	{regexp.MustCompile(`-Xms\d+[kKmMgG]?`), "-XX:InitialRAMPercentage"},
+	{regexp.MustCompile(`--max-old-space-size=\d+`), "a heap size computed from the container memory limit"},
+	{regexp.MustCompile(`\bGOMAXPROCS=\d+`), "the default GOMAXPROCS with go.uber.org/automaxprocs"},
+}
+
+/*
+ENV JAVA_OPTS="-Xms512m -Xmx2g"
+ENV GOMAXPROCS=8
+CMD ["node", "--max-old-space-size=4096", "server.js"]
+*/
+func analyzeResourceFlags(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, flag := range resourceFlags {
+		match := flag.re.FindString(s)
+		if match == "" {
+			continue
+		}
+		results = append(results, RuleHardcodedResources.Failed(i18n.Sprintf(ctx, `'%s' set in %s %s ignores the limits of the container. OpenShift enforces the memory and CPU limits through cgroups, use %s instead`,
+			match, instruction, GenerateErrorLocation(ctx, source, line), i18n.Translate(ctx, flag.alternative))).At(source, line))
+	}
+	return results
+}
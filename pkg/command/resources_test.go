@@ -0,0 +1,44 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import "testing"
+
+func TestFailIfResourcesAreHardcoded(t *testing.T) {
+	for _, instruction := range []string{
+		`ENV JAVA_OPTS="-Xmx2g"`,
+		"ENV GOMAXPROCS=8",
+		"ENV GOMAXPROCS 8",
+		`CMD ["node", "--max-old-space-size=4096", "server.js"]`,
+		"ENTRYPOINT java -Xmx512m -jar app.jar",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleHardcodedResources)
+			if len(results) != 1 {
+				t.Errorf("Expected a %s suggestion but they were %v", RuleHardcodedResources.ID, results)
+			}
+		})
+	}
+}
+
+func TestContainerAwareSettingsAreNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nENV JAVA_OPTS=\"-XX:MaxRAMPercentage=75.0\"\n"), RuleHardcodedResources)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
@@ -0,0 +1,343 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// Volume records the volumes declared by the image, the paths below them stay writable with a
+// read-only root filesystem. Its post processing reports the writes of the application to the
+// image at runtime, see GROUP_READ_ONLY_ROOTFS, and to the installation, run and database data
+// directories which are not group writable.
+type Volume struct{}
+
+type volumesKeyType struct{}
+type runtimeWritesKeyType struct{}
+type stdioLinksKeyType struct{}
+
+var volumesKey volumesKeyType
+
+// stdioLinksKey holds the log files linked to the standard output or error in each stage, e.g.
+// ln -sf /dev/stdout /var/log/nginx/access.log
+var stdioLinksKey stdioLinksKeyType
+
+// runtimeWritesKey holds the writes of the application found in the ENV instructions and in the
+// copied configuration files
+var runtimeWritesKey runtimeWritesKeyType
+
+// runtimeWrite is a path the application writes to at runtime
+type runtimeWrite struct {
+	rule   Rule
+	path   string
+	origin string
+	stage  int
+	source utils.Source
+	line   Line
+}
+
+type writePattern struct {
+	rule Rule
+	re   *regexp.Regexp
+}
+
+// configWritePatterns match the log files, PID files and temporary directories set in the
+// configuration files of common servers (nginx, httpd, redis, supervisord, ...)
+var configWritePatterns = []writePattern{
+	{RuleRuntimeLogFile, regexp.MustCompile(`(?mi)^\s*(?:error_log|access_log|ErrorLog|CustomLog|TransferLog|logfile|log_file|log-file|logpath)\s*[=:]?\s*["']?(/[^\s;"']+)`)},
+	// the file appenders of log4j, log4j2 and logback
+	{RuleRuntimeLogFile, regexp.MustCompile(`(?mi)^\s*(?:log4j\.appender\.[\w.]+\.File|appender\.[\w.]+\.fileName)\s*[=:]\s*(/[^\s"']+)`)},
+	{RuleRuntimeLogFile, regexp.MustCompile(`(?i)\bfileName\s*=\s*"(/[^"]+)"|<file>\s*(/[^<\s]+)\s*</file>|<param\s+name="File"\s+value="(/[^"]+)"`)},
+	{RuleRuntimePidFile, regexp.MustCompile(`(?mi)^\s*(?:pid|PidFile|pidfile|pid_file|pid-file)\s*[=:]?\s*["']?(/[^\s;"']+)`)},
+	{RulePidFilePermission, regexp.MustCompile(`(?mi)^\s*(?:lock_file|lockfile|lock-file|LockFile|Mutex\s+(?:file|fcntl|flock):)\s*[=:]?\s*["']?(/[^\s;"']+)`)},
+	{RuleRuntimeTempFile, regexp.MustCompile(`(?mi)^\s*(?:client_body_temp_path|proxy_temp_path|fastcgi_temp_path|uwsgi_temp_path|scgi_temp_path|tmpdir|tmp_dir)\s*[=:]?\s*["']?(/[^\s;"']+)`)},
+}
+
+// configWriteFiles are the names of the configuration files checked for writes
+var configWriteFiles = []string{"*.conf", "*.cnf", "*.ini", "*.cfg", "log4j*.properties", "log4j*.xml", "logback*.xml"}
+
+// commandWritePatterns match the writes of the start commands and scripts: options of the
+// servers, redirections and temporary files
+var commandWritePatterns = []writePattern{
+	{RuleRuntimeLogFile, regexp.MustCompile(`--log-?(?:file|dir|path)[= ](/[^\s;&|)"']+)`)},
+	{RuleRuntimeLogFile, regexp.MustCompile(`(?:>>?|\btee\s+(?:-a\s+)?)\s*(/[^\s;&|)"']+\.log)\b`)},
+	{RuleRuntimeLogFile, regexp.MustCompile(`(?:>>?|\btee\s+(?:-a\s+)?)\s*(/var/log/[^\s;&|)"']+)`)},
+	{RuleRuntimePidFile, regexp.MustCompile(`--pid-?file[= ](/[^\s;&|)"']+)`)},
+	{RuleRuntimePidFile, regexp.MustCompile(`>\s*(/[^\s;&|)"']+\.pid)\b`)},
+	{RulePidFilePermission, regexp.MustCompile(`--lock-?file[= ](/[^\s;&|)"']+)`)},
+	{RulePidFilePermission, regexp.MustCompile(`(?:>|\btouch\s+|\bflock\s+(?:-\w+\s+)*)\s*(/[^\s;&|)"']+\.lock)\b`)},
+	{RuleRuntimeTempFile, regexp.MustCompile(`(?:-Djava\.io\.tmpdir=|--tmpdir[= ]|\bmktemp\s+(?:-\w+\s+)*-p\s+)(/[^\s;&|)"']+)`)},
+}
+
+// logVariableRegexp, pidVariableRegexp, lockVariableRegexp and tempVariableRegexp match the names
+// of the variables commonly used to configure where the application writes, e.g. LOG_DIR,
+// APP_PID_FILE, LOCK_FILE or TMPDIR
+var logVariableRegexp = regexp.MustCompile(`(?:^|_)LOGS?_?(?:DIR|FILE|PATH|FOLDER|LOCATION)?$`)
+var pidVariableRegexp = regexp.MustCompile(`(?:^|_)PID_?(?:FILE|PATH)?$`)
+var lockVariableRegexp = regexp.MustCompile(`(?:^|_)LOCK_?(?:FILE|PATH|DIR)$`)
+var tempVariableRegexp = regexp.MustCompile(`^(?:TMPDIR|TMP|TEMP|TEMPDIR)$|_(?:TMP|TEMP)_?DIR$`)
+
+// stdioLinkRegexp matches the links of log files to the standard output or error
+var stdioLinkRegexp = regexp.MustCompile(`\bln\s+(?:-\S+\s+)*/dev/(?:stdout|stderr|fd/[12])\s+(/[^\s;&|)"']+)`)
+
+// writablePaths stay writable with a read-only root filesystem: /tmp is expected to be mounted as
+// an emptyDir, the devices (e.g. /dev/stdout) and the proc files are not part of the image
+var writablePaths = []string{"/tmp", "/dev", "/proc"}
+
+func (v Volume) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(volumesKey).(map[int][]string)
+	volumes := map[int][]string{}
+	for index, paths := range previous {
+		volumes[index] = paths
+	}
+	volumes[stage.Index] = append(append([]string{}, volumes[stage.Index]...), path.Clean(node.Value))
+	if source.Type != utils.Parent {
+		ctx = withDataDirVolume(ctx, path.Clean(node.Value), source, line)
+	}
+	return context.WithValue(ctx, volumesKey, volumes)
+}
+
+func (v Volume) PostProcess(ctx context.Context) []Result {
+	results := append(analyzeRuntimeWrites(ctx), profileRule(ctx, RuleInstallDirWrite, func() []Result {
+		return analyzeInstallDirWrites(ctx)
+	})...)
+	results = append(results, profileRule(ctx, RulePidFilePermission, func() []Result {
+		return analyzePidFilePermissions(ctx)
+	})...)
+	return append(results, profileRule(ctx, RuleDatabaseDataDir, func() []Result {
+		return analyzeDataDirs(ctx)
+	})...)
+}
+
+func withRuntimeWrites(ctx context.Context, writes ...runtimeWrite) context.Context {
+	if len(writes) == 0 {
+		return ctx
+	}
+	previous, _ := ctx.Value(runtimeWritesKey).([]runtimeWrite)
+	return context.WithValue(ctx, runtimeWritesKey, append(append([]runtimeWrite{}, previous...), writes...))
+}
+
+/*
+ENV LOG_DIR=/var/log/app
+ENV TMPDIR=/app/tmp JAVA_OPTS="-Djava.io.tmpdir=/app/tmp"
+*/
+func envWrites(ctx context.Context, name string, value string, source utils.Source, line Line) []runtimeWrite {
+	stage, _ := CurrentStage(ctx)
+	origin := "ENV " + name
+	if writes := commandWrites(ctx, origin, value, source, line); len(writes) > 0 {
+		return writes
+	}
+	if !path.IsAbs(value) {
+		return nil
+	}
+	name = strings.ToUpper(name)
+	var rule Rule
+	switch {
+	case pidVariableRegexp.MatchString(name):
+		rule = RuleRuntimePidFile
+	case lockVariableRegexp.MatchString(name):
+		rule = RulePidFilePermission
+	case logVariableRegexp.MatchString(name):
+		rule = RuleRuntimeLogFile
+	case tempVariableRegexp.MatchString(name):
+		rule = RuleRuntimeTempFile
+	default:
+		return nil
+	}
+	return []runtimeWrite{{rule: rule, path: path.Clean(value), origin: origin, stage: stage.Index, source: source, line: line}}
+}
+
+func commandWrites(ctx context.Context, origin string, s string, source utils.Source, line Line) []runtimeWrite {
+	stage, _ := CurrentStage(ctx)
+	var writes []runtimeWrite
+	for _, pattern := range commandWritePatterns {
+		for _, match := range pattern.re.FindAllStringSubmatch(s, -1) {
+			writes = append(writes, runtimeWrite{rule: pattern.rule, path: path.Clean(match[1]), origin: origin, stage: stage.Index, source: source, line: line})
+		}
+	}
+	return writes
+}
+
+// configWrites returns the writes set in the configuration files copied from the build context,
+// file being relative to the build context. Directories are walked.
+func configWrites(ctx context.Context, file string, source utils.Source, line Line) []runtimeWrite {
+	dir, ok := buildContext(ctx)
+	if !ok || strings.ContainsAny(file, "*?[") {
+		return nil
+	}
+	stage, _ := CurrentStage(ctx)
+	var writes []runtimeWrite
+	root := filepath.Join(dir, filepath.FromSlash(file))
+	filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !isConfigWriteFile(info.Name()) {
+			return nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, file)
+		for _, pattern := range configWritePatterns {
+			for _, match := range pattern.re.FindAllStringSubmatch(string(content), -1) {
+				writes = append(writes, runtimeWrite{rule: pattern.rule, path: path.Clean(firstGroup(match)), origin: filepath.ToSlash(rel), stage: stage.Index, source: source, line: line})
+			}
+		}
+		return nil
+	})
+	return writes
+}
+
+// firstGroup returns the first group of the match which matched, the patterns having
+// alternatives each capturing the path
+func firstGroup(match []string) string {
+	for _, group := range match[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
+
+/*
+RUN ln -sf /dev/stdout /var/log/nginx/access.log && ln -sf /dev/stderr /var/log/nginx/error.log
+*/
+func withStdioLinks(ctx context.Context, s string) context.Context {
+	matches := stdioLinkRegexp.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(stdioLinksKey).(map[int][]string)
+	links := map[int][]string{}
+	for index, paths := range previous {
+		links[index] = paths
+	}
+	links[stage.Index] = append([]string{}, links[stage.Index]...)
+	for _, match := range matches {
+		links[stage.Index] = append(links[stage.Index], path.Clean(match[1]))
+	}
+	return context.WithValue(ctx, stdioLinksKey, links)
+}
+
+func isConfigWriteFile(name string) bool {
+	for _, pattern := range configWriteFiles {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeRuntimeWrites reports the writes of the final stage, and of its start commands, outside
+// the volumes of the image and /tmp.
+func analyzeRuntimeWrites(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	writes, _ := ctx.Value(runtimeWritesKey).([]runtimeWrite)
+	writes = append(append([]runtimeWrite{}, writes...), startCommandWrites(ctx, commandWrites)...)
+
+	volumes, _ := ctx.Value(volumesKey).(map[int][]string)
+	links, _ := ctx.Value(stdioLinksKey).(map[int][]string)
+	var results []Result
+	reported := map[string]bool{}
+	for _, write := range writes {
+		if write.stage != stage.Index || write.rule.Group != GROUP_READ_ONLY_ROOTFS || reported[write.rule.ID+write.path] || isWritable(write.path, volumes[stage.Index]) {
+			continue
+		}
+		if write.rule.ID == RuleRuntimeLogFile.ID && isStdioLink(write.path, links[stage.Index]) {
+			continue
+		}
+		reported[write.rule.ID+write.path] = true
+		location := GenerateErrorLocation(ctx, write.source, write.line)
+		var description string
+		switch write.rule.ID {
+		case RuleRuntimeLogFile.ID:
+			description = i18n.Sprintf(ctx, `%s %s writes the logs to %s in the image: OpenShift only collects the standard output and error of the containers, and the writes fail when the pod sets readOnlyRootFilesystem: true. Log to the standard output, link the file to /dev/stdout or mount a volume on %s`, write.origin, location, write.path, mountPoint(write.path))
+		case RuleRuntimePidFile.ID:
+			description = i18n.Sprintf(ctx, `%s %s writes the PID file %s in the image, which fails when the pod sets readOnlyRootFilesystem: true. Write it to /tmp or mount a volume on %s`, write.origin, location, write.path, mountPoint(write.path))
+		default:
+			description = i18n.Sprintf(ctx, `%s %s sets the temporary directory to %s, which fails when the pod sets readOnlyRootFilesystem: true. Use /tmp or mount a volume on %s`, write.origin, location, write.path, write.path)
+		}
+		results = append(results, write.rule.Failed(description).At(write.source, write.line))
+	}
+	return results
+}
+
+// startCommandWrites returns the writes found by find in the last ENTRYPOINT and CMD of the final
+// stage and in the script they start.
+func startCommandWrites(ctx context.Context, find func(ctx context.Context, origin string, s string, source utils.Source, line Line) []runtimeWrite) []runtimeWrite {
+	var writes []runtimeWrite
+	entrypoints, cmds := finalStartCommands(ctx)
+	for _, commands := range [][]startCommand{entrypoints, cmds} {
+		if len(commands) == 0 {
+			continue
+		}
+		command := commands[len(commands)-1]
+		writes = append(writes, find(ctx, command.instruction, command.command, command.source, command.line)...)
+	}
+	// the script started by the container is the one of ENTRYPOINT, or of CMD without ENTRYPOINT
+	if commands := append(entrypoints, cmds...); len(commands) > 0 {
+		command := commands[len(commands)-1]
+		if len(entrypoints) > 0 {
+			command = entrypoints[len(entrypoints)-1]
+		}
+		if rel, content, ok := startScript(ctx, command); ok {
+			writes = append(writes, find(ctx, rel, string(content), command.source, command.line)...)
+		}
+	}
+	return writes
+}
+
+// isWritable reports whether the path is below /tmp, a device, a proc file or a volume.
+func isWritable(file string, volumes []string) bool {
+	for _, dir := range append(append([]string{}, writablePaths...), volumes...) {
+		if file == dir || strings.HasPrefix(file, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdioLink reports whether the log file is linked to the standard output or error.
+func isStdioLink(file string, links []string) bool {
+	for _, link := range links {
+		if file == link {
+			return true
+		}
+	}
+	return false
+}
+
+// mountPoint returns the directory to mount a volume on for the application to write to the
+// path: the directory of a file, the path itself otherwise.
+func mountPoint(file string) string {
+	if strings.Contains(path.Base(file), ".") {
+		return path.Dir(file)
+	}
+	return file
+}
@@ -0,0 +1,100 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfEnvWritesToTheImage(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch\nUSER 1001\nENV LOG_DIR=/var/log/app APP_PID_FILE=/run/app.pid TMPDIR=/tmp/app LOGIN_URL=/login\nENV JAVA_OPTS=-Djava.io.tmpdir=/app/tmp\n")
+	if logs := resultsOfRule(results, RuleRuntimeLogFile); len(logs) != 1 || !strings.Contains(logs[0].Description, "/var/log/app") {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleRuntimeLogFile.ID, logs)
+	}
+	if pids := resultsOfRule(results, RuleRuntimePidFile); len(pids) != 1 || pids[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleRuntimePidFile.ID, pids)
+	}
+	if temps := resultsOfRule(results, RuleRuntimeTempFile); len(temps) != 1 || temps[0].Line.Start != 4 {
+		t.Errorf("Expected a %s suggestion at line 4 but they were %v", RuleRuntimeTempFile.ID, temps)
+	}
+}
+
+func TestWritesToVolumesAreAllowed(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch\nUSER 1001\nVOLUME /var/log\nCMD [\"app\", \"--log-file=/var/log/app/app.log\", \"--pid-file=/dev/shm/app.pid\"]\n")
+	if len(resultsOfRule(results, RuleRuntimeLogFile)) != 0 || len(resultsOfRule(results, RuleRuntimePidFile)) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestFailIfStartCommandWritesToTheImage(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nCMD ./server >> /opt/app/server.log 2>&1\n"), RuleRuntimeLogFile)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleRuntimeLogFile.ID, results)
+	}
+}
+
+func TestFailIfCopiedConfigWritesToTheImage(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"nginx.conf": "pid /run/nginx.pid;\nerror_log /dev/stderr;\nhttp {\n  access_log /var/log/nginx/access.log;\n  client_body_temp_path /var/cache/nginx/body;\n}\n",
+		"start.sh":   "#!/bin/sh\necho $$ > /var/run/start.pid\nexec nginx\n",
+	})
+	results := analyzeInContext(dir, "FROM scratch\nCOPY nginx.conf /etc/nginx/nginx.conf\nCOPY start.sh /start.sh\nUSER 1001\nENTRYPOINT [\"/start.sh\"]\n")
+	if logs := resultsOfRule(results, RuleRuntimeLogFile); len(logs) != 1 || logs[0].Line.Start != 2 {
+		t.Errorf("Expected a %s suggestion at line 2 but they were %v", RuleRuntimeLogFile.ID, logs)
+	}
+	if pids := resultsOfRule(results, RuleRuntimePidFile); len(pids) != 2 || !strings.Contains(pids[1].Description, "start.sh") {
+		t.Errorf("Expected 2 %s suggestions but they were %v", RuleRuntimePidFile.ID, pids)
+	}
+	if temps := resultsOfRule(results, RuleRuntimeTempFile); len(temps) != 1 {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleRuntimeTempFile.ID, temps)
+	}
+}
+
+func TestWritesOfBuilderStagesAreIgnored(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch AS builder\nENV LOG_FILE=/var/log/build.log\nFROM scratch\nUSER 1001\n")
+	if len(resultsOfRule(results, RuleRuntimeLogFile)) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestFailIfLogAppenderWritesToTheImage(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"config/log4j.properties": "log4j.rootLogger=INFO, file\nlog4j.appender.file=org.apache.log4j.RollingFileAppender\nlog4j.appender.file.File=/opt/app/logs/app.log\n",
+		"config/log4j2.xml":       "<Configuration>\n  <Appenders>\n    <File name=\"audit\" fileName=\"/var/log/app/audit.log\"/>\n  </Appenders>\n</Configuration>\n",
+		"config/logback.xml":      "<configuration>\n  <appender name=\"FILE\" class=\"ch.qos.logback.core.FileAppender\">\n    <file>/var/log/app/app.log</file>\n  </appender>\n</configuration>\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY config /opt/app/config\nUSER 1001\n"), RuleRuntimeLogFile)
+	if len(results) != 3 || results[0].Line.Start != 2 {
+		t.Errorf("Expected 3 %s suggestions at line 2 but they were %v", RuleRuntimeLogFile.ID, results)
+	}
+}
+
+func TestLogFilesLinkedToStdoutAreAllowed(t *testing.T) {
+	results := analyzeContent(t, "FROM scratch\nRUN ln -sf /dev/stdout /var/log/nginx/access.log && ln -sf /dev/stderr /var/log/nginx/error.log\nUSER 1001\nCMD nginx -g 'daemon off;' 2>> /var/log/nginx/error.log\n")
+	if logs := resultsOfRule(results, RuleRuntimeLogFile); len(logs) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", logs)
+	}
+}
+
+func TestFailIfStartCommandTeesToVarLog(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\nCMD ./server 2>&1 | tee -a /var/log/server/output\n"), RuleRuntimeLogFile)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "/var/log/server/output") {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleRuntimeLogFile.ID, results)
+	}
+}
@@ -0,0 +1,757 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+// Rule describes a check performed by the analyzer. Every result reported by a check refers to
+// its rule through the rule ID.
+type Rule struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Severity    ResultSeverity   `json:"severity"`
+	Confidence  ResultConfidence `json:"confidence"`
+	Description string           `json:"description"`
+	Remediation string           `json:"remediation"`
+	References  []string         `json:"references,omitempty"`
+	// Instructions are the instructions checked by the rule, e.g. RUN
+	Instructions []string `json:"instructions,omitempty"`
+	// Group gathers the rules checking the same concern, e.g. GROUP_OC_NEW_APP
+	Group string `json:"group,omitempty"`
+	// Platforms restricts the rule to the platforms, it's checked on every platform when empty
+	Platforms []Platform `json:"platforms,omitempty"`
+	// Pack is the pack of the rule, e.g. PACK_JAVA, the rules without pack are always checked
+	Pack string `json:"pack,omitempty"`
+	// Advisory rules are informational, their failed results never fail the verdict
+	Advisory bool `json:"advisory,omitempty"`
+}
+
+// GROUP_OC_NEW_APP rules check the conventions oc new-app and the developer console rely on to
+// deploy an image
+const GROUP_OC_NEW_APP = "oc-new-app"
+
+// GROUP_READ_ONLY_ROOTFS rules check the writes of the application to the image at runtime, which
+// fail when the pod sets readOnlyRootFilesystem: true
+const GROUP_READ_ONLY_ROOTFS = "read-only-rootfs"
+
+// GROUP_PRIVILEGE rules check the hints that the workload needs privileges the restricted SCC
+// doesn't grant
+const GROUP_PRIVILEGE = "privilege"
+
+// GROUP_APPLICATION rules check the Containerfiles of the containers forming one application
+// against each other, see doa app
+const GROUP_APPLICATION = "application"
+
+// GROUP_BUILD_CONTEXT rules check the files sent to the builder with the build context and its
+// .containerignore or .dockerignore file
+const GROUP_BUILD_CONTEXT = "build-context"
+
+// GROUP_LAYERING rules are advisories about the layers of the image and the build cache, which
+// slow down the builds on the shared OpenShift build nodes. They don't fail the verdict.
+const GROUP_LAYERING = "layering"
+
+const (
+	REFERENCE_OPENSHIFT_GUIDELINES = "https://docs.openshift.com/container-platform/latest/openshift_images/create-images.html#images-create-guide-openshift_create-images"
+	REFERENCE_ADAPTING_CONTAINERS  = "https://developers.redhat.com/blog/2020/10/26/adapting-docker-and-kubernetes-containers-to-run-on-red-hat-openshift-container-platform"
+	REFERENCE_DOCKERFILE_SYNTAX    = "https://docs.docker.com/build/dockerfile/frontend/"
+	// REFERENCE_KUBERNETES_SECURITY_CONTEXT documents runAsNonRoot and readOnlyRootFilesystem
+	REFERENCE_KUBERNETES_SECURITY_CONTEXT = "https://kubernetes.io/docs/tasks/configure-pod-container/security-context/"
+	REFERENCE_SCC                         = "https://docs.openshift.com/container-platform/latest/authentication/managing-security-context-constraints.html"
+	REFERENCE_DOWNWARD_API                = "https://kubernetes.io/docs/concepts/workloads/pods/downward-api/"
+	REFERENCE_HEADLESS_SERVICES           = "https://kubernetes.io/docs/concepts/services-networking/service/#headless-services"
+)
+
+var (
+	RuleEmptyValue = Rule{
+		ID:           "empty-value",
+		Name:         "Wrong value",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "An instruction has an empty value.",
+		Remediation:  "Set a value or remove the instruction.",
+		Instructions: []string{"ARG", "EXPOSE", "RUN", "USER"},
+	}
+	RuleInvalidPort = Rule{
+		ID:           "invalid-port",
+		Name:         "Wrong port value",
+		Severity:     SeverityCritical,
+		Confidence:   ConfidenceHigh,
+		Description:  "The EXPOSE instruction contains a value which is not a port number.",
+		Remediation:  "Use a port number, optionally followed by the protocol (e.g. 8080/tcp).",
+		Instructions: []string{"EXPOSE"},
+	}
+	RulePrivilegedPort = Rule{
+		ID:           "privileged-port",
+		Name:         "Privileged port exposed",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "Ports 1-1023 are privileged ports that only the root user can bind. OpenShift runs containers with an arbitrarily assigned non-root user ID.",
+		Remediation:  "Configure the application to listen on a port greater than 1023 (e.g. 8080) and expose that port.",
+		Instructions: []string{"EXPOSE"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+	}
+	RuleBaseImageAnalysis = Rule{
+		ID:           "base-image-analysis",
+		Name:         "Analyze error",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceHigh,
+		Description:  "The base image referenced by FROM can't be retrieved, its instructions are not analyzed.",
+		Remediation:  "Make the base image available from the local Podman or Docker engine or from its registry.",
+		Instructions: []string{"FROM"},
+	}
+	RuleSudo = Rule{
+		ID:           "sudo-su",
+		Name:         "Use of sudo/su command",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "In OpenShift, containers are run using arbitrarily assigned user ID and elevating privileges with sudo or su could lead to an unexpected behavior.",
+		Remediation:  "Run the command without sudo/su and make the files it needs accessible to the root group.",
+		Instructions: []string{"RUN"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+	}
+	RuleChownGroup = Rule{
+		ID:           "chown-group",
+		Name:         "Owner set",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "In OpenShift the group ID must always be set to the root group (0), files owned by another group may not be accessible at runtime.",
+		Remediation:  "Set the group ownership to the root group, e.g. chown -R 1001:0 /app.",
+		Instructions: []string{"RUN", "COPY", "ADD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleChmodGroupPermission = Rule{
+		ID:           "chmod-group-permission",
+		Name:         "Permission set",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "In Openshift, directories and files need to be read/writable by the root group and files that must be executed should have group execute permissions.",
+		Remediation:  "Give the group the same permissions as the owner, e.g. chmod g=u /app or chmod 770 /app.",
+		Instructions: []string{"RUN"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleChmodSyntax = Rule{
+		ID:           "chmod-syntax",
+		Name:         "Syntax error",
+		Severity:     SeverityCritical,
+		Confidence:   ConfidenceMedium,
+		Description:  "The arguments of a chmod command can't be parsed.",
+		Remediation:  "Check the permissions passed to chmod, numeric permissions are made of 3 digits.",
+		Instructions: []string{"RUN"},
+	}
+	RuleUserRoot = Rule{
+		ID:           "user-root",
+		Name:         "User set to root",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "In OpenShift, containers are run using arbitrarily assigned user ID, running as root (explicitly or because no USER is set) could lead to unexpected results.",
+		Remediation:  "Set USER to a non-root numeric user ID, e.g. USER 1001.",
+		Instructions: []string{"USER"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+	}
+	RuleUserLowUID = Rule{
+		ID:           "user-low-uid",
+		Name:         "User set to a system UID",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceHigh,
+		Description:  "USER sets a numeric UID below 1000, which is reserved to system users. Under the restricted SCC, OpenShift ignores the UID of the image and runs the container with a UID assigned from the namespace range.",
+		Remediation:  "Set USER to a UID of 1000 or greater, e.g. USER 1001, and don't rely on it at runtime: make the files the application needs owned by the root group (0).",
+		Instructions: []string{"USER"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleUserNotCreated = Rule{
+		ID:           "user-not-created",
+		Name:         "User not created",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "USER refers to a user name which is neither created by the Containerfile nor known to be defined by the base image, the container fails to start with \"unable to find user\".",
+		Remediation:  "Set USER to a numeric UID, e.g. USER 1001, which doesn't need to be defined in /etc/passwd, or create the user with useradd before the USER instruction.",
+		Instructions: []string{"USER"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+	}
+	RuleRunAsNonRoot = Rule{
+		ID:           "run-as-non-root",
+		Name:         "User name incompatible with runAsNonRoot",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "USER sets a user name rather than a numeric UID. Kubernetes can't verify that a user name is not root, the pods setting runAsNonRoot: true fail to start with CreateContainerConfigError.",
+		Remediation:  "Set USER to the numeric UID of the user, e.g. USER 1001.",
+		Instructions: []string{"USER"},
+		References:   []string{REFERENCE_KUBERNETES_SECURITY_CONTEXT},
+		Platforms:    []Platform{PlatformKubernetes},
+	}
+	RuleReadOnlyRootFilesystem = Rule{
+		ID:           "writable-image-path",
+		Name:         "Writable image path",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The final stage gives the write permission on paths of the image, which the application is expected to write to at runtime. Pods hardened with readOnlyRootFilesystem: true can't write to the image.",
+		Remediation:  "Write to the paths mounted as volumes, e.g. an emptyDir mounted on the directory, or to /tmp.",
+		Instructions: []string{"RUN"},
+		References:   []string{REFERENCE_KUBERNETES_SECURITY_CONTEXT},
+		Platforms:    []Platform{PlatformKubernetes},
+		Group:        GROUP_READ_ONLY_ROOTFS,
+	}
+	RuleRuntimeLogFile = Rule{
+		ID:           "runtime-log-file",
+		Name:         "Log file written to the image",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The application writes its logs to a file of the image, e.g. under /var/log, as set by a copied configuration file (nginx, httpd, log4j or logback appenders, ...), an environment variable or the start command. OpenShift only aggregates the standard output and error of the containers, and the writes fail when the pod sets readOnlyRootFilesystem: true.",
+		Remediation:  "Log to the standard output and error, which the platform collects, e.g. with a console appender or by linking the log file to /dev/stdout (ln -sf /dev/stdout /var/log/app.log), or mount a volume (e.g. an emptyDir) on the log directory.",
+		Instructions: []string{"COPY", "ENV", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_KUBERNETES_SECURITY_CONTEXT},
+		Group:        GROUP_READ_ONLY_ROOTFS,
+	}
+	RuleRuntimePidFile = Rule{
+		ID:           "runtime-pid-file",
+		Name:         "PID file written to the image",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The application writes its PID file to the image, e.g. under /run, as set by a copied configuration file, an environment variable or the start command. The write fails when the pod sets readOnlyRootFilesystem: true.",
+		Remediation:  "Write the PID file to /tmp, don't write it at all when the application runs in the foreground, or mount a volume (e.g. an emptyDir) on its directory.",
+		Instructions: []string{"COPY", "ENV", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_KUBERNETES_SECURITY_CONTEXT},
+		Group:        GROUP_READ_ONLY_ROOTFS,
+	}
+	RuleRuntimeTempFile = Rule{
+		ID:           "runtime-temp-file",
+		Name:         "Temporary files outside /tmp",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The temporary directory of the application is set outside /tmp, e.g. by TMPDIR, java.io.tmpdir or the temp paths of nginx. The writes fail when the pod sets readOnlyRootFilesystem: true, where only the mounted volumes are writable.",
+		Remediation:  "Keep the temporary files in /tmp and mount an emptyDir on it, or mount a volume on the temporary directory.",
+		Instructions: []string{"COPY", "ENV", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_KUBERNETES_SECURITY_CONTEXT},
+		Group:        GROUP_READ_ONLY_ROOTFS,
+	}
+	RuleWebServerNonRoot = Rule{
+		ID:           "web-server-non-root",
+		Name:         "nginx/httpd not adapted to a non-root user",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The official nginx and httpd images and their default configurations run the server as root: they listen on port 80, write the PID and temporary files to directories owned by root and switch to the user of the user, User or Group directives. They fail with the arbitrary non-root UID of OpenShift.",
+		Remediation:  "Use the non-root variants (nginxinc/nginx-unprivileged, ubi9/nginx-122, ubi9/httpd-24) or adapt the configuration like them: listen on 8080, write the PID and temporary files to /tmp and remove the user directives.",
+		Instructions: []string{"FROM", "COPY"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+		Pack:         PACK_WEBSERVER,
+	}
+	RuleDatabaseDataDir = Rule{
+		ID:           "database-data-dir",
+		Name:         "Database data directory not prepared for arbitrary UIDs",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "The data directory of PostgreSQL, MySQL or MariaDB, set by PGDATA, MYSQL_DATADIR or declared as a VOLUME, is not owned by the root group and group writable, or the database is initialized at build time by a fixed UID. The database started by the arbitrary UID of OpenShift can't create or use its files.",
+		Remediation:  "Give the data directory, or its parent directory, to the root group and make it group writable (chgrp -R 0 /var/lib/pgsql/data && chmod -R g=u /var/lib/pgsql/data), and initialize the database when the container starts.",
+		Instructions: []string{"ENV", "VOLUME", "RUN"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+		Pack:         PACK_DATABASE,
+	}
+	RulePidFilePermission = Rule{
+		ID:           "pid-file-permission",
+		Name:         "PID or lock file in a root-only directory",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The application writes its PID file or a lock file under /run, /var/run or /var/lock, as set by a copied configuration file, an environment variable or the start command. These directories are only writable by root in the base images, the write fails for the non-root UID the container runs with unless a tmpfs or an emptyDir is mounted on them.",
+		Remediation:  "Write the PID and lock files to /tmp, declare the directory as a VOLUME and mount an emptyDir on it, or make it group writable (chgrp 0 /var/run/app && chmod g=u /var/run/app).",
+		Instructions: []string{"COPY", "ENV", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+	}
+	RuleInstallDirWrite = Rule{
+		ID:           "install-dir-write",
+		Name:         "Runtime writes to /opt or /usr/local",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The application writes to its installation directory under /opt or to /usr/local at runtime, as set by an environment variable, a copied configuration file or the start command, while the directory was never made group writable. The arbitrary UID OpenShift runs the container with, a member of the root group, gets \"permission denied\".",
+		Remediation:  "Make the directory owned by the root group and group writable (chgrp -R 0 /opt/app && chmod -R g=u /opt/app) or mount a volume on it.",
+		Instructions: []string{"ENV", "COPY", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleHostPath = Rule{
+		ID:           "host-path",
+		Name:         "Host path assumption",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "The container engine socket, the processes of the host, the cgroup file system or the kernel parameters are only available to privileged containers or to containers mounting host paths, which the default OpenShift policies don't allow.",
+		Remediation:  "Don't rely on the host: use the OpenShift APIs (e.g. builds or jobs) instead of the container engine socket and set resources and kernel parameters in the pod specification.",
+		Instructions: []string{"RUN", "ENTRYPOINT", "CMD", "ENV"},
+		References:   []string{REFERENCE_ADAPTING_CONTAINERS},
+		Group:        GROUP_PRIVILEGE,
+	}
+	RuleNetworkCapability = Rule{
+		ID:           "network-capability",
+		Name:         "Network capability required",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "Network tools like tcpdump, setuid ping, ip route or iptables require the NET_RAW or NET_ADMIN capabilities, which are dropped by the restricted SCC.",
+		Remediation:  "Don't manage the network from the container: configure it through OpenShift (services, network policies) or run the tool in a debug pod (oc debug) with the required SCC.",
+		Instructions: []string{"RUN", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_ADAPTING_CONTAINERS},
+		Group:        GROUP_PRIVILEGE,
+	}
+	RulePrivilegedWorkload = Rule{
+		ID:           "privileged-workload",
+		Name:         "Custom SCC probably required",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceLow,
+		Description:  "The final stage has several hints that the workload needs privileges: device access, kernel modules or parameters, mounts, namespaces, a container engine, host networking tools or an init system. Their weights make a privilege likelihood, reported from a threshold, so that platform teams can plan the custom SCC before the deployment fails.",
+		Remediation:  "Review whether the privileges are needed. If they are, request a custom SCC (e.g. privileged or hostaccess) for the service account of the workload, otherwise remove the commands needing them.",
+		Instructions: []string{"RUN", "ENTRYPOINT", "CMD", "ENV"},
+		References:   []string{REFERENCE_SCC, REFERENCE_ADAPTING_CONTAINERS},
+		Group:        GROUP_PRIVILEGE,
+	}
+	RuleSharedVolumeOwnership = Rule{
+		ID:           "shared-volume-ownership",
+		Name:         "Shared volume ownership",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "Containers of the application mounting the same volume expect it owned by different users, either set by chown or by their USER. Only one of them can write to it, and the fixed UIDs are replaced by an arbitrary UID on OpenShift.",
+		Remediation:  "Make the mount paths owned by the root group and group writable in every image (chgrp -R 0 <path> && chmod -R g=u <path>) and set the fsGroup of the pod.",
+		Instructions: []string{"VOLUME", "USER", "RUN", "COPY", "ADD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+		Group:        GROUP_APPLICATION,
+	}
+	RulePodPortConflict = Rule{
+		ID:           "pod-port-conflict",
+		Name:         "Port exposed by several containers of a pod",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "Containers of the same pod share its network namespace, only one of them can listen on a port exposed by several images.",
+		Remediation:  "Make the port of one of the containers configurable and change it, or run the containers in different pods.",
+		Instructions: []string{"EXPOSE"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+		Group:        GROUP_APPLICATION,
+	}
+	RuleHardcodedResources = Rule{
+		ID:           "hardcoded-resources",
+		Name:         "Hardcoded memory/CPU settings",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "Hardcoded heap sizes (-Xmx, -Xms, --max-old-space-size) or GOMAXPROCS ignore the limits of the container, which OpenShift enforces through cgroups: the process is killed when it exceeds the memory limit or wastes the resources it is given.",
+		Remediation:  "Use container-aware settings, e.g. -XX:MaxRAMPercentage=75.0 for Java, and let the runtime size itself from the limits of the container.",
+		Instructions: []string{"ENV", "ENTRYPOINT", "CMD"},
+	}
+	RuleRuntimeSystemConfig = Rule{
+		ID:           "runtime-system-config",
+		Name:         "Timezone/locale set at startup",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "Setting the timezone or generating locales when the container starts writes to system paths (e.g. /etc/localtime), which fails for the arbitrarily assigned user ID OpenShift runs containers with.",
+		Remediation:  "Configure the timezone and the locales at build time in a RUN instruction, or set the TZ environment variable.",
+		Instructions: []string{"ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+	}
+	RuleHostIdentity = Rule{
+		ID:           "host-identity",
+		Name:         "Reliance on the host identity",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "Licensing or clustering based on the container hostname, a fixed MAC address or entries added to /etc/hosts behaves differently on OpenShift: the hostname is the name of the pod and, like its MAC address, changes every time the pod is recreated, while /etc/hosts is managed for every pod.",
+		Remediation:  "Read the name of the pod from the downward API, give the members of a cluster stable network identities with a StatefulSet and a headless Service, and declare the host entries with hostAliases in the pod spec.",
+		Instructions: []string{"RUN", "ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_DOWNWARD_API, REFERENCE_HEADLESS_SERVICES},
+	}
+	RuleRuntimeTruststoreImport = Rule{
+		ID:           "runtime-truststore-import",
+		Name:         "Certificates imported at startup",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "Importing certificates when the container starts, with keytool into the cacerts of the JVM or with update-ca-trust into the system trust, writes to files owned by root, which fails for the arbitrarily assigned user ID OpenShift runs containers with.",
+		Remediation:  "Import the certificates at build time in a RUN instruction, or copy cacerts to a writable directory (e.g. an emptyDir), import them there and set -Djavax.net.ssl.trustStore to it.",
+		Instructions: []string{"ENTRYPOINT", "CMD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+	}
+	RuleUnpinnedPackages = Rule{
+		ID:           "unpinned-packages",
+		Name:         "Unpinned packages",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceHigh,
+		Description:  "Upgrading the packages of the base image (apt-get upgrade, dnf update) or installing packages without a version makes the build not reproducible: every build, e.g. every BuildConfig run on OpenShift, could produce a different image.",
+		Remediation:  "Pin the versions of the installed packages (e.g. apt-get install curl=7.88.1-10, dnf install nginx-1.20.1) and use an updated base image instead of upgrading its packages.",
+		Instructions: []string{"RUN"},
+	}
+	RuleGitCloneMutableRef = Rule{
+		ID:           "git-clone-mutable-ref",
+		Name:         "Git clone of a mutable reference",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "Cloning a branch, or the default branch, of a Git repository during the build makes it not reproducible, and credentials in the repository URL are stored in the image history.",
+		Remediation:  "Check out a pinned commit or tag, COPY vendored sources, or use an OpenShift Git source build with a source secret.",
+		Instructions: []string{"RUN"},
+	}
+	RuleBuildToolsInFinalStage = Rule{
+		ID:           "build-tools-final-stage",
+		Name:         "Build tools in the final stage",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "Compilers and build tools (gcc, make, maven, golang, node-gyp, ...) installed in the final stage, or a build image used as final stage, inflate the image and its attack surface.",
+		Remediation:  "Convert the Containerfile to a multi-stage build: build the application in a builder stage and copy the built artifacts only to the final stage, e.g. COPY --from=builder /app/bin /app.",
+		Instructions: []string{"FROM", "RUN"},
+	}
+	RuleCacheBustingCopy = Rule{
+		ID:           "cache-busting-copy",
+		Name:         "Sources copied before the dependencies are installed",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The whole build context is copied before the dependencies are installed (npm ci, pip install -r, mvn, go mod download, ...): every change to the sources invalidates the cache of the installation, which runs again on each build.",
+		Remediation:  "Copy the dependency manifests first, install the dependencies, then copy the sources, e.g. COPY package*.json ./, RUN npm ci, COPY . .",
+		Instructions: []string{"COPY", "ADD", "RUN"},
+		References:   []string{"https://docs.docker.com/build/cache/#order-your-layers"},
+		Group:        GROUP_LAYERING,
+		Advisory:     true,
+	}
+	RuleConsecutiveRuns = Rule{
+		ID:           "consecutive-runs",
+		Name:         "Consecutive RUN instructions",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceHigh,
+		Description:  "Several consecutive RUN instructions create a layer each, making the image bigger and slower to push and pull, and the files they remove are still stored by the previous layers.",
+		Remediation:  "Merge the consecutive RUN instructions with &&, or use a heredoc, unless they are deliberately split to be cached separately.",
+		Instructions: []string{"RUN"},
+		References:   []string{"https://docs.docker.com/build/building/best-practices/#run"},
+		Group:        GROUP_LAYERING,
+		Advisory:     true,
+	}
+	RuleDeletedLayerFile = Rule{
+		ID:           "deleted-layer-file",
+		Name:         "File removed in a later layer",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "A file added by COPY, ADD or downloaded by a RUN is removed by a later RUN: the layer adding it still stores it, so the removal doesn't make the image smaller.",
+		Remediation:  "Download, use and remove the file in the same RUN instruction, or use a multi-stage build or a RUN --mount to make it available without storing it.",
+		Instructions: []string{"COPY", "ADD", "RUN"},
+		References:   []string{"https://docs.docker.com/build/building/multi-stage/"},
+		Group:        GROUP_LAYERING,
+		Advisory:     true,
+	}
+	RuleSecretCopy = Rule{
+		ID:           "secret-copy",
+		Name:         "Secret copied into the image",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "Files usually holding credentials or private keys (id_rsa, *.pem, .npmrc, .netrc, credentials, .env, ...) copied from the build context are stored in the image layers, where anyone pulling the image can read them.",
+		Remediation:  "Use build secrets (RUN --mount=type=secret) or OpenShift build secrets to use credentials during the build, and mount OpenShift Secrets in the pod to use them at runtime.",
+		Instructions: []string{"COPY", "ADD"},
+		References:   []string{"https://docs.openshift.com/container-platform/latest/cicd/builds/creating-build-inputs.html#builds-input-secrets-configmaps_creating-build-inputs"},
+	}
+	RuleProxyCredentials = Rule{
+		ID:           "proxy-credentials",
+		Name:         "Proxy credentials in the image",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "Proxy URLs with credentials set by ENV, or proxy build arguments declared by ARG, in the final stage are stored in the image configuration or history, where anyone pulling the image can read them.",
+		Remediation:  "Don't declare the proxy variables in the final stage: the predefined proxy build arguments are not recorded in the image history, or use build secrets (RUN --mount=type=secret).",
+		Instructions: []string{"ENV", "ARG"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#predefined-args"},
+	}
+	RulePortMismatch = Rule{
+		ID:           "port-mismatch",
+		Name:         "Port not exposed",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The application listens on a port, set in a copied configuration file (nginx, httpd, Spring Boot, gunicorn), in the command line or in the PORT variable, which is not exposed. OpenShift creates the Service and the Route of the application (oc new-app) from the exposed ports.",
+		Remediation:  "Expose the port the application listens on with EXPOSE, or configure the application to listen on the exposed port.",
+		Instructions: []string{"COPY", "ENTRYPOINT", "CMD", "ENV"},
+		References:   []string{"https://docs.openshift.com/container-platform/latest/applications/creating_applications/creating-applications-using-cli.html"},
+	}
+	RuleNoExposedPort = Rule{
+		ID:           "no-exposed-port",
+		Name:         "No port exposed",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "The image runs a command but exposes no port, oc new-app and the developer console don't create any Service nor Route for it.",
+		Remediation:  "Expose the port the application listens on with EXPOSE, e.g. EXPOSE 8080.",
+		Instructions: []string{"ENTRYPOINT", "CMD"},
+		References:   []string{"https://docs.openshift.com/container-platform/latest/applications/creating_applications/creating-applications-using-cli.html"},
+		Group:        GROUP_OC_NEW_APP,
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleEntrypointCmdConflict = Rule{
+		ID:           "entrypoint-cmd-conflict",
+		Name:         "Conflicting ENTRYPOINT/CMD",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "The CMD of the final stage is ignored (shell form ENTRYPOINT, several ENTRYPOINT or CMD instructions) or passed to an exec form ENTRYPOINT as a shell command, so the container doesn't start the expected command.",
+		Remediation:  "Use the exec form for both ENTRYPOINT and CMD, CMD holding the default arguments of ENTRYPOINT, and keep one of each in the final stage.",
+		Instructions: []string{"ENTRYPOINT", "CMD"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#understand-how-cmd-and-entrypoint-interact"},
+		Group:        GROUP_OC_NEW_APP,
+	}
+	RuleEntrypointArguments = Rule{
+		ID:           "entrypoint-arguments",
+		Name:         "ENTRYPOINT without default arguments",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The ENTRYPOINT of the final stage, or the script it starts, uses the arguments of the container ($@, $1) but no CMD sets default ones. The console and oc new-app deploy the image without arguments, so the container fails and ends in CrashLoopBackOff.",
+		Remediation:  "Set the default arguments with CMD in the exec form, e.g. CMD [\"serve\"], or give the parameters a default value, e.g. ${1:-serve}.",
+		Instructions: []string{"ENTRYPOINT"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#understand-how-cmd-and-entrypoint-interact"},
+		Group:        GROUP_OC_NEW_APP,
+	}
+	RuleDuplicateInstruction = Rule{
+		ID:           "duplicate-instruction",
+		Name:         "Duplicate instruction",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceHigh,
+		Description:  "Only the last ENTRYPOINT, CMD and HEALTHCHECK of a stage are used and exposing a port twice has no effect: the other instructions are dead and mislead the readers of the Containerfile. The ENTRYPOINT and CMD instructions of the final stage are checked by entrypoint-cmd-conflict.",
+		Remediation:  "Remove the dead instructions, keeping the last ENTRYPOINT, CMD and HEALTHCHECK of each stage and one EXPOSE per port.",
+		Instructions: []string{"ENTRYPOINT", "CMD", "HEALTHCHECK", "EXPOSE"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#healthcheck"},
+	}
+	RuleOverwrittenEnv = Rule{
+		ID:           "overwritten-env",
+		Name:         "Overwritten environment variable",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "An ENV variable is set again to another value later in the stage, while no instruction in between uses it: the first value is never used and misleads the readers of the Containerfile.",
+		Remediation:  "Remove the first ENV, or set the variable once to the value the image needs.",
+		Instructions: []string{"ENV"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#env"},
+	}
+	RuleExposeServicesLabel = Rule{
+		ID:           "expose-services-label",
+		Name:         "Wrong io.openshift.expose-services label",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceHigh,
+		Description:  "The io.openshift.expose-services label, used to generate the Service of the image, must list exposed ports as <port>:<name>.",
+		Remediation:  "Set the label to the exposed ports with their service names, e.g. LABEL io.openshift.expose-services=\"8080:http\".",
+		Instructions: []string{"LABEL"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+		Group:        GROUP_OC_NEW_APP,
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleOwnershipBoundToUID = Rule{
+		ID:           "uid-bound-ownership",
+		Name:         "Ownership bound to the USER UID",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "Files are owned by the exact UID set by USER. Under the restricted SCC, OpenShift runs the container with a UID assigned from the namespace range, which doesn't own these files.",
+		Remediation:  "Give the ownership of the files to the root group and the same permissions as the owner, e.g. chown -R 1001:0 /app && chmod -R g=u /app.",
+		Instructions: []string{"RUN"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES, REFERENCE_ADAPTING_CONTAINERS},
+		Platforms:    []Platform{PlatformOpenShift},
+	}
+	RuleOwnershipFixAfterCopy = Rule{
+		ID:           "copy-ownership-fix",
+		Name:         "Ownership fixed after copy",
+		Severity:     SeverityLow,
+		Confidence:   ConfidenceMedium,
+		Description:  "Files copied by COPY or ADD, owned by root:root unless --chown is set, are changed by a RUN chown or chmod in the final stage. The files are duplicated in a new layer, which inflates the image.",
+		Remediation:  "Set the owner and the permissions when copying the files, e.g. COPY --from=builder --chown=1001:0 --chmod=775 /out /app.",
+		Instructions: []string{"RUN", "COPY", "ADD"},
+		References:   []string{REFERENCE_OPENSHIFT_GUIDELINES},
+	}
+	RuleSyntaxDirective = Rule{
+		ID:           "syntax-directive",
+		Name:         "Dockerfile syntax",
+		Severity:     SeverityMedium,
+		Confidence:   ConfidenceMedium,
+		Description:  "The # syntax directive pins an unpinned channel of the Dockerfile frontend (latest, labs), or features like heredocs or RUN --mount are used without a syntax supporting them. OpenShift builds could break or fail to parse the Containerfile.",
+		Remediation:  "Pin the Dockerfile frontend to a version supporting the features used, e.g. # syntax=docker/dockerfile:1.7.",
+		Instructions: []string{"RUN", "COPY", "ADD"},
+		References:   []string{REFERENCE_DOCKERFILE_SYNTAX},
+	}
+	RuleUnsupportedFlag = Rule{
+		ID:           "unsupported-flag",
+		Name:         "Unsupported instruction flag",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceMedium,
+		Description:  "An instruction flag or a RUN --mount option is not supported by the builder the Containerfile is analyzed for (--dialect docker or podman), e.g. the SELinux relabeling option Z with docker build. The build fails.",
+		Remediation:  "Remove the flag or the option, or analyze the Containerfile with the dialect of the builder actually used.",
+		Instructions: []string{"RUN", "COPY", "ADD", "FROM", "HEALTHCHECK"},
+		References:   []string{"https://github.com/containers/common/blob/main/docs/Containerfile.5.md"},
+	}
+	RuleUnknownInstruction = Rule{
+		ID:          "unknown-instruction",
+		Name:        "Unknown instruction",
+		Severity:    SeverityHigh,
+		Confidence:  ConfidenceHigh,
+		Description: "The instruction is not a Dockerfile instruction, usually a typo, e.g. COPYY or ENTYRPOINT. The build fails on it and the analyzer can't check it.",
+		Remediation: "Fix the name of the instruction, e.g. COPY or ENTRYPOINT.",
+		References:  []string{"https://docs.docker.com/engine/reference/builder/#overview"},
+	}
+	RuleContextSecret = Rule{
+		ID:          "context-secret",
+		Name:        "Secret in the build context",
+		Severity:    SeverityHigh,
+		Confidence:  ConfidenceMedium,
+		Description: "A file which could contain credentials is in the build context and not excluded by .containerignore or .dockerignore. The whole build context is sent to the builder, e.g. by oc start-build --from-dir, and copied into the image by COPY . /app.",
+		Remediation: "Exclude the file in .containerignore or .dockerignore, e.g. add .env and *.pem.",
+		References:  []string{"https://docs.docker.com/build/building/context/#dockerignore-files"},
+		Group:       GROUP_BUILD_CONTEXT,
+	}
+	RuleContextDirectory = Rule{
+		ID:          "context-directory",
+		Name:        "Large directory in the build context",
+		Severity:    SeverityLow,
+		Confidence:  ConfidenceMedium,
+		Description: "A large directory not needed by the build (.git, node_modules, .venv) is in the build context and not excluded by .containerignore or .dockerignore. It slows down the upload of the build context and could be copied into the image.",
+		Remediation: "Exclude the directory in .containerignore or .dockerignore, e.g. add .git and node_modules.",
+		References:  []string{"https://docs.docker.com/build/building/context/#dockerignore-files"},
+		Group:       GROUP_BUILD_CONTEXT,
+	}
+	RuleCopyIgnoredSource = Rule{
+		ID:           "copy-ignored-source",
+		Name:         "Copied file excluded from the build context",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "COPY or ADD copies files which are excluded by .containerignore or .dockerignore. They are not sent to the builder and the build fails.",
+		Remediation:  "Remove the pattern excluding the files from the ignore file, add an exception (!path) or stop copying them.",
+		Instructions: []string{"COPY", "ADD"},
+		References:   []string{"https://docs.docker.com/build/building/context/#dockerignore-files"},
+		Group:        GROUP_BUILD_CONTEXT,
+	}
+	RuleCopyMissingSource = Rule{
+		ID:           "copy-missing-source",
+		Name:         "Copied file not found",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "COPY or ADD copies a file, or a wildcard pattern matching no file, which is not in the build context. The build fails with a file not found error, e.g. in the OpenShift build pod.",
+		Remediation:  "Fix the path of the source, relative to the build context, or create the file before the build.",
+		Instructions: []string{"COPY", "ADD"},
+		References:   []string{"https://docs.docker.com/engine/reference/builder/#copy"},
+		Group:        GROUP_BUILD_CONTEXT,
+	}
+	RuleCopiedSecret = Rule{
+		ID:           "copied-secret",
+		Name:         "Credentials in a copied file",
+		Severity:     SeverityHigh,
+		Confidence:   ConfidenceHigh,
+		Description:  "A file copied from the build context contains credentials, e.g. a private key, a cloud access key, a token or a random looking string, which are stored in the image layers. The result is located in the copied file rather than in the Containerfile.",
+		Remediation:  "Remove the credentials from the file and pass them at runtime with an OpenShift Secret, or exclude the file from the build context.",
+		Instructions: []string{"COPY", "ADD"},
+		References:   []string{"https://docs.openshift.com/container-platform/latest/nodes/pods/nodes-pods-secrets.html"},
+		Group:        GROUP_BUILD_CONTEXT,
+	}
+	RuleParseError = Rule{
+		ID:          "parse-error",
+		Name:        "Parse error",
+		Severity:    SeverityCritical,
+		Confidence:  ConfidenceHigh,
+		Description: "An instruction can't be parsed, the build fails. The other instructions are still analyzed.",
+		Remediation: "Fix the syntax of the instruction, see the Containerfile reference.",
+	}
+	RulePolicyOverride = Rule{
+		ID:          "policy-override",
+		Name:        "Policy override",
+		Severity:    SeverityHigh,
+		Confidence:  ConfidenceHigh,
+		Description: "The configuration of the project weakens a setting locked by the organization policy, see --policy-lock. The setting is ignored.",
+		Remediation: "Remove the setting from the configuration of the project or ask the owners of the policy to unlock it.",
+	}
+)
+
+// Rules is the catalog of all the rules known by the analyzer.
+var Rules = []Rule{
+	RuleEmptyValue,
+	RuleInvalidPort,
+	RulePrivilegedPort,
+	RuleBaseImageAnalysis,
+	RuleSudo,
+	RuleChownGroup,
+	RuleChmodGroupPermission,
+	RuleChmodSyntax,
+	RuleUserRoot,
+	RuleUserLowUID,
+	RuleUserNotCreated,
+	RuleRunAsNonRoot,
+	RuleReadOnlyRootFilesystem,
+	RuleRuntimeLogFile,
+	RuleRuntimePidFile,
+	RuleRuntimeTempFile,
+	RuleOwnershipBoundToUID,
+	RuleWebServerNonRoot,
+	RuleDatabaseDataDir,
+	RulePidFilePermission,
+	RuleInstallDirWrite,
+	RuleHostPath,
+	RuleNetworkCapability,
+	RulePrivilegedWorkload,
+	RuleSharedVolumeOwnership,
+	RulePodPortConflict,
+	RuleHardcodedResources,
+	RuleRuntimeSystemConfig,
+	RuleHostIdentity,
+	RuleRuntimeTruststoreImport,
+	RuleUnpinnedPackages,
+	RuleGitCloneMutableRef,
+	RuleBuildToolsInFinalStage,
+	RuleCacheBustingCopy,
+	RuleConsecutiveRuns,
+	RuleDeletedLayerFile,
+	RuleSecretCopy,
+	RuleProxyCredentials,
+	RulePortMismatch,
+	RuleNoExposedPort,
+	RuleEntrypointCmdConflict,
+	RuleEntrypointArguments,
+	RuleDuplicateInstruction,
+	RuleOverwrittenEnv,
+	RuleExposeServicesLabel,
+	RuleOwnershipFixAfterCopy,
+	RuleSyntaxDirective,
+	RuleUnsupportedFlag,
+	RuleUnknownInstruction,
+	RuleContextSecret,
+	RuleContextDirectory,
+	RuleCopyIgnoredSource,
+	RuleCopyMissingSource,
+	RuleCopiedSecret,
+	RuleParseError,
+	RulePolicyOverride,
+}
+
+func FindRule(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// IsAdvisory reports whether the result comes from an advisory rule, see Rule.Advisory.
+func IsAdvisory(result Result) bool {
+	rule, ok := FindRule(result.RuleID)
+	return ok && rule.Advisory
+}
+
+// Passed returns a result reporting that the check of the rule passed, see WithPassedResults.
+func (r Rule) Passed(description string) Result {
+	return Result{
+		RuleID:      r.ID,
+		Name:        r.Name,
+		Status:      StatusPass,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Description: description,
+	}
+}
+
+// Failed creates a failed result of the rule.
+func (r Rule) Failed(description string) Result {
+	return Result{
+		RuleID:      r.ID,
+		Name:        r.Name,
+		Status:      StatusFailed,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Description: description,
+	}
+}
@@ -0,0 +1,40 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import "testing"
+
+func TestRulesHaveUniqueIDs(t *testing.T) {
+	ids := map[string]bool{}
+	for _, rule := range Rules {
+		if rule.ID == "" || rule.Name == "" || rule.Severity == "" {
+			t.Errorf("Rule %+v is missing its ID, name or severity", rule)
+		}
+		if ids[rule.ID] {
+			t.Errorf("Rule ID %s is used more than once", rule.ID)
+		}
+		ids[rule.ID] = true
+	}
+}
+
+func TestResultsReferToTheirRule(t *testing.T) {
+	suggestions := verifyParsingCommand(t, "chown -R node:node /app", 1)
+	if suggestions[0].RuleID != RuleChownGroup.ID {
+		t.Errorf("Expected rule %s but it was %s", RuleChownGroup.ID, suggestions[0].RuleID)
+	}
+}
@@ -19,11 +19,11 @@
 
 import (
 	"context"
-	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
 	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
 )
 
@@ -33,57 +33,131 @@ type runResultKeyType struct{}
 
 var runResultKey runResultKeyType
 
+var sudoRegexp = regexp.MustCompile(`(\s+|^)(sudo|su)\s+`)
+
+var chownGroupRegexp = regexp.MustCompile(`(\$*\w+)*:(\$*\w+)`)
+
+var chmodRegexp = regexp.MustCompile(`chmod\s+(\d+)\s+(.*)`)
+
+var userCreationRegexp = regexp.MustCompile(`(?:^|[\s;|(])(?:useradd|adduser)\s+([^;&|)]+)`)
+
 func (r Run) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
 
-	// let's split the run command by &&. E.g chmod 070 /app && chmod 070 /app/routes && chmod 070 /app/bin
-	splittedCommands := strings.Split(node.Value, "&&")
+	// the script is split into its commands once. E.g chmod 070 /app && chmod 070 /app/routes && chmod 070 /app/bin
+	script := tokenize(node.Value)
 	var results []Result
-	for _, command := range splittedCommands {
+	for _, command := range script.commands {
 		if r.isChmodCommand(command) {
-			result := r.analyzeChmodCommand(command, source, line)
+			result := r.analyzeChmodCommand(ctx, command, source, line)
 			if result != nil {
-				results = append(results, *result)
+				results = append(results, result.At(source, line))
 			}
 		} else if r.isChownCommand(command) {
-			result := r.analyzeChownCommand(command, source, line)
+			result := r.analyzeChownCommand(ctx, command, source, line)
+			if result != nil {
+				results = append(results, result.At(source, line))
+			}
+			result = r.analyzeChownUIDCommand(ctx, command, source, line)
 			if result != nil {
-				results = append(results, *result)
+				results = append(results, result.At(source, line))
 			}
 		} else if r.isSudoOrSuCommand(command) {
-			result := r.analyzeSudoAndSuCommand(command, source, line)
+			result := r.analyzeSudoAndSuCommand(ctx, command, source, line)
 			if result != nil {
-				results = append(results, *result)
+				results = append(results, result.At(source, line))
 			}
 		}
 	}
-	return context.WithValue(ctx, runResultKey, results)
+	results = append(results, profileRule(ctx, RuleHostPath, func() []Result {
+		return analyzeHostPaths(ctx, "RUN", node.Value, source, line)
+	})...)
+	results = append(results, profileRule(ctx, RuleNetworkCapability, func() []Result {
+		return analyzeNetworkCapabilities(ctx, "RUN", node.Value, source, line)
+	})...)
+	results = append(results, profileRule(ctx, RuleHostIdentity, func() []Result {
+		return analyzeHostIdentity(ctx, "RUN", node.Value, source, line)
+	})...)
+	installs := script.mentions(packageManagers...)
+	if installs {
+		results = append(results, profileRule(ctx, RuleUnpinnedPackages, func() []Result {
+			return append(analyzePackageUpgrade(ctx, node.Value, source, line), analyzeUnpinnedPackages(ctx, node.Value, source, line)...)
+		})...)
+	}
+	if script.mentions("git") {
+		results = append(results, profileRule(ctx, RuleGitCloneMutableRef, func() []Result {
+			return analyzeGitClone(ctx, node.Value, source, line)
+		})...)
+	}
+	if source.Type != utils.Parent && script.mentions("chown", "chgrp", "chmod") {
+		ctx = appendFinalStageResults(ctx, analyzeOwnershipFix(ctx, script.commands, source, line)...)
+	}
+	if source.Type != utils.Parent && platformOf(ctx) == PlatformKubernetes && script.mentions("chmod") {
+		ctx = appendFinalStageResults(ctx, profileRule(ctx, RuleReadOnlyRootFilesystem, func() []Result {
+			return analyzeWritablePaths(ctx, script.commands, source, line)
+		})...)
+	}
+	if source.Type != utils.Parent && (installs || script.mentions("npm")) {
+		ctx = appendFinalStageResults(ctx, profileRule(ctx, RuleBuildToolsInFinalStage, func() []Result {
+			return analyzeBuildTools(ctx, node.Value, source, line)
+		})...)
+	}
+	if script.mentions("chmod") {
+		ctx = withGroupWritablePaths(ctx, script.commands)
+	}
+	if script.mentions("chown", "chgrp") {
+		ctx = withRootGroupPaths(ctx, script.commands)
+	}
+	if source.Type != utils.Parent && script.mentions("initdb", "pg_ctl", "mysql_install_db", "mariadb-install-db", "mysqld") {
+		ctx = appendFinalStageResults(ctx, profileRule(ctx, RuleDatabaseDataDir, func() []Result {
+			return analyzeInitdb(ctx, node.Value, source, line)
+		})...)
+	}
+	if script.mentions("ln") {
+		ctx = withStdioLinks(ctx, node.Value)
+	}
+	ctx = withPrivilegeSignals(ctx, node.Value, source, line)
+	ctx = withCreatedUsers(ctx, createdUsers(node.Value))
+	return appendResults(ctx, runResultKey, results...)
+}
+
+// createdUsers returns the users created with useradd or adduser, the user name being the last
+// argument of the command, e.g.
+//
+//	useradd -u 1001 -g 0 -m appuser
+//	adduser --disabled-password --gecos "" appuser
+//	adduser -D -u 1001 appuser
+func createdUsers(s string) []string {
+	var users []string
+	for _, match := range userCreationRegexp.FindAllStringSubmatch(s, -1) {
+		fields := strings.Fields(match[1])
+		if len(fields) == 0 {
+			continue
+		}
+		user := strings.Trim(fields[len(fields)-1], `"'`)
+		if user != "" && !strings.HasPrefix(user, "-") {
+			users = append(users, user)
+		}
+	}
+	return users
 }
 
 func (r Run) PostProcess(ctx context.Context) []Result {
-	result := ctx.Value(runResultKey)
-	if result == nil {
-		return nil
-	}
-	return result.([]Result)
+	return append(storedResults(ctx, runResultKey), profileRule(ctx, RulePrivilegedWorkload, func() []Result {
+		return analyzePrivilegeLikelihood(ctx)
+	})...)
 }
 
 func (r Run) isSudoOrSuCommand(s string) bool {
 	return IsCommand(s, "sudo") || IsCommand(s, "su")
 }
 
-func (r Run) analyzeSudoAndSuCommand(s string, source utils.Source, line Line) *Result {
-	re := regexp.MustCompile(`(\s+|^)(sudo|su)\s+`)
-
-	match := re.FindStringSubmatch(s)
+func (r Run) analyzeSudoAndSuCommand(ctx context.Context, s string, source utils.Source, line Line) *Result {
+	match := sudoRegexp.FindStringSubmatch(s)
 	if len(match) > 0 {
-		return &Result{
-			Name:     "Use of sudo/su command",
-			Status:   StatusFailed,
-			Severity: SeverityMedium,
-			Description: fmt.Sprintf(`sudo/su command used in '%s' %s could cause an unexpected behavior. 
+		result := RuleSudo.Failed(i18n.Sprintf(ctx, `sudo/su command used in '%s' %s could cause an unexpected behavior. 
 		In OpenShift, containers are run using arbitrarily assigned user ID and elevating privileges could lead 
-		to an unexpected behavior`, s, GenerateErrorLocation(source, line)),
-		}
+		to an unexpected behavior`, s, GenerateErrorLocation(ctx, source, line)))
+		return &result
 	}
 	return nil
 }
@@ -103,23 +177,68 @@ chown -R 1000:1000 /app
 chown 1001 /deployments/run-java.sh
 chown -h 501:20 './AirRun Updates'
 */
-func (r Run) analyzeChownCommand(s string, source utils.Source, line Line) *Result {
-	re := regexp.MustCompile(`(\$*\w+)*:(\$*\w+)`)
-
-	match := re.FindStringSubmatch(s)
+func (r Run) analyzeChownCommand(ctx context.Context, s string, source utils.Source, line Line) *Result {
+	match := chownGroupRegexp.FindStringSubmatch(s)
 	if len(match) == 0 {
 		return nil // errors.New("unable to find any group set by the chown command")
 	}
 	group := match[len(match)-1]
 	if strings.ToLower(group) != "root" && group != "0" {
-		return &Result{
-			Name:     "Owner set",
-			Status:   StatusFailed,
-			Severity: SeverityMedium,
-			Description: fmt.Sprintf(`owner set on %s %s could cause an unexpected behavior. 
-			In OpenShift the group ID must always be set to the root group (0)`, s, GenerateErrorLocation(source, line)),
+		result := RuleChownGroup.Failed(i18n.Sprintf(ctx, `owner set on %s %s could cause an unexpected behavior. 
+			In OpenShift the group ID must always be set to the root group (0)`, s, GenerateErrorLocation(ctx, source, line)))
+		if strings.HasPrefix(group, "$") {
+			// the group is a variable which could resolve to the root group at build time
+			result.Confidence = ConfidenceLow
+		}
+		return &result
+	}
+	if reportsPassed(ctx) {
+		result := RuleChownGroup.Passed(i18n.Sprintf(ctx, `owner set on %s %s belongs to the root group (0)`, s, GenerateErrorLocation(ctx, source, line)))
+		return &result
+	}
+	return nil
+}
+
+// chownOwner returns the user and the group, if any, set by a chown command.
+func chownOwner(s string) (string, string) {
+	fields := strings.Fields(s)
+	for i, field := range fields {
+		if field != "chown" {
+			continue
+		}
+		for _, arg := range fields[i+1:] {
+			if strings.HasPrefix(arg, "--") && strings.Contains(arg, "=") && !strings.HasPrefix(arg, "--from=") && !strings.HasPrefix(arg, "--reference=") {
+				// --recursive=owner:group
+				arg = arg[strings.Index(arg, "=")+1:]
+			} else if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			owner := strings.SplitN(arg, ":", 2)
+			if len(owner) == 1 {
+				return owner[0], ""
+			}
+			return owner[0], owner[1]
 		}
 	}
+	return "", ""
+}
+
+/*
+USER 1001
+RUN chown 1001 /app
+*/
+func (r Run) analyzeChownUIDCommand(ctx context.Context, s string, source utils.Source, line Line) *Result {
+	uid, _ := ctx.Value(userUIDKey).(string)
+	if uid == "" {
+		return nil
+	}
+	owner, group := chownOwner(s)
+	// a group is checked by analyzeChownCommand
+	if owner == uid && group == "" {
+		result := RuleOwnershipBoundToUID.Failed(i18n.Sprintf(ctx, `owner set on %s %s is bound to the UID %s set by USER. 
+			OpenShift runs the container with a UID assigned from the namespace range, set the group to the root group (0) as well`, s, GenerateErrorLocation(ctx, source, line), uid))
+		return &result
+	}
 	return nil
 }
 
@@ -127,43 +246,30 @@ func (r Run) isChmodCommand(s string) bool {
 	return IsCommand(s, "chmod")
 }
 
-func (r Run) analyzeChmodCommand(s string, source utils.Source, line Line) *Result {
-	re := regexp.MustCompile(`chmod\s+(\d+)\s+(.*)`)
-	match := re.FindStringSubmatch(s)
+func (r Run) analyzeChmodCommand(ctx context.Context, s string, source utils.Source, line Line) *Result {
+	match := chmodRegexp.FindStringSubmatch(s)
 	if len(match) == 0 {
 		return nil
 	}
 	if len(match) != 3 {
-		return &Result{
-			Name:        "Syntax error",
-			Status:      StatusFailed,
-			Severity:    SeverityCritical,
-			Description: fmt.Sprintf("unable to fetch args of chmod command %s. Is it correct?", GenerateErrorLocation(source, line)),
-		}
+		result := RuleChmodSyntax.Failed(i18n.Sprintf(ctx, "unable to fetch args of chmod command %s. Is it correct?", GenerateErrorLocation(ctx, source, line)))
+		return &result
 	}
 	permission := match[1]
 	if len(permission) != 3 {
-		return &Result{
-			Name:        "Syntax error",
-			Status:      StatusFailed,
-			Severity:    SeverityCritical,
-			Description: fmt.Sprintf("unable to fetch args of chmod command %s. Is it correct?", GenerateErrorLocation(source, line)),
-		}
+		result := RuleChmodSyntax.Failed(i18n.Sprintf(ctx, "unable to fetch args of chmod command %s. Is it correct?", GenerateErrorLocation(ctx, source, line)))
+		return &result
 	}
 	groupPermission := permission[1:2]
 	if groupPermission != "7" {
-		proposal := fmt.Sprintf("Is it an executable file? Try updating permissions to %s7%s", permission[0:1], permission[2:3])
+		proposal := i18n.Sprintf(ctx, "Is it an executable file? Try updating permissions to %s7%s", permission[0:1], permission[2:3])
 		if groupPermission != "6" {
-			proposal += fmt.Sprintf(" otherwise set it to %s6%s", permission[0:1], permission[2:3])
-		}
-		return &Result{
-			Name:     "Permission set",
-			Status:   StatusFailed,
-			Severity: SeverityMedium,
-			Description: fmt.Sprintf("permission set on %s %s could cause an unexpected behavior. %s\n"+
-				"Explanation - in Openshift, directories and files need to be read/writable by the root group and "+
-				"files that must be executed should have group execute permissions", s, GenerateErrorLocation(source, line), proposal),
+			proposal += i18n.Sprintf(ctx, " otherwise set it to %s6%s", permission[0:1], permission[2:3])
 		}
+		result := RuleChmodGroupPermission.Failed(i18n.Sprintf(ctx, "permission set on %s %s could cause an unexpected behavior. %s\n"+
+			"Explanation - in Openshift, directories and files need to be read/writable by the root group and "+
+			"files that must be executed should have group execute permissions", s, GenerateErrorLocation(ctx, source, line), proposal))
+		return &result
 	}
 
 	return nil
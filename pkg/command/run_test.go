@@ -127,3 +127,63 @@ func verifyParsingCommand(t *testing.T, cmd string, numberExpectedErrors int) []
 	}
 	return suggestions
 }
+
+func TestLowConfidenceIfChownCommandWithVariableGroup(t *testing.T) {
+	suggestions := verifyParsingCommand(t, "chown -R $APP_USER:$APP_GROUP /app", 1)
+	if suggestions[0].Confidence != ConfidenceLow {
+		t.Errorf("Expected the confidence to be low but it was %s", suggestions[0].Confidence)
+	}
+}
+
+func TestHighConfidenceIfChownCommandWithUserAndNonRootGroup(t *testing.T) {
+	suggestions := verifyParsingCommand(t, "chown -R node:node /app", 1)
+	if suggestions[0].Confidence != ConfidenceHigh {
+		t.Errorf("Expected the confidence to be high but it was %s", suggestions[0].Confidence)
+	}
+}
+
+func TestChownWithRootGroupReportsPassedCheck(t *testing.T) {
+	result := Run{}.analyzeChownCommand(WithPassedResults(context.Background()), "chown -R 1001:0 /app", utils.Source{Name: "test", Type: utils.Image}, Line{Start: 1, End: 1})
+	if result == nil || result.Status != StatusPass || result.RuleID != RuleChownGroup.ID {
+		t.Errorf("Expected a passed %s result but it was %v", RuleChownGroup.ID, result)
+	}
+	if result := (Run{}).analyzeChownCommand(context.Background(), "chown -R 1001:0 /app", utils.Source{Name: "test", Type: utils.Image}, Line{Start: 1, End: 1}); result != nil {
+		t.Errorf("Expected no result unless passed checks are reported but it was %v", result)
+	}
+}
+
+// runCorpus are RUN commands of common Containerfiles
+var runCorpus = []string{
+	"apt-get update && apt-get install -y --no-install-recommends curl ca-certificates && rm -rf /var/lib/apt/lists/*",
+	"dnf install -y nginx-1.20.1 python3-pip && dnf clean all",
+	"useradd -u 1001 -g 0 -m appuser && chown -R 1001:0 /app && chmod -R 775 /app",
+	"git clone --branch v1.2.0 --depth 1 https://github.com/org/app.git /src && cd /src && make install",
+	"npm ci && npm run build && npm cache clean --force",
+	"mkdir -p /var/cache/nginx && chmod 770 /var/cache/nginx && chown nginx:root /var/cache/nginx",
+	"ln -snf /usr/share/zoneinfo/Europe/Rome /etc/localtime && echo Europe/Rome > /etc/timezone",
+	"pip install --no-cache-dir -r requirements.txt",
+}
+
+func BenchmarkRunAnalyze(b *testing.B) {
+	run := Run{}
+	source := utils.Source{Name: "test", Type: utils.Image}
+	for i := 0; i < b.N; i++ {
+		ctx := context.Background()
+		for j, cmd := range runCorpus {
+			ctx = run.Analyze(ctx, &parser.Node{Value: cmd}, source, Line{Start: j + 1, End: j + 1})
+		}
+		run.PostProcess(ctx)
+	}
+}
+
+func FuzzRunChmodChown(f *testing.F) {
+	for _, cmd := range runCorpus {
+		f.Add(cmd)
+	}
+	f.Add("chown --recursive=$USER:$GROUP /app && chmod 0 /app && sudo -u root chown :")
+	f.Fuzz(func(t *testing.T, cmd string) {
+		ctx := context.WithValue(context.Background(), userUIDKey, "1001")
+		Run{}.Analyze(ctx, &parser.Node{Value: cmd}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 1, End: 1})
+		chownOwner(cmd)
+	})
+}
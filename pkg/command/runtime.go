@@ -0,0 +1,280 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// Runtime is the language runtime of the application of an image.
+type Runtime string
+
+const (
+	RuntimeJava   Runtime = "java"
+	RuntimeNodeJS Runtime = "nodejs"
+	RuntimePython Runtime = "python"
+	RuntimeGo     Runtime = "go"
+)
+
+// Runtimes lists the runtimes which can be detected, the first ones winning the ties.
+var Runtimes = []Runtime{RuntimeJava, RuntimeNodeJS, RuntimePython, RuntimeGo}
+
+// RuntimeStack is the runtime detected from a Containerfile along with the hints it was detected
+// from. Runtime is empty when no hint was found.
+type RuntimeStack struct {
+	Runtime  Runtime  `json:"runtime,omitempty"`
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// runtimePacks are the packs activated by the runtimes
+var runtimePacks = map[Runtime]string{
+	RuntimeJava:   PACK_JAVA,
+	RuntimeNodeJS: PACK_NODEJS,
+	RuntimePython: PACK_PYTHON,
+	RuntimeGo:     PACK_GOLANG,
+}
+
+// the weights of the hints: the command tells what really runs, whatever the base image is, and
+// the packages may only be needed by the build
+const (
+	commandWeight = 3
+	imageWeight   = 2
+	packageWeight = 1
+)
+
+var (
+	installRegexp = regexp.MustCompile(`\b(?:dnf|microdnf|yum|apt-get|apt|apk)\s+(?:-\S+\s+)*(?:install|add)\s+([^;&|]+)`)
+	// runtimePackages match the packages installing the runtimes, in the RHEL, Debian and Alpine
+	// repositories
+	runtimePackages = map[Runtime]*regexp.Regexp{
+		RuntimeJava:   regexp.MustCompile(`^(?:java-[\d.]+-openjdk(?:-headless|-devel)?|openjdk-?\d*(?:-jre|-jdk)?(?:-headless)?|temurin-\d+-(?:jre|jdk)|default-jre(?:-headless)?|default-jdk)$`),
+		RuntimeNodeJS: regexp.MustCompile(`^(?:nodejs(?:\d+)?|npm)(?:-[\d.:]+)?$`),
+		RuntimePython: regexp.MustCompile(`^(?:python3?(?:\.\d+)?|python3\d*-pip|py3-pip)$`),
+		RuntimeGo:     regexp.MustCompile(`^(?:golang|go-toolset|go)$`),
+	}
+	// runtimeCommands match the binaries started by CMD or ENTRYPOINT
+	runtimeCommands = map[Runtime]*regexp.Regexp{
+		RuntimeJava:   regexp.MustCompile(`^(?:java|mvnw?|gradlew?|catalina\.sh|standalone\.sh|run-java\.sh)$`),
+		RuntimeNodeJS: regexp.MustCompile(`^(?:node|nodejs|npm|npx|yarn|pnpm|pm2(?:-runtime)?)$`),
+		RuntimePython: regexp.MustCompile(`^(?:python[\d.]*|gunicorn|uvicorn|hypercorn|flask|celery|django-admin)$`),
+	}
+	envAssignmentRegexp = regexp.MustCompile(`^\w+=`)
+)
+
+// runtimeRemediations are the remediations of the rules tailored to the runtimes, see
+// Rule.RemediationFor
+var runtimeRemediations = map[Runtime]map[string]string{
+	RuntimeJava: {
+		RuleUserRoot.ID:           "Use a UBI OpenJDK runtime image, e.g. registry.access.redhat.com/ubi9/openjdk-21-runtime, which runs as the non-root user 185 of the root group, or set USER 1001 after making the /deployments directory writable by the root group.",
+		RulePrivilegedPort.ID:     "Make the application listen on 8080, e.g. with server.port=8080 for Spring Boot or quarkus.http.port=8080 for Quarkus, and expose that port.",
+		RuleHardcodedResources.ID: "Replace -Xmx and -Xms by -XX:MaxRAMPercentage=75.0, which sizes the heap from the memory limit of the container.",
+		RuleRuntimeTempFile.ID:    "Keep java.io.tmpdir to /tmp and mount an emptyDir on it.",
+	},
+	RuntimeNodeJS: {
+		RuleUserRoot.ID:           "Use a UBI Node.js image, e.g. registry.access.redhat.com/ubi9/nodejs-20, and set USER 1001, the user of the image which belongs to the root group.",
+		RulePrivilegedPort.ID:     "Make the application read its port from the PORT environment variable, set it to 8080 and expose that port.",
+		RuleHardcodedResources.ID: "Remove --max-old-space-size: Node.js 20 and later size the heap from the memory limit of the container.",
+	},
+	RuntimePython: {
+		RuleUserRoot.ID:       "Use a UBI Python image, e.g. registry.access.redhat.com/ubi9/python-312, and set USER 1001, the user of the image which belongs to the root group.",
+		RulePrivilegedPort.ID: "Bind the server to an unprivileged port, e.g. gunicorn --bind 0.0.0.0:8080, and expose that port.",
+	},
+	RuntimeGo: {
+		RuleUserRoot.ID:           "Copy the binary to a ubi-minimal or scratch final stage and set USER 1001: a Go binary doesn't need any user to exist in the image.",
+		RulePrivilegedPort.ID:     "Listen on an unprivileged port, e.g. :8080, and expose that port.",
+		RuleHardcodedResources.ID: "Remove GOMAXPROCS and let go.uber.org/automaxprocs set it from the CPU limit of the container.",
+	},
+}
+
+// RemediationFor returns the remediation of the rule tailored to the runtime, or the remediation
+// of the rule when there isn't any.
+func (r Rule) RemediationFor(runtime Runtime) string {
+	if remediation, ok := runtimeRemediations[runtime][r.ID]; ok {
+		return remediation
+	}
+	return r.Remediation
+}
+
+// DetectRuntime classifies the runtime of the image built by the Containerfile from the base
+// image of the final stage, the packages it installs and the binary its CMD or ENTRYPOINT
+// starts. The final stage inherits the hints of the stages it is based on.
+func DetectRuntime(node *parser.Node) RuntimeStack {
+	stages := astStages(node)
+	if len(stages) == 0 {
+		return RuntimeStack{}
+	}
+	scores := map[Runtime]int{}
+	evidence := map[Runtime][]string{}
+	hint := func(runtime Runtime, weight int, format string, args ...interface{}) {
+		scores[runtime] += weight
+		evidence[runtime] = append(evidence[runtime], fmt.Sprintf(format, args...))
+	}
+
+	final := stages[len(stages)-1]
+	chain := stageChain(stages, final)
+	image := chain[len(chain)-1].image
+	for _, runtime := range Runtimes {
+		if runtimeImage(runtime, image) {
+			hint(runtime, imageWeight, "base image %s", image)
+		}
+	}
+	for _, stage := range chain {
+		for _, instruction := range stage.instructions {
+			switch strings.ToLower(instruction.Value) {
+			case "run":
+				for _, pkg := range installedPackages(instruction.Original) {
+					for _, runtime := range Runtimes {
+						if runtimePackages[runtime].MatchString(pkg) {
+							hint(runtime, packageWeight, "package %s installed", pkg)
+						}
+					}
+				}
+			case "copy":
+				if from, ok := instructionFlagValue(instruction, "from"); ok {
+					if builder, ok := findASTStage(stages, from); ok {
+						builderImage := stageChain(stages, builder)
+						if runtimeImage(RuntimeGo, builderImage[len(builderImage)-1].image) {
+							hint(RuntimeGo, commandWeight, "binary copied from the %s builder stage", from)
+						}
+					}
+				}
+			}
+		}
+	}
+	if binary := startedBinary(chain); binary != "" {
+		for _, runtime := range Runtimes {
+			if pattern, ok := runtimeCommands[runtime]; ok && pattern.MatchString(binary) {
+				hint(runtime, commandWeight, "%s started by the image", binary)
+			}
+		}
+	}
+
+	stack := RuntimeStack{}
+	best := 0
+	for _, runtime := range Runtimes {
+		if scores[runtime] > best {
+			best = scores[runtime]
+			stack = RuntimeStack{Runtime: runtime, Evidence: evidence[runtime]}
+		}
+	}
+	return stack
+}
+
+// DetectRuntimeOf detects the runtime of the Containerfile content, see DetectRuntime.
+func DetectRuntimeOf(content []byte) (RuntimeStack, error) {
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return RuntimeStack{}, err
+	}
+	return DetectRuntime(res.AST), nil
+}
+
+// DetectRuntimePath detects the runtime of the Containerfile at path, or of the
+// Dockerfile/Containerfile of the path directory, see DetectRuntime.
+func DetectRuntimePath(path string) (RuntimeStack, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir := path
+		path = filepath.Join(dir, "Dockerfile")
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(dir, "Containerfile")
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return RuntimeStack{}, err
+	}
+	return DetectRuntimeOf(content)
+}
+
+func runtimeImage(runtime Runtime, image string) bool {
+	pack, ok := FindPack(runtimePacks[runtime])
+	return ok && pack.images.MatchString(strings.ToLower(image))
+}
+
+// installedPackages returns the packages installed by the package managers of the RUN command,
+// without their versions.
+func installedPackages(command string) []string {
+	var packages []string
+	for _, match := range installRegexp.FindAllStringSubmatch(command, -1) {
+		for _, word := range strings.Fields(strings.ReplaceAll(match[1], "\\", " ")) {
+			if strings.HasPrefix(word, "-") {
+				continue
+			}
+			word = strings.SplitN(strings.SplitN(word, "=", 2)[0], "@", 2)[0]
+			packages = append(packages, strings.ToLower(strings.Trim(word, `"'`)))
+		}
+	}
+	return packages
+}
+
+// startedBinary returns the name of the binary started by the last ENTRYPOINT of the stages, or
+// by their last CMD without ENTRYPOINT. The shell wrappers and the environment assignments are
+// skipped.
+func startedBinary(chain []astStage) string {
+	var entrypoint, cmd *parser.Node
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, instruction := range chain[i].instructions {
+			switch strings.ToLower(instruction.Value) {
+			case "entrypoint":
+				entrypoint = instruction
+			case "cmd":
+				cmd = instruction
+			}
+		}
+	}
+	instruction := entrypoint
+	if instruction == nil {
+		instruction = cmd
+	}
+	if instruction == nil {
+		return ""
+	}
+	var words []string
+	for n := instruction.Next; n != nil; n = n.Next {
+		words = append(words, strings.Fields(n.Value)...)
+	}
+	for _, word := range words {
+		name := path.Base(strings.Trim(word, `"'`))
+		switch {
+		case envAssignmentRegexp.MatchString(word), strings.HasPrefix(word, "-"):
+		case name == "exec" || name == "env" || name == "tini" || name == "dumb-init" || name == "sh" || name == "bash" || name == "--":
+		default:
+			return name
+		}
+	}
+	return ""
+}
+
+// instructionFlagValue returns the value of a flag of the instruction, e.g. --from of COPY.
+func instructionFlagValue(node *parser.Node, name string) (string, bool) {
+	for _, flag := range node.Flags {
+		if strings.HasPrefix(flag, "--"+name+"=") {
+			return strings.TrimPrefix(flag, "--"+name+"="), true
+		}
+	}
+	return "", false
+}
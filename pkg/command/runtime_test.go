@@ -0,0 +1,77 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectRuntime(t *testing.T) {
+	for content, expected := range map[string]Runtime{
+		"FROM eclipse-temurin:21-jre\nCOPY app.jar /app.jar\nCMD [\"java\", \"-jar\", \"/app.jar\"]\n":                                            RuntimeJava,
+		"FROM registry.access.redhat.com/ubi9/ubi-minimal\nRUN microdnf install -y java-21-openjdk-headless\n":                                    RuntimeJava,
+		"FROM node:20 AS base\nFROM base\nWORKDIR /app\nCMD npm start\n":                                                                          RuntimeNodeJS,
+		"FROM debian:12\nRUN apt-get update && apt-get install -y --no-install-recommends python3 python3-pip\nCMD [\"gunicorn\", \"app:app\"]\n": RuntimePython,
+		"FROM golang:1.22 AS builder\nRUN go build -o /app .\nFROM scratch\nCOPY --from=builder /app /app\nENTRYPOINT [\"/app\"]\n":               RuntimeGo,
+		// the command wins over the base image
+		"FROM node:20\nRUN apt-get install -y python3\nCMD [\"sh\", \"-c\", \"exec python3 main.py\"]\n": RuntimePython,
+		"FROM registry.access.redhat.com/ubi9/ubi-minimal\nCOPY app /app\nCMD [\"/app\"]\n":              "",
+	} {
+		stack, err := DetectRuntimeOf([]byte(content))
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		if stack.Runtime != expected {
+			t.Errorf("Expected the runtime %q for %q but it was %q (%v)", expected, content, stack.Runtime, stack.Evidence)
+		}
+	}
+}
+
+func TestDetectRuntimeEvidence(t *testing.T) {
+	stack, err := DetectRuntimeOf([]byte("FROM registry.access.redhat.com/ubi9/openjdk-21-runtime\nCMD [\"java\", \"-jar\", \"/deployments/app.jar\"]\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := []string{"base image registry.access.redhat.com/ubi9/openjdk-21-runtime", "java started by the image"}
+	if !reflect.DeepEqual(stack.Evidence, expected) {
+		t.Errorf("Expected the evidence %v but it was %v", expected, stack.Evidence)
+	}
+}
+
+func TestRuntimeActivatesPack(t *testing.T) {
+	res, _, err := parse([]byte("FROM alpine:3.19\nRUN apk add --no-cache openjdk17-jre\nCMD [\"/app/start\"]\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if detected := detectPacks(res.AST); !detected[PACK_JAVA] {
+		t.Errorf("Expected the java pack to be detected from the runtime but the packs were %v", detected)
+	}
+}
+
+func TestRemediationFor(t *testing.T) {
+	if remediation := RuleUserRoot.RemediationFor(RuntimeNodeJS); remediation == RuleUserRoot.Remediation {
+		t.Errorf("Expected a remediation tailored to Node.js")
+	}
+	if remediation := RuleSudo.RemediationFor(RuntimeNodeJS); remediation != RuleSudo.Remediation {
+		t.Errorf("Expected the remediation of the rule but it was %q", remediation)
+	}
+	if remediation := RuleUserRoot.RemediationFor(""); remediation != RuleUserRoot.Remediation {
+		t.Errorf("Expected the remediation of the rule but it was %q", remediation)
+	}
+}
@@ -0,0 +1,207 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+)
+
+// MAX_SCANNED_FILE_SIZE is the size of the largest file scanned for secrets
+const MAX_SCANNED_FILE_SIZE = 1 << 20
+
+// MAX_SCANNED_FILES is the number of files of the build context scanned for secrets
+const MAX_SCANNED_FILES = 5000
+
+// MIN_SECRET_ENTROPY is the Shannon entropy, in bits per character, from which a random looking
+// string is reported. Hexadecimal strings, e.g. digests, are at most 4.
+const MIN_SECRET_ENTROPY = 4.5
+
+type secretScanKeyType struct{}
+
+var secretScanKey secretScanKeyType
+
+type secretFormat struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// secretFormats are the formats of well known credentials
+var secretFormats = []secretFormat{
+	{"a private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY`)},
+	{"an AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"a GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{"a GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"a Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"a credential in a URL", regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@"']+:[^/\s:@"']+@`)},
+}
+
+// randomStringRegexp matches the strings long enough to be a token or a password
+var randomStringRegexp = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+// secretScanSkippedFiles hold checksums, not secrets
+var secretScanSkippedFiles = []string{
+	"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "go.sum", "Cargo.lock", "poetry.lock",
+	"composer.lock", "Gemfile.lock", "*.map",
+}
+
+// WithSecretScan enables the scan of the build context files copied into the image, looking for
+// credentials in their content. It reads every copied file so it's not enabled by default.
+func WithSecretScan(ctx context.Context) context.Context {
+	return context.WithValue(ctx, secretScanKey, true)
+}
+
+func scansSecrets(ctx context.Context) bool {
+	scan, _ := ctx.Value(secretScanKey).(bool)
+	return scan
+}
+
+// analyzeCopiedSecrets scans the files copied from the build context by COPY and ADD, the
+// results are located in the scanned files.
+func analyzeCopiedSecrets(ctx context.Context) []Result {
+	dir, ok := buildContext(ctx)
+	if !ok || !scansSecrets(ctx) {
+		return nil
+	}
+	ignore := contextIgnoreFile(ctx)
+	all, _ := ctx.Value(copiedFilesKey).([]copiedFiles)
+	scanned := map[string]bool{}
+	var results []Result
+	for _, files := range all {
+		if files.provenance != ProvenanceBuildContext {
+			continue
+		}
+		for _, src := range files.sources {
+			rel, ok := contextSource(src)
+			if !ok {
+				continue
+			}
+			matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(rel)))
+			for _, match := range matches {
+				filepath.WalkDir(match, func(file string, entry fs.DirEntry, err error) error {
+					if err != nil || entry.IsDir() || len(scanned) == MAX_SCANNED_FILES {
+						return nil
+					}
+					rel, err := filepath.Rel(dir, file)
+					if err != nil || scanned[rel] || ignore.excludes(rel) || isSecretScanSkipped(entry.Name()) {
+						return nil
+					}
+					scanned[rel] = true
+					for _, secret := range scanSecrets(file) {
+						results = append(results, RuleCopiedSecret.Failed(i18n.Sprintf(ctx, "%s copied by %s at line %d contains %s (%s) at line %d, anyone pulling the image can read it. Remove it from the file and pass it at runtime with an OpenShift Secret",
+							filepath.ToSlash(rel), files.instruction, files.line.Start, i18n.Translate(ctx, secret.kind), maskSecret(secret.value), secret.line)).InFile(filepath.ToSlash(rel), secret.line))
+						if secret.kind == "a random string" {
+							results[len(results)-1].Confidence = ConfidenceLow
+						}
+					}
+					return nil
+				})
+			}
+		}
+	}
+	return results
+}
+
+type foundSecret struct {
+	kind  string
+	value string
+	line  int
+}
+
+// scanSecrets returns the credentials found in the file, at most one per line. Binary and large
+// files are not scanned.
+func scanSecrets(file string) []foundSecret {
+	info, err := os.Stat(file)
+	if err != nil || info.Size() > MAX_SCANNED_FILE_SIZE {
+		return nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return nil
+	}
+	var secrets []foundSecret
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), MAX_SCANNED_FILE_SIZE)
+	for line := 1; scanner.Scan(); line++ {
+		if secret, ok := findSecret(scanner.Text()); ok {
+			secret.line = line
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+func findSecret(text string) (foundSecret, bool) {
+	for _, format := range secretFormats {
+		if match := format.re.FindString(text); match != "" {
+			return foundSecret{kind: format.name, value: match}, true
+		}
+	}
+	for _, match := range randomStringRegexp.FindAllString(text, -1) {
+		if strings.ContainsAny(match, "0123456789") && strings.IndexFunc(match, isLetter) >= 0 && entropy(match) >= MIN_SECRET_ENTROPY {
+			return foundSecret{kind: "a random string", value: match}, true
+		}
+	}
+	return foundSecret{}, false
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var e float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(s))
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// maskSecret keeps the first characters of the secret, enough to find it in the file
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", 8)
+}
+
+func isSecretScanSkipped(name string) bool {
+	for _, pattern := range secretScanSkippedFiles {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"path"
+	"strings"
+)
+
+// shellScript is the shell command of a RUN instruction tokenized once, so that the rules can
+// skip the commands they don't check without scanning the whole string again.
+type shellScript struct {
+	text string
+	// commands are the commands separated by &&, ||, ; or |
+	commands []string
+	// words are the words of the script, stripped of quotes
+	words map[string]bool
+}
+
+// shellOperators separate the commands of a script, the longest operators first
+var shellOperators = []string{"&&", "||", ";", "|", "\n"}
+
+func tokenize(s string) *shellScript {
+	script := &shellScript{text: s, words: map[string]bool{}}
+	start := 0
+	for i := 0; i < len(s); {
+		operator := ""
+		for _, op := range shellOperators {
+			if strings.HasPrefix(s[i:], op) {
+				operator = op
+				break
+			}
+		}
+		if operator == "" {
+			i++
+			continue
+		}
+		script.add(s[start:i])
+		i += len(operator)
+		start = i
+	}
+	script.add(s[start:])
+	return script
+}
+
+func (s *shellScript) add(command string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	s.commands = append(s.commands, command)
+	for _, field := range strings.Fields(command) {
+		word := strings.Trim(field, "\"'`()$")
+		s.words[word] = true
+		// /usr/bin/git is git
+		s.words[path.Base(word)] = true
+	}
+}
+
+// mentions reports whether one of the words is used by the script, e.g. as a command name.
+func (s *shellScript) mentions(words ...string) bool {
+	for _, word := range words {
+		if s.words[word] {
+			return true
+		}
+	}
+	return false
+}
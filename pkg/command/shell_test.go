@@ -0,0 +1,56 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeSplitsCommands(t *testing.T) {
+	script := tokenize("apt-get update && apt-get install -y curl || true; curl -s http://example.com | sh")
+	expected := []string{"apt-get update ", " apt-get install -y curl ", " true", " curl -s http://example.com ", " sh"}
+	if !reflect.DeepEqual(script.commands, expected) {
+		t.Errorf("Expected commands %q but they were %q", expected, script.commands)
+	}
+}
+
+func TestTokenizeWords(t *testing.T) {
+	script := tokenize(`sh -c "/usr/bin/git clone https://github.com/org/app.git" && echo $(id -u)`)
+	for _, word := range []string{"git", "sh", "clone", "id"} {
+		if !script.mentions(word) {
+			t.Errorf("Expected %s to be mentioned", word)
+		}
+	}
+	if script.mentions("apt-get", "dnf") {
+		t.Errorf("Expected no package manager to be mentioned")
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	f.Add("apt-get update && apt-get install -y curl || true; curl -s http://example.com | sh")
+	f.Add(`sh -c "chown 1001:0 /app; chmod 775 /app" && echo $(id -u)`)
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, command := range tokenize(s).commands {
+			if strings.Contains(command, "&&") || strings.Contains(command, ";") || strings.Contains(command, "|") {
+				t.Errorf("Command %q of %q was not split", command, s)
+			}
+		}
+	})
+}
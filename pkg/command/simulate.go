@@ -0,0 +1,772 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// DEFAULT_SIMULATED_UID is a UID of the range OpenShift assigns to the projects, the containers
+// running with it and with the root group
+const DEFAULT_SIMULATED_UID int64 = 1000680000
+
+// Access is the access of the container to a path at runtime.
+type Access string
+
+const (
+	AccessRead    Access = "read"
+	AccessWrite   Access = "write"
+	AccessExecute Access = "execute"
+)
+
+var accessBits = map[Access]fs.FileMode{AccessRead: 4, AccessWrite: 2, AccessExecute: 1}
+
+// chmodRemoveRegexp matches the symbolic modes of chmod looking like options, e.g. -w
+var chmodRemoveRegexp = regexp.MustCompile(`^-[rwxX]+$`)
+
+// Denial is a path the container would fail to access with the simulated UID.
+type Denial struct {
+	Path   string `json:"path"`
+	Access Access `json:"access"`
+	// Purpose is why the container accesses the path, e.g. WORKDIR or ENV LOG_DIR
+	Purpose string `json:"purpose"`
+	// Reason describes the owner and the mode denying the access
+	Reason string `json:"reason"`
+	// Line is the instruction which last changed the path denying the access
+	Line Line `json:"line"`
+}
+
+// Simulation is the result of running the image with an arbitrary UID, see Simulate.
+type Simulation struct {
+	UID int64 `json:"uid"`
+	// User is the USER of the image, replaced by the UID
+	User    string `json:"user,omitempty"`
+	Checked int    `json:"checked"`
+	// Undetermined are the accesses to the paths whose permissions come from the base image or
+	// from files of the build context which aren't available
+	Undetermined int      `json:"undetermined"`
+	Denials      []Denial `json:"denials"`
+}
+
+// simulatedMode holds the permission bits of a path along with the bits which are known, the
+// modes of the base image files and of the files copied from another image being unknown.
+type simulatedMode struct {
+	bits  fs.FileMode
+	known fs.FileMode
+}
+
+func knownMode(mode fs.FileMode) simulatedMode {
+	return simulatedMode{bits: mode & 0777, known: 0777}
+}
+
+// String returns the mode as ls does, ? standing for the unknown bits, e.g. rwxr-?---
+func (m simulatedMode) String() string {
+	var s strings.Builder
+	for i := 8; i >= 0; i-- {
+		bit := fs.FileMode(1) << i
+		switch {
+		case m.known&bit == 0:
+			s.WriteByte('?')
+		case m.bits&bit != 0:
+			s.WriteByte("xwr"[i%3])
+		default:
+			s.WriteByte('-')
+		}
+	}
+	return s.String()
+}
+
+// simulatedPath is the owner and the mode of a path of the image.
+type simulatedPath struct {
+	owner string
+	group string
+	mode  simulatedMode
+	dir   bool
+	// line is the instruction which last changed the path
+	line Line
+}
+
+// simulatedChange is an instruction creating or changing the paths matching target, and the
+// paths below when recursive. It returns whether the path exists after the change.
+type simulatedChange struct {
+	target    string
+	recursive bool
+	apply     func(file string, attrs *simulatedPath, exists bool) bool
+}
+
+func (c simulatedChange) matches(file string) bool {
+	if c.target == file || (c.recursive && (c.target == "/" || strings.HasPrefix(file, c.target+"/"))) {
+		return true
+	}
+	if !strings.ContainsAny(c.target, "*?[") {
+		return false
+	}
+	for p := file; p != "/" && p != "."; p = path.Dir(p) {
+		if matched, _ := path.Match(c.target, p); matched && (p == file || c.recursive) {
+			return true
+		}
+	}
+	return false
+}
+
+// simulatedFS replays the changes of the Containerfile to get the owner and the mode of the paths.
+type simulatedFS struct {
+	changes []simulatedChange
+}
+
+func (f *simulatedFS) add(target string, recursive bool, apply func(file string, attrs *simulatedPath, exists bool) bool) {
+	f.changes = append(f.changes, simulatedChange{target: target, recursive: recursive, apply: apply})
+}
+
+// lookup returns the owner and mode of the file, it reports false when the file isn't created by
+// the Containerfile, e.g. it comes from the base image.
+func (f *simulatedFS) lookup(file string) (simulatedPath, bool) {
+	attrs := simulatedPath{}
+	exists := false
+	for _, change := range f.changes {
+		if change.matches(file) {
+			exists = change.apply(file, &attrs, exists)
+		}
+	}
+	return attrs, exists
+}
+
+// create records the creation of a directory, or of a file, which is left unchanged when it
+// already exists, e.g. mkdir -p.
+func (f *simulatedFS) create(file string, owner, group string, mode fs.FileMode, dir bool, line Line) {
+	f.add(file, false, func(_ string, attrs *simulatedPath, exists bool) bool {
+		if !exists {
+			*attrs = simulatedPath{owner: owner, group: group, mode: knownMode(mode), dir: dir, line: line}
+		}
+		return true
+	})
+}
+
+// createParents records the creation of the missing parent directories of the file.
+func (f *simulatedFS) createParents(file string, owner, group string, line Line) {
+	var parents []string
+	for dir := path.Dir(file); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		parents = append([]string{dir}, parents...)
+	}
+	for _, dir := range parents {
+		if _, exists := f.lookup(dir); !exists {
+			f.create(dir, owner, group, 0755, true, line)
+		}
+	}
+}
+
+// buildUser is the user and the group the RUN instructions create the files with.
+type buildUser struct {
+	owner string
+	group string
+}
+
+func parseBuildUser(value string) buildUser {
+	owner, group, ok := strings.Cut(value, ":")
+	if !ok {
+		// the primary group of a named user is only known from the /etc/passwd of the image
+		group = "0"
+		if _, err := strconv.Atoi(owner); err != nil {
+			group = owner
+		}
+	}
+	return buildUser{owner: owner, group: group}
+}
+
+// Simulate simulates the container of the final stage, or of the stage set by WithTarget, run by
+// OpenShift with the arbitrary UID uid and the root group: the working directory, the files of
+// the start command, the volumes and the paths the application writes to are checked against the
+// owners and modes set by the Containerfile. The modes of the files copied from the build context
+// are read from it when the context has one, see WithBuildContext.
+func Simulate(ctx context.Context, node *parser.Node, uid int64) Simulation {
+	simulation := Simulation{UID: uid, Denials: []Denial{}}
+	state, ok := replayStages(ctx, node)
+	if !ok {
+		return simulation
+	}
+	simulation.User = state.finalUser
+	for _, access := range state.accesses() {
+		simulation.Checked++
+		denial, determined := state.check(access, uid)
+		if !determined {
+			simulation.Undetermined++
+		} else if denial != nil {
+			simulation.Denials = append(simulation.Denials, *denial)
+		}
+	}
+	return simulation
+}
+
+// LocatePath returns the line of the instruction of the final stage, or of the stage set by
+// WithTarget, which last changed the permissions of the path, or of its closest parent directory,
+// or else which makes the container access it, e.g. the ENV setting a log file. It reports false
+// when the path doesn't come from the Containerfile.
+func LocatePath(ctx context.Context, node *parser.Node, file string) (Line, bool) {
+	state, ok := replayStages(ctx, node)
+	if !ok || !path.IsAbs(file) {
+		return Line{}, false
+	}
+	file = path.Clean(file)
+	for p := file; p != "/"; p = path.Dir(p) {
+		if attrs, exists := state.fs.lookup(p); exists {
+			return attrs.line, true
+		}
+	}
+	for _, access := range state.accesses() {
+		if access.line.Start > 0 && (access.path == file || strings.HasPrefix(file, access.path+"/")) {
+			return access.line, true
+		}
+	}
+	return Line{}, false
+}
+
+// replayStages replays the instructions of the final stage, or of the stage set by WithTarget,
+// and of the stages it is based on.
+func replayStages(ctx context.Context, node *parser.Node) (*simulationState, bool) {
+	stages := astStages(node)
+	if len(stages) == 0 {
+		return nil, false
+	}
+	stage := stages[len(stages)-1]
+	if target, ok := targetOf(ctx); ok {
+		if stage, ok = findASTStage(stages, target); !ok {
+			return nil, false
+		}
+	}
+	state := &simulationState{ctx: ctx, fs: &simulatedFS{}, workdir: "/", user: buildUser{owner: "root", group: "root"}}
+	chain := stageChain(stages, stage)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, instruction := range chain[i].instructions {
+			state.replay(instruction)
+		}
+	}
+	return state, true
+}
+
+// SimulatePath simulates the container of the image built from the Containerfile at path, or
+// from the Dockerfile/Containerfile of the path directory, see Simulate. The directory of the
+// Containerfile is the build context unless the context already sets one.
+func SimulatePath(ctx context.Context, path string, uid int64) (Simulation, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir := path
+		path = filepath.Join(dir, "Dockerfile")
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(dir, "Containerfile")
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Simulation{}, err
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return Simulation{}, err
+	}
+	if _, ok := buildContext(ctx); !ok {
+		ctx = WithBuildContext(ctx, filepath.Dir(path))
+	}
+	return Simulate(ctx, res.AST, uid), nil
+}
+
+// simulatedAccess is an access of the container to a path at runtime
+type simulatedAccess struct {
+	path    string
+	access  Access
+	purpose string
+	// line is the instruction making the container access the path
+	line Line
+}
+
+type simulationState struct {
+	ctx     context.Context
+	fs      *simulatedFS
+	workdir string
+	// workdirLine is the line of the last WORKDIR
+	workdirLine Line
+	user        buildUser
+	finalUser   string
+	volumes     []simulatedAccess
+	env         []*parser.Node
+	start       *parser.Node
+	startCmd    *parser.Node
+}
+
+func (s *simulationState) replay(instruction *parser.Node) {
+	line := instructionLine(instruction)
+	switch strings.ToLower(instruction.Value) {
+	case "user":
+		if instruction.Next != nil {
+			s.user = parseBuildUser(instruction.Next.Value)
+			s.finalUser = instruction.Next.Value
+		}
+	case "workdir":
+		if instruction.Next == nil {
+			return
+		}
+		s.workdir = resolvePath(s.workdir, instruction.Next.Value)
+		s.workdirLine = line
+		if _, exists := s.fs.lookup(s.workdir); !exists && !strings.Contains(s.workdir, "$") {
+			// BuildKit creates the working directory with the current user
+			s.fs.createParents(s.workdir, "root", "root", line)
+			s.fs.create(s.workdir, s.user.owner, s.user.group, 0755, true, line)
+		}
+	case "copy", "add":
+		s.replayCopy(instruction, line)
+	case "run":
+		for _, command := range tokenize(strings.Join(instructionWords(instruction), " ")).commands {
+			s.replayCommand(strings.Fields(command), line)
+		}
+	case "volume":
+		for n := instruction.Next; n != nil; n = n.Next {
+			s.volumes = append(s.volumes, simulatedAccess{resolvePath("/", n.Value), AccessWrite, "VOLUME", line})
+		}
+	case "env":
+		s.env = append(s.env, instruction)
+	case "entrypoint":
+		s.start = instruction
+		// ENTRYPOINT resets the CMD of the base image
+		s.startCmd = nil
+	case "cmd":
+		s.startCmd = instruction
+	}
+}
+
+func (s *simulationState) replayCopy(instruction *parser.Node, line Line) {
+	sources := copySources(instruction.Next)
+	if len(sources) == 0 {
+		return
+	}
+	destination := instruction.Next
+	for destination.Next != nil {
+		destination = destination.Next
+	}
+	owner, group := "root", "root"
+	if chown, ok := instructionFlagValue(instruction, "chown"); ok {
+		owner, group, _ = strings.Cut(chown, ":")
+		if group == "" {
+			group = owner
+		}
+	}
+	var chmod *simulatedMode
+	if value, ok := instructionFlagValue(instruction, "chmod"); ok {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+			known := knownMode(fs.FileMode(mode))
+			chmod = &known
+		}
+	}
+	_, fromOther := instructionFlagValue(instruction, "from")
+	dir, hasContext := buildContext(s.ctx)
+	for _, copied := range sources {
+		if strings.Contains(copied, "$") || strings.Contains(destination.Value, "$") {
+			continue
+		}
+		remote := strings.Contains(copied, "://")
+		if strings.EqualFold(instruction.Value, "add") && !remote && archiveRegexp.MatchString(copied) {
+			// the content of the archive extracted by ADD is unknown
+			continue
+		}
+		var info fs.FileInfo
+		local := ""
+		if hasContext && !fromOther && !remote && !strings.ContainsAny(copied, "*?[") {
+			local = filepath.Join(dir, filepath.FromSlash(copied))
+			info, _ = os.Stat(local)
+		}
+		target := resolvePath(s.workdir, destination.Value)
+		existing, exists := s.fs.lookup(target)
+		if (info == nil || !info.IsDir()) && (len(sources) > 1 || strings.HasSuffix(destination.Value, "/") || destination.Value == "." || (exists && existing.dir)) {
+			target = path.Join(target, path.Base(copied))
+		}
+		s.fs.createParents(target, owner, group, line)
+		source := local
+		sourceDir := info != nil && info.IsDir()
+		s.fs.add(target, true, func(file string, attrs *simulatedPath, exists bool) bool {
+			mode := simulatedMode{}
+			isDir := false
+			switch {
+			case file == target && remote:
+				// the files downloaded by ADD are only readable by their owner
+				mode = knownMode(0600)
+			case file == target && exists && sourceDir:
+				// the content of the directory is copied into the existing one
+				return exists
+			case source != "":
+				stat, err := os.Stat(filepath.Join(source, filepath.FromSlash(strings.TrimPrefix(file, target))))
+				if err != nil {
+					return exists
+				}
+				mode, isDir = knownMode(stat.Mode().Perm()), stat.IsDir()
+			case file != target:
+				// the content of a directory copied from a stage or an image is unknown
+				return exists
+			}
+			if chmod != nil {
+				mode = *chmod
+			}
+			*attrs = simulatedPath{owner: owner, group: group, mode: mode, dir: isDir, line: line}
+			return true
+		})
+	}
+}
+
+// replayCommand replays the commands of a RUN instruction changing the files: mkdir, touch,
+// chmod, chown, chgrp and rm.
+func (s *simulationState) replayCommand(fields []string, line Line) {
+	if len(fields) == 0 {
+		return
+	}
+	name := path.Base(fields[0])
+	var args []string
+	recursive := false
+	mode := ""
+	for i := 1; i < len(fields); i++ {
+		field := strings.Trim(fields[i], `"'`)
+		switch {
+		case field == "-R" || field == "--recursive" || (name == "rm" && strings.HasPrefix(field, "-") && strings.ContainsAny(field, "rR")):
+			recursive = true
+		case name == "mkdir" && (field == "-m" || field == "--mode") && i+1 < len(fields):
+			mode = strings.Trim(fields[i+1], `"'`)
+			i++
+		case name == "mkdir" && strings.HasPrefix(field, "--mode="):
+			mode = strings.TrimPrefix(field, "--mode=")
+		case name == "chmod" && len(args) == 0 && chmodRemoveRegexp.MatchString(field):
+			// e.g. chmod -w file
+			args = append(args, field)
+		case strings.HasPrefix(field, "-"):
+		default:
+			args = append(args, field)
+		}
+	}
+	var targets []string
+	switch name {
+	case "mkdir", "touch", "rm":
+		targets = args
+	case "chmod", "chown", "chgrp":
+		if len(args) < 2 {
+			return
+		}
+		targets = args[1:]
+	default:
+		return
+	}
+	for _, target := range targets {
+		if strings.Contains(target, "$") {
+			continue
+		}
+		target = resolvePath(s.workdir, target)
+		switch name {
+		case "mkdir":
+			dirMode := fs.FileMode(0755)
+			if parsed, err := strconv.ParseUint(mode, 8, 32); err == nil {
+				dirMode = fs.FileMode(parsed)
+			}
+			s.fs.createParents(target, s.user.owner, s.user.group, line)
+			s.fs.create(target, s.user.owner, s.user.group, dirMode, true, line)
+		case "touch":
+			s.fs.create(target, s.user.owner, s.user.group, 0644, false, line)
+		case "rm":
+			s.fs.add(target, recursive, func(string, *simulatedPath, bool) bool {
+				return false
+			})
+		case "chmod":
+			change := args[0]
+			s.fs.add(target, recursive, func(_ string, attrs *simulatedPath, exists bool) bool {
+				if exists {
+					attrs.mode = applyChmod(attrs.mode, change, attrs.dir)
+					attrs.line = line
+				}
+				return exists
+			})
+		case "chown", "chgrp":
+			owner, group, ok := "", args[0], true
+			if name == "chown" {
+				owner, group, ok = strings.Cut(args[0], ":")
+			}
+			s.fs.add(target, recursive, func(_ string, attrs *simulatedPath, exists bool) bool {
+				if exists {
+					if owner != "" {
+						attrs.owner = owner
+					}
+					if ok && group != "" {
+						attrs.group = group
+					}
+					attrs.line = line
+				}
+				return exists
+			})
+		}
+	}
+}
+
+// accesses returns the accesses of the container: the working directory, the files of the
+// start command, the volumes and the paths the application writes to.
+func (s *simulationState) accesses() []simulatedAccess {
+	accesses := []simulatedAccess{
+		{s.workdir, AccessExecute, "WORKDIR", s.workdirLine},
+		{s.workdir, AccessRead, "WORKDIR", s.workdirLine},
+	}
+	first := true
+	for _, word := range s.startWords() {
+		name := strings.Trim(word.value, `"'`)
+		switch {
+		case envAssignmentRegexp.MatchString(name), strings.HasPrefix(name, "-"):
+			continue
+		case name == "exec" || name == "env" || name == "tini" || name == "dumb-init" || name == "--":
+			// the next word is the started program
+			continue
+		case (path.IsAbs(name) || strings.HasPrefix(name, "./")) && !strings.Contains(name, "$"):
+			file := resolvePath(s.workdir, name)
+			if first {
+				accesses = append(accesses, simulatedAccess{file, AccessExecute, word.origin, word.line})
+			}
+			if !first || path.Ext(file) != "" {
+				// the scripts are read by their interpreter
+				accesses = append(accesses, simulatedAccess{file, AccessRead, word.origin, word.line})
+			}
+		}
+		first = false
+	}
+	accesses = append(accesses, s.volumes...)
+	var writes []runtimeWrite
+	for _, instruction := range s.env {
+		for key := instruction.Next; key != nil && key.Next != nil; {
+			value := key.Next
+			writes = append(writes, envWrites(s.ctx, key.Value, value.Value, utils.Source{}, instructionLine(instruction))...)
+			key = value.Next
+		}
+	}
+	for _, instruction := range []*parser.Node{s.start, s.startCmd} {
+		if instruction != nil {
+			writes = append(writes, commandWrites(s.ctx, strings.ToUpper(instruction.Value), strings.Join(instructionWords(instruction), " "), utils.Source{}, instructionLine(instruction))...)
+		}
+	}
+	seen := map[simulatedAccess]bool{}
+	var unique []simulatedAccess
+	for _, write := range writes {
+		accesses = append(accesses, simulatedAccess{write.path, AccessWrite, write.origin, write.line})
+	}
+	for _, access := range accesses {
+		key := simulatedAccess{path: access.path, access: access.access}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, access)
+		}
+	}
+	return unique
+}
+
+// startWord is a word of the start command of the container
+type startWord struct {
+	value  string
+	origin string
+	line   Line
+}
+
+// startWords returns the words of the command started by the container: the ENTRYPOINT followed
+// by the CMD arguments in exec form, or the CMD without ENTRYPOINT.
+func (s *simulationState) startWords() []startWord {
+	var words []startWord
+	for _, instruction := range []*parser.Node{s.start, s.startCmd} {
+		if instruction == nil || (instruction == s.startCmd && s.start != nil && !s.start.Attributes["json"]) {
+			continue
+		}
+		for _, value := range instructionWords(instruction) {
+			for _, field := range strings.Fields(value) {
+				words = append(words, startWord{field, strings.ToUpper(instruction.Value), instructionLine(instruction)})
+			}
+		}
+	}
+	return words
+}
+
+// check checks the access of the uid, in the root group, to the file and to its parent
+// directories. It reports false when the permissions aren't known.
+func (s *simulationState) check(access simulatedAccess, uid int64) (*Denial, bool) {
+	file := access.path
+	attrs, exists := s.fs.lookup(file)
+	needed := access.access
+	if !exists && access.access == AccessWrite {
+		// creating a file requires writing to its directory
+		file = path.Dir(access.path)
+		if attrs, exists = s.fs.lookup(file); exists && !attrs.dir {
+			return nil, false
+		}
+	}
+	if exists && attrs.dir && access.access == AccessWrite {
+		// the files of a directory can't be created without searching it
+		if denial, determined := s.checkPath(access, file, attrs, AccessExecute, uid); denial != nil || !determined {
+			return denial, determined
+		}
+	}
+	var dirs []string
+	for dir := path.Dir(file); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		dirAttrs, ok := s.fs.lookup(dir)
+		if !ok {
+			continue
+		}
+		if denial, determined := s.checkPath(access, dir, dirAttrs, AccessExecute, uid); denial != nil {
+			return denial, true
+		} else if !determined {
+			return nil, false
+		}
+	}
+	if !exists {
+		return nil, false
+	}
+	return s.checkPath(access, file, attrs, needed, uid)
+}
+
+// checkPath checks the permission of the uid on the path.
+func (s *simulationState) checkPath(access simulatedAccess, file string, attrs simulatedPath, needed Access, uid int64) (*Denial, bool) {
+	shift := 0
+	class := "others"
+	switch {
+	case strings.Contains(attrs.owner, "$") || strings.Contains(attrs.group, "$"):
+		return nil, false
+	case attrs.owner == strconv.FormatInt(uid, 10):
+		shift, class = 6, "owner"
+	case attrs.group == "0" || attrs.group == "root":
+		shift, class = 3, "group"
+	}
+	bit := accessBits[needed] << shift
+	if attrs.mode.known&bit == 0 {
+		return nil, false
+	}
+	if attrs.mode.bits&bit != 0 {
+		return nil, true
+	}
+	var reason string
+	if file == access.path {
+		reason = fmt.Sprintf("%s is owned by %s:%s with mode %s, the UID %d can't %s it as %s", file, attrs.owner, attrs.group, attrs.mode, uid, needed, class)
+	} else {
+		reason = fmt.Sprintf("its directory %s is owned by %s:%s with mode %s, the UID %d can't %s it as %s", file, attrs.owner, attrs.group, attrs.mode, uid, needed, class)
+	}
+	return &Denial{Path: access.path, Access: access.access, Purpose: access.purpose, Reason: reason, Line: attrs.line}, true
+}
+
+func instructionLine(instruction *parser.Node) Line {
+	return Line{Start: instruction.StartLine, End: instruction.EndLine}
+}
+
+// instructionWords returns the arguments of the instruction.
+func instructionWords(instruction *parser.Node) []string {
+	var words []string
+	for n := instruction.Next; n != nil; n = n.Next {
+		words = append(words, n.Value)
+	}
+	return words
+}
+
+// applyChmod applies the octal or symbolic mode of chmod, e.g. 775, g=u or u+x,go-w, to the mode.
+func applyChmod(mode simulatedMode, change string, dir bool) simulatedMode {
+	if parsed, err := strconv.ParseUint(change, 8, 32); err == nil {
+		return knownMode(fs.FileMode(parsed))
+	}
+	for _, clause := range strings.Split(change, ",") {
+		who := strings.TrimLeft(clause, "ugoa")
+		classes := clause[:len(clause)-len(who)]
+		if classes == "" || strings.Contains(classes, "a") {
+			classes = "ugo"
+		}
+		for len(who) > 0 && strings.ContainsRune("+-=", rune(who[0])) {
+			op := who[0]
+			perms := strings.TrimLeft(who[1:], "rwxXstugo")
+			mode = applyChmodOp(mode, classes, op, who[1:len(who)-len(perms)], dir)
+			who = perms
+		}
+		if who != "" {
+			// not a mode chmod understands, the permissions become unknown
+			return simulatedMode{}
+		}
+	}
+	return mode
+}
+
+var classShifts = map[rune]int{'u': 6, 'g': 3, 'o': 0}
+
+func applyChmodOp(mode simulatedMode, classes string, op byte, perms string, dir bool) simulatedMode {
+	// set are the bits of the change, maybe the bits which may or may not be part of it
+	var set, maybe fs.FileMode
+	for _, perm := range perms {
+		switch perm {
+		case 'r':
+			set |= 4
+		case 'w':
+			set |= 2
+		case 'x':
+			set |= 1
+		case 'X':
+			// executable for the directories and the files executable by someone
+			if dir || mode.bits&mode.known&0111 != 0 {
+				set |= 1
+			} else if mode.known&0111 != 0111 {
+				maybe |= 1
+			}
+		case 'u', 'g', 'o':
+			shift := classShifts[perm]
+			set |= (mode.bits & mode.known >> shift) & 7
+			maybe |= (^mode.known >> shift) & 7
+		}
+	}
+	maybe &^= set
+	result := mode
+	for _, class := range classes {
+		shift := classShifts[class]
+		for bit := fs.FileMode(1); bit <= 4; bit <<= 1 {
+			target := bit << shift
+			oldSet, oldKnown := mode.bits&target != 0, mode.known&target != 0
+			newSet, newKnown := oldSet, oldKnown
+			switch {
+			case set&bit != 0:
+				newSet, newKnown = op != '-', true
+			case maybe&bit != 0 && op == '=':
+				newKnown = false
+			case maybe&bit != 0 && op == '+':
+				newKnown = oldKnown && oldSet
+			case maybe&bit != 0 && op == '-':
+				newKnown = oldKnown && !oldSet
+			case op == '=':
+				newSet, newKnown = false, true
+			}
+			result.bits &^= target
+			result.known &^= target
+			if newSet {
+				result.bits |= target
+			}
+			if newKnown {
+				result.known |= target
+			}
+		}
+	}
+	return result
+}
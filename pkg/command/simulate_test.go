@@ -0,0 +1,121 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+func simulate(t *testing.T, ctx context.Context, content string) Simulation {
+	res, err := parser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %s", content, err)
+	}
+	return Simulate(ctx, res.AST, DEFAULT_SIMULATED_UID)
+}
+
+func denied(simulation Simulation) map[string]bool {
+	denials := map[string]bool{}
+	for _, denial := range simulation.Denials {
+		denials[denial.Path+" "+string(denial.Access)] = true
+	}
+	return denials
+}
+
+func TestSimulateArbitraryUID(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithBuildContext(context.Background(), dir)
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal
+RUN mkdir -p /app/logs && chown -R 1001:1001 /app && chmod -R 750 /app
+WORKDIR /app
+COPY --chown=1001:0 run.sh /app/run.sh
+ADD https://example.com/app.jar /app/app.jar
+ENV LOG_DIR=/app/logs
+VOLUME /data
+USER 1001
+ENTRYPOINT ["/app/run.sh"]
+CMD ["/app/app.jar"]
+`
+	simulation := simulate(t, ctx, content)
+	expected := []string{"/app execute", "/app read", "/app/run.sh execute", "/app/run.sh read", "/app/app.jar read", "/app/logs write"}
+	if denials := denied(simulation); len(denials) != len(expected) {
+		t.Errorf("Expected the denials %v but they were %v", expected, simulation.Denials)
+	} else {
+		for _, denial := range expected {
+			if !denials[denial] {
+				t.Errorf("Expected %s to be denied but the denials were %v", denial, simulation.Denials)
+			}
+		}
+	}
+	if simulation.User != "1001" || simulation.Undetermined != 1 {
+		t.Errorf("Expected the USER 1001 to be replaced and the volume to be undetermined but got %v", simulation)
+	}
+	if denial := simulation.Denials[0]; denial.Line.Start != 2 || !strings.Contains(denial.Reason, "owned by 1001:1001 with mode rwxr-x---") {
+		t.Errorf("Unexpected denial %v", denial)
+	}
+
+	fixed := strings.Replace(content, "chown -R 1001:1001 /app && chmod -R 750 /app", "chown -R 1001:0 /app && chmod -R g=u /app", 1)
+	fixed = strings.Replace(fixed, "ADD https://example.com/app.jar /app/app.jar", "ADD --chmod=644 https://example.com/app.jar /app/app.jar", 1)
+	if simulation := simulate(t, ctx, fixed); len(simulation.Denials) != 0 {
+		t.Errorf("Expected no denial but they were %v", simulation.Denials)
+	}
+}
+
+func TestApplyChmod(t *testing.T) {
+	for _, test := range []struct {
+		mode     simulatedMode
+		change   string
+		dir      bool
+		expected string
+	}{
+		{knownMode(0644), "755", false, "rwxr-xr-x"},
+		{knownMode(0644), "u+x,g=u", false, "rwxrwxr--"},
+		{knownMode(0750), "g+w,o=g", true, "rwxrwxrwx"},
+		{knownMode(0640), "a+X", false, "rw-r-----"},
+		{knownMode(0640), "a+X", true, "rwxr-x--x"},
+		{knownMode(0777), "go-w", false, "rwxr-xr-x"},
+		{simulatedMode{}, "g+w", false, "????w????"},
+		{simulatedMode{}, "g=u", false, "?????????"},
+		{simulatedMode{}, "o=r", false, "??????r--"},
+	} {
+		if mode := applyChmod(test.mode, test.change, test.dir); mode.String() != test.expected {
+			t.Errorf("Expected chmod %s of %s to be %s but it was %s", test.change, test.mode, test.expected, mode)
+		}
+	}
+}
+
+func TestLocatePath(t *testing.T) {
+	res, _ := parser.Parse(strings.NewReader("FROM ubi9\nRUN mkdir -p /app/logs && chmod 755 /app/logs\nENV PID_FILE=/run/app/app.pid\nCOPY --from=builder /build/app /app/bin/\n"))
+	for file, expected := range map[string]int{"/app/logs/app.log": 2, "/app/logs": 2, "/run/app/app.pid": 3, "/app/bin/app": 4} {
+		if line, ok := LocatePath(context.Background(), res.AST, file); !ok || line.Start != expected {
+			t.Errorf("Expected %s to be located at line %d but got %v", file, expected, line)
+		}
+	}
+	if line, ok := LocatePath(context.Background(), res.AST, "/etc/passwd"); ok {
+		t.Errorf("Expected /etc/passwd not to be located but got %v", line)
+	}
+}
@@ -0,0 +1,246 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+const (
+	// PACKAGE_SIZE is the average size of an installed package, the sizes of the packages are
+	// not known before the build
+	PACKAGE_SIZE int64 = 5 << 20
+	// PACKAGE_CACHE_SIZE is the size of the metadata and the downloaded packages left by a package
+	// manager whose cache isn't cleaned in the same instruction
+	PACKAGE_CACHE_SIZE int64 = 40 << 20
+	// MAX_SIZE_OFFENDERS is the number of largest contributions flagged by EstimateSize
+	MAX_SIZE_OFFENDERS = 3
+)
+
+// packageCacheCleanRegexp matches the commands cleaning the cache of the package managers
+var packageCacheCleanRegexp = regexp.MustCompile(`\b(?:(?:dnf|microdnf|yum|apt-get|apt)\s+clean\b|rm\s+(?:-\S+\s+)*[^&|;]*/var/(?:cache/(?:dnf|yum|apk)|lib/apt/lists)|apk\s+(?:-\S+\s+)*add\s+(?:[^&|;]*\s)?--no-cache\b)`)
+
+// SizeEstimate is the estimated size an instruction adds to the image.
+type SizeEstimate struct {
+	Instruction string `json:"instruction"`
+	Line        Line   `json:"line"`
+	Bytes       int64  `json:"bytes"`
+	// Detail describes what the size is made of, e.g. the number of installed packages
+	Detail string `json:"detail,omitempty"`
+	// Top is set on the MAX_SIZE_OFFENDERS largest contributions to the image
+	Top bool `json:"top,omitempty"`
+}
+
+// ImageSizer returns the size of an image, e.g. read from its manifest in the registry.
+type ImageSizer func(image string) (int64, error)
+
+type imageSizerKeyType struct{}
+
+var imageSizerKey imageSizerKeyType
+
+// WithImageSizer estimates the size of the base images with sizer, they are left out otherwise.
+func WithImageSizer(ctx context.Context, sizer ImageSizer) context.Context {
+	return context.WithValue(ctx, imageSizerKey, sizer)
+}
+
+func imageSizer(ctx context.Context) ImageSizer {
+	sizer, _ := ctx.Value(imageSizerKey).(ImageSizer)
+	return sizer
+}
+
+// EstimateSize estimates the size added to the image by the instructions of the final stage, or of
+// the stage set by WithTarget, and of the stages it is based on. The base image is estimated when
+// the context has an ImageSizer and the files copied from the build context when it has one, see
+// WithBuildContext. The instructions adding nothing are left out.
+func EstimateSize(ctx context.Context, node *parser.Node) []SizeEstimate {
+	stages := astStages(node)
+	if len(stages) == 0 {
+		return nil
+	}
+	stage := stages[len(stages)-1]
+	if target, ok := targetOf(ctx); ok {
+		if stage, ok = findASTStage(stages, target); !ok {
+			return nil
+		}
+	}
+	ctx = withIgnoreFile(ctx)
+	var estimates []SizeEstimate
+	chain := stageChain(stages, stage)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if i == len(chain)-1 {
+			if estimate, ok := estimateBaseImage(ctx, chain[i]); ok {
+				estimates = append(estimates, estimate)
+			}
+		}
+		for _, instruction := range chain[i].instructions {
+			if estimate, ok := estimateInstruction(ctx, instruction); ok {
+				estimates = append(estimates, estimate)
+			}
+		}
+	}
+	flagOffenders(estimates)
+	return estimates
+}
+
+// EstimateSizePath estimates the size of the image built from the Containerfile at path, or from
+// the Dockerfile/Containerfile of the path directory, see EstimateSize. The directory of the
+// Containerfile is the build context unless the context already sets one.
+func EstimateSizePath(ctx context.Context, path string) ([]SizeEstimate, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir := path
+		path = filepath.Join(dir, "Dockerfile")
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(dir, "Containerfile")
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := buildContext(ctx); !ok {
+		ctx = WithBuildContext(ctx, filepath.Dir(path))
+	}
+	return EstimateSize(ctx, res.AST), nil
+}
+
+func estimateBaseImage(ctx context.Context, stage astStage) (SizeEstimate, bool) {
+	sizer := imageSizer(ctx)
+	if sizer == nil || stage.image == "" || strings.EqualFold(stage.image, "scratch") || strings.Contains(stage.image, "$") {
+		return SizeEstimate{}, false
+	}
+	image, ok := resolveImage(ctx, stage.image)
+	if !ok {
+		return SizeEstimate{}, false
+	}
+	size, err := sizer(image)
+	if err != nil || size == 0 {
+		return SizeEstimate{}, false
+	}
+	return SizeEstimate{
+		Instruction: "FROM",
+		Line:        Line{Start: stage.from.StartLine, End: stage.from.EndLine},
+		Bytes:       size,
+		Detail:      fmt.Sprintf("base image %s", image),
+	}, true
+}
+
+func estimateInstruction(ctx context.Context, instruction *parser.Node) (SizeEstimate, bool) {
+	estimate := SizeEstimate{
+		Instruction: strings.ToUpper(instruction.Value),
+		Line:        Line{Start: instruction.StartLine, End: instruction.EndLine},
+	}
+	switch estimate.Instruction {
+	case "COPY", "ADD":
+		if _, ok := instructionFlagValue(instruction, "from"); ok {
+			return estimate, false
+		}
+		files := 0
+		for _, src := range copySources(instruction.Next) {
+			size, count := contextSourceSize(ctx, src)
+			estimate.Bytes += size
+			files += count
+		}
+		estimate.Detail = fmt.Sprintf("%d file(s) of the build context", files)
+	case "RUN":
+		packages := installedPackages(instruction.Original)
+		if len(packages) == 0 {
+			return estimate, false
+		}
+		estimate.Bytes = int64(len(packages)) * PACKAGE_SIZE
+		estimate.Detail = fmt.Sprintf("%d package(s)", len(packages))
+		if !packageCacheCleanRegexp.MatchString(instruction.Original) {
+			estimate.Bytes += PACKAGE_CACHE_SIZE
+			estimate.Detail += ", package manager cache not cleaned"
+		}
+	}
+	return estimate, estimate.Bytes > 0
+}
+
+// contextSourceSize returns the size and the number of the files of the build context matched by
+// the COPY or ADD source, the files excluded by the ignore file being left out.
+func contextSourceSize(ctx context.Context, src string) (int64, int) {
+	dir, ok := buildContext(ctx)
+	if !ok {
+		return 0, 0
+	}
+	rel, ok := contextSource(src)
+	if !ok {
+		if src == "." || src == "./" || src == "/" {
+			rel = "."
+		} else {
+			return 0, 0
+		}
+	}
+	ignore := contextIgnoreFile(ctx)
+	matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(rel)))
+	var size int64
+	count := 0
+	entries := 0
+	for _, match := range matches {
+		filepath.WalkDir(match, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entries++; entries > MAX_CONTEXT_ENTRIES {
+				return errContextTooLarge
+			}
+			fileRel, err := filepath.Rel(dir, file)
+			if err != nil || fileRel == "." || entry.IsDir() {
+				return nil
+			}
+			if ignore.excludes(fileRel) {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+				count++
+			}
+			return nil
+		})
+	}
+	return size, count
+}
+
+// flagOffenders sets Top on the MAX_SIZE_OFFENDERS largest estimates.
+func flagOffenders(estimates []SizeEstimate) {
+	indexes := make([]int, len(estimates))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return estimates[indexes[i]].Bytes > estimates[indexes[j]].Bytes
+	})
+	for i := 0; i < len(indexes) && i < MAX_SIZE_OFFENDERS; i++ {
+		estimates[indexes[i]].Top = true
+	}
+}
@@ -0,0 +1,100 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+func TestEstimateSize(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		".dockerignore":     "**/*.log\n",
+		"app/main.js":       strings.Repeat("a", 1000),
+		"app/debug.log":     strings.Repeat("a", 5000),
+		"package.json":      strings.Repeat("a", 10),
+		"assets/logo.png":   strings.Repeat("a", 3000),
+		"Containerfile.dev": "",
+	})
+	content := `FROM registry.access.redhat.com/ubi9/nodejs-20 AS builder
+RUN npm ci
+FROM registry.access.redhat.com/ubi9/ubi-minimal
+RUN microdnf install -y nodejs tar && microdnf clean all
+COPY app /app
+COPY package.json assets/ /app/
+COPY --from=builder /opt/app-root/src/node_modules /app/node_modules
+RUN apt-get install -y curl
+USER 1001
+`
+	if err := os.WriteFile(filepath.Join(dir, "Containerfile"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sizer := func(image string) (int64, error) {
+		if image != "registry.access.redhat.com/ubi9/ubi-minimal" {
+			t.Errorf("Expected the size of the final base image but got %s", image)
+		}
+		return 1 << 20, nil
+	}
+	estimates, err := EstimateSizePath(WithImageSizer(context.Background(), sizer), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SizeEstimate{
+		{Instruction: "FROM", Line: Line{Start: 3, End: 3}, Bytes: 1 << 20, Top: true},
+		{Instruction: "RUN", Line: Line{Start: 4, End: 4}, Bytes: 2 * PACKAGE_SIZE, Top: true},
+		{Instruction: "COPY", Line: Line{Start: 5, End: 5}, Bytes: 1000},
+		{Instruction: "COPY", Line: Line{Start: 6, End: 6}, Bytes: 3010},
+		{Instruction: "RUN", Line: Line{Start: 8, End: 8}, Bytes: PACKAGE_SIZE + PACKAGE_CACHE_SIZE, Top: true},
+	}
+	if len(estimates) != len(expected) {
+		t.Fatalf("Expected %d estimates but got %v", len(expected), estimates)
+	}
+	for i, estimate := range estimates {
+		if estimate.Instruction != expected[i].Instruction || estimate.Line != expected[i].Line || estimate.Bytes != expected[i].Bytes || estimate.Top != expected[i].Top {
+			t.Errorf("Expected %v but got %v", expected[i], estimate)
+		}
+	}
+}
+
+func TestEstimateSizeTarget(t *testing.T) {
+	content := `FROM registry.access.redhat.com/ubi9/ubi-minimal AS base
+RUN microdnf install -y shadow-utils && microdnf clean all
+FROM base AS builder
+RUN microdnf install -y gcc make && microdnf clean all
+FROM base
+USER 1001
+`
+	res, err := parser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %s", content, err)
+	}
+	node := res.AST
+	estimates := EstimateSize(context.Background(), node)
+	if len(estimates) != 1 || estimates[0].Line.Start != 2 {
+		t.Errorf("Expected the packages of the base stage only but got %v", estimates)
+	}
+	estimates = EstimateSize(WithTarget(context.Background(), "builder"), node)
+	if len(estimates) != 2 || estimates[1].Line.Start != 4 {
+		t.Errorf("Expected the packages of the builder and base stages but got %v", estimates)
+	}
+}
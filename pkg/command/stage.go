@@ -0,0 +1,179 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// Stage is a build stage, made of a FROM instruction and the instructions following it.
+type Stage struct {
+	// Index is the position of the stage in the Containerfile, starting from 0
+	Index int
+	Name  string
+	Image string
+}
+
+type stageKeyType struct{}
+type stagesKeyType struct{}
+type finalStageResultKeyType struct{}
+
+var stageKey stageKeyType
+
+// stagesKey holds the stages analyzed so far, to resolve the references to them
+var stagesKey stagesKeyType
+var finalStageResultKey finalStageResultKeyType
+
+// stageResult is a result which is only reported when found in the final stage
+type stageResult struct {
+	stage  int
+	result Result
+}
+
+// CurrentStage returns the stage of the instruction being analyzed, if any.
+func CurrentStage(ctx context.Context) (Stage, bool) {
+	stage, ok := ctx.Value(stageKey).(Stage)
+	return stage, ok
+}
+
+func withStage(ctx context.Context, image string, name string) context.Context {
+	index := 0
+	if previous, ok := CurrentStage(ctx); ok {
+		index = previous.Index + 1
+	}
+	stage := Stage{
+		Index: index,
+		Name:  name,
+		Image: image,
+	}
+	previous, _ := ctx.Value(stagesKey).([]Stage)
+	ctx = context.WithValue(ctx, stagesKey, append(append([]Stage{}, previous...), stage))
+	return context.WithValue(ctx, stageKey, stage)
+}
+
+// stageByReference returns the previous stage referenced by name or by index, e.g. by
+// COPY --from=builder or COPY --from=0.
+func stageByReference(ctx context.Context, reference string) (Stage, bool) {
+	stages, _ := ctx.Value(stagesKey).([]Stage)
+	index, err := strconv.Atoi(reference)
+	for _, stage := range stages {
+		if (err == nil && stage.Index == index) || (stage.Name != "" && strings.EqualFold(stage.Name, reference)) {
+			return stage, true
+		}
+	}
+	return Stage{}, false
+}
+
+// appendFinalStageResults stores results which only have to be reported if the current stage
+// turns out to be the final one.
+func appendFinalStageResults(ctx context.Context, results ...Result) context.Context {
+	if len(results) == 0 {
+		return ctx
+	}
+	stage, _ := CurrentStage(ctx)
+	previous, _ := ctx.Value(finalStageResultKey).([]stageResult)
+	stored := append([]stageResult{}, previous...)
+	for _, result := range results {
+		stored = append(stored, stageResult{stage: stage.Index, result: result})
+	}
+	return context.WithValue(ctx, finalStageResultKey, stored)
+}
+
+// finalStageResults returns the results stored by appendFinalStageResults for the final stage,
+// i.e. the current stage once all the instructions have been analyzed.
+func finalStageResults(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	stored, _ := ctx.Value(finalStageResultKey).([]stageResult)
+	var results []Result
+	for _, s := range stored {
+		if s.stage == stage.Index {
+			results = append(results, s.result)
+		}
+	}
+	return results
+}
+
+// astStage is a build stage of the syntax tree, for the checks running before or outside the
+// analysis of the instructions, e.g. the runtime detection
+type astStage struct {
+	name  string
+	image string
+	// from is the FROM instruction of the stage
+	from         *parser.Node
+	instructions []*parser.Node
+}
+
+// astStages splits the instructions of the syntax tree into stages, dropping the global ones.
+func astStages(node *parser.Node) []astStage {
+	var stages []astStage
+	if node == nil {
+		return stages
+	}
+	for _, child := range node.Children {
+		if strings.EqualFold(child.Value, "FROM") {
+			stage := astStage{from: child}
+			if child.Next != nil {
+				stage.image = child.Next.Value
+				if as := child.Next.Next; as != nil && strings.EqualFold(as.Value, "AS") && as.Next != nil {
+					stage.name = as.Next.Value
+				}
+			}
+			stages = append(stages, stage)
+		} else if len(stages) > 0 {
+			stages[len(stages)-1].instructions = append(stages[len(stages)-1].instructions, child)
+		}
+	}
+	return stages
+}
+
+// findASTStage returns the stage referenced by name or by index.
+func findASTStage(stages []astStage, reference string) (astStage, bool) {
+	index, ok := findASTStageIndex(stages, reference)
+	if !ok {
+		return astStage{}, false
+	}
+	return stages[index], true
+}
+
+func findASTStageIndex(stages []astStage, reference string) (int, bool) {
+	for i, stage := range stages {
+		if (stage.name != "" && strings.EqualFold(stage.name, reference)) || fmt.Sprint(i) == reference {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// stageChain returns the stage followed by the stages it is based on, the last one being based on
+// an image.
+func stageChain(stages []astStage, stage astStage) []astStage {
+	chain := []astStage{stage}
+	for len(chain) <= len(stages) {
+		parent, ok := findASTStage(stages, chain[len(chain)-1].image)
+		if !ok {
+			break
+		}
+		chain = append(chain, parent)
+	}
+	return chain
+}
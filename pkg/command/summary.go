@@ -0,0 +1,67 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+type Verdict string
+
+const (
+	VerdictPassed Verdict = "passed"
+	VerdictFailed Verdict = "failed"
+)
+
+type Summary struct {
+	Total      int                    `json:"total"`
+	Failed     int                    `json:"failed"`
+	BySeverity map[ResultSeverity]int `json:"bySeverity"`
+	Verdict    Verdict                `json:"verdict"`
+	// Runtime is the runtime detected from the Containerfile, see DetectRuntime
+	Runtime Runtime `json:"runtime,omitempty"`
+}
+
+// Summarize counts the failed results by severity. The verdict is failed as soon as
+// one result has failed.
+func Summarize(results []Result) Summary {
+	return SummarizeFailingOn(results, SeverityLow)
+}
+
+// SummarizeFailingOn counts the failed results by severity. The verdict is failed as soon as
+// one result at least as severe as failOn has failed, the results of the advisory rules being
+// only counted.
+func SummarizeFailingOn(results []Result, failOn ResultSeverity) Summary {
+	summary := Summary{
+		Total: len(results),
+		BySeverity: map[ResultSeverity]int{
+			SeverityCritical: 0,
+			SeverityHigh:     0,
+			SeverityMedium:   0,
+			SeverityLow:      0,
+		},
+		Verdict: VerdictPassed,
+	}
+	for _, result := range results {
+		if result.Status != StatusFailed {
+			continue
+		}
+		summary.Failed++
+		summary.BySeverity[result.Severity]++
+		if result.Severity.AtLeast(failOn) && !IsAdvisory(result) {
+			summary.Verdict = VerdictFailed
+		}
+	}
+	return summary
+}
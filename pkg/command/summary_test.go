@@ -0,0 +1,76 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import "testing"
+
+func TestSummarizeWithoutResultsPasses(t *testing.T) {
+	summary := Summarize(nil)
+	if summary.Verdict != VerdictPassed {
+		t.Errorf("Expected verdict to be %s but it was %s", VerdictPassed, summary.Verdict)
+	}
+}
+
+func TestSummarizeCountsFailedResultsBySeverity(t *testing.T) {
+	summary := Summarize([]Result{
+		{Name: "a", Status: StatusFailed, Severity: SeverityHigh},
+		{Name: "b", Status: StatusFailed, Severity: SeverityHigh},
+		{Name: "c", Status: StatusFailed, Severity: SeverityLow},
+		{Name: "d", Status: StatusPass, Severity: SeverityCritical},
+	})
+	if summary.Verdict != VerdictFailed {
+		t.Errorf("Expected verdict to be %s but it was %s", VerdictFailed, summary.Verdict)
+	}
+	if summary.Failed != 3 {
+		t.Errorf("Expected 3 failed results but they were %d", summary.Failed)
+	}
+	if summary.BySeverity[SeverityHigh] != 2 || summary.BySeverity[SeverityCritical] != 0 {
+		t.Errorf("Unexpected count by severity %v", summary.BySeverity)
+	}
+}
+
+func TestSummarizeFailingOnIgnoresLessSevereResults(t *testing.T) {
+	results := []Result{
+		{Name: "a", Status: StatusFailed, Severity: SeverityMedium},
+		{Name: "b", Status: StatusPass, Severity: SeverityCritical},
+	}
+	if summary := SummarizeFailingOn(results, SeverityHigh); summary.Verdict != VerdictPassed || summary.Failed != 1 {
+		t.Errorf("Unexpected summary %v", summary)
+	}
+	if summary := SummarizeFailingOn(results, SeverityMedium); summary.Verdict != VerdictFailed {
+		t.Errorf("Expected verdict to be %s but it was %s", VerdictFailed, summary.Verdict)
+	}
+}
+
+func TestFilterByConfidenceDropsLessConfidentResults(t *testing.T) {
+	results := FilterByConfidence([]Result{
+		{Name: "a", Status: StatusFailed, Confidence: ConfidenceHigh},
+		{Name: "b", Status: StatusFailed, Confidence: ConfidenceMedium},
+		{Name: "c", Status: StatusFailed, Confidence: ConfidenceLow},
+		{Name: "d", Status: StatusFailed},
+	}, ConfidenceMedium)
+	if len(results) != 3 || results[0].Name != "a" || results[1].Name != "b" || results[2].Name != "d" {
+		t.Errorf("Unexpected filtered results %v", results)
+	}
+}
+
+func TestParseConfidenceRejectsUnknownValues(t *testing.T) {
+	if _, err := ParseConfidence("certain"); err == nil {
+		t.Errorf("Expected an error for an unknown confidence")
+	}
+}
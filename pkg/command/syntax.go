@@ -0,0 +1,151 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// DOCKERFILE_FRONTEND is the image of the official Dockerfile frontend set by # syntax=
+const DOCKERFILE_FRONTEND = "docker/dockerfile"
+
+// heredocRegexp matches <<EOF and <<-"EOF" but not the <<< here-strings
+var heredocRegexp = regexp.MustCompile(`(^|[^<])<<-?\s*["']?[A-Za-z_]\w*["']?`)
+
+// syntaxFeature is a Dockerfile feature which is only available from a version of the frontend
+type syntaxFeature struct {
+	name    string
+	version []int
+	used    func(node *parser.Node) bool
+}
+
+var syntaxFeatures = []syntaxFeature{
+	{"RUN --mount", []int{1, 2}, func(node *parser.Node) bool {
+		return strings.EqualFold(node.Value, "run") && hasFlag(node, "mount")
+	}},
+	{"RUN --network", []int{1, 3}, func(node *parser.Node) bool {
+		return strings.EqualFold(node.Value, "run") && hasFlag(node, "network")
+	}},
+	{"heredocs", []int{1, 4}, func(node *parser.Node) bool {
+		instruction := strings.ToLower(node.Value)
+		return (instruction == "run" || instruction == "copy" || instruction == "add") && (len(node.Heredocs) > 0 || heredocRegexp.MatchString(node.Original))
+	}},
+}
+
+func hasFlag(node *parser.Node, name string) bool {
+	for _, flag := range node.Flags {
+		if strings.HasPrefix(flag, "--"+name+"=") || flag == "--"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// frontendVersion returns the version of the docker/dockerfile frontend set by the syntax
+// directive, e.g. [1 4] for docker/dockerfile:1.4, and false for another frontend.
+func frontendVersion(syntax string) (string, []int, bool) {
+	syntax = strings.SplitN(syntax, "@", 2)[0]
+	image, tag := syntax, ""
+	if index := strings.LastIndex(syntax, ":"); index > strings.LastIndex(syntax, "/") {
+		image, tag = syntax[:index], syntax[index+1:]
+	}
+	image = strings.TrimPrefix(strings.TrimPrefix(image, "docker.io/"), "index.docker.io/")
+	if image != DOCKERFILE_FRONTEND {
+		return tag, nil, false
+	}
+	var version []int
+	for _, part := range strings.Split(strings.SplitN(tag, "-", 2)[0], ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		version = append(version, number)
+	}
+	return tag, version, true
+}
+
+// supports reports whether the version of the frontend supports the feature, a version
+// without minor, e.g. 1, being the latest of its major version.
+func supports(version []int, required []int) bool {
+	for i := range required {
+		if i >= len(version) {
+			return true
+		}
+		if version[i] != required[i] {
+			return version[i] > required[i]
+		}
+	}
+	return true
+}
+
+/*
+# syntax=docker/dockerfile:labs
+RUN --mount=type=cache,target=/root/.m2 mvn package
+COPY <<EOF /etc/app.conf
+*/
+func analyzeSyntax(ctx context.Context, content []byte, ast *parser.Node, source utils.Source) []Result {
+	if dialectOf(ctx) == DialectPodman {
+		// Buildah ignores the syntax directive, it supports the features natively
+		return nil
+	}
+	var results []Result
+	syntax, _, location, found := parser.DetectSyntax(content)
+	var version []int
+	official := false
+	if found {
+		line := Line{}
+		if len(location) > 0 {
+			line = Line{Start: location[0].Start.Line, End: location[0].End.Line}
+		}
+		var tag string
+		tag, version, official = frontendVersion(syntax)
+		if official && (tag == "" || tag == "latest" || strings.Contains(tag, "labs")) {
+			results = append(results, RuleSyntaxDirective.Failed(i18n.Sprintf(ctx, `syntax directive %s %s uses an unpinned channel of the Dockerfile frontend, the build could break when a new frontend is released. Pin a version, e.g. # syntax=docker/dockerfile:1.7`,
+				syntax, GenerateErrorLocation(ctx, source, line))).At(source, line))
+		}
+		if !official {
+			// the features of other frontends are unknown
+			return results
+		}
+	}
+	for _, feature := range syntaxFeatures {
+		for _, node := range ast.Children {
+			if !feature.used(node) {
+				continue
+			}
+			line := Line{Start: node.StartLine, End: node.EndLine}
+			if !found {
+				results = append(results, RuleSyntaxDirective.Failed(i18n.Sprintf(ctx, `%s %s is used without a syntax directive. Builders without BuildKit, e.g. OpenShift Docker strategy builds with older Buildah versions, fail on it, set # syntax=docker/dockerfile:1.%d or later`,
+					feature.name, GenerateErrorLocation(ctx, source, line), feature.version[1])).At(source, line))
+			} else if len(version) > 0 && !supports(version, feature.version) {
+				results = append(results, RuleSyntaxDirective.Failed(i18n.Sprintf(ctx, `%s %s requires the Dockerfile frontend 1.%d or later but the syntax directive sets %s`,
+					feature.name, GenerateErrorLocation(ctx, source, line), feature.version[1], syntax)).At(source, line))
+			}
+			// one result per feature is enough
+			break
+		}
+	}
+	return results
+}
@@ -0,0 +1,67 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFailIfSyntaxIsUnpinned(t *testing.T) {
+	for _, syntax := range []string{"docker/dockerfile", "docker/dockerfile:latest", "docker/dockerfile:labs", "docker.io/docker/dockerfile:1-labs"} {
+		results := resultsOfRule(analyzeFile(t, "# syntax="+syntax+"\nFROM scratch\nUSER 1001\n"), RuleSyntaxDirective)
+		if len(results) != 1 || results[0].Line == nil || results[0].Line.Start != 1 {
+			t.Errorf("Expected a %s suggestion at line 1 for %s but they were %v", RuleSyntaxDirective.ID, syntax, results)
+		}
+	}
+}
+
+func TestPinnedSyntax(t *testing.T) {
+	for _, syntax := range []string{"docker/dockerfile:1", "docker/dockerfile:1.7", "docker/dockerfile:1.4.3@sha256:9ba7531bd80fb0a858632727cf7a112fbfd19b17e94c4e84ced81e24ef1a0dbc", "example.com/custom/frontend:latest"} {
+		results := resultsOfRule(analyzeFile(t, "# syntax="+syntax+"\nFROM scratch\nRUN --mount=type=cache,target=/root/.m2 mvn package\nUSER 1001\n"), RuleSyntaxDirective)
+		if len(results) != 0 {
+			t.Errorf("Expected no %s suggestion for %s but they were %v", RuleSyntaxDirective.ID, syntax, results)
+		}
+	}
+}
+
+func TestFailIfMountIsUsedWithoutSyntax(t *testing.T) {
+	results := resultsOfRule(analyzeFile(t, "FROM scratch\nRUN --mount=type=secret,id=token make\nRUN --mount=type=cache,target=/cache make\nUSER 1001\n"), RuleSyntaxDirective)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Errorf("Expected a %s suggestion at line 2 but they were %v", RuleSyntaxDirective.ID, results)
+	}
+}
+
+func TestFailIfHeredocIsNotSupportedBySyntax(t *testing.T) {
+	results := resultsOfRule(analyzeFile(t, "# syntax=docker/dockerfile:1.3\nFROM scratch\nRUN cat <<EOF > /etc/app.conf\nport=8080\nEOF\nUSER 1001\n"), RuleSyntaxDirective)
+	if len(results) != 1 || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleSyntaxDirective.ID, results)
+	}
+}
+
+func TestHereStringIsNotAHeredoc(t *testing.T) {
+	results := resultsOfRule(analyzeFile(t, "FROM scratch\nRUN grep -q x <<< \"$VALUE\"\nUSER 1001\n"), RuleSyntaxDirective)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion but they were %v", RuleSyntaxDirective.ID, results)
+	}
+}
+
+func analyzeFile(t *testing.T, content string) []Result {
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(content))
+	return results
+}
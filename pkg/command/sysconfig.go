@@ -0,0 +1,51 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// systemConfigWrites change the timezone or the locale by writing to system paths, which only
+// root can do
+var systemConfigWrites = []*regexp.Regexp{
+	regexp.MustCompile(`\bln\s+(-\w+\s+)*\S*/zoneinfo/\S+\s+/etc/localtime`),
+	regexp.MustCompile(`>\s*/etc/(timezone|localtime|locale\.gen|default/locale)\b`),
+	regexp.MustCompile(`\b(locale-gen|localedef|update-locale|timedatectl)\b`),
+	regexp.MustCompile(`\bdpkg-reconfigure\s+(-\w+\s+)*(tzdata|locales)\b`),
+}
+
+/*
+ENTRYPOINT ["sh", "-c", "ln -sf /usr/share/zoneinfo/$TZ /etc/localtime && exec java -jar app.jar"]
+CMD locale-gen en_US.UTF-8 && ./start.sh
+*/
+func analyzeSystemConfigWrites(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	for _, re := range systemConfigWrites {
+		match := re.FindString(s)
+		if match == "" {
+			continue
+		}
+		return []Result{RuleRuntimeSystemConfig.Failed(i18n.Sprintf(ctx, `'%s' run by %s %s writes to system paths when the container starts. It fails on OpenShift where containers are run using arbitrarily assigned user ID, do it in a RUN instruction or set the TZ environment variable instead`,
+			match, instruction, GenerateErrorLocation(ctx, source, line))).At(source, line)}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import "testing"
+
+func TestFailIfTimezoneOrLocaleSetAtStartup(t *testing.T) {
+	for _, instruction := range []string{
+		`ENTRYPOINT ["sh", "-c", "ln -sf /usr/share/zoneinfo/$TZ /etc/localtime && exec java -jar app.jar"]`,
+		"CMD echo $TZ > /etc/timezone && ./start.sh",
+		"CMD locale-gen en_US.UTF-8 && ./start.sh",
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleRuntimeSystemConfig)
+			if len(results) != 1 {
+				t.Errorf("Expected a %s suggestion but they were %v", RuleRuntimeSystemConfig.ID, results)
+			}
+		})
+	}
+}
+
+func TestTimezoneSetAtBuildTimeIsNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nRUN ln -sf /usr/share/zoneinfo/Europe/Rome /etc/localtime\nUSER 1001\n"), RuleRuntimeSystemConfig)
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
@@ -0,0 +1,120 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+type targetKeyType struct{}
+
+var targetKey targetKeyType
+
+// WithTarget restricts the analysis to a stage, as docker build --target does: the stage is
+// analyzed as the final one and only the stages it depends on are analyzed with it.
+func WithTarget(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, targetKey, stage)
+}
+
+func targetOf(ctx context.Context) (string, bool) {
+	target, ok := ctx.Value(targetKey).(string)
+	return target, ok && target != ""
+}
+
+// targetAST returns the syntax tree built for the target stage: the global instructions, the
+// stages the target depends on through FROM, COPY --from or RUN --mount=from, and the target. It
+// returns false when no stage is named target.
+func targetAST(node *parser.Node, target string) (*parser.Node, bool) {
+	stages := astStages(node)
+	index := -1
+	for i, stage := range stages {
+		if stage.name != "" && strings.EqualFold(stage.name, target) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, false
+	}
+
+	reachable := map[int]bool{}
+	pending := []int{index}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if reachable[current] {
+			continue
+		}
+		reachable[current] = true
+		for _, reference := range stageDependencies(stages[current]) {
+			// a stage can only depend on the stages defined before it
+			if dependency, ok := findASTStageIndex(stages, reference); ok && dependency < current {
+				pending = append(pending, dependency)
+			}
+		}
+	}
+
+	kept := map[*parser.Node]bool{}
+	for i := range reachable {
+		kept[stages[i].from] = true
+		for _, instruction := range stages[i].instructions {
+			kept[instruction] = true
+		}
+	}
+	pruned := *node
+	pruned.Children = nil
+	inStage := false
+	for _, child := range node.Children {
+		if strings.EqualFold(child.Value, "FROM") {
+			inStage = true
+		}
+		if !inStage || kept[child] {
+			pruned.Children = append(pruned.Children, child)
+		}
+	}
+	return &pruned, true
+}
+
+// stageDependencies returns the references of the stage to the other stages: its base image,
+// COPY --from and RUN --mount=from.
+func stageDependencies(stage astStage) []string {
+	references := []string{stage.image}
+	for _, instruction := range stage.instructions {
+		switch strings.ToLower(instruction.Value) {
+		case "copy":
+			if from, ok := instructionFlagValue(instruction, "from"); ok {
+				references = append(references, from)
+			}
+		case "run":
+			for _, flag := range instruction.Flags {
+				if !strings.HasPrefix(flag, "--mount=") {
+					continue
+				}
+				for _, option := range strings.Split(strings.TrimPrefix(flag, "--mount="), ",") {
+					if strings.HasPrefix(option, "from=") {
+						references = append(references, strings.TrimPrefix(option, "from="))
+					}
+				}
+			}
+		}
+	}
+	return references
+}
@@ -0,0 +1,85 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+)
+
+const multiTargetContent = `FROM golang:1.22 AS builder
+RUN go build -o /app .
+
+FROM builder AS test
+RUN go test ./...
+EXPOSE 8080
+
+FROM registry.access.redhat.com/ubi9/ubi-minimal AS release
+COPY --from=builder /app /app
+USER 1001
+EXPOSE 80
+`
+
+func TestTargetAST(t *testing.T) {
+	res, _, err := parse([]byte("ARG VERSION=1\nFROM alpine AS deps\nRUN apk add curl\nFROM alpine AS unused\nRUN true\nFROM alpine AS build\nRUN --mount=type=cache,target=/cache,from=deps make\nFROM build AS test\nRUN make test\nFROM alpine\nCOPY --from=build /out /out\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	ast, ok := targetAST(res.AST, "TEST")
+	if !ok {
+		t.Fatalf("Expected the test stage to be found")
+	}
+	var lines []int
+	for _, child := range ast.Children {
+		lines = append(lines, child.StartLine)
+	}
+	expected := []int{1, 2, 3, 6, 7, 8, 9}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected the lines %v but they were %v", expected, lines)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Fatalf("Expected the lines %v but they were %v", expected, lines)
+		}
+	}
+	if _, ok := targetAST(res.AST, "release"); ok {
+		t.Errorf("Expected the release stage not to be found")
+	}
+}
+
+func TestAnalyzeTarget(t *testing.T) {
+	_, results := parseAndAnalyze(context.Background(), "Containerfile", []byte(multiTargetContent))
+	if len(resultsOfRule(results, RulePrivilegedPort)) != 1 {
+		t.Errorf("Expected the release stage to be analyzed but the results were %v", results)
+	}
+
+	_, results = parseAndAnalyze(WithTarget(context.Background(), "test"), "Containerfile", []byte(multiTargetContent))
+	for _, result := range results {
+		if result.Line != nil && result.Line.Start > 6 {
+			t.Errorf("Expected the release stage not to be analyzed but got %v", result)
+		}
+	}
+	if len(resultsOfRule(results, RulePrivilegedPort)) != 0 {
+		t.Errorf("Expected the release stage not to be analyzed but the results were %v", results)
+	}
+
+	ast, results := parseAndAnalyze(WithTarget(context.Background(), "missing"), "Containerfile", []byte(multiTargetContent))
+	if ast != nil || len(results) != 1 || results[0].Severity != SeverityCritical {
+		t.Errorf("Expected an analyze error for a missing target but the results were %v", results)
+	}
+}
@@ -0,0 +1,91 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// keytoolImportRegexp matches the keytool commands importing certificates or keystores
+var keytoolImportRegexp = regexp.MustCompile(`\bkeytool\s+[^;&|\n]*-import(?:cert|keystore)?\b[^;&|\n]*`)
+
+// systemKeystoreRegexp matches the options of keytool selecting the truststore of the JVM or of
+// the system, owned by root
+var systemKeystoreRegexp = regexp.MustCompile(`-cacerts\b|-(?:dest)?keystore\s+["']?(?:\S*cacerts\b|\$\{?JAVA_HOME\}?/|/usr/lib/jvm/|/opt/java/|/etc/pki/|/etc/ssl/)`)
+
+// userKeystoreRegexp matches the options of keytool selecting a keystore
+var userKeystoreRegexp = regexp.MustCompile(`-(?:dest)?keystore\s`)
+
+// caTrustRegexp matches the updates of the certificate authorities of the system
+var caTrustRegexp = regexp.MustCompile(`\b(?:update-ca-trust|update-ca-certificates|trust\s+anchor)\b`)
+
+/*
+ENTRYPOINT ["sh", "-c", "keytool -importcert -noprompt -cacerts -storepass changeit -file /certs/ca.crt && exec java -jar app.jar"]
+CMD update-ca-trust && exec ./server
+*/
+func analyzeTruststoreImport(ctx context.Context, instruction string, s string, source utils.Source, line Line) []Result {
+	var results []Result
+	for _, command := range keytoolImportRegexp.FindAllString(s, -1) {
+		command = strings.TrimSpace(command)
+		confidence := ConfidenceHigh
+		if !systemKeystoreRegexp.MatchString(command) {
+			if userKeystoreRegexp.MatchString(command) {
+				// a keystore chosen by the application, e.g. in /tmp or in a volume
+				continue
+			}
+			// the default keystore is $HOME/.keystore, HOME being / for the arbitrary UID
+			confidence = ConfidenceMedium
+		}
+		result := RuleRuntimeTruststoreImport.Failed(i18n.Sprintf(ctx, `'%s' run by %s %s imports certificates into the truststore of the JVM when the container starts. It is owned by root and fails on OpenShift where containers are run using arbitrarily assigned user ID: import them at build time, or into a copy of cacerts in a writable directory set with -Djavax.net.ssl.trustStore`,
+			command, instruction, GenerateErrorLocation(ctx, source, line))).At(source, line)
+		result.Confidence = confidence
+		results = append(results, result)
+	}
+	if match := caTrustRegexp.FindString(s); match != "" {
+		results = append(results, RuleRuntimeTruststoreImport.Failed(i18n.Sprintf(ctx, `'%s' run by %s %s updates the certificate authorities of the system when the container starts, which only root can do. Add the certificates at build time, or mount them and point the application to them (e.g. SSL_CERT_FILE)`,
+			match, instruction, GenerateErrorLocation(ctx, source, line))).At(source, line))
+	}
+	return results
+}
+
+/*
+COPY entrypoint.sh /entrypoint.sh
+ENTRYPOINT ["/entrypoint.sh"]
+*/
+func analyzeStartScriptTruststoreImport(ctx context.Context) []Result {
+	entrypoints, cmds := finalStartCommands(ctx)
+	commands := append(entrypoints, cmds...)
+	if len(commands) == 0 {
+		return nil
+	}
+	command := commands[len(commands)-1]
+	if len(entrypoints) > 0 {
+		command = entrypoints[len(entrypoints)-1]
+	}
+	rel, content, ok := startScript(ctx, command)
+	if !ok {
+		return nil
+	}
+	return analyzeTruststoreImport(ctx, rel, string(content), command.source, command.line)
+}
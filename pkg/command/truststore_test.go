@@ -0,0 +1,57 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfTruststoreIsImportedAtStartup(t *testing.T) {
+	for instruction, confidence := range map[string]ResultConfidence{
+		`ENTRYPOINT ["sh", "-c", "keytool -importcert -noprompt -cacerts -storepass changeit -file /certs/ca.crt && exec java -jar app.jar"]`:                   ConfidenceHigh,
+		`CMD keytool -import -trustcacerts -keystore $JAVA_HOME/lib/security/cacerts -storepass changeit -file /certs/ca.crt -noprompt; exec java -jar app.jar`: ConfidenceHigh,
+		`CMD keytool -importcert -alias ca -file /certs/ca.crt -noprompt && exec java -jar app.jar`:                                                             ConfidenceMedium,
+		`CMD update-ca-trust && exec ./server`: ConfidenceHigh,
+	} {
+		t.Run(instruction, func(t *testing.T) {
+			results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER 1001\n"+instruction+"\n"), RuleRuntimeTruststoreImport)
+			if len(results) != 1 || results[0].Confidence != confidence || results[0].Line.Start != 3 {
+				t.Errorf("Expected a %s confidence %s finding at line 3 but they were %v", confidence, RuleRuntimeTruststoreImport.ID, results)
+			}
+		})
+	}
+}
+
+func TestBuildTimeOrWritableTruststoreIsNotReported(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nRUN keytool -importcert -noprompt -cacerts -storepass changeit -file /tmp/ca.crt\nUSER 1001\n"+
+		"CMD cp $JAVA_HOME/lib/security/cacerts /tmp/truststore && keytool -importcert -noprompt -keystore /tmp/truststore -storepass changeit -file /certs/ca.crt && exec java -Djavax.net.ssl.trustStore=/tmp/truststore -jar app.jar\n"), RuleRuntimeTruststoreImport)
+	if len(results) != 0 {
+		t.Errorf("Expected no finding but they were %v", results)
+	}
+}
+
+func TestTruststoreImportOfStartScript(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"entrypoint.sh": "#!/bin/sh\nfor cert in /certs/*.crt; do\n  keytool -importcert -noprompt -cacerts -storepass changeit -alias \"$cert\" -file \"$cert\"\ndone\nexec java -jar app.jar\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM scratch\nCOPY entrypoint.sh /entrypoint.sh\nUSER 1001\nENTRYPOINT [\"/entrypoint.sh\"]\n"), RuleRuntimeTruststoreImport)
+	if len(results) != 1 || !strings.HasPrefix(results[0].Description, "'keytool -importcert") || !strings.Contains(results[0].Description, "entrypoint.sh") {
+		t.Errorf("Expected a %s finding of entrypoint.sh but they were %v", RuleRuntimeTruststoreImport.ID, results)
+	}
+}
@@ -19,10 +19,11 @@
 
 import (
 	"context"
-	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
 	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
 )
 
@@ -30,38 +31,132 @@ type User struct{}
 
 type userResultKeyType struct{}
 type userProcessedKeyType struct{}
+type userUIDKeyType struct{}
+type createdUsersKeyType struct{}
+type userNameResultKeyType struct{}
+type userNotCreatedResultKeyType struct{}
 
 var userResultKey userResultKeyType
 var userProcessedKey userProcessedKeyType
 
+// userNotCreatedResultKey holds the results of every USER instruction set to a user which is not
+// created, the instructions following it fail to run
+var userNotCreatedResultKey userNotCreatedResultKeyType
+
+// userUIDKey holds the numeric UID set by the last USER instruction, if any
+var userUIDKey userUIDKeyType
+
+// userNameResultKey holds the run-as-non-root result of the last USER instruction, nil when it
+// sets a numeric UID or root
+var userNameResultKey userNameResultKeyType
+
+// createdUsersKey holds the set of users created by the RUN instructions analyzed so far
+var createdUsersKey createdUsersKeyType
+
+// knownUsers are defined by the common base images, they don't need to be created
+var knownUsers = map[string]bool{
+	"root":          true,
+	"nobody":        true,
+	"daemon":        true,
+	"bin":           true,
+	"sys":           true,
+	"www-data":      true,
+	"apache":        true,
+	"nginx":         true,
+	"node":          true,
+	"postgres":      true,
+	"mysql":         true,
+	"redis":         true,
+	"mongodb":       true,
+	"rabbitmq":      true,
+	"elasticsearch": true,
+	"jenkins":       true,
+	"jboss":         true,
+	"default":       true,
+}
+
+// MIN_REGULAR_UID is the first UID which is not reserved to system users
+const MIN_REGULAR_UID = 1000
+
+// Analyze checks the user set by USER. Only the last USER instruction sets the user of the
+// container, so its root and UID results replace the ones of the previous instructions, e.g. the
+// root user installing the packages before the privileges are dropped.
 func (u User) Analyze(ctx context.Context, node *parser.Node, source utils.Source, line Line) context.Context {
 	var results []Result
-	if strings.EqualFold(node.Value, "root") {
-		results = append(results, Result{
-			Name:        "User set to root",
-			Status:      StatusFailed,
-			Severity:    SeverityMedium,
-			Description: fmt.Sprintf(`USER directive set to root %s could cause an unexpected behavior. In OpenShift, containers are run using arbitrarily assigned user ID`, GenerateErrorLocation(source, line)),
-		})
+	// USER <user>[:<group>]
+	user := strings.SplitN(node.Value, ":", 2)[0]
+	uid, err := strconv.Atoi(user)
+	if strings.EqualFold(user, "root") || (err == nil && uid == 0) {
+		results = append(results, RuleUserRoot.Failed(
+			i18n.Sprintf(ctx, `USER directive set to root %s could cause an unexpected behavior. In OpenShift, containers are run using arbitrarily assigned user ID`, GenerateErrorLocation(ctx, source, line)),
+		).At(source, line))
+	} else if err == nil && uid < MIN_REGULAR_UID {
+		results = append(results, RuleUserLowUID.Failed(
+			i18n.Sprintf(ctx, `USER directive set to UID %d %s which is below %d. OpenShift ignores the UID of the image under the restricted SCC and runs the container with a UID assigned from the namespace range`, uid, GenerateErrorLocation(ctx, source, line), MIN_REGULAR_UID),
+		).At(source, line))
+	} else if err != nil && user != "" && !strings.HasPrefix(user, "$") && !knownUsers[user] && !isCreatedUser(ctx, user) {
+		ctx = appendResults(ctx, userNotCreatedResultKey, RuleUserNotCreated.Failed(
+			i18n.Sprintf(ctx, `USER directive set to %s %s refers to a user which is not created in the Containerfile. The container could fail to start with "unable to find user %s", use a numeric UID instead`, user, GenerateErrorLocation(ctx, source, line), user),
+		).At(source, line))
+	} else if reportsPassed(ctx) && user != "" {
+		results = append(results, RuleUserRoot.Passed(
+			i18n.Sprintf(ctx, `USER directive set to the non-root user %s %s`, user, GenerateErrorLocation(ctx, source, line)),
+		).At(source, line))
+	}
+	var nameResult *Result
+	if err != nil && user != "" && !strings.HasPrefix(user, "$") && !strings.EqualFold(user, "root") {
+		result := RuleRunAsNonRoot.Failed(
+			i18n.Sprintf(ctx, `USER directive set to the user name %s %s. Kubernetes can't verify that the user is not root, pods with runAsNonRoot: true fail to start, use a numeric UID instead`, user, GenerateErrorLocation(ctx, source, line)),
+		).At(source, line)
+		nameResult = &result
+	}
+	ctx = context.WithValue(ctx, userNameResultKey, nameResult)
+	if err == nil {
+		ctx = context.WithValue(ctx, userUIDKey, user)
+	} else {
+		ctx = context.WithValue(ctx, userUIDKey, "")
 	}
 	ctx = context.WithValue(ctx, userResultKey, results)
 	return context.WithValue(ctx, userProcessedKey, true)
 }
 
+func isCreatedUser(ctx context.Context, user string) bool {
+	users, _ := ctx.Value(createdUsersKey).(map[string]bool)
+	return users[user]
+}
+
+// withCreatedUsers records users created by a RUN instruction. The set is copied as the parent
+// contexts must not see the new users.
+func withCreatedUsers(ctx context.Context, created []string) context.Context {
+	if len(created) == 0 {
+		return ctx
+	}
+	previous, _ := ctx.Value(createdUsersKey).(map[string]bool)
+	users := map[string]bool{}
+	for user := range previous {
+		users[user] = true
+	}
+	for _, user := range created {
+		users[user] = true
+	}
+	return context.WithValue(ctx, createdUsersKey, users)
+}
+
 func (u User) PostProcess(ctx context.Context) []Result {
 	processed := ctx.Value(userProcessedKey)
 	res := ctx.Value(userResultKey)
-	var results []Result
+	results := append([]Result{}, storedResults(ctx, userNotCreatedResultKey)...)
 	if res != nil {
-		results = res.([]Result)
+		results = append(results, res.([]Result)...)
 	}
 	if processed == nil {
-		results = append(results, Result{
-			Name:        "User set to root",
-			Status:      StatusFailed,
-			Severity:    SeverityMedium,
-			Description: fmt.Sprintf("USER directive implicitely set to root could cause an unexpected behavior. In OpenShift, containers are run using arbitrarily assigned user ID"),
-		})
+		results = append(results, RuleUserRoot.Failed(
+			i18n.Sprintf(ctx, "USER directive implicitely set to root could cause an unexpected behavior. In OpenShift, containers are run using arbitrarily assigned user ID"),
+		))
+	}
+	// only the last USER instruction sets the user of the container
+	if result, _ := ctx.Value(userNameResultKey).(*Result); result != nil && platformOf(ctx) == PlatformKubernetes {
+		results = append(results, *result)
 	}
 	return results
 
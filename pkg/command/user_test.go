@@ -0,0 +1,131 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+func analyzeUsers(users ...string) (context.Context, []Result) {
+	user := User{}
+	ctx := context.Background()
+	for i, value := range users {
+		ctx = user.Analyze(ctx, &parser.Node{Value: value}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: i + 1, End: i + 1})
+	}
+	return ctx, user.PostProcess(ctx)
+}
+
+func TestUserWithRegularUID(t *testing.T) {
+	_, results := analyzeUsers("1001")
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %d", len(results))
+	}
+}
+
+func TestUserWithRegularUIDReportsPassedCheck(t *testing.T) {
+	user := User{}
+	ctx := user.Analyze(WithPassedResults(context.Background()), &parser.Node{Value: "1001"}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 2, End: 2})
+	results := user.PostProcess(ctx)
+	if len(results) != 1 || results[0].RuleID != RuleUserRoot.ID || results[0].Status != StatusPass {
+		t.Errorf("Expected a passed %s result but they were %v", RuleUserRoot.ID, results)
+	}
+	if Summarize(results).Verdict != VerdictPassed {
+		t.Errorf("Expected passed results not to fail the verdict")
+	}
+}
+
+func TestFailIfUserWithUIDZero(t *testing.T) {
+	_, results := analyzeUsers("0:0")
+	if len(results) != 1 || results[0].RuleID != RuleUserRoot.ID {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleUserRoot.ID, results)
+	}
+}
+
+func TestFailIfUserWithSystemUID(t *testing.T) {
+	_, results := analyzeUsers("101")
+	if len(results) != 1 || results[0].RuleID != RuleUserLowUID.ID {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleUserLowUID.ID, results)
+	}
+}
+
+func TestOnlyTheLastUserInstructionIsReported(t *testing.T) {
+	_, results := analyzeUsers("root", "1001", "101")
+	if len(results) != 1 || results[0].RuleID != RuleUserLowUID.ID || results[0].Line.Start != 3 {
+		t.Errorf("Expected a %s suggestion at line 3 but they were %v", RuleUserLowUID.ID, results)
+	}
+}
+
+func TestRootDroppedToNonRootUser(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch\nUSER root\nRUN chmod -R g=u /app\nUSER 1001\n"), RuleUserRoot)
+	if len(results) != 0 {
+		t.Errorf("Expected no %s suggestion but they were %v", RuleUserRoot.ID, results)
+	}
+}
+
+func TestFailIfChownCommandBoundToUserUID(t *testing.T) {
+	ctx, _ := analyzeUsers("1001")
+	run := Run{}
+	ctx = run.Analyze(ctx, &parser.Node{Value: "chown -R 1001 /app"}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 2, End: 2})
+	results := run.PostProcess(ctx)
+	if len(results) != 1 || results[0].RuleID != RuleOwnershipBoundToUID.ID {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleOwnershipBoundToUID.ID, results)
+	}
+}
+
+func TestChownCommandWithUserUIDAndRootGroup(t *testing.T) {
+	ctx, _ := analyzeUsers("1001")
+	run := Run{}
+	ctx = run.Analyze(ctx, &parser.Node{Value: "chown -R 1001:0 /app"}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 2, End: 2})
+	if results := run.PostProcess(ctx); len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestFailIfUserNotCreated(t *testing.T) {
+	_, results := analyzeUsers("appuser")
+	if len(results) != 1 || results[0].RuleID != RuleUserNotCreated.ID {
+		t.Errorf("Expected a %s suggestion but they were %v", RuleUserNotCreated.ID, results)
+	}
+}
+
+func TestUserKnownByBaseImages(t *testing.T) {
+	_, results := analyzeUsers("node:node")
+	if len(results) != 0 {
+		t.Errorf("Expected no suggestions but they were %v", results)
+	}
+}
+
+func TestUserCreatedByRunInstruction(t *testing.T) {
+	for _, cmd := range []string{
+		"useradd -u 1001 -g 0 -m appuser",
+		`apt-get update && adduser --disabled-password --gecos "" appuser`,
+		"adduser -D -u 1001 appuser; chown -R appuser:0 /app",
+	} {
+		t.Run(cmd, func(t *testing.T) {
+			ctx := Run{}.Analyze(context.Background(), &parser.Node{Value: cmd}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 1, End: 1})
+			ctx = User{}.Analyze(ctx, &parser.Node{Value: "appuser"}, utils.Source{Name: "test", Type: utils.Image}, Line{Start: 2, End: 2})
+			if results := (User{}).PostProcess(ctx); len(results) != 0 {
+				t.Errorf("Expected no suggestions but they were %v", results)
+			}
+		})
+	}
+}
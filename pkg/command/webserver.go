@@ -0,0 +1,245 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package command
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/utils"
+)
+
+// webServer describes the configuration of nginx or httpd and how their OpenShift compatible
+// variants run them as a non-root user
+type webServer struct {
+	name string
+	// image matches the official image of the server, which runs it as root
+	image *regexp.Regexp
+	// files are the names of the main configuration files, dirs the directories the other
+	// configuration files are copied to
+	files  []string
+	dirs   []string
+	checks []webServerCheck
+	// variants are the images running the server as a non-root user
+	variants string
+}
+
+// webServerCheck is a directive of the configuration which needs root, re captures its value
+type webServerCheck struct {
+	re      *regexp.Regexp
+	problem string
+	fix     string
+	// port checks report the ports below 1024, path checks the paths outside /tmp and the volumes
+	port bool
+	path bool
+}
+
+type webServerImagesKeyType struct{}
+
+// webServerImagesKey holds the stages based on the official nginx or httpd images
+var webServerImagesKey webServerImagesKeyType
+
+type webServerImage struct {
+	server *webServer
+	image  string
+	stage  int
+	source utils.Source
+	line   Line
+}
+
+var webServers = []*webServer{
+	{
+		name:  "nginx",
+		image: regexp.MustCompile(`^(?:docker\.io/)?(?:library/)?nginx(?::|@|$)`),
+		files: []string{"nginx.conf"},
+		dirs:  []string{"/etc/nginx"},
+		checks: []webServerCheck{
+			{re: regexp.MustCompile(`(?mi)^\s*listen\s+(?:\S*:)?(\d+)`), problem: "listens on port %s", fix: "listen 8080;", port: true},
+			{re: regexp.MustCompile(`(?mi)^\s*pid\s+(/[^\s;]+)`), problem: "writes the PID file to %s", fix: "pid /tmp/nginx.pid;", path: true},
+			{re: regexp.MustCompile(`(?mi)^\s*(?:client_body|proxy|fastcgi|uwsgi|scgi)_temp_path\s+(/[^\s;]+)`), problem: "keeps temporary files in %s", fix: "client_body_temp_path /tmp/client_temp; (and the proxy, fastcgi, uwsgi and scgi temp paths)", path: true},
+			{re: regexp.MustCompile(`(?mi)^\s*user\s+([^\s;]+)`), problem: "sets the user directive to %s", fix: "remove the user directive"},
+		},
+		variants: "docker.io/nginxinc/nginx-unprivileged or registry.access.redhat.com/ubi9/nginx-122",
+	},
+	{
+		name:  "httpd",
+		image: regexp.MustCompile(`^(?:docker\.io/)?(?:library/)?httpd(?::|@|$)`),
+		files: []string{"httpd.conf", "apache2.conf"},
+		dirs:  []string{"/etc/httpd", "/etc/apache2", "/usr/local/apache2/conf"},
+		checks: []webServerCheck{
+			{re: regexp.MustCompile(`(?mi)^\s*Listen\s+(?:\S*:)?(\d+)`), problem: "listens on port %s", fix: "Listen 8080", port: true},
+			{re: regexp.MustCompile(`(?mi)^\s*PidFile\s+"?(/[^\s"]+)`), problem: "writes the PID file to %s", fix: "PidFile /tmp/httpd.pid", path: true},
+			{re: regexp.MustCompile(`(?mi)^\s*(?:DefaultRuntimeDir|CacheRoot)\s+"?(/[^\s"]+)`), problem: "keeps runtime files in %s", fix: "DefaultRuntimeDir /tmp", path: true},
+			{re: regexp.MustCompile(`(?mi)^\s*(?:User|Group)\s+(\S+)`), problem: "switches to the user or group %s", fix: "remove the User and Group directives"},
+		},
+		variants: "registry.access.redhat.com/ubi9/httpd-24",
+	},
+}
+
+// configuredBy returns the server configured by the file copied to destination, if any.
+func configuredBy(name string, destination string) (*webServer, bool) {
+	for _, server := range webServers {
+		for _, file := range server.files {
+			if name == file {
+				return server, true
+			}
+		}
+		if path.Ext(name) != ".conf" {
+			continue
+		}
+		for _, dir := range server.dirs {
+			if destination == dir || strings.HasPrefix(destination, dir+"/") {
+				return server, true
+			}
+		}
+	}
+	return nil, false
+}
+
+/*
+COPY nginx.conf /etc/nginx/nginx.conf
+COPY default.conf /etc/nginx/conf.d/
+COPY httpd.conf /etc/httpd/conf/httpd.conf
+*/
+func analyzeWebServerConfigs(ctx context.Context, node *parser.Node, source utils.Source, line Line) []Result {
+	dir, ok := buildContext(ctx)
+	sources := copySources(node)
+	if !ok || len(sources) == 0 {
+		return nil
+	}
+	stage, _ := CurrentStage(ctx)
+	volumes, _ := ctx.Value(volumesKey).(map[int][]string)
+	var destination string
+	for n := node; n != nil; n = n.Next {
+		destination = path.Clean(n.Value)
+	}
+	var results []Result
+	for _, src := range sources {
+		if strings.ContainsAny(src, "*?[") {
+			continue
+		}
+		filepath.Walk(filepath.Join(dir, filepath.FromSlash(src)), func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			server, ok := configuredBy(info.Name(), destination)
+			if !ok {
+				return nil
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil
+			}
+			var problems, fixes []string
+			fixed := map[string]bool{}
+			for _, check := range server.checks {
+				for _, match := range check.re.FindAllStringSubmatch(string(content), -1) {
+					if port, err := strconv.Atoi(match[1]); check.port && (err != nil || port >= 1024) {
+						continue
+					}
+					if check.path && isWritable(path.Clean(match[1]), volumes[stage.Index]) {
+						continue
+					}
+					problems = append(problems, i18n.Sprintf(ctx, check.problem, match[1]))
+					if !fixed[check.fix] {
+						fixed[check.fix] = true
+						fixes = append(fixes, check.fix)
+					}
+				}
+			}
+			if len(problems) == 0 {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, file)
+			results = append(results, RuleWebServerNonRoot.Failed(i18n.Sprintf(ctx, `the %s configuration %s copied %s needs root: it %s. Adapt it like the non-root images of %s (%s): %s`,
+				server.name, filepath.ToSlash(rel), GenerateErrorLocation(ctx, source, line), strings.Join(problems, ", "), server.name, server.variants, strings.Join(fixes, ", "))).At(source, line))
+			return nil
+		})
+	}
+	return results
+}
+
+// withWebServerImage records the stage based on the official nginx or httpd image.
+func withWebServerImage(ctx context.Context, image string, source utils.Source, line Line) context.Context {
+	for _, server := range webServers {
+		if !server.image.MatchString(image) {
+			continue
+		}
+		stage, _ := CurrentStage(ctx)
+		previous, _ := ctx.Value(webServerImagesKey).([]webServerImage)
+		return context.WithValue(ctx, webServerImagesKey, append(append([]webServerImage{}, previous...), webServerImage{
+			server: server,
+			image:  image,
+			stage:  stage.Index,
+			source: source,
+			line:   line,
+		}))
+	}
+	return ctx
+}
+
+/*
+FROM nginx:1.25
+COPY html /usr/share/nginx/html
+*/
+func analyzeWebServerImage(ctx context.Context) []Result {
+	stage, _ := CurrentStage(ctx)
+	images, _ := ctx.Value(webServerImagesKey).([]webServerImage)
+	copied, _ := ctx.Value(copiedFilesKey).([]copiedFiles)
+	var results []Result
+	for _, image := range images {
+		if image.stage != stage.Index || configCopied(image.server, copied, stage.Index) {
+			continue
+		}
+		results = append(results, RuleWebServerNonRoot.Failed(i18n.Sprintf(ctx, `the final stage is based on the official %s image %s %s, which runs %s as root: it listens on port 80 and writes to directories owned by root. Use %s, which run it as a non-root user on port 8080, or copy a configuration adapted to a non-root user`,
+			image.server.name, image.image, GenerateErrorLocation(ctx, image.source, image.line), image.server.name, image.server.variants)).At(image.source, image.line))
+	}
+	return results
+}
+
+// configCopied reports whether a configuration of the server is copied into the stage: a main
+// configuration file or files copied to its configuration directories.
+func configCopied(server *webServer, copied []copiedFiles, stage int) bool {
+	for _, files := range copied {
+		if files.stage != stage {
+			continue
+		}
+		destination := path.Clean(files.destination)
+		for _, dir := range server.dirs {
+			if destination == dir || strings.HasPrefix(destination, dir+"/") {
+				return true
+			}
+		}
+		for _, src := range append([]string{destination}, files.sources...) {
+			for _, file := range server.files {
+				if path.Base(src) == file {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailIfNginxConfigNeedsRoot(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"nginx.conf": "user nginx;\npid /var/run/nginx.pid;\nhttp {\n  client_body_temp_path /var/cache/nginx/client_temp;\n  server {\n    listen 80;\n  }\n}\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM registry.access.redhat.com/ubi9/nginx-122\nCOPY nginx.conf /etc/nginx/nginx.conf\nUSER 1001\n"), RuleWebServerNonRoot)
+	if len(results) != 1 || results[0].Line.Start != 2 {
+		t.Fatalf("Expected a %s finding at line 2 but they were %v", RuleWebServerNonRoot.ID, results)
+	}
+	for _, expected := range []string{"port 80", "/var/run/nginx.pid", "/var/cache/nginx/client_temp", "user directive", "listen 8080;", "pid /tmp/nginx.pid;"} {
+		if !strings.Contains(results[0].Description, expected) {
+			t.Errorf("Expected the finding to mention %s but it was %s", expected, results[0].Description)
+		}
+	}
+}
+
+func TestAdaptedWebServerConfigIsNotReported(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"default.conf": "server {\n  listen 8080;\n}\n",
+		"httpd.conf":   "Listen 8080\nPidFile /tmp/httpd.pid\n",
+	})
+	for _, containerfile := range []string{
+		"FROM nginx:1.25\nCOPY default.conf /etc/nginx/conf.d/default.conf\nUSER 1001\n",
+		"FROM httpd:2.4\nCOPY httpd.conf /usr/local/apache2/conf/httpd.conf\nUSER 1001\n",
+	} {
+		if results := resultsOfRule(analyzeInContext(dir, containerfile), RuleWebServerNonRoot); len(results) != 0 {
+			t.Errorf("Expected no finding but they were %v", results)
+		}
+	}
+}
+
+func TestFailIfHttpdConfigNeedsRoot(t *testing.T) {
+	dir := buildContextDir(t, map[string]string{
+		"conf/httpd.conf": "Listen 443\nUser apache\nGroup apache\nPidFile \"/run/httpd/httpd.pid\"\n",
+	})
+	results := resultsOfRule(analyzeInContext(dir, "FROM registry.access.redhat.com/ubi9/httpd-24\nCOPY conf /etc/httpd/conf\nUSER 1001\n"), RuleWebServerNonRoot)
+	if len(results) != 1 || !strings.Contains(results[0].Description, "the httpd configuration conf/httpd.conf") || !strings.Contains(results[0].Description, "remove the User and Group directives") {
+		t.Errorf("Expected a %s finding of conf/httpd.conf but they were %v", RuleWebServerNonRoot.ID, results)
+	}
+}
+
+func TestFailIfFinalStageIsOfficialWebServerImage(t *testing.T) {
+	results := resultsOfRule(analyzeContent(t, "FROM scratch AS build\nFROM docker.io/library/httpd:2.4\nCOPY html /usr/local/apache2/htdocs/\n"), RuleWebServerNonRoot)
+	if len(results) != 1 || results[0].Line.Start != 2 || !strings.Contains(results[0].Description, "ubi9/httpd-24") {
+		t.Errorf("Expected a %s finding at line 2 but they were %v", RuleWebServerNonRoot.ID, results)
+	}
+	if results := resultsOfRule(analyzeContent(t, "FROM nginx AS build\nFROM nginxinc/nginx-unprivileged:1.25\n"), RuleWebServerNonRoot); len(results) != 0 {
+		t.Errorf("Expected no finding but they were %v", results)
+	}
+}
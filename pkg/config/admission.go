@@ -0,0 +1,94 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The admission modes of the namespaces, see Admission
+const (
+	// ADMISSION_ENFORCE rejects the images without a valid analysis attestation
+	ADMISSION_ENFORCE = "enforce"
+	// ADMISSION_WARN admits them with a warning
+	ADMISSION_WARN = "warn"
+	// ADMISSION_IGNORE doesn't verify the images
+	ADMISSION_IGNORE = "ignore"
+)
+
+// Admission is the policy of the admission webhook of doa serve, verifying the analysis
+// attestations of the images run in the cluster, e.g.
+//
+//	admission:
+//	  min-score: 80
+//	  mode: warn
+//	  namespaces:
+//	    production: enforce
+//	    kube-system: ignore
+//
+// The images of the namespaces without mode are verified with the default mode, enforce when unset.
+type Admission struct {
+	// MinScore is the least score of the analysis of the admitted images
+	MinScore int `yaml:"min-score,omitempty"`
+	// Mode is the default admission mode: enforce, warn or ignore
+	Mode string `yaml:"mode,omitempty"`
+	// Namespaces override the admission mode of the namespaces, keyed by namespace
+	Namespaces map[string]string `yaml:"namespaces,omitempty"`
+}
+
+// ModeOf returns the admission mode of the namespace.
+func (a *Admission) ModeOf(namespace string) string {
+	if mode, ok := a.Namespaces[namespace]; ok {
+		return mode
+	}
+	if a.Mode == "" {
+		return ADMISSION_ENFORCE
+	}
+	return a.Mode
+}
+
+func (a *Admission) validate() error {
+	if a.MinScore < 0 || a.MinScore > 100 {
+		return errors.Errorf("invalid admission min-score %d, expected a score from 0 to 100", a.MinScore)
+	}
+	mode, err := parseAdmissionMode(a.Mode)
+	if err != nil {
+		return err
+	}
+	a.Mode = mode
+	for namespace, value := range a.Namespaces {
+		if value == "" {
+			return errors.Errorf("no admission mode for namespace %s", namespace)
+		}
+		if a.Namespaces[namespace], err = parseAdmissionMode(value); err != nil {
+			return errors.Wrapf(err, "invalid admission mode for namespace %s", namespace)
+		}
+	}
+	return nil
+}
+
+func parseAdmissionMode(value string) (string, error) {
+	switch mode := strings.ToLower(value); mode {
+	case "", ADMISSION_ENFORCE, ADMISSION_WARN, ADMISSION_IGNORE:
+		return mode, nil
+	}
+	return "", errors.Errorf("unknown admission mode %q, expected enforce, warn or ignore", value)
+}
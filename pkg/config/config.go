@@ -0,0 +1,293 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package config loads the .doa.yaml configuration file of a project, which customizes the
+// rules checked by doa, e.g.
+//
+//	extends: https://config.example.com/doa/openshift.yaml
+//	rules:
+//	  unpinned-packages:
+//	    severity: medium
+//	  network-capability:
+//	    disabled: true
+//	images:
+//	  quay.io/org/web: web/Containerfile
+//	custom-rules:
+//	  - id: debug-enabled
+//	    expression: instruction == "ENV" && value.matches("DEBUG=true")
+//	    message: the debug mode is enabled in the image
+//	    severity: medium
+//	fail-on: high
+//	platform: kubernetes
+//	packs:
+//	  database:
+//	    enabled: false
+//	  java:
+//	    severity: low
+//	projects:
+//	  - name: web
+//	    path: services/web
+//	    owners: ["@org/web-team"]
+//	notifications:
+//	  - type: slack
+//	    url: ${SLACK_WEBHOOK_URL}
+//	    severity: high
+ package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const DEFAULT_FILE = ".doa.yaml"
+
+type RuleConfig struct {
+	// Severity overrides the severity of the findings of the rule
+	Severity analyzer.ResultSeverity `yaml:"severity,omitempty"`
+	// Disabled rules are not reported
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// PackConfig configures a rule pack, see analyzer.Pack.
+type PackConfig struct {
+	// Enabled forces the pack on or off, it's detected from the Containerfile when unset
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Severity overrides the severity of the findings of the rules of the pack
+	Severity analyzer.ResultSeverity `yaml:"severity,omitempty"`
+}
+
+type Config struct {
+	// Extends are the configurations this one inherits from, see Extends
+	Extends Extends `yaml:"extends,omitempty"`
+	// Rules is keyed by rule ID
+	Rules map[string]RuleConfig `yaml:"rules,omitempty"`
+	// Images maps the images deployed by the manifests, without tag or digest, to the
+	// Containerfile they are built from, relative to the configuration file
+	Images map[string]string `yaml:"images,omitempty"`
+	// CustomRules are checked along with the built-in rules, see CustomRule
+	CustomRules []CustomRule `yaml:"custom-rules,omitempty"`
+	// Projects map the subdirectories of a monorepo to projects, see doa workspace
+	Projects []Project `yaml:"projects,omitempty"`
+	// FailOn is the least severe failed finding failing the verdict, low by default
+	FailOn analyzer.ResultSeverity `yaml:"fail-on,omitempty"`
+	// Lock is only read in the organization policies, see Enforce
+	Lock *Lock `yaml:"lock,omitempty"`
+	// Platform is the platform the images are deployed to, openshift by default
+	Platform analyzer.Platform `yaml:"platform,omitempty"`
+	// Notifications post a findings summary to chat channels, see Notification
+	Notifications []Notification `yaml:"notifications,omitempty"`
+	// Packs enable, disable or change the severity of the rule packs, keyed by pack name
+	Packs map[string]PackConfig `yaml:"packs,omitempty"`
+	// ExitCodes map the severities to the exit code of doa analyze when a finding of the severity
+	// fails, see ExitCode
+	ExitCodes map[analyzer.ResultSeverity]int `yaml:"exit-codes,omitempty"`
+	// Admission is the policy of the admission webhook of doa serve, see Admission
+	Admission *Admission `yaml:"admission,omitempty"`
+}
+
+// MAX_EXIT_CODE is the highest exit code, the greater ones being reserved by the shells
+const MAX_EXIT_CODE = 125
+
+var severities = map[analyzer.ResultSeverity]bool{
+	analyzer.SeverityCritical: true,
+	analyzer.SeverityHigh:     true,
+	analyzer.SeverityMedium:   true,
+	analyzer.SeverityLow:      true,
+}
+
+// Load reads the configuration file at path, merged on top of the configurations it extends. A
+// missing file is an empty configuration.
+func Load(path string) (*Config, error) {
+	return LoadWith(path, Fetch)
+}
+
+// Parse parses and validates the configuration, merged on top of the configurations it extends,
+// name is only used to report errors and to resolve the relative extended files.
+func Parse(bytes []byte, name string) (*Config, error) {
+	return ParseWith(bytes, name, Fetch)
+}
+
+func (c *Config) Validate() error {
+	custom := map[string]bool{}
+	for _, rule := range c.CustomRules {
+		if custom[rule.ID] {
+			return errors.Errorf("custom rule %s is defined twice", rule.ID)
+		}
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		custom[rule.ID] = true
+	}
+	for id, rule := range c.Rules {
+		if _, ok := analyzer.FindRule(id); !ok && !custom[id] {
+			return errors.Errorf("unknown rule %s", id)
+		}
+		if rule.Severity != "" && !severities[analyzer.ResultSeverity(strings.ToLower(string(rule.Severity)))] {
+			return errors.Errorf("unknown severity %s for rule %s, expected one of critical, high, medium, low", rule.Severity, id)
+		}
+	}
+	for name, pack := range c.Packs {
+		if _, ok := analyzer.FindPack(name); !ok {
+			return errors.Errorf("unknown pack %s, expected one of %s", name, strings.Join(analyzer.PackNames(), ", "))
+		}
+		if pack.Severity != "" && !severities[analyzer.ResultSeverity(strings.ToLower(string(pack.Severity)))] {
+			return errors.Errorf("unknown severity %s for pack %s, expected one of critical, high, medium, low", pack.Severity, name)
+		}
+	}
+	projects := map[string]bool{}
+	for _, project := range c.Projects {
+		if project.Name == "" || project.Path == "" {
+			return errors.New("projects must have a name and a path")
+		}
+		if projects[project.Name] {
+			return errors.Errorf("project %s is defined twice", project.Name)
+		}
+		projects[project.Name] = true
+	}
+	if c.FailOn != "" {
+		if _, err := analyzer.ParseSeverity(string(c.FailOn)); err != nil {
+			return errors.Wrap(err, "invalid fail-on")
+		}
+	}
+	if c.Platform != "" {
+		platform, err := analyzer.ParsePlatform(string(c.Platform))
+		if err != nil {
+			return errors.Wrap(err, "invalid platform")
+		}
+		c.Platform = platform
+	}
+	for i := range c.Notifications {
+		if err := c.Notifications[i].validate(); err != nil {
+			return err
+		}
+	}
+	if len(c.ExitCodes) > 0 {
+		codes := map[analyzer.ResultSeverity]int{}
+		for severity, code := range c.ExitCodes {
+			parsed, err := analyzer.ParseSeverity(string(severity))
+			if err != nil {
+				return errors.Wrap(err, "invalid exit-codes")
+			}
+			if code < 0 || code > MAX_EXIT_CODE {
+				return errors.Errorf("invalid exit code %d for severity %s, expected a code from 0 to %d", code, severity, MAX_EXIT_CODE)
+			}
+			codes[parsed] = code
+		}
+		c.ExitCodes = codes
+	}
+	if c.Admission != nil {
+		if err := c.Admission.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Lock != nil {
+		return c.Lock.validate(custom)
+	}
+	return nil
+}
+
+// Project is a subdirectory of a monorepo holding the Containerfiles of a service.
+type Project struct {
+	Name string `yaml:"name"`
+	// Path is relative to the root of the workspace
+	Path string `yaml:"path"`
+	// Owners default to the owners of the Containerfiles in the CODEOWNERS file
+	Owners []string `yaml:"owners,omitempty"`
+}
+
+// FailOnSeverity returns the least severe failed finding failing the verdict.
+func (c *Config) FailOnSeverity() analyzer.ResultSeverity {
+	if c.FailOn == "" {
+		return analyzer.SeverityLow
+	}
+	return analyzer.ResultSeverity(strings.ToLower(string(c.FailOn)))
+}
+
+// ExitCode returns the highest exit code of the severities of the failed results, e.g. with
+//
+//	exit-codes:
+//	  critical: 3
+//	  medium: 2
+//	  low: 0
+//
+// a critical finding exits with 3 and a medium one with 2. The severities without exit code exit
+// with 1 when they fail the verdict, 0 otherwise.
+func (c *Config) ExitCode(results []analyzer.Result) int {
+	code := 0
+	failOn := c.FailOnSeverity()
+	for _, result := range results {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		severityCode, ok := c.ExitCodes[result.Severity]
+		if !ok && result.Severity.AtLeast(failOn) {
+			severityCode = 1
+		}
+		if severityCode > code {
+			code = severityCode
+		}
+	}
+	return code
+}
+
+// Containerfile returns the Containerfile the image is built from, the image being looked up with
+// and then without its tag or digest.
+func (c *Config) Containerfile(image string) (string, bool) {
+	if file, ok := c.Images[image]; ok {
+		return file, true
+	}
+	repository := strings.SplitN(image, "@", 2)[0]
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		repository = repository[:index]
+	}
+	file, ok := c.Images[repository]
+	return file, ok
+}
+
+// EnabledPacks returns the packs forced on or off, see analyzer.WithPacks.
+func (c *Config) EnabledPacks() map[string]bool {
+	packs := map[string]bool{}
+	for name, pack := range c.Packs {
+		if pack.Enabled != nil {
+			packs[name] = *pack.Enabled
+		}
+	}
+	return packs
+}
+
+// Apply drops the results of the disabled rules and overrides the severity of the others, the
+// severity of a rule taking precedence over the one of its pack.
+func (c *Config) Apply(results []analyzer.Result) []analyzer.Result {
+	applied := []analyzer.Result{}
+	for _, result := range results {
+		rule, ok := c.Rules[result.RuleID]
+		if ok && rule.Disabled {
+			continue
+		}
+		if catalog, found := analyzer.FindRule(result.RuleID); found && c.Packs[catalog.Pack].Severity != "" {
+			result.Severity = analyzer.ResultSeverity(strings.ToLower(string(c.Packs[catalog.Pack].Severity)))
+		}
+		if ok && rule.Severity != "" {
+			result.Severity = analyzer.ResultSeverity(strings.ToLower(string(rule.Severity)))
+		}
+		applied = append(applied, result)
+	}
+	return applied
+}
@@ -0,0 +1,152 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestParseRejectsUnknownRules(t *testing.T) {
+	if _, err := Parse([]byte("rules:\n  unknown-rule:\n    severity: high\n"), "test"); err == nil {
+		t.Errorf("Expected an error for an unknown rule")
+	}
+}
+
+func TestParseRejectsUnknownSeverities(t *testing.T) {
+	if _, err := Parse([]byte("rules:\n  unpinned-packages:\n    severity: blocker\n"), "test"); err == nil {
+		t.Errorf("Expected an error for an unknown severity")
+	}
+}
+
+func TestApplyOverridesSeverityAndDropsDisabledRules(t *testing.T) {
+	config, err := Parse([]byte("rules:\n  unpinned-packages:\n    severity: High\n  network-capability:\n    disabled: true\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	results := config.Apply([]analyzer.Result{
+		analyzer.RuleUnpinnedPackages.Failed("unpinned"),
+		analyzer.RuleNetworkCapability.Failed("tcpdump"),
+		analyzer.RuleUserRoot.Failed("root"),
+	})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results but they were %d", len(results))
+	}
+	if results[0].Severity != analyzer.SeverityHigh {
+		t.Errorf("Expected severity %s but it was %s", analyzer.SeverityHigh, results[0].Severity)
+	}
+	if results[1].Severity != analyzer.RuleUserRoot.Severity {
+		t.Errorf("Expected severity %s but it was %s", analyzer.RuleUserRoot.Severity, results[1].Severity)
+	}
+}
+
+func TestContainerfileIgnoresTagAndDigest(t *testing.T) {
+	config, err := Parse([]byte("images:\n  quay.io/org/web: web/Containerfile\n  localhost:5000/api: api/Dockerfile\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	for image, expected := range map[string]string{
+		"quay.io/org/web:1.0":         "web/Containerfile",
+		"quay.io/org/web@sha256:abcd": "web/Containerfile",
+		"localhost:5000/api":          "api/Dockerfile",
+		"localhost:5000/api:latest":   "api/Dockerfile",
+		"quay.io/org/worker:1.0":      "",
+	} {
+		if file, _ := config.Containerfile(image); file != expected {
+			t.Errorf("Expected %q for %s but it was %q", expected, image, file)
+		}
+	}
+}
+
+func TestParseValidatesNotifications(t *testing.T) {
+	config, err := Parse([]byte("notifications:\n  - type: Teams\n    url: ${TEAMS_WEBHOOK_URL}\n    severity: HIGH\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if notification := config.Notifications[0]; notification.Type != NOTIFICATION_TEAMS || notification.Severity != analyzer.SeverityHigh {
+		t.Errorf("Unexpected notification %v", notification)
+	}
+	for _, invalid := range []string{"  - type: email\n    url: x\n", "  - type: slack\n", "  - type: slack\n    url: x\n    min-score: 120\n"} {
+		if _, err := Parse([]byte("notifications:\n"+invalid), "test"); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestAdmissionModes(t *testing.T) {
+	config, err := Parse([]byte("admission:\n  min-score: 80\n  namespaces:\n    production: Enforce\n    sandbox: warn\n    kube-system: ignore\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	for namespace, expected := range map[string]string{"production": ADMISSION_ENFORCE, "sandbox": ADMISSION_WARN, "kube-system": ADMISSION_IGNORE, "shop": ADMISSION_ENFORCE} {
+		if mode := config.Admission.ModeOf(namespace); mode != expected {
+			t.Errorf("Expected %s for namespace %s but got %s", expected, namespace, mode)
+		}
+	}
+	for _, invalid := range []string{"  mode: audit\n", "  min-score: -1\n", "  namespaces:\n    shop: reject\n"} {
+		if _, err := Parse([]byte("admission:\n"+invalid), "test"); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	config, err := Parse([]byte("fail-on: medium\nexit-codes:\n  Critical: 3\n  medium: 2\n  low: 0\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	critical := analyzer.RuleUserRoot.Failed("USER root")
+	critical.Severity = analyzer.SeverityCritical
+	high := analyzer.RuleUserRoot.Failed("USER root")
+	high.Severity = analyzer.SeverityHigh
+	low := analyzer.RuleUserRoot.Failed("USER root")
+	low.Severity = analyzer.SeverityLow
+	for expected, results := range map[int][]analyzer.Result{
+		0: {low, analyzer.RuleUserRoot.Passed("USER 1001")},
+		1: {high, low},
+		2: {analyzer.RuleUserRoot.Failed("USER root"), low},
+		3: {high, critical},
+	} {
+		if code := config.ExitCode(results); code != expected {
+			t.Errorf("Expected exit code %d but it was %d for %v", expected, code, results)
+		}
+	}
+	for _, invalid := range []string{"exit-codes:\n  blocker: 2\n", "exit-codes:\n  high: 256\n"} {
+		if _, err := Parse([]byte(invalid), "test"); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestPacksConfiguration(t *testing.T) {
+	if _, err := Parse([]byte("packs:\n  cobol:\n    enabled: true\n"), "test"); err == nil {
+		t.Errorf("Expected an error for an unknown pack")
+	}
+	config, err := Parse([]byte("packs:\n  database:\n    enabled: false\n  webserver:\n    severity: low\n  java: {}\n"), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if packs := config.EnabledPacks(); len(packs) != 1 || packs[analyzer.PACK_DATABASE] {
+		t.Errorf("Expected the database pack to be disabled but the packs were %v", packs)
+	}
+	results := config.Apply([]analyzer.Result{analyzer.RuleWebServerNonRoot.Failed("nginx"), analyzer.RuleUserRoot.Failed("root")})
+	if results[0].Severity != analyzer.SeverityLow || results[1].Severity != analyzer.RuleUserRoot.Severity {
+		t.Errorf("Expected the severity of the webserver pack to be low but the results were %v", results)
+	}
+}
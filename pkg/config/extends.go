@@ -0,0 +1,285 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package config
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// MAX_EXTENDS_DEPTH limits the chains of configurations extending each other
+const MAX_EXTENDS_DEPTH = 10
+
+// httpClient doesn't follow the redirects downgrading the download to plain http
+var httpClient = &http.Client{Timeout: 30 * time.Second, CheckRedirect: httpsRedirect}
+
+func httpsRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" {
+		return errors.Errorf("insecure redirect to %s", req.URL)
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// Extends are the configurations a configuration inherits from, e.g. the shared configuration of
+// an organization, its own settings being merged on top of them, see Merge. A single source can
+// be given as a string:
+//
+//	extends: https://config.example.com/doa/openshift.yaml
+//	rules:
+//	  unpinned-packages:
+//	    disabled: true
+//
+// The sources are files, relative to the extending configuration, http(s) URLs or, with
+// policy.Fetcher, OCI artifacts pushed by doa policy push, e.g. oci://ghcr.io/org/policy:v3.
+type Extends []string
+
+func (e *Extends) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Extends{value.Value}
+		return nil
+	}
+	var sources []string
+	if err := value.Decode(&sources); err != nil {
+		return err
+	}
+	*e = sources
+	return nil
+}
+
+// Fetcher returns the content of an extended configuration, source being resolved against the
+// extending configuration.
+type Fetcher func(source string) ([]byte, error)
+
+// Fetch reads the configuration files and downloads the https URLs. Plain http URLs are rejected
+// as the downloaded configuration could be tampered with on the way.
+func Fetch(source string) ([]byte, error) {
+	if !isURL(source) {
+		if strings.HasPrefix(source, "http://") {
+			return nil, errors.Errorf("insecure configuration source %s, use https", source)
+		}
+		if strings.Contains(source, "://") {
+			return nil, errors.Errorf("unsupported configuration source %s, expected a file or an https URL", source)
+		}
+		content, err := os.ReadFile(source)
+		return content, errors.Wrapf(err, "unable to read the configuration file %s", source)
+	}
+	resp, err := httpClient.Get(source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download the configuration %s", source)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to download the configuration %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "https://")
+}
+
+// LoadWith reads the configuration file at path as Load does, the configurations it extends being
+// fetched with fetch.
+func LoadWith(path string, fetch Fetcher) (*Config, error) {
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the configuration file %s", path)
+	}
+	return ParseWith(bytes, path, fetch)
+}
+
+// ParseWith parses the configuration as Parse does, the configurations it extends being fetched
+// with fetch. The configuration is validated once merged, so that it can refer to the custom
+// rules of the configurations it extends.
+func ParseWith(bytes []byte, name string, fetch Fetcher) (*Config, error) {
+	config, err := resolve(bytes, name, fetch, map[string]bool{name: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration file %s", name)
+	}
+	return config, nil
+}
+
+// resolve parses the configuration and merges it on top of the configurations it extends,
+// visited holding the sources of the chain to detect the cycles.
+func resolve(bytes []byte, name string, fetch Fetcher, visited map[string]bool) (*Config, error) {
+	config := &Config{}
+	if err := yaml.Unmarshal(bytes, config); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the configuration file %s", name)
+	}
+	if len(config.Extends) == 0 {
+		return config, nil
+	}
+	if len(visited) > MAX_EXTENDS_DEPTH {
+		return nil, errors.Errorf("%s extends more than %d configurations in a chain", name, MAX_EXTENDS_DEPTH)
+	}
+	merged := &Config{}
+	for _, source := range config.Extends {
+		source, err := resolveSource(source, name)
+		if err != nil {
+			return nil, err
+		}
+		if visited[source] {
+			return nil, errors.Errorf("%s extends %s, which extends it back", name, source)
+		}
+		content, err := fetch(source)
+		if err != nil {
+			return nil, err
+		}
+		visited[source] = true
+		extended, err := resolve(content, source, fetch, visited)
+		delete(visited, source)
+		if err != nil {
+			return nil, err
+		}
+		merged = merged.Merge(extended)
+	}
+	return merged.Merge(config), nil
+}
+
+// resolveSource returns the source relative to the configuration extending it. A remote
+// configuration, https or OCI, can only extend remote sources, so that it can't read the local
+// files.
+func resolveSource(source string, extending string) (string, error) {
+	remote := strings.Contains(extending, "://")
+	if strings.Contains(source, "://") || filepath.IsAbs(source) {
+		if remote && !isURL(source) && !strings.HasPrefix(source, "oci://") {
+			return "", errors.Errorf("%s can't extend %s, expected an https or oci source", extending, source)
+		}
+		return source, nil
+	}
+	if isURL(extending) {
+		base, err := url.Parse(extending)
+		if err != nil {
+			return "", err
+		}
+		relative, err := url.Parse(source)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid configuration source %s", source)
+		}
+		return base.ResolveReference(relative).String(), nil
+	}
+	if remote {
+		return "", errors.Errorf("%s can't extend the relative source %s", extending, source)
+	}
+	return filepath.Join(filepath.Dir(extending), filepath.FromSlash(source)), nil
+}
+
+// Merge returns the configuration with local merged on top of it: the settings of the rules and
+// of the packs and the images are merged by key, local winning, the custom rules and the
+// notifications are added, local redefining the custom rules of the same ID, and the other
+// settings of local replace the ones of the configuration when they are set.
+func (c *Config) Merge(local *Config) *Config {
+	merged := *c
+	merged.Extends = nil
+	merged.Rules = mergeRules(c.Rules, local.Rules)
+	merged.Images = mergeImages(c.Images, local.Images)
+	merged.Packs = mergePacks(c.Packs, local.Packs)
+
+	merged.CustomRules = nil
+	redefined := map[string]bool{}
+	for _, rule := range local.CustomRules {
+		redefined[rule.ID] = true
+	}
+	for _, rule := range c.CustomRules {
+		if !redefined[rule.ID] {
+			merged.CustomRules = append(merged.CustomRules, rule)
+		}
+	}
+	merged.CustomRules = append(merged.CustomRules, local.CustomRules...)
+	merged.Notifications = append(append([]Notification{}, c.Notifications...), local.Notifications...)
+
+	if len(local.Projects) > 0 {
+		merged.Projects = local.Projects
+	}
+	if local.FailOn != "" {
+		merged.FailOn = local.FailOn
+	}
+	if local.Lock != nil {
+		merged.Lock = local.Lock
+	}
+	if local.Platform != "" {
+		merged.Platform = local.Platform
+	}
+	if len(local.ExitCodes) > 0 {
+		merged.ExitCodes = local.ExitCodes
+	}
+	if local.Admission != nil {
+		merged.Admission = local.Admission
+	}
+	return &merged
+}
+
+func mergeRules(base map[string]RuleConfig, local map[string]RuleConfig) map[string]RuleConfig {
+	if len(base) == 0 && len(local) == 0 {
+		return nil
+	}
+	merged := map[string]RuleConfig{}
+	for id, rule := range base {
+		merged[id] = rule
+	}
+	for id, rule := range local {
+		merged[id] = rule
+	}
+	return merged
+}
+
+func mergeImages(base map[string]string, local map[string]string) map[string]string {
+	if len(base) == 0 && len(local) == 0 {
+		return nil
+	}
+	merged := map[string]string{}
+	for image, file := range base {
+		merged[image] = file
+	}
+	for image, file := range local {
+		merged[image] = file
+	}
+	return merged
+}
+
+func mergePacks(base map[string]PackConfig, local map[string]PackConfig) map[string]PackConfig {
+	if len(base) == 0 && len(local) == 0 {
+		return nil
+	}
+	merged := map[string]PackConfig{}
+	for name, pack := range base {
+		merged[name] = pack
+	}
+	for name, pack := range local {
+		merged[name] = pack
+	}
+	return merged
+}
@@ -0,0 +1,144 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const orgConfig = `custom-rules:
+  - id: debug-enabled
+    expression: instruction == "ENV" && value.matches("DEBUG=true")
+    message: the debug mode is enabled in the image
+    severity: medium
+rules:
+  unpinned-packages:
+    severity: high
+  sudo-su:
+    disabled: true
+fail-on: high
+notifications:
+  - type: slack
+    url: https://hooks.slack.com/services/org
+`
+
+func TestLoadMergesExtendedFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"shared/org.yaml":  orgConfig,
+		"shared/team.yaml": "extends: org.yaml\nplatform: kubernetes\n",
+		// the project configures the custom rule of the organization
+		DEFAULT_FILE: "extends: [shared/team.yaml]\nrules:\n  sudo-su:\n    severity: low\n  debug-enabled:\n    severity: low\nfail-on: medium\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := Load(filepath.Join(dir, DEFAULT_FILE))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cfg.Rules["unpinned-packages"].Severity != "high" || cfg.Rules["sudo-su"].Disabled || cfg.Rules["sudo-su"].Severity != "low" {
+		t.Errorf("Expected the rules of the project merged on top of the organization ones but got %v", cfg.Rules)
+	}
+	if len(cfg.CustomRules) != 1 || len(cfg.Notifications) != 1 || cfg.Extends != nil {
+		t.Errorf("Expected the custom rules and notifications of the organization but got %v", cfg)
+	}
+	if cfg.FailOn != "medium" || cfg.Platform != analyzer.PlatformKubernetes {
+		t.Errorf("Expected fail-on medium on kubernetes but got %s on %s", cfg.FailOn, cfg.Platform)
+	}
+}
+
+func TestParseExtendsURL(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/doa/openshift.yaml":
+			w.Write([]byte("extends: base.yaml\nfail-on: high\n"))
+		case "/doa/base.yaml":
+			w.Write([]byte(orgConfig))
+		case "/doa/redirect.yaml":
+			http.Redirect(w, r, "http://"+r.Host+"/doa/base.yaml", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(client *http.Client) { httpClient = client }(httpClient)
+	httpClient = server.Client()
+	httpClient.CheckRedirect = httpsRedirect
+	cfg, err := Parse([]byte("extends: "+server.URL+"/doa/openshift.yaml\n"), DEFAULT_FILE)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cfg.FailOn != "high" || cfg.Rules["unpinned-packages"].Severity != "high" {
+		t.Errorf("Unexpected configuration %v", cfg)
+	}
+	if _, err := Parse([]byte("extends: "+server.URL+"/doa/missing.yaml\n"), DEFAULT_FILE); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a download error but got %v", err)
+	}
+	if _, err := Parse([]byte("extends: http://example.com/doa/openshift.yaml\n"), DEFAULT_FILE); err == nil || !strings.Contains(err.Error(), "insecure") {
+		t.Errorf("Expected plain http to be rejected but got %v", err)
+	}
+	if _, err := Parse([]byte("extends: "+server.URL+"/doa/redirect.yaml\n"), DEFAULT_FILE); err == nil || !strings.Contains(err.Error(), "insecure") {
+		t.Errorf("Expected a redirect to plain http to be rejected but got %v", err)
+	}
+}
+
+func TestParseRejectsExtendsCycles(t *testing.T) {
+	fetch := func(source string) ([]byte, error) {
+		return []byte("extends: " + DEFAULT_FILE + "\n"), nil
+	}
+	if _, err := ParseWith([]byte("extends: shared.yaml\n"), DEFAULT_FILE, fetch); err == nil || !strings.Contains(err.Error(), "extends it back") {
+		t.Errorf("Expected a cycle error but got %v", err)
+	}
+	if _, err := Parse([]byte("extends: oci://ghcr.io/org/policy:v3\n"), DEFAULT_FILE); err == nil {
+		t.Errorf("Expected an error for an OCI source without fetcher")
+	}
+}
+
+func TestRemoteConfigurationCantExtendLocalFiles(t *testing.T) {
+	local := filepath.Join(t.TempDir(), "local.yaml")
+	if err := os.WriteFile(local, []byte(orgConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, remote := range []string{"https://config.example.com/doa/openshift.yaml", "oci://ghcr.io/org/policy:v3"} {
+		fetch := func(source string) ([]byte, error) {
+			if source == remote {
+				return []byte("extends: " + local + "\n"), nil
+			}
+			return Fetch(source)
+		}
+		if _, err := ParseWith([]byte("extends: "+remote+"\n"), DEFAULT_FILE, fetch); err == nil || !strings.Contains(err.Error(), "expected an https or oci source") {
+			t.Errorf("Expected %s to be rejected extending a local file but got %v", remote, err)
+		}
+	}
+	if source, err := resolveSource("base.yaml", "https://config.example.com/doa/openshift.yaml"); err != nil || source != "https://config.example.com/doa/base.yaml" {
+		t.Errorf("Expected the relative source to be resolved against the URL but got %s, %v", source, err)
+	}
+}
@@ -0,0 +1,216 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// Lock is the part of an organization policy the configuration of a project can't weaken, e.g.
+//
+//	lock:
+//	  rules:
+//	    - user-root
+//	    - privileged-port
+//	  fail-on: high
+type Lock struct {
+	// Rules can't be disabled or have their severity changed by the projects
+	Rules []string `yaml:"rules,omitempty"`
+	// FailOn is the least strict fail-on the projects can set
+	FailOn analyzer.ResultSeverity `yaml:"fail-on,omitempty"`
+}
+
+// Override is a setting of the configuration of a project ignored because it weakens the lock of
+// the policy.
+type Override struct {
+	// RuleID is empty for the settings not bound to a rule, e.g. fail-on
+	RuleID  string
+	Setting string
+}
+
+func (o Override) String() string {
+	if o.RuleID == "" {
+		return fmt.Sprintf("%s is locked by the policy", o.Setting)
+	}
+	return fmt.Sprintf("%s of rule %s is locked by the policy", o.Setting, o.RuleID)
+}
+
+// Result reports the attempted override as a finding.
+func (o Override) Result() analyzer.Result {
+	return analyzer.RulePolicyOverride.Failed(o.String() + ", the setting is ignored")
+}
+
+func (l *Lock) validate(custom map[string]bool) error {
+	for _, id := range l.Rules {
+		if _, ok := analyzer.FindRule(id); !ok && !custom[id] {
+			return errors.Errorf("unknown locked rule %s", id)
+		}
+	}
+	if l.FailOn != "" {
+		if _, err := analyzer.ParseSeverity(string(l.FailOn)); err != nil {
+			return errors.Wrap(err, "invalid locked fail-on")
+		}
+	}
+	return nil
+}
+
+// Locks reports whether the rule is locked.
+func (l *Lock) Locks(ruleID string) bool {
+	if l == nil {
+		return false
+	}
+	for _, id := range l.Rules {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// LocksPack reports whether a rule of the pack is locked.
+func (l *Lock) LocksPack(name string) bool {
+	if l == nil {
+		return false
+	}
+	for _, id := range l.Rules {
+		if rule, ok := analyzer.FindRule(id); ok && rule.Pack == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Enforce returns the configuration of the project applied on top of the policy, without the
+// settings weakening the lock of the policy, which are returned as overrides.
+func (c *Config) Enforce(project *Config) (*Config, []Override) {
+	enforced := &Config{
+		Rules:  map[string]RuleConfig{},
+		Images: map[string]string{},
+		FailOn: c.FailOn,
+		Lock:   c.Lock,
+	}
+	var overrides []Override
+	for id, rule := range c.Rules {
+		enforced.Rules[id] = rule
+	}
+	ids := make([]string, 0, len(project.Rules))
+	for id := range project.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rule := project.Rules[id]
+		if !c.Lock.Locks(id) {
+			enforced.Rules[id] = rule
+			continue
+		}
+		if rule.Disabled && !c.Rules[id].Disabled {
+			overrides = append(overrides, Override{RuleID: id, Setting: "disabled: true"})
+		}
+		if rule.Severity != "" && !strings.EqualFold(string(rule.Severity), string(c.Rules[id].Severity)) {
+			overrides = append(overrides, Override{RuleID: id, Setting: fmt.Sprintf("severity: %s", rule.Severity)})
+		}
+	}
+
+	for name, pack := range c.Packs {
+		if enforced.Packs == nil {
+			enforced.Packs = map[string]PackConfig{}
+		}
+		enforced.Packs[name] = pack
+	}
+	names := make([]string, 0, len(project.Packs))
+	for name := range project.Packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pack := project.Packs[name]
+		if c.Lock.LocksPack(name) {
+			// the pack of a locked rule can't be disabled nor have its severity changed
+			if pack.Enabled != nil && !*pack.Enabled {
+				overrides = append(overrides, Override{Setting: fmt.Sprintf("packs: %s: enabled: false", name)})
+			}
+			if pack.Severity != "" {
+				overrides = append(overrides, Override{Setting: fmt.Sprintf("packs: %s: severity: %s", name, pack.Severity)})
+			}
+			continue
+		}
+		if enforced.Packs == nil {
+			enforced.Packs = map[string]PackConfig{}
+		}
+		enforced.Packs[name] = pack
+	}
+
+	for image, file := range c.Images {
+		enforced.Images[image] = file
+	}
+	for image, file := range project.Images {
+		enforced.Images[image] = file
+	}
+
+	enforced.CustomRules = append(enforced.CustomRules, c.CustomRules...)
+	defined := map[string]bool{}
+	for _, rule := range c.CustomRules {
+		defined[rule.ID] = true
+	}
+	for _, rule := range project.CustomRules {
+		if defined[rule.ID] {
+			overrides = append(overrides, Override{RuleID: rule.ID, Setting: "custom rule definition"})
+			continue
+		}
+		enforced.CustomRules = append(enforced.CustomRules, rule)
+	}
+
+	if project.FailOn != "" {
+		enforced.FailOn = project.FailOn
+	}
+	exitCodes := c.ExitCodes
+	if len(project.ExitCodes) > 0 {
+		exitCodes = project.ExitCodes
+	}
+	for severity, code := range exitCodes {
+		if enforced.ExitCodes == nil {
+			enforced.ExitCodes = map[analyzer.ResultSeverity]int{}
+		}
+		enforced.ExitCodes[severity] = code
+	}
+	if c.Lock != nil && c.Lock.FailOn != "" {
+		locked := analyzer.ResultSeverity(strings.ToLower(string(c.Lock.FailOn)))
+		if !locked.AtLeast(enforced.FailOnSeverity()) {
+			if project.FailOn != "" {
+				overrides = append(overrides, Override{Setting: fmt.Sprintf("fail-on: %s", project.FailOn)})
+			}
+			enforced.FailOn = locked
+		}
+		// the findings failing the locked verdict can't exit with 0 either
+		for _, severity := range []analyzer.ResultSeverity{analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium, analyzer.SeverityLow} {
+			if code, ok := enforced.ExitCodes[severity]; ok && code == 0 && severity.AtLeast(locked) {
+				if len(project.ExitCodes) > 0 {
+					overrides = append(overrides, Override{Setting: fmt.Sprintf("exit-codes: %s: 0", severity)})
+				}
+				delete(enforced.ExitCodes, severity)
+			}
+		}
+	}
+	return enforced, overrides
+}
@@ -0,0 +1,124 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const lockingPolicy = `rules:
+  unpinned-packages:
+    severity: high
+lock:
+  rules:
+    - user-root
+    - unpinned-packages
+  fail-on: high
+`
+
+func TestEnforceIgnoresOverridesOfLockedSettings(t *testing.T) {
+	policy, err := Parse([]byte(lockingPolicy), "policy")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	project, err := Parse([]byte(`rules:
+  user-root:
+    disabled: true
+  unpinned-packages:
+    severity: low
+  network-capability:
+    disabled: true
+fail-on: critical
+`), "project")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	enforced, overrides := policy.Enforce(project)
+	if len(overrides) != 3 {
+		t.Fatalf("Expected 3 overrides but they were %v", overrides)
+	}
+	if overrides[0].RuleID != "unpinned-packages" || overrides[1].RuleID != "user-root" || overrides[2].Setting != "fail-on: critical" {
+		t.Errorf("Unexpected overrides %v", overrides)
+	}
+	if enforced.Rules["user-root"].Disabled || enforced.Rules["unpinned-packages"].Severity != analyzer.SeverityHigh {
+		t.Errorf("Expected the locked rules to keep the settings of the policy but they were %v", enforced.Rules)
+	}
+	if !enforced.Rules["network-capability"].Disabled {
+		t.Errorf("Expected the rules which are not locked to be configurable")
+	}
+	if enforced.FailOnSeverity() != analyzer.SeverityHigh {
+		t.Errorf("Expected fail-on %s but it was %s", analyzer.SeverityHigh, enforced.FailOnSeverity())
+	}
+}
+
+func TestEnforceKeepsStricterFailOn(t *testing.T) {
+	policy, err := Parse([]byte(lockingPolicy), "policy")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	enforced, overrides := policy.Enforce(&Config{FailOn: analyzer.SeverityMedium})
+	if len(overrides) != 0 || enforced.FailOnSeverity() != analyzer.SeverityMedium {
+		t.Errorf("Unexpected fail-on %s, overrides %v", enforced.FailOnSeverity(), overrides)
+	}
+}
+
+func TestParseRejectsUnknownLockedRules(t *testing.T) {
+	if _, err := Parse([]byte("lock:\n  rules:\n    - unknown-rule\n"), "test"); err == nil {
+		t.Errorf("Expected an error for an unknown locked rule")
+	}
+	if _, err := Parse([]byte("fail-on: blocker\n"), "test"); err == nil {
+		t.Errorf("Expected an error for an unknown fail-on")
+	}
+}
+
+func TestEnforceKeepsLockedFindingsFatal(t *testing.T) {
+	policy, err := Parse([]byte(lockingPolicy+"exit-codes:\n  critical: 3\n"), "policy")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	enforced, overrides := policy.Enforce(&Config{ExitCodes: map[analyzer.ResultSeverity]int{analyzer.SeverityHigh: 0, analyzer.SeverityLow: 0}})
+	if len(overrides) != 1 || overrides[0].Setting != "exit-codes: high: 0" {
+		t.Errorf("Unexpected overrides %v", overrides)
+	}
+	if _, ok := enforced.ExitCodes[analyzer.SeverityHigh]; ok || len(enforced.ExitCodes) != 1 {
+		t.Errorf("Unexpected exit codes %v", enforced.ExitCodes)
+	}
+	if enforced, _ = policy.Enforce(&Config{}); enforced.ExitCodes[analyzer.SeverityCritical] != 3 {
+		t.Errorf("Expected the exit codes of the policy but they were %v", enforced.ExitCodes)
+	}
+}
+
+func TestEnforceKeepsPacksOfLockedRules(t *testing.T) {
+	policy, err := Parse([]byte("lock:\n  rules:\n    - database-data-dir\n"), "policy")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	project, err := Parse([]byte("packs:\n  database:\n    enabled: false\n  webserver:\n    enabled: false\n"), "project")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	enforced, overrides := policy.Enforce(project)
+	if len(overrides) != 1 || overrides[0].Setting != "packs: database: enabled: false" {
+		t.Errorf("Expected the database pack to be locked but the overrides were %v", overrides)
+	}
+	if packs := enforced.EnabledPacks(); len(packs) != 1 || packs[analyzer.PACK_WEBSERVER] {
+		t.Errorf("Expected only the webserver pack to be disabled but the packs were %v", packs)
+	}
+}
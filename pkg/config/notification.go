@@ -0,0 +1,79 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// The chat services the notifications are posted to, through their incoming webhooks
+const (
+	NOTIFICATION_SLACK = "slack"
+	NOTIFICATION_TEAMS = "teams"
+)
+
+// Notification posts a findings summary to a chat channel when its threshold is exceeded, see
+// pkg/notify, e.g.
+//
+//	notifications:
+//	  - type: slack
+//	    url: ${SLACK_WEBHOOK_URL}
+//	    severity: high
+//	    min-score: 80
+//	    report-url: ${CI_JOB_URL}/artifacts/report.html
+//
+// Without threshold, the notification is posted when the verdict is failed.
+type Notification struct {
+	// Type is slack or teams
+	Type string `yaml:"type"`
+	// URL is the incoming webhook of the channel, the environment variables are expanded so that
+	// it can be kept out of the file
+	URL string `yaml:"url"`
+	// Severity posts the notification when a failed finding is at least this severe
+	Severity analyzer.ResultSeverity `yaml:"severity,omitempty"`
+	// MinScore posts the notification when the score is below it
+	MinScore int `yaml:"min-score,omitempty"`
+	// ReportURL links the notification to the full report, the environment variables are
+	// expanded
+	ReportURL string `yaml:"report-url,omitempty"`
+}
+
+func (n *Notification) validate() error {
+	n.Type = strings.ToLower(n.Type)
+	if n.Type != NOTIFICATION_SLACK && n.Type != NOTIFICATION_TEAMS {
+		return errors.Errorf("unknown notification type %q, expected slack or teams", n.Type)
+	}
+	if n.URL == "" {
+		return errors.Errorf("the %s notification has no url", n.Type)
+	}
+	if n.Severity != "" {
+		severity, err := analyzer.ParseSeverity(string(n.Severity))
+		if err != nil {
+			return errors.Wrapf(err, "invalid severity of the %s notification", n.Type)
+		}
+		n.Severity = severity
+	}
+	if n.MinScore < 0 || n.MinScore > 100 {
+		return errors.Errorf("invalid min-score %d of the %s notification, expected a score from 0 to 100", n.MinScore, n.Type)
+	}
+	return nil
+}
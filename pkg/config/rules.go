@@ -0,0 +1,221 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/cel-go/cel"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// CustomRule is a rule of the configuration file whose check is a CEL expression evaluated on
+// every instruction of the Containerfile, e.g.
+//
+//	custom-rules:
+//	  - id: debug-enabled
+//	    expression: instruction == "ENV" && value.matches("DEBUG=true")
+//	    message: the debug mode is enabled in the image
+//	    severity: medium
+//
+// The expression gets the variables:
+//   - instruction, the uppercase instruction, e.g. ENV
+//   - value, the arguments of the instruction without its flags, e.g. DEBUG=true
+//   - flags, the flags of the instruction, e.g. ["--chown=1001:0"]
+//   - line, the first line of the instruction
+//   - stage, the name of the stage of the instruction, or its index when it has no name
+//   - final, whether the instruction belongs to the final stage
+type CustomRule struct {
+	ID string `yaml:"id"`
+	// Name defaults to the ID
+	Name        string                  `yaml:"name,omitempty"`
+	Expression  string                  `yaml:"expression"`
+	Message     string                  `yaml:"message"`
+	Severity    analyzer.ResultSeverity `yaml:"severity"`
+	Remediation string                  `yaml:"remediation,omitempty"`
+}
+
+// Rule is the catalog entry of the custom rule.
+func (r CustomRule) Rule() analyzer.Rule {
+	name := r.Name
+	if name == "" {
+		name = r.ID
+	}
+	return analyzer.Rule{
+		ID:          r.ID,
+		Name:        name,
+		Severity:    analyzer.ResultSeverity(strings.ToLower(string(r.Severity))),
+		Confidence:  analyzer.ConfidenceHigh,
+		Description: r.Message,
+		Remediation: r.Remediation,
+	}
+}
+
+var celEnv, celEnvErr = cel.NewEnv(
+	cel.Variable("instruction", cel.StringType),
+	cel.Variable("value", cel.StringType),
+	cel.Variable("flags", cel.ListType(cel.StringType)),
+	cel.Variable("line", cel.IntType),
+	cel.Variable("stage", cel.StringType),
+	cel.Variable("final", cel.BoolType),
+)
+
+func (r CustomRule) compile() (cel.Program, error) {
+	if celEnvErr != nil {
+		return nil, celEnvErr
+	}
+	ast, issues := celEnv.Compile(r.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.Errorf("invalid expression of rule %s: %s", r.ID, issues.Err())
+	}
+	if !cel.BoolType.IsAssignableType(ast.OutputType()) {
+		return nil, errors.Errorf("the expression of rule %s returns %s instead of a bool", r.ID, ast.OutputType())
+	}
+	return celEnv.Program(ast)
+}
+
+func (r CustomRule) validate() error {
+	if r.ID == "" {
+		return errors.New("a custom rule has no id")
+	}
+	if _, ok := analyzer.FindRule(r.ID); ok {
+		return errors.Errorf("custom rule %s is already defined", r.ID)
+	}
+	if r.Message == "" {
+		return errors.Errorf("custom rule %s has no message", r.ID)
+	}
+	if !severities[analyzer.ResultSeverity(strings.ToLower(string(r.Severity)))] {
+		return errors.Errorf("unknown severity %s for custom rule %s, expected one of critical, high, medium, low", r.Severity, r.ID)
+	}
+	_, err := r.compile()
+	return err
+}
+
+// Plugin returns the custom rules as a plugin run along with the built-in rules, see
+// analyzer.WithPlugins. Their catalog entries have to be registered with analyzer.RegisterRules.
+func (c *Config) Plugin() (analyzer.Plugin, error) {
+	plugin := &customRules{}
+	for _, rule := range c.CustomRules {
+		program, err := rule.compile()
+		if err != nil {
+			return nil, err
+		}
+		plugin.rules = append(plugin.rules, compiledRule{rule: rule.Rule(), program: program})
+	}
+	return plugin, nil
+}
+
+type compiledRule struct {
+	rule    analyzer.Rule
+	program cel.Program
+}
+
+type customRules struct {
+	rules []compiledRule
+}
+
+func (p *customRules) Name() string {
+	return DEFAULT_FILE
+}
+
+func (p *customRules) Rules() ([]analyzer.Rule, error) {
+	var rules []analyzer.Rule
+	for _, compiled := range p.rules {
+		rules = append(rules, compiled.rule)
+	}
+	return rules, nil
+}
+
+// Analyze evaluates the expressions on every instruction. A Containerfile which can't be parsed
+// has no results, the parse errors being reported by the analyzer.
+func (p *customRules) Analyze(content []byte) ([]analyzer.Result, error) {
+	if len(p.rules) == 0 {
+		return nil, nil
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil
+	}
+	stages := 0
+	for _, node := range res.AST.Children {
+		if strings.EqualFold(node.Value, "from") {
+			stages++
+		}
+	}
+
+	var results []analyzer.Result
+	index, stage := -1, ""
+	for _, node := range res.AST.Children {
+		instruction := strings.ToUpper(node.Value)
+		if instruction == "FROM" {
+			index++
+			stage = stageName(node, index)
+		}
+		vars := map[string]interface{}{
+			"instruction": instruction,
+			"value":       instructionValue(node),
+			"flags":       node.Flags,
+			"line":        node.StartLine,
+			"stage":       stage,
+			"final":       index == stages-1,
+		}
+		if node.Flags == nil {
+			vars["flags"] = []string{}
+		}
+		for _, compiled := range p.rules {
+			out, _, err := compiled.program.Eval(vars)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to evaluate the expression of rule %s at line %d", compiled.rule.ID, node.StartLine)
+			}
+			if matched, ok := out.Value().(bool); ok && matched {
+				result := compiled.rule.Failed(compiled.rule.Description)
+				result.Line = &analyzer.Line{Start: node.StartLine, End: node.EndLine}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// stageName is the name given by FROM ... AS name, or the index of the stage.
+func stageName(node *parser.Node, index int) string {
+	for n := node.Next; n != nil; n = n.Next {
+		if strings.EqualFold(n.Value, "as") && n.Next != nil {
+			return n.Next.Value
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// instructionValue is the original text of the instruction without its keyword and its flags.
+func instructionValue(node *parser.Node) string {
+	value := strings.TrimSpace(node.Original)
+	for first := true; first || strings.HasPrefix(value, "--"); first = false {
+		index := strings.IndexFunc(value, unicode.IsSpace)
+		if index < 0 {
+			return ""
+		}
+		value = strings.TrimSpace(value[index:])
+	}
+	return value
+}
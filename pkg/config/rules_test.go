@@ -0,0 +1,85 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const customRulesConfig = `custom-rules:
+  - id: debug-enabled
+    expression: instruction == "ENV" && value.matches("DEBUG=true")
+    message: the debug mode is enabled in the image
+    severity: Medium
+  - id: final-stage-curl
+    name: curl in the final stage
+    expression: final && instruction == "RUN" && value.contains("curl")
+    message: curl is run in the final stage
+    severity: low
+rules:
+  final-stage-curl:
+    severity: high
+`
+
+func TestCustomRules(t *testing.T) {
+	config, err := Parse([]byte(customRulesConfig), "test")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	plugin, err := config.Plugin()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	results, err := plugin.Analyze([]byte(`FROM ubi9 AS builder
+ENV DEBUG=true
+RUN curl -o app https://example.com/app
+FROM ubi9-minimal
+COPY --from=builder app .
+RUN curl --version
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results but they were %v", results)
+	}
+	if results[0].RuleID != "debug-enabled" || results[0].Line.Start != 2 || results[0].Description != "the debug mode is enabled in the image" {
+		t.Errorf("Unexpected result %v", results[0])
+	}
+	if results[1].RuleID != "final-stage-curl" || results[1].Line.Start != 6 || results[1].Name != "curl in the final stage" {
+		t.Errorf("Unexpected result %v", results[1])
+	}
+	if results := config.Apply(results); results[1].Severity != "high" {
+		t.Errorf("Expected the severity of the custom rule to be overridden but it was %s", results[1].Severity)
+	}
+}
+
+func TestCustomRulesAreValidated(t *testing.T) {
+	for content, expected := range map[string]string{
+		"custom-rules:\n  - id: broken\n    expression: instruction ==\n    message: m\n    severity: low\n": "invalid expression",
+		"custom-rules:\n  - id: not-bool\n    expression: line + 1\n    message: m\n    severity: low\n":     "instead of a bool",
+		"custom-rules:\n  - id: user-root\n    expression: \"true\"\n    message: m\n    severity: low\n":    "already defined",
+		"custom-rules:\n  - id: no-message\n    expression: \"true\"\n    severity: low\n":                   "no message",
+		"custom-rules:\n  - id: severity\n    expression: \"true\"\n    message: m\n    severity: blocker\n": "unknown severity",
+	} {
+		if _, err := Parse([]byte(content), "test"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q but it was %v", expected, err)
+		}
+	}
+}
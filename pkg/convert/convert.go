@@ -0,0 +1,345 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package convert rewrites a Containerfile into an OpenShift friendly shape: Universal Base
+// Images, a non-root USER of the root group, permission fixes applied to all the copied files
+// and unprivileged ports. Every transformation is reported along with the converted file.
+ package convert
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+)
+
+type TransformationKind string
+
+const (
+	TransformationBaseImage   TransformationKind = "base-image"
+	TransformationUser        TransformationKind = "user"
+	TransformationPermissions TransformationKind = "permissions"
+	TransformationPort        TransformationKind = "port"
+	// TransformationManual is a change which couldn't be applied and has to be done by hand
+	TransformationManual TransformationKind = "manual"
+)
+
+// DEFAULT_UID is the user set in the final stage when it runs as root
+const DEFAULT_UID = "1001"
+
+// PORT_OFFSET is added to the privileged ports, e.g. 80 becomes 8080
+const PORT_OFFSET = 8000
+
+type Transformation struct {
+	Kind TransformationKind `json:"kind"`
+	// Line is the line of the original Containerfile
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+}
+
+type Result struct {
+	Content         []byte           `json:"-"`
+	Transformations []Transformation `json:"transformations"`
+	// Edits are the changes of the lines of the original Containerfile giving Content
+	Edits []Edit `json:"-"`
+}
+
+// Edit replaces the lines from Start to End of the original Containerfile with Lines, removing them
+// when Lines is empty. The lines are inserted before Start when End is before Start.
+type Edit struct {
+	Start int
+	End   int
+	Lines []string
+}
+
+// Insertion reports whether the edit only inserts lines.
+func (e Edit) Insertion() bool {
+	return e.End < e.Start
+}
+
+var copyChownRegexp = regexp.MustCompile(`--chown=([^\s:]+):(\S+)`)
+
+var runChownRegexp = regexp.MustCompile(`\bchown\s+((?:-\S+\s+)*)([^\s:]+):([^\s;&|]+)`)
+
+var instructionRegexp = regexp.MustCompile(`(?i)^(\s*)(COPY|ADD)\b`)
+
+// permissionCommands only change the owner or the permissions of files
+var permissionCommands = map[string]bool{"chown": true, "chmod": true, "chgrp": true}
+
+var packageManagers = []string{"apt-get", "apt", "apk", "dnf", "microdnf", "yum"}
+
+type converter struct {
+	editor          *editor
+	transformations []Transformation
+}
+
+func (c *converter) add(kind TransformationKind, line int, format string, args ...interface{}) {
+	c.transformations = append(c.transformations, Transformation{Kind: kind, Line: line, Description: fmt.Sprintf(format, args...)})
+}
+
+// SafeTransformations don't require changing the application, e.g. to listen on another port or
+// to run on another base image, so that they can be applied unattended, see ConvertOnly
+var SafeTransformations = []TransformationKind{TransformationPermissions}
+
+// Convert returns the converted Containerfile and the transformations applied to it.
+func Convert(content []byte) (*Result, error) {
+	return ConvertOnly(content, TransformationBaseImage, TransformationUser, TransformationPermissions, TransformationPort)
+}
+
+// ConvertOnly converts the Containerfile as Convert does, only applying the transformations of
+// the given kinds.
+func ConvertOnly(content []byte, kinds ...TransformationKind) (*Result, error) {
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the Containerfile")
+	}
+	var stages [][]*parser.Node
+	for _, node := range res.AST.Children {
+		if strings.EqualFold(node.Value, "from") {
+			stages = append(stages, nil)
+		}
+		if len(stages) > 0 {
+			stages[len(stages)-1] = append(stages[len(stages)-1], node)
+		}
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("the Containerfile has no FROM instruction")
+	}
+
+	c := &converter{editor: newEditor(string(content))}
+	enabled := map[TransformationKind]bool{}
+	for _, kind := range kinds {
+		enabled[kind] = true
+	}
+	names := map[string]bool{}
+	for i, stage := range stages {
+		if enabled[TransformationBaseImage] {
+			c.convertBaseImage(stage, i == len(stages)-1, names)
+		}
+	}
+	final := stages[len(stages)-1]
+	if enabled[TransformationPort] {
+		c.convertPorts(final)
+	}
+	if enabled[TransformationPermissions] {
+		c.convertChownGroups(final)
+		c.movePermissionFixes(final)
+	}
+	if enabled[TransformationUser] {
+		c.convertUser(final)
+	}
+	sort.SliceStable(c.transformations, func(i, j int) bool {
+		return c.transformations[i].Line < c.transformations[j].Line
+	})
+	return &Result{Content: []byte(c.editor.String()), Transformations: c.transformations, Edits: c.editor.edits()}, nil
+}
+
+func (c *converter) convertBaseImage(stage []*parser.Node, final bool, names map[string]bool) {
+	from := stage[0]
+	var args []string
+	for n := from.Next; n != nil; n = n.Next {
+		args = append(args, n.Value)
+	}
+	if len(args) == 0 {
+		return
+	}
+	image := args[0]
+	if len(args) == 3 && strings.EqualFold(args[1], "as") {
+		defer func() { names[strings.ToLower(args[2])] = true }()
+	}
+	if strings.Contains(image, "$") || names[strings.ToLower(image)] || image == "scratch" {
+		return
+	}
+	packageManager := ""
+	for _, node := range stage[1:] {
+		if !strings.EqualFold(node.Value, "run") {
+			continue
+		}
+		for _, word := range strings.Fields(node.Original) {
+			for _, manager := range packageManagers {
+				if word == manager && packageManager == "" {
+					packageManager = manager
+				}
+			}
+		}
+	}
+	ubi, reason := ubiImage(image, final, packageManager)
+	if reason != "" {
+		c.add(TransformationManual, from.StartLine, "%s is not replaced by a Universal Base Image: %s", image, reason)
+		return
+	}
+	if ubi == "" {
+		return
+	}
+	line := "FROM "
+	for _, flag := range from.Flags {
+		line += flag + " "
+	}
+	line += ubi
+	if len(args) == 3 {
+		line += " " + args[1] + " " + args[2]
+	}
+	c.editor.replace(from.StartLine, from.EndLine, line)
+	c.add(TransformationBaseImage, from.StartLine, "base image %s replaced by %s, check that the paths used by the Containerfile exist in it", image, ubi)
+}
+
+func (c *converter) convertPorts(stage []*parser.Node) {
+	for _, node := range stage {
+		if !strings.EqualFold(node.Value, "expose") {
+			continue
+		}
+		var ports []string
+		changed := false
+		for n := node.Next; n != nil; n = n.Next {
+			port := n.Value
+			number, protocol := port, ""
+			if index := strings.Index(port, "/"); index >= 0 {
+				number, protocol = port[:index], port[index:]
+			}
+			if value, err := strconv.Atoi(number); err == nil && value > 0 && value < 1024 {
+				port = strconv.Itoa(value+PORT_OFFSET) + protocol
+				changed = true
+				c.add(TransformationPort, node.StartLine, "privileged port %d replaced by %d, the application must listen on it", value, value+PORT_OFFSET)
+			}
+			ports = append(ports, port)
+		}
+		if changed {
+			c.editor.replace(node.StartLine, node.EndLine, "EXPOSE "+strings.Join(ports, " "))
+		}
+	}
+}
+
+func (c *converter) convertChownGroups(stage []*parser.Node) {
+	for _, node := range stage {
+		instruction := strings.ToLower(node.Value)
+		re, group := runChownRegexp, 3
+		if instruction == "copy" || instruction == "add" {
+			re, group = copyChownRegexp, 2
+		} else if instruction != "run" {
+			continue
+		}
+		text := c.editor.text(node.StartLine, node.EndLine)
+		converted := re.ReplaceAllStringFunc(text, func(match string) string {
+			submatches := re.FindStringSubmatchIndex(match)
+			owner := match[submatches[2*group]:submatches[2*group+1]]
+			if owner == "0" || owner == "root" {
+				return match
+			}
+			return match[:submatches[2*group]] + "0" + match[submatches[2*group+1]:]
+		})
+		if converted != text {
+			c.editor.replace(node.StartLine, node.EndLine, strings.Split(converted, "\n")...)
+			c.add(TransformationPermissions, node.StartLine, "group of the %s owner set to root (0), the group of the UID assigned by OpenShift", strings.ToUpper(node.Value))
+		}
+	}
+}
+
+// movePermissionFixes moves the RUN instructions only changing owners and permissions after the
+// last COPY or ADD of the stage, so that they apply to all the copied files.
+func (c *converter) movePermissionFixes(stage []*parser.Node) {
+	lastCopy := -1
+	for i, node := range stage {
+		if instruction := strings.ToLower(node.Value); instruction == "copy" || instruction == "add" {
+			lastCopy = i
+		}
+	}
+	for i := 0; i < lastCopy; i++ {
+		node := stage[i]
+		if !strings.EqualFold(node.Value, "run") || !isPermissionFix(node) || userBetween(stage[i+1:lastCopy]) {
+			continue
+		}
+		target := stage[lastCopy]
+		c.editor.move(node.StartLine, node.EndLine, target.EndLine)
+		c.add(TransformationPermissions, node.StartLine, "RUN moved after the %s at line %d so that it applies to the copied files", strings.ToUpper(target.Value), target.StartLine)
+	}
+}
+
+func isPermissionFix(node *parser.Node) bool {
+	parts := strings.SplitN(strings.TrimSpace(node.Original), " ", 2)
+	if len(parts) < 2 {
+		return false
+	}
+	script := parts[1]
+	script = strings.NewReplacer("&&", ";", "||", ";", "\n", ";").Replace(script)
+	commands := 0
+	for _, command := range strings.Split(script, ";") {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		if !permissionCommands[fields[0]] {
+			return false
+		}
+		commands++
+	}
+	return commands > 0
+}
+
+func userBetween(nodes []*parser.Node) bool {
+	for _, node := range nodes {
+		if strings.EqualFold(node.Value, "user") {
+			return true
+		}
+	}
+	return false
+}
+
+// convertUser makes the final stage run as DEFAULT_UID when it runs as root. The files copied
+// without --chown are then given to the user and to the root group.
+func (c *converter) convertUser(stage []*parser.Node) {
+	var user *parser.Node
+	last := stage[0]
+	for _, node := range stage {
+		switch strings.ToLower(node.Value) {
+		case "user":
+			user = node
+		case "run", "copy", "add", "workdir":
+			last = node
+		}
+	}
+	if user != nil && user.Next != nil {
+		name := strings.SplitN(user.Next.Value, ":", 2)[0]
+		if name != "root" && name != "0" {
+			return
+		}
+	}
+	if user != nil && user.StartLine > last.StartLine {
+		c.editor.replace(user.StartLine, user.EndLine, "USER "+DEFAULT_UID)
+		c.add(TransformationUser, user.StartLine, "USER root replaced by USER %s, OpenShift runs the containers with a non-root UID", DEFAULT_UID)
+	} else {
+		c.editor.insertAfter(last.EndLine, "USER "+DEFAULT_UID)
+		c.add(TransformationUser, last.StartLine, "USER %s set after the %s at line %d, the final stage ran as root", DEFAULT_UID, strings.ToUpper(last.Value), last.StartLine)
+	}
+	for _, node := range stage {
+		if instruction := strings.ToLower(node.Value); instruction != "copy" && instruction != "add" {
+			continue
+		}
+		text := c.editor.text(node.StartLine, node.EndLine)
+		if text == "" || strings.Contains(text, "--chown") {
+			continue
+		}
+		converted := instructionRegexp.ReplaceAllString(text, "${1}${2} --chown="+DEFAULT_UID+":0")
+		c.editor.replace(node.StartLine, node.EndLine, strings.Split(converted, "\n")...)
+		c.add(TransformationPermissions, node.StartLine, "files copied by %s owned by %s:0 instead of root", strings.ToUpper(node.Value), DEFAULT_UID)
+	}
+}
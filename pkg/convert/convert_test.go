@@ -0,0 +1,160 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package convert
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func countKind(transformations []Transformation, kind TransformationKind) int {
+	count := 0
+	for _, transformation := range transformations {
+		if transformation.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func TestConvert(t *testing.T) {
+	content := `FROM golang:1.21 AS builder
+RUN go build -o /out/app .
+
+FROM node:20
+WORKDIR /app
+RUN chown -R node:node /app
+COPY package.json .
+COPY --from=builder /out/app /usr/local/bin/app
+EXPOSE 80
+CMD ["node", "server.js"]
+`
+	result, err := Convert([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := `FROM registry.access.redhat.com/ubi9/go-toolset:1.21 AS builder
+RUN go build -o /out/app .
+
+FROM registry.access.redhat.com/ubi9/nodejs-20-minimal:1
+WORKDIR /app
+COPY --chown=1001:0 package.json .
+COPY --chown=1001:0 --from=builder /out/app /usr/local/bin/app
+RUN chown -R node:0 /app
+USER 1001
+EXPOSE 8080
+CMD ["node", "server.js"]
+`
+	if string(result.Content) != expected {
+		t.Errorf("Expected\n%s\nbut it was\n%s", expected, result.Content)
+	}
+	for kind, count := range map[TransformationKind]int{
+		TransformationBaseImage:   2,
+		TransformationPort:        1,
+		TransformationUser:        1,
+		TransformationPermissions: 4,
+	} {
+		if actual := countKind(result.Transformations, kind); actual != count {
+			t.Errorf("Expected %d %s transformations but they were %d: %v", count, kind, actual, result.Transformations)
+		}
+	}
+}
+
+func TestEditsGiveConvertedContent(t *testing.T) {
+	content := `FROM node:20
+RUN chown -R node:node /app
+COPY package.json .
+EXPOSE 80
+CMD ["node", "server.js"]`
+	result, err := Convert([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	lines := strings.Split(content, "\n")
+	var edited []string
+	line := 1
+	for _, edit := range result.Edits {
+		edited = append(edited, lines[line-1:edit.Start-1]...)
+		edited = append(edited, edit.Lines...)
+		line = edit.End + 1
+		if edit.Insertion() {
+			line = edit.Start
+		}
+	}
+	edited = append(edited, lines[line-1:]...)
+	if strings.Join(edited, "\n") != string(result.Content) {
+		t.Errorf("Expected the edits to give\n%s\nbut they gave\n%s", result.Content, strings.Join(edited, "\n"))
+	}
+}
+
+func TestConvertedContainerfileHasFewerFindings(t *testing.T) {
+	content := "FROM debian:12\nRUN apt-get update && apt-get install -y curl\nCOPY app /app\nUSER root\nEXPOSE 443\nCMD [\"/app/run\"]\n"
+	result, err := Convert([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if countKind(result.Transformations, TransformationManual) != 1 {
+		t.Errorf("Expected the debian base image to be converted by hand but they were %v", result.Transformations)
+	}
+	ctx := analyzer.WithoutBaseImageAnalysis(context.Background())
+	before := analyzer.AnalyzeReader(ctx, "Containerfile", strings.NewReader(content))
+	after := analyzer.AnalyzeReader(ctx, "Containerfile", bytes.NewReader(result.Content))
+	for _, res := range after {
+		if res.RuleID == analyzer.RuleUserRoot.ID || res.RuleID == analyzer.RulePrivilegedPort.ID {
+			t.Errorf("Unexpected %s suggestion in the converted Containerfile %s", res.RuleID, result.Content)
+		}
+	}
+	if len(after) >= len(before) {
+		t.Errorf("Expected fewer suggestions after the conversion but they were %v", after)
+	}
+}
+
+func TestConvertWithoutFrom(t *testing.T) {
+	if _, err := Convert([]byte("# nothing\n")); err == nil {
+		t.Errorf("Expected an error for a Containerfile without FROM")
+	}
+}
+
+func TestConvertOnlySafeTransformations(t *testing.T) {
+	content := `FROM node:20
+RUN chown -R node:node /app
+COPY package.json .
+EXPOSE 80
+CMD ["node", "server.js"]`
+	result, err := ConvertOnly([]byte(content), SafeTransformations...)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	for _, transformation := range result.Transformations {
+		if transformation.Kind != TransformationPermissions {
+			t.Errorf("Expected only permission transformations but got %v", transformation)
+		}
+	}
+	expected := `FROM node:20
+COPY package.json .
+RUN chown -R node:0 /app
+EXPOSE 80
+CMD ["node", "server.js"]`
+	if string(result.Content) != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, result.Content)
+	}
+}
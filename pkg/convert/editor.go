@@ -0,0 +1,111 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package convert
+
+import "strings"
+
+// editor changes the lines of a file, lines being numbered from 1 as in the parsed nodes. The
+// changes refer to the original lines so that they can be applied in any order.
+type editor struct {
+	lines []string
+	// replaced holds the new text of the ranges of lines starting at a line, the other lines of
+	// the range being in removed
+	replaced map[int][]string
+	// ends holds the last line of the ranges of replaced
+	ends    map[int]int
+	removed map[int]bool
+	after   map[int][]string
+}
+
+func newEditor(content string) *editor {
+	return &editor{
+		lines:    strings.Split(content, "\n"),
+		replaced: map[int][]string{},
+		ends:     map[int]int{},
+		removed:  map[int]bool{},
+		after:    map[int][]string{},
+	}
+}
+
+// text returns the current text of the lines from start to end, empty when they were moved.
+func (e *editor) text(start, end int) string {
+	if e.removed[start] {
+		return ""
+	}
+	if lines, ok := e.replaced[start]; ok {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(e.lines[start-1:end], "\n")
+}
+
+func (e *editor) replace(start, end int, lines ...string) {
+	e.replaced[start] = lines
+	e.ends[start] = end
+	for line := start + 1; line <= end; line++ {
+		e.removed[line] = true
+	}
+}
+
+func (e *editor) insertAfter(line int, lines ...string) {
+	e.after[line] = append(e.after[line], lines...)
+}
+
+// move moves the lines from start to end after the line target.
+func (e *editor) move(start, end, target int) {
+	e.insertAfter(target, strings.Split(e.text(start, end), "\n")...)
+	for line := start; line <= end; line++ {
+		e.removed[line] = true
+	}
+	delete(e.replaced, start)
+	delete(e.ends, start)
+}
+
+// edits returns the changes as edits of the original lines, in the order of the lines.
+func (e *editor) edits() []Edit {
+	var edits []Edit
+	for i := range e.lines {
+		line := i + 1
+		if lines, ok := e.replaced[line]; ok {
+			edits = append(edits, Edit{Start: line, End: e.ends[line], Lines: lines})
+		} else if e.removed[line] {
+			if last := len(edits) - 1; last >= 0 && edits[last].Lines == nil && edits[last].End == line-1 {
+				edits[last].End = line
+			} else {
+				edits = append(edits, Edit{Start: line, End: line})
+			}
+		}
+		if lines, ok := e.after[line]; ok {
+			edits = append(edits, Edit{Start: line + 1, End: line, Lines: lines})
+		}
+	}
+	return edits
+}
+
+func (e *editor) String() string {
+	var out []string
+	for i, text := range e.lines {
+		line := i + 1
+		if lines, ok := e.replaced[line]; ok {
+			out = append(out, lines...)
+		} else if !e.removed[line] {
+			out = append(out, text)
+		}
+		out = append(out, e.after[line]...)
+	}
+	return strings.Join(out, "\n")
+}
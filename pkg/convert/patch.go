@@ -0,0 +1,155 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// DIFF_CONTEXT is the number of unchanged lines around the changes of the patches
+const DIFF_CONTEXT = 3
+
+// AssignEdits returns the edits changing the lines of each failed result of the Containerfile,
+// keyed by the index of the result. Each edit is assigned once, to the first result of the line
+// it changes.
+func AssignEdits(edits []Edit, results []analyzer.Result) map[int][]Edit {
+	assigned := map[int][]Edit{}
+	done := map[int]bool{}
+	for r, res := range results {
+		if res.Status != analyzer.StatusFailed || res.File != nil || res.Manifest != nil || res.Line == nil {
+			continue
+		}
+		for i, edit := range edits {
+			changed := edit.Start
+			if edit.Insertion() {
+				// the inserted lines follow the line of the finding
+				changed = edit.End
+			}
+			if !done[i] && changed >= res.Line.Start && changed <= res.Line.End {
+				done[i] = true
+				assigned[r] = append(assigned[r], edit)
+			}
+		}
+	}
+	return assigned
+}
+
+// AttachPatches sets the Patch of the failed results of the Containerfile doa convert fixes,
+// file being the path of the Containerfile in the patches.
+func AttachPatches(file string, content []byte, results []analyzer.Result) []analyzer.Result {
+	converted, err := Convert(content)
+	if err != nil {
+		return results
+	}
+	for i, edits := range AssignEdits(converted.Edits, results) {
+		results[i].Patch = Diff(file, content, edits)
+	}
+	return results
+}
+
+// change replaces the original lines from a to b, 0-based and b excluded, with lines
+type change struct {
+	a, b  int
+	lines []string
+}
+
+// Diff returns the edits of the content as a unified diff, which git apply and patch -p1 apply to
+// the file.
+func Diff(file string, content []byte, edits []Edit) string {
+	text := string(content)
+	newline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	n := len(lines)
+
+	changes := make([]change, 0, len(edits))
+	for _, edit := range edits {
+		c := change{a: edit.Start - 1, b: edit.End, lines: edit.Lines}
+		if edit.Insertion() {
+			c.b = c.a
+		}
+		if !newline && c.b == n && c.a > 0 && (len(c.lines) == 0 || c.a == c.b) {
+			// the last line of the file changes as it gains or loses its newline
+			c.a--
+			c.lines = append([]string{lines[c.a]}, c.lines...)
+		}
+		changes = append(changes, c)
+	}
+
+	file = filepath.ToSlash(filepath.Clean(file))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", file, file)
+	delta := 0
+	for first := 0; first < len(changes); {
+		last := first
+		for last+1 < len(changes) && changes[last+1].a-changes[last].b <= 2*DIFF_CONTEXT {
+			last++
+		}
+		start, end := changes[first].a-DIFF_CONTEXT, changes[last].b+DIFF_CONTEXT
+		if start < 0 {
+			start = 0
+		}
+		if end > n {
+			end = n
+		}
+		var hunk strings.Builder
+		line := func(prefix byte, text string, eof bool) {
+			hunk.WriteByte(prefix)
+			hunk.WriteString(text)
+			hunk.WriteByte('\n')
+			if eof {
+				hunk.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		added := 0
+		i := start
+		for _, c := range changes[first : last+1] {
+			for ; i < c.a; i++ {
+				line(' ', lines[i], !newline && i == n-1)
+			}
+			for ; i < c.b; i++ {
+				line('-', lines[i], !newline && i == n-1)
+			}
+			for j, text := range c.lines {
+				line('+', text, !newline && c.b == n && j == len(c.lines)-1)
+			}
+			added += len(c.lines) - (c.b - c.a)
+		}
+		for ; i < end; i++ {
+			line(' ', lines[i], !newline && i == n-1)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(start, end-start), hunkRange(start+delta, end-start+added))
+		out.WriteString(hunk.String())
+		delta += added
+		first = last + 1
+	}
+	return out.String()
+}
+
+// hunkRange returns the range of the lines of a hunk header, an empty range starting at the line
+// before the hunk.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,81 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestDiff(t *testing.T) {
+	content := "FROM ubi9\nRUN dnf install -y nginx\nRUN echo a\nRUN echo b\nRUN echo c\nRUN echo d\nEXPOSE 80\nCMD [\"nginx\"]\n"
+	diff := Diff("./Containerfile", []byte(content), []Edit{{Start: 7, End: 7, Lines: []string{"EXPOSE 8080"}}, {Start: 9, End: 8, Lines: []string{"USER 1001"}}})
+	expected := `--- a/Containerfile
++++ b/Containerfile
+@@ -4,5 +4,6 @@
+ RUN echo b
+ RUN echo c
+ RUN echo d
+-EXPOSE 80
++EXPOSE 8080
+ CMD ["nginx"]
++USER 1001
+`
+	if diff != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, diff)
+	}
+}
+
+func TestDiffWithoutNewlineAtEnd(t *testing.T) {
+	diff := Diff("Containerfile", []byte("FROM ubi9\nCMD [\"sh\"]"), []Edit{{Start: 3, End: 2, Lines: []string{"USER 1001"}}})
+	expected := `--- a/Containerfile
++++ b/Containerfile
+@@ -1,2 +1,3 @@
+ FROM ubi9
+-CMD ["sh"]
+\ No newline at end of file
++CMD ["sh"]
++USER 1001
+\ No newline at end of file
+`
+	if diff != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, diff)
+	}
+}
+
+func TestAttachPatches(t *testing.T) {
+	content := "FROM registry.access.redhat.com/ubi9/ubi-minimal\nEXPOSE 80\nCMD [\"server\"]\n"
+	results := analyzer.AnalyzeReader(context.Background(), "Containerfile", strings.NewReader(content))
+	results = AttachPatches("Containerfile", []byte(content), results)
+	patched := 0
+	for _, result := range results {
+		if result.Patch == "" {
+			continue
+		}
+		patched++
+		if result.RuleID == "privileged-port" && !strings.Contains(result.Patch, "-EXPOSE 80\n+EXPOSE 8080\n") {
+			t.Errorf("Expected the port to be replaced but got\n%s", result.Patch)
+		}
+	}
+	if patched == 0 {
+		t.Errorf("Expected patched results but got %v", results)
+	}
+}
@@ -0,0 +1,129 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// UBI_REGISTRY hosts the Red Hat Universal Base Images
+const UBI_REGISTRY = "registry.access.redhat.com/ubi9"
+
+// versionRegexp matches the major and minor versions at the start of a tag, e.g. 3.11 in 3.11-slim
+var versionRegexp = regexp.MustCompile(`^(\d+)(?:\.(\d+))?`)
+
+// jdkVersionRegexp matches the JDK version of the maven and gradle tags, e.g. 17 in 3.9-eclipse-temurin-17
+var jdkVersionRegexp = regexp.MustCompile(`(?:jdk|temurin|openjdk|corretto)-?(\d+)`)
+
+// osImages are the base images of distributions, replaced by ubi-minimal, or by ubi when the
+// stage installs packages with dnf or yum
+var osImages = map[string]bool{
+	"alpine":     true,
+	"debian":     true,
+	"ubuntu":     true,
+	"centos":     true,
+	"fedora":     true,
+	"rockylinux": true,
+	"almalinux":  true,
+	"busybox":    true,
+}
+
+var (
+	nodeVersions   = map[string]bool{"18": true, "20": true, "22": true}
+	pythonVersions = map[string]bool{"39": true, "311": true, "312": true}
+	jdkVersions    = map[string]bool{"11": true, "17": true, "21": true}
+)
+
+// ubiImage returns the Universal Base Image equivalent to the image, final telling whether it's
+// the base image of the final stage, where the runtime images without build tools are used. The
+// reason is set when the image has no equivalent.
+func ubiImage(image string, final bool, packageManager string) (string, string) {
+	repository, tag := splitImage(image)
+	version := versionRegexp.FindStringSubmatch(tag)
+	major, minor := "", ""
+	if version != nil {
+		major, minor = version[1], version[2]
+	}
+	switch repository {
+	case "node":
+		if !nodeVersions[major] {
+			return "", fmt.Sprintf("no UBI image for Node.js %s", tag)
+		}
+		if final {
+			return fmt.Sprintf("%s/nodejs-%s-minimal:1", UBI_REGISTRY, major), ""
+		}
+		return fmt.Sprintf("%s/nodejs-%s:1", UBI_REGISTRY, major), ""
+	case "python":
+		if !pythonVersions[major+minor] {
+			return "", fmt.Sprintf("no UBI image for Python %s", tag)
+		}
+		return fmt.Sprintf("%s/python-%s%s:1", UBI_REGISTRY, major, minor), ""
+	case "openjdk", "eclipse-temurin", "amazoncorretto", "maven", "gradle":
+		jdk := major
+		if match := jdkVersionRegexp.FindStringSubmatch(tag); match != nil {
+			jdk = match[1]
+		} else if repository == "maven" || repository == "gradle" {
+			jdk = ""
+		}
+		if !jdkVersions[jdk] {
+			return "", fmt.Sprintf("no UBI image for the JDK of %s", image)
+		}
+		if final {
+			return fmt.Sprintf("%s/openjdk-%s-runtime:1", UBI_REGISTRY, jdk), ""
+		}
+		return fmt.Sprintf("%s/openjdk-%s:1", UBI_REGISTRY, jdk), ""
+	case "golang":
+		if final {
+			return "", "the final stage is based on a build image, build the application in a builder stage"
+		}
+		if major == "" || minor == "" {
+			return UBI_REGISTRY + "/go-toolset:latest", ""
+		}
+		return fmt.Sprintf("%s/go-toolset:%s.%s", UBI_REGISTRY, major, minor), ""
+	case "nginx":
+		return UBI_REGISTRY + "/nginx-124:1", ""
+	case "httpd":
+		return UBI_REGISTRY + "/httpd-24:1", ""
+	}
+	if !osImages[repository] {
+		return "", ""
+	}
+	switch packageManager {
+	case "apt-get", "apt", "apk":
+		return "", fmt.Sprintf("the stage installs packages with %s, translate the packages to dnf and use %s/ubi", packageManager, UBI_REGISTRY)
+	case "dnf", "yum":
+		return UBI_REGISTRY + "/ubi:latest", ""
+	}
+	return UBI_REGISTRY + "/ubi-minimal:latest", ""
+}
+
+// splitImage returns the repository of an official image, e.g. node for docker.io/library/node,
+// and its tag. The repository of other images is returned with their registry.
+func splitImage(image string) (string, string) {
+	image = strings.SplitN(image, "@", 2)[0]
+	repository, tag := image, ""
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		repository, tag = image[:index], image[index+1:]
+	}
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "library/"} {
+		repository = strings.TrimPrefix(repository, prefix)
+	}
+	return repository, tag
+}
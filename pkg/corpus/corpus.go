@@ -0,0 +1,229 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package corpus runs the analyzer on a corpus of Containerfiles and compares the findings with
+// the expected ones, so that rule changes can be measured for both performance and precision.
+// Every directory of the corpus holds a Containerfile, its build context and the expected.json
+// file listing the expected findings. Rule authors can run their own corpus with Check and
+// Benchmark from their tests.
+ package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const (
+	CONTAINERFILE = "Containerfile"
+	EXPECTED_FILE = "expected.json"
+	// UPDATE_ENV rewrites the expected findings of the corpus with the current ones when set
+	UPDATE_ENV = "DOA_UPDATE_CORPUS"
+)
+
+// Finding identifies a result by its rule and the first line of its instruction, 0 when the
+// result doesn't refer to a line.
+type Finding struct {
+	RuleID string `json:"ruleId"`
+	Line   int    `json:"line,omitempty"`
+}
+
+type Case struct {
+	Name          string
+	Containerfile string
+	Expected      []Finding
+}
+
+// Score counts the expected findings which were reported (true positives), the unexpected
+// ones (false positives) and the expected ones which were not reported (false negatives).
+type Score struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+func (s Score) Precision() float64 {
+	if s.TruePositives+s.FalsePositives == 0 {
+		return 1
+	}
+	return float64(s.TruePositives) / float64(s.TruePositives+s.FalsePositives)
+}
+
+func (s Score) Recall() float64 {
+	if s.TruePositives+s.FalseNegatives == 0 {
+		return 1
+	}
+	return float64(s.TruePositives) / float64(s.TruePositives+s.FalseNegatives)
+}
+
+func (s Score) Add(other Score) Score {
+	return Score{
+		TruePositives:  s.TruePositives + other.TruePositives,
+		FalsePositives: s.FalsePositives + other.FalsePositives,
+		FalseNegatives: s.FalseNegatives + other.FalseNegatives,
+	}
+}
+
+// Load reads the cases of the corpus directory. A case without expected.json expects no finding.
+func Load(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the corpus %s", dir)
+	}
+	var cases []Case
+	for _, entry := range entries {
+		containerfile := filepath.Join(dir, entry.Name(), CONTAINERFILE)
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(containerfile); err != nil {
+			continue
+		}
+		c := Case{Name: entry.Name(), Containerfile: containerfile}
+		bytes, err := os.ReadFile(filepath.Join(dir, entry.Name(), EXPECTED_FILE))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "unable to read the expected findings of %s", c.Name)
+		}
+		if err == nil {
+			if err := json.Unmarshal(bytes, &c.Expected); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse the expected findings of %s", c.Name)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Analyze returns the findings of the Containerfile of the case. The base images are not
+// analyzed, so that the findings don't depend on the images available to the engine.
+func Analyze(ctx context.Context, c Case) []Finding {
+	ctx = analyzer.WithoutBaseImageAnalysis(ctx)
+	findings := []Finding{}
+	for _, result := range analyzer.AnalyzePath(ctx, c.Containerfile) {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		finding := Finding{RuleID: result.RuleID}
+		if result.Line != nil {
+			finding.Line = result.Line.Start
+		}
+		findings = append(findings, finding)
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// Compare scores the actual findings against the expected ones and returns the expected
+// findings which are missing and the unexpected ones.
+func Compare(expected []Finding, actual []Finding) (Score, []Finding, []Finding) {
+	remaining := map[Finding]int{}
+	for _, finding := range expected {
+		remaining[finding]++
+	}
+	score := Score{}
+	var unexpected []Finding
+	for _, finding := range actual {
+		if remaining[finding] > 0 {
+			remaining[finding]--
+			score.TruePositives++
+		} else {
+			unexpected = append(unexpected, finding)
+			score.FalsePositives++
+		}
+	}
+	var missing []Finding
+	for _, finding := range expected {
+		if remaining[finding] > 0 {
+			remaining[finding]--
+			missing = append(missing, finding)
+			score.FalseNegatives++
+		}
+	}
+	return score, missing, unexpected
+}
+
+// Check analyzes every case of the corpus and reports the missing and the unexpected findings
+// as test errors, along with the precision and recall of the whole corpus. When the
+// DOA_UPDATE_CORPUS environment variable is set, the expected findings are rewritten instead.
+func Check(t testing.TB, dir string) Score {
+	t.Helper()
+	cases, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := Score{}
+	for _, c := range cases {
+		actual := Analyze(context.Background(), c)
+		if os.Getenv(UPDATE_ENV) != "" {
+			if err := save(filepath.Join(filepath.Dir(c.Containerfile), EXPECTED_FILE), actual); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		score, missing, unexpected := Compare(c.Expected, actual)
+		for _, finding := range missing {
+			t.Errorf("%s: expected %s at line %d was not reported", c.Name, finding.RuleID, finding.Line)
+		}
+		for _, finding := range unexpected {
+			t.Errorf("%s: unexpected %s at line %d", c.Name, finding.RuleID, finding.Line)
+		}
+		total = total.Add(score)
+	}
+	t.Logf("corpus %s: %d cases, precision %.2f, recall %.2f", dir, len(cases), total.Precision(), total.Recall())
+	return total
+}
+
+// Benchmark analyzes every case of the corpus b.N times.
+func Benchmark(b *testing.B, dir string) {
+	cases, err := Load(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range cases {
+			Analyze(context.Background(), c)
+		}
+	}
+}
+
+func save(path string, findings []Finding) error {
+	bytes, err := json.MarshalIndent(findings, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(bytes, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "unable to write the expected findings %s", path)
+	}
+	return nil
+}
+
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].RuleID < findings[j].RuleID
+	})
+}
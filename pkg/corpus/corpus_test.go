@@ -0,0 +1,46 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package corpus
+
+import "testing"
+
+const CORPUS_DIR = "testdata/corpus"
+
+func TestCorpus(t *testing.T) {
+	Check(t, CORPUS_DIR)
+}
+
+func TestCompare(t *testing.T) {
+	score, missing, unexpected := Compare(
+		[]Finding{{RuleID: "user-root"}, {RuleID: "chown-group", Line: 3}},
+		[]Finding{{RuleID: "chown-group", Line: 3}, {RuleID: "privileged-port", Line: 5}},
+	)
+	if score.TruePositives != 1 || score.FalsePositives != 1 || score.FalseNegatives != 1 {
+		t.Errorf("Unexpected score %v", score)
+	}
+	if len(missing) != 1 || missing[0].RuleID != "user-root" || len(unexpected) != 1 || unexpected[0].RuleID != "privileged-port" {
+		t.Errorf("Unexpected missing %v and unexpected %v findings", missing, unexpected)
+	}
+	if score.Precision() != 0.5 || score.Recall() != 0.5 {
+		t.Errorf("Expected precision and recall of 0.5 but they were %.2f and %.2f", score.Precision(), score.Recall())
+	}
+}
+
+func BenchmarkCorpus(b *testing.B) {
+	Benchmark(b, CORPUS_DIR)
+}
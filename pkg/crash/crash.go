@@ -0,0 +1,180 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package crash explains the errors logged by a crashing container, e.g. in CrashLoopBackOff,
+// with the findings of the analysis of its Containerfile most likely responsible for them.
+ package crash
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/testrun"
+)
+
+// The kinds of the errors, besides testrun.KIND_PERMISSION_DENIED and testrun.KIND_READ_ONLY
+const (
+	KIND_PORT_BIND   = "port-bind"
+	KIND_USER_LOOKUP = "user-lookup"
+)
+
+var (
+	portBindRegexp = regexp.MustCompile(`(?i)\b(bind|listen)\b.*(permission denied|EACCES|address already in use|EADDRINUSE)|(permission denied|EACCES|address already in use|EADDRINUSE).*\b(bind|listen)\b`)
+	// portRegexp matches the port following the bind or listen call, rather than the timestamp
+	portRegexp = regexp.MustCompile(`(?i)\b(?:bind|listen)\b.*?(?:port\s+|:)(\d{1,5})\b`)
+	userRegexp = regexp.MustCompile(`(?i)unable to find user|no matching entries in passwd file|cannot find name for user ID|I have no name!|getpwuid|no user exists for uid|unknown uid|user .* does not exist`)
+)
+
+// kindRules are the rules which may cause each kind of error, the most likely first
+var kindRules = map[string][]string{
+	testrun.KIND_PERMISSION_DENIED: {"uid-bound-ownership", "chown-group", "chmod-group-permission", "copy-ownership-fix",
+		"install-dir-write", "pid-file-permission", "database-data-dir", "web-server-non-root", "sudo-su"},
+	testrun.KIND_READ_ONLY: {"writable-image-path", "runtime-log-file", "runtime-pid-file", "runtime-temp-file"},
+	KIND_PORT_BIND:         {"privileged-port", "web-server-non-root", "pod-port-conflict", "port-mismatch"},
+	KIND_USER_LOOKUP:       {"user-not-created", "user-low-uid", "uid-bound-ownership"},
+}
+
+// Cause is an error logged by the container and its likely causes in the Containerfile.
+type Cause struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	// Line is the instruction which set the permissions of the path, or made the container
+	// access it, see analyzer.LocatePath
+	Line *analyzer.Line `json:"line,omitempty"`
+	// Rules are the rules which may cause the error, the most likely first
+	Rules []string `json:"rules"`
+	// Results are the failed results of these rules, the most likely responsible first
+	Results []analyzer.Result `json:"results"`
+}
+
+// ParseLogs returns the permission, port binding and user lookup errors of the logs, each line
+// being reported once.
+func ParseLogs(logs []byte) []Cause {
+	causes := []Cause{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(logs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		switch {
+		case portBindRegexp.MatchString(line):
+			cause := Cause{Kind: KIND_PORT_BIND, Message: line}
+			if match := portRegexp.FindStringSubmatch(line); match != nil {
+				cause.Port, _ = strconv.Atoi(match[1])
+			}
+			causes = append(causes, cause)
+		case userRegexp.MatchString(line):
+			causes = append(causes, Cause{Kind: KIND_USER_LOOKUP, Message: line})
+		default:
+			for _, found := range testrun.ParseErrors([]byte(line)) {
+				causes = append(causes, Cause{Kind: found.Kind, Message: found.Message, Path: found.Path})
+			}
+		}
+	}
+	for i := range causes {
+		causes[i].Rules = kindRules[causes[i].Kind]
+	}
+	return causes
+}
+
+// Explain returns the errors of the logs with the failed results responsible for them among the
+// results of the analysis of the Containerfile node.
+func Explain(ctx context.Context, node *parser.Node, results []analyzer.Result, logs []byte) []Cause {
+	causes := ParseLogs(logs)
+	for i := range causes {
+		cause := &causes[i]
+		if cause.Path != "" {
+			if line, ok := analyzer.LocatePath(ctx, node, cause.Path); ok {
+				cause.Line = &line
+			}
+		}
+		cause.Results = []analyzer.Result{}
+		for _, result := range results {
+			if result.Status == analyzer.StatusFailed && rank(cause.Rules, result.RuleID) >= 0 {
+				cause.Results = append(cause.Results, result)
+			}
+		}
+		sort.SliceStable(cause.Results, func(a, b int) bool {
+			return relevance(*cause, cause.Results[a]) > relevance(*cause, cause.Results[b])
+		})
+	}
+	return causes
+}
+
+// relevance ranks the results of the cause: the results on the instruction of its path first,
+// then the ones mentioning its path or port, then by the likelihood of their rule.
+func relevance(cause Cause, result analyzer.Result) int {
+	score := len(cause.Rules) - rank(cause.Rules, result.RuleID)
+	if cause.Line != nil && result.Line != nil && result.Line.Start == cause.Line.Start {
+		score += 200
+	}
+	switch {
+	case cause.Path != "" && mentions(result.Description, cause.Path):
+		score += 100
+	case cause.Port != 0 && strings.Contains(result.Description, strconv.Itoa(cause.Port)):
+		score += 100
+	}
+	return score
+}
+
+// mentions returns whether the description mentions the path or one of its parent directories.
+func mentions(description string, path string) bool {
+	for path != "/" && path != "." {
+		if strings.Contains(description, path) {
+			return true
+		}
+		path = filepath.Dir(path)
+	}
+	return false
+}
+
+func rank(rules []string, id string) int {
+	for i, rule := range rules {
+		if rule == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExplainFile analyzes the Containerfile and explains the errors of the logs with its results,
+// see Explain. The context carries the analysis settings, e.g. the build context.
+func ExplainFile(ctx context.Context, file string, logs []byte) ([]Cause, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	results := analyzer.AnalyzeReader(ctx, file, bytes.NewReader(content))
+	return Explain(ctx, res.AST, results, logs), nil
+}
@@ -0,0 +1,79 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package crash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/testrun"
+)
+
+func TestParseLogs(t *testing.T) {
+	logs := `2023/05/02 10:12:01 [emerg] 1#1: bind() to 0.0.0.0:80 failed (13: Permission denied)
+Error: listen EADDRINUSE: address already in use :::8080
+whoami: cannot find name for user ID 1000680000
+mkdir: cannot create directory '/app/cache': Read-only file system
+mkdir: cannot create directory '/app/cache': Read-only file system
+starting
+`
+	causes := ParseLogs([]byte(logs))
+	if len(causes) != 4 {
+		t.Fatalf("Expected 4 causes but got %v", causes)
+	}
+	if causes[0].Kind != KIND_PORT_BIND || causes[0].Port != 80 {
+		t.Errorf("Expected the bind error of the port 80 but got %v", causes[0])
+	}
+	if causes[1].Kind != KIND_PORT_BIND || causes[1].Port != 8080 {
+		t.Errorf("Expected the bind error of the port 8080 but got %v", causes[1])
+	}
+	if causes[2].Kind != KIND_USER_LOOKUP || causes[2].Rules[0] != "user-not-created" {
+		t.Errorf("Expected a user lookup error but got %v", causes[2])
+	}
+	if causes[3].Kind != testrun.KIND_READ_ONLY || causes[3].Path != "/app/cache" {
+		t.Errorf("Expected a read-only error on /app/cache but got %v", causes[3])
+	}
+}
+
+func TestExplainFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Containerfile")
+	content := "FROM ubi9\nEXPOSE 80\nUSER 1001\nRUN mkdir /app && chown 1001 /app\nCMD [\"nginx\"]\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logs := "bind() to 0.0.0.0:80 failed (13: Permission denied)\nopen() \"/app/nginx.pid\" failed (13: Permission denied)\n"
+	causes, err := ExplainFile(context.Background(), file, []byte(logs))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(causes) != 2 {
+		t.Fatalf("Expected 2 causes but got %v", causes)
+	}
+	if len(causes[0].Results) == 0 || causes[0].Results[0].RuleID != "privileged-port" || causes[0].Results[0].Line.Start != 2 {
+		t.Errorf("Expected the privileged port of the line 2 but got %v", causes[0].Results)
+	}
+	if causes[1].Line == nil || causes[1].Line.Start != 4 {
+		t.Errorf("Expected the path located on the line 4 but got %v", causes[1].Line)
+	}
+	if len(causes[1].Results) == 0 || causes[1].Results[0].RuleID != "uid-bound-ownership" {
+		t.Errorf("Expected the ownership bound to the UID but got %v", causes[1].Results)
+	}
+}
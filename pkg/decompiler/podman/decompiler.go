@@ -19,10 +19,14 @@
 
 import (
 	"context"
+	"fmt"
 	"github.com/containers/common/pkg/config"
 	"github.com/containers/podman/v4/pkg/bindings"
 	"github.com/containers/podman/v4/pkg/bindings/images"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -47,53 +51,78 @@ func (o OrderedHistory) Swap(i, j int) {
 
 type PodmanProvider struct{}
 
-func getPodmanConnection() (string, string) {
-	conf, err := config.NewConfig("")
-	if err != nil {
-		return "", ""
+// ROOTFUL_SOCKET is the socket of the Podman service run by root
+const ROOTFUL_SOCKET = "/run/podman/podman.sock"
+
+type connection struct {
+	uri      string
+	identity string
+}
+
+// getPodmanConnections returns the Podman services to query, in order: the active service of
+// containers.conf (e.g. the Podman machine of Podman Desktop on macOS and Windows), the service
+// set by CONTAINER_HOST and the local rootless and rootful sockets.
+func getPodmanConnections() []connection {
+	var connections []connection
+	if conf, err := config.NewConfig(""); err == nil && conf.Engine.ActiveService != "" {
+		destination := conf.Engine.ServiceDestinations[conf.Engine.ActiveService]
+		connections = append(connections, connection{uri: destination.URI, identity: destination.Identity})
+	}
+	if uri := os.Getenv("CONTAINER_HOST"); uri != "" {
+		connections = append(connections, connection{uri: uri, identity: os.Getenv("CONTAINER_SSHKEY")})
 	}
-	uri := ""
-	identity := ""
-	if conf.Engine.ActiveService != "" {
-		uri = conf.Engine.ServiceDestinations[conf.Engine.ActiveService].URI
-		identity = conf.Engine.ServiceDestinations[conf.Engine.ActiveService].Identity
+	var sockets []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		sockets = append(sockets, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	} else if runtime.GOOS == "linux" {
+		sockets = append(sockets, fmt.Sprintf("/run/user/%d/podman/podman.sock", os.Getuid()))
 	}
-	return uri, identity
+	sockets = append(sockets, ROOTFUL_SOCKET)
+	for _, socket := range sockets {
+		if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			connections = append(connections, connection{uri: "unix://" + socket})
+		}
+	}
+	return connections
 }
 
+// Decompile rebuilds the Containerfile of the image from its history, querying the Podman
+// services so that images built locally can be analyzed without pushing them to a registry.
 func (p PodmanProvider) Decompile(imageName string) (*parser.Node, error) {
-	uri, identity := getPodmanConnection()
-	if uri != "" {
-		ctx, err := bindings.NewConnectionWithIdentity(context.Background(), uri, identity, false)
+	for _, conn := range getPodmanConnections() {
+		ctx, err := bindings.NewConnectionWithIdentity(context.Background(), conn.uri, conn.identity, false)
 		if err != nil {
-			return nil, nil
+			continue
 		}
 		image, err := images.GetImage(ctx, imageName, nil)
 		if err != nil {
-			return nil, nil
+			continue
 		}
+		return historyToNode(image.History)
+	}
+	return nil, nil
+}
 
-		root := &parser.Node{}
-		sort.Sort(OrderedHistory(image.History))
-		for _, hist := range image.History {
-			if hist.Comment != "" && strings.HasPrefix(strings.ToUpper(hist.Comment), utils.FROM_INSTRUCTION) &&
-				!hist.EmptyLayer {
-				err := decompilerutils.Line2Node(hist.Comment, root)
+func historyToNode(history []v1.History) (*parser.Node, error) {
+	root := &parser.Node{}
+	sort.Sort(OrderedHistory(history))
+	for _, hist := range history {
+		if hist.Comment != "" && strings.HasPrefix(strings.ToUpper(hist.Comment), utils.FROM_INSTRUCTION) &&
+			!hist.EmptyLayer {
+			err := decompilerutils.Line2Node(hist.Comment, root)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hist.CreatedBy != "" {
+			cmd := decompilerutils.ExtractCmd(hist.CreatedBy)
+			if cmd != "" {
+				err := decompilerutils.Line2Node(cmd, root)
 				if err != nil {
 					return nil, err
 				}
 			}
-			if hist.CreatedBy != "" {
-				cmd := decompilerutils.ExtractCmd(hist.CreatedBy)
-				if cmd != "" {
-					err := decompilerutils.Line2Node(cmd, root)
-					if err != nil {
-						return nil, err
-					}
-				}
-			}
 		}
-		return root, nil
 	}
-	return nil, nil
+	return root, nil
 }
@@ -20,6 +20,7 @@
 import (
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -46,6 +47,35 @@ func (o OrderedHistory) Swap(i, j int) {
 
 type RegistryProvider struct{}
 
+// configs caches the config blobs of the images by digest, it is shared by the images analyzed
+// concurrently whose tags often point to the same image, e.g. the tags of an image stream
+var configs = struct {
+	sync.Mutex
+	files map[v1.Hash]*v1.ConfigFile
+}{files: map[v1.Hash]*v1.ConfigFile{}}
+
+// configFile returns the config of the image, pulled once per digest.
+func configFile(img v1.Image) (*v1.ConfigFile, error) {
+	digest, err := img.ConfigName()
+	if err != nil {
+		return nil, err
+	}
+	configs.Lock()
+	cached, ok := configs.files[digest]
+	configs.Unlock()
+	if ok {
+		return cached, nil
+	}
+	file, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	configs.Lock()
+	configs.files[digest] = file
+	configs.Unlock()
+	return file, nil
+}
+
 func (p RegistryProvider) Decompile(imageName string) (*parser.Node, error) {
 	ref, err := name.ParseReference(imageName)
 	if err != nil {
@@ -57,14 +87,15 @@ func (p RegistryProvider) Decompile(imageName string) (*parser.Node, error) {
 		return nil, nil
 	}
 
-	configFile, err := img.ConfigFile()
+	config, err := configFile(img)
 	if err != nil {
 		return nil, err
 	}
 
 	root := &parser.Node{}
 
-	history := configFile.History
+	// the cached config is shared, its history is sorted on a copy
+	history := append([]v1.History{}, config.History...)
 	sort.Sort(OrderedHistory(history))
 	for _, hist := range history {
 		if hist.Comment != "" && strings.HasPrefix(strings.ToUpper(hist.Comment), utils.FROM_INSTRUCTION) &&
@@ -85,8 +116,8 @@ func (p RegistryProvider) Decompile(imageName string) (*parser.Node, error) {
 		}
 	}
 
-	if configFile.Config.User != "" {
-		err := decompilerutils.Line2Node(utils.USER_INSTRUCTION+configFile.Config.User, root)
+	if config.Config.User != "" {
+		err := decompilerutils.Line2Node(utils.USER_INSTRUCTION+config.Config.User, root)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,143 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+// Package i18n translates the messages of the findings. Messages are identified by their English
+// format string, translations are loaded from the JSON files of the locales directory.
+ package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales
+var locales embed.FS
+
+type languageKeyType struct{}
+
+var languageKey languageKeyType
+
+// catalogs maps a language to its translations, indexed by the English format string
+var catalogs = map[language.Tag]map[string]string{}
+
+// supported lists the languages with a catalog, English first as it is the fallback
+var supported = []language.Tag{language.English}
+
+var matcher = language.NewMatcher(supported)
+
+func init() {
+	if err := load(); err != nil {
+		panic(err)
+	}
+}
+
+func load() error {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		tag, err := language.Parse(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return errors.Wrapf(err, "invalid locale file %s", entry.Name())
+		}
+		content, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return err
+		}
+		translations := map[string]string{}
+		if err := json.Unmarshal(content, &translations); err != nil {
+			return errors.Wrapf(err, "invalid locale file %s", entry.Name())
+		}
+		Register(tag, translations)
+	}
+	return nil
+}
+
+// Register adds translations for the given language.
+func Register(tag language.Tag, translations map[string]string) {
+	catalog, ok := catalogs[tag]
+	if !ok {
+		catalog = map[string]string{}
+		catalogs[tag] = catalog
+		if tag != language.English {
+			supported = append(supported, tag)
+			matcher = language.NewMatcher(supported)
+		}
+	}
+	for key, translation := range translations {
+		catalog[key] = translation
+	}
+}
+
+// Languages returns the languages with a translation, English included.
+func Languages() []string {
+	var languages []string
+	for _, tag := range supported {
+		languages = append(languages, tag.String())
+	}
+	return languages
+}
+
+// WithLanguage returns a context whose messages are translated in the language closest to lang,
+// e.g. "it-IT" for a catalog of "it". It fails when no catalog matches the language.
+func WithLanguage(ctx context.Context, lang string) (context.Context, error) {
+	if lang == "" {
+		return ctx, nil
+	}
+	requested, err := language.Parse(lang)
+	if err != nil {
+		return ctx, errors.Errorf("unknown language %s", lang)
+	}
+	_, index, confidence := matcher.Match(requested)
+	if confidence == language.No {
+		return ctx, errors.Errorf("no translation for the language %s, the languages are %s", lang, strings.Join(Languages(), ", "))
+	}
+	return context.WithValue(ctx, languageKey, supported[index]), nil
+}
+
+// Translate returns the translation of text in the language of the context.
+func Translate(ctx context.Context, text string) string {
+	tag, ok := ctx.Value(languageKey).(language.Tag)
+	if !ok {
+		return text
+	}
+	if translation, ok := catalogs[tag][text]; ok && translation != "" {
+		return translation
+	}
+	// messages written on several lines in the source code are translated as single lines
+	if translation, ok := catalogs[tag][strings.Join(strings.Fields(text), " ")]; ok && translation != "" {
+		return translation
+	}
+	return text
+}
+
+// Sprintf formats the translation of format in the language of the context. Translations can
+// reorder the arguments with explicit indexes, e.g. %[2]s.
+func Sprintf(ctx context.Context, format string, args ...interface{}) string {
+	return fmt.Sprintf(Translate(ctx, format), args...)
+}
@@ -0,0 +1,68 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package i18n
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMessagesAreTranslated(t *testing.T) {
+	Register(language.Italian, map[string]string{
+		"port %d exposed":        "porta %d esposta",
+		"first line second line": "prima riga seconda riga",
+		"%s set on %s":           "%[2]s impostato su %[1]s",
+	})
+	ctx, err := WithLanguage(context.Background(), "it-IT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := Sprintf(ctx, "port %d exposed", 80); message != "porta 80 esposta" {
+		t.Errorf("Unexpected translation %s", message)
+	}
+	if message := Translate(ctx, "first line\n\t\tsecond line"); message != "prima riga seconda riga" {
+		t.Errorf("Unexpected translation of a multi-line message %s", message)
+	}
+	if message := Sprintf(ctx, "%s set on %s", "owner", "/app"); message != "/app impostato su owner" {
+		t.Errorf("Unexpected translation with reordered arguments %s", message)
+	}
+	if message := Sprintf(ctx, "not translated %d", 1); message != "not translated 1" {
+		t.Errorf("Expected untranslated message to fall back to English but it was %s", message)
+	}
+}
+
+func TestLanguageWithoutTranslation(t *testing.T) {
+	if _, err := WithLanguage(context.Background(), "ja"); err == nil {
+		t.Error("Expected an error for a language without translation")
+	}
+	ctx, err := WithLanguage(context.Background(), "en-US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := Sprintf(ctx, "port %d exposed", 80); message != "port 80 exposed" {
+		t.Errorf("Expected English message but it was %s", message)
+	}
+}
+
+func TestInvalidLanguage(t *testing.T) {
+	if _, err := WithLanguage(context.Background(), "not a language"); err == nil {
+		t.Error("Expected an error for an invalid language")
+	}
+}
@@ -0,0 +1,165 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+// Package machine implements the protocol used by long-lived clients (e.g. the Podman Desktop
+// extension) to run several analyses with a single doa process.
+//
+// Each message, in both directions, is a frame made of the length of the JSON payload as a
+// 4 bytes big-endian unsigned integer followed by the payload itself.
+ package machine
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/i18n"
+)
+
+// MAX_FRAME_SIZE protects against reading garbage as a huge length
+const MAX_FRAME_SIZE = 64 * 1024 * 1024
+
+// Request asks for the analysis of a Containerfile path, an image or an inline Containerfile
+// content. Exactly one of File, Image and Content has to be set.
+type Request struct {
+	ID      string `json:"id"`
+	File    string `json:"file,omitempty"`
+	Image   string `json:"image,omitempty"`
+	Content string `json:"content,omitempty"`
+	// Lang is the language of the reported issues, the one of the settings by default
+	Lang string `json:"lang,omitempty"`
+}
+
+// Setup returns the context of the analysis of a request and the function finishing it, which
+// filters the results and releases the settings, e.g. the configuration, the plugins, the
+// platform, the packs, the feedback file and the minimum confidence of doa analyze. It is called
+// for every request, so that the changes of the configuration are applied without restarting.
+type Setup func() (context.Context, func([]analyzer.Result) []analyzer.Result, error)
+
+// DefaultSetup analyzes the requests without settings.
+func DefaultSetup() (context.Context, func([]analyzer.Result) []analyzer.Result, error) {
+	return context.Background(), func(results []analyzer.Result) []analyzer.Result { return results }, nil
+}
+
+type Response struct {
+	ID      string            `json:"id"`
+	Results []analyzer.Result `json:"results"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// Serve reads requests from in and writes a response for each of them to out until in is closed,
+// the requests are analyzed with the settings of setup.
+func Serve(in io.Reader, out io.Writer, setup Setup) error {
+	for {
+		payload, err := ReadFrame(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var response Response
+		request := Request{}
+		if err := json.Unmarshal(payload, &request); err != nil {
+			response.Error = "invalid request: " + err.Error()
+		} else {
+			response = Handle(request, setup)
+		}
+
+		bytes, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		if err := WriteFrame(out, bytes); err != nil {
+			return err
+		}
+	}
+}
+
+// Handle analyzes the request with the settings of setup, the language of the request overriding
+// the one of the settings.
+func Handle(request Request, setup Setup) Response {
+	response := Response{
+		ID: request.ID,
+	}
+	set := 0
+	for _, value := range []string{request.File, request.Image, request.Content} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		response.Error = "exactly one of file, image and content must be set"
+		return response
+	}
+
+	ctx, finish, err := setup()
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+	if request.Lang != "" {
+		if ctx, err = i18n.WithLanguage(ctx, request.Lang); err != nil {
+			finish(nil)
+			response.Error = err.Error()
+			return response
+		}
+	}
+
+	var results []analyzer.Result
+	switch {
+	case request.File != "":
+		results = analyzer.AnalyzePath(ctx, request.File)
+	case request.Image != "":
+		results = analyzer.AnalyzeImage(ctx, request.Image)
+	default:
+		results = analyzer.AnalyzeReader(ctx, "content", strings.NewReader(request.Content))
+	}
+	response.Results = finish(results)
+	return response
+}
+
+func ReadFrame(in io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(in, binary.BigEndian, &size); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated frame length")
+		}
+		return nil, err
+	}
+	if size > MAX_FRAME_SIZE {
+		return nil, errors.Errorf("frame of %d bytes exceeds the maximum size", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(in, payload); err != nil {
+		return nil, errors.Wrap(err, "truncated frame")
+	}
+	return payload, nil
+}
+
+func WriteFrame(out io.Writer, payload []byte) error {
+	if err := binary.Write(out, binary.BigEndian, uint32(len(payload))); err != nil {
+		return err
+	}
+	_, err := out.Write(payload)
+	return err
+}
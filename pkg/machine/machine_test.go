@@ -0,0 +1,103 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package machine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestServeAnswersEachRequest(t *testing.T) {
+	in := &bytes.Buffer{}
+	for _, request := range []Request{
+		{ID: "1", Content: "FROM scratch\nUSER root\n"},
+		{ID: "2"},
+	} {
+		payload, _ := json.Marshal(request)
+		if err := WriteFrame(in, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	if err := Serve(in, out, DefaultSetup); err != nil {
+		t.Fatal(err)
+	}
+
+	first := readResponse(t, out)
+	if first.ID != "1" || first.Error != "" || len(first.Results) != 1 {
+		t.Errorf("Expected one result for request 1 but got %+v", first)
+	}
+	second := readResponse(t, out)
+	if second.ID != "2" || second.Error == "" {
+		t.Errorf("Expected an error for request 2 but got %+v", second)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected exactly two responses")
+	}
+}
+
+func TestHandleAppliesTheSettings(t *testing.T) {
+	finished := 0
+	setup := func() (context.Context, func([]analyzer.Result) []analyzer.Result, error) {
+		return context.Background(), func(results []analyzer.Result) []analyzer.Result {
+			finished++
+			// e.g. the configuration disables the rules
+			return []analyzer.Result{}
+		}, nil
+	}
+	response := Handle(Request{ID: "1", Content: "FROM scratch\nUSER root\n"}, setup)
+	if response.Error != "" || len(response.Results) != 0 || finished != 1 {
+		t.Errorf("Expected the results to be filtered by the settings but got %+v", response)
+	}
+
+	response = Handle(Request{ID: "2", Content: "FROM scratch\n", Lang: "ja"}, setup)
+	if response.Error == "" || finished != 2 {
+		t.Errorf("Expected an error for a language without translation but got %+v", response)
+	}
+
+	failing := func() (context.Context, func([]analyzer.Result) []analyzer.Result, error) {
+		return nil, nil, errors.New("invalid configuration")
+	}
+	if response := Handle(Request{ID: "3", Content: "FROM scratch\n"}, failing); response.Error != "invalid configuration" {
+		t.Errorf("Expected the error of the settings but got %+v", response)
+	}
+}
+
+func TestServeFailsOnTruncatedFrame(t *testing.T) {
+	in := bytes.NewBuffer([]byte{0, 0, 0, 10, '{'})
+	if err := Serve(in, &bytes.Buffer{}, DefaultSetup); err == nil {
+		t.Error("Expected an error on truncated frame")
+	}
+}
+
+func readResponse(t *testing.T, out *bytes.Buffer) Response {
+	payload, err := ReadFrame(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := Response{}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
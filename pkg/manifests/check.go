@@ -0,0 +1,203 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Container is a container of a workload found in the manifests.
+type Container struct {
+	// Workload is the kind and the name of the resource, e.g. Deployment/web
+	Workload string
+	Name     string
+	Image    string
+	Ports    []Port
+	// RunAsUser and RunAsNonRoot are the effective settings, the ones of the container
+	// overriding the ones of the pod
+	RunAsUser    *int64
+	RunAsNonRoot *bool
+}
+
+// Inconsistency is a difference between a container spec and the Containerfile of its image.
+type Inconsistency struct {
+	Workload  string
+	Container string
+	Message   string
+}
+
+func (i Inconsistency) String() string {
+	return fmt.Sprintf("%s container %s: %s", i.Workload, i.Container, i.Message)
+}
+
+type podSpec struct {
+	SecurityContext securityContext `yaml:"securityContext"`
+	InitContainers  []struct {
+		Image string `yaml:"image"`
+	} `yaml:"initContainers"`
+	Containers []struct {
+		Name  string `yaml:"name"`
+		Image string `yaml:"image"`
+		Ports []struct {
+			ContainerPort int    `yaml:"containerPort"`
+			Protocol      string `yaml:"protocol"`
+		} `yaml:"ports"`
+		SecurityContext securityContext `yaml:"securityContext"`
+	} `yaml:"containers"`
+}
+
+type securityContext struct {
+	RunAsUser    *int64 `yaml:"runAsUser"`
+	RunAsNonRoot *bool  `yaml:"runAsNonRoot"`
+}
+
+type podTemplate struct {
+	Spec podSpec `yaml:"spec"`
+}
+
+// workload holds the fields of the workloads the pod spec can be read from: a Pod, the template
+// of a Deployment, StatefulSet, DaemonSet, Job or DeploymentConfig, or the job template of a CronJob.
+type workload struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name            string `yaml:"name"`
+		OwnerReferences []struct {
+			Kind string `yaml:"kind"`
+			Name string `yaml:"name"`
+		} `yaml:"ownerReferences"`
+	} `yaml:"metadata"`
+	Spec struct {
+		podSpec     `yaml:",inline"`
+		Template    podTemplate `yaml:"template"`
+		JobTemplate struct {
+			Spec struct {
+				Template podTemplate `yaml:"template"`
+			} `yaml:"spec"`
+		} `yaml:"jobTemplate"`
+	} `yaml:"spec"`
+}
+
+func (w workload) podSpec() (podSpec, bool) {
+	switch w.Kind {
+	case "Pod":
+		return w.Spec.podSpec, true
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "DeploymentConfig":
+		return w.Spec.Template.Spec, true
+	case "CronJob":
+		return w.Spec.JobTemplate.Spec.Template.Spec, true
+	}
+	return podSpec{}, false
+}
+
+// Containers returns the containers of the workloads of the multi-document YAML manifests.
+func Containers(content []byte) ([]Container, error) {
+	var containers []Container
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var res workload
+		err := decoder.Decode(&res)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the manifests")
+		}
+		spec, ok := res.podSpec()
+		if !ok {
+			continue
+		}
+		for _, c := range spec.Containers {
+			container := Container{
+				Workload:     res.Kind + "/" + res.Metadata.Name,
+				Name:         c.Name,
+				Image:        c.Image,
+				RunAsUser:    spec.SecurityContext.RunAsUser,
+				RunAsNonRoot: spec.SecurityContext.RunAsNonRoot,
+			}
+			if c.SecurityContext.RunAsUser != nil {
+				container.RunAsUser = c.SecurityContext.RunAsUser
+			}
+			if c.SecurityContext.RunAsNonRoot != nil {
+				container.RunAsNonRoot = c.SecurityContext.RunAsNonRoot
+			}
+			for _, port := range c.Ports {
+				protocol := strings.ToUpper(port.Protocol)
+				if protocol == "" {
+					protocol = "TCP"
+				}
+				container.Ports = append(container.Ports, Port{Number: port.ContainerPort, Protocol: protocol})
+			}
+			containers = append(containers, container)
+		}
+	}
+	return containers, nil
+}
+
+// Check compares the container spec with the image built from the Containerfile and returns the
+// securityContext and port inconsistencies.
+func Check(container Container, image *Image) []Inconsistency {
+	var messages []string
+	user, numeric := image.User, true
+	uid, err := strconv.ParseInt(strings.SplitN(user, ":", 2)[0], 10, 64)
+	if err != nil {
+		numeric = false
+	}
+	root := user == "" || user == "root" || (numeric && uid == 0)
+
+	if container.RunAsUser != nil {
+		if *container.RunAsUser == 0 && !root {
+			messages = append(messages, fmt.Sprintf("runAsUser 0 runs as root the image built to run as USER %s", user))
+		}
+	} else if container.RunAsNonRoot != nil && *container.RunAsNonRoot {
+		if root {
+			messages = append(messages, "runAsNonRoot is set but the image runs as root, the container won't start")
+		} else if !numeric {
+			messages = append(messages, fmt.Sprintf("runAsNonRoot is set but USER %s is not numeric, the kubelet can't verify it and the container won't start", user))
+		}
+	}
+
+	exposed := map[Port]bool{}
+	for _, port := range image.Ports {
+		exposed[port] = true
+	}
+	declared := map[Port]bool{}
+	for _, port := range container.Ports {
+		declared[port] = true
+		if !exposed[port] {
+			messages = append(messages, fmt.Sprintf("containerPort %d/%s is not exposed by the Containerfile", port.Number, port.Protocol))
+		}
+	}
+	for _, port := range image.Ports {
+		if !declared[port] {
+			messages = append(messages, fmt.Sprintf("port %d/%s exposed by the Containerfile is not declared in the container ports", port.Number, port.Protocol))
+		}
+	}
+
+	var inconsistencies []Inconsistency
+	for _, message := range messages {
+		inconsistencies = append(inconsistencies, Inconsistency{Workload: container.Workload, Container: container.Name, Message: message})
+	}
+	return inconsistencies
+}
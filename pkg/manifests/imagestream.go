@@ -0,0 +1,74 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package manifests
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// imageStream is the part of an ImageStream listing the images of its tags
+type imageStream struct {
+	Status struct {
+		Tags []struct {
+			Tag   string `json:"tag"`
+			Items []struct {
+				DockerImageReference string `json:"dockerImageReference"`
+			} `json:"items"`
+		} `json:"tags"`
+	} `json:"status"`
+}
+
+// ImageStreamImages returns the images of the tags of the ImageStream, read from the cluster of
+// the current context with the oc binary or with kubectl when oc is not installed.
+func ImageStreamImages(name string, namespace string) ([]string, error) {
+	args := []string{"get", "imagestream", name, "--output", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	content, err := clusterOutput("read the image stream "+name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseImageStreamImages(content)
+}
+
+// parseImageStreamImages returns the current image of each tag of the ImageStream, the tags
+// pointing to the same image being reported once.
+func parseImageStreamImages(content []byte) ([]string, error) {
+	var stream imageStream
+	if err := json.Unmarshal(content, &stream); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the image stream")
+	}
+	var images []string
+	seen := map[string]bool{}
+	for _, tag := range stream.Status.Tags {
+		// the first item is the current image of the tag, the others are its history
+		if len(tag.Items) == 0 || tag.Items[0].DockerImageReference == "" {
+			continue
+		}
+		image := tag.Items[0].DockerImageReference
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
@@ -0,0 +1,290 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package manifests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"gopkg.in/yaml.v3"
+)
+
+// dockerfileKeyRegexp matches the keys, and the names of the params and environment variables,
+// whose value may be a Containerfile, e.g. dockerfile or DOCKERFILE_CONTENT
+var dockerfileKeyRegexp = regexp.MustCompile(`(?i)(docker|container)[-_]?file`)
+
+// heredocRegexp matches the here-documents of the scripts, e.g. cat > Dockerfile <<'EOF'
+var heredocRegexp = regexp.MustCompile(`<<(-?)\s*['"]?(\w+)['"]?`)
+
+// stdinBuildRegexp matches the builds reading the Containerfile from stdin, e.g. docker build -f - .
+var stdinBuildRegexp = regexp.MustCompile(`\bbuild\b.*\s(?:-f|--file)[ =]-(?:\s|$)`)
+
+// InlineDockerfile is a Containerfile embedded in a manifest: the spec.source.dockerfile field of
+// a BuildConfig using the Docker strategy, a Tekton param or a here-document of a CI script.
+type InlineDockerfile struct {
+	// Resource is the kind and the name of the resource, e.g. BuildConfig/web, or the job of a
+	// GitHub workflow, e.g. Workflow/ci jobs.build
+	Resource string
+	Content  string
+	// Line is the line of the field holding the Containerfile in the manifests
+	Line int
+	// first is the line of the manifests holding the first line of the Containerfile, only known
+	// for literal block scalars (dockerfile: |) whose lines match the lines of the manifests
+	first int
+}
+
+type embeddingResource struct {
+	Kind     string `yaml:"kind"`
+	Name     string `yaml:"name"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Strategy struct {
+			Type           string     `yaml:"type"`
+			DockerStrategy *yaml.Node `yaml:"dockerStrategy"`
+		} `yaml:"strategy"`
+	} `yaml:"spec"`
+}
+
+// IsManifest reports whether the file to analyze is a YAML manifest rather than a Containerfile.
+func IsManifest(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml")
+}
+
+// InlineDockerfiles returns the Containerfiles embedded in the multi-document YAML manifests, the
+// items of the List documents included, e.g. the output of oc get bc -o yaml. They are found in
+// the BuildConfigs, in the resources, e.g. Tekton PipelineRuns, and in the GitHub workflows.
+func InlineDockerfiles(content []byte) ([]InlineDockerfile, error) {
+	var dockerfiles []InlineDockerfile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the manifests")
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		found, err := inlineDockerfiles(document.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		dockerfiles = append(dockerfiles, found...)
+	}
+	return dockerfiles, nil
+}
+
+func inlineDockerfiles(node *yaml.Node) ([]InlineDockerfile, error) {
+	var res embeddingResource
+	if err := node.Decode(&res); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the manifests")
+	}
+	switch {
+	case strings.HasSuffix(res.Kind, "List"):
+		var dockerfiles []InlineDockerfile
+		if items := field(node, "items"); items != nil {
+			for _, item := range items.Content {
+				found, err := inlineDockerfiles(item)
+				if err != nil {
+					return nil, err
+				}
+				dockerfiles = append(dockerfiles, found...)
+			}
+		}
+		return dockerfiles, nil
+	case res.Kind == "BuildConfig":
+		// the dockerfile of the other strategies is ignored by the builds
+		if res.Spec.Strategy.DockerStrategy == nil && res.Spec.Strategy.Type != "Docker" {
+			return nil, nil
+		}
+		dockerfile := field(field(field(node, "spec"), "source"), "dockerfile")
+		if dockerfile == nil || dockerfile.Kind != yaml.ScalarNode || strings.TrimSpace(dockerfile.Value) == "" {
+			return nil, nil
+		}
+		return []InlineDockerfile{newInlineDockerfile(res.Kind+"/"+res.Metadata.Name, dockerfile, dockerfile.Value, 0)}, nil
+	case res.Kind != "":
+		return embedded(res.Kind+"/"+res.Metadata.Name, node), nil
+	case field(node, "jobs") != nil:
+		// a GitHub workflow, every job being reported on its own
+		var dockerfiles []InlineDockerfile
+		jobs := field(node, "jobs")
+		for i := 0; jobs.Kind == yaml.MappingNode && i+1 < len(jobs.Content); i += 2 {
+			dockerfiles = append(dockerfiles, embedded(fmt.Sprintf("Workflow/%s jobs.%s", res.Name, jobs.Content[i].Value), jobs.Content[i+1])...)
+		}
+		return dockerfiles, nil
+	}
+	return nil, nil
+}
+
+func newInlineDockerfile(resource string, node *yaml.Node, content string, offset int) InlineDockerfile {
+	dockerfile := InlineDockerfile{Resource: resource, Content: content, Line: node.Line}
+	if node.Style == yaml.LiteralStyle {
+		// the content of the block starts on the line following the indicator
+		dockerfile.first = node.Line + 1 + offset
+	}
+	return dockerfile
+}
+
+// embedded returns the Containerfiles found below the node: the values of the dockerfile keys, or
+// of the params and environment variables named after them, and the here-documents written to a
+// Containerfile or read by a build in the run and script keys.
+func embedded(resource string, node *yaml.Node) []InlineDockerfile {
+	var dockerfiles []InlineDockerfile
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			dockerfiles = append(dockerfiles, embedded(resource, item)...)
+		}
+	case yaml.MappingNode:
+		name := field(node, "name")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				dockerfiles = append(dockerfiles, embedded(resource, value)...)
+				continue
+			}
+			named := key == "value" && name != nil && dockerfileKeyRegexp.MatchString(name.Value)
+			if (named || dockerfileKeyRegexp.MatchString(key)) && looksLikeDockerfile(value.Value) {
+				dockerfiles = append(dockerfiles, newInlineDockerfile(resource, value, value.Value, 0))
+			} else if key == "run" || key == "script" {
+				dockerfiles = append(dockerfiles, heredocs(resource, value)...)
+			}
+		}
+	}
+	return dockerfiles
+}
+
+// heredocs returns the here-documents of the script written to a Containerfile, e.g.
+// cat > Dockerfile <<EOF, or read by a build, e.g. podman build -f - . <<EOF
+func heredocs(resource string, node *yaml.Node) []InlineDockerfile {
+	var dockerfiles []InlineDockerfile
+	lines := strings.Split(node.Value, "\n")
+	for i := 0; i < len(lines); i++ {
+		match := heredocRegexp.FindStringSubmatch(lines[i])
+		if match == nil || !(dockerfileKeyRegexp.MatchString(lines[i]) || stdinBuildRegexp.MatchString(lines[i])) {
+			continue
+		}
+		stripTabs, delimiter := match[1] == "-", match[2]
+		var body []string
+		end := i + 1
+		for ; end < len(lines); end++ {
+			line := lines[end]
+			if stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if strings.TrimSpace(line) == delimiter {
+				break
+			}
+			body = append(body, line)
+		}
+		content := strings.Join(body, "\n")
+		if looksLikeDockerfile(content) {
+			dockerfiles = append(dockerfiles, newInlineDockerfile(resource, node, content, i+1))
+		}
+		i = end
+	}
+	return dockerfiles
+}
+
+// looksLikeDockerfile reports whether the value is a Containerfile rather than, e.g., the path of
+// a Containerfile: its first instruction is a FROM or an ARG.
+func looksLikeDockerfile(value string) bool {
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		instruction := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		return instruction == "FROM" || instruction == "ARG"
+	}
+	return false
+}
+
+// field returns the value of the key of the mapping node, nil when the node is not a mapping or
+// has no such key.
+func field(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// ManifestLine returns the line of the manifests matching the line of the Containerfile. The lines
+// are only known for literal block scalars, the line of the field holding the Containerfile is
+// returned otherwise.
+func (d InlineDockerfile) ManifestLine(line int) int {
+	if d.first == 0 || line < 1 {
+		return d.Line
+	}
+	return d.first + line - 1
+}
+
+// Locate sets the location in the manifests at path of the results of the inline Containerfile.
+func (d InlineDockerfile) Locate(path string, results []analyzer.Result) []analyzer.Result {
+	located := make([]analyzer.Result, 0, len(results))
+	for _, result := range results {
+		line := 0
+		if result.Line != nil {
+			line = result.Line.Start
+		}
+		result.Manifest = &analyzer.ManifestLocation{Path: path, Resource: d.Resource, Line: d.ManifestLine(line)}
+		located = append(located, result)
+	}
+	return located
+}
+
+// AnalyzeManifests analyzes the Containerfiles embedded in the manifests at path, see
+// InlineDockerfiles. Their build context is not available, the rules checking it are skipped.
+func AnalyzeManifests(ctx context.Context, path string) ([]analyzer.Result, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the manifests %s", path)
+	}
+	dockerfiles, err := InlineDockerfiles(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to analyze %s", path)
+	}
+	if len(dockerfiles) == 0 {
+		return nil, errors.Errorf("no Containerfile found in %s, neither in a BuildConfig nor in a CI pipeline", path)
+	}
+	results := []analyzer.Result{}
+	for _, dockerfile := range dockerfiles {
+		found := analyzer.AnalyzeReader(ctx, path+" "+dockerfile.Resource, strings.NewReader(dockerfile.Content))
+		results = append(results, dockerfile.Locate(path, found)...)
+	}
+	return results, nil
+}
@@ -0,0 +1,140 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package manifests generates the Kubernetes and OpenShift manifests deploying the image built
+// from a Containerfile: a Deployment (or a DeploymentConfig), a Service and a Route, consistent
+// with its exposed ports, its HEALTHCHECK and its USER.
+ package manifests
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+)
+
+type Port struct {
+	Number   int
+	Protocol string
+}
+
+// HealthCheck is the HEALTHCHECK of the image, the durations being the ones of the Containerfile
+// or the defaults of the HEALTHCHECK instruction.
+type HealthCheck struct {
+	// Command is the exec form command, or /bin/sh -c and the shell form command
+	Command     []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// Image is what the final stage of the Containerfile tells about the container.
+type Image struct {
+	Ports       []Port
+	HealthCheck *HealthCheck
+	// User is the last USER of the final stage, empty when it's not set
+	User string
+}
+
+// Inspect reads the exposed ports, the health check and the user of the final stage.
+func Inspect(content []byte) (*Image, error) {
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the Containerfile")
+	}
+	image := &Image{}
+	for _, node := range res.AST.Children {
+		switch strings.ToLower(node.Value) {
+		case "from":
+			// only the final stage matters
+			*image = Image{}
+		case "expose":
+			for n := node.Next; n != nil; n = n.Next {
+				if port, ok := parsePort(n.Value); ok {
+					image.Ports = append(image.Ports, port)
+				}
+			}
+		case "user":
+			if node.Next != nil {
+				image.User = node.Next.Value
+			}
+		case "healthcheck":
+			image.HealthCheck = parseHealthCheck(node)
+		}
+	}
+	return image, nil
+}
+
+func parsePort(value string) (Port, bool) {
+	number, protocol := value, "TCP"
+	if index := strings.Index(value, "/"); index >= 0 {
+		number, protocol = value[:index], strings.ToUpper(value[index+1:])
+	}
+	port, err := strconv.Atoi(number)
+	if err != nil || port <= 0 || port > 65535 {
+		return Port{}, false
+	}
+	return Port{Number: port, Protocol: protocol}, true
+}
+
+// parseHealthCheck returns the health check of the HEALTHCHECK instruction, nil for HEALTHCHECK NONE.
+func parseHealthCheck(node *parser.Node) *HealthCheck {
+	if node.Next == nil || strings.EqualFold(node.Next.Value, "none") {
+		return nil
+	}
+	check := &HealthCheck{
+		Interval:    30 * time.Second,
+		Timeout:     30 * time.Second,
+		StartPeriod: 0,
+		Retries:     3,
+	}
+	for _, flag := range node.Flags {
+		name, value := strings.TrimPrefix(strings.SplitN(flag, "=", 2)[0], "--"), ""
+		if parts := strings.SplitN(flag, "=", 2); len(parts) == 2 {
+			value = parts[1]
+		}
+		duration, _ := time.ParseDuration(value)
+		switch name {
+		case "interval":
+			check.Interval = duration
+		case "timeout":
+			check.Timeout = duration
+		case "start-period":
+			check.StartPeriod = duration
+		case "retries":
+			check.Retries, _ = strconv.Atoi(value)
+		}
+	}
+	// the command follows the CMD node
+	command := node.Next.Next
+	if node.Attributes["json"] {
+		for n := command; n != nil; n = n.Next {
+			check.Command = append(check.Command, n.Value)
+		}
+	} else if command != nil {
+		check.Command = []string{"/bin/sh", "-c", command.Value}
+	}
+	if len(check.Command) == 0 {
+		return nil
+	}
+	return check
+}
@@ -0,0 +1,36 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package manifests
+
+// PodLogs returns the logs of the container of the pod, the first one when container is empty,
+// read with oc or kubectl. The logs of the previous instance of the container are returned when
+// it was restarted, e.g. by a CrashLoopBackOff, the current ones otherwise.
+func PodLogs(pod string, namespace string, container string) ([]byte, error) {
+	args := []string{"logs", pod}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	if container != "" {
+		args = append(args, "--container", container)
+	}
+	if logs, err := clusterOutput("read the logs of the pod "+pod, append(args, "--previous")...); err == nil {
+		return logs, nil
+	}
+	return clusterOutput("read the logs of the pod "+pod, args...)
+}
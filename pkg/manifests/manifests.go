@@ -0,0 +1,240 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Kind string
+
+const (
+	KindDeployment Kind = "deployment"
+	// KindDeploymentConfig is the OpenShift specific, deprecated, alternative to Deployment
+	KindDeploymentConfig Kind = "deploymentconfig"
+)
+
+// httpCheckRegexp matches the curl and wget commands checking a local HTTP endpoint
+var httpCheckRegexp = regexp.MustCompile(`\b(?:curl|wget)\b[^|;&]*?\bhttps?://(?:localhost|127\.0\.0\.1|0\.0\.0\.0)(?::(\d+))?(/\S*)?`)
+
+// invalidNameRegexp matches the characters not allowed in the names of the resources
+var invalidNameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type Options struct {
+	// Name of the resources and of the app label
+	Name string
+	// Image is the image to deploy
+	Image    string
+	Kind     Kind
+	Replicas int
+}
+
+// Generated holds the manifests, as a multi-document YAML file, and the warnings about what
+// couldn't be derived from the Containerfile.
+type Generated struct {
+	Content  []byte
+	Warnings []string
+}
+
+func ParseKind(value string) (Kind, error) {
+	kind := Kind(strings.ToLower(value))
+	if kind != KindDeployment && kind != KindDeploymentConfig {
+		return "", fmt.Errorf("unknown kind %s, expected one of deployment, deploymentconfig", value)
+	}
+	return kind, nil
+}
+
+// Name returns a valid resource name for value, e.g. the name of the project directory.
+func Name(value string) string {
+	name := strings.Trim(invalidNameRegexp.ReplaceAllString(strings.ToLower(value), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+// portName is the name oc new-app gives to a port, e.g. 8080-tcp
+func portName(port Port) string {
+	return fmt.Sprintf("%d-%s", port.Number, strings.ToLower(port.Protocol))
+}
+
+// Generate returns the manifests deploying the image.
+func Generate(image *Image, options Options) (*Generated, error) {
+	if options.Kind == "" {
+		options.Kind = KindDeployment
+	}
+	if options.Replicas == 0 {
+		options.Replicas = 1
+	}
+	generated := &Generated{}
+	labels := map[string]string{"app": options.Name}
+	container := map[string]interface{}{
+		"name":            options.Name,
+		"image":           options.Image,
+		"securityContext": generated.securityContext(image.User),
+	}
+	var ports []map[string]interface{}
+	for _, port := range image.Ports {
+		ports = append(ports, map[string]interface{}{"name": portName(port), "containerPort": port.Number, "protocol": port.Protocol})
+	}
+	if len(ports) > 0 {
+		container["ports"] = ports
+	}
+	if image.HealthCheck != nil {
+		probe := probe(image.HealthCheck)
+		container["livenessProbe"] = probe
+		container["readinessProbe"] = probe
+	} else {
+		generated.Warnings = append(generated.Warnings, "the Containerfile has no HEALTHCHECK, no probe is generated")
+	}
+	template := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{container},
+		},
+	}
+
+	var documents []interface{}
+	if options.Kind == KindDeploymentConfig {
+		documents = append(documents, resource("apps.openshift.io/v1", "DeploymentConfig", options.Name, labels, map[string]interface{}{
+			"replicas": options.Replicas,
+			"selector": labels,
+			"template": template,
+			"triggers": []interface{}{map[string]interface{}{"type": "ConfigChange"}},
+		}))
+	} else {
+		documents = append(documents, resource("apps/v1", "Deployment", options.Name, labels, map[string]interface{}{
+			"replicas": options.Replicas,
+			"selector": map[string]interface{}{"matchLabels": labels},
+			"template": template,
+		}))
+	}
+
+	var servicePorts []map[string]interface{}
+	var routePort string
+	for _, port := range image.Ports {
+		servicePorts = append(servicePorts, map[string]interface{}{"name": portName(port), "port": port.Number, "protocol": port.Protocol, "targetPort": port.Number})
+		if routePort == "" && port.Protocol == "TCP" {
+			routePort = portName(port)
+		}
+	}
+	if len(servicePorts) == 0 {
+		generated.Warnings = append(generated.Warnings, "the Containerfile exposes no port, no Service nor Route is generated")
+	} else {
+		documents = append(documents, resource("v1", "Service", options.Name, labels, map[string]interface{}{
+			"selector": labels,
+			"ports":    servicePorts,
+		}))
+	}
+	if routePort != "" {
+		documents = append(documents, resource("route.openshift.io/v1", "Route", options.Name, labels, map[string]interface{}{
+			"to":   map[string]interface{}{"kind": "Service", "name": options.Name},
+			"port": map[string]interface{}{"targetPort": routePort},
+			"tls":  map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"},
+		}))
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	generated.Content = buf.Bytes()
+	return generated, nil
+}
+
+func resource(apiVersion string, kind string, name string, labels map[string]string, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "labels": labels},
+		"spec":       spec,
+	}
+}
+
+// securityContext returns the security context of the container compatible with the
+// restricted-v2 SCC. The UID is not set, OpenShift assigns one from the namespace range.
+func (g *Generated) securityContext(user string) map[string]interface{} {
+	security := map[string]interface{}{
+		"allowPrivilegeEscalation": false,
+		"capabilities":             map[string]interface{}{"drop": []string{"ALL"}},
+		"seccompProfile":           map[string]interface{}{"type": "RuntimeDefault"},
+	}
+	name := strings.SplitN(user, ":", 2)[0]
+	uid, err := strconv.Atoi(name)
+	switch {
+	case name == "" || name == "root" || (err == nil && uid == 0):
+		g.Warnings = append(g.Warnings, "the image runs as root, runAsNonRoot is not set: set a non-root numeric USER in the Containerfile")
+	case err != nil:
+		g.Warnings = append(g.Warnings, fmt.Sprintf("the USER %s of the image is not numeric, the kubelet can't verify runAsNonRoot: set its UID in the Containerfile", user))
+	default:
+		security["runAsNonRoot"] = true
+	}
+	return security
+}
+
+// probe converts the HEALTHCHECK, a local HTTP check becoming an httpGet probe.
+func probe(check *HealthCheck) map[string]interface{} {
+	probe := map[string]interface{}{
+		"periodSeconds":    seconds(check.Interval),
+		"timeoutSeconds":   seconds(check.Timeout),
+		"failureThreshold": check.Retries,
+	}
+	if check.StartPeriod > 0 {
+		probe["initialDelaySeconds"] = seconds(check.StartPeriod)
+	}
+	command := strings.Join(check.Command, " ")
+	if match := httpCheckRegexp.FindStringSubmatch(command); match != nil {
+		port := 80
+		if match[1] != "" {
+			port, _ = strconv.Atoi(match[1])
+		}
+		path := "/"
+		if parsed, err := url.Parse("http://localhost" + strings.Trim(match[2], `"'`)); err == nil && parsed.Path != "" {
+			path = parsed.Path
+		}
+		probe["httpGet"] = map[string]interface{}{"path": path, "port": port}
+		return probe
+	}
+	probe["exec"] = map[string]interface{}{"command": check.Command}
+	return probe
+}
+
+func seconds(duration time.Duration) int {
+	if s := int(duration.Seconds()); s > 0 {
+		return s
+	}
+	return 1
+}
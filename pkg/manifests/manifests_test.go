@@ -0,0 +1,405 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"gopkg.in/yaml.v3"
+)
+
+const containerfile = `FROM registry.access.redhat.com/ubi9/nodejs-20:1 AS builder
+EXPOSE 3000
+FROM registry.access.redhat.com/ubi9/nodejs-20-minimal:1
+USER 1001
+EXPOSE 8080 9090/udp
+HEALTHCHECK --interval=10s --start-period=30s CMD curl -f http://localhost:8080/health || exit 1
+CMD ["node", "server.js"]
+`
+
+func TestInspect(t *testing.T) {
+	image, err := Inspect([]byte(containerfile))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(image.Ports) != 2 || image.Ports[0].Number != 8080 || image.Ports[1].Protocol != "UDP" {
+		t.Errorf("Expected the ports of the final stage but they were %v", image.Ports)
+	}
+	if image.User != "1001" {
+		t.Errorf("Expected the user 1001 but it was %s", image.User)
+	}
+	if check := image.HealthCheck; check == nil || check.Interval != 10*time.Second || check.StartPeriod != 30*time.Second || check.Retries != 3 {
+		t.Errorf("Unexpected health check %v", image.HealthCheck)
+	}
+}
+
+func decode(t *testing.T, content []byte) []map[string]interface{} {
+	var documents []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		document := map[string]interface{}{}
+		if err := decoder.Decode(&document); err != nil {
+			break
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+func TestGenerate(t *testing.T) {
+	image, _ := Inspect([]byte(containerfile))
+	generated, err := Generate(image, Options{Name: "web", Image: "quay.io/org/web:1.0"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	documents := decode(t, generated.Content)
+	if len(documents) != 3 || documents[0]["kind"] != "Deployment" || documents[1]["kind"] != "Service" || documents[2]["kind"] != "Route" {
+		t.Fatalf("Expected a Deployment, a Service and a Route but they were %s", generated.Content)
+	}
+	for _, expected := range []string{"runAsNonRoot: true", "path: /health", "port: 8080", "initialDelaySeconds: 30", "targetPort: 8080-tcp", "name: 9090-udp"} {
+		if !strings.Contains(string(generated.Content), expected) {
+			t.Errorf("Expected %s in\n%s", expected, generated.Content)
+		}
+	}
+	if len(generated.Warnings) != 0 {
+		t.Errorf("Expected no warnings but they were %v", generated.Warnings)
+	}
+}
+
+func TestGenerateDeploymentConfigForRootImage(t *testing.T) {
+	image, _ := Inspect([]byte("FROM scratch\nHEALTHCHECK CMD [\"/app/check\"]\nCMD [\"/app/run\"]\n"))
+	generated, err := Generate(image, Options{Name: "worker", Image: "worker", Kind: KindDeploymentConfig})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	documents := decode(t, generated.Content)
+	if len(documents) != 1 || documents[0]["kind"] != "DeploymentConfig" {
+		t.Fatalf("Expected only a DeploymentConfig but they were %s", generated.Content)
+	}
+	if strings.Contains(string(generated.Content), "runAsNonRoot") || !strings.Contains(string(generated.Content), "- /app/check") {
+		t.Errorf("Expected an exec probe and no runAsNonRoot in\n%s", generated.Content)
+	}
+	if len(generated.Warnings) != 2 {
+		t.Errorf("Expected warnings about the root user and the missing ports but they were %v", generated.Warnings)
+	}
+}
+
+func TestName(t *testing.T) {
+	for value, expected := range map[string]string{"My_App": "my-app", "--": "app", "api.v2": "api-v2"} {
+		if name := Name(value); name != expected {
+			t.Errorf("Expected %s for %s but it was %s", expected, value, name)
+		}
+	}
+}
+
+const deployment = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers:
+        - name: web
+          image: quay.io/org/web:1.0
+          ports:
+            - containerPort: 80
+        - name: proxy
+          image: quay.io/org/proxy:1.0
+          securityContext:
+            runAsUser: 0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: quay.io/org/cleanup:1.0
+`
+
+func TestContainers(t *testing.T) {
+	containers, err := Containers([]byte(deployment))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(containers) != 3 {
+		t.Fatalf("Expected 3 containers but they were %v", containers)
+	}
+	if containers[0].Workload != "Deployment/web" || containers[0].RunAsNonRoot == nil || len(containers[0].Ports) != 1 {
+		t.Errorf("Unexpected container %v", containers[0])
+	}
+	if containers[1].RunAsUser == nil || *containers[1].RunAsUser != 0 {
+		t.Errorf("Expected the container runAsUser to be read but it was %v", containers[1].RunAsUser)
+	}
+	if containers[2].Workload != "CronJob/cleanup" {
+		t.Errorf("Expected the container of the CronJob but it was %s", containers[2].Workload)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	containers, _ := Containers([]byte(deployment))
+	image := &Image{User: "node", Ports: []Port{{Number: 8080, Protocol: "TCP"}}}
+	inconsistencies := Check(containers[0], image)
+	if len(inconsistencies) != 3 {
+		t.Fatalf("Expected the non numeric user and the 2 ports to be reported but they were %v", inconsistencies)
+	}
+	if !strings.Contains(inconsistencies[0].Message, "not numeric") {
+		t.Errorf("Unexpected message %s", inconsistencies[0].Message)
+	}
+	if inconsistencies := Check(containers[1], &Image{User: "1001"}); len(inconsistencies) != 1 {
+		t.Errorf("Expected runAsUser 0 to be reported but they were %v", inconsistencies)
+	}
+	if inconsistencies := Check(containers[2], &Image{}); len(inconsistencies) != 0 {
+		t.Errorf("Expected no inconsistency but they were %v", inconsistencies)
+	}
+}
+
+func TestReport(t *testing.T) {
+	content, err := Report([]analyzer.Result{analyzer.RuleUserRoot.Failed("root")}, ReportOptions{
+		Name:      "quay.io/org/web",
+		Namespace: "shop",
+		Image:     "quay.io/org/web:1.0",
+		Workload:  "deployment/web",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	configMap := decode(t, content)[0]
+	metadata := configMap["metadata"].(map[string]interface{})
+	labels := metadata["labels"].(map[string]interface{})
+	annotations := metadata["annotations"].(map[string]interface{})
+	if metadata["name"] != "doa-report-quay-io-org-web" || metadata["namespace"] != "shop" || labels[VERDICT_LABEL] != "failed" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if annotations[WORKLOAD_ANNOTATION] != "deployment/web" || annotations[SOURCE_ANNOTATION] != nil {
+		t.Errorf("Unexpected annotations %v", annotations)
+	}
+	var results []analyzer.Result
+	if err := json.Unmarshal([]byte(configMap["data"].(map[string]interface{})["results.json"].(string)), &results); err != nil || len(results) != 1 {
+		t.Errorf("Unexpected results %v, error %v", results, err)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("istag/web:latest")
+	if err != nil || target.Kind != "imagestreamtag" || target.Name != "web:latest" {
+		t.Errorf("Unexpected target %v, error %v", target, err)
+	}
+	for _, value := range []string{"web", "deployment/web", "imagestreamtag/web", "bc/"} {
+		if _, err := ParseTarget(value); err == nil {
+			t.Errorf("Expected an error for %s", value)
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	low := analyzer.RuleBaseImageAnalysis.Failed("unavailable")
+	critical := analyzer.RuleInvalidPort.Failed("abc")
+	critical.Line = &analyzer.Line{Start: 4, End: 4}
+	annotations, err := Annotations([]analyzer.Result{low, analyzer.RuleUserRoot.Failed("root"), critical}, 61, ReportOptions{Image: "quay.io/org/web:1.0"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if annotations[VERDICT_LABEL] != "failed" || annotations[SCORE_ANNOTATION] != "61" || annotations[IMAGE_ANNOTATION] != "quay.io/org/web:1.0" {
+		t.Errorf("Unexpected annotations %v", annotations)
+	}
+	if annotations[FINDINGS_ANNOTATION] != `{"critical":1,"high":0,"low":1,"medium":1}` {
+		t.Errorf("Unexpected findings %s", annotations[FINDINGS_ANNOTATION])
+	}
+	if !strings.HasPrefix(annotations[TOP_FINDINGS_ANNOTATION], `[{"ruleId":"invalid-port","severity":"critical","line":4},{"ruleId":"user-root"`) {
+		t.Errorf("Unexpected top findings %s", annotations[TOP_FINDINGS_ANNOTATION])
+	}
+}
+
+func TestAnnotateArgs(t *testing.T) {
+	args := annotateArgs(Target{Kind: "buildconfig", Name: "web", Namespace: "shop"}, map[string]string{SCORE_ANNOTATION: "90", VERDICT_LABEL: "passed"})
+	expected := "annotate --overwrite buildconfig/web --namespace shop doa.redhat.com/score=90 doa.redhat.com/verdict=passed"
+	if strings.Join(args, " ") != expected {
+		t.Errorf("Expected %s but it was %s", expected, strings.Join(args, " "))
+	}
+}
+
+func TestInlineDockerfiles(t *testing.T) {
+	content := `apiVersion: v1
+kind: List
+items:
+- apiVersion: build.openshift.io/v1
+  kind: BuildConfig
+  metadata:
+    name: web
+  spec:
+    source:
+      dockerfile: |
+        FROM ubi9
+        USER root
+    strategy:
+      dockerStrategy: {}
+- apiVersion: build.openshift.io/v1
+  kind: BuildConfig
+  metadata:
+    name: s2i
+  spec:
+    source:
+      dockerfile: "FROM ubi9"
+    strategy:
+      sourceStrategy: {}
+---
+apiVersion: build.openshift.io/v1
+kind: BuildConfig
+metadata:
+  name: api
+spec:
+  source:
+    dockerfile: "FROM ubi9\nUSER root"
+  strategy:
+    type: Docker
+`
+	dockerfiles, err := InlineDockerfiles([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(dockerfiles) != 2 || dockerfiles[0].Resource != "BuildConfig/web" || dockerfiles[1].Resource != "BuildConfig/api" {
+		t.Fatalf("Unexpected dockerfiles %v", dockerfiles)
+	}
+	if dockerfiles[0].Line != 10 || dockerfiles[0].ManifestLine(2) != 12 {
+		t.Errorf("Unexpected lines %d, %d", dockerfiles[0].Line, dockerfiles[0].ManifestLine(2))
+	}
+	if dockerfiles[1].ManifestLine(2) != 31 {
+		t.Errorf("Expected the line of the dockerfile field but it was %d", dockerfiles[1].ManifestLine(2))
+	}
+	results := dockerfiles[0].Locate("bc.yaml", []analyzer.Result{{RuleID: "user-root", Line: &analyzer.Line{Start: 2, End: 2}}})
+	if results[0].Manifest == nil || results[0].Manifest.Line != 12 || results[0].Line.Start != 2 {
+		t.Errorf("Unexpected location %v", results[0].Manifest)
+	}
+}
+
+func TestInlineDockerfilesOfPipelines(t *testing.T) {
+	content := `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  params:
+  - name: DOCKERFILE
+    value: ./Containerfile
+  - name: DOCKERFILE_CONTENT
+    value: |
+      FROM ubi9
+      USER root
+---
+name: ci
+on: push
+jobs:
+  image:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo "not a Containerfile" <<EOF
+    - name: Build
+      run: |
+        cat > Dockerfile <<'EOF'
+        FROM ubi9
+        EXPOSE 80
+        EOF
+        podman build -f - . <<EOF
+        FROM ubi9
+        EOF
+`
+	dockerfiles, err := InlineDockerfiles([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(dockerfiles) != 3 {
+		t.Fatalf("Unexpected dockerfiles %v", dockerfiles)
+	}
+	if dockerfiles[0].Resource != "PipelineRun/build" || dockerfiles[0].ManifestLine(2) != 12 {
+		t.Errorf("Unexpected dockerfile %v at line %d", dockerfiles[0], dockerfiles[0].ManifestLine(2))
+	}
+	if dockerfiles[1].Resource != "Workflow/ci jobs.image" || dockerfiles[1].Content != "FROM ubi9\nEXPOSE 80" || dockerfiles[1].ManifestLine(2) != 25 {
+		t.Errorf("Unexpected dockerfile %v at line %d", dockerfiles[1], dockerfiles[1].ManifestLine(2))
+	}
+	if dockerfiles[2].Content != "FROM ubi9" || dockerfiles[2].ManifestLine(1) != 28 {
+		t.Errorf("Unexpected dockerfile %v at line %d", dockerfiles[2], dockerfiles[2].ManifestLine(1))
+	}
+}
+
+func TestParseImageStreamImages(t *testing.T) {
+	content := `{
+  "kind": "ImageStream",
+  "status": {
+    "tags": [
+      {"tag": "latest", "items": [{"dockerImageReference": "quay.io/shop/web@sha256:bbb"}, {"dockerImageReference": "quay.io/shop/web@sha256:aaa"}]},
+      {"tag": "1.1", "items": [{"dockerImageReference": "quay.io/shop/web@sha256:bbb"}]},
+      {"tag": "1.0", "items": [{"dockerImageReference": "quay.io/shop/web@sha256:aaa"}]},
+      {"tag": "next", "items": []}
+    ]
+  }
+}`
+	images, err := parseImageStreamImages([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if strings.Join(images, " ") != "quay.io/shop/web@sha256:bbb quay.io/shop/web@sha256:aaa" {
+		t.Errorf("Unexpected images %v", images)
+	}
+}
+
+func TestParseNamespaceImages(t *testing.T) {
+	content := `{
+  "kind": "List",
+  "items": [
+    {"kind": "Pod", "metadata": {"name": "web-7d9f-abcde", "ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f"}]},
+     "spec": {"containers": [{"image": "quay.io/shop/web:1.2"}]}},
+    {"kind": "Pod", "metadata": {"name": "debug"}, "spec": {"containers": [{"image": "registry.access.redhat.com/ubi9/ubi"}]}},
+    {"kind": "Deployment", "metadata": {"name": "web"},
+     "spec": {"template": {"spec": {"initContainers": [{"image": "quay.io/shop/migrate:1.2"}], "containers": [{"image": "quay.io/shop/web:1.2"}]}}}},
+    {"kind": "CronJob", "metadata": {"name": "cleanup"},
+     "spec": {"jobTemplate": {"spec": {"template": {"spec": {"containers": [{"image": "quay.io/shop/web:1.2"}]}}}}}}
+  ]
+}`
+	usages, err := parseNamespaceImages([]byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := []ImageUsage{
+		{Image: "quay.io/shop/migrate:1.2", Workloads: []string{"Deployment/web"}},
+		{Image: "quay.io/shop/web:1.2", Workloads: []string{"CronJob/cleanup", "Deployment/web", "ReplicaSet/web-7d9f"}},
+		{Image: "registry.access.redhat.com/ubi9/ubi", Workloads: []string{"Pod/debug"}},
+	}
+	if !reflect.DeepEqual(usages, expected) {
+		t.Errorf("Expected %v but got %v", expected, usages)
+	}
+}
@@ -0,0 +1,198 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// The annotations summarizing the results on the ImageStreamTag or the BuildConfig, the verdict
+// being stored under the same key as the label of the report ConfigMaps.
+const (
+	SCORE_ANNOTATION        = "doa.redhat.com/score"
+	FINDINGS_ANNOTATION     = "doa.redhat.com/findings"
+	TOP_FINDINGS_ANNOTATION = "doa.redhat.com/top-findings"
+	ANALYZED_ANNOTATION     = "doa.redhat.com/analyzed-at"
+)
+
+// TOP_FINDINGS is the number of findings kept in the top findings annotation, annotations being
+// limited in size
+const TOP_FINDINGS = 5
+
+var publishedKinds = map[string]string{
+	"imagestreamtag": "imagestreamtag",
+	"istag":          "imagestreamtag",
+	"buildconfig":    "buildconfig",
+	"bc":             "buildconfig",
+}
+
+// Target is the resource the results are published on.
+type Target struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (t Target) String() string {
+	return t.Kind + "/" + t.Name
+}
+
+// ParseTarget parses an ImageStreamTag or a BuildConfig in the kind/name form, e.g.
+// imagestreamtag/web:latest or bc/web.
+func ParseTarget(value string) (Target, error) {
+	kind, name, found := strings.Cut(value, "/")
+	canonical, known := publishedKinds[strings.ToLower(kind)]
+	if !found || !known || name == "" {
+		return Target{}, errors.Errorf("invalid target %q, expected imagestreamtag/<name>:<tag> or buildconfig/<name>", value)
+	}
+	if canonical == "imagestreamtag" && !strings.Contains(name, ":") {
+		return Target{}, errors.Errorf("invalid target %q, an ImageStreamTag is named <imagestream>:<tag>", value)
+	}
+	return Target{Kind: canonical, Name: name}, nil
+}
+
+type finding struct {
+	RuleID   string                  `json:"ruleId"`
+	Severity analyzer.ResultSeverity `json:"severity"`
+	Line     int                     `json:"line,omitempty"`
+}
+
+// Annotations summarizes the results as annotations: the verdict, the score, the number of
+// findings per severity and the most severe findings.
+func Annotations(results []analyzer.Result, score int, options ReportOptions) (map[string]string, error) {
+	if options.FailOn == "" {
+		options.FailOn = analyzer.SeverityLow
+	}
+	summary := analyzer.SummarizeFailingOn(results, options.FailOn)
+	counts := map[analyzer.ResultSeverity]int{}
+	for _, severity := range []analyzer.ResultSeverity{analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium, analyzer.SeverityLow} {
+		counts[severity] = summary.BySeverity[severity]
+	}
+	countsJSON, err := json.Marshal(counts)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := []analyzer.Result{}
+	for _, result := range results {
+		if result.Status == analyzer.StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return !failed[j].Severity.AtLeast(failed[i].Severity)
+	})
+	top := []finding{}
+	for i := 0; i < len(failed) && i < TOP_FINDINGS; i++ {
+		f := finding{RuleID: failed[i].RuleID, Severity: failed[i].Severity}
+		if failed[i].Line != nil {
+			f.Line = failed[i].Line.Start
+		}
+		top = append(top, f)
+	}
+	topJSON, err := json.Marshal(top)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{
+		VERDICT_LABEL:           string(summary.Verdict),
+		SCORE_ANNOTATION:        strconv.Itoa(score),
+		FINDINGS_ANNOTATION:     string(countsJSON),
+		TOP_FINDINGS_ANNOTATION: string(topJSON),
+		RULESET_ANNOTATION:      analyzer.RULESET_VERSION,
+		ANALYZED_ANNOTATION:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if options.Image != "" {
+		annotations[IMAGE_ANNOTATION] = options.Image
+	}
+	if options.Source != "" {
+		annotations[SOURCE_ANNOTATION] = options.Source
+	}
+	return annotations, nil
+}
+
+// Publish writes the annotations on the target in the cluster of the current context, with the oc
+// binary or with kubectl when oc is not installed. Previous annotations are overwritten.
+func Publish(target Target, annotations map[string]string) error {
+	name, err := clusterCLI("publish the results")
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, annotateArgs(target, annotations)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "unable to annotate %s: %s", target, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// clusterCLI returns the oc binary, or kubectl when oc is not installed, purpose describing what
+// it is required for in the error.
+func clusterCLI(purpose string) (string, error) {
+	name := "oc"
+	if _, err := exec.LookPath(name); err != nil {
+		name = "kubectl"
+		if _, err := exec.LookPath(name); err != nil {
+			return "", errors.Errorf("oc or kubectl is required to %s but neither was found in the PATH", purpose)
+		}
+	}
+	return name, nil
+}
+
+// clusterOutput runs the oc or kubectl command, see clusterCLI, and returns its output.
+func clusterOutput(purpose string, args ...string) ([]byte, error) {
+	cli, err := clusterCLI(purpose)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(cli, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to %s: %s", purpose, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func annotateArgs(target Target, annotations map[string]string) []string {
+	args := []string{"annotate", "--overwrite", target.String()}
+	if target.Namespace != "" {
+		args = append(args, "--namespace", target.Namespace)
+	}
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key+"="+annotations[key])
+	}
+	return args
+}
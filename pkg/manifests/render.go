@@ -0,0 +1,56 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package manifests
+
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// RenderHelm renders the chart with the helm binary, the values files being applied in order.
+func RenderHelm(chart string, values []string) ([]byte, error) {
+	args := []string{"template", "doa", chart}
+	for _, file := range values {
+		args = append(args, "--values", file)
+	}
+	return render("helm", args...)
+}
+
+// RenderKustomize builds the overlay with the kustomize binary, or with kubectl when kustomize is
+// not installed.
+func RenderKustomize(dir string) ([]byte, error) {
+	if _, err := exec.LookPath("kustomize"); err == nil {
+		return render("kustomize", "build", dir)
+	}
+	return render("kubectl", "kustomize", dir)
+}
+
+func render(name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, errors.Errorf("%s is required to render the manifests but it was not found in the PATH", name)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to render the manifests with %s: %s", name, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,110 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"gopkg.in/yaml.v3"
+)
+
+// The report ConfigMaps are found by the console, and any other tool, through their labels and
+// bound to the workloads they describe through their annotations.
+const (
+	REPORT_LABEL        = "doa.redhat.com/report"
+	VERDICT_LABEL       = "doa.redhat.com/verdict"
+	IMAGE_ANNOTATION    = "doa.redhat.com/image"
+	SOURCE_ANNOTATION   = "doa.redhat.com/source"
+	WORKLOAD_ANNOTATION = "doa.redhat.com/workload"
+	RULESET_ANNOTATION  = "doa.redhat.com/ruleset-version"
+)
+
+type ReportOptions struct {
+	// Name of the ConfigMap, prefixed by doa-report-
+	Name      string
+	Namespace string
+	// Image and Source are the analyzed image or Containerfile
+	Image  string
+	Source string
+	// Workload is the workload running the image, e.g. deployment/web
+	Workload string
+	FailOn   analyzer.ResultSeverity
+}
+
+// Report returns the results as a ConfigMap to apply in the namespace of the workload running the
+// image, so that they can be shown next to it. The summary and the results are stored as JSON in
+// the summary.json and results.json keys.
+func Report(results []analyzer.Result, options ReportOptions) ([]byte, error) {
+	if options.FailOn == "" {
+		options.FailOn = analyzer.SeverityLow
+	}
+	summary := analyzer.SummarizeFailingOn(results, options.FailOn)
+	summaryJSON, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	resultsJSON, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"name": "doa-report-" + Name(options.Name),
+		"labels": map[string]string{
+			"app.kubernetes.io/managed-by": "doa",
+			REPORT_LABEL:                   "true",
+			VERDICT_LABEL:                  string(summary.Verdict),
+		},
+	}
+	if options.Namespace != "" {
+		metadata["namespace"] = options.Namespace
+	}
+	annotations := map[string]string{RULESET_ANNOTATION: analyzer.RULESET_VERSION}
+	for key, value := range map[string]string{
+		IMAGE_ANNOTATION:    options.Image,
+		SOURCE_ANNOTATION:   options.Source,
+		WORKLOAD_ANNOTATION: options.Workload,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	metadata["annotations"] = annotations
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   metadata,
+		"data": map[string]string{
+			"summary.json": string(summaryJSON) + "\n",
+			"results.json": string(resultsJSON) + "\n",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
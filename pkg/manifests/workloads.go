@@ -0,0 +1,135 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package manifests
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// auditedKinds are the workloads whose images are audited, the pods managed by a controller being
+// reported as their owner
+const auditedKinds = "pods,deployments,statefulsets,daemonsets,jobs,cronjobs"
+
+// ImageUsage is an image run by workloads of the namespace.
+type ImageUsage struct {
+	Image string `json:"image"`
+	// Workloads are the workloads running the image, e.g. Deployment/web
+	Workloads []string `json:"workloads"`
+}
+
+// NamespaceImages returns the images run by the Pods, Deployments, StatefulSets, DaemonSets, Jobs
+// and CronJobs of the namespace, read with oc or kubectl from the cluster of the kubeconfig file,
+// the one of the current context when kubeconfig is empty.
+func NamespaceImages(namespace string, kubeconfig string) ([]ImageUsage, error) {
+	args := []string{"get", auditedKinds, "--output", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	content, err := clusterOutput("list the workloads of the namespace", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseNamespaceImages(content)
+}
+
+// parseNamespaceImages returns the images of the workloads of the List, sorted.
+func parseNamespaceImages(content []byte) ([]ImageUsage, error) {
+	var list struct {
+		Items []workload `yaml:"items"`
+	}
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the workloads")
+	}
+	workloads := map[string][]string{}
+	for _, item := range list.Items {
+		spec, ok := item.podSpec()
+		if !ok {
+			continue
+		}
+		name := item.Kind + "/" + item.Metadata.Name
+		if owners := item.Metadata.OwnerReferences; len(owners) > 0 {
+			name = owners[0].Kind + "/" + owners[0].Name
+		}
+		for _, image := range spec.images() {
+			if !containsString(workloads[image], name) {
+				workloads[image] = append(workloads[image], name)
+			}
+		}
+	}
+	usages := []ImageUsage{}
+	for image, names := range workloads {
+		sort.Strings(names)
+		usages = append(usages, ImageUsage{Image: image, Workloads: names})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Image < usages[j].Image
+	})
+	return usages, nil
+}
+
+// WorkloadImages returns the images of the init containers and of the containers of the workload,
+// e.g. the object of an admission request, nil when it's not a workload.
+func WorkloadImages(content []byte) ([]string, error) {
+	var res workload
+	if err := yaml.Unmarshal(content, &res); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the workload")
+	}
+	spec, ok := res.podSpec()
+	if !ok {
+		return nil, nil
+	}
+	var images []string
+	for _, image := range spec.images() {
+		if !containsString(images, image) {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// images returns the images of the init containers then of the containers.
+func (s podSpec) images() []string {
+	var images []string
+	for _, container := range s.InitContainers {
+		if container.Image != "" {
+			images = append(images, container.Image)
+		}
+	}
+	for _, container := range s.Containers {
+		if container.Image != "" {
+			images = append(images, container.Image)
+		}
+	}
+	return images
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
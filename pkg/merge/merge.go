@@ -0,0 +1,110 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package merge consolidates the JSON reports of doa analyze written by sharded CI jobs.
+ package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// Report holds the results of a target, the Containerfile or the image they were found in
+type Report struct {
+	Target  string            `json:"target"`
+	Results []analyzer.Result `json:"results"`
+}
+
+// Load reads a report written by doa analyze -o json. The spec is the path of the report, prefixed
+// by its target and = when it isn't the default target, e.g. web/Containerfile=web.json.
+func Load(spec string, defaultTarget string) (Report, error) {
+	report := Report{Target: defaultTarget}
+	path := spec
+	if index := strings.LastIndex(spec, "="); index > 0 {
+		report.Target, path = spec[:index], spec[index+1:]
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return report, errors.Wrapf(err, "unable to read the report %s", path)
+	}
+	if err := json.Unmarshal(bytes, &report.Results); err != nil {
+		return report, errors.Wrapf(err, "unable to parse the report %s, expected the output of doa analyze -o json", path)
+	}
+	return report, nil
+}
+
+// Merge returns a report per target, in the order they are first seen, holding the results of
+// all its reports. The results found by several reports, e.g. when the shards overlap, are
+// only kept once.
+func Merge(reports []Report) []Report {
+	merged := []Report{}
+	targets := map[string]int{}
+	seen := map[string]bool{}
+	for _, report := range reports {
+		index, ok := targets[report.Target]
+		if !ok {
+			index = len(merged)
+			targets[report.Target] = index
+			merged = append(merged, Report{Target: report.Target, Results: []analyzer.Result{}})
+		}
+		for _, result := range report.Results {
+			k := report.Target + "\x00" + key(result)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged[index].Results = append(merged[index].Results, result)
+		}
+	}
+	return merged
+}
+
+// key identifies the result, by its fingerprint when it has one.
+func key(result analyzer.Result) string {
+	rule := result.RuleID
+	if rule == "" {
+		rule = result.Name
+	}
+	if result.Fingerprint != "" {
+		return fmt.Sprintf("%s\x00%s\x00%s", rule, result.Status, result.Fingerprint)
+	}
+	location := ""
+	switch {
+	case result.Manifest != nil:
+		location = fmt.Sprintf("%s:%d", result.Manifest.Path, result.Manifest.Line)
+	case result.File != nil:
+		location = fmt.Sprintf("%s:%d", result.File.Path, result.File.Line)
+	case result.Line != nil:
+		location = fmt.Sprintf("%d", result.Line.Start)
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s", rule, result.Status, location, result.Description)
+}
+
+// Results returns the results of all the targets.
+func Results(reports []Report) []analyzer.Result {
+	results := []analyzer.Result{}
+	for _, report := range reports {
+		results = append(results, report.Results...)
+	}
+	return results
+}
@@ -0,0 +1,74 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package merge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func writeReport(t *testing.T, name string, results ...analyzer.Result) string {
+	bytes, _ := json.Marshal(results)
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, bytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeDeduplicatesResultsOfTheSameTarget(t *testing.T) {
+	root := analyzer.RuleUserRoot.Failed("USER root")
+	root.Fingerprint = "aa"
+	port := analyzer.RulePrivilegedPort.Failed("EXPOSE 80")
+	port.Line = &analyzer.Line{Start: 3, End: 3}
+
+	var reports []Report
+	for _, spec := range []string{
+		writeReport(t, "shard1.json", root, port),
+		writeReport(t, "shard2.json", root, port),
+		"api/Containerfile=" + writeReport(t, "api.json", root),
+	} {
+		report, err := Load(spec, "Containerfile")
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		reports = append(reports, report)
+	}
+	merged := Merge(reports)
+	if len(merged) != 2 || merged[0].Target != "Containerfile" || len(merged[0].Results) != 2 || merged[1].Target != "api/Containerfile" || len(merged[1].Results) != 1 {
+		t.Errorf("Unexpected merged reports %v", merged)
+	}
+	if summary := analyzer.Summarize(Results(merged)); summary.Failed != 3 {
+		t.Errorf("Expected 3 failed results but they were %d", summary.Failed)
+	}
+}
+
+func TestLoadRejectsOtherDocuments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	os.WriteFile(path, []byte(`{"total": 1}`), 0600)
+	if _, err := Load(path, "Containerfile"); err == nil {
+		t.Errorf("Expected an error for a summary")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json"), "Containerfile"); err == nil {
+		t.Errorf("Expected an error for a missing report")
+	}
+}
@@ -0,0 +1,202 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package notify posts a findings summary to the Slack or Microsoft Teams channels of the
+// configuration file when their threshold is exceeded, see config.Notification.
+ package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+// TOP_FINDINGS is the number of findings listed in the notifications
+const TOP_FINDINGS = 5
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Message is the findings summary of a run.
+type Message struct {
+	// Title is what was analyzed, e.g. the Containerfile or the image
+	Title   string
+	Score   int
+	Summary analyzer.Summary
+	Results []analyzer.Result
+	// ReportURL links to the full report, it overrides the report URL of the notifications
+	ReportURL string
+}
+
+// NewMessage summarizes the results, failOn being the least severe failed finding failing the
+// verdict.
+func NewMessage(title string, results []analyzer.Result, score int, failOn analyzer.ResultSeverity) Message {
+	return Message{
+		Title:   title,
+		Score:   score,
+		Summary: analyzer.SummarizeFailingOn(results, failOn),
+		Results: results,
+	}
+}
+
+// Exceeded reports whether the message exceeds the threshold of the notification: a failed
+// finding at least as severe as its severity or a score below its min-score, or a failed verdict
+// when it has no threshold.
+func Exceeded(notification config.Notification, message Message) bool {
+	if notification.Severity == "" && notification.MinScore == 0 {
+		return message.Summary.Verdict == analyzer.VerdictFailed
+	}
+	if notification.MinScore > 0 && message.Score < notification.MinScore {
+		return true
+	}
+	if notification.Severity != "" {
+		for _, result := range message.Results {
+			if result.Status == analyzer.StatusFailed && result.Severity.AtLeast(notification.Severity) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Notify posts the message to the notifications whose threshold is exceeded, the errors of the
+// notifications not stopping the other ones.
+func Notify(notifications []config.Notification, message Message) error {
+	var failures []string
+	for _, notification := range notifications {
+		if !Exceeded(notification, message) {
+			continue
+		}
+		if err := Post(notification, message); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Post posts the message to the channel of the notification, whatever its threshold.
+func Post(notification config.Notification, message Message) error {
+	if message.ReportURL == "" {
+		message.ReportURL = os.ExpandEnv(notification.ReportURL)
+	}
+	var payload interface{}
+	switch notification.Type {
+	case config.NOTIFICATION_SLACK:
+		payload = slackPayload(message)
+	case config.NOTIFICATION_TEAMS:
+		payload = teamsPayload(message)
+	default:
+		return errors.Errorf("unknown notification type %q", notification.Type)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(os.ExpandEnv(notification.URL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the error holds the URL, which is a secret
+		return errors.Errorf("unable to post the %s notification", notification.Type)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unable to post the %s notification: %s", notification.Type, resp.Status)
+	}
+	return nil
+}
+
+func headline(message Message) string {
+	summary := message.Summary
+	return fmt.Sprintf("%s, score %d: %d critical, %d high, %d medium, %d low", strings.ToUpper(string(summary.Verdict)), message.Score,
+		summary.BySeverity[analyzer.SeverityCritical], summary.BySeverity[analyzer.SeverityHigh],
+		summary.BySeverity[analyzer.SeverityMedium], summary.BySeverity[analyzer.SeverityLow])
+}
+
+func finding(result analyzer.Result) string {
+	text := fmt.Sprintf("[%s] %s: %s", result.Severity, result.Name, result.Description)
+	if result.Line != nil {
+		text += fmt.Sprintf(" (line %d)", result.Line.Start)
+	}
+	return text
+}
+
+func slackPayload(message Message) map[string]string {
+	text := fmt.Sprintf("*%s* %s", message.Title, headline(message))
+	for _, result := range TopFindings(message.Results) {
+		text += "\n• " + finding(result)
+	}
+	if message.ReportURL != "" {
+		text += fmt.Sprintf("\n<%s|Open the report>", message.ReportURL)
+	}
+	return map[string]string{"text": text}
+}
+
+// teamsPayload is a message card, the format of the incoming webhooks of Microsoft Teams
+func teamsPayload(message Message) map[string]interface{} {
+	var findings []string
+	for _, result := range TopFindings(message.Results) {
+		findings = append(findings, "- "+finding(result))
+	}
+	color := "2EB886"
+	if message.Summary.Verdict == analyzer.VerdictFailed {
+		color = "D40E0D"
+	}
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    message.Title + " " + headline(message),
+		"themeColor": color,
+		"title":      message.Title,
+		"text":       headline(message) + "\n\n" + strings.Join(findings, "\n"),
+	}
+	if message.ReportURL != "" {
+		card["potentialAction"] = []map[string]interface{}{{
+			"@type":   "OpenUri",
+			"name":    "Open the report",
+			"targets": []map[string]string{{"os": "default", "uri": message.ReportURL}},
+		}}
+	}
+	return card
+}
+
+// TopFindings returns the most severe failed findings.
+func TopFindings(results []analyzer.Result) []analyzer.Result {
+	var failed []analyzer.Result
+	for _, result := range results {
+		if result.Status == analyzer.StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return !failed[j].Severity.AtLeast(failed[i].Severity)
+	})
+	if len(failed) > TOP_FINDINGS {
+		failed = failed[:TOP_FINDINGS]
+	}
+	return failed
+}
@@ -0,0 +1,99 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+func message(score int, results ...analyzer.Result) Message {
+	return NewMessage("web/Containerfile", results, score, analyzer.SeverityHigh)
+}
+
+func TestExceeded(t *testing.T) {
+	medium := analyzer.RuleUserRoot.Failed("USER root")
+	for name, test := range map[string]struct {
+		notification config.Notification
+		message      Message
+		expected     bool
+	}{
+		"failed verdict":             {config.Notification{}, message(80, analyzer.RuleHostPath.Failed("docker.sock")), true},
+		"passed verdict":             {config.Notification{}, message(96, medium), false},
+		"severity exceeded":          {config.Notification{Severity: analyzer.SeverityMedium}, message(96, medium), true},
+		"severity not exceeded":      {config.Notification{Severity: analyzer.SeverityCritical}, message(96, medium), false},
+		"score below min-score":      {config.Notification{MinScore: 97}, message(96, medium), true},
+		"score not below min-score":  {config.Notification{MinScore: 90}, message(96, medium), false},
+		"passed results are ignored": {config.Notification{Severity: analyzer.SeverityLow}, message(100, analyzer.RuleUserRoot.Passed("USER 1001")), false},
+	} {
+		if Exceeded(test.notification, test.message) != test.expected {
+			t.Errorf("%s: expected %t", name, test.expected)
+		}
+	}
+}
+
+func TestNotifyPostsToChannelsExceedingThreshold(t *testing.T) {
+	payloads := map[string]map[string]interface{}{}
+	channel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads[r.URL.Path] = payload
+	}))
+	defer channel.Close()
+	t.Setenv("TEAMS_WEBHOOK", channel.URL+"/teams")
+	t.Setenv("CI_JOB_URL", "https://ci.example.com/job/42")
+
+	notifications := []config.Notification{
+		{Type: config.NOTIFICATION_SLACK, URL: channel.URL + "/slack", Severity: analyzer.SeverityHigh},
+		{Type: config.NOTIFICATION_TEAMS, URL: "${TEAMS_WEBHOOK}", ReportURL: "${CI_JOB_URL}/report.html"},
+		{Type: config.NOTIFICATION_SLACK, URL: channel.URL + "/quiet", Severity: analyzer.SeverityCritical},
+	}
+	if err := Notify(notifications, message(90, analyzer.RuleHostPath.Failed("the docker socket is mounted"))); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("Expected 2 notifications but they were %v", payloads)
+	}
+	if text, _ := payloads["/slack"]["text"].(string); !strings.HasPrefix(text, "*web/Containerfile* FAILED, score 90: 0 critical, 1 high") || !strings.Contains(text, "docker socket") {
+		t.Errorf("Unexpected Slack message %s", text)
+	}
+	card := payloads["/teams"]
+	if card["@type"] != "MessageCard" || card["themeColor"] != "D40E0D" || !strings.Contains(card["text"].(string), "[high] Host path assumption") {
+		t.Errorf("Unexpected Teams card %v", card)
+	}
+	if actions, _ := json.Marshal(card["potentialAction"]); !strings.Contains(string(actions), "https://ci.example.com/job/42/report.html") {
+		t.Errorf("Expected a link to the report but the actions were %s", actions)
+	}
+}
+
+func TestPostReportsStatus(t *testing.T) {
+	channel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer channel.Close()
+	err := Post(config.Notification{Type: config.NOTIFICATION_SLACK, URL: channel.URL}, message(100))
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a 403 error but it was %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package pdf writes the analysis reports as PDF documents, laid out with the standard fonts of
+// the PDF readers so that no font has to be embedded.
+ package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// the pages are A4, in points
+const (
+	PAGE_WIDTH  = 595.0
+	PAGE_HEIGHT = 842.0
+	MARGIN      = 50.0
+)
+
+// Font is one of the standard fonts, which every PDF reader provides
+type Font string
+
+const (
+	FontRegular Font = "Helvetica"
+	FontBold    Font = "Helvetica-Bold"
+	FontMono    Font = "Courier"
+)
+
+// fontNames are the resource names of the fonts in the content streams
+var fontNames = map[Font]string{
+	FontRegular: "F1",
+	FontBold:    "F2",
+	FontMono:    "F3",
+}
+
+// helveticaWidths are the widths of the characters 32 to 126 of Helvetica, in thousandths of the
+// font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// winAnsi maps the characters outside of Latin-1 to their code in WinAnsiEncoding, the encoding
+// of the fonts
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, '✔': 'v', '✖': 'x',
+}
+
+// Document lays out text on pages, top to bottom, starting a new page when the current one is full.
+type Document struct {
+	Title  string
+	Author string
+	// Footer is written at the bottom of every page, followed by the page number
+	Footer string
+	Date   time.Time
+	pages  []*bytes.Buffer
+	y      float64
+}
+
+func NewDocument(title string) *Document {
+	d := &Document{Title: title, Date: time.Now()}
+	d.NewPage()
+	return d
+}
+
+// NewPage starts a new page.
+func (d *Document) NewPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PAGE_HEIGHT - MARGIN
+}
+
+// Space moves the next line down.
+func (d *Document) Space(points float64) {
+	d.y -= points
+}
+
+// Ensure starts a new page unless the given height fits in the current one, so that e.g. a heading
+// isn't left alone at the bottom of a page.
+func (d *Document) Ensure(height float64) {
+	if d.y-height < MARGIN+20 {
+		d.NewPage()
+	}
+}
+
+// Text writes the text wrapped to the width of the page minus the indent.
+func (d *Document) Text(font Font, size float64, indent float64, text string) {
+	d.ColoredText(font, size, indent, text, "")
+}
+
+// ColoredText writes the text like Text, with the fill color given as "r g b" components between 0
+// and 1, black when empty.
+func (d *Document) ColoredText(font Font, size float64, indent float64, text string, color string) {
+	leading := size * 1.35
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrap(font, size, PAGE_WIDTH-2*MARGIN-indent, paragraph) {
+			d.Ensure(leading)
+			d.y -= leading
+			d.write(font, size, MARGIN+indent, d.y, line, color)
+		}
+	}
+}
+
+// Rule draws a horizontal line across the page.
+func (d *Document) Rule() {
+	d.Ensure(10)
+	d.y -= 6
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.6 g %.2f %.2f %.2f 0.5 re f 0 g\n", MARGIN, d.y, PAGE_WIDTH-2*MARGIN)
+	d.y -= 6
+}
+
+// Row writes the cells of a table row at the given horizontal positions, relative to the margin,
+// truncating the cells to the next position.
+func (d *Document) Row(font Font, size float64, positions []float64, cells ...string) {
+	leading := size * 1.5
+	d.Ensure(leading)
+	d.y -= leading
+	for i, cell := range cells {
+		width := PAGE_WIDTH - 2*MARGIN - positions[i]
+		if i+1 < len(positions) {
+			width = positions[i+1] - positions[i] - 6
+		}
+		d.write(font, size, MARGIN+positions[i], d.y, truncate(font, size, width, cell), "")
+	}
+}
+
+func (d *Document) write(font Font, size float64, x float64, y float64, text string, color string) {
+	page := d.pages[len(d.pages)-1]
+	if color != "" {
+		fmt.Fprintf(page, "%s rg ", color)
+	}
+	fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET", fontNames[font], size, x, y, escape(text))
+	if color != "" {
+		page.WriteString(" 0 g")
+	}
+	page.WriteString("\n")
+}
+
+// WriteTo writes the PDF document, numbering the pages in their footer.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &bytes.Buffer{}
+	offsets := []int{}
+	object := func(content string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 catalog, 2 pages, 3 info, 4 to 6 fonts, then a page and its content for each page
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 7+2*i))
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Author (%s) /Producer (doa) /CreationDate (D:%s) >>",
+		escape(d.Title), escape(d.Author), d.Date.UTC().Format("20060102150405Z")))
+	for _, font := range []Font{FontRegular, FontBold, FontMono} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+	for i, page := range d.pages {
+		content := page.String()
+		footer := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+		if d.Footer != "" {
+			footer = d.Footer + "  -  " + footer
+		}
+		content += fmt.Sprintf("0.4 g BT /F1 8.0 Tf %.2f %.2f Td (%s) Tj ET 0 g\n", MARGIN, MARGIN-20, escape(footer))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents %d 0 R >>",
+			PAGE_WIDTH, PAGE_HEIGHT, 8+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// Width returns the width of the text in points.
+func Width(font Font, size float64, text string) float64 {
+	width := 0
+	for _, r := range text {
+		switch {
+		case font == FontMono:
+			width += 600
+		case r >= 32 && r <= 126:
+			width += helveticaWidths[r-32]
+		default:
+			width += 556
+		}
+	}
+	if font == FontBold {
+		// Helvetica-Bold is about 6% wider than Helvetica
+		width = width * 106 / 100
+	}
+	return float64(width) * size / 1000
+}
+
+func wrap(font Font, size float64, width float64, text string) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	lines := []string{}
+	line := ""
+	for _, word := range words {
+		for Width(font, size, word) > width && utf8.RuneCountInString(word) > 1 {
+			// words longer than a line, e.g. URLs, are broken
+			head := truncate(font, size, width, word)
+			if head == "" {
+				_, n := utf8.DecodeRuneInString(word)
+				head = word[:n]
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, head)
+			word = word[len(head):]
+		}
+		if line != "" && Width(font, size, line+" "+word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
+
+// truncate returns the longest prefix of the text fitting the width.
+func truncate(font Font, size float64, width float64, text string) string {
+	end := 0
+	for i, r := range text {
+		next := i + utf8.RuneLen(r)
+		if Width(font, size, text[:next]) > width {
+			break
+		}
+		end = next
+	}
+	return text[:end]
+}
+
+// escape encodes the text in WinAnsiEncoding as a PDF string literal.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if c, ok := winAnsi[r]; ok && c < 128 {
+				b.WriteByte(c)
+			} else if ok {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestWrap(t *testing.T) {
+	lines := wrap(FontRegular, 10, 100, "The image runs as root (USER root), which the restricted SCC forbids")
+	if len(lines) < 3 {
+		t.Errorf("Expected the text to be wrapped but it was %v", lines)
+	}
+	for _, line := range lines {
+		if Width(FontRegular, 10, line) > 100 {
+			t.Errorf("Line %q is wider than 100 points", line)
+		}
+	}
+	if lines = wrap(FontMono, 10, 60, "https://docs.openshift.com"); len(lines) != 3 || lines[0] != "https://do" {
+		t.Errorf("Expected the URL to be broken but it was %v", lines)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if escaped := escape(`chmod (g=u) C:\app – café`); escaped != `chmod \(g=u\) C:\\app \226 caf\351` {
+		t.Errorf("Unexpected escaped text %s", escaped)
+	}
+}
+
+func TestReportIsValidPDF(t *testing.T) {
+	root := analyzer.RuleUserRoot.Failed("USER root is set at line 3")
+	root.Line = &analyzer.Line{Start: 3, End: 3}
+	report := Report{
+		Target:  "web/Containerfile",
+		Date:    time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+		Results: []analyzer.Result{root, analyzer.RuleUserRoot.Passed("USER 1001")},
+		FailOn:  analyzer.SeverityLow,
+		Score:   96,
+		Version: "1.0.0",
+		Coverage: []analyzer.NodeCoverage{
+			{Instruction: "USER", Line: analyzer.Line{Start: 3, End: 3}, Evaluated: true, Rules: []string{analyzer.RuleUserRoot.ID}},
+			{Instruction: "WORKDIR", Line: analyzer.Line{Start: 4, End: 4}},
+		},
+	}
+	var out bytes.Buffer
+	if err := Write(&out, report); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	document := out.String()
+	if !strings.HasPrefix(document, "%PDF-1.4") || !strings.HasSuffix(document, "%%EOF\n") {
+		t.Fatalf("Unexpected document %s", document)
+	}
+	for _, text := range []string{"(FAILED - score 96/100) Tj", "(line 3) Tj", "(Appendix: remediation) Tj", "(not evaluated) Tj", "(1/2 instruction\\(s\\) covered by at least one rule) Tj", "(Sign-off) Tj"} {
+		if !strings.Contains(document, text) {
+			t.Errorf("Expected the document to contain %s", text)
+		}
+	}
+	// the cross-reference table points to the objects
+	matches := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(document)
+	xref, _ := strconv.Atoi(matches[1])
+	if !strings.HasPrefix(document[xref:], "xref\n") {
+		t.Fatalf("Expected startxref to point to the cross-reference table")
+	}
+	for i, offset := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(document[xref:], -1) {
+		position, _ := strconv.Atoi(offset[1])
+		if !strings.HasPrefix(document[position:], strconv.Itoa(i+1)+" 0 obj") {
+			t.Errorf("Expected object %d at offset %d", i+1, position)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package pdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+var severityColors = map[analyzer.ResultSeverity]string{
+	analyzer.SeverityCritical: "0.6 0 0",
+	analyzer.SeverityHigh:     "0.85 0.1 0.1",
+	analyzer.SeverityMedium:   "0.8 0.5 0",
+	analyzer.SeverityLow:      "0.1 0.4 0.7",
+}
+
+var severities = []analyzer.ResultSeverity{analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium, analyzer.SeverityLow}
+
+// Report is the analysis of a Containerfile or an image, as required by the compliance and audit
+// workflows: a summary, the details of the findings of each rule, a remediation appendix and a
+// sign-off block.
+type Report struct {
+	// Target is the analyzed Containerfile or image
+	Target  string
+	Date    time.Time
+	Results []analyzer.Result
+	// FailOn is the least severe failed finding failing the verdict
+	FailOn analyzer.ResultSeverity
+	Score  int
+	// Version is the version of doa
+	Version string
+	// Runtime is the runtime detected from the Containerfile, the remediations are tailored to it
+	Runtime analyzer.Runtime
+	// Coverage is the coverage of the instructions, the section is left out when empty
+	Coverage []analyzer.NodeCoverage
+}
+
+// ruleFindings are the failed findings of a rule
+type ruleFindings struct {
+	rule     analyzer.Rule
+	findings []analyzer.Result
+}
+
+// Write writes the report as a PDF document.
+func Write(w io.Writer, report Report) error {
+	_, err := report.Document().WriteTo(w)
+	return err
+}
+
+// Document lays out the report.
+func (r Report) Document() *Document {
+	d := NewDocument("OpenShift compliance report - " + r.Target)
+	d.Author = "doa " + r.Version
+	d.Date = r.Date
+	d.Footer = fmt.Sprintf("doa %s, ruleset %s - %s", r.Version, analyzer.RULESET_VERSION, r.Target)
+
+	summary := analyzer.SummarizeFailingOn(r.Results, r.FailOn)
+	rules := r.failedRules()
+
+	d.Text(FontBold, 20, 0, "OpenShift compliance report")
+	d.Space(8)
+	columns := []float64{0, 110}
+	d.Row(FontBold, 10, columns, "Target", r.Target)
+	d.Row(FontBold, 10, columns, "Date", r.Date.Format("2006-01-02 15:04 MST"))
+	d.Row(FontBold, 10, columns, "Analyzer", fmt.Sprintf("doa %s, ruleset %s", r.Version, analyzer.RULESET_VERSION))
+	d.Row(FontBold, 10, columns, "Fails on", fmt.Sprintf("%s severity and above", r.FailOn))
+	if r.Runtime != "" {
+		d.Row(FontBold, 10, columns, "Runtime", string(r.Runtime))
+	}
+	d.Space(8)
+	verdict, color := "PASSED", "0 0.5 0.1"
+	if summary.Verdict == analyzer.VerdictFailed {
+		verdict, color = "FAILED", severityColors[analyzer.SeverityHigh]
+	}
+	d.ColoredText(FontBold, 16, 0, fmt.Sprintf("%s - score %d/100", verdict, r.Score), color)
+	counts := []string{}
+	for _, severity := range severities {
+		counts = append(counts, fmt.Sprintf("%d %s", summary.BySeverity[severity], severity))
+	}
+	d.Text(FontRegular, 10, 0, fmt.Sprintf("%d issue(s) found: %s", summary.Failed, strings.Join(counts, ", ")))
+	d.Rule()
+
+	d.Ensure(60)
+	d.Text(FontBold, 14, 0, "Summary")
+	d.Space(4)
+	if len(rules) == 0 {
+		d.Text(FontRegular, 10, 0, "No issue was found.")
+	} else {
+		columns = []float64{0, 260, 340, 420}
+		d.Row(FontBold, 9, columns, "Rule", "Severity", "Confidence", "Findings")
+		for _, rule := range rules {
+			d.Row(FontRegular, 9, columns, rule.rule.Name, string(rule.rule.Severity), string(rule.rule.Confidence), fmt.Sprint(len(rule.findings)))
+		}
+	}
+
+	if len(rules) > 0 {
+		d.Rule()
+		d.Ensure(60)
+		d.Text(FontBold, 14, 0, "Findings")
+		for _, rule := range rules {
+			d.Space(6)
+			d.Ensure(50)
+			d.Text(FontBold, 11, 0, fmt.Sprintf("%s (%s)", rule.rule.Name, rule.rule.ID))
+			d.ColoredText(FontRegular, 9, 0, fmt.Sprintf("%s severity, %s confidence", rule.rule.Severity, rule.rule.Confidence), severityColors[rule.rule.Severity])
+			if rule.rule.Description != "" {
+				d.Text(FontRegular, 9, 0, rule.rule.Description)
+			}
+			for _, finding := range rule.findings {
+				d.Space(2)
+				if where := location(finding); where != "" {
+					d.Text(FontMono, 8, 12, where)
+				}
+				d.Text(FontRegular, 9, 12, "• "+finding.Description)
+			}
+		}
+
+		d.NewPage()
+		d.Text(FontBold, 14, 0, "Appendix: remediation")
+		for _, rule := range rules {
+			d.Space(6)
+			d.Ensure(50)
+			d.Text(FontBold, 11, 0, fmt.Sprintf("%s (%s)", rule.rule.Name, rule.rule.ID))
+			remediation := rule.rule.RemediationFor(r.Runtime)
+			if remediation == "" {
+				remediation = "No remediation is documented for this rule."
+			}
+			d.Text(FontRegular, 9, 0, remediation)
+			if len(rule.rule.Instructions) > 0 {
+				d.Text(FontRegular, 9, 0, "Checked instructions: "+strings.Join(rule.rule.Instructions, ", "))
+			}
+			for _, reference := range rule.rule.References {
+				d.Text(FontMono, 8, 12, reference)
+			}
+		}
+	}
+
+	if len(r.Coverage) > 0 {
+		d.Rule()
+		d.Ensure(60)
+		d.Text(FontBold, 14, 0, "Instruction coverage")
+		d.Space(4)
+		covered := 0
+		columns = []float64{0, 40, 120, 200}
+		d.Row(FontBold, 9, columns, "Line", "Instruction", "Coverage", "Rules")
+		for _, entry := range r.Coverage {
+			if entry.Covered() {
+				covered++
+			}
+			d.Row(FontRegular, 9, columns, fmt.Sprint(entry.Line.Start), entry.Instruction, entry.Status(), strings.Join(entry.Rules, ", "))
+		}
+		d.Space(4)
+		d.Text(FontRegular, 9, 0, fmt.Sprintf("%d/%d instruction(s) covered by at least one rule", covered, len(r.Coverage)))
+	}
+
+	d.Rule()
+	d.Ensure(150)
+	d.Text(FontBold, 14, 0, "Sign-off")
+	d.Space(4)
+	d.Text(FontRegular, 9, 0, "[ ] Approved     [ ] Approved with exceptions     [ ] Rejected")
+	for _, field := range []string{"Reviewed by", "Role", "Date", "Signature"} {
+		d.Space(10)
+		d.Text(FontRegular, 10, 0, field+": ____________________________________________")
+	}
+	return d
+}
+
+// failedRules groups the failed findings by rule, the most severe rules first.
+func (r Report) failedRules() []*ruleFindings {
+	byID := map[string]*ruleFindings{}
+	rules := []*ruleFindings{}
+	for _, result := range r.Results {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		id := result.RuleID
+		if id == "" {
+			id = result.Name
+		}
+		rule, ok := byID[id]
+		if !ok {
+			found, known := analyzer.FindRule(id)
+			if !known {
+				found = analyzer.Rule{ID: id, Name: result.Name}
+			}
+			// the severity and the confidence may have been changed by the configuration
+			found.Severity, found.Confidence = result.Severity, result.Confidence
+			rule = &ruleFindings{rule: found}
+			byID[id] = rule
+			rules = append(rules, rule)
+		}
+		rule.findings = append(rule.findings, result)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].rule.Severity.AtLeast(rules[j].rule.Severity) && !rules[j].rule.Severity.AtLeast(rules[i].rule.Severity)
+	})
+	return rules
+}
+
+// location returns where the finding was found, empty when it isn't bound to a line.
+func location(result analyzer.Result) string {
+	switch {
+	case result.Manifest != nil:
+		return fmt.Sprintf("%s, %s line %d", result.Manifest.Resource, result.Manifest.Path, result.Manifest.Line)
+	case result.File != nil:
+		return fmt.Sprintf("%s line %d", result.File.Path, result.File.Line)
+	case result.Line != nil && result.Line.Start > 0:
+		if result.Line.End > result.Line.Start {
+			return fmt.Sprintf("lines %d-%d", result.Line.Start, result.Line.End)
+		}
+		return fmt.Sprintf("line %d", result.Line.Start)
+	}
+	return ""
+}
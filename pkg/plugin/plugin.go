@@ -0,0 +1,282 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package plugin loads the rule packs shipped as separate binaries and serves their
+// implementation. A plugin is an executable of the plugins directory calling Serve with its
+// analyzer.Plugin implementation, e.g.
+//
+//	func main() {
+//		plugin.Serve(myRules{})
+//	}
+//
+// doa starts the plugins and talks to them over gRPC with hashicorp/go-plugin, so they can be
+// written and distributed independently of the tool.
+ package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// PROTOCOL_VERSION has to be bumped on every incompatible change of the gRPC service, the
+// plugins built for another version are refused.
+const PROTOCOL_VERSION = 1
+
+const PLUGIN_NAME = "analyzer"
+
+// SERVICE_NAME is the name of the gRPC service of the plugins
+const SERVICE_NAME = "doa.plugin.v1.Analyzer"
+
+// Handshake prevents the plugins from being run directly and checks their protocol version.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  PROTOCOL_VERSION,
+	MagicCookieKey:   "DOA_PLUGIN",
+	MagicCookieValue: "7d8f3b1e-docker-openshift-analyzer",
+}
+
+// DefaultDir is the directory the plugins are discovered in, e.g. ~/.config/doa/plugins
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "doa", "plugins")
+}
+
+// Serve serves the plugin implementation, it's meant to be called by the main function of the
+// plugin binary and only returns when doa stops the plugin.
+func Serve(impl analyzer.Plugin) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         pluginSet(impl),
+		GRPCServer:      goplugin.DefaultGRPCServer,
+	})
+}
+
+func pluginSet(impl analyzer.Plugin) map[string]goplugin.Plugin {
+	return map[string]goplugin.Plugin{PLUGIN_NAME: &analyzerPlugin{impl: impl}}
+}
+
+// Set is the plugins started by Load, they have to be stopped with Close.
+type Set struct {
+	Plugins []analyzer.Plugin
+	// Paths are the executables of the plugins by name
+	Paths   map[string]string
+	clients []*goplugin.Client
+}
+
+// Load starts the executables of dir and registers the rules of the plugins. A missing directory
+// has no plugins.
+func Load(dir string) (*Set, error) {
+	set := &Set{Paths: map[string]string{}}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the plugins directory %s", dir)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || (runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0) {
+			continue
+		}
+		if err := set.start(entry.Name(), filepath.Join(dir, entry.Name())); err != nil {
+			set.Close()
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func (s *Set) start(name string, path string) error {
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          pluginSet(nil),
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "plugin",
+			Output: os.Stderr,
+			Level:  hclog.Error,
+		}),
+	})
+	s.clients = append(s.clients, client)
+	rpcClient, err := client.Client()
+	if err != nil {
+		return errors.Wrapf(err, "unable to start the plugin %s", path)
+	}
+	raw, err := rpcClient.Dispense(PLUGIN_NAME)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load the plugin %s", path)
+	}
+	plugin := raw.(*grpcClient)
+	plugin.name = name
+	rules, err := plugin.Rules()
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the rules of the plugin %s", path)
+	}
+	if err := analyzer.RegisterRules(name, rules); err != nil {
+		return err
+	}
+	s.Plugins = append(s.Plugins, plugin)
+	s.Paths[name] = path
+	return nil
+}
+
+// Close stops the plugins.
+func (s *Set) Close() {
+	for _, client := range s.clients {
+		client.Kill()
+	}
+	s.clients = nil
+}
+
+// analyzerPlugin is the go-plugin glue of analyzer.Plugin, only the gRPC protocol is supported.
+type analyzerPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+	impl analyzer.Plugin
+}
+
+func (p *analyzerPlugin) GRPCServer(broker *goplugin.GRPCBroker, server *grpc.Server) error {
+	server.RegisterService(&serviceDesc, &grpcServer{impl: p.impl})
+	return nil
+}
+
+func (p *analyzerPlugin) GRPCClient(ctx context.Context, broker *goplugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return &grpcClient{conn: conn}, nil
+}
+
+// The service exchanges JSON documents wrapped in BytesValue messages, so that the rules and the
+// results keep the format of the JSON output of doa:
+//
+//	service Analyzer {
+//	  rpc Rules(google.protobuf.Empty) returns (google.protobuf.BytesValue);
+//	  rpc Analyze(google.protobuf.BytesValue) returns (google.protobuf.BytesValue);
+//	}
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: SERVICE_NAME,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Rules",
+			Handler: unaryHandler("Rules", func() interface{} { return &emptypb.Empty{} }, func(s *grpcServer, in interface{}) (interface{}, error) {
+				return s.rules()
+			}),
+		},
+		{
+			MethodName: "Analyze",
+			Handler: unaryHandler("Analyze", func() interface{} { return &wrapperspb.BytesValue{} }, func(s *grpcServer, in interface{}) (interface{}, error) {
+				return s.analyze(in.(*wrapperspb.BytesValue))
+			}),
+		},
+	},
+	Metadata: "doa/plugin/v1/analyzer.proto",
+}
+
+// unaryHandler decodes the request of the method and calls it through the interceptors of the
+// server, as the handlers generated by protoc-gen-go-grpc do.
+func unaryHandler(method string, request func() interface{}, call func(*grpcServer, interface{}) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := request()
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(*grpcServer), req)
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + SERVICE_NAME + "/" + method}, handler)
+	}
+}
+
+type grpcServer struct {
+	impl analyzer.Plugin
+}
+
+func (s *grpcServer) rules() (*wrapperspb.BytesValue, error) {
+	rules, err := s.impl.Rules()
+	if err != nil {
+		return nil, err
+	}
+	return marshal(rules)
+}
+
+func (s *grpcServer) analyze(content *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	results, err := s.impl.Analyze(content.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return marshal(results)
+}
+
+func marshal(v interface{}) (*wrapperspb.BytesValue, error) {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return wrapperspb.Bytes(bytes), nil
+}
+
+// grpcClient is the analyzer.Plugin implementation calling the plugin process.
+type grpcClient struct {
+	name string
+	conn *grpc.ClientConn
+}
+
+func (c *grpcClient) Name() string {
+	return c.name
+}
+
+func (c *grpcClient) Rules() ([]analyzer.Rule, error) {
+	out := &wrapperspb.BytesValue{}
+	if err := c.conn.Invoke(context.Background(), "/"+SERVICE_NAME+"/Rules", &emptypb.Empty{}, out); err != nil {
+		return nil, err
+	}
+	var rules []analyzer.Rule
+	if err := json.Unmarshal(out.GetValue(), &rules); err != nil {
+		return nil, errors.Wrap(err, "invalid rules")
+	}
+	return rules, nil
+}
+
+func (c *grpcClient) Analyze(content []byte) ([]analyzer.Result, error) {
+	out := &wrapperspb.BytesValue{}
+	if err := c.conn.Invoke(context.Background(), "/"+SERVICE_NAME+"/Analyze", wrapperspb.Bytes(content), out); err != nil {
+		return nil, err
+	}
+	var results []analyzer.Result
+	if err := json.Unmarshal(out.GetValue(), &results); err != nil {
+		return nil, errors.Wrap(err, "invalid results")
+	}
+	return results, nil
+}
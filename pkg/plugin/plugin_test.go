@@ -0,0 +1,86 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package plugin
+
+import (
+	"strings"
+	"testing"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+var ruleCurlPipe = analyzer.Rule{
+	ID:       "test-curl-pipe",
+	Name:     "Piped download",
+	Severity: analyzer.SeverityHigh,
+}
+
+type curlPipe struct{}
+
+func (curlPipe) Name() string {
+	return "curl-pipe"
+}
+
+func (curlPipe) Rules() ([]analyzer.Rule, error) {
+	return []analyzer.Rule{ruleCurlPipe}, nil
+}
+
+func (curlPipe) Analyze(content []byte) ([]analyzer.Result, error) {
+	var results []analyzer.Result
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.Contains(line, "| sh") {
+			result := ruleCurlPipe.Failed("the downloaded script is run without verification")
+			result.Line = &analyzer.Line{Start: i + 1, End: i + 1}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func TestPluginOverGRPC(t *testing.T) {
+	client, _ := goplugin.TestPluginGRPCConn(t, pluginSet(curlPipe{}))
+	defer client.Close()
+	raw, err := client.Dispense(PLUGIN_NAME)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	plugin := raw.(analyzer.Plugin)
+
+	rules, err := plugin.Rules()
+	if err != nil || len(rules) != 1 || rules[0].ID != ruleCurlPipe.ID {
+		t.Fatalf("Unexpected rules %v, error %v", rules, err)
+	}
+	results, err := plugin.Analyze([]byte("FROM ubi9\nRUN curl -sL https://example.com/install | sh\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(results) != 1 || results[0].RuleID != ruleCurlPipe.ID || results[0].Line.Start != 2 {
+		t.Errorf("Unexpected results %v", results)
+	}
+}
+
+func TestLoadMissingDirectoryHasNoPlugins(t *testing.T) {
+	set, err := Load(t.TempDir() + "/plugins")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(set.Plugins) != 0 {
+		t.Errorf("Expected no plugins but they were %d", len(set.Plugins))
+	}
+}
@@ -0,0 +1,238 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package policy distributes the configuration of doa as OCI artifacts, so that a platform team
+// can version a policy, push it to a registry and have every project pull and run the exact same
+// rules, e.g.
+//
+//	doa policy push ghcr.io/org/openshift-policy:v3 -f .doa.yaml
+//	doa policy pull ghcr.io/org/openshift-policy:v3
+//	doa analyze -f Containerfile --policy ghcr.io/org/openshift-policy:v3
+ package policy
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+)
+
+const (
+	// CONFIG_MEDIA_TYPE identifies the policy artifacts, as the artifact type of OCI 1.1
+	CONFIG_MEDIA_TYPE types.MediaType = "application/vnd.redhat.doa.policy.config.v1+json"
+	// LAYER_MEDIA_TYPE is the media type of the layer holding the configuration file
+	LAYER_MEDIA_TYPE types.MediaType = "application/vnd.redhat.doa.policy.layer.v1+yaml"
+	// POLICY_FILE is the name of the configuration file in the cache and in the layer annotations
+	POLICY_FILE = "policy.yaml"
+)
+
+// Bundle is a policy pulled from a registry.
+type Bundle struct {
+	Reference string
+	// Digest is the digest of the manifest of the artifact
+	Digest string
+	// Content is the configuration file, see config.Parse
+	Content []byte
+}
+
+// Config parses the configuration file of the bundle.
+func (b *Bundle) Config() (*config.Config, error) {
+	return config.Parse(b.Content, b.Reference)
+}
+
+// Push validates the configuration file and pushes it as an OCI artifact, it returns the digest
+// of the pushed manifest.
+func Push(reference string, content []byte) (string, error) {
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return "", err
+	}
+	if _, err := config.Parse(content, POLICY_FILE); err != nil {
+		return "", err
+	}
+	artifact := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	artifact = mutate.ConfigMediaType(artifact, CONFIG_MEDIA_TYPE)
+	artifact, err = mutate.Append(artifact, mutate.Addendum{
+		Layer: static.NewLayer(content, LAYER_MEDIA_TYPE),
+		Annotations: map[string]string{
+			"org.opencontainers.image.title": POLICY_FILE,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := remote.Write(ref, artifact, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+		return "", errors.Wrapf(err, "unable to push the policy %s", reference)
+	}
+	digest, err := artifact.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+// Pull fetches the policy artifact from the registry. When verifier is not nil, the artifact must
+// have a trusted cosign signature.
+func Pull(reference string, verifier *signature.Verifier) (*Bundle, error) {
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pull the policy %s", reference)
+	}
+	manifest, err := artifact.Manifest()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pull the policy %s", reference)
+	}
+	if manifest.Config.MediaType != CONFIG_MEDIA_TYPE {
+		return nil, errors.Errorf("%s is not a doa policy, its config media type is %s", reference, manifest.Config.MediaType)
+	}
+	digest, err := artifact.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if verifier != nil {
+		if err := verifier.VerifyImage(ref.Context().Digest(digest.String()), remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+			return nil, errors.Wrapf(err, "unable to verify the policy %s", reference)
+		}
+	}
+	layer, err := policyLayer(artifact, manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pull the policy %s", reference)
+	}
+	// the layer is stored as is, without compression
+	reader, err := layer.Compressed()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pull the policy %s", reference)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pull the policy %s", reference)
+	}
+	return &Bundle{Reference: reference, Digest: digest.String(), Content: content}, nil
+}
+
+func policyLayer(artifact v1.Image, manifest *v1.Manifest) (v1.Layer, error) {
+	for _, descriptor := range manifest.Layers {
+		if descriptor.MediaType == LAYER_MEDIA_TYPE {
+			return artifact.LayerByDigest(descriptor.Digest)
+		}
+	}
+	return nil, errors.Errorf("no layer of type %s", LAYER_MEDIA_TYPE)
+}
+
+// CacheDir is the directory the pulled policies are stored in, e.g. ~/.cache/doa/policies
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "doa", "policies"), nil
+}
+
+// cachePath is the path of the configuration file of the reference in the cache, e.g.
+// ghcr.io/org/openshift-policy/v3/policy.yaml
+func cachePath(reference string) (string, error) {
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return "", err
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	identifier := strings.ReplaceAll(ref.Identifier(), ":", "-")
+	return filepath.Join(dir, ref.Context().RegistryStr(), filepath.FromSlash(ref.Context().RepositoryStr()), identifier, POLICY_FILE), nil
+}
+
+// Store saves the bundle in the cache and returns the path of its configuration file.
+func Store(bundle *Bundle) (string, error) {
+	path, err := cachePath(bundle.Reference)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", errors.Wrap(err, "unable to create the policy cache")
+	}
+	if err := os.WriteFile(path, bundle.Content, 0644); err != nil {
+		return "", errors.Wrap(err, "unable to store the policy")
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "digest"), []byte(bundle.Digest+"\n"), 0644); err != nil {
+		return "", errors.Wrap(err, "unable to store the policy")
+	}
+	return path, nil
+}
+
+// Load returns the policy from the cache, pulling and storing it when it was never pulled. The
+// policies in the cache were verified when they were pulled.
+func Load(reference string, verifier *signature.Verifier) (*Bundle, error) {
+	path, err := cachePath(reference)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		bundle, err := Pull(reference, verifier)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := Store(bundle); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the policy %s", path)
+	}
+	digest, _ := os.ReadFile(filepath.Join(filepath.Dir(path), "digest"))
+	return &Bundle{Reference: reference, Digest: strings.TrimSpace(string(digest)), Content: content}, nil
+}
+
+// OCI_SCHEME prefixes the references of the policies extended by a configuration file, e.g.
+// extends: oci://ghcr.io/org/openshift-policy:v3
+const OCI_SCHEME = "oci://"
+
+// Fetcher returns the fetcher of the configurations extended by a configuration file, the OCI
+// artifacts being loaded as Load does and the other sources fetched by config.Fetch.
+func Fetcher(verifier *signature.Verifier) config.Fetcher {
+	return func(source string) ([]byte, error) {
+		if !strings.HasPrefix(source, OCI_SCHEME) {
+			return config.Fetch(source)
+		}
+		bundle, err := Load(strings.TrimPrefix(source, OCI_SCHEME), verifier)
+		if err != nil {
+			return nil, err
+		}
+		return bundle.Content, nil
+	}
+}
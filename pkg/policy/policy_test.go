@@ -0,0 +1,167 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+)
+
+const policyContent = "rules:\n  unpinned-packages:\n    severity: high\n"
+
+func newRegistry() *httptest.Server {
+	return httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+}
+
+func TestPushAndPull(t *testing.T) {
+	server := newRegistry()
+	defer server.Close()
+	reference := strings.TrimPrefix(server.URL, "http://") + "/org/openshift-policy:v3"
+
+	digest, err := Push(reference, []byte(policyContent))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	bundle, err := Pull(reference, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if bundle.Digest != digest || string(bundle.Content) != policyContent {
+		t.Errorf("Unexpected bundle %s %q", bundle.Digest, bundle.Content)
+	}
+	cfg, err := bundle.Config()
+	if err != nil || cfg.Rules["unpinned-packages"].Severity != "high" {
+		t.Errorf("Unexpected configuration %v, error %v", cfg, err)
+	}
+}
+
+func TestPushRejectsInvalidConfiguration(t *testing.T) {
+	if _, err := Push("localhost:5000/org/policy:v1", []byte("rules:\n  unknown-rule:\n    disabled: true\n")); err == nil {
+		t.Errorf("Expected an error for an invalid configuration")
+	}
+}
+
+func TestLoadStoresPulledPolicy(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	server := newRegistry()
+	reference := strings.TrimPrefix(server.URL, "http://") + "/org/openshift-policy:v3"
+	if _, err := Push(reference, []byte(policyContent)); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if _, err := Load(reference, nil); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	// the policy is read from the cache once the registry is gone
+	server.Close()
+	bundle, err := Load(reference, nil)
+	if err != nil || string(bundle.Content) != policyContent || bundle.Digest == "" {
+		t.Errorf("Unexpected bundle %v, error %v", bundle, err)
+	}
+}
+
+func TestFetcherExtendsPolicy(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	server := newRegistry()
+	defer server.Close()
+	reference := strings.TrimPrefix(server.URL, "http://") + "/org/openshift-policy:v3"
+	if _, err := Push(reference, []byte(policyContent+"fail-on: medium\n")); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	content := "extends: " + OCI_SCHEME + reference + "\nrules:\n  sudo-su:\n    disabled: true\n"
+	cfg, err := config.ParseWith([]byte(content), ".doa.yaml", Fetcher(nil))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if cfg.Rules["unpinned-packages"].Severity != "high" || !cfg.Rules["sudo-su"].Disabled || cfg.FailOn != "medium" {
+		t.Errorf("Expected the project configuration merged on top of the policy but got %v", cfg)
+	}
+}
+
+func TestPullVerifiesSignature(t *testing.T) {
+	server := newRegistry()
+	defer server.Close()
+	reference := strings.TrimPrefix(server.URL, "http://") + "/org/openshift-policy:v3"
+	digest, err := Push(reference, []byte(policyContent))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	verifier, err := signature.NewVerifier(signature.Trust{
+		Keys: []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Pull(reference, verifier); err == nil {
+		t.Errorf("Expected an error for an unsigned policy")
+	}
+	cosignSign(t, reference, digest, key)
+	if _, err := Pull(reference, verifier); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+}
+
+// cosignSign pushes the signature of the policy as cosign sign does
+func cosignSign(t *testing.T, reference string, digest string, key *ecdsa.PrivateKey) {
+	ref, _ := name.ParseReference(reference)
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, ref.Context().Name(), digest))
+	hash := sha256.Sum256(payload)
+	content, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: map[string]string{signature.SIGNATURE_ANNOTATION: base64.StdEncoding.EncodeToString(content)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := ref.Context().Tag(strings.Replace(digest, ":", "-", 1) + ".sig")
+	if err := remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,158 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package rdjson writes the results in the Reviewdog Diagnostic Format
+// (https://github.com/reviewdog/reviewdog/tree/master/proto/rdf), so that reviewdog -f=rdjson can
+// post them as review comments, along with the changes of doa convert as suggestions.
+ package rdjson
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/checkstyle"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/convert"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/sarif"
+)
+
+type DiagnosticResult struct {
+	Source      Source       `json:"source"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type Source struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type Diagnostic struct {
+	Message     string       `json:"message"`
+	Location    Location     `json:"location"`
+	Severity    string       `json:"severity"`
+	Code        *Code        `json:"code,omitempty"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+}
+
+type Location struct {
+	Path  string `json:"path"`
+	Range *Range `json:"range,omitempty"`
+}
+
+// Range is the range of text from Start to End, excluded
+type Range struct {
+	Start Position  `json:"start"`
+	End   *Position `json:"end,omitempty"`
+}
+
+// Position is a position in a file, the lines and columns being numbered from 1
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type Code struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type Suggestion struct {
+	Range Range  `json:"range"`
+	Text  string `json:"text"`
+}
+
+// Severity maps a severity to the corresponding rdjson severity.
+func Severity(severity analyzer.ResultSeverity) string {
+	switch severity {
+	case analyzer.SeverityCritical, analyzer.SeverityHigh:
+		return "ERROR"
+	case analyzer.SeverityMedium:
+		return "WARNING"
+	default:
+		return "INFO"
+	}
+}
+
+// NewResult returns the failed results as diagnostics. When the content of the Containerfile is
+// given, the edits of doa convert are suggested on the findings of the lines they change, each edit
+// being suggested once.
+func NewResult(containerfile string, content []byte, results []analyzer.Result) DiagnosticResult {
+	var edits []convert.Edit
+	if content != nil {
+		if converted, err := convert.Convert(content); err == nil {
+			edits = converted.Edits
+		}
+	}
+	lines := strings.Split(string(content), "\n")
+	assigned := convert.AssignEdits(edits, results)
+
+	result := DiagnosticResult{
+		Source:      Source{Name: sarif.TOOL_NAME, URL: sarif.TOOL_URI},
+		Diagnostics: []Diagnostic{},
+	}
+	for i, res := range results {
+		if res.Status != analyzer.StatusFailed {
+			continue
+		}
+		path, line := checkstyle.Location(containerfile, res)
+		diagnostic := Diagnostic{
+			Message:  res.Description,
+			Location: Location{Path: path},
+			Severity: Severity(res.Severity),
+		}
+		if line > 0 {
+			diagnostic.Location.Range = &Range{Start: Position{Line: line}}
+		}
+		if res.RuleID != "" {
+			diagnostic.Code = &Code{Value: res.RuleID}
+			if rule, ok := analyzer.FindRule(res.RuleID); ok && len(rule.References) > 0 {
+				diagnostic.Code.URL = rule.References[0]
+			}
+		}
+		for _, edit := range assigned[i] {
+			diagnostic.Suggestions = append(diagnostic.Suggestions, suggestion(edit, lines))
+		}
+		result.Diagnostics = append(result.Diagnostics, diagnostic)
+	}
+	return result
+}
+
+// suggestion returns the edit as the replacement of the text of its lines.
+func suggestion(edit convert.Edit, lines []string) Suggestion {
+	end := Position{Line: edit.End, Column: 1}
+	if edit.End >= 1 && edit.End <= len(lines) {
+		end.Column = len(lines[edit.End-1]) + 1
+	}
+	switch {
+	case edit.Insertion():
+		// the lines are inserted at the end of the previous line, which also works at the end of
+		// the file
+		return Suggestion{Range: Range{Start: end, End: &end}, Text: "\n" + strings.Join(edit.Lines, "\n")}
+	case len(edit.Lines) == 0:
+		return Suggestion{Range: Range{Start: Position{Line: edit.Start, Column: 1}, End: &Position{Line: edit.End + 1, Column: 1}}}
+	}
+	return Suggestion{Range: Range{Start: Position{Line: edit.Start, Column: 1}, End: &end}, Text: strings.Join(edit.Lines, "\n")}
+}
+
+// Write writes the failed results as a diagnostic result, see NewResult.
+func Write(w io.Writer, containerfile string, content []byte, results []analyzer.Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewResult(containerfile, content, results))
+}
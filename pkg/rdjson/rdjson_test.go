@@ -0,0 +1,64 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package rdjson
+
+import (
+	"reflect"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/convert"
+)
+
+func TestNewResultSuggestsConversions(t *testing.T) {
+	content := []byte(`FROM registry.access.redhat.com/ubi9/ubi-minimal:9.3
+EXPOSE 80
+USER root
+CMD ["/app"]`)
+	port := analyzer.RulePrivilegedPort.Failed("port 80 is privileged")
+	port.Line = &analyzer.Line{Start: 2, End: 2}
+	root := analyzer.RuleUserRoot.Failed("USER root")
+	root.Line = &analyzer.Line{Start: 3, End: 3}
+	secret := analyzer.RuleContextSecret.Failed("id_rsa is in the build context")
+	secret.File = &analyzer.FileLocation{Path: "id_rsa"}
+
+	result := NewResult("Containerfile", content, []analyzer.Result{port, root, secret, analyzer.RuleUserRoot.Passed("USER 1001")})
+	if len(result.Diagnostics) != 3 || result.Source.Name != "doa" {
+		t.Fatalf("Unexpected result %v", result)
+	}
+	expected := []Suggestion{{Range: Range{Start: Position{Line: 2, Column: 1}, End: &Position{Line: 2, Column: 10}}, Text: "EXPOSE 8080"}}
+	if diagnostic := result.Diagnostics[0]; diagnostic.Severity != "ERROR" || diagnostic.Location.Range.Start.Line != 2 || !reflect.DeepEqual(diagnostic.Suggestions, expected) {
+		t.Errorf("Unexpected diagnostic %+v", diagnostic)
+	}
+	expected = []Suggestion{{Range: Range{Start: Position{Line: 3, Column: 1}, End: &Position{Line: 3, Column: 10}}, Text: "USER 1001"}}
+	if diagnostic := result.Diagnostics[1]; diagnostic.Code.Value != analyzer.RuleUserRoot.ID || !reflect.DeepEqual(diagnostic.Suggestions, expected) {
+		t.Errorf("Unexpected diagnostic %+v", diagnostic)
+	}
+	if diagnostic := result.Diagnostics[2]; diagnostic.Location.Path != "id_rsa" || diagnostic.Location.Range != nil || diagnostic.Suggestions != nil {
+		t.Errorf("Unexpected diagnostic %+v", diagnostic)
+	}
+}
+
+func TestInsertionSuggestion(t *testing.T) {
+	lines := []string{"FROM ubi9", `CMD ["/app"]`}
+	end := Position{Line: 2, Column: 13}
+	suggestion := suggestion(convert.Edit{Start: 3, End: 2, Lines: []string{"USER 1001"}}, lines)
+	if !reflect.DeepEqual(suggestion, Suggestion{Range: Range{Start: end, End: &end}, Text: "\nUSER 1001"}) {
+		t.Errorf("Unexpected suggestion %+v", suggestion)
+	}
+}
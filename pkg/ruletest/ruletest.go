@@ -0,0 +1,187 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package ruletest lets the authors of plugins and of custom rules test their rules the way the
+// built-in rules are tested: Containerfile snippets are analyzed with the rules and the findings
+// of a single rule are asserted, e.g.
+//
+//	func TestDebugEnabled(t *testing.T) {
+//		rules := ruletest.ConfigFile(t, ".doa.yaml")
+//		rules.Expect(t, "FROM ubi9\nENV DEBUG=true\n", "debug-enabled", ruletest.Finding{Line: 2, Severity: analyzer.SeverityMedium})
+//		rules.Expect(t, "FROM ubi9\nENV DEBUG=false\n", "debug-enabled")
+//	}
+ package ruletest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+// Finding is an expected failed result of a rule, its zero fields are not checked.
+type Finding struct {
+	Line     int
+	Severity analyzer.ResultSeverity
+}
+
+func (f Finding) String() string {
+	var parts []string
+	if f.Line != 0 {
+		parts = append(parts, fmt.Sprintf("line %d", f.Line))
+	}
+	if f.Severity != "" {
+		parts = append(parts, string(f.Severity))
+	}
+	if len(parts) == 0 {
+		return "any finding"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Runner analyzes the snippets with the built-in rules and the rules under test.
+type Runner struct {
+	ctx context.Context
+	cfg *config.Config
+}
+
+// New returns a runner of the plugins, whose rules are registered in the catalog until the end
+// of the test. Without plugin, only the built-in rules are run.
+func New(t testing.TB, plugins ...analyzer.Plugin) *Runner {
+	t.Helper()
+	for _, plugin := range plugins {
+		register(t, plugin)
+	}
+	return &Runner{ctx: analyzer.WithPlugins(context.Background(), plugins), cfg: &config.Config{}}
+}
+
+// Config returns a runner of the custom rules of the configuration, whose severity overrides and
+// disabled rules are applied to the findings as doa analyze does.
+func Config(t testing.TB, content string) *Runner {
+	t.Helper()
+	cfg, err := config.Parse([]byte(content), "configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return withConfig(t, cfg)
+}
+
+// ConfigFile is Config for the configuration file at path, e.g. the .doa.yaml of the repository.
+func ConfigFile(t testing.TB, path string) *Runner {
+	t.Helper()
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return withConfig(t, cfg)
+}
+
+func withConfig(t testing.TB, cfg *config.Config) *Runner {
+	t.Helper()
+	plugin, err := cfg.Plugin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	register(t, plugin)
+	ctx := analyzer.WithPlugins(context.Background(), []analyzer.Plugin{plugin})
+	if cfg.Platform != "" {
+		ctx = analyzer.WithPlatform(ctx, cfg.Platform)
+	}
+	return &Runner{ctx: analyzer.WithPacks(ctx, cfg.EnabledPacks()), cfg: cfg}
+}
+
+// register adds the rules of the plugin to the catalog and removes them when the test ends, so
+// that the tests of a package can register the same rules.
+func register(t testing.TB, plugin analyzer.Plugin) {
+	t.Helper()
+	rules, err := plugin.Rules()
+	if err == nil {
+		err = analyzer.RegisterRules(plugin.Name(), rules)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		analyzer.UnregisterRules(rules)
+	})
+}
+
+// Analyze returns the results of the analysis of the Containerfile snippet.
+func (r *Runner) Analyze(t testing.TB, snippet string) []analyzer.Result {
+	t.Helper()
+	results := r.cfg.Apply(analyzer.AnalyzeReader(r.ctx, "Containerfile", strings.NewReader(snippet)))
+	for _, result := range results {
+		if result.RuleID == analyzer.RuleParseError.ID {
+			t.Fatalf("Unable to parse the snippet %q: %s", snippet, result.Description)
+		}
+	}
+	return results
+}
+
+// Findings returns the failed results of the rule for the Containerfile snippet.
+func (r *Runner) Findings(t testing.TB, snippet string, ruleID string) []analyzer.Result {
+	t.Helper()
+	if _, ok := analyzer.FindRule(ruleID); !ok {
+		t.Fatalf("Unknown rule %s", ruleID)
+	}
+	findings := []analyzer.Result{}
+	for _, result := range r.Analyze(t, snippet) {
+		if result.RuleID == ruleID && result.Status == analyzer.StatusFailed {
+			findings = append(findings, result)
+		}
+	}
+	return findings
+}
+
+// Expect checks that the rule reports the expected findings for the Containerfile snippet, in
+// order, and nothing else. Without expected finding, the rule must pass.
+func (r *Runner) Expect(t testing.TB, snippet string, ruleID string, expected ...Finding) {
+	t.Helper()
+	findings := r.Findings(t, snippet, ruleID)
+	if len(findings) != len(expected) {
+		t.Errorf("Expected %d findings of %s but got %d: %s", len(expected), ruleID, len(findings), describe(findings))
+		return
+	}
+	for i, finding := range findings {
+		line := 0
+		if finding.Line != nil {
+			line = finding.Line.Start
+		}
+		if (expected[i].Line != 0 && expected[i].Line != line) || (expected[i].Severity != "" && expected[i].Severity != finding.Severity) {
+			t.Errorf("Expected the finding %d of %s to be %s but got %s", i+1, ruleID, expected[i], describe(findings[i:i+1]))
+		}
+	}
+}
+
+func describe(findings []analyzer.Result) string {
+	if len(findings) == 0 {
+		return "none"
+	}
+	var descriptions []string
+	for _, finding := range findings {
+		line := "no line"
+		if finding.Line != nil {
+			line = fmt.Sprintf("line %d", finding.Line.Start)
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s, %s: %s", line, finding.Severity, finding.Description))
+	}
+	return strings.Join(descriptions, "; ")
+}
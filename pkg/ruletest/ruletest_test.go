@@ -0,0 +1,103 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package ruletest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const customRules = `custom-rules:
+  - id: debug-enabled
+    expression: instruction == "ENV" && value.matches("DEBUG=true")
+    message: the debug mode is enabled in the image
+    severity: medium
+rules:
+  debug-enabled:
+    severity: high
+`
+
+var ruleWgetInsecure = analyzer.Rule{ID: "wget-insecure", Name: "Insecure wget", Severity: analyzer.SeverityHigh, Confidence: analyzer.ConfidenceHigh}
+
+// wgetPlugin reports the wget commands not checking the certificates
+type wgetPlugin struct{}
+
+func (wgetPlugin) Name() string {
+	return "wget"
+}
+
+func (wgetPlugin) Rules() ([]analyzer.Rule, error) {
+	return []analyzer.Rule{ruleWgetInsecure}, nil
+}
+
+func (wgetPlugin) Analyze(content []byte) ([]analyzer.Result, error) {
+	results := []analyzer.Result{}
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.Contains(line, "--no-check-certificate") {
+			result := ruleWgetInsecure.Failed("the certificate is not checked")
+			result.Line = &analyzer.Line{Start: i + 1, End: i + 1}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// recorder records the errors of the assertions instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExpectCustomRule(t *testing.T) {
+	rules := Config(t, customRules)
+	rules.Expect(t, "FROM ubi9\nUSER 1001\nENV DEBUG=true\n", "debug-enabled", Finding{Line: 3, Severity: analyzer.SeverityHigh})
+	rules.Expect(t, "FROM ubi9\nUSER 1001\nENV DEBUG=false\n", "debug-enabled")
+
+	failing := &recorder{TB: t}
+	rules.Expect(failing, "FROM ubi9\nUSER 1001\nENV DEBUG=true\n", "debug-enabled", Finding{Line: 2})
+	rules.Expect(failing, "FROM ubi9\nUSER 1001\nENV DEBUG=true\n", "debug-enabled")
+	if len(failing.errors) != 2 || !strings.Contains(failing.errors[0], "to be line 2") || !strings.Contains(failing.errors[1], "Expected 0 findings") {
+		t.Errorf("Expected the wrong expectations to fail but the errors were %v", failing.errors)
+	}
+}
+
+func TestExpectPlugin(t *testing.T) {
+	rules := New(t, wgetPlugin{})
+	rules.Expect(t, "FROM ubi9\nUSER 1001\nRUN wget --no-check-certificate https://example.com/app.tar.gz\n", ruleWgetInsecure.ID, Finding{Line: 3})
+	if _, ok := analyzer.FindRule(ruleWgetInsecure.ID); !ok {
+		t.Errorf("Expected %s to be registered during the test", ruleWgetInsecure.ID)
+	}
+	// the built-in rules are run along with the plugin
+	rules.Expect(t, "FROM ubi9\nUSER root\n", analyzer.RuleUserRoot.ID, Finding{Line: 2})
+}
+
+func TestRulesAreUnregistered(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		New(t, wgetPlugin{})
+	})
+	if _, ok := analyzer.FindRule(ruleWgetInsecure.ID); ok {
+		t.Errorf("Expected %s to be unregistered at the end of the test", ruleWgetInsecure.ID)
+	}
+}
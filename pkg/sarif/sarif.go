@@ -0,0 +1,141 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+// Package sarif contains the subset of the SARIF 2.1.0 format
+// (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) written by doa.
+ package sarif
+
+import (
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const (
+	SCHEMA  = "https://json.schemastore.org/sarif-2.1.0.json"
+	VERSION = "2.1.0"
+
+	TOOL_NAME = "doa"
+	TOOL_URI  = "https://github.com/redhat-developer/podman-desktop-image-checker-openshift-ext"
+)
+
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool       Tool            `json:"tool"`
+	Taxonomies []ToolComponent `json:"taxonomies,omitempty"`
+}
+
+type Tool struct {
+	Driver ToolComponent `json:"driver"`
+}
+
+type ToolComponent struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules,omitempty"`
+	Taxa           []ReportingDescriptor `json:"taxa,omitempty"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *Message               `json:"shortDescription,omitempty"`
+	FullDescription      *Message               `json:"fullDescription,omitempty"`
+	Help                 *Message               `json:"help,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *Configuration         `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Level maps a severity to the corresponding SARIF level.
+func Level(severity analyzer.ResultSeverity) string {
+	switch severity {
+	case analyzer.SeverityCritical, analyzer.SeverityHigh:
+		return "error"
+	case analyzer.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func Descriptor(rule analyzer.Rule) ReportingDescriptor {
+	descriptor := ReportingDescriptor{
+		ID:                   rule.ID,
+		Name:                 rule.Name,
+		ShortDescription:     &Message{Text: rule.Name},
+		FullDescription:      &Message{Text: rule.Description},
+		Help:                 &Message{Text: rule.Remediation},
+		DefaultConfiguration: &Configuration{Level: Level(rule.Severity)},
+		Properties: map[string]interface{}{
+			"severity":   rule.Severity,
+			"confidence": rule.Confidence,
+		},
+	}
+	if len(rule.References) > 0 {
+		descriptor.HelpURI = rule.References[0]
+		descriptor.Properties["references"] = rule.References
+	}
+	if rule.Group != "" {
+		// SARIF tags allow viewers to filter the rules of a group
+		descriptor.Properties["tags"] = []string{rule.Group}
+	}
+	return descriptor
+}
+
+// Taxonomy returns a SARIF log describing the rules as the taxonomy of the doa tool component.
+func Taxonomy(rules []analyzer.Rule, version string) Log {
+	taxa := []ReportingDescriptor{}
+	for _, rule := range rules {
+		taxa = append(taxa, Descriptor(rule))
+	}
+	return Log{
+		Schema:  SCHEMA,
+		Version: VERSION,
+		Runs: []Run{
+			{
+				Tool: Tool{
+					Driver: ToolComponent{
+						Name:           TOOL_NAME,
+						Version:        version,
+						InformationURI: TOOL_URI,
+					},
+				},
+				Taxonomies: []ToolComponent{
+					{
+						Name:           TOOL_NAME,
+						Version:        version,
+						InformationURI: TOOL_URI,
+						Taxa:           taxa,
+					},
+				},
+			},
+		},
+	}
+}
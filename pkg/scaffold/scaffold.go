@@ -0,0 +1,130 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package scaffold generates starter Containerfiles following the rules of the analyzer: a UBI
+// base image, a non-root numeric USER of the root group, group permissions equal to the owner
+// ones, an unprivileged port and an exec form entrypoint.
+ package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+type Runtime string
+
+const (
+	RuntimeNodeJS Runtime = "nodejs"
+	RuntimeJava   Runtime = "java"
+	RuntimePython Runtime = "python"
+	RuntimeGo     Runtime = "go"
+)
+
+// DEFAULT_PORT is the port OpenShift images usually listen on
+const DEFAULT_PORT = 8080
+
+type Options struct {
+	// Port the application listens on, it must not be privileged
+	Port int
+}
+
+// templates are the Containerfiles of the runtimes. The application directories of the UBI
+// images belong to the root group, so that they are writable by the UID assigned by OpenShift.
+var templates = map[Runtime]string{
+	RuntimeNodeJS: `FROM registry.access.redhat.com/ubi9/nodejs-20:1
+WORKDIR /opt/app-root/src
+COPY --chown=1001:0 package*.json ./
+RUN npm ci --omit=dev && npm cache clean --force
+COPY --chown=1001:0 . .
+USER 1001
+ENV PORT={{.Port}}
+EXPOSE {{.Port}}
+CMD ["npm", "start"]
+`,
+	RuntimeJava: `FROM registry.access.redhat.com/ubi9/openjdk-21:1 AS builder
+WORKDIR /build
+COPY --chown=1001:0 pom.xml .
+RUN mvn -B dependency:go-offline
+COPY --chown=1001:0 src src
+RUN mvn -B package -DskipTests
+
+FROM registry.access.redhat.com/ubi9/openjdk-21-runtime:1
+COPY --from=builder --chown=1001:0 --chmod=775 /build/target/*.jar /deployments/app.jar
+USER 1001
+EXPOSE {{.Port}}
+ENTRYPOINT ["java", "-jar", "/deployments/app.jar"]
+CMD ["--server.port={{.Port}}"]
+`,
+	RuntimePython: `FROM registry.access.redhat.com/ubi9/python-312:1
+WORKDIR /opt/app-root/src
+COPY --chown=1001:0 requirements.txt .
+RUN pip install --no-cache-dir -r requirements.txt
+COPY --chown=1001:0 . .
+USER 1001
+EXPOSE {{.Port}}
+CMD ["gunicorn", "--bind", "0.0.0.0:{{.Port}}", "app:app"]
+`,
+	RuntimeGo: `FROM registry.access.redhat.com/ubi9/go-toolset:1.21 AS builder
+COPY --chown=1001:0 . .
+RUN CGO_ENABLED=0 go build -o /tmp/app .
+
+FROM registry.access.redhat.com/ubi9/ubi-minimal:9.4
+COPY --from=builder --chown=1001:0 --chmod=775 /tmp/app /usr/local/bin/app
+USER 1001
+EXPOSE {{.Port}}
+ENTRYPOINT ["/usr/local/bin/app"]
+`,
+}
+
+// Runtimes returns the runtimes a Containerfile can be generated for.
+func Runtimes() []Runtime {
+	return []Runtime{RuntimeNodeJS, RuntimeJava, RuntimePython, RuntimeGo}
+}
+
+func ParseRuntime(value string) (Runtime, error) {
+	runtime := Runtime(strings.ToLower(value))
+	if _, ok := templates[runtime]; !ok {
+		var names []string
+		for _, r := range Runtimes() {
+			names = append(names, string(r))
+		}
+		return "", fmt.Errorf("unknown runtime %s, expected one of %s", value, strings.Join(names, ", "))
+	}
+	return runtime, nil
+}
+
+// Generate returns the Containerfile of the runtime.
+func Generate(runtime Runtime, options Options) ([]byte, error) {
+	text, ok := templates[runtime]
+	if !ok {
+		return nil, fmt.Errorf("unknown runtime %s", runtime)
+	}
+	if options.Port == 0 {
+		options.Port = DEFAULT_PORT
+	}
+	if options.Port < 1024 || options.Port > 65535 {
+		return nil, fmt.Errorf("port %d is not allowed, use a port between 1024 and 65535: OpenShift runs the containers as non-root users, which can't bind privileged ports", options.Port)
+	}
+	buf := &bytes.Buffer{}
+	if err := template.Must(template.New(string(runtime)).Parse(text)).Execute(buf, options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package scaffold
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestGeneratedContainerfilesFollowTheRules(t *testing.T) {
+	for _, runtime := range Runtimes() {
+		content, err := Generate(runtime, Options{})
+		if err != nil {
+			t.Fatalf("Unable to generate the %s Containerfile: %s", runtime, err)
+		}
+		ctx := analyzer.WithoutBaseImageAnalysis(context.Background())
+		if results := analyzer.AnalyzeReader(ctx, string(runtime), bytes.NewReader(content)); len(results) != 0 {
+			t.Errorf("Expected no suggestions for the %s Containerfile but they were %v", runtime, results)
+		}
+	}
+}
+
+func TestGenerateWithPort(t *testing.T) {
+	content, err := Generate(RuntimePython, Options{Port: 5000})
+	if err != nil || !strings.Contains(string(content), "EXPOSE 5000") {
+		t.Errorf("Expected the port 5000 to be exposed but it was %s, error %v", content, err)
+	}
+	if _, err := Generate(RuntimeGo, Options{Port: 80}); err == nil {
+		t.Errorf("Expected an error for a privileged port")
+	}
+}
+
+func TestParseRuntime(t *testing.T) {
+	if runtime, err := ParseRuntime("Java"); err != nil || runtime != RuntimeJava {
+		t.Errorf("Expected the java runtime but it was %s, error %v", runtime, err)
+	}
+	if _, err := ParseRuntime("cobol"); err == nil {
+		t.Errorf("Expected an error for an unknown runtime")
+	}
+}
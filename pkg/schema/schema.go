@@ -0,0 +1,71 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package schema holds the JSON schemas of the JSON output of doa, so that the integrations can
+// rely on its stability, and validates the reports against them.
+ package schema
+
+import (
+	"embed"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	// RESULTS is the schema of the results, doa analyze -o json
+	RESULTS = "results"
+	// SUMMARY is the schema of the summary, doa analyze --summary-only -o json
+	SUMMARY = "summary"
+)
+
+//go:embed *.schema.json
+var schemas embed.FS
+
+// Names lists the schemas.
+var Names = []string{RESULTS, SUMMARY}
+
+// Get returns the schema.
+func Get(name string) ([]byte, error) {
+	bytes, err := schemas.ReadFile(name + ".schema.json")
+	if err != nil {
+		return nil, errors.Errorf("unknown schema %s, expected one of %s", name, strings.Join(Names, ", "))
+	}
+	return bytes, nil
+}
+
+// Validate validates the JSON document against the schema, the error listing the violations.
+func Validate(name string, document []byte) error {
+	bytes, err := Get(name)
+	if err != nil {
+		return err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(bytes), gojsonschema.NewBytesLoader(document))
+	if err != nil {
+		return errors.Wrapf(err, "unable to validate the %s", name)
+	}
+	if result.Valid() {
+		return nil
+	}
+	violations := []string{}
+	for _, violation := range result.Errors() {
+		violations = append(violations, violation.String())
+	}
+	return errors.Errorf("the %s output doesn't match its schema: %s", name, strings.Join(violations, "; "))
+}
@@ -0,0 +1,56 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package schema
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestAnalyzerOutputMatchesSchemas(t *testing.T) {
+	ctx := analyzer.WithPassedResults(analyzer.WithoutBaseImageAnalysis(context.Background()))
+	results := analyzer.AnalyzeReader(ctx, "Containerfile", strings.NewReader("FROM debian:12\nUSER root\nEXPOSE 80\nRUN chmod 777 /app\nUSER 1001\n"))
+	blamed := analyzer.RuleUserRoot.Failed("USER root").InFile("build/run.sh", 3)
+	blamed.Blame = &analyzer.Blame{Commit: "0a1b2c", Author: "dev", Email: "dev@example.com", Date: time.Now()}
+	blamed.Manifest = &analyzer.ManifestLocation{Path: "buildconfig.yaml", Resource: "BuildConfig/web", Line: 12}
+	results = append(results, blamed)
+
+	document, _ := json.Marshal(results)
+	if err := Validate(RESULTS, document); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	document, _ = json.Marshal(analyzer.Summarize(results))
+	if err := Validate(SUMMARY, document); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+}
+
+func TestValidateReportsViolations(t *testing.T) {
+	err := Validate(RESULTS, []byte(`[{"name": "User set to root", "status": "failed", "severity": "blocker", "description": "USER root", "extra": 1}]`))
+	if err == nil || !strings.Contains(err.Error(), "severity") || !strings.Contains(err.Error(), "extra") {
+		t.Errorf("Expected the violations to be reported but the error was %v", err)
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Errorf("Expected an error for an unknown schema")
+	}
+}
@@ -0,0 +1,130 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+)
+
+// ADMISSION_PATH is the path of the validating admission webhook
+const ADMISSION_PATH = "/admission"
+
+// VerifyFunc checks that the image has a trusted analysis attestation scoring at least minScore,
+// e.g. attestation.VerifyImage with the keys of the trust file
+type VerifyFunc func(ctx context.Context, image string, minScore int) error
+
+// admissionReview is the admission.k8s.io/v1 AdmissionReview sent by the API server, the response
+// being returned in the same review.
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       string          `json:"uid"`
+	Namespace string          `json:"namespace"`
+	Object    json.RawMessage `json:"object"`
+}
+
+type admissionResponse struct {
+	UID      string           `json:"uid"`
+	Allowed  bool             `json:"allowed"`
+	Status   *admissionStatus `json:"status,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+type admissionStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// admission reviews the workloads created in the cluster: the images without a trusted analysis
+// attestation scoring at least the min-score of the policy are rejected in the enforced
+// namespaces and admitted with a warning in the warned ones.
+func (s *Server) admission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authenticated(r) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MAX_BODY_SIZE))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	review.Response = s.review(r.Context(), review.Request)
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func (s *Server) review(ctx context.Context, request *admissionRequest) *admissionResponse {
+	response := &admissionResponse{UID: request.UID, Allowed: true}
+	policy := s.options.Admission
+	if policy == nil {
+		policy = &config.Admission{}
+	}
+	mode := policy.ModeOf(request.Namespace)
+	if mode == config.ADMISSION_IGNORE {
+		return response
+	}
+	images, err := manifests.WorkloadImages(request.Object)
+	if err != nil {
+		response.Allowed = false
+		response.Status = &admissionStatus{Code: http.StatusBadRequest, Message: err.Error()}
+		return response
+	}
+	var failures []string
+	for _, image := range images {
+		if err := s.options.Verify(ctx, image, policy.MinScore); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", image, err))
+		}
+	}
+	if len(failures) == 0 {
+		return response
+	}
+	message := "the images are not verified by doa: " + strings.Join(failures, "; ")
+	s.options.Logger.Printf("%s in namespace %s", message, request.Namespace)
+	if mode == config.ADMISSION_WARN {
+		response.Warnings = failures
+		return response
+	}
+	response.Allowed = false
+	response.Status = &admissionStatus{Code: http.StatusForbidden, Message: message}
+	return response
+}
@@ -0,0 +1,129 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+)
+
+// API_PREFIX is the prefix of the read endpoints of the results API
+const API_PREFIX = "/api/v1/"
+
+// MAX_RUNS is the default number of runs returned by the runs endpoint
+const MAX_RUNS = 100
+
+// api serves the runs recorded in the store, for dashboards like Grafana:
+//
+//	GET /api/v1/runs                 the runs, the most recent first
+//	GET /api/v1/runs/<id>/findings   the failed findings of a run
+//	GET /api/v1/rules                the number of findings of each rule
+//	GET /api/v1/trend                the average score and findings per day, or per week with period=week
+//	GET /api/v1/projects             the score of each project, from the last run of its targets
+//
+// The runs are filtered by the target, project, rule and since query parameters, see
+// store.Query, and the runs endpoint by limit.
+type api struct {
+	store *store.Store
+}
+
+func (a api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query, err := parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, API_PREFIX), "/"), "/")
+	var response interface{}
+	switch {
+	case len(path) == 1 && path[0] == "runs":
+		if query.Limit == 0 {
+			query.Limit = MAX_RUNS
+		}
+		response, err = a.store.Runs(query)
+	case len(path) == 3 && path[0] == "runs" && path[2] == "findings":
+		id, parseErr := strconv.ParseInt(path[1], 10, 64)
+		if parseErr != nil {
+			http.Error(w, "invalid run id "+path[1], http.StatusBadRequest)
+			return
+		}
+		findings, findingsErr := a.store.Findings(id)
+		if findingsErr == nil && findings == nil {
+			http.Error(w, "run "+path[1]+" not found", http.StatusNotFound)
+			return
+		}
+		response, err = findings, findingsErr
+	case len(path) == 1 && path[0] == "rules":
+		response, err = a.store.Rules(query)
+	case len(path) == 1 && path[0] == "trend":
+		period := store.Period(r.URL.Query().Get("period"))
+		if period == "" {
+			period = store.PeriodDay
+		}
+		if period != store.PeriodDay && period != store.PeriodWeek {
+			http.Error(w, "unknown period "+string(period)+", expected day or week", http.StatusBadRequest)
+			return
+		}
+		response, err = a.store.Trend(query, period)
+	case len(path) == 1 && path[0] == "projects":
+		response, err = a.store.Projects(query)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func parseQuery(r *http.Request) (store.Query, error) {
+	values := r.URL.Query()
+	query := store.Query{
+		Target:  values.Get("target"),
+		Project: values.Get("project"),
+		RuleID:  values.Get("rule"),
+	}
+	if since := values.Get("since"); since != "" {
+		var err error
+		if query.Since, err = store.ParseSince(since, time.Now()); err != nil {
+			return query, err
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 0 {
+			return query, errors.Errorf("invalid limit %s", limit)
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,230 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package server runs doa as a service: the push webhooks of the registries trigger the analysis
+// of the pushed images, whose results are sent to the configured sinks, so that the images are
+// continuously checked without changing the CI pipelines.
+ package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/workspace"
+)
+
+// QUEUE_SIZE is the number of images waiting for their analysis, the webhooks are rejected with
+// 503 Service Unavailable beyond
+const QUEUE_SIZE = 100
+
+// MAX_BODY_SIZE is the size limit of the webhook payloads
+const MAX_BODY_SIZE = 1 << 20
+
+// AnalyzeFunc analyzes an image, e.g. analyzer.AnalyzeImage with the configuration applied
+type AnalyzeFunc func(ctx context.Context, image string) []analyzer.Result
+
+type Options struct {
+	// Token authenticates the webhooks, given as the token query parameter, the registries
+	// not signing their payloads, or as a bearer token. The webhooks are not authenticated
+	// when it's empty.
+	Token string
+	// FailOn is the least severe failed finding failing the verdict, low when empty
+	FailOn analyzer.ResultSeverity
+	// Logger logs the analyses and the errors of the sinks, to the standard error by default
+	Logger *log.Logger
+	// Store records the analyses, which are served by the results API, see api
+	Store *store.Store
+	// Verify enables the admission webhook, see ADMISSION_PATH, verifying the analysis
+	// attestations of the images with the Admission policy
+	Verify VerifyFunc
+	// Admission is the policy of the admission webhook, all the namespaces are enforced without
+	// min-score when nil
+	Admission *config.Admission
+}
+
+type Server struct {
+	analyze AnalyzeFunc
+	sinks   []Sink
+	options Options
+	queue   chan string
+
+	lock sync.Mutex
+	// pending are the queued images, pushed again before their analysis they are only
+	// analyzed once
+	pending map[string]bool
+}
+
+// New returns a server analyzing the pushed images with analyze and sending the analyses to the
+// sinks.
+func New(analyze AnalyzeFunc, sinks []Sink, options Options) *Server {
+	if options.FailOn == "" {
+		options.FailOn = analyzer.SeverityLow
+	}
+	if options.Logger == nil {
+		options.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &Server{
+		analyze: analyze,
+		sinks:   sinks,
+		options: options,
+		queue:   make(chan string, QUEUE_SIZE),
+		pending: map[string]bool{},
+	}
+}
+
+// Handler serves the webhooks, /webhooks/quay, /webhooks/dockerhub and /webhooks/oci, the
+// /healthz probe, with a store, the results API under /api/v1/ and, with a verifier, the
+// admission webhook.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	for source, parse := range parsers {
+		mux.HandleFunc("/webhooks/"+source, s.webhook(parse))
+	}
+	if s.options.Store != nil {
+		mux.Handle(API_PREFIX, s.authenticate(api{store: s.options.Store}))
+	}
+	if s.options.Verify != nil {
+		mux.HandleFunc(ADMISSION_PATH, s.admission)
+	}
+	return mux
+}
+
+func (s *Server) webhook(parse func([]byte) ([]string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authenticated(r) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, MAX_BODY_SIZE))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		images, err := parse(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		queued := []string{}
+		for _, image := range images {
+			if !s.enqueue(image) {
+				http.Error(w, "too many images waiting for their analysis", http.StatusServiceUnavailable)
+				return
+			}
+			queued = append(queued, image)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string][]string{"queued": queued})
+	}
+}
+
+// authenticate rejects the requests without the token.
+func (s *Server) authenticate(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticated(r) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authenticated(r *http.Request) bool {
+	if s.options.Token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); bearer != r.Header.Get("Authorization") {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.options.Token)) == 1
+}
+
+// enqueue queues the image unless it's already waiting, it reports false when the queue is full.
+func (s *Server) enqueue(image string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pending[image] {
+		return true
+	}
+	select {
+	case s.queue <- image:
+		s.pending[image] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Run analyzes the queued images one after the other, until the context is done.
+func (s *Server) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case image := <-s.queue:
+			s.lock.Lock()
+			delete(s.pending, image)
+			s.lock.Unlock()
+			s.process(ctx, image)
+		}
+	}
+}
+
+func (s *Server) process(ctx context.Context, image string) {
+	results := s.analyze(ctx, image)
+	analysis := Analysis{
+		Image:   image,
+		Date:    time.Now().UTC(),
+		Score:   workspace.Score(results),
+		Summary: analyzer.SummarizeFailingOn(results, s.options.FailOn),
+		Results: results,
+	}
+	s.options.Logger.Printf("%s analyzed: %s, score %d", image, analysis.Summary.Verdict, analysis.Score)
+	if s.options.Store != nil {
+		run := &store.Run{Date: analysis.Date, Target: image, Score: analysis.Score, Summary: analysis.Summary}
+		if err := s.options.Store.Record(run, results); err != nil {
+			s.options.Logger.Printf("unable to record the analysis of %s: %s", image, err)
+		}
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Send(analysis); err != nil {
+			s.options.Logger.Printf("unable to send the analysis of %s to %s: %s", image, sink, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/store"
+)
+
+type recordingSink struct {
+	analyses chan Analysis
+}
+
+func (s *recordingSink) Send(analysis Analysis) error {
+	s.analyses <- analysis
+	return nil
+}
+
+func (s *recordingSink) String() string {
+	return "recording"
+}
+
+func failedUserRoot(ctx context.Context, image string) []analyzer.Result {
+	return []analyzer.Result{analyzer.RuleUserRoot.Failed("USER root")}
+}
+
+func TestParsePushEvents(t *testing.T) {
+	for name, test := range map[string]struct {
+		parse    func([]byte) ([]string, error)
+		body     string
+		expected []string
+	}{
+		"quay": {ParseQuayPush, `{"repository": "team/web", "docker_url": "quay.io/team/web", "updated_tags": ["latest", "1.2"]}`,
+			[]string{"quay.io/team/web:latest", "quay.io/team/web:1.2"}},
+		"docker hub": {ParseDockerHubPush, `{"push_data": {"tag": "v1"}, "repository": {"repo_name": "team/web"}}`,
+			[]string{"docker.io/team/web:v1"}},
+		"distribution": {ParseOCIPush, `{"events": [
+			{"action": "push", "target": {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "repository": "team/web", "digest": "sha256:aa"}, "request": {"host": "registry:5000"}},
+			{"action": "push", "target": {"mediaType": "application/vnd.oci.image.manifest.v1+json", "repository": "team/web", "tag": "v2", "digest": "sha256:bb"}, "request": {"host": "registry:5000"}},
+			{"action": "pull", "target": {"mediaType": "application/vnd.oci.image.manifest.v1+json", "repository": "team/web", "tag": "v1"}}]}`,
+			[]string{"registry:5000/team/web:v2"}},
+		"plain": {ParseOCIPush, `{"image": "ghcr.io/team/web:v3"}`, []string{"ghcr.io/team/web:v3"}},
+	} {
+		images, err := test.parse([]byte(test.body))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", name, err)
+		}
+		if !reflect.DeepEqual(images, test.expected) {
+			t.Errorf("%s: expected %v but they were %v", name, test.expected, images)
+		}
+	}
+	if _, err := ParseQuayPush([]byte(`{"updated_tags": ["latest"]}`)); err == nil {
+		t.Errorf("Expected an error for a Quay event without docker_url")
+	}
+}
+
+func TestWebhookQueuesAnalysis(t *testing.T) {
+	sink := &recordingSink{analyses: make(chan Analysis, 1)}
+	s := New(failedUserRoot, []Sink{sink}, Options{Token: "s3cr3t", Logger: log.New(io.Discard, "", 0)})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+	body := `{"docker_url": "quay.io/team/web", "updated_tags": ["latest"]}`
+
+	resp, err := http.Post(server.URL+"/webhooks/quay", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token but it was %s", resp.Status)
+	}
+
+	resp, err = http.Post(server.URL+"/webhooks/quay?token=s3cr3t", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var queued map[string][]string
+	json.NewDecoder(resp.Body).Decode(&queued)
+	if resp.StatusCode != http.StatusAccepted || !reflect.DeepEqual(queued["queued"], []string{"quay.io/team/web:latest"}) {
+		t.Fatalf("Expected the image to be queued but the response was %s %v", resp.Status, queued)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	analysis := <-sink.analyses
+	if analysis.Image != "quay.io/team/web:latest" || analysis.Summary.Verdict != analyzer.VerdictFailed || analysis.Score != 96 {
+		t.Errorf("Unexpected analysis %v", analysis)
+	}
+}
+
+func TestAdmissionReview(t *testing.T) {
+	verify := func(ctx context.Context, image string, minScore int) error {
+		if image == "quay.io/shop/web:1.2" && minScore == 80 {
+			return nil
+		}
+		return fmt.Errorf("no attestation found")
+	}
+	policy := &config.Admission{MinScore: 80, Namespaces: map[string]string{"sandbox": config.ADMISSION_WARN, "kube-system": config.ADMISSION_IGNORE}}
+	s := New(failedUserRoot, nil, Options{Verify: verify, Admission: policy, Logger: log.New(io.Discard, "", 0)})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+	review := func(namespace string, images ...string) admissionResponse {
+		containers := []string{}
+		for _, image := range images {
+			containers = append(containers, fmt.Sprintf(`{"name": "c", "image": %q}`, image))
+		}
+		body := fmt.Sprintf(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview", "request": {"uid": "42", "namespace": %q,
+			"object": {"kind": "Pod", "metadata": {"name": "web"}, "spec": {"containers": [%s]}}}}`, namespace, strings.Join(containers, ","))
+		resp, err := http.Post(server.URL+ADMISSION_PATH, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		reviewed := admissionReview{}
+		json.NewDecoder(resp.Body).Decode(&reviewed)
+		if resp.StatusCode != http.StatusOK || reviewed.Kind != "AdmissionReview" || reviewed.Response == nil || reviewed.Response.UID != "42" {
+			t.Fatalf("Unexpected review %s %v", resp.Status, reviewed)
+		}
+		return *reviewed.Response
+	}
+
+	if response := review("shop", "quay.io/shop/web:1.2"); !response.Allowed {
+		t.Errorf("Expected the verified image to be allowed but got %v", response)
+	}
+	if response := review("shop", "quay.io/shop/web:1.2", "docker.io/library/nginx"); response.Allowed || !strings.Contains(response.Status.Message, "docker.io/library/nginx: no attestation found") {
+		t.Errorf("Expected the unverified image to be rejected but got %v", response)
+	}
+	if response := review("sandbox", "docker.io/library/nginx"); !response.Allowed || len(response.Warnings) != 1 {
+		t.Errorf("Expected the unverified image to be allowed with a warning but got %v", response)
+	}
+	if response := review("kube-system", "docker.io/library/nginx"); !response.Allowed || len(response.Warnings) != 0 {
+		t.Errorf("Expected the ignored namespace to be allowed but got %v", response)
+	}
+}
+
+func TestPendingImageIsQueuedOnce(t *testing.T) {
+	s := New(failedUserRoot, nil, Options{})
+	s.enqueue("quay.io/team/web:latest")
+	s.enqueue("quay.io/team/web:latest")
+	if len(s.queue) != 1 {
+		t.Errorf("Expected 1 queued image but they were %d", len(s.queue))
+	}
+}
+
+func TestParseSink(t *testing.T) {
+	for _, value := range []string{"file:analyses.jsonl", "slack:https://hooks.slack.com/services/T/B/X", "teams:https://example.webhook.office.com/webhookb2/x", "annotation", "annotation:images"} {
+		if _, err := ParseSink(value, analyzer.SeverityLow); err != nil {
+			t.Errorf("Unexpected error %s", err)
+		}
+	}
+	for _, value := range []string{"file", "slack:http://hooks.example.com", "email:dev@example.com"} {
+		if _, err := ParseSink(value, analyzer.SeverityLow); err == nil {
+			t.Errorf("Expected an error for %s", value)
+		}
+	}
+}
+
+func TestFileSinkAppendsAnalyses(t *testing.T) {
+	sink := &FileSink{Path: filepath.Join(t.TempDir(), "analyses.jsonl")}
+	for _, image := range []string{"quay.io/team/web:1", "quay.io/team/web:2"} {
+		if err := sink.Send(Analysis{Image: image}); err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+	}
+	content, _ := os.ReadFile(sink.Path)
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "web:2") {
+		t.Errorf("Unexpected content %s", content)
+	}
+}
+
+func TestNotificationSinkThresholds(t *testing.T) {
+	posted := 0
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted++
+	}))
+	defer slack.Close()
+	results := failedUserRoot(context.Background(), "")
+	analysis := Analysis{Image: "quay.io/team/web:latest", Score: 96, Results: results}
+	sink := &NotificationSink{Notification: config.Notification{Type: config.NOTIFICATION_SLACK, URL: slack.URL, Severity: analyzer.SeverityHigh}, Thresholds: true}
+	if err := sink.Send(analysis); err != nil || posted != 0 {
+		t.Errorf("Expected no notification below the threshold, posted %d, error %v", posted, err)
+	}
+	sink.Thresholds = false
+	if err := sink.Send(analysis); err != nil || posted != 1 {
+		t.Errorf("Expected a notification, posted %d, error %v", posted, err)
+	}
+}
+
+func TestImageStreamTag(t *testing.T) {
+	if tag, err := imageStreamTag("registry:5000/team/web:v2"); err != nil || tag != "web:v2" {
+		t.Errorf("Unexpected ImageStreamTag %s, error %v", tag, err)
+	}
+	for _, image := range []string{"registry:5000/team/web", "quay.io/team/web@sha256:aa"} {
+		if _, err := imageStreamTag(image); err == nil {
+			t.Errorf("Expected an error for %s", image)
+		}
+	}
+}
+
+func TestResultsAPI(t *testing.T) {
+	results, err := store.Open(filepath.Join(t.TempDir(), "doa.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+	s := New(failedUserRoot, nil, Options{Token: "s3cr3t", Store: results, Logger: log.New(io.Discard, "", 0)})
+	s.process(context.Background(), "quay.io/team/web:latest")
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	get := func(path string, expected int, response interface{}) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("%s: expected %d but it was %s", path, expected, resp.Status)
+		}
+		if response != nil {
+			json.NewDecoder(resp.Body).Decode(response)
+		}
+	}
+	var runs []store.Run
+	get("/api/v1/runs?target=quay.io/team/web:latest", http.StatusOK, &runs)
+	if len(runs) != 1 || runs[0].Score != 96 {
+		t.Fatalf("Unexpected runs %v", runs)
+	}
+	var findings []store.Finding
+	get(fmt.Sprintf("/api/v1/runs/%d/findings", runs[0].ID), http.StatusOK, &findings)
+	if len(findings) != 1 || findings[0].RuleID != analyzer.RuleUserRoot.ID {
+		t.Errorf("Unexpected findings %v", findings)
+	}
+	var points []store.Point
+	get("/api/v1/trend?period=week", http.StatusOK, &points)
+	if len(points) != 1 || points[0].Runs != 1 {
+		t.Errorf("Unexpected trend %v", points)
+	}
+	get("/api/v1/runs/42/findings", http.StatusNotFound, nil)
+	get("/api/v1/trend?period=month", http.StatusBadRequest, nil)
+	get("/api/v1/runs?limit=x", http.StatusBadRequest, nil)
+	get("/api/v1/unknown", http.StatusNotFound, nil)
+
+	resp, err := http.Get(server.URL + "/api/v1/runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token but it was %s", resp.Status)
+	}
+}
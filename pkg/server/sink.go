@@ -0,0 +1,162 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package server
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/manifests"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/notify"
+)
+
+// Analysis is the analysis of a pushed image, sent to the sinks.
+type Analysis struct {
+	Image   string            `json:"image"`
+	Date    time.Time         `json:"date"`
+	Score   int               `json:"score"`
+	Summary analyzer.Summary  `json:"summary"`
+	Results []analyzer.Result `json:"results"`
+}
+
+// Sink receives the analyses of the pushed images.
+type Sink interface {
+	Send(analysis Analysis) error
+	String() string
+}
+
+// ParseSink parses a sink given as kind:argument:
+//   - file:<path> appends the analyses to a JSON lines file
+//   - slack:<incoming webhook URL> posts a summary to a Slack channel
+//   - teams:<incoming webhook URL> posts a summary to a Microsoft Teams channel
+//   - annotation[:<namespace>] annotates the ImageStreamTag of the image, see doa publish
+//
+// failOn is the least severe failed finding failing the verdict of the annotations.
+func ParseSink(value string, failOn analyzer.ResultSeverity) (Sink, error) {
+	kind, argument, _ := strings.Cut(value, ":")
+	switch strings.ToLower(kind) {
+	case "file":
+		if argument == "" {
+			return nil, errors.Errorf("invalid sink %q, expected file:<path>", value)
+		}
+		return &FileSink{Path: argument}, nil
+	case config.NOTIFICATION_SLACK, config.NOTIFICATION_TEAMS:
+		if !strings.HasPrefix(argument, "https://") {
+			return nil, errors.Errorf("invalid sink %q, expected %s:<https incoming webhook URL>", value, kind)
+		}
+		return &NotificationSink{Notification: config.Notification{Type: strings.ToLower(kind), URL: argument}, FailOn: failOn}, nil
+	case "annotation":
+		return &AnnotationSink{Namespace: argument, FailOn: failOn}, nil
+	}
+	return nil, errors.Errorf("unknown sink %q, expected file:<path>, slack:<URL>, teams:<URL> or annotation[:<namespace>]", value)
+}
+
+// FileSink appends the analyses to a JSON lines file.
+type FileSink struct {
+	Path string
+	lock sync.Mutex
+}
+
+func (s *FileSink) Send(analysis Analysis) error {
+	line, err := json.Marshal(analysis)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", s.Path)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *FileSink) String() string {
+	return "file:" + s.Path
+}
+
+// NotificationSink posts a findings summary to a Slack or Microsoft Teams channel, see
+// pkg/notify. The sinks given on the command line post every analysis, the notifications of the
+// configuration file only the analyses exceeding their threshold.
+type NotificationSink struct {
+	Notification config.Notification
+	FailOn       analyzer.ResultSeverity
+	// Thresholds only posts the analyses exceeding the threshold of the notification
+	Thresholds bool
+}
+
+func (s *NotificationSink) Send(analysis Analysis) error {
+	message := notify.NewMessage(analysis.Image, analysis.Results, analysis.Score, s.FailOn)
+	if s.Thresholds && !notify.Exceeded(s.Notification, message) {
+		return nil
+	}
+	return notify.Post(s.Notification, message)
+}
+
+func (s *NotificationSink) String() string {
+	// the URL of the webhook is a secret
+	return s.Notification.Type
+}
+
+// AnnotationSink annotates the ImageStreamTag named after the repository and the tag of the
+// image, e.g. web:latest for quay.io/team/web:latest, see manifests.Publish.
+type AnnotationSink struct {
+	Namespace string
+	FailOn    analyzer.ResultSeverity
+}
+
+func (s *AnnotationSink) Send(analysis Analysis) error {
+	tag, err := imageStreamTag(analysis.Image)
+	if err != nil {
+		return err
+	}
+	annotations, err := manifests.Annotations(analysis.Results, analysis.Score, manifests.ReportOptions{
+		Image:  analysis.Image,
+		FailOn: s.FailOn,
+	})
+	if err != nil {
+		return err
+	}
+	return manifests.Publish(manifests.Target{Kind: "imagestreamtag", Name: tag, Namespace: s.Namespace}, annotations)
+}
+
+func (s *AnnotationSink) String() string {
+	if s.Namespace == "" {
+		return "annotation"
+	}
+	return "annotation:" + s.Namespace
+}
+
+// imageStreamTag returns the name of the ImageStreamTag of the image, the last segment of its
+// repository and its tag.
+func imageStreamTag(image string) (string, error) {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if strings.Contains(name, "@") || !strings.Contains(name, ":") {
+		return "", errors.Errorf("%s has no tag, unable to annotate its ImageStreamTag", image)
+	}
+	return name, nil
+}
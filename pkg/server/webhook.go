@@ -0,0 +1,124 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+ package server
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The registries sending push events, each one having its own payload
+const (
+	SOURCE_QUAY       = "quay"
+	SOURCE_DOCKER_HUB = "dockerhub"
+	// SOURCE_OCI is the notification envelope of the distribution registry, also sent by Harbor
+	// and most of the OCI registries, or a plain {"image": "<reference>"} body
+	SOURCE_OCI = "oci"
+)
+
+var parsers = map[string]func([]byte) ([]string, error){
+	SOURCE_QUAY:       ParseQuayPush,
+	SOURCE_DOCKER_HUB: ParseDockerHubPush,
+	SOURCE_OCI:        ParseOCIPush,
+}
+
+// ParseQuayPush returns the images of the tags updated by a Quay repository push notification.
+func ParseQuayPush(body []byte) ([]string, error) {
+	var event struct {
+		DockerURL   string   `json:"docker_url"`
+		UpdatedTags []string `json:"updated_tags"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, errors.Wrap(err, "invalid Quay push event")
+	}
+	if event.DockerURL == "" {
+		return nil, errors.New("invalid Quay push event: no docker_url")
+	}
+	var images []string
+	for _, tag := range event.UpdatedTags {
+		images = append(images, event.DockerURL+":"+tag)
+	}
+	return images, nil
+}
+
+// ParseDockerHubPush returns the image of a Docker Hub push webhook.
+func ParseDockerHubPush(body []byte) ([]string, error) {
+	var event struct {
+		PushData struct {
+			Tag string `json:"tag"`
+		} `json:"push_data"`
+		Repository struct {
+			RepoName string `json:"repo_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, errors.Wrap(err, "invalid Docker Hub push event")
+	}
+	if event.Repository.RepoName == "" || event.PushData.Tag == "" {
+		return nil, errors.New("invalid Docker Hub push event: no repository or tag")
+	}
+	return []string{"docker.io/" + event.Repository.RepoName + ":" + event.PushData.Tag}, nil
+}
+
+// ParseOCIPush returns the images of the manifests pushed according to a notification envelope
+// of the distribution registry, the pushes of blobs being ignored, or the image of a plain
+// {"image": "<reference>"} body.
+func ParseOCIPush(body []byte) ([]string, error) {
+	var envelope struct {
+		Image  string `json:"image"`
+		Events []struct {
+			Action string `json:"action"`
+			Target struct {
+				MediaType  string `json:"mediaType"`
+				Repository string `json:"repository"`
+				Tag        string `json:"tag"`
+				Digest     string `json:"digest"`
+			} `json:"target"`
+			Request struct {
+				Host string `json:"host"`
+			} `json:"request"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, errors.Wrap(err, "invalid push event")
+	}
+	if envelope.Image != "" {
+		return []string{envelope.Image}, nil
+	}
+	var images []string
+	for _, event := range envelope.Events {
+		target := event.Target
+		if event.Action != "push" || target.Repository == "" || !strings.Contains(target.MediaType, "manifest") && !strings.Contains(target.MediaType, "index") {
+			continue
+		}
+		image := target.Repository
+		if event.Request.Host != "" {
+			image = event.Request.Host + "/" + image
+		}
+		switch {
+		case target.Tag != "":
+			images = append(images, image+":"+target.Tag)
+		case target.Digest != "":
+			images = append(images, image+"@"+target.Digest)
+		}
+	}
+	return images, nil
+}
@@ -0,0 +1,506 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package signature verifies the cosign signatures of the policies pulled by doa and of its
+// releases, so that the rules run in regulated environments can't be tampered with. What is
+// trusted is configured by the administrators in ~/.config/doa/trust.yaml (see DefaultPath), e.g.
+//
+//	keys:
+//	  - /etc/doa/cosign.pub
+//	identities:
+//	  - subject: https://github.com/org/policies/.github/workflows/release.yml@refs/heads/main
+//	    issuer: https://token.actions.githubusercontent.com
+//	fulcio-roots: /etc/doa/fulcio.pem
+//	rekor-keys: /etc/doa/rekor.pub
+//
+// Signatures made with a key are verified against the keys. Keyless signatures are verified
+// against the identities: the certificate must chain to the Fulcio roots at the time recorded by
+// the Rekor transparency log, whose entry must be signed by one of the Rekor keys.
+ package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/go-openapi/runtime"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	fulcio "github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	sigstoresig "github.com/sigstore/sigstore/pkg/signature"
+	"gopkg.in/yaml.v3"
+)
+
+const DEFAULT_FILE = "trust.yaml"
+
+// Annotations of the layers of the signature images pushed by cosign
+const (
+	SIGNATURE_ANNOTATION   = "dev.cosignproject.cosign/signature"
+	CERTIFICATE_ANNOTATION = "dev.sigstore.cosign/certificate"
+	CHAIN_ANNOTATION       = "dev.sigstore.cosign/chain"
+	BUNDLE_ANNOTATION      = "dev.sigstore.cosign/bundle"
+)
+
+// issuerV2OID is the Fulcio extension holding the OIDC issuer of the identity as a DER encoded
+// UTF8String, the raw string extension is parsed by the fulcio package
+var issuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+
+// Kind and version of the transparency log entries of the cosign signatures
+const (
+	HASHEDREKORD_KIND    = "hashedrekord"
+	HASHEDREKORD_VERSION = "0.0.1"
+)
+
+// Identity is the signer of a keyless signature.
+type Identity struct {
+	// Subject is the email or the URI of the certificate, e.g. the workflow publishing a release
+	Subject string `yaml:"subject"`
+	// Issuer is the OIDC provider which authenticated the subject
+	Issuer string `yaml:"issuer"`
+}
+
+// Trust is the content of the trust.yaml file. Keys and certificates are PEM encoded, either inline
+// or in files whose paths are relative to the trust.yaml file.
+type Trust struct {
+	Keys        []string   `yaml:"keys,omitempty"`
+	Identities  []Identity `yaml:"identities,omitempty"`
+	FulcioRoots string     `yaml:"fulcio-roots,omitempty"`
+	RekorKeys   string     `yaml:"rekor-keys,omitempty"`
+}
+
+// Verifier checks signatures against the keys and identities of a trust.yaml file.
+type Verifier struct {
+	keys          []crypto.PublicKey
+	identities    []Identity
+	roots         *x509.CertPool
+	intermediates []*x509.Certificate
+	rekorKeys     []crypto.PublicKey
+}
+
+// Signature is a cosign signature, made with a key or keyless, i.e. with a certificate.
+type Signature struct {
+	Content []byte
+	// Certificate and Chain are PEM encoded, they are empty for the signatures made with a key
+	Certificate []byte
+	Chain       []byte
+	// Bundle is the entry of the signature in the transparency log
+	Bundle *Bundle
+}
+
+// Bundle is the proof that a signature was recorded in the Rekor transparency log.
+type Bundle struct {
+	SignedEntryTimestamp []byte        `json:"SignedEntryTimestamp"`
+	Payload              BundlePayload `json:"Payload"`
+}
+
+// BundlePayload is the transparency log entry, signed by Rekor in its canonical JSON form.
+type BundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// blobBundle is the file written by cosign sign-blob --bundle
+type blobBundle struct {
+	Signature   string  `json:"base64Signature"`
+	Certificate string  `json:"cert,omitempty"`
+	Bundle      *Bundle `json:"rekorBundle,omitempty"`
+}
+
+// simpleSigning is the payload signed by cosign for images
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			Digest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// DefaultPath returns the path of the trust.yaml file, DOA_TRUST_FILE or
+// ~/.config/doa/trust.yaml by default.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("DOA_TRUST_FILE"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "doa", DEFAULT_FILE), nil
+}
+
+// Load reads the trust.yaml file at path. A missing file disables the verification, it returns
+// a nil Verifier.
+func Load(path string) (*Verifier, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the trust file %s", path)
+	}
+	trust := Trust{}
+	if err := yaml.Unmarshal(content, &trust); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the trust file %s", path)
+	}
+	verifier, err := NewVerifier(trust, filepath.Dir(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid trust file %s", path)
+	}
+	return verifier, nil
+}
+
+// NewVerifier returns the verifier of the trust configuration, dir is the directory the paths
+// are relative to.
+func NewVerifier(trust Trust, dir string) (*Verifier, error) {
+	if len(trust.Keys) == 0 && len(trust.Identities) == 0 {
+		return nil, errors.New("no key and no identity is trusted")
+	}
+	verifier := &Verifier{identities: trust.Identities}
+	for _, key := range trust.Keys {
+		keys, err := publicKeys(key, dir)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys = append(verifier.keys, keys...)
+	}
+	if len(trust.Identities) == 0 {
+		return verifier, nil
+	}
+	for _, identity := range trust.Identities {
+		if identity.Subject == "" || identity.Issuer == "" {
+			return nil, errors.New("identities must have a subject and an issuer")
+		}
+	}
+	if trust.FulcioRoots == "" || trust.RekorKeys == "" {
+		return nil, errors.New("fulcio-roots and rekor-keys are required to verify identities")
+	}
+	certificates, err := certificates(trust.FulcioRoots, dir)
+	if err != nil {
+		return nil, err
+	}
+	verifier.roots = x509.NewCertPool()
+	for _, certificate := range certificates {
+		if bytes.Equal(certificate.RawIssuer, certificate.RawSubject) {
+			verifier.roots.AddCert(certificate)
+		} else {
+			verifier.intermediates = append(verifier.intermediates, certificate)
+		}
+	}
+	if verifier.rekorKeys, err = publicKeys(trust.RekorKeys, dir); err != nil {
+		return nil, err
+	}
+	return verifier, nil
+}
+
+// Verify checks that the payload was signed by a trusted key or identity.
+func (v *Verifier) Verify(payload []byte, signature Signature) error {
+	if len(signature.Certificate) == 0 {
+		for _, key := range v.keys {
+			if verifySignature(key, payload, signature.Content) == nil {
+				return nil
+			}
+		}
+		return errors.New("the signature doesn't match any trusted key")
+	}
+	if len(v.identities) == 0 {
+		return errors.New("keyless signatures are not trusted, no identity is configured")
+	}
+	certificates, err := cryptoutils.UnmarshalCertificatesFromPEM(append(append([]byte{}, signature.Certificate...), signature.Chain...))
+	if err != nil || len(certificates) == 0 {
+		return errors.New("invalid signing certificate")
+	}
+	if signature.Bundle == nil {
+		return errors.New("the signature was not recorded in the transparency log")
+	}
+	signedAt, err := v.verifyBundle(signature.Bundle, payload, signature.Content, certificates[0])
+	if err != nil {
+		return err
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range append(certificates[1:], v.intermediates...) {
+		intermediates.AddCert(certificate)
+	}
+	_, err = certificates[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		// the certificates are short-lived, they must be valid when the signature was logged
+		CurrentTime: signedAt,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return errors.Wrap(err, "untrusted signing certificate")
+	}
+	if err := v.verifyIdentity(certificates[0]); err != nil {
+		return err
+	}
+	return verifySignature(certificates[0].PublicKey, payload, signature.Content)
+}
+
+// VerifyBlob checks the bundle written by cosign sign-blob --bundle for the blob.
+func (v *Verifier) VerifyBlob(blob []byte, bundle []byte) error {
+	parsed := blobBundle{}
+	if err := json.Unmarshal(bundle, &parsed); err != nil {
+		return errors.Wrap(err, "invalid signature bundle")
+	}
+	signature := Signature{Bundle: parsed.Bundle}
+	var err error
+	if signature.Content, err = base64.StdEncoding.DecodeString(parsed.Signature); err != nil {
+		return errors.Wrap(err, "invalid signature bundle")
+	}
+	if signature.Certificate, err = base64.StdEncoding.DecodeString(parsed.Certificate); err != nil {
+		return errors.Wrap(err, "invalid signature bundle")
+	}
+	return v.Verify(blob, signature)
+}
+
+// VerifyImage checks the signatures pushed by cosign sign for the image, at least one of them
+// must be trusted.
+func (v *Verifier) VerifyImage(digest name.Digest, options ...remote.Option) error {
+	tag := digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + ".sig")
+	image, err := remote.Image(tag, options...)
+	if err != nil {
+		return errors.Wrapf(err, "no signature found for %s", digest)
+	}
+	manifest, err := image.Manifest()
+	if err != nil {
+		return errors.Wrapf(err, "no signature found for %s", digest)
+	}
+	var failures []string
+	for _, descriptor := range manifest.Layers {
+		layer, err := image.LayerByDigest(descriptor.Digest)
+		if err != nil {
+			return err
+		}
+		reader, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+		payload, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		if err = verifyImagePayload(payload, digest); err == nil {
+			err = v.Verify(payload, imageSignature(descriptor.Annotations))
+		}
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+	if len(failures) == 0 {
+		return errors.Errorf("no signature found for %s", digest)
+	}
+	return errors.Errorf("no trusted signature for %s: %s", digest, strings.Join(failures, ", "))
+}
+
+func imageSignature(annotations map[string]string) Signature {
+	signature := Signature{
+		Certificate: []byte(annotations[CERTIFICATE_ANNOTATION]),
+		Chain:       []byte(annotations[CHAIN_ANNOTATION]),
+	}
+	signature.Content, _ = base64.StdEncoding.DecodeString(annotations[SIGNATURE_ANNOTATION])
+	if bundle := annotations[BUNDLE_ANNOTATION]; bundle != "" {
+		signature.Bundle = &Bundle{}
+		if json.Unmarshal([]byte(bundle), signature.Bundle) != nil {
+			signature.Bundle = nil
+		}
+	}
+	return signature
+}
+
+// verifyImagePayload checks that the signed payload refers to the image, so that the signature
+// of another image can't be replayed.
+func verifyImagePayload(payload []byte, digest name.Digest) error {
+	parsed := simpleSigning{}
+	if err := json.Unmarshal(payload, &parsed); err != nil {
+		return errors.Wrap(err, "invalid signature payload")
+	}
+	if parsed.Critical.Image.Digest != digest.DigestStr() {
+		return errors.Errorf("the signature is for %s", parsed.Critical.Image.Digest)
+	}
+	return nil
+}
+
+// verifyBundle checks that the transparency log entry is signed by Rekor and records the
+// signature of the payload by the certificate, it returns the time the entry was logged.
+func (v *Verifier) verifyBundle(bundle *Bundle, payload []byte, signature []byte, signer *x509.Certificate) (time.Time, error) {
+	content, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// Rekor signs the RFC 8785 canonical form of the entry
+	canonical, err := jsoncanonicalizer.Transform(content)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid transparency log entry")
+	}
+	verified := false
+	for _, key := range v.rekorKeys {
+		// the log ID is the digest of the public key of the Rekor instance
+		der, err := cryptoutils.MarshalPublicKeyToDER(key)
+		if err != nil {
+			continue
+		}
+		if id := sha256.Sum256(der); hex.EncodeToString(id[:]) != bundle.Payload.LogID {
+			continue
+		}
+		if verifySignature(key, canonical, bundle.SignedEntryTimestamp) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return time.Time{}, errors.New("the transparency log entry is not signed by a trusted Rekor key")
+	}
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid transparency log entry")
+	}
+	proposed, err := models.UnmarshalProposedEntry(bytes.NewReader(body), runtime.JSONConsumer())
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid transparency log entry")
+	}
+	rekord, ok := proposed.(*models.Hashedrekord)
+	if !ok {
+		return time.Time{}, errors.Errorf("unsupported transparency log entry %s, expected %s", proposed.Kind(), HASHEDREKORD_KIND)
+	}
+	if rekord.APIVersion == nil || *rekord.APIVersion != HASHEDREKORD_VERSION {
+		return time.Time{}, errors.Errorf("unsupported %s transparency log entry version", HASHEDREKORD_KIND)
+	}
+	spec := models.HashedrekordV001Schema{}
+	if content, err := json.Marshal(rekord.Spec); err != nil || json.Unmarshal(content, &spec) != nil || spec.Data == nil || spec.Data.Hash == nil || spec.Signature == nil {
+		return time.Time{}, errors.New("invalid transparency log entry")
+	}
+	digest := sha256.Sum256(payload)
+	hash := spec.Data.Hash
+	if hash.Algorithm == nil || *hash.Algorithm != models.HashedrekordV001SchemaDataHashAlgorithmSha256 || hash.Value == nil ||
+		*hash.Value != hex.EncodeToString(digest[:]) || !bytes.Equal(spec.Signature.Content, signature) {
+		return time.Time{}, errors.New("the transparency log entry is for another signature")
+	}
+	if spec.Signature.PublicKey == nil {
+		return time.Time{}, errors.New("the transparency log entry has no signing certificate")
+	}
+	logged, err := cryptoutils.UnmarshalCertificatesFromPEM(spec.Signature.PublicKey.Content)
+	if err != nil || len(logged) == 0 || !logged[0].Equal(signer) {
+		return time.Time{}, errors.New("the transparency log entry is for another signing certificate")
+	}
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+func (v *Verifier) verifyIdentity(certificate *x509.Certificate) error {
+	subjects := cryptoutils.GetSubjectAlternateNames(certificate)
+	issuer := certificateIssuer(certificate)
+	for _, identity := range v.identities {
+		if identity.Issuer != issuer {
+			continue
+		}
+		for _, subject := range subjects {
+			if subject == identity.Subject {
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("the signer %s (issuer %s) is not a trusted identity", strings.Join(subjects, ", "), issuer)
+}
+
+func certificateIssuer(certificate *x509.Certificate) string {
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(issuerV2OID) {
+			var issuer string
+			if _, err := asn1.Unmarshal(extension.Value, &issuer); err == nil {
+				return issuer
+			}
+		}
+	}
+	extensions, _ := fulcio.ParseExtensions(certificate.Extensions)
+	return extensions.Issuer
+}
+
+func verifySignature(key crypto.PublicKey, payload []byte, signature []byte) error {
+	verifier, err := sigstoresig.LoadVerifier(key, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySignature(bytes.NewReader(signature), bytes.NewReader(payload))
+}
+
+// readPEM returns the PEM content, inline or read from the file
+func readPEM(value string, dir string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	if !filepath.IsAbs(value) {
+		value = filepath.Join(dir, value)
+	}
+	content, err := os.ReadFile(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read %s", value)
+	}
+	return content, nil
+}
+
+func publicKeys(value string, dir string) ([]crypto.PublicKey, error) {
+	content, err := readPEM(value, dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []crypto.PublicKey
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid public key")
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.Errorf("no public key found in %s", value)
+	}
+	return keys, nil
+}
+
+func certificates(value string, dir string) ([]*x509.Certificate, error) {
+	content, err := readPEM(value, dir)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid certificate")
+	}
+	if len(certificates) == 0 {
+		return nil, errors.Errorf("no certificate found in %s", value)
+	}
+	return certificates, nil
+}
@@ -0,0 +1,283 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+)
+
+const (
+	workflow = "https://github.com/org/policies/.github/workflows/release.yml@refs/heads/main"
+	issuer   = "https://token.actions.githubusercontent.com"
+)
+
+// sigstore is a Fulcio root and a Rekor key issuing keyless signatures for the tests
+type sigstore struct {
+	root      *x509.Certificate
+	rootKey   *ecdsa.PrivateKey
+	rekorKey  *ecdsa.PrivateKey
+	loggedAt  time.Time
+	validFrom time.Time
+}
+
+func newSigstore(t *testing.T) *sigstore {
+	rootKey := generateKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := x509.ParseCertificate(der)
+	// the signing certificate has expired since the signature was logged
+	validFrom := time.Now().Add(-time.Hour)
+	return &sigstore{root: root, rootKey: rootKey, rekorKey: generateKey(t), validFrom: validFrom, loggedAt: validFrom.Add(time.Minute)}
+}
+
+func (s *sigstore) trust(t *testing.T, identities ...Identity) *Verifier {
+	verifier, err := NewVerifier(Trust{
+		Identities:  identities,
+		FulcioRoots: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.root.Raw})),
+		RekorKeys:   publicKeyPEM(t, &s.rekorKey.PublicKey),
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+// certificate returns a signing key and its certificate issued by Fulcio to the subject
+func (s *sigstore) certificate(t *testing.T, subject string) (*ecdsa.PrivateKey, []byte) {
+	key := generateKey(t)
+	issuerValue, _ := asn1.Marshal(issuer)
+	subjectURL, _ := url.Parse(subject)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       s.validFrom,
+		NotAfter:        s.validFrom.Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{subjectURL},
+		ExtraExtensions: []pkix.Extension{{Id: issuerV2OID, Value: issuerValue}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.root, &key.PublicKey, s.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// sign returns the cosign sign-blob bundle of a keyless signature of the blob by the subject
+func (s *sigstore) sign(t *testing.T, blob []byte, subject string) []byte {
+	key, certificate := s.certificate(t, subject)
+	signature := sign(t, key, blob)
+	bundle, _ := json.Marshal(blobBundle{
+		Signature:   base64.StdEncoding.EncodeToString(signature),
+		Certificate: base64.StdEncoding.EncodeToString(certificate),
+		Bundle:      s.log(t, hashedRekord(HASHEDREKORD_KIND, blob, signature, certificate)),
+	})
+	return bundle
+}
+
+// log returns the Rekor bundle of the transparency log entry
+func (s *sigstore) log(t *testing.T, body []byte) *Bundle {
+	der, err := x509.MarshalPKIXPublicKey(&s.rekorKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(der)
+	payload := BundlePayload{Body: base64.StdEncoding.EncodeToString(body), IntegratedTime: s.loggedAt.Unix(), LogID: hex.EncodeToString(logID[:]), LogIndex: 1}
+	content, _ := json.Marshal(payload)
+	canonical, err := jsoncanonicalizer.Transform(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Bundle{SignedEntryTimestamp: sign(t, s.rekorKey, canonical), Payload: payload}
+}
+
+func hashedRekord(kind string, blob []byte, signature []byte, certificate []byte) []byte {
+	digest := sha256.Sum256(blob)
+	body, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": HASHEDREKORD_VERSION,
+		"kind":       kind,
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])}},
+			"signature": map[string]interface{}{
+				"content":   base64.StdEncoding.EncodeToString(signature),
+				"publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString(certificate)},
+			},
+		},
+	})
+	return body
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, payload []byte) []byte {
+	digest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func publicKeyPEM(t *testing.T, key *ecdsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyBlobWithKey(t *testing.T) {
+	key := generateKey(t)
+	verifier, err := NewVerifier(Trust{Keys: []string{publicKeyPEM(t, &key.PublicKey)}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := []byte("checksums")
+	bundle := []byte(fmt.Sprintf(`{"base64Signature": %q}`, base64.StdEncoding.EncodeToString(sign(t, key, blob))))
+	if err := verifier.VerifyBlob(blob, bundle); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	if err := verifier.VerifyBlob([]byte("tampered checksums"), bundle); err == nil {
+		t.Errorf("Expected an error for a tampered blob")
+	}
+	other := generateKey(t)
+	bundle = []byte(fmt.Sprintf(`{"base64Signature": %q}`, base64.StdEncoding.EncodeToString(sign(t, other, blob))))
+	if err := verifier.VerifyBlob(blob, bundle); err == nil {
+		t.Errorf("Expected an error for an untrusted key")
+	}
+}
+
+func TestVerifyBlobWithIdentity(t *testing.T) {
+	sigstore := newSigstore(t)
+	verifier := sigstore.trust(t, Identity{Subject: workflow, Issuer: issuer})
+	blob := []byte("checksums")
+	if err := verifier.VerifyBlob(blob, sigstore.sign(t, blob, workflow)); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	if err := verifier.VerifyBlob([]byte("tampered checksums"), sigstore.sign(t, blob, workflow)); err == nil {
+		t.Errorf("Expected an error for a tampered blob")
+	}
+}
+
+func TestVerifyBlobRejectsOtherIdentity(t *testing.T) {
+	sigstore := newSigstore(t)
+	blob := []byte("checksums")
+	bundle := sigstore.sign(t, blob, "https://github.com/attacker/policies/.github/workflows/release.yml@refs/heads/main")
+	if err := sigstore.trust(t, Identity{Subject: workflow, Issuer: issuer}).VerifyBlob(blob, bundle); err == nil {
+		t.Errorf("Expected an error for an untrusted subject")
+	}
+	bundle = sigstore.sign(t, blob, workflow)
+	if err := sigstore.trust(t, Identity{Subject: workflow, Issuer: "https://accounts.google.com"}).VerifyBlob(blob, bundle); err == nil {
+		t.Errorf("Expected an error for an untrusted issuer")
+	}
+}
+
+func TestVerifyBlobRejectsUnloggedSignature(t *testing.T) {
+	sigstore := newSigstore(t)
+	verifier := sigstore.trust(t, Identity{Subject: workflow, Issuer: issuer})
+	blob := []byte("checksums")
+	bundle := blobBundle{}
+	json.Unmarshal(sigstore.sign(t, blob, workflow), &bundle)
+
+	// signed by another Rekor instance
+	bundle.Bundle.SignedEntryTimestamp = sign(t, generateKey(t), []byte("entry"))
+	content, _ := json.Marshal(bundle)
+	if err := verifier.VerifyBlob(blob, content); err == nil {
+		t.Errorf("Expected an error for an untrusted transparency log")
+	}
+	bundle.Bundle = nil
+	content, _ = json.Marshal(bundle)
+	if err := verifier.VerifyBlob(blob, content); err == nil {
+		t.Errorf("Expected an error for a signature missing from the transparency log")
+	}
+}
+
+func TestVerifyBlobRejectsOtherLogEntry(t *testing.T) {
+	sigstore := newSigstore(t)
+	verifier := sigstore.trust(t, Identity{Subject: workflow, Issuer: issuer})
+	blob := []byte("checksums")
+	key, certificate := sigstore.certificate(t, workflow)
+	signature := sign(t, key, blob)
+	bundle := blobBundle{
+		Signature:   base64.StdEncoding.EncodeToString(signature),
+		Certificate: base64.StdEncoding.EncodeToString(certificate),
+		Bundle:      sigstore.log(t, hashedRekord("rekord", blob, signature, certificate)),
+	}
+	content, _ := json.Marshal(bundle)
+	if err := verifier.VerifyBlob(blob, content); err == nil {
+		t.Errorf("Expected an error for a transparency log entry of another kind")
+	}
+
+	// the entry logs the signature with another certificate
+	_, other := sigstore.certificate(t, workflow)
+	bundle.Bundle = sigstore.log(t, hashedRekord(HASHEDREKORD_KIND, blob, signature, other))
+	content, _ = json.Marshal(bundle)
+	if err := verifier.VerifyBlob(blob, content); err == nil {
+		t.Errorf("Expected an error for a transparency log entry of another signing certificate")
+	}
+}
+
+func TestNewVerifierRequiresTrustedKeysOrIdentities(t *testing.T) {
+	if _, err := NewVerifier(Trust{}, t.TempDir()); err == nil {
+		t.Errorf("Expected an error for an empty trust configuration")
+	}
+	if _, err := NewVerifier(Trust{Identities: []Identity{{Subject: workflow, Issuer: issuer}}}, t.TempDir()); err == nil {
+		t.Errorf("Expected an error for identities without Fulcio roots and Rekor keys")
+	}
+}
+
+func TestLoadMissingFileDisablesVerification(t *testing.T) {
+	verifier, err := Load(t.TempDir() + "/" + DEFAULT_FILE)
+	if err != nil || verifier != nil {
+		t.Errorf("Unexpected verifier %v, error %v", verifier, err)
+	}
+}
@@ -0,0 +1,408 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package store persists the findings of every run in a local SQLite database, so that their
+// trend can be followed without external infrastructure, see doa history. The SQLite driver is
+// written in Go, so that the store works in the binaries built without cgo.
+ package store
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	// the driver registers itself as sqlite
+	_ "modernc.org/sqlite"
+)
+
+// STORE_ENV is the environment variable setting the database when --store is not given
+const STORE_ENV = "DOA_STORE"
+
+// dateFormat sorts the dates as strings
+const dateFormat = "2006-01-02T15:04:05Z"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	date     TEXT NOT NULL,
+	target   TEXT NOT NULL,
+	project  TEXT NOT NULL DEFAULT '',
+	ruleset  TEXT NOT NULL,
+	score    INTEGER NOT NULL,
+	verdict  TEXT NOT NULL,
+	critical INTEGER NOT NULL,
+	high     INTEGER NOT NULL,
+	medium   INTEGER NOT NULL,
+	low      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_target ON runs (target, date);
+CREATE TABLE IF NOT EXISTS findings (
+	run_id      INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	rule_id     TEXT NOT NULL,
+	severity    TEXT NOT NULL,
+	line        INTEGER,
+	fingerprint TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS findings_rule ON findings (rule_id);
+CREATE INDEX IF NOT EXISTS findings_run ON findings (run_id);
+`
+
+type Store struct {
+	db *sql.DB
+}
+
+// Run is an analysis of a Containerfile or of an image.
+type Run struct {
+	ID   int64     `json:"id"`
+	Date time.Time `json:"date"`
+	// Target is the analyzed Containerfile or image
+	Target string `json:"target"`
+	// Project is the project of the Containerfile in a workspace, see doa workspace
+	Project string           `json:"project,omitempty"`
+	Ruleset string           `json:"ruleset"`
+	Score   int              `json:"score"`
+	Summary analyzer.Summary `json:"summary"`
+}
+
+// Query filters the runs, its empty fields matching every run.
+type Query struct {
+	Target  string
+	Project string
+	RuleID  string
+	Since   time.Time
+	// Limit is the maximum number of runs, the most recent ones
+	Limit int
+}
+
+// Open opens the database at path, creating it when it doesn't exist.
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrapf(err, "unable to create the directory of the store %s", path)
+		}
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open the store %s", path)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "unable to open the store %s", path)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores the run and its failed findings, its ID and date being set.
+func (s *Store) Record(run *Run, results []analyzer.Result) error {
+	if run.Date.IsZero() {
+		run.Date = time.Now()
+	}
+	run.Date = run.Date.UTC().Truncate(time.Second)
+	if run.Ruleset == "" {
+		run.Ruleset = analyzer.RULESET_VERSION
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "unable to record the run")
+	}
+	defer tx.Rollback()
+	counts := run.Summary.BySeverity
+	res, err := tx.Exec(`INSERT INTO runs (date, target, project, ruleset, score, verdict, critical, high, medium, low) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.Date.Format(dateFormat), run.Target, run.Project, run.Ruleset, run.Score, string(run.Summary.Verdict),
+		counts[analyzer.SeverityCritical], counts[analyzer.SeverityHigh], counts[analyzer.SeverityMedium], counts[analyzer.SeverityLow])
+	if err != nil {
+		return errors.Wrap(err, "unable to record the run")
+	}
+	if run.ID, err = res.LastInsertId(); err != nil {
+		return errors.Wrap(err, "unable to record the run")
+	}
+	for _, result := range results {
+		if result.Status != analyzer.StatusFailed {
+			continue
+		}
+		var line sql.NullInt64
+		if result.Line != nil {
+			line = sql.NullInt64{Int64: int64(result.Line.Start), Valid: true}
+		}
+		if _, err := tx.Exec(`INSERT INTO findings (run_id, rule_id, severity, line, fingerprint, description) VALUES (?, ?, ?, ?, ?, ?)`,
+			run.ID, result.RuleID, string(result.Severity), line, result.Fingerprint, result.Description); err != nil {
+			return errors.Wrap(err, "unable to record the findings")
+		}
+	}
+	return errors.Wrap(tx.Commit(), "unable to record the run")
+}
+
+// where returns the conditions of the query on the runs table, aliased r.
+func (q Query) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if q.Target != "" {
+		conditions = append(conditions, "r.target = ?")
+		args = append(args, q.Target)
+	}
+	if q.Project != "" {
+		conditions = append(conditions, "r.project = ?")
+		args = append(args, q.Project)
+	}
+	if q.RuleID != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM findings f WHERE f.run_id = r.id AND f.rule_id = ?)")
+		args = append(args, q.RuleID)
+	}
+	if !q.Since.IsZero() {
+		conditions = append(conditions, "r.date >= ?")
+		args = append(args, q.Since.UTC().Format(dateFormat))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Runs returns the runs matching the query, the most recent first.
+func (s *Store) Runs(query Query) ([]Run, error) {
+	where, args := query.where()
+	statement := `SELECT r.id, r.date, r.target, r.project, r.ruleset, r.score, r.verdict, r.critical, r.high, r.medium, r.low FROM runs r` + where + ` ORDER BY r.date DESC, r.id DESC`
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the runs")
+	}
+	defer rows.Close()
+	runs := []Run{}
+	for rows.Next() {
+		var run Run
+		var date, verdict string
+		var critical, high, medium, low int
+		if err := rows.Scan(&run.ID, &date, &run.Target, &run.Project, &run.Ruleset, &run.Score, &verdict, &critical, &high, &medium, &low); err != nil {
+			return nil, errors.Wrap(err, "unable to query the runs")
+		}
+		run.Date, _ = time.Parse(dateFormat, date)
+		run.Summary = analyzer.Summary{
+			Failed:  critical + high + medium + low,
+			Verdict: analyzer.Verdict(verdict),
+			BySeverity: map[analyzer.ResultSeverity]int{
+				analyzer.SeverityCritical: critical,
+				analyzer.SeverityHigh:     high,
+				analyzer.SeverityMedium:   medium,
+				analyzer.SeverityLow:      low,
+			},
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// severityLevel orders the severities of the findings, whose severity can be changed by the
+// configuration, so that the most severe one is reported
+const severityLevel = `CASE f.severity WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END`
+
+var severities = map[int]analyzer.ResultSeverity{
+	4: analyzer.SeverityCritical,
+	3: analyzer.SeverityHigh,
+	2: analyzer.SeverityMedium,
+	1: analyzer.SeverityLow,
+}
+
+// RuleCount is the number of findings of a rule in the matching runs.
+type RuleCount struct {
+	RuleID   string                  `json:"ruleId"`
+	Severity analyzer.ResultSeverity `json:"severity"`
+	Findings int                     `json:"findings"`
+	// Runs is the number of runs having findings of the rule
+	Runs     int       `json:"runs"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Rules counts the findings per rule in the runs matching the query, the most frequent first.
+func (s *Store) Rules(query Query) ([]RuleCount, error) {
+	where, args := query.where()
+	rows, err := s.db.Query(`SELECT f.rule_id, MAX(`+severityLevel+`), COUNT(*), COUNT(DISTINCT r.id), MAX(r.date) FROM findings f JOIN runs r ON r.id = f.run_id`+
+		where+` GROUP BY f.rule_id ORDER BY COUNT(*) DESC, f.rule_id`, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the findings")
+	}
+	defer rows.Close()
+	counts := []RuleCount{}
+	for rows.Next() {
+		var count RuleCount
+		var date string
+		var level int
+		if err := rows.Scan(&count.RuleID, &level, &count.Findings, &count.Runs, &date); err != nil {
+			return nil, errors.Wrap(err, "unable to query the findings")
+		}
+		count.Severity = severities[level]
+		count.LastSeen, _ = time.Parse(dateFormat, date)
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// Finding is a failed finding of a run.
+type Finding struct {
+	RuleID      string                  `json:"ruleId"`
+	Severity    analyzer.ResultSeverity `json:"severity"`
+	Line        int                     `json:"line,omitempty"`
+	Fingerprint string                  `json:"fingerprint,omitempty"`
+	Description string                  `json:"description"`
+}
+
+// Findings returns the failed findings of the run, nil when the run doesn't exist.
+func (s *Store) Findings(runID int64) ([]Finding, error) {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT COUNT(*) > 0 FROM runs WHERE id = ?`, runID).Scan(&exists); err != nil || !exists {
+		return nil, errors.Wrap(err, "unable to query the findings")
+	}
+	rows, err := s.db.Query(`SELECT rule_id, severity, line, fingerprint, description FROM findings WHERE run_id = ? ORDER BY rowid`, runID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the findings")
+	}
+	defer rows.Close()
+	findings := []Finding{}
+	for rows.Next() {
+		var finding Finding
+		var line sql.NullInt64
+		if err := rows.Scan(&finding.RuleID, &finding.Severity, &line, &finding.Fingerprint, &finding.Description); err != nil {
+			return nil, errors.Wrap(err, "unable to query the findings")
+		}
+		finding.Line = int(line.Int64)
+		findings = append(findings, finding)
+	}
+	return findings, rows.Err()
+}
+
+// ProjectScore is the state of a project according to the last run of each of its targets.
+type ProjectScore struct {
+	// Project is empty for the targets outside a workspace, e.g. the images
+	Project string `json:"project"`
+	Targets int    `json:"targets"`
+	// Score is the average score of the targets
+	Score    int       `json:"score"`
+	Critical int       `json:"critical"`
+	High     int       `json:"high"`
+	Medium   int       `json:"medium"`
+	Low      int       `json:"low"`
+	LastRun  time.Time `json:"lastRun"`
+}
+
+// Projects returns the score of each project, from the last run of each of its targets matching
+// the query.
+func (s *Store) Projects(query Query) ([]ProjectScore, error) {
+	query.Limit = 0
+	where, args := query.where()
+	rows, err := s.db.Query(`SELECT r.project, COUNT(*), ROUND(AVG(r.score)), SUM(r.critical), SUM(r.high), SUM(r.medium), SUM(r.low), MAX(r.date) FROM runs r
+		WHERE r.id IN (SELECT MAX(r.id) FROM runs r`+where+` GROUP BY r.target) GROUP BY r.project ORDER BY r.project`, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the projects")
+	}
+	defer rows.Close()
+	projects := []ProjectScore{}
+	for rows.Next() {
+		var project ProjectScore
+		var score float64
+		var date string
+		if err := rows.Scan(&project.Project, &project.Targets, &score, &project.Critical, &project.High, &project.Medium, &project.Low, &date); err != nil {
+			return nil, errors.Wrap(err, "unable to query the projects")
+		}
+		project.Score = int(score)
+		project.LastRun, _ = time.Parse(dateFormat, date)
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// ParseSince parses a date, e.g. 2024-03-01, or a duration before now, e.g. 12h, 30d or 8w.
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) {
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid since %q, expected a date (2006-01-02) or a duration (e.g. 12h, 30d, 8w)", value)
+	}
+	return now.Add(-duration), nil
+}
+
+// Period groups the runs of a trend
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+)
+
+// periodFormats are the strftime formats of the periods, the weeks starting on Monday
+var periodFormats = map[Period]string{
+	PeriodDay:  "%Y-%m-%d",
+	PeriodWeek: "%Y-W%W",
+}
+
+// Point is the trend of the matching runs over a period.
+type Point struct {
+	Period string `json:"period"`
+	Runs   int    `json:"runs"`
+	// Score is the average score of the runs
+	Score int `json:"score"`
+	// Findings is the average number of failed findings of the runs
+	Findings float64 `json:"findings"`
+	Failed   int     `json:"failed"`
+}
+
+// Trend returns the average score and number of findings of the matching runs per period, the
+// oldest first.
+func (s *Store) Trend(query Query, period Period) ([]Point, error) {
+	format, ok := periodFormats[period]
+	if !ok {
+		return nil, errors.Errorf("unknown period %s, expected day or week", period)
+	}
+	where, args := query.where()
+	rows, err := s.db.Query(`SELECT strftime(?, r.date) AS period, COUNT(*), ROUND(AVG(r.score)), AVG(r.critical + r.high + r.medium + r.low), SUM(r.verdict = ?) FROM runs r`+
+		where+` GROUP BY period ORDER BY period`, append([]interface{}{format, string(analyzer.VerdictFailed)}, args...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the trend")
+	}
+	defer rows.Close()
+	points := []Point{}
+	for rows.Next() {
+		var point Point
+		var score float64
+		if err := rows.Scan(&point.Period, &point.Runs, &score, &point.Findings, &point.Failed); err != nil {
+			return nil, errors.Wrap(err, "unable to query the trend")
+		}
+		point.Score = int(score)
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
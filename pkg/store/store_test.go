@@ -0,0 +1,137 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func openStore(t *testing.T) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "history", "doa.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func record(t *testing.T, s *Store, date time.Time, target string, score int, results ...analyzer.Result) {
+	run := &Run{Date: date, Target: target, Score: score, Summary: analyzer.Summarize(results)}
+	if err := s.Record(run, results); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if run.ID == 0 {
+		t.Errorf("Expected the ID of the run to be set")
+	}
+}
+
+func TestRecordAndQueryRuns(t *testing.T) {
+	s := openStore(t)
+	day := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	record(t, s, day, "web/Containerfile", 96, analyzer.RuleUserRoot.Failed("USER root"), analyzer.RuleUserRoot.Passed("USER 1001"))
+	record(t, s, day.Add(time.Hour), "api/Containerfile", 100)
+	record(t, s, day.AddDate(0, 0, 1), "web/Containerfile", 100)
+
+	runs, err := s.Runs(Query{Target: "web/Containerfile"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(runs) != 2 || runs[0].Score != 100 || runs[1].Summary.BySeverity[analyzer.SeverityMedium] != 1 || runs[1].Summary.Verdict != analyzer.VerdictFailed {
+		t.Errorf("Unexpected runs %v", runs)
+	}
+	if runs, _ = s.Runs(Query{Since: day.Add(30 * time.Minute)}); len(runs) != 2 {
+		t.Errorf("Expected 2 runs since the first one but they were %v", runs)
+	}
+	if runs, _ = s.Runs(Query{RuleID: analyzer.RuleUserRoot.ID}); len(runs) != 1 || !runs[0].Date.Equal(day) {
+		t.Errorf("Expected the first run but they were %v", runs)
+	}
+	if runs, _ = s.Runs(Query{Limit: 1}); len(runs) != 1 || runs[0].Target != "web/Containerfile" {
+		t.Errorf("Expected the last run but they were %v", runs)
+	}
+}
+
+func TestRulesCountsFailedFindings(t *testing.T) {
+	s := openStore(t)
+	now := time.Now()
+	highRoot := analyzer.RuleUserRoot.Failed("USER root")
+	highRoot.Severity = analyzer.SeverityHigh
+	record(t, s, now.Add(-time.Hour), "web/Containerfile", 90, highRoot, analyzer.RuleChownGroup.Failed("chown"))
+	record(t, s, now, "web/Containerfile", 95, analyzer.RuleUserRoot.Failed("USER root"))
+
+	counts, err := s.Rules(Query{})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(counts) != 2 || counts[0].RuleID != analyzer.RuleUserRoot.ID || counts[0].Findings != 2 || counts[0].Runs != 2 || counts[0].Severity != analyzer.SeverityHigh {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+}
+
+func TestTrend(t *testing.T) {
+	s := openStore(t)
+	monday := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	record(t, s, monday, "web/Containerfile", 80, analyzer.RuleHostPath.Failed("docker.sock"), analyzer.RuleUserRoot.Failed("USER root"))
+	record(t, s, monday.Add(time.Hour), "api/Containerfile", 91, analyzer.RuleUserRoot.Failed("USER root"))
+	record(t, s, monday.AddDate(0, 0, 7), "web/Containerfile", 100)
+
+	points, err := s.Trend(Query{}, PeriodWeek)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(points) != 2 || points[0].Period != "2024-W10" || points[0].Runs != 2 || points[0].Score != 86 || points[0].Findings != 1.5 || points[0].Failed != 2 {
+		t.Errorf("Unexpected trend %v", points)
+	}
+	if points, _ = s.Trend(Query{Target: "web/Containerfile"}, PeriodDay); len(points) != 2 || points[1].Period != "2024-03-11" || points[1].Score != 100 {
+		t.Errorf("Unexpected trend %v", points)
+	}
+	if _, err := s.Trend(Query{}, "month"); err == nil {
+		t.Errorf("Expected an error for an unknown period")
+	}
+}
+
+func TestFindingsAndProjects(t *testing.T) {
+	s := openStore(t)
+	day := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	record(t, s, day, "web/Containerfile", 96, analyzer.RuleUserRoot.Failed("USER root"))
+	runs, _ := s.Runs(Query{})
+	record(t, s, day.Add(time.Hour), "web/Containerfile", 100)
+	record(t, s, day.Add(time.Hour), "quay.io/team/api:latest", 90, analyzer.RuleHostPath.Failed("docker.sock"))
+
+	findings, err := s.Findings(runs[0].ID)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != analyzer.RuleUserRoot.ID {
+		t.Errorf("Unexpected findings %v", findings)
+	}
+	if findings, err = s.Findings(42); findings != nil || err != nil {
+		t.Errorf("Expected no findings for an unknown run but they were %v, %v", findings, err)
+	}
+
+	projects, err := s.Projects(Query{})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(projects) != 1 || projects[0].Targets != 2 || projects[0].Score != 95 || projects[0].High != 1 {
+		t.Errorf("Unexpected projects %v", projects)
+	}
+}
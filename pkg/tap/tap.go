@@ -0,0 +1,106 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package tap writes the results in the Test Anything Protocol (https://testanything.org), version
+// 13, so that they can be consumed by the TAP harnesses, e.g. prove.
+ package tap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/checkstyle"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const VERSION = 13
+
+// Target holds the results of a Containerfile or an image
+type Target struct {
+	File    string
+	Results []analyzer.Result
+}
+
+// Write writes a test point per result, not ok for the failed results, followed by a YAML block
+// describing them. A Containerfile without result is reported as a single passed test point.
+func Write(w io.Writer, containerfile string, results []analyzer.Result) error {
+	return WriteTargets(w, []Target{{File: containerfile, Results: results}})
+}
+
+// WriteTargets writes the test points of the targets one after the other, see Write.
+func WriteTargets(w io.Writer, targets []Target) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version %d\n", VERSION)
+	total := 0
+	for _, target := range targets {
+		total += len(target.Results)
+		if len(target.Results) == 0 {
+			total++
+		}
+	}
+	fmt.Fprintf(&b, "1..%d\n", total)
+	point := 0
+	for _, target := range targets {
+		if len(target.Results) == 0 {
+			point++
+			fmt.Fprintf(&b, "ok %d - %s has no issue\n", point, description(target.File))
+		}
+		for _, result := range target.Results {
+			point++
+			status := "ok"
+			if result.Status == analyzer.StatusFailed {
+				status = "not ok"
+			}
+			fmt.Fprintf(&b, "%s %d - %s\n", status, point, description(result.Name))
+			if result.Status != analyzer.StatusFailed {
+				continue
+			}
+			file, line := checkstyle.Location(target.File, result)
+			b.WriteString("  ---\n")
+			field(&b, "message", result.Description)
+			field(&b, "severity", string(result.Severity))
+			if result.Confidence != "" {
+				field(&b, "confidence", string(result.Confidence))
+			}
+			if result.RuleID != "" {
+				field(&b, "rule", result.RuleID)
+			}
+			field(&b, "file", file)
+			if line > 0 {
+				fmt.Fprintf(&b, "  line: %d\n", line)
+			}
+			b.WriteString("  ...\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// description escapes the characters the harnesses interpret in the description of a test point.
+func description(text string) string {
+	text = strings.ReplaceAll(text, "#", "\\#")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// field writes a YAML field, the value being quoted as a JSON string, which is valid YAML.
+func field(b *strings.Builder, name string, value string) {
+	quoted, _ := json.Marshal(value)
+	fmt.Fprintf(b, "  %s: %s\n", name, quoted)
+}
@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package tap
+
+import (
+	"bytes"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestWrite(t *testing.T) {
+	root := analyzer.RuleUserRoot.Failed("USER root is set # line 3")
+	root.Line = &analyzer.Line{Start: 3, End: 3}
+	results := []analyzer.Result{root, analyzer.RuleUserRoot.Passed("USER 1001")}
+
+	var out bytes.Buffer
+	if err := Write(&out, "Containerfile", results); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	expected := `TAP version 13
+1..2
+not ok 1 - User set to root
+  ---
+  message: "USER root is set # line 3"
+  severity: "medium"
+  confidence: "high"
+  rule: "user-root"
+  file: "Containerfile"
+  line: 3
+  ...
+ok 2 - User set to root
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\nbut it was\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	Write(&out, "web/Containerfile", nil)
+	if expected = "TAP version 13\n1..1\nok 1 - web/Containerfile has no issue\n"; out.String() != expected {
+		t.Errorf("Expected\n%s\nbut it was\n%s", expected, out.String())
+	}
+}
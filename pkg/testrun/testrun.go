@@ -0,0 +1,243 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package testrun builds the image of a Containerfile with Podman, or Buildah, and starts it as
+// OpenShift runs it, with an arbitrary UID, the root group and a read-only root filesystem, so
+// that the permission errors of the entrypoint are caught before the deployment and correlated
+// back to the instructions of the Containerfile.
+ package testrun
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+// DEFAULT_DURATION is how long the container runs before its logs are checked
+const DEFAULT_DURATION = 10 * time.Second
+
+// The kinds of the runtime errors
+const (
+	KIND_PERMISSION_DENIED = "permission-denied"
+	KIND_READ_ONLY         = "read-only"
+)
+
+var (
+	permissionDeniedRegexp = regexp.MustCompile(`(?i)permission denied|\bEACCES\b|operation not permitted|\bEPERM\b|AccessDeniedException`)
+	readOnlyRegexp         = regexp.MustCompile(`(?i)read-only file ?system|\bEROFS\b`)
+	// quotedPathRegexp and pathRegexp match the path of the error, the quoted one first
+	quotedPathRegexp = regexp.MustCompile("[\"'`‘](/[^\"'`’\\s]+)[\"'`’]")
+	pathRegexp       = regexp.MustCompile(`(?:^|[\s(=:])(/[\w@%+=,.~/-]*[\w/])`)
+)
+
+type Options struct {
+	// Containerfile is the Containerfile of the image
+	Containerfile string
+	// Context is the build context, the directory of the Containerfile when empty
+	Context string
+	// Target is the stage built, the final one when empty
+	Target string
+	// Builder builds the image, podman or buildah, the image being run by podman
+	Builder string
+	UID     int64
+	// Duration is how long the container runs, DEFAULT_DURATION when zero
+	Duration time.Duration
+	// WritableRoot doesn't mount the root filesystem read-only
+	WritableRoot bool
+}
+
+// Error is a permission error logged by the container.
+type Error struct {
+	Kind string `json:"kind"`
+	// Message is the line of the logs reporting the error
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	// Line is the instruction which set the permissions of the path, or made the container
+	// access it, see analyzer.LocatePath
+	Line *analyzer.Line `json:"line,omitempty"`
+}
+
+// Report is the result of the test run.
+type Report struct {
+	UID int64 `json:"uid"`
+	// Running is set when the container was still running at the end of the test run
+	Running  bool    `json:"running"`
+	ExitCode int     `json:"exitCode"`
+	Errors   []Error `json:"errors"`
+	Logs     string  `json:"logs"`
+}
+
+// Run builds the image and runs it for the duration of the options, the image and the container
+// are removed afterwards.
+func Run(ctx context.Context, options Options) (*Report, error) {
+	if options.Builder == "" {
+		options.Builder = "podman"
+	}
+	if options.Builder != "podman" && options.Builder != "buildah" {
+		return nil, errors.Errorf("unknown builder %s, expected podman or buildah", options.Builder)
+	}
+	if options.Duration == 0 {
+		options.Duration = DEFAULT_DURATION
+	}
+	if options.Context == "" {
+		options.Context = filepath.Dir(options.Containerfile)
+	}
+	for _, tool := range []string{options.Builder, "podman"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil, errors.Errorf("%s is required to test run the image but it was not found in the PATH", tool)
+		}
+	}
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	name := "doa-test-run-" + hex.EncodeToString(suffix)
+	image := "localhost/" + name
+
+	args := []string{"build", "--file", options.Containerfile, "--tag", image}
+	if options.Target != "" {
+		args = append(args, "--target", options.Target)
+	}
+	if _, err := output(ctx, "build the image", options.Builder, append(args, options.Context)...); err != nil {
+		return nil, err
+	}
+	defer output(context.Background(), "remove the image", "podman", "rmi", "--force", image)
+
+	args = []string{"run", "--detach", "--name", name, "--user", fmt.Sprintf("%d:0", options.UID),
+		"--cap-drop", "ALL", "--security-opt", "no-new-privileges"}
+	if !options.WritableRoot {
+		// podman mounts a tmpfs on /tmp, /var/tmp and /run
+		args = append(args, "--read-only")
+	}
+	if _, err := output(ctx, "start the container", "podman", append(args, image)...); err != nil {
+		return nil, err
+	}
+	defer output(context.Background(), "remove the container", "podman", "rm", "--force", name)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(options.Duration):
+	}
+
+	logs, err := output(ctx, "read the logs of the container", "podman", "logs", name)
+	if err != nil {
+		return nil, err
+	}
+	state, err := output(ctx, "inspect the container", "podman", "inspect", "--format", "{{.State.Running}} {{.State.ExitCode}}", name)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{UID: options.UID, Logs: string(logs)}
+	fields := strings.Fields(string(state))
+	if len(fields) == 2 {
+		report.Running = fields[0] == "true"
+		report.ExitCode, _ = strconv.Atoi(fields[1])
+	}
+	report.Errors = ParseErrors(logs)
+	if err := Locate(ctx, options, report.Errors); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// output runs the command and returns its standard output and error, purpose describing the
+// command in the error.
+func output(ctx context.Context, purpose string, name string, args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "unable to %s: %s", purpose, bytes.TrimSpace(out.Bytes()))
+	}
+	return out.Bytes(), nil
+}
+
+// ParseErrors returns the permission denied and read-only filesystem errors of the logs, each
+// line being reported once.
+func ParseErrors(logs []byte) []Error {
+	found := []Error{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(logs), "\n") {
+		line = strings.TrimSpace(line)
+		kind := ""
+		switch {
+		case readOnlyRegexp.MatchString(line):
+			kind = KIND_READ_ONLY
+		case permissionDeniedRegexp.MatchString(line):
+			kind = KIND_PERMISSION_DENIED
+		default:
+			continue
+		}
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		found = append(found, Error{Kind: kind, Message: line, Path: errorPath(line)})
+	}
+	return found
+}
+
+// errorPath returns the absolute path of the error message, e.g. /var/log/nginx/error.log of
+// open() "/var/log/nginx/error.log" failed (13: Permission denied)
+func errorPath(line string) string {
+	if match := quotedPathRegexp.FindStringSubmatch(line); match != nil {
+		return match[1]
+	}
+	if match := pathRegexp.FindStringSubmatch(line); match != nil {
+		return strings.TrimRight(match[1], ".,:")
+	}
+	return ""
+}
+
+// Locate sets the line of the instruction of the Containerfile each error comes from.
+func Locate(ctx context.Context, options Options, found []Error) error {
+	content, err := os.ReadFile(options.Containerfile)
+	if err != nil {
+		return err
+	}
+	res, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	ctx = analyzer.WithBuildContext(ctx, options.Context)
+	if options.Target != "" {
+		ctx = analyzer.WithTarget(ctx, options.Target)
+	}
+	for i := range found {
+		if found[i].Path == "" {
+			continue
+		}
+		if line, ok := analyzer.LocatePath(ctx, res.AST, found[i].Path); ok {
+			found[i].Line = &line
+		}
+	}
+	return nil
+}
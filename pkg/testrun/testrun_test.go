@@ -0,0 +1,63 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package testrun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	logs := `/docker-entrypoint.sh: Configuration complete; ready for start up
+2023/05/02 10:12:01 [emerg] 1#1: open() "/var/log/nginx/error.log" failed (13: Permission denied)
+mkdir: cannot create directory '/app/cache': Read-only file system
+Error: EACCES: permission denied, open /app/logs/app.log
+Error: EACCES: permission denied, open /app/logs/app.log
+exec /app/run.sh: permission denied
+listening on :8080
+`
+	found := ParseErrors([]byte(logs))
+	expected := []Error{
+		{Kind: KIND_PERMISSION_DENIED, Message: `2023/05/02 10:12:01 [emerg] 1#1: open() "/var/log/nginx/error.log" failed (13: Permission denied)`, Path: "/var/log/nginx/error.log"},
+		{Kind: KIND_READ_ONLY, Message: "mkdir: cannot create directory '/app/cache': Read-only file system", Path: "/app/cache"},
+		{Kind: KIND_PERMISSION_DENIED, Message: "Error: EACCES: permission denied, open /app/logs/app.log", Path: "/app/logs/app.log"},
+		{Kind: KIND_PERMISSION_DENIED, Message: "exec /app/run.sh: permission denied", Path: "/app/run.sh"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v but got %v", expected, found)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	dir := t.TempDir()
+	containerfile := filepath.Join(dir, "Containerfile")
+	content := "FROM ubi9\nWORKDIR /app\nRUN mkdir logs && chown 1001:1001 logs\nCOPY run.sh /app/run.sh\nENTRYPOINT [\"/app/run.sh\"]\n"
+	if err := os.WriteFile(containerfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	found := []Error{{Path: "/app/logs/app.log"}, {Path: "/app/run.sh"}, {Path: "/etc/passwd"}, {}}
+	if err := Locate(context.Background(), Options{Containerfile: containerfile}, found); err != nil {
+		t.Fatal(err)
+	}
+	if found[0].Line == nil || found[0].Line.Start != 3 || found[1].Line == nil || found[1].Line.Start != 4 || found[2].Line != nil || found[3].Line != nil {
+		t.Errorf("Unexpected lines %v", found)
+	}
+}
@@ -0,0 +1,189 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package triage stores the findings marked as false positives by the users, so that they are
+// suppressed on subsequent runs. Every entry records who triaged the finding and why, and the
+// waivers also when they expire: the expired ones stop suppressing their findings.
+ package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+const DEFAULT_FILE = ".doa-triage.json"
+
+type Entry struct {
+	RuleID string `json:"ruleId"`
+	// Line is the first line of the instruction the finding refers to, 0 matches every line
+	Line int `json:"line,omitempty"`
+	// Fingerprint matches a single finding whatever its line, it takes precedence over Line
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Reason      string    `json:"reason"`
+	Author      string    `json:"author"`
+	Date        time.Time `json:"date"`
+	// Expires is the date the entry stops suppressing the finding, e.g. a waiver granted until a
+	// fix is released, nil never expires
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Status of an entry, see Entry.Status
+const (
+	STATUS_PERMANENT = "permanent"
+	STATUS_ACTIVE    = "active"
+	STATUS_EXPIRED   = "expired"
+)
+
+// Expired reports whether the entry expired at now.
+func (e Entry) Expired(now time.Time) bool {
+	return e.Expires != nil && !now.Before(*e.Expires)
+}
+
+// Status returns whether the entry is permanent, active until it expires or expired at now.
+func (e Entry) Status(now time.Time) string {
+	switch {
+	case e.Expires == nil:
+		return STATUS_PERMANENT
+	case e.Expired(now):
+		return STATUS_EXPIRED
+	}
+	return STATUS_ACTIVE
+}
+
+// ParseExpiry parses a date, e.g. 2024-12-31, the entry expiring on that day, or a duration after
+// now, e.g. 12h, 30d or 8w.
+func ParseExpiry(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) {
+			return now.Add(time.Duration(count) * unit), nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return time.Time{}, errors.Errorf("invalid expiry %q, expected a date (2006-01-02) or a duration (e.g. 12h, 30d, 8w)", value)
+	}
+	return now.Add(duration), nil
+}
+
+type File struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads the feedback file at path. A missing file is an empty feedback file.
+func Load(path string) (*File, error) {
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the feedback file %s", path)
+	}
+	file := &File{}
+	if err := json.Unmarshal(bytes, file); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the feedback file %s", path)
+	}
+	// the entries are auditable exceptions, they must tell what they suppress and why
+	for i, entry := range file.Entries {
+		if entry.RuleID == "" || strings.TrimSpace(entry.Reason) == "" {
+			return nil, errors.Errorf("entry %d of the feedback file %s must have a ruleId and a reason", i+1, path)
+		}
+	}
+	return file, nil
+}
+
+func (f *File) Save(path string) error {
+	bytes, err := json.MarshalIndent(f, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(bytes, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "unable to write the feedback file %s", path)
+	}
+	return nil
+}
+
+// Add records the entry, replacing a previous entry for the same rule, line and fingerprint.
+func (f *File) Add(entry Entry) {
+	f.Remove(entry.RuleID, entry.Line, entry.Fingerprint)
+	f.Entries = append(f.Entries, entry)
+}
+
+// Remove deletes the entry for the rule, line and fingerprint, it reports whether an entry was
+// found.
+func (f *File) Remove(ruleID string, line int, fingerprint string) bool {
+	for i, entry := range f.Entries {
+		if entry.RuleID == ruleID && entry.Line == line && entry.Fingerprint == fingerprint {
+			f.Entries = append(f.Entries[:i], f.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the entry suppressing the result, if any.
+func (f *File) Match(result analyzer.Result) *Entry {
+	for i, entry := range f.Entries {
+		if entry.RuleID == "" || entry.RuleID != result.RuleID {
+			continue
+		}
+		if entry.Fingerprint != "" {
+			if entry.Fingerprint == result.Fingerprint {
+				return &f.Entries[i]
+			}
+			continue
+		}
+		if entry.Line == 0 || (result.Line != nil && result.Line.Start == entry.Line) {
+			return &f.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Suppress drops the results marked as false positives and returns the remaining ones along
+// with the number of suppressed results. The results matched by expired entries only are reported
+// again, their description telling whose waiver expired.
+func (f *File) Suppress(results []analyzer.Result) ([]analyzer.Result, int) {
+	now := time.Now()
+	active := &File{}
+	for _, entry := range f.Entries {
+		if !entry.Expired(now) {
+			active.Entries = append(active.Entries, entry)
+		}
+	}
+	kept := []analyzer.Result{}
+	for _, result := range results {
+		if active.Match(result) != nil {
+			continue
+		}
+		if expired := f.Match(result); expired != nil {
+			result.Description += fmt.Sprintf(" (the waiver of %s expired on %s: %s)", expired.Author, expired.Expires.Local().Format("2006-01-02"), expired.Reason)
+		}
+		kept = append(kept, result)
+	}
+	return kept, len(results) - len(kept)
+}
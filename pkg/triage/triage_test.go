@@ -0,0 +1,147 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package triage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+)
+
+func TestLoadMissingFileIsEmpty(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), DEFAULT_FILE))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(file.Entries) != 0 {
+		t.Errorf("Expected no entries but they were %d", len(file.Entries))
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DEFAULT_FILE)
+	file := &File{}
+	file.Add(Entry{RuleID: "chown-group", Line: 3, Reason: "the group is created by the base image", Author: "jdoe"})
+	if err := file.Save(path); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0].Author != "jdoe" || loaded.Entries[0].Line != 3 {
+		t.Errorf("Unexpected entries %v", loaded.Entries)
+	}
+}
+
+func TestAddReplacesEntryForSameRuleAndLine(t *testing.T) {
+	file := &File{}
+	file.Add(Entry{RuleID: "chown-group", Line: 3, Reason: "first"})
+	file.Add(Entry{RuleID: "chown-group", Line: 3, Reason: "second"})
+	if len(file.Entries) != 1 || file.Entries[0].Reason != "second" {
+		t.Errorf("Unexpected entries %v", file.Entries)
+	}
+}
+
+func TestSuppressMatchesRuleAndLine(t *testing.T) {
+	file := &File{Entries: []Entry{
+		{RuleID: "chown-group", Line: 3},
+		{RuleID: "user-root"},
+	}}
+	results, suppressed := file.Suppress([]analyzer.Result{
+		{RuleID: "chown-group", Line: &analyzer.Line{Start: 3, End: 3}},
+		{RuleID: "chown-group", Line: &analyzer.Line{Start: 5, End: 5}},
+		{RuleID: "user-root"},
+		{RuleID: "privileged-port", Line: &analyzer.Line{Start: 3, End: 3}},
+	})
+	if suppressed != 2 {
+		t.Errorf("Expected 2 suppressed results but they were %d", suppressed)
+	}
+	if len(results) != 2 || results[0].Line.Start != 5 || results[1].RuleID != "privileged-port" {
+		t.Errorf("Unexpected results %v", results)
+	}
+}
+
+func TestSuppressMatchesFingerprintWhateverTheLine(t *testing.T) {
+	file := &File{Entries: []Entry{
+		{RuleID: "chown-group", Line: 3, Fingerprint: "abcd"},
+	}}
+	results, suppressed := file.Suppress([]analyzer.Result{
+		{RuleID: "chown-group", Line: &analyzer.Line{Start: 7, End: 7}, Fingerprint: "abcd"},
+		{RuleID: "chown-group", Line: &analyzer.Line{Start: 3, End: 3}, Fingerprint: "ef01"},
+	})
+	if suppressed != 1 || len(results) != 1 || results[0].Fingerprint != "ef01" {
+		t.Errorf("Unexpected results %v", results)
+	}
+}
+
+func TestSuppressReportsExpiredWaivers(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	file := &File{Entries: []Entry{
+		{RuleID: "chown-group", Line: 3, Reason: "fixed by the next base image", Author: "jdoe", Expires: &yesterday},
+		{RuleID: "user-root", Reason: "build image", Expires: &tomorrow},
+	}}
+	results, suppressed := file.Suppress([]analyzer.Result{
+		{RuleID: "chown-group", Line: &analyzer.Line{Start: 3, End: 3}, Description: "chown"},
+		{RuleID: "user-root", Description: "root"},
+	})
+	if suppressed != 1 || len(results) != 1 || results[0].RuleID != "chown-group" {
+		t.Fatalf("Expected the finding of the expired waiver to be reported but the results were %v", results)
+	}
+	if !strings.Contains(results[0].Description, "the waiver of jdoe expired on "+yesterday.Local().Format("2006-01-02")) {
+		t.Errorf("Expected the description to tell the waiver expired but it was %s", results[0].Description)
+	}
+	if file.Entries[0].Status(time.Now()) != STATUS_EXPIRED || file.Entries[1].Status(time.Now()) != STATUS_ACTIVE || (Entry{}).Status(time.Now()) != STATUS_PERMANENT {
+		t.Errorf("Unexpected statuses of the entries %v", file.Entries)
+	}
+}
+
+func TestLoadRequiresReason(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DEFAULT_FILE)
+	if err := os.WriteFile(path, []byte(`{"entries": [{"ruleId": "user-root", "author": "jdoe"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for an entry without reason")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	for value, expected := range map[string]time.Time{
+		"2024-06-30": time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local),
+		"30d":        now.Add(30 * 24 * time.Hour),
+		"2w":         now.Add(14 * 24 * time.Hour),
+		"12h":        now.Add(12 * time.Hour),
+	} {
+		expiry, err := ParseExpiry(value, now)
+		if err != nil || !expiry.Equal(expected) {
+			t.Errorf("Expected %s for %s but got %s, error %v", expected, value, expiry, err)
+		}
+	}
+	for _, value := range []string{"soon", "-5h"} {
+		if _, err := ParseExpiry(value, now); err == nil {
+			t.Errorf("Expected an error for %s", value)
+		}
+	}
+}
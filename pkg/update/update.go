@@ -0,0 +1,244 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+)
+
+const DEFAULT_REPOSITORY = "redhat-developer/podman-desktop-image-checker-openshift-ext"
+
+const CHECKSUMS_ASSET = "checksums.txt"
+
+// CHECKSUMS_BUNDLE_ASSET is the cosign sign-blob bundle of the checksums
+const CHECKSUMS_BUNDLE_ASSET = CHECKSUMS_ASSET + ".bundle"
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+var githubAPI = "https://api.github.com"
+
+type Asset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+type Release struct {
+	TagName string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+func (r Release) Version() (semver.Version, error) {
+	return semver.ParseTolerant(r.TagName)
+}
+
+func (r Release) asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// BinaryAssetName returns the name of the release asset containing the doa binary for the
+// current platform, e.g. doa-linux-amd64 or doa-windows-arm64.exe
+func BinaryAssetName() string {
+	name := fmt.Sprintf("doa-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// LatestRelease fetches the latest published release of the given GitHub repository (owner/name).
+func LatestRelease(repository string) (*Release, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s/releases/latest", githubAPI, repository), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query the GitHub releases")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to query the GitHub releases of %s: %s", repository, resp.Status)
+	}
+	release := &Release{}
+	if err := json.NewDecoder(resp.Body).Decode(release); err != nil {
+		return nil, errors.Wrap(err, "unable to read the GitHub release")
+	}
+	return release, nil
+}
+
+// IsNewer reports whether the release is more recent than the current version. Development
+// builds, whose version is not a semantic version, are never considered up to date.
+func IsNewer(release *Release, current string) (bool, error) {
+	latest, err := release.Version()
+	if err != nil {
+		return false, errors.Wrapf(err, "release %s has an invalid version", release.TagName)
+	}
+	currentVersion, err := semver.ParseTolerant(current)
+	if err != nil {
+		return true, nil
+	}
+	return latest.GT(currentVersion), nil
+}
+
+// Apply downloads the binary of the release for the current platform, verifies it against the
+// checksums published with the release and replaces the executable with it. When verifier is not
+// nil, the checksums must have a trusted cosign signature.
+func Apply(release *Release, executable string, verifier *signature.Verifier) error {
+	binary := release.asset(BinaryAssetName())
+	if binary == nil {
+		return errors.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums := release.asset(CHECKSUMS_ASSET)
+	if checksums == nil {
+		return errors.Errorf("release %s has no %s, unable to verify the binary", release.TagName, CHECKSUMS_ASSET)
+	}
+
+	content, err := fetch(checksums.DownloadURL, "the checksums")
+	if err != nil {
+		return err
+	}
+	if verifier != nil {
+		bundle := release.asset(CHECKSUMS_BUNDLE_ASSET)
+		if bundle == nil {
+			return errors.Errorf("release %s has no %s, unable to verify the checksums", release.TagName, CHECKSUMS_BUNDLE_ASSET)
+		}
+		signature, err := fetch(bundle.DownloadURL, "the signature of the checksums")
+		if err != nil {
+			return err
+		}
+		if err := verifier.VerifyBlob(content, signature); err != nil {
+			return errors.Wrapf(err, "unable to verify the checksums of release %s", release.TagName)
+		}
+	}
+	expected, err := findChecksum(content, binary.Name)
+	if err != nil {
+		return err
+	}
+
+	// download next to the executable so that the final rename doesn't cross file systems
+	tmp, err := os.CreateTemp(filepath.Dir(executable), ".doa-update-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to create the temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	actual, err := download(binary.DownloadURL, tmp)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return errors.Errorf("checksum mismatch for %s: expected %s, got %s", binary.Name, expected, actual)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return replace(executable, tmp.Name())
+}
+
+func fetch(url string, description string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download %s", description)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to download %s: %s", description, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download %s", description)
+	}
+	return content, nil
+}
+
+func findChecksum(checksums []byte, assetName string) (string, error) {
+	// sha256sum format: <hex digest>  <file name>
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Errorf("no checksum found for %s", assetName)
+}
+
+func download(url string, out io.Writer) (string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to download the binary")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unable to download the binary: %s", resp.Status)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), resp.Body); err != nil {
+		return "", errors.Wrap(err, "unable to download the binary")
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replace swaps the executable with the new binary. A running executable can't be overwritten on
+// Windows but it can be renamed, so the old binary is moved aside first.
+func replace(executable string, newBinary string) error {
+	old := executable + ".old"
+	os.Remove(old)
+	if err := os.Rename(executable, old); err != nil {
+		return errors.Wrap(err, "unable to replace the executable")
+	}
+	if err := os.Rename(newBinary, executable); err != nil {
+		// restore the previous binary
+		os.Rename(old, executable)
+		return errors.Wrap(err, "unable to replace the executable")
+	}
+	if runtime.GOOS != "windows" {
+		os.Remove(old)
+	}
+	return nil
+}
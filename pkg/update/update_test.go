@@ -0,0 +1,156 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package update
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/signature"
+)
+
+func TestIsNewer(t *testing.T) {
+	release := &Release{TagName: "v1.2.0"}
+	for current, expected := range map[string]bool{"1.1.0": true, "v1.2.0": false, "1.3.0": false, "dev": true} {
+		newer, err := IsNewer(release, current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if newer != expected {
+			t.Errorf("Expected IsNewer to be %t for current version %s", expected, current)
+		}
+	}
+}
+
+func TestApplyReplacesExecutable(t *testing.T) {
+	binary := []byte("new doa binary")
+	server := newReleaseServer(binary, sha256Hex(binary))
+	defer server.Close()
+
+	executable := filepath.Join(t.TempDir(), "doa")
+	if err := os.WriteFile(executable, []byte("old doa binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(releaseOf(server), executable, nil); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(executable)
+	if string(content) != string(binary) {
+		t.Errorf("Expected the executable to be replaced but it was %s", content)
+	}
+}
+
+func TestApplyFailsOnChecksumMismatch(t *testing.T) {
+	server := newReleaseServer([]byte("tampered binary"), sha256Hex([]byte("new doa binary")))
+	defer server.Close()
+
+	executable := filepath.Join(t.TempDir(), "doa")
+	if err := os.WriteFile(executable, []byte("old doa binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(releaseOf(server), executable, nil); err == nil {
+		t.Error("Expected the update to fail on checksum mismatch")
+	}
+	content, _ := os.ReadFile(executable)
+	if string(content) != "old doa binary" {
+		t.Errorf("Expected the executable to be left untouched but it was %s", content)
+	}
+}
+
+func TestApplyRequiresSignatureWhenVerifying(t *testing.T) {
+	binary := []byte("new doa binary")
+	server := newReleaseServer(binary, sha256Hex(binary))
+	defer server.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	verifier, err := signature.NewVerifier(signature.Trust{
+		Keys: []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+	}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	executable := filepath.Join(t.TempDir(), "doa")
+	if err := os.WriteFile(executable, []byte("old doa binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(releaseOf(server), executable, verifier); err == nil {
+		t.Error("Expected the update to fail without the signature of the checksums")
+	}
+
+	checksums := []byte(fmt.Sprintf("%s  %s\n", sha256Hex(binary), BinaryAssetName()))
+	hash := sha256.Sum256(checksums)
+	content, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := releaseOf(server)
+	bundle := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"base64Signature": %q}`, base64.StdEncoding.EncodeToString(content))
+	}))
+	defer bundle.Close()
+	release.Assets = append(release.Assets, Asset{Name: CHECKSUMS_BUNDLE_ASSET, DownloadURL: bundle.URL})
+	if err := Apply(release, executable, verifier); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := os.ReadFile(executable)
+	if string(updated) != string(binary) {
+		t.Errorf("Expected the executable to be replaced but it was %s", updated)
+	}
+}
+
+func newReleaseServer(binary []byte, checksum string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+BinaryAssetName(), func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	mux.HandleFunc("/"+CHECKSUMS_ASSET, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  %s\n", checksum, BinaryAssetName())
+	})
+	return httptest.NewServer(mux)
+}
+
+func releaseOf(server *httptest.Server) *Release {
+	return &Release{
+		TagName: "v9.9.9",
+		Assets: []Asset{
+			{Name: BinaryAssetName(), DownloadURL: server.URL + "/" + BinaryAssetName()},
+			{Name: CHECKSUMS_ASSET, DownloadURL: server.URL + "/" + CHECKSUMS_ASSET},
+		},
+	}
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -25,7 +25,7 @@ const FROM_INSTRUCTION = "FROM "
 const RUN_INSTRUCTION = "RUN "
 const CMD_INSTRUCTION = "CMD "
 const LABEL_INSTRUCTION = "LABEL "
-const MAINTAINER_INSTRUCTION = "MAINAINER "
+const MAINTAINER_INSTRUCTION = "MAINTAINER "
 const EXPOSE_INSTRUCTION = "EXPOSE "
 const ENV_INSTRUCTION = "ENV "
 const ADD_INSTRUCTION = "ADD "
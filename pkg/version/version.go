@@ -0,0 +1,58 @@
+/**********************************************************************
+ * Copyright (C) 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package version
+
+import (
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// The variables below are set at build time, e.g.
+// -ldflags "-X github.com/redhat-developer/docker-openshift-analyzer/pkg/version.Version=x.y.z"
+var (
+	// Version is the released version of doa
+	Version = "dev"
+	// Commit is the git commit doa has been built from
+	Commit = "unknown"
+	// BuildDate is the build time as seconds since the Unix epoch
+	BuildDate = ""
+)
+
+type Info struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	BuildDate      string `json:"buildDate,omitempty"`
+	RulesetVersion string `json:"rulesetVersion"`
+	GoVersion      string `json:"goVersion"`
+	Platform       string `json:"platform"`
+}
+
+func Get(rulesetVersion string) Info {
+	info := Info{
+		Version:        Version,
+		Commit:         Commit,
+		RulesetVersion: rulesetVersion,
+		GoVersion:      runtime.Version(),
+		Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if seconds, err := strconv.ParseInt(BuildDate, 10, 64); err == nil {
+		info.BuildDate = time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+	}
+	return info
+}
@@ -0,0 +1,332 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+
+// Package workspace analyzes all the Containerfiles of a monorepo and reports them per project,
+// with the counts of findings, a score and the owners of each project, for the dashboards of the
+// platform teams. The projects are the subdirectories mapped in the configuration file, see
+// config.Project, or every directory holding a Containerfile otherwise.
+ package workspace
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+// UNASSIGNED_PROJECT gathers the Containerfiles outside the configured projects
+const UNASSIGNED_PROJECT = "unassigned"
+
+// penalties are the points a failed finding removes from the score of its Containerfile
+var penalties = map[analyzer.ResultSeverity]int{
+	analyzer.SeverityCritical: 25,
+	analyzer.SeverityHigh:     10,
+	analyzer.SeverityMedium:   4,
+	analyzer.SeverityLow:      1,
+}
+
+// skippedDirs are never searched for Containerfiles
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+type File struct {
+	// Path is relative to the root of the workspace
+	Path    string            `json:"path"`
+	Score   int               `json:"score"`
+	Results []analyzer.Result `json:"results"`
+}
+
+type Project struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Owners  []string         `json:"owners,omitempty"`
+	Score   int              `json:"score"`
+	Summary analyzer.Summary `json:"summary"`
+	Files   []File           `json:"files"`
+}
+
+type Report struct {
+	Score    int              `json:"score"`
+	Summary  analyzer.Summary `json:"summary"`
+	Projects []Project        `json:"projects"`
+}
+
+// AnalyzeFunc analyzes a Containerfile, e.g. analyzer.AnalyzePath
+type AnalyzeFunc func(ctx context.Context, path string) []analyzer.Result
+
+// Find returns the Containerfiles of the workspace, relative to its root and sorted.
+func Find(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if file != root && skippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isContainerfile(entry.Name()) {
+			relative, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relative))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// isContainerfile matches Containerfile, Dockerfile and their variants, e.g. Dockerfile.dev or
+// api.Containerfile, but not their ignore files, e.g. Containerfile.dockerignore
+func isContainerfile(name string) bool {
+	if strings.HasSuffix(name, ".dockerignore") || strings.HasSuffix(name, ".containerignore") {
+		return false
+	}
+	for _, base := range []string{"Containerfile", "Dockerfile"} {
+		if name == base || strings.HasPrefix(name, base+".") || strings.HasSuffix(name, "."+base) {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze analyzes the Containerfiles of the workspace and groups their results by project. The
+// configuration is applied to the results and its projects used to group them.
+func Analyze(ctx context.Context, root string, cfg *config.Config, analyze AnalyzeFunc) (*Report, error) {
+	files, err := Find(root)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := LoadCodeOwners(root)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := map[string]*Project{}
+	var names []string
+	for _, file := range files {
+		definition := projectOf(file, cfg.Projects)
+		project, ok := projects[definition.Name]
+		if !ok {
+			project = &Project{Name: definition.Name, Path: definition.Path, Owners: definition.Owners}
+			projects[definition.Name] = project
+			names = append(names, definition.Name)
+		}
+		results := cfg.Apply(analyze(ctx, filepath.Join(root, filepath.FromSlash(file))))
+		project.Files = append(project.Files, File{Path: file, Score: Score(results), Results: results})
+		if len(definition.Owners) == 0 {
+			project.Owners = appendMissing(project.Owners, owners.Owners(file)...)
+		}
+	}
+	sort.Strings(names)
+
+	report := &Report{Projects: []Project{}}
+	var all []analyzer.Result
+	var scores []int
+	for _, name := range names {
+		project := projects[name]
+		var results []analyzer.Result
+		var projectScores []int
+		for _, file := range project.Files {
+			results = append(results, file.Results...)
+			projectScores = append(projectScores, file.Score)
+		}
+		project.Summary = analyzer.SummarizeFailingOn(results, cfg.FailOnSeverity())
+		project.Score = Average(projectScores)
+		report.Projects = append(report.Projects, *project)
+		all = append(all, results...)
+		scores = append(scores, projectScores...)
+	}
+	report.Summary = analyzer.SummarizeFailingOn(all, cfg.FailOnSeverity())
+	report.Score = Average(scores)
+	return report, nil
+}
+
+// projectOf returns the configured project holding the file, the deepest one when they are
+// nested. Without configured projects, each directory is a project.
+func projectOf(file string, projects []config.Project) config.Project {
+	dir := path.Dir(file)
+	if len(projects) == 0 {
+		return config.Project{Name: dir, Path: dir}
+	}
+	found := config.Project{Name: UNASSIGNED_PROJECT}
+	depth := -1
+	for _, project := range projects {
+		projectPath := strings.Trim(path.Clean(filepath.ToSlash(project.Path)), "/")
+		projectDepth := len(strings.Split(projectPath, "/"))
+		if projectPath == "." || projectPath == "" {
+			projectPath, projectDepth = ".", 0
+		}
+		matches := projectPath == "." || dir == projectPath || strings.HasPrefix(dir, projectPath+"/")
+		if matches && projectDepth > depth {
+			found, depth = project, projectDepth
+			found.Path = projectPath
+		}
+	}
+	return found
+}
+
+// Score rates the Containerfile from 100, without failed finding, down to 0, each failed finding
+// removing points according to its severity.
+func Score(results []analyzer.Result) int {
+	score := 100
+	for _, result := range results {
+		if result.Status == analyzer.StatusFailed {
+			score -= penalties[result.Severity]
+		}
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Average returns the rounded average of the scores, 100 without score.
+func Average(scores []int) int {
+	if len(scores) == 0 {
+		return 100
+	}
+	total := 0
+	for _, score := range scores {
+		total += score
+	}
+	return (total + len(scores)/2) / len(scores)
+}
+
+func appendMissing(values []string, added ...string) []string {
+	for _, value := range added {
+		found := false
+		for _, existing := range values {
+			found = found || existing == value
+		}
+		if !found {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// CodeOwners are the rules of a CODEOWNERS file, the last matching rule giving the owners of a
+// file.
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern string
+	owners  []string
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of the workspace, looked up in .github, the root and
+// docs like GitHub does. A missing file has no owners.
+func LoadCodeOwners(root string) (*CodeOwners, error) {
+	for _, location := range []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"} {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(location)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ParseCodeOwners(string(content)), nil
+	}
+	return &CodeOwners{}, nil
+}
+
+func ParseCodeOwners(content string) *CodeOwners {
+	owners := &CodeOwners{}
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		owners.rules = append(owners.rules, codeOwnersRule{pattern: fields[0], owners: fields[1:]})
+	}
+	return owners
+}
+
+// Owners returns the owners of the file, whose path is relative to the root of the workspace.
+func (c *CodeOwners) Owners(file string) []string {
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if matchesCodeOwners(c.rules[i].pattern, file) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// matchesCodeOwners implements the gitignore-like patterns of CODEOWNERS: a pattern without a
+// slash matches a file or directory name at any depth, the other ones match from the root, and a
+// pattern matching a directory matches all the files below it.
+func matchesCodeOwners(pattern string, file string) bool {
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+	if pattern == "*" || pattern == "**" {
+		return true
+	}
+	segments := strings.Split(file, "/")
+	if strings.HasPrefix(pattern, "**/") {
+		pattern = strings.TrimPrefix(pattern, "**/")
+		if !strings.Contains(pattern, "/") {
+			return matchesSegment(pattern, segments)
+		}
+		for i := range segments {
+			if matchesPrefix(pattern, segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		return matchesSegment(pattern, segments)
+	}
+	return matchesPrefix(strings.TrimPrefix(pattern, "/"), segments)
+}
+
+func matchesSegment(pattern string, segments []string) bool {
+	for _, segment := range segments {
+		if matched, _ := path.Match(pattern, segment); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPrefix(pattern string, segments []string) bool {
+	for i := 1; i <= len(segments); i++ {
+		if matched, _ := path.Match(pattern, strings.Join(segments[:i], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+/**********************************************************************
+ * Copyright (C) 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ ***********************************************************************/
+ package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	analyzer "github.com/redhat-developer/docker-openshift-analyzer/pkg/command"
+	"github.com/redhat-developer/docker-openshift-analyzer/pkg/config"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/web/Containerfile":              "FROM ubi9",
+		"services/api/Dockerfile.dev":             "FROM ubi9",
+		"services/api/build.Dockerfile":           "FROM ubi9",
+		"services/api/main.go":                    "package main",
+		"node_modules/lib/Dockerfile":             "FROM node",
+		"services/web/Containerfile.dockerignore": "",
+	})
+	files, err := Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"services/api/Dockerfile.dev", "services/api/build.Dockerfile", "services/web/Containerfile"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v but it was %v", expected, files)
+	}
+}
+
+func TestAnalyzeGroupsByProject(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/web/Containerfile":      "web",
+		"services/web/test/Containerfile": "web",
+		"services/api/Containerfile":      "api",
+		"tools/Containerfile":             "tools",
+		".github/CODEOWNERS":              "* @org/platform\n/services/api/ @org/api-team\n",
+	})
+	cfg := &config.Config{Projects: []config.Project{
+		{Name: "web", Path: "services/web", Owners: []string{"@org/web-team"}},
+		{Name: "api", Path: "services/api/"},
+	}}
+	analyze := func(ctx context.Context, path string) []analyzer.Result {
+		content, _ := os.ReadFile(path)
+		if string(content) == "web" {
+			return []analyzer.Result{analyzer.RuleUserRoot.Failed("root"), analyzer.RuleUserRoot.Passed("not root")}
+		}
+		return []analyzer.Result{}
+	}
+	report, err := Analyze(context.Background(), root, cfg, analyze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Projects) != 3 {
+		t.Fatalf("Expected 3 projects but they were %v", report.Projects)
+	}
+	api, unassigned, web := report.Projects[0], report.Projects[1], report.Projects[2]
+	if api.Name != "api" || !reflect.DeepEqual(api.Owners, []string{"@org/api-team"}) || api.Score != 100 {
+		t.Errorf("Unexpected project %+v", api)
+	}
+	if unassigned.Name != UNASSIGNED_PROJECT || !reflect.DeepEqual(unassigned.Owners, []string{"@org/platform"}) {
+		t.Errorf("Unexpected project %+v", unassigned)
+	}
+	expectedScore := 100 - penalties[analyzer.RuleUserRoot.Severity]
+	if web.Name != "web" || len(web.Files) != 2 || web.Summary.Failed != 2 || web.Score != expectedScore || !reflect.DeepEqual(web.Owners, []string{"@org/web-team"}) {
+		t.Errorf("Unexpected project %+v", web)
+	}
+	if report.Summary.Failed != 2 || report.Summary.Verdict != analyzer.VerdictFailed {
+		t.Errorf("Unexpected summary %+v", report.Summary)
+	}
+}
+
+func TestAnalyzeWithoutProjectsGroupsByDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"Containerfile": "", "api/Dockerfile": ""})
+	report, err := Analyze(context.Background(), root, &config.Config{}, func(ctx context.Context, path string) []analyzer.Result {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Projects) != 2 || report.Projects[0].Name != "." || report.Projects[1].Name != "api" {
+		t.Errorf("Unexpected projects %+v", report.Projects)
+	}
+}
+
+func TestCodeOwners(t *testing.T) {
+	owners := ParseCodeOwners(`# comment
+*                   @org/platform
+*.Dockerfile        @org/docker
+/services/          @org/services
+docs/**             @org/docs
+**/legacy/          @org/legacy # inline comment
+`)
+	for file, expected := range map[string][]string{
+		"Containerfile":                     {"@org/platform"},
+		"tools/build.Dockerfile":            {"@org/docker"},
+		"services/web/Containerfile":        {"@org/services"},
+		"docs/examples/Containerfile":       {"@org/docs"},
+		"services/legacy/app/Containerfile": {"@org/legacy"},
+	} {
+		if actual := owners.Owners(file); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected owners %v for %s but they were %v", expected, file, actual)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	results := []analyzer.Result{}
+	for i := 0; i < 5; i++ {
+		results = append(results, analyzer.Result{Status: analyzer.StatusFailed, Severity: analyzer.SeverityCritical})
+	}
+	if score := Score(results); score != 0 {
+		t.Errorf("Expected score 0 but it was %d", score)
+	}
+	if score := Score([]analyzer.Result{{Status: analyzer.StatusPass, Severity: analyzer.SeverityHigh}}); score != 100 {
+		t.Errorf("Expected score 100 but it was %d", score)
+	}
+}
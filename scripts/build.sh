@@ -14,6 +14,7 @@ for os in linux windows darwin; do
             --build-arg PLATFORM_ARG=${arch} \
             --build-arg OS_ARG=${os} \
             --build-arg TARGET_ARG=${target} \
+            --build-arg VERSION_ARG=${TAG} \
             -t ${IMAGE}:${TAG}-${os}-${arch} \
             .
         podman push \